@@ -13,6 +13,11 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/api/global"
+	"go.opentelemetry.io/otel/exporters/stdout"
+	"go.opentelemetry.io/otel/exporters/trace/zipkin"
+	sdkexporttrace "go.opentelemetry.io/otel/sdk/export/trace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
@@ -25,15 +30,18 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 	kuberestmetrics "k8s.io/client-go/tools/metrics"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
 	"github.com/bookingcom/shipper/pkg/chart/repo"
 	"github.com/bookingcom/shipper/pkg/client"
+	shipperclientset "github.com/bookingcom/shipper/pkg/client/clientset/versioned"
 	shipperscheme "github.com/bookingcom/shipper/pkg/client/clientset/versioned/scheme"
 	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
 	"github.com/bookingcom/shipper/pkg/clusterclientstore"
+	shippercontroller "github.com/bookingcom/shipper/pkg/controller"
 	"github.com/bookingcom/shipper/pkg/controller/application"
 	"github.com/bookingcom/shipper/pkg/controller/capacity"
 	"github.com/bookingcom/shipper/pkg/controller/installation"
@@ -72,11 +80,38 @@ var (
 	chartCacheDir       = flag.String("cachedir", filepath.Join(os.TempDir(), "chart-cache"), "location for the local cache of downloaded charts")
 	resync              = flag.Duration("resync", defaultResync, "Informer's cache re-sync in Go's duration format.")
 	restTimeout         = flag.Duration("rest-timeout", defaultRESTTimeout, "Timeout value for management and target REST clients. Does not affect informer watches.")
+	writeQPS            = flag.Float64("write-qps", 0, "Most writes per second the controllers send to the API servers, all of them together. Unlimited when 0.")
+	writeBurst          = flag.Int("write-burst", 10, "Most writes the controllers send at once, all of them together, on top of -write-qps.")
 	webhookCertPath     = flag.String("webhook-cert", "", "Path to the TLS certificate for the webhook controller.")
 	webhookKeyPath      = flag.String("webhook-key", "", "Path to the TLS private key for the webhook controller.")
 	webhookBindAddr     = flag.String("webhook-addr", "0.0.0.0", "Addr to bind the webhook controller.")
 	webhookBindPort     = flag.String("webhook-port", "9443", "Port to bind the webhook controller.")
 	heartbeatPeriod     = flag.Duration("metrics-webhook-heartbeat-period", defaultHeartbeat, "time between two heartbeats of validating webhook")
+	maxPatchSize        = flag.Int("max-patch-size", release.DefaultMaxPatchSize, "Maximum size in bytes of a single patch the release controller will send to the API server.")
+	conditionDedup      = flag.Duration("condition-event-dedup-window", 0, "Suppress identical release condition transition events emitted within this window. Disabled when 0.")
+	minServingPods      = flag.Int("traffic-min-serving-pods", 0, "Minimum number of pods a release still meant to receive traffic keeps serving while traffic is shifted away from it, even if that's more traffic than its weight asks for.")
+	excludeBadNodes     = flag.Bool("traffic-exclude-unhealthy-nodes", false, "Never pick pods on cordoned or NotReady nodes to start receiving traffic.")
+	rejectBadClusters   = flag.Bool("traffic-reject-unknown-clusters", false, "Fail to process TrafficTargets that reference clusters not registered in the management cluster, instead of only warning about them.")
+	preserveNodeSpread  = flag.Bool("traffic-preserve-node-spread", false, "Pick pods to stop receiving traffic from the nodes with the most serving pods first, so the remaining ones stay spread across nodes.")
+	gateStuckThreshold  = flag.Duration("release-gate-stuck-threshold", 0, "Mark releases whose strategy has been denying them advancement for the same reason for longer than this as GateStuck. Disabled when 0.")
+	honorPausedApps     = flag.Bool("release-honor-paused-applications", false, "Freeze all the releases of applications annotated with shipper.io/paused=true until the annotation is removed.")
+	releaseInstanceID   = flag.String("release-instance-id", "", "Label every target object the release controller patches with shipper.io/managed-by set to this value. Disabled when empty.")
+	releasePatchTimeout = flag.Duration("release-patch-timeout", 0, "Give up on any single patch the release controller sends after this long, and retry it later. Disabled when 0.")
+	completionReportURL = flag.String("release-completion-report-url", "", "POST a JSON record of every release that completes its strategy to this URL. Disabled when empty.")
+	releaseDebounce     = flag.Duration("release-enqueue-debounce", 0, "Hold on to releases for this long before reconciling them, so a burst of changes to them and their target objects only causes a single reconcile. Disabled when 0.")
+	releaseShardIndex   = flag.Int("release-shard-index", 0, "Only reconcile releases in the namespaces that hash to this shard, out of -release-shards.")
+	releaseShards       = flag.Int("release-shards", 1, "Number of shards namespaces are split into across release controllers, each of them running with a different -release-shard-index. Every namespace is reconciled when 1.")
+	releaseTraceSize    = flag.Int("release-reconcile-trace-size", 0, "Number of reconciles of every release to keep a summary of in memory and expose on /release-reconcile-traces. Disabled when 0.")
+	releaseTracing      = flag.Bool("release-tracing", false, "Emit OpenTelemetry traces of every release reconcile, and of the patches it sends.")
+	tracingExporter     = flag.String("release-tracing-exporter", "stdout", "Where -release-tracing sends traces: \"stdout\" writes them to standard output, \"zipkin\" POSTs them to -release-tracing-zipkin-url.")
+	tracingZipkinURL    = flag.String("release-tracing-zipkin-url", "http://localhost:9411/api/v2/spans", "Zipkin compatible endpoint -release-tracing-exporter=zipkin sends traces to.")
+	selectionPolicy     = flag.String("traffic-selection-policy", "", "Which pod gets its traffic label toggled next: \"oldest-first\", \"newest-first\" or \"node-spread\". Pods are picked in the order they're listed when empty, unless -traffic-preserve-node-spread is set.")
+	trafficShiftPolicy  = flag.String("traffic-shift-policy", string(traffic.ShiftPolicyRelabel), "How pods are made to start receiving traffic: \"relabel\" patches their traffic label, \"recreate\" deletes them so they come back with their current configuration. Disruptive, use with care.")
+	routeWeightsCRD     = flag.String("traffic-route-weights-resource", "", "Shift traffic by writing release weights into the objects of this custom resource, given as resource.version.group, instead of labeling pods. Disabled when empty.")
+	drainMaxShift       = flag.Uint("traffic-drain-max-shift-per-sync", 10, "Most traffic weight taken away from a release in a cluster annotated with shipper.booking.com/cluster.drain=true every time its traffic target is synced. Drains clusters in one go when 0.")
+	divergenceThreshold = flag.Duration("traffic-divergence-threshold", 0, "Mark traffic targets whose achieved weight has been diverging from the requested one for longer than this with a TrafficDivergence condition. Disabled when 0.")
+	readinessTimeout    = flag.Duration("traffic-readiness-wait-timeout", traffic.DefaultReadinessWaitTimeout, "Longest a cluster holds its traffic weight until the pods already labeled to receive traffic in it are ready, before labeling more of them anyway.")
+	decisionLogSize     = flag.Int("traffic-decision-log-size", 0, "Number of traffic shifting decisions to keep in memory and expose on /traffic-decisions. Disabled when 0.")
 )
 
 type metricsCfg struct {
@@ -86,6 +121,9 @@ type metricsCfg struct {
 	restLatency *shippermetrics.RESTLatencyMetric
 	restResult  *shippermetrics.RESTResultMetric
 	certExpire  *shippermetrics.WebhookMetric
+
+	trafficDecisionLog *traffic.RingBufferDecisionLog
+	releaseTraces      *release.ReconcileTraceLog
 }
 
 type cfg struct {
@@ -108,6 +146,26 @@ type cfg struct {
 	certPath, keyPath string
 	ns                string
 	workers           int
+	maxPatchSize      int
+	conditionDedup    time.Duration
+	gateStuckAfter    time.Duration
+	honorPausedApps   bool
+	instanceID        string
+	patchTimeout      time.Duration
+	completionURL     string
+	enqueueDebounce   time.Duration
+	releaseShard      release.Shard
+	minServingPods    int
+	excludeBadNodes   bool
+	preserveSpread    bool
+	selectionPolicy   traffic.SelectionPolicy
+	rejectBadClusters bool
+	shiftPolicy       traffic.ShiftPolicy
+	routeWeights      *schema.GroupVersionResource
+	drainMaxShift     uint32
+	divergenceAfter   time.Duration
+	readinessWait     time.Duration
+	writeBudget       flowcontrol.RateLimiter
 
 	webhookCertPath, webhookKeyPath  string
 	webhookBindAddr, webhookBindPort string
@@ -122,6 +180,45 @@ func main() {
 	klog.InitFlags(nil)
 	flag.Parse()
 
+	switch traffic.ShiftPolicy(*trafficShiftPolicy) {
+	case traffic.ShiftPolicyRelabel, traffic.ShiftPolicyRecreate:
+	default:
+		klog.Fatalf("Invalid -traffic-shift-policy %q, must be one of %q or %q",
+			*trafficShiftPolicy, traffic.ShiftPolicyRelabel, traffic.ShiftPolicyRecreate)
+	}
+
+	switch traffic.SelectionPolicy(*selectionPolicy) {
+	case traffic.SelectionPolicyInOrder, traffic.SelectionPolicyOldestFirst,
+		traffic.SelectionPolicyNewestFirst, traffic.SelectionPolicyNodeSpread:
+	default:
+		klog.Fatalf("Invalid -traffic-selection-policy %q, must be one of %q, %q or %q",
+			*selectionPolicy, traffic.SelectionPolicyOldestFirst,
+			traffic.SelectionPolicyNewestFirst, traffic.SelectionPolicyNodeSpread)
+	}
+
+	if *tracingExporter != "stdout" && *tracingExporter != "zipkin" {
+		klog.Fatalf("Invalid -release-tracing-exporter %q, must be one of %q or %q",
+			*tracingExporter, "stdout", "zipkin")
+	}
+
+	var routeWeights *schema.GroupVersionResource
+	if *routeWeightsCRD != "" {
+		routeWeights, _ = schema.ParseResourceArg(*routeWeightsCRD)
+		if routeWeights == nil {
+			klog.Fatalf("Invalid -traffic-route-weights-resource %q, must be of the form resource.version.group",
+				*routeWeightsCRD)
+		}
+	}
+
+	if *writeQPS > 0 && *writeBurst < 1 {
+		klog.Fatalf("Invalid -write-burst %d, must be at least 1 when -write-qps is set", *writeBurst)
+	}
+
+	if *releaseShards > 1 && (*releaseShardIndex < 0 || *releaseShardIndex >= *releaseShards) {
+		klog.Fatalf("Invalid -release-shard-index %d, must be between 0 and %d",
+			*releaseShardIndex, *releaseShards-1)
+	}
+
 	baseRestCfg, err := clientcmd.BuildConfigFromFlags(*masterURL, *kubeconfig)
 	if err != nil {
 		klog.Fatal(err)
@@ -154,6 +251,10 @@ func main() {
 
 	enabledControllers := buildEnabledControllers(*enabledControllers, *disabledControllers)
 
+	if *releaseTracing {
+		setupTracing(*tracingExporter, *tracingZipkinURL)
+	}
+
 	secretInformer := corev1informers.New(kubeInformerFactory, *ns, nil).Secrets()
 	store := clusterclientstore.NewStore(
 		func(clusterName string, ua string, config *rest.Config) (kubernetes.Interface, error) {
@@ -198,8 +299,28 @@ func main() {
 		chartVersionResolver: repo.ResolveChartVersionFunc(repoCatalog),
 		chartFetcher:         repo.FetchChartFunc(repoCatalog),
 
-		ns:      *ns,
-		workers: *workers,
+		ns:                *ns,
+		workers:           *workers,
+		maxPatchSize:      *maxPatchSize,
+		conditionDedup:    *conditionDedup,
+		gateStuckAfter:    *gateStuckThreshold,
+		honorPausedApps:   *honorPausedApps,
+		instanceID:        *releaseInstanceID,
+		patchTimeout:      *releasePatchTimeout,
+		completionURL:     *completionReportURL,
+		enqueueDebounce:   *releaseDebounce,
+		releaseShard:      release.Shard{Index: *releaseShardIndex, Count: *releaseShards},
+		minServingPods:    *minServingPods,
+		excludeBadNodes:   *excludeBadNodes,
+		preserveSpread:    *preserveNodeSpread,
+		selectionPolicy:   traffic.SelectionPolicy(*selectionPolicy),
+		rejectBadClusters: *rejectBadClusters,
+		shiftPolicy:       traffic.ShiftPolicy(*trafficShiftPolicy),
+		routeWeights:      routeWeights,
+		drainMaxShift:     uint32(*drainMaxShift),
+		divergenceAfter:   *divergenceThreshold,
+		readinessWait:     *readinessTimeout,
+		writeBudget:       shippercontroller.NewWriteBudget(float32(*writeQPS), *writeBurst),
 
 		webhookCertPath: *webhookCertPath,
 		webhookKeyPath:  *webhookKeyPath,
@@ -218,6 +339,14 @@ func main() {
 		},
 	}
 
+	if *decisionLogSize > 0 {
+		cfg.metrics.trafficDecisionLog = traffic.NewRingBufferDecisionLog(*decisionLogSize)
+	}
+
+	if *releaseTraceSize > 0 {
+		cfg.metrics.releaseTraces = release.NewReconcileTraceLog(*releaseTraceSize)
+	}
+
 	go func() {
 		klog.V(1).Infof("Metrics will listen on %s", *metricsAddr)
 		<-metricsReadyCh
@@ -245,15 +374,26 @@ func runMetrics(cfg *metricsCfg) {
 	prometheus.MustRegister(cfg.certExpire.GetMetrics()...)
 	prometheus.MustRegister(instrumentedclient.GetMetrics()...)
 
+	mux := http.NewServeMux()
+	mux.Handle("/", promhttp.HandlerFor(
+		prometheus.DefaultGatherer,
+		promhttp.HandlerOpts{
+			ErrorHandling: promhttp.ContinueOnError,
+			ErrorLog:      klogStdLogger{},
+		},
+	))
+
+	if cfg.trafficDecisionLog != nil {
+		mux.Handle("/traffic-decisions", cfg.trafficDecisionLog)
+	}
+
+	if cfg.releaseTraces != nil {
+		mux.Handle("/release-reconcile-traces", cfg.releaseTraces)
+	}
+
 	srv := http.Server{
-		Addr: *metricsAddr,
-		Handler: promhttp.HandlerFor(
-			prometheus.DefaultGatherer,
-			promhttp.HandlerOpts{
-				ErrorHandling: promhttp.ContinueOnError,
-				ErrorLog:      klogStdLogger{},
-			},
-		),
+		Addr:    *metricsAddr,
+		Handler: mux,
 	}
 	err := srv.ListenAndServe()
 	if err != nil {
@@ -261,6 +401,31 @@ func runMetrics(cfg *metricsCfg) {
 	}
 }
 
+// setupTracing registers a global tracer provider that sends every span to
+// the exporter named exporterName: standard output, or the Zipkin compatible
+// endpoint at zipkinURL. Without it, the default global provider discards
+// all spans.
+func setupTracing(exporterName, zipkinURL string) {
+	var exporter sdkexporttrace.SpanExporter
+	switch exporterName {
+	case "zipkin":
+		zipkinExporter, err := zipkin.NewRawExporter(zipkinURL, "shipper",
+			zipkin.WithClient(&http.Client{Timeout: *restTimeout}))
+		if err != nil {
+			klog.Fatalf("could not set up tracing: %s", err)
+		}
+		exporter = zipkinExporter
+	default:
+		stdoutExporter, err := stdout.NewExporter()
+		if err != nil {
+			klog.Fatalf("could not set up tracing: %s", err)
+		}
+		exporter = stdoutExporter
+	}
+
+	global.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter)))
+}
+
 func buildEnabledControllers(enabledControllers, disabledControllers string) map[string]bool {
 	willRun := map[string]bool{}
 	for _, controller := range controllers {
@@ -394,11 +559,37 @@ func startReleaseController(cfg *cfg) (bool, error) {
 		return false, nil
 	}
 
+	var completionReporter release.CompletionReporter = release.NoopCompletionReporter{}
+	if cfg.completionURL != "" {
+		completionReporter = release.NewHTTPCompletionReporter(cfg.completionURL, *cfg.restTimeout)
+	}
+
+	// Patches can't be cancelled once they're sent, so the ones the
+	// release controller gives up on are timed out by their client.
+	var patchClientset shipperclientset.Interface
+	if cfg.patchTimeout > 0 {
+		patchClientset = client.NewShipperClientOrDie(cfg.restCfg, release.AgentName, &cfg.patchTimeout)
+	}
+
 	c := release.NewController(
 		client.NewShipperClientOrDie(cfg.restCfg, release.AgentName, cfg.restTimeout),
 		cfg.shipperInformerFactory,
 		cfg.chartFetcher,
 		cfg.recorder(release.AgentName),
+		release.Config{
+			MaxPatchSize:              cfg.maxPatchSize,
+			ConditionEventDedupWindow: cfg.conditionDedup,
+			GateStuckThreshold:        cfg.gateStuckAfter,
+			HonorPausedApplications:   cfg.honorPausedApps,
+			InstanceID:                cfg.instanceID,
+			PatchTimeout:              cfg.patchTimeout,
+			PatchClientset:            patchClientset,
+			CompletionReporter:        completionReporter,
+			EnqueueDebounce:           cfg.enqueueDebounce,
+			Shard:                     cfg.releaseShard,
+			WriteBudget:               cfg.writeBudget,
+			ReconcileTraces:           cfg.metrics.releaseTraces,
+		},
 	)
 
 	cfg.wg.Add(1)
@@ -438,6 +629,7 @@ func startInstallationController(cfg *cfg) (bool, error) {
 		dynamicClientBuilderFunc,
 		cfg.chartFetcher,
 		cfg.recorder(installation.AgentName),
+		cfg.writeBudget,
 	)
 
 	cfg.wg.Add(1)
@@ -460,6 +652,7 @@ func startCapacityController(cfg *cfg) (bool, error) {
 		cfg.shipperInformerFactory,
 		cfg.store,
 		cfg.recorder(capacity.AgentName),
+		cfg.writeBudget,
 	)
 	cfg.wg.Add(1)
 	go func() {
@@ -475,11 +668,39 @@ func startTrafficController(cfg *cfg) (bool, error) {
 		return false, nil
 	}
 
+	var decisionLog traffic.DecisionLog
+	if cfg.metrics.trafficDecisionLog != nil {
+		decisionLog = cfg.metrics.trafficDecisionLog
+	}
+
+	var trafficShifter traffic.TrafficShifter
+	if cfg.routeWeights != nil {
+		trafficShifter = traffic.NewRouteWeightsShifter(
+			traffic.CachedDynamicClientFunc(cfg.store.GetConfig),
+			*cfg.routeWeights,
+			cfg.writeBudget,
+		)
+	}
+
 	c := traffic.NewController(
 		client.NewShipperClientOrDie(cfg.restCfg, traffic.AgentName, cfg.restTimeout),
 		cfg.shipperInformerFactory,
 		cfg.store,
 		cfg.recorder(traffic.AgentName),
+		traffic.Config{
+			DecisionLog:           decisionLog,
+			MinServingPods:        cfg.minServingPods,
+			ExcludeUnhealthyNodes: cfg.excludeBadNodes,
+			PreserveNodeSpread:    cfg.preserveSpread,
+			SelectionPolicy:       cfg.selectionPolicy,
+			RejectUnknownClusters: cfg.rejectBadClusters,
+			ShiftPolicy:           cfg.shiftPolicy,
+			TrafficShifter:        trafficShifter,
+			MaxShiftPerSync:       cfg.drainMaxShift,
+			DivergenceThreshold:   cfg.divergenceAfter,
+			ReadinessWaitTimeout:  cfg.readinessWait,
+			WriteBudget:           cfg.writeBudget,
+		},
 	)
 
 	cfg.wg.Add(1)
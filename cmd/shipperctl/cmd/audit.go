@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/bookingcom/shipper/cmd/shipperctl/configurator"
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/controller/traffic"
+)
+
+var auditReleaseCmd = &cobra.Command{
+	Use:   "audit <release>",
+	Short: "compare the pods getting traffic with the traffic weights on the clusters of a release",
+	Long: "counting, for every release of the same application on each cluster the " +
+		"release is on, the pods labeled to receive traffic and the pods that should " +
+		"be according to their weights. clusters are reached through the context " +
+		"with the same name in the kubernetes configuration file.",
+	Args: cobra.ExactArgs(1),
+	RunE: runAuditReleaseCommand,
+}
+
+func init() {
+	ReleaseCmd.AddCommand(auditReleaseCmd)
+}
+
+func runAuditReleaseCommand(cmd *cobra.Command, args []string) error {
+	shipperClient, err := configurator.NewShipperClientFromKubeConfig(kubeConfigFile, managementClusterContext)
+	if err != nil {
+		return err
+	}
+
+	relName := args[0]
+	rel, err := shipperClient.ShipperV1alpha1().Releases(releaseNamespace).Get(relName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get release: %s", err.Error())
+	}
+
+	appName, ok := rel.Labels[shipper.AppLabel]
+	if !ok {
+		return fmt.Errorf("release %s/%s has no %q label", rel.Namespace, rel.Name, shipper.AppLabel)
+	}
+
+	selector := labels.Set{shipper.AppLabel: appName}.AsSelector().String()
+
+	tts, err := shipperClient.ShipperV1alpha1().TrafficTargets(rel.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list traffic targets: %s", err.Error())
+	}
+
+	weightsByCluster := make(map[string]map[string]uint32)
+	var clusters []string
+	for _, tt := range tts.Items {
+		releaseName := tt.Labels[shipper.ReleaseLabel]
+		for _, spec := range tt.Spec.Clusters {
+			if releaseName == rel.Name {
+				clusters = append(clusters, spec.Name)
+			}
+
+			if _, ok := weightsByCluster[spec.Name]; !ok {
+				weightsByCluster[spec.Name] = make(map[string]uint32)
+			}
+			weightsByCluster[spec.Name][releaseName] += spec.Weight
+		}
+	}
+	sort.Strings(clusters)
+
+	if len(clusters) == 0 {
+		cmd.Printf("release %s/%s has no traffic target clusters\n", rel.Namespace, rel.Name)
+		return nil
+	}
+
+	var unbalanced bool
+	for _, cluster := range clusters {
+		kubeClient, err := configurator.NewKubeClientFromKubeConfig(kubeConfigFile, cluster)
+		if err != nil {
+			return fmt.Errorf("failed to build a client for cluster %q: %s", cluster, err.Error())
+		}
+
+		podList, err := kubeClient.CoreV1().Pods(rel.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return fmt.Errorf("failed to list pods in cluster %q: %s", cluster, err.Error())
+		}
+
+		pods := make([]*corev1.Pod, 0, len(podList.Items))
+		for i := range podList.Items {
+			pods = append(pods, &podList.Items[i])
+		}
+
+		report := traffic.AuditTrafficLabels(pods, weightsByCluster[cluster], len(pods))
+		for _, audit := range report.Releases {
+			cmd.Printf("cluster %q: release %q has %d out of %d pods getting traffic, expected %d\n",
+				cluster, audit.Release, audit.Serving, audit.Pods, audit.Expected)
+		}
+
+		if !report.Balanced() {
+			unbalanced = true
+		}
+	}
+
+	if unbalanced {
+		return fmt.Errorf("traffic labels of application %s/%s don't match its weights", rel.Namespace, appName)
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/bookingcom/shipper/cmd/shipperctl/configurator"
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/controller/traffic"
+)
+
+var blastRadiusReleaseCmd = &cobra.Command{
+	Use:   "blast-radius <release>",
+	Short: "show how much of the traffic of its application a release touches",
+	Long: "summarizing the clusters a release gets traffic in, its combined weight " +
+		"in them, and the fraction of the traffic of its application across the " +
+		"fleet it gets, so it can be assessed before approving a step.",
+	Args: cobra.ExactArgs(1),
+	RunE: runBlastRadiusReleaseCommand,
+}
+
+func init() {
+	ReleaseCmd.AddCommand(blastRadiusReleaseCmd)
+}
+
+func runBlastRadiusReleaseCommand(cmd *cobra.Command, args []string) error {
+	shipperClient, err := configurator.NewShipperClientFromKubeConfig(kubeConfigFile, managementClusterContext)
+	if err != nil {
+		return err
+	}
+
+	relName := args[0]
+	rel, err := shipperClient.ShipperV1alpha1().Releases(releaseNamespace).Get(relName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get release: %s", err.Error())
+	}
+
+	appName, ok := rel.Labels[shipper.AppLabel]
+	if !ok {
+		return fmt.Errorf("release %s/%s has no %q label", rel.Namespace, rel.Name, shipper.AppLabel)
+	}
+
+	selector := labels.Set{shipper.AppLabel: appName}.AsSelector().String()
+
+	tts, err := shipperClient.ShipperV1alpha1().TrafficTargets(rel.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list traffic targets: %s", err.Error())
+	}
+
+	var releaseTT *shipper.TrafficTarget
+	appTTs := make([]*shipper.TrafficTarget, 0, len(tts.Items))
+	for i := range tts.Items {
+		tt := &tts.Items[i]
+		if tt.Labels[shipper.ReleaseLabel] == rel.Name {
+			releaseTT = tt
+		}
+		appTTs = append(appTTs, tt)
+	}
+
+	if releaseTT == nil {
+		cmd.Printf("release %s/%s has no traffic target\n", rel.Namespace, rel.Name)
+		return nil
+	}
+
+	clusters, weight, fraction, err := traffic.BlastRadius(releaseTT, appTTs...)
+	if err != nil {
+		return fmt.Errorf("failed to compute blast radius: %s", err.Error())
+	}
+
+	cmd.Printf("release %s/%s gets traffic in %d clusters, with a combined weight of %d, for %.1f%% of the traffic of %s\n",
+		rel.Namespace, rel.Name, clusters, weight, fraction*100, appName)
+
+	return nil
+}
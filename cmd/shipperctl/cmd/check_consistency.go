@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bookingcom/shipper/cmd/shipperctl/configurator"
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+var checkConsistencyReleaseCmd = &cobra.Command{
+	Use:   "check-consistency",
+	Short: "find releases whose conditions contradict their phase across all namespaces",
+	Long: "listing every release whose conditions say something different than its " +
+		"strategy status does, such as being complete without being scheduled, " +
+		"or whose target objects don't all reference the same clusters, which " +
+		"points at corrupted objects or bugs.",
+	Args: cobra.NoArgs,
+	RunE: runCheckConsistencyReleaseCommand,
+}
+
+func init() {
+	ReleaseCmd.AddCommand(checkConsistencyReleaseCmd)
+}
+
+func runCheckConsistencyReleaseCommand(cmd *cobra.Command, args []string) error {
+	shipperClient, err := configurator.NewShipperClientFromKubeConfig(kubeConfigFile, managementClusterContext)
+	if err != nil {
+		return err
+	}
+
+	releaseList, err := shipperClient.ShipperV1alpha1().Releases(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list releases: %s", err.Error())
+	}
+
+	itList, err := shipperClient.ShipperV1alpha1().InstallationTargets(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list installation targets: %s", err.Error())
+	}
+	its := make(map[string]*shipper.InstallationTarget, len(itList.Items))
+	for i := range itList.Items {
+		it := &itList.Items[i]
+		its[it.Namespace+"/"+it.Name] = it
+	}
+
+	ttList, err := shipperClient.ShipperV1alpha1().TrafficTargets(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list traffic targets: %s", err.Error())
+	}
+	tts := make(map[string]*shipper.TrafficTarget, len(ttList.Items))
+	for i := range ttList.Items {
+		tt := &ttList.Items[i]
+		tts[tt.Namespace+"/"+tt.Name] = tt
+	}
+
+	ctList, err := shipperClient.ShipperV1alpha1().CapacityTargets(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list capacity targets: %s", err.Error())
+	}
+	cts := make(map[string]*shipper.CapacityTarget, len(ctList.Items))
+	for i := range ctList.Items {
+		ct := &ctList.Items[i]
+		cts[ct.Namespace+"/"+ct.Name] = ct
+	}
+
+	inconsistent := 0
+	for i := range releaseList.Items {
+		rel := &releaseList.Items[i]
+		mismatches := releaseutil.DetectPhaseConditionMismatch(rel)
+
+		// Releases that haven't been scheduled yet have no target
+		// objects to compare.
+		key := rel.Namespace + "/" + rel.Name
+		it, tt, ct := its[key], tts[key], cts[key]
+		if it != nil || tt != nil || ct != nil {
+			if partial := partialClusters(it, tt, ct); len(partial) > 0 {
+				mismatches = append(mismatches,
+					fmt.Sprintf("clusters %v are not in all of its target objects", partial))
+			}
+		}
+
+		if len(mismatches) == 0 {
+			continue
+		}
+
+		inconsistent++
+		for _, mismatch := range mismatches {
+			cmd.Printf("release %s/%s: %s\n", rel.Namespace, rel.Name, mismatch)
+		}
+	}
+
+	if inconsistent > 0 {
+		return fmt.Errorf("%d releases are inconsistent", inconsistent)
+	}
+
+	cmd.Println("all releases are consistent")
+
+	return nil
+}
+
+// partialClusters returns the clusters some of the target objects of a
+// release reference, but not all of them do.
+func partialClusters(it *shipper.InstallationTarget, tt *shipper.TrafficTarget, ct *shipper.CapacityTarget) []string {
+	common := make(map[string]bool)
+	for _, cluster := range releaseutil.CommonClusters(it, tt, ct) {
+		common[cluster] = true
+	}
+
+	var partial []string
+	for _, cluster := range releaseutil.EffectiveClusters(it, tt, ct) {
+		if !common[cluster] {
+			partial = append(partial, cluster)
+		}
+	}
+
+	return partial
+}
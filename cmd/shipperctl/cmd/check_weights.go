@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/bookingcom/shipper/cmd/shipperctl/configurator"
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/controller/traffic"
+)
+
+var checkWeightsReleaseCmd = &cobra.Command{
+	Use:   "check-weights <release>",
+	Short: "check whether the traffic weights on the clusters of a release can be honoured",
+	Long: "comparing the traffic weights of every release of the same application " +
+		"with the number of pods they have on each cluster the release is on, " +
+		"warning about weights the traffic controller can't represent.",
+	Args: cobra.ExactArgs(1),
+	RunE: runCheckWeightsReleaseCommand,
+}
+
+func init() {
+	ReleaseCmd.AddCommand(checkWeightsReleaseCmd)
+}
+
+func runCheckWeightsReleaseCommand(cmd *cobra.Command, args []string) error {
+	shipperClient, err := configurator.NewShipperClientFromKubeConfig(kubeConfigFile, managementClusterContext)
+	if err != nil {
+		return err
+	}
+
+	relName := args[0]
+	rel, err := shipperClient.ShipperV1alpha1().Releases(releaseNamespace).Get(relName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get release: %s", err.Error())
+	}
+
+	appName, ok := rel.Labels[shipper.AppLabel]
+	if !ok {
+		return fmt.Errorf("release %s/%s has no %q label", rel.Namespace, rel.Name, shipper.AppLabel)
+	}
+
+	selector := labels.Set{shipper.AppLabel: appName}.AsSelector().String()
+
+	tts, err := shipperClient.ShipperV1alpha1().TrafficTargets(rel.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list traffic targets: %s", err.Error())
+	}
+
+	cts, err := shipperClient.ShipperV1alpha1().CapacityTargets(rel.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list capacity targets: %s", err.Error())
+	}
+
+	weightsByCluster := make(map[string]map[string]uint32)
+	var clusters []string
+	for _, tt := range tts.Items {
+		releaseName := tt.Labels[shipper.ReleaseLabel]
+		for _, spec := range tt.Spec.Clusters {
+			if releaseName == rel.Name {
+				clusters = append(clusters, spec.Name)
+			}
+
+			if _, ok := weightsByCluster[spec.Name]; !ok {
+				weightsByCluster[spec.Name] = make(map[string]uint32)
+			}
+			weightsByCluster[spec.Name][releaseName] += spec.Weight
+		}
+	}
+
+	// The traffic controller splits weights across the pods that are
+	// actually there, not the ones the capacity targets ask for.
+	podsByCluster := make(map[string]int)
+	for _, ct := range cts.Items {
+		for _, status := range ct.Status.Clusters {
+			podsByCluster[status.Name] += int(status.AvailableReplicas)
+		}
+	}
+
+	if len(clusters) == 0 {
+		cmd.Printf("release %s/%s has no traffic target clusters\n", rel.Namespace, rel.Name)
+		return nil
+	}
+
+	var invalid bool
+	for _, cluster := range clusters {
+		errs := traffic.ValidateWeightsAgainstFleet(weightsByCluster[cluster], podsByCluster[cluster])
+		if len(errs) == 0 {
+			cmd.Printf("cluster %q: weights can be honoured by its %d pods\n", cluster, podsByCluster[cluster])
+			continue
+		}
+
+		invalid = true
+		for _, err := range errs {
+			cmd.Printf("warning: cluster %q: %s\n", cluster, err)
+		}
+	}
+
+	if invalid {
+		return fmt.Errorf("some weights for release %s/%s can't be honoured", rel.Namespace, rel.Name)
+	}
+
+	return nil
+}
@@ -34,6 +34,7 @@ var (
 	applicationClusterServiceAccount string
 
 	webhookFailurePolicyIgnore bool
+	podTrafficWebhookURL       string
 
 	setupCmd = &cobra.Command{
 		Use:   "setup",
@@ -88,6 +89,7 @@ func init() {
 	setupMgmtCmd.Flags().BoolVar(&webhookFailurePolicyIgnore, "webhook-ignore", false, "set shippers validating webhook failure policy to Ignore")
 
 	joinCmd.Flags().StringVar(&applicationClusterServiceAccount, "application-cluster-service-account", shipper.ShipperApplicationServiceAccount, "the name of the service account Shipper will use for the application cluster")
+	joinCmd.Flags().StringVar(&podTrafficWebhookURL, "pod-traffic-webhook-url", "", "the URL application clusters reach Shipper's webhook at, serving its certificate. when set, pods get their traffic label as they're created instead of waiting for the traffic controller")
 
 	joinCmd.Flags().StringVarP(&clustersYaml, fileFlagName, "f", "clusters.yaml", "the path to an YAML file containing application cluster configuration")
 	err := joinCmd.MarkFlagFilename(fileFlagName, "yaml")
@@ -245,6 +247,13 @@ func joinClusters(
 		return err
 	}
 
+	if podTrafficWebhookURL != "" {
+		err = createPodTrafficLabelWebhookConfiguration(cmd, mgmtConfigurator, appConfigurator, cluster.Name)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -418,6 +427,28 @@ func createValidatingWebhookConfiguration(cmd *cobra.Command, configurator *conf
 	return nil
 }
 
+// createPodTrafficLabelWebhookConfiguration registers the pod traffic
+// label webhook in an application cluster. Its certificate is signed by the
+// management cluster, so that's where the CA bundle comes from.
+func createPodTrafficLabelWebhookConfiguration(
+	cmd *cobra.Command,
+	mgmtConfigurator, appConfigurator *configurator.Cluster,
+	clusterName string,
+) error {
+	cmd.Printf("Creating the MutatingWebhookConfiguration for pod traffic labels in cluster %s... ", clusterName)
+	caBundle, err := mgmtConfigurator.FetchKubernetesCABundle()
+	if err != nil {
+		return err
+	}
+
+	if err := appConfigurator.CreateOrUpdatePodTrafficLabelWebhookConfiguration(caBundle, podTrafficWebhookURL, clusterName); err != nil {
+		return err
+	}
+	cmd.Println("done")
+
+	return nil
+}
+
 func updateFailurePolicyForValidatingWebhook(cmd *cobra.Command, configurator *configurator.Cluster) error {
 	cmd.Printf("Updating the failure policy of the ValidatingWebhookConfiguration in %s namespace... ", shipperNamespace)
 	if err := configurator.UpdateValidatingWebhookConfigurationFailurePolicyToFail(); err != nil {
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/bookingcom/shipper/cmd/shipperctl/configurator"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	"github.com/bookingcom/shipper/pkg/controller/release"
+)
+
+var honorPausedApplications bool
+
+var explainReleaseCmd = &cobra.Command{
+	Use:   "explain <release>",
+	Short: "show what the release controller would do next for a release",
+	Long: "working out, without changing anything, the step a release is after, " +
+		"the action the release controller would take for it on its next " +
+		"reconcile and the strategy conditions holding it back.",
+	Args: cobra.ExactArgs(1),
+	RunE: runExplainReleaseCommand,
+}
+
+func init() {
+	explainReleaseCmd.Flags().BoolVar(&honorPausedApplications, "honor-paused-applications", false,
+		"whether the release controller being explained freezes the releases of paused applications")
+
+	ReleaseCmd.AddCommand(explainReleaseCmd)
+}
+
+func runExplainReleaseCommand(cmd *cobra.Command, args []string) error {
+	shipperClient, err := configurator.NewShipperClientFromKubeConfig(kubeConfigFile, managementClusterContext)
+	if err != nil {
+		return err
+	}
+
+	informerFactory := shipperinformers.NewSharedInformerFactoryWithOptions(
+		shipperClient, 0, shipperinformers.WithNamespace(releaseNamespace))
+
+	// Explaining a release neither fetches charts, records events,
+	// reports completions nor keeps traces, so none of them need anything
+	// real.
+	c := release.NewController(
+		shipperClient,
+		informerFactory,
+		nil,
+		&record.FakeRecorder{},
+		release.Config{
+			HonorPausedApplications: honorPausedApplications,
+		},
+	)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	informerFactory.Start(stopCh)
+	informerFactory.WaitForCacheSync(stopCh)
+
+	explanation, err := c.ExplainReconcile(releaseNamespace, args[0])
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("release %s is after step [%d] %q\n",
+		explanation.Release, explanation.TargetStep, explanation.TargetStepName)
+	if explanation.AchievedStep != nil {
+		cmd.Printf("achieved step: [%d] %q\n", explanation.AchievedStep.Step, explanation.AchievedStep.Name)
+	} else {
+		cmd.Printf("achieved step: none\n")
+	}
+	cmd.Printf("action: %s (%s)\n", explanation.Action, explanation.Reason)
+
+	for _, patch := range explanation.Patches {
+		cmd.Printf("  patch %s\n", patch)
+	}
+
+	for _, cond := range explanation.BlockingConditions {
+		cmd.Printf("  blocked by %s\n", cond)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bookingcom/shipper/cmd/shipperctl/configurator"
+	"github.com/bookingcom/shipper/cmd/shipperctl/release"
+)
+
+var kickReleaseCmd = &cobra.Command{
+	Use:   "kick <release>",
+	Short: "make Shipper reconcile a release right away",
+	Long: "touching a release, without changing anything about what it does, " +
+		"so the release controller picks it up and reconciles it immediately.",
+	Args: cobra.ExactArgs(1),
+	RunE: runKickReleaseCommand,
+}
+
+func init() {
+	ReleaseCmd.AddCommand(kickReleaseCmd)
+}
+
+func runKickReleaseCommand(cmd *cobra.Command, args []string) error {
+	shipperClient, err := configurator.NewShipperClientFromKubeConfig(kubeConfigFile, managementClusterContext)
+	if err != nil {
+		return err
+	}
+
+	relName := args[0]
+	if _, err := release.KickRelease(shipperClient, releaseNamespace, relName, time.Now()); err != nil {
+		return fmt.Errorf("failed to kick release: %s", err.Error())
+	}
+
+	cmd.Printf("release %s/%s kicked\n", releaseNamespace, relName)
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bookingcom/shipper/cmd/shipperctl/configurator"
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+var mostStalledReleaseCmd = &cobra.Command{
+	Use:   "most-stalled",
+	Short: "show the rollout that has been stuck the longest across all namespaces",
+	Long: "finding the release that is still progressing and has gone the longest " +
+		"without any of its conditions changing, as a starting point for triage.",
+	Args: cobra.NoArgs,
+	RunE: runMostStalledReleaseCommand,
+}
+
+func init() {
+	ReleaseCmd.AddCommand(mostStalledReleaseCmd)
+}
+
+func runMostStalledReleaseCommand(cmd *cobra.Command, args []string) error {
+	shipperClient, err := configurator.NewShipperClientFromKubeConfig(kubeConfigFile, managementClusterContext)
+	if err != nil {
+		return err
+	}
+
+	releaseList, err := shipperClient.ShipperV1alpha1().Releases(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list releases: %s", err.Error())
+	}
+
+	rels := make([]*shipper.Release, 0, len(releaseList.Items))
+	for i := range releaseList.Items {
+		rels = append(rels, &releaseList.Items[i])
+	}
+
+	rel, stalledFor := releaseutil.MostStalledRelease(rels)
+	if rel == nil {
+		cmd.Println("no releases are progressing")
+		return nil
+	}
+
+	cmd.Printf("release %s/%s has been stalled for %s\n", rel.Namespace, rel.Name, stalledFor.Round(time.Second))
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/bookingcom/shipper/cmd/shipperctl/configurator"
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/controller/traffic"
+)
+
+var preflightReleaseCmd = &cobra.Command{
+	Use:   "preflight <release>",
+	Short: "check whether traffic can be shifted for a release on all of its clusters",
+	Long: "checking every cluster a release gets traffic in has the production " +
+		"service traffic is shifted through. clusters are reached through the " +
+		"context with the same name in the kubernetes configuration file.",
+	Args: cobra.ExactArgs(1),
+	RunE: runPreflightReleaseCommand,
+}
+
+func init() {
+	ReleaseCmd.AddCommand(preflightReleaseCmd)
+}
+
+func runPreflightReleaseCommand(cmd *cobra.Command, args []string) error {
+	shipperClient, err := configurator.NewShipperClientFromKubeConfig(kubeConfigFile, managementClusterContext)
+	if err != nil {
+		return err
+	}
+
+	relName := args[0]
+	tt, err := shipperClient.ShipperV1alpha1().TrafficTargets(releaseNamespace).Get(relName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get traffic target: %s", err.Error())
+	}
+
+	// Applications pick the selector of their production services, but
+	// the release may outlive its application, so it's fine if it's gone.
+	var app *shipper.Application
+	if appName, ok := tt.Labels[shipper.AppLabel]; ok {
+		app, err = shipperClient.ShipperV1alpha1().Applications(releaseNamespace).Get(appName, metav1.GetOptions{})
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to get application: %s", err.Error())
+			}
+			app = nil
+		}
+	}
+
+	clusters := make([]string, 0, len(tt.Spec.Clusters))
+	clients := make(map[string]kubernetes.Interface)
+	for _, spec := range tt.Spec.Clusters {
+		clusters = append(clusters, spec.Name)
+
+		client, err := configurator.NewKubeClientFromKubeConfig(kubeConfigFile, spec.Name)
+		if err != nil {
+			cmd.Printf("warning: cluster %q: failed to build a client: %s\n", spec.Name, err)
+			continue
+		}
+		clients[spec.Name] = client
+	}
+	sort.Strings(clusters)
+
+	problems := traffic.ValidateProductionServices(tt, app, clients)
+	for _, cluster := range clusters {
+		if err, ok := problems[cluster]; ok {
+			cmd.Printf("cluster %q: %s\n", cluster, err)
+			continue
+		}
+
+		cmd.Printf("cluster %q: production service found\n", cluster)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("traffic can't be shifted for release %s/%s in %d clusters", tt.Namespace, tt.Name, len(problems))
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bookingcom/shipper/cmd/shipperctl/configurator"
+	"github.com/bookingcom/shipper/cmd/shipperctl/release"
+	"github.com/bookingcom/shipper/cmd/shipperctl/ui"
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperclientset "github.com/bookingcom/shipper/pkg/client/clientset/versioned"
+)
+
+const (
+	removeClustersFlagName = "remove"
+	addClustersFlagName    = "add"
+)
+
+var (
+	releaseNamespace string
+	clustersToRemove []string
+	clustersToAdd    []string
+
+	ReleaseCmd = &cobra.Command{
+		Use:   "release",
+		Short: "manage Shipper releases",
+	}
+
+	rescheduleReleaseCmd = &cobra.Command{
+		Use:   "reschedule <release>",
+		Short: "move a Shipper release to a different set of clusters",
+		Long: "updating the clusters a release is scheduled on, together with the " +
+			"clusters listed in its installation, capacity and traffic targets.",
+		Args: cobra.ExactArgs(1),
+		RunE: runRescheduleReleaseCommand,
+	}
+)
+
+func init() {
+	ReleaseCmd.PersistentFlags().StringVar(&kubeConfigFile, kubeConfigFlagName, "~/.kube/config", "The path to the Kubernetes configuration file")
+	if err := ReleaseCmd.MarkPersistentFlagFilename(kubeConfigFlagName, "yaml"); err != nil {
+		ReleaseCmd.Printf("warning: could not mark %q for filename autocompletion: %s\n", kubeConfigFlagName, err)
+	}
+	ReleaseCmd.PersistentFlags().StringVar(&managementClusterContext, "management-cluster-context", "", "The name of the context to use to communicate with the management cluster. defaults to the current one")
+	ReleaseCmd.PersistentFlags().StringVarP(&releaseNamespace, "namespace", "n", "default", "The namespace of the release")
+
+	rescheduleReleaseCmd.Flags().StringSliceVar(&clustersToRemove, removeClustersFlagName, clustersToRemove, "List of clusters to remove the release from")
+	rescheduleReleaseCmd.Flags().StringSliceVar(&clustersToAdd, addClustersFlagName, clustersToAdd, "List of clusters to add the release to")
+	rescheduleReleaseCmd.Flags().BoolVar(&dryrun, "dry-run", false, "If true, only prints the changes that would be made")
+
+	ReleaseCmd.AddCommand(rescheduleReleaseCmd)
+}
+
+func runRescheduleReleaseCommand(cmd *cobra.Command, args []string) error {
+	if len(clustersToRemove) == 0 && len(clustersToAdd) == 0 {
+		return fmt.Errorf("at least one of --%s or --%s is required", removeClustersFlagName, addClustersFlagName)
+	}
+
+	shipperClient, err := configurator.NewShipperClientFromKubeConfig(kubeConfigFile, managementClusterContext)
+	if err != nil {
+		return err
+	}
+
+	relName := args[0]
+	rel, err := shipperClient.ShipperV1alpha1().Releases(releaseNamespace).Get(relName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get release: %s", err.Error())
+	}
+
+	it, tt, ct, err := release.TargetObjectsForRelease(rel.Name, rel.Namespace, shipperClient)
+	if err != nil {
+		return err
+	}
+
+	oldClusters := release.ScheduledClusters(rel)
+	newClusters := release.RescheduleClusters(oldClusters, clustersToRemove, clustersToAdd)
+	if len(newClusters) == 0 {
+		return fmt.Errorf("refusing to leave release %s/%s without any clusters", rel.Namespace, rel.Name)
+	}
+
+	for _, cluster := range release.ClustersCarryingTraffic(tt, clustersToRemove) {
+		cmd.Printf("warning: cluster %q currently carries traffic for release %s/%s\n", cluster, rel.Namespace, rel.Name)
+	}
+
+	cmd.Printf(
+		"Rescheduling release %s/%s from %s to %s\n",
+		rel.Namespace,
+		rel.Name,
+		strings.Join(oldClusters, ","),
+		strings.Join(newClusters, ","),
+	)
+
+	if dryrun {
+		return nil
+	}
+
+	confirm, err := ui.AskForConfirmation(os.Stdin, "Are you sure?")
+	if err != nil {
+		return err
+	}
+	if !confirm {
+		return nil
+	}
+
+	return rescheduleRelease(cmd, shipperClient, rel, it, tt, ct, newClusters)
+}
+
+// rescheduleRelease updates the release and its target objects one by one.
+// The API doesn't let us do this atomically, so it keeps going after a
+// failure and reports everything that could not be updated at the end.
+func rescheduleRelease(
+	cmd *cobra.Command,
+	shipperClient shipperclientset.Interface,
+	rel *shipper.Release,
+	it *shipper.InstallationTarget,
+	tt *shipper.TrafficTarget,
+	ct *shipper.CapacityTarget,
+	clusters []string,
+) error {
+	var errList []string
+
+	cmd.Printf("Updating release %s/%s ...", rel.Namespace, rel.Name)
+	rel.Annotations[shipper.ReleaseClustersAnnotation] = strings.Join(clusters, ",")
+	if _, err := shipperClient.ShipperV1alpha1().Releases(rel.Namespace).Update(rel); err != nil {
+		errList = append(errList, fmt.Sprintf("failed to update release: %s", err.Error()))
+		cmd.Printf("errored: %s\n", err.Error())
+	} else {
+		cmd.Println("done")
+	}
+
+	cmd.Printf("Updating installation target %s/%s ...", it.Namespace, it.Name)
+	it.Spec.Clusters = clusters
+	if _, err := shipperClient.ShipperV1alpha1().InstallationTargets(it.Namespace).Update(it); err != nil {
+		errList = append(errList, fmt.Sprintf("failed to update installation target: %s", err.Error()))
+		cmd.Printf("errored: %s\n", err.Error())
+	} else {
+		cmd.Println("done")
+	}
+
+	cmd.Printf("Updating capacity target %s/%s ...", ct.Namespace, ct.Name)
+	ct.Spec.Clusters = rescheduleCapacityTargetClusters(ct.Spec.Clusters, clusters)
+	if _, err := shipperClient.ShipperV1alpha1().CapacityTargets(ct.Namespace).Update(ct); err != nil {
+		errList = append(errList, fmt.Sprintf("failed to update capacity target: %s", err.Error()))
+		cmd.Printf("errored: %s\n", err.Error())
+	} else {
+		cmd.Println("done")
+	}
+
+	cmd.Printf("Updating traffic target %s/%s ...", tt.Namespace, tt.Name)
+	tt.Spec.Clusters = rescheduleTrafficTargetClusters(tt.Spec.Clusters, clusters)
+	if _, err := shipperClient.ShipperV1alpha1().TrafficTargets(tt.Namespace).Update(tt); err != nil {
+		errList = append(errList, fmt.Sprintf("failed to update traffic target: %s", err.Error()))
+		cmd.Printf("errored: %s\n", err.Error())
+	} else {
+		cmd.Println("done")
+	}
+
+	if len(errList) > 0 {
+		return fmt.Errorf("%s", strings.Join(errList, ", "))
+	}
+
+	return nil
+}
+
+// rescheduleCapacityTargetClusters keeps the spec of clusters the release
+// stays on. New clusters start with no capacity and the same total replica
+// count as the others, leaving it to the release strategy to scale them up.
+func rescheduleCapacityTargetClusters(specs []shipper.ClusterCapacityTarget, clusters []string) []shipper.ClusterCapacityTarget {
+	var totalReplicaCount int32
+	existing := make(map[string]shipper.ClusterCapacityTarget)
+	for _, spec := range specs {
+		existing[spec.Name] = spec
+		totalReplicaCount = spec.TotalReplicaCount
+	}
+
+	newSpecs := make([]shipper.ClusterCapacityTarget, 0, len(clusters))
+	for _, cluster := range clusters {
+		spec, ok := existing[cluster]
+		if !ok {
+			spec = shipper.ClusterCapacityTarget{
+				Name:              cluster,
+				Percent:           0,
+				TotalReplicaCount: totalReplicaCount,
+			}
+		}
+		newSpecs = append(newSpecs, spec)
+	}
+
+	return newSpecs
+}
+
+// rescheduleTrafficTargetClusters keeps the spec of clusters the release
+// stays on. New clusters start with no traffic.
+func rescheduleTrafficTargetClusters(specs []shipper.ClusterTrafficTarget, clusters []string) []shipper.ClusterTrafficTarget {
+	existing := make(map[string]shipper.ClusterTrafficTarget)
+	for _, spec := range specs {
+		existing[spec.Name] = spec
+	}
+
+	newSpecs := make([]shipper.ClusterTrafficTarget, 0, len(clusters))
+	for _, cluster := range clusters {
+		spec, ok := existing[cluster]
+		if !ok {
+			spec = shipper.ClusterTrafficTarget{Name: cluster}
+		}
+		newSpecs = append(newSpecs, spec)
+	}
+
+	return newSpecs
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/bookingcom/shipper/cmd/shipperctl/configurator"
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/controller/traffic"
+	apputil "github.com/bookingcom/shipper/pkg/util/application"
+)
+
+var servingPodsCluster string
+
+var servingPodsReleaseCmd = &cobra.Command{
+	Use:   "serving-pods <release>",
+	Short: "list the pods of a release that are serving traffic in a cluster",
+	Long: "listing the pods of a release that are behind the production service of " +
+		"its application in a cluster. the cluster is reached through the " +
+		"context with the same name in the kubernetes configuration file.",
+	Args: cobra.ExactArgs(1),
+	RunE: runServingPodsReleaseCommand,
+}
+
+func init() {
+	servingPodsReleaseCmd.Flags().StringVar(&servingPodsCluster, "cluster", "", "The cluster to list serving pods in. (Required)")
+	if err := servingPodsReleaseCmd.MarkFlagRequired("cluster"); err != nil {
+		servingPodsReleaseCmd.Printf("warning: could not mark %q as required: %s\n", "cluster", err)
+	}
+
+	ReleaseCmd.AddCommand(servingPodsReleaseCmd)
+}
+
+func runServingPodsReleaseCommand(cmd *cobra.Command, args []string) error {
+	shipperClient, err := configurator.NewShipperClientFromKubeConfig(kubeConfigFile, managementClusterContext)
+	if err != nil {
+		return err
+	}
+
+	relName := args[0]
+	rel, err := shipperClient.ShipperV1alpha1().Releases(releaseNamespace).Get(relName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get release: %s", err.Error())
+	}
+
+	appName, ok := rel.Labels[shipper.AppLabel]
+	if !ok {
+		return fmt.Errorf("release %s/%s has no %q label", rel.Namespace, rel.Name, shipper.AppLabel)
+	}
+
+	kubeClient, err := configurator.NewKubeClientFromKubeConfig(kubeConfigFile, servingPodsCluster)
+	if err != nil {
+		return fmt.Errorf("failed to build a client for cluster %q: %s", servingPodsCluster, err.Error())
+	}
+
+	// Applications pick the selector of their production services, but
+	// the release may outlive its application, so it's fine if it's gone.
+	serviceSelector := apputil.DefaultProductionServiceSelector(appName)
+	app, err := shipperClient.ShipperV1alpha1().Applications(rel.Namespace).Get(appName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get application: %s", err.Error())
+		}
+	} else {
+		serviceSelector, err = apputil.GetProductionServiceSelector(app)
+		if err != nil {
+			return fmt.Errorf("failed to get production service selector: %s", err.Error())
+		}
+	}
+
+	services, err := kubeClient.CoreV1().Services(rel.Namespace).List(metav1.ListOptions{LabelSelector: serviceSelector.String()})
+	if err != nil {
+		return fmt.Errorf("failed to list services: %s", err.Error())
+	}
+
+	if len(services.Items) == 0 {
+		return fmt.Errorf("application %s/%s has no production service in cluster %q", rel.Namespace, appName, servingPodsCluster)
+	}
+
+	podSelector := labels.Set{
+		shipper.AppLabel:     appName,
+		shipper.ReleaseLabel: rel.Name,
+	}.AsSelector().String()
+	podList, err := kubeClient.CoreV1().Pods(rel.Namespace).List(metav1.ListOptions{LabelSelector: podSelector})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %s", err.Error())
+	}
+
+	pods := make([]*corev1.Pod, 0, len(podList.Items))
+	for i := range podList.Items {
+		pods = append(pods, &podList.Items[i])
+	}
+
+	for _, svc := range services.Items {
+		serving := traffic.ServingPods(pods, svc.Spec.Selector)
+		cmd.Printf("service %q: %d out of %d pods of release %s/%s are serving traffic\n",
+			svc.Name, len(serving), len(pods), rel.Namespace, rel.Name)
+		for _, pod := range serving {
+			cmd.Printf("  %s\n", pod.Name)
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package configurator
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
@@ -31,6 +33,8 @@ const (
 	shipperValidatingWebhookName        = "shipper.booking.com"
 	shipperValidatingWebhookServiceName = "shipper-validating-webhook"
 	shipperValidatingWebhookServicePath = "/validate"
+	shipperPodTrafficLabelWebhookName   = "pod-traffic-label.shipper.booking.com"
+	shipperPodTrafficLabelWebhookPath   = "/mutate/pods"
 	MaximumRetries                      = 20
 	AgentName                           = "configurator"
 )
@@ -467,6 +471,67 @@ func (c *Cluster) UpdateValidatingWebhookConfigurationFailurePolicyToFail() erro
 	return err
 }
 
+// CreateOrUpdatePodTrafficLabelWebhookConfiguration registers shipper's
+// pod traffic label webhook, served at webhookURL, in an application
+// cluster. The name of the cluster is passed along in the "cluster" query
+// parameter, since admission requests don't say where they come from. The
+// webhook is ignored when it can't be reached, as the traffic controller
+// labels pods eventually anyway.
+func (c *Cluster) CreateOrUpdatePodTrafficLabelWebhookConfiguration(caBundle []byte, webhookURL, clusterName string) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL %q: %s", webhookURL, err)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + shipperPodTrafficLabelWebhookPath
+	u.RawQuery = url.Values{"cluster": []string{clusterName}}.Encode()
+	clientURL := u.String()
+
+	sideEffectClassNone := admissionregistrationv1beta1.SideEffectClassNone
+	failurePolicy := admissionregistrationv1beta1.Ignore
+	mutatingWebhookConfiguration := &admissionregistrationv1beta1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: shipperPodTrafficLabelWebhookName,
+		},
+		Webhooks: []admissionregistrationv1beta1.MutatingWebhook{
+			admissionregistrationv1beta1.MutatingWebhook{
+				Name: shipperPodTrafficLabelWebhookName,
+				ClientConfig: admissionregistrationv1beta1.WebhookClientConfig{
+					CABundle: caBundle,
+					URL:      &clientURL,
+				},
+				Rules: []admissionregistrationv1beta1.RuleWithOperations{
+					admissionregistrationv1beta1.RuleWithOperations{
+						Operations: []admissionregistrationv1beta1.OperationType{
+							admissionregistrationv1beta1.Create,
+						},
+						Rule: admissionregistrationv1beta1.Rule{
+							APIGroups:   []string{corev1.SchemeGroupVersion.Group},
+							APIVersions: []string{corev1.SchemeGroupVersion.Version},
+							Resources:   []string{"pods"},
+						},
+					},
+				},
+				SideEffects:   &sideEffectClassNone,
+				FailurePolicy: &failurePolicy,
+			},
+		},
+	}
+
+	existingConfig, err := c.KubeClient.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().Get(shipperPodTrafficLabelWebhookName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			_, err = c.KubeClient.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().Create(mutatingWebhookConfiguration)
+			return err
+		} else {
+			return err
+		}
+	}
+
+	existingConfig.Webhooks = mutatingWebhookConfiguration.Webhooks
+	_, err = c.KubeClient.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().Update(existingConfig)
+	return err
+}
+
 func (c *Cluster) CreateOrUpdateValidatingWebhookService(namespace string) error {
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
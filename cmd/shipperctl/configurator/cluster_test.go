@@ -126,6 +126,57 @@ func TestUpdateValidatingWebhookService(t *testing.T) {
 	shippertesting.CheckActions(f.actions, actualActions, f.t)
 }
 
+func TestCreatePodTrafficLabelWebhookConfiguration(t *testing.T) {
+	f := newFixture(t)
+	caBundle := []byte{}
+	if err := f.configurator.CreateOrUpdatePodTrafficLabelWebhookConfiguration(caBundle, "https://shipper.example.com/", "kube-a"); err != nil {
+		t.Fatal(err)
+	}
+
+	clientURL := "https://shipper.example.com/mutate/pods?cluster=kube-a"
+	sideEffectClassNone := admissionregistrationv1beta1.SideEffectClassNone
+	failurePolicy := admissionregistrationv1beta1.Ignore
+	expectedConfiguration := &admissionregistrationv1beta1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: shipperPodTrafficLabelWebhookName,
+		},
+		Webhooks: []admissionregistrationv1beta1.MutatingWebhook{
+			admissionregistrationv1beta1.MutatingWebhook{
+				Name: shipperPodTrafficLabelWebhookName,
+				ClientConfig: admissionregistrationv1beta1.WebhookClientConfig{
+					CABundle: caBundle,
+					URL:      &clientURL,
+				},
+				Rules: []admissionregistrationv1beta1.RuleWithOperations{
+					admissionregistrationv1beta1.RuleWithOperations{
+						Operations: []admissionregistrationv1beta1.OperationType{
+							admissionregistrationv1beta1.Create,
+						},
+						Rule: admissionregistrationv1beta1.Rule{
+							APIGroups:   []string{""},
+							APIVersions: []string{"v1"},
+							Resources:   []string{"pods"},
+						},
+					},
+				},
+				SideEffects:   &sideEffectClassNone,
+				FailurePolicy: &failurePolicy,
+			},
+		},
+	}
+	gvr := admissionregistrationv1beta1.SchemeGroupVersion.WithResource("mutatingwebhookconfigurations")
+	getAction := kubetesting.NewGetAction(gvr, "", expectedConfiguration.Name)
+	createAction := kubetesting.NewCreateAction(gvr, "", expectedConfiguration)
+	f.actions = append(f.actions, getAction, createAction)
+
+	clientSet, ok := f.configurator.KubeClient.(*kubefake.Clientset)
+	if !ok {
+		t.Fatalf("not a *kubefake.Clientset: %#v", f.configurator.KubeClient)
+	}
+	actualActions := shippertesting.FilterActions(clientSet.Actions())
+	shippertesting.CheckActions(f.actions, actualActions, f.t)
+}
+
 type fixture struct {
 	t            *testing.T
 	configurator *Cluster
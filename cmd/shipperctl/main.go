@@ -21,6 +21,7 @@ func init() {
 	rootCmd.AddCommand(cmd.ClustersCmd)
 	rootCmd.AddCommand(cmd.ListCmd)
 	rootCmd.AddCommand(cmd.CleanCmd)
+	rootCmd.AddCommand(cmd.ReleaseCmd)
 	rootCmd.AddCommand(backup.BackupCmd)
 }
 
@@ -1,8 +1,15 @@
 package release
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
 	shipperclientset "github.com/bookingcom/shipper/pkg/client/clientset/versioned"
@@ -22,8 +29,105 @@ func FilterSelectedClusters(selectedClusters []string, clustersToRemove []string
 	return filteredClusters
 }
 
+// RescheduleClusters removes clustersToRemove from selectedClusters and adds
+// clustersToAdd to what is left, returning a sorted list with no duplicates.
+func RescheduleClusters(selectedClusters, clustersToRemove, clustersToAdd []string) []string {
+	rescheduledClusters := FilterSelectedClusters(selectedClusters, clustersToRemove)
+	for _, cluster := range clustersToAdd {
+		if cluster == "" || filters.SliceContainsString(rescheduledClusters, cluster) {
+			continue
+		}
+		rescheduledClusters = append(rescheduledClusters, cluster)
+	}
+	sort.Strings(rescheduledClusters)
+	return rescheduledClusters
+}
+
+// ClusterSetDiff compares the clusters releases a and b are scheduled on,
+// returning the clusters only a is on, the ones only b is on and the ones
+// both are on. Each of them is sorted.
+func ClusterSetDiff(a, b *shipper.Release) (onlyA, onlyB, both []string) {
+	clustersA := ScheduledClusters(a)
+	clustersB := ScheduledClusters(b)
+
+	for _, cluster := range clustersA {
+		if filters.SliceContainsString(clustersB, cluster) {
+			both = append(both, cluster)
+		} else {
+			onlyA = append(onlyA, cluster)
+		}
+	}
+
+	for _, cluster := range clustersB {
+		if !filters.SliceContainsString(clustersA, cluster) {
+			onlyB = append(onlyB, cluster)
+		}
+	}
+
+	return onlyA, onlyB, both
+}
+
+// ScheduledClusters returns the sorted, deduplicated list of clusters rel is
+// scheduled on according to its clusters annotation.
+func ScheduledClusters(rel *shipper.Release) []string {
+	var clusters []string
+	for _, cluster := range strings.Split(rel.Annotations[shipper.ReleaseClustersAnnotation], ",") {
+		cluster = strings.TrimSpace(cluster)
+		if cluster == "" || filters.SliceContainsString(clusters, cluster) {
+			continue
+		}
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+	return clusters
+}
+
+// ClustersCarryingTraffic returns the clusters out of the given ones in which
+// the traffic target has either achieved or been asked for some traffic.
+func ClustersCarryingTraffic(tt *shipper.TrafficTarget, clusters []string) []string {
+	var carrying []string
+	for _, cluster := range clusters {
+		hasTraffic := false
+		for _, spec := range tt.Spec.Clusters {
+			if spec.Name == cluster && spec.Weight > 0 {
+				hasTraffic = true
+			}
+		}
+		for _, status := range tt.Status.Clusters {
+			if status.Name == cluster && status.AchievedTraffic > 0 {
+				hasTraffic = true
+			}
+		}
+		if hasTraffic {
+			carrying = append(carrying, cluster)
+		}
+	}
+	return carrying
+}
+
+// KickRelease sets the kicked-at annotation of a release to now, so the
+// release controller sees it change and reconciles it right away. Nothing
+// else about the release is touched.
+func KickRelease(shipperClient shipperclientset.Interface, namespace, name string, now time.Time) (*shipper.Release, error) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				shipper.ReleaseKickedAtAnnotation: now.UTC().Format(time.RFC3339),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shipperClient.ShipperV1alpha1().Releases(namespace).Patch(name, types.MergePatchType, patch)
+}
+
 func IsContender(rel *shipper.Release, shipperClient shipperclientset.Interface) (bool, error) {
-	appName := rel.Labels[shipper.AppLabel]
+	appName, err := applicationName(rel)
+	if err != nil {
+		return false, err
+	}
 	app, err := shipperClient.ShipperV1alpha1().Applications(rel.Namespace).Get(appName, metav1.GetOptions{})
 	if err != nil {
 		return false, err
@@ -36,7 +140,10 @@ func IsContender(rel *shipper.Release, shipperClient shipperclientset.Interface)
 }
 
 func IsIncumbent(rel *shipper.Release, shipperClient shipperclientset.Interface) (bool, error) {
-	appName := rel.Labels[shipper.AppLabel]
+	appName, err := applicationName(rel)
+	if err != nil {
+		return false, err
+	}
 	app, err := shipperClient.ShipperV1alpha1().Applications(rel.Namespace).Get(appName, metav1.GetOptions{})
 	if err != nil {
 		return false, err
@@ -48,6 +155,16 @@ func IsIncumbent(rel *shipper.Release, shipperClient shipperclientset.Interface)
 	return incumbent.Name == rel.Name && incumbent.Namespace == rel.Namespace, nil
 }
 
+// applicationName returns the name of the application rel belongs to, or a
+// MissingAppLabelError if it doesn't say.
+func applicationName(rel *shipper.Release) (string, error) {
+	appName, ok := rel.Labels[shipper.AppLabel]
+	if !ok || appName == "" {
+		return "", shippererrors.NewMissingAppLabelError(fmt.Sprintf("%s/%s", rel.Namespace, rel.Name))
+	}
+	return appName, nil
+}
+
 func GetContender(app *shipper.Application, shipperClient shipperclientset.Interface) (*shipper.Release, error) {
 	releaseList, err := ReleasesForApplication(app.Name, app.Namespace, shipperClient)
 	if err != nil {
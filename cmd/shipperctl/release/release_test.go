@@ -4,6 +4,14 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperclientset "github.com/bookingcom/shipper/pkg/client/clientset/versioned"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
 )
 
 func TestFilterSelectedClusters(t *testing.T) {
@@ -75,3 +83,237 @@ func TestFilterSelectedClusters(t *testing.T) {
 		})
 	}
 }
+
+func TestRescheduleClusters(t *testing.T) {
+	tests := []struct {
+		Name              string
+		ScheduledClusters []string
+		RemovedClusters   []string
+		AddedClusters     []string
+		ExpectedClusters  []string
+	}{
+		{
+			Name:              "move to a different cluster",
+			ScheduledClusters: []string{"cluster-A", "cluster-B"},
+			RemovedClusters:   []string{"cluster-A"},
+			AddedClusters:     []string{"cluster-C"},
+			ExpectedClusters:  []string{"cluster-B", "cluster-C"},
+		},
+		{
+			Name:              "add an already scheduled cluster",
+			ScheduledClusters: []string{"cluster-A", "cluster-B"},
+			RemovedClusters:   nil,
+			AddedClusters:     []string{"cluster-B"},
+			ExpectedClusters:  []string{"cluster-A", "cluster-B"},
+		},
+		{
+			Name:              "remove only",
+			ScheduledClusters: []string{"cluster-B", "cluster-A"},
+			RemovedClusters:   []string{"cluster-B"},
+			AddedClusters:     nil,
+			ExpectedClusters:  []string{"cluster-A"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			clusters := RescheduleClusters(test.ScheduledClusters, test.RemovedClusters, test.AddedClusters)
+			if !reflect.DeepEqual(test.ExpectedClusters, clusters) {
+				t.Fatalf(
+					"expected clusters %q got %q",
+					strings.Join(test.ExpectedClusters, ","),
+					strings.Join(clusters, ","))
+			}
+		})
+	}
+}
+
+func TestClustersCarryingTraffic(t *testing.T) {
+	tt := &shipper.TrafficTarget{
+		Spec: shipper.TrafficTargetSpec{
+			Clusters: []shipper.ClusterTrafficTarget{
+				{Name: "cluster-A", Weight: 0},
+				{Name: "cluster-B", Weight: 10},
+				{Name: "cluster-C", Weight: 0},
+			},
+		},
+		Status: shipper.TrafficTargetStatus{
+			Clusters: []*shipper.ClusterTrafficStatus{
+				{Name: "cluster-A", AchievedTraffic: 5},
+				{Name: "cluster-B", AchievedTraffic: 0},
+				{Name: "cluster-C", AchievedTraffic: 0},
+			},
+		},
+	}
+
+	expected := []string{"cluster-A", "cluster-B"}
+	carrying := ClustersCarryingTraffic(tt, []string{"cluster-A", "cluster-B", "cluster-C"})
+	if !reflect.DeepEqual(expected, carrying) {
+		t.Fatalf("expected clusters %q got %q", strings.Join(expected, ","), strings.Join(carrying, ","))
+	}
+}
+
+func TestScheduledClusters(t *testing.T) {
+	rel := &shipper.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				shipper.ReleaseClustersAnnotation: "cluster-B, cluster-A,,cluster-B,",
+			},
+		},
+	}
+
+	expected := []string{"cluster-A", "cluster-B"}
+	clusters := ScheduledClusters(rel)
+	if !reflect.DeepEqual(expected, clusters) {
+		t.Fatalf("expected clusters %q got %q", strings.Join(expected, ","), strings.Join(clusters, ","))
+	}
+}
+
+func TestClusterSetDiff(t *testing.T) {
+	tests := []struct {
+		Name          string
+		ClustersA     string
+		ClustersB     string
+		ExpectedOnlyA []string
+		ExpectedOnlyB []string
+		ExpectedBoth  []string
+	}{
+		{
+			Name:          "identical sets",
+			ClustersA:     "cluster-A,cluster-B",
+			ClustersB:     "cluster-B,cluster-A",
+			ExpectedOnlyA: nil,
+			ExpectedOnlyB: nil,
+			ExpectedBoth:  []string{"cluster-A", "cluster-B"},
+		},
+		{
+			Name:          "disjoint sets",
+			ClustersA:     "cluster-A,cluster-B",
+			ClustersB:     "cluster-C",
+			ExpectedOnlyA: []string{"cluster-A", "cluster-B"},
+			ExpectedOnlyB: []string{"cluster-C"},
+			ExpectedBoth:  nil,
+		},
+		{
+			Name:          "overlapping sets",
+			ClustersA:     "cluster-A,cluster-B",
+			ClustersB:     "cluster-B,cluster-C",
+			ExpectedOnlyA: []string{"cluster-A"},
+			ExpectedOnlyB: []string{"cluster-C"},
+			ExpectedBoth:  []string{"cluster-B"},
+		},
+		{
+			Name:          "unscheduled release",
+			ClustersA:     "",
+			ClustersB:     "cluster-A",
+			ExpectedOnlyA: nil,
+			ExpectedOnlyB: []string{"cluster-A"},
+			ExpectedBoth:  nil,
+		},
+		{
+			Name:          "duplicated clusters",
+			ClustersA:     "cluster-A,cluster-A",
+			ClustersB:     "cluster-A",
+			ExpectedOnlyA: nil,
+			ExpectedOnlyB: nil,
+			ExpectedBoth:  []string{"cluster-A"},
+		},
+	}
+
+	buildRelease := func(clusters string) *shipper.Release {
+		return &shipper.Release{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					shipper.ReleaseClustersAnnotation: clusters,
+				},
+			},
+		}
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			onlyA, onlyB, both := ClusterSetDiff(buildRelease(test.ClustersA), buildRelease(test.ClustersB))
+			if !reflect.DeepEqual(test.ExpectedOnlyA, onlyA) {
+				t.Errorf("expected clusters only in a %q got %q",
+					strings.Join(test.ExpectedOnlyA, ","), strings.Join(onlyA, ","))
+			}
+			if !reflect.DeepEqual(test.ExpectedOnlyB, onlyB) {
+				t.Errorf("expected clusters only in b %q got %q",
+					strings.Join(test.ExpectedOnlyB, ","), strings.Join(onlyB, ","))
+			}
+			if !reflect.DeepEqual(test.ExpectedBoth, both) {
+				t.Errorf("expected clusters in both %q got %q",
+					strings.Join(test.ExpectedBoth, ","), strings.Join(both, ","))
+			}
+		})
+	}
+}
+
+func TestReleaseWithoutAppLabel(t *testing.T) {
+	rel := &shipper.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-release",
+			Namespace: "test-namespace",
+			Labels:    map[string]string{},
+		},
+	}
+
+	shipperClient := shipperfake.NewSimpleClientset()
+
+	tests := []struct {
+		Name string
+		Func func(*shipper.Release, shipperclientset.Interface) (bool, error)
+	}{
+		{Name: "IsContender", Func: IsContender},
+		{Name: "IsIncumbent", Func: IsIncumbent},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ok, err := test.Func(rel, shipperClient)
+			if !shippererrors.IsMissingAppLabelError(err) {
+				t.Fatalf("expected a MissingAppLabelError, got %v", err)
+			}
+			if ok {
+				t.Errorf("expected a release without an application not to be reported as %s", test.Name)
+			}
+			if actions := shipperClient.Actions(); len(actions) != 0 {
+				t.Errorf("expected no calls to the API server, got %d", len(actions))
+			}
+		})
+	}
+}
+
+func TestKickRelease(t *testing.T) {
+	rel := &shipper.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-release",
+			Namespace: "test-namespace",
+			Annotations: map[string]string{
+				shipper.ReleaseClustersAnnotation: "cluster-A",
+			},
+		},
+		Spec: shipper.ReleaseSpec{
+			TargetStep: 1,
+		},
+	}
+
+	shipperClient := shipperfake.NewSimpleClientset(rel.DeepCopy())
+
+	now := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	kicked, err := KickRelease(shipperClient, rel.Namespace, rel.Name, now)
+	if err != nil {
+		t.Fatalf("unexpected error kicking release: %s", err)
+	}
+
+	expected := rel.DeepCopy()
+	expected.Annotations[shipper.ReleaseKickedAtAnnotation] = "2020-01-01T12:00:00Z"
+
+	if !reflect.DeepEqual(kicked.ObjectMeta.Annotations, expected.Annotations) {
+		t.Errorf("expected annotations %v, got %v", expected.Annotations, kicked.Annotations)
+	}
+
+	if !reflect.DeepEqual(kicked.Spec, expected.Spec) {
+		t.Errorf("expected kicking the release not to change its spec, got %v", kicked.Spec)
+	}
+}
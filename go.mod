@@ -10,7 +10,7 @@ require (
 	github.com/cespare/xxhash v1.1.0
 	github.com/cyphar/filepath-securejoin v0.2.2 // indirect
 	github.com/gobwas/glob v0.2.2 // indirect
-	github.com/google/go-cmp v0.4.0
+	github.com/google/go-cmp v0.5.2
 	github.com/huandu/xstrings v0.0.0-20171208101919-37469d0c81a7 // indirect
 	github.com/imdario/mergo v0.3.7 // indirect
 	github.com/mitchellh/go-homedir v1.1.0
@@ -20,6 +20,10 @@ require (
 	github.com/satori/go.uuid v1.2.0 // indirect
 	github.com/spaolacci/murmur3 v1.1.0 // indirect
 	github.com/spf13/cobra v1.0.0
+	go.opentelemetry.io/otel v0.13.0
+	go.opentelemetry.io/otel/exporters/stdout v0.13.0
+	go.opentelemetry.io/otel/exporters/trace/zipkin v0.13.0
+	go.opentelemetry.io/otel/sdk v0.13.0
 	k8s.io/api v0.17.12
 	k8s.io/apiextensions-apiserver v0.17.12
 	k8s.io/apimachinery v0.17.12
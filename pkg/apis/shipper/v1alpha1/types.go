@@ -19,8 +19,13 @@ const (
 	ReleaseEnvironmentHashLabel  = "shipper-release-hash"
 	PodTrafficStatusLabel        = "shipper-traffic-status"
 	InstallationTargetOwnerLabel = "shipper-owned-by"
+	ManagedByLabel               = "shipper.io/managed-by"
 
 	AppHighestObservedGenerationAnnotation = "shipper.booking.com/app.highestObservedGeneration"
+	AppDefaultTrafficPolicyAnnotation      = "shipper.booking.com/app.traffic.defaultPolicy"
+	AppTrafficServiceSelectorAnnotation    = "shipper.booking.com/app.traffic.serviceSelector"
+	AppPausedAnnotation                    = "shipper.io/paused"
+	AppCompletionPolicyAnnotation          = "shipper.booking.com/app.completion"
 
 	AppChartNameAnnotation            = "shipper.booking.com/app.chart.name"
 	AppChartVersionResolvedAnnotation = "shipper.booking.com/app.chart.version.resolved"
@@ -29,9 +34,15 @@ const (
 	ReleaseGenerationAnnotation        = "shipper.booking.com/release.generation"
 	ReleaseTemplateIterationAnnotation = "shipper.booking.com/release.template.iteration"
 	ReleaseClustersAnnotation          = "shipper.booking.com/release.clusters"
+	ReleasePriorityAnnotation          = "shipper.io/priority"
+	ReleaseKickedAtAnnotation          = "shipper.io/kicked-at"
+	ReleaseDependsOnAnnotation         = "shipper.booking.com/release.dependsOn"
+	ReleaseDependsOnPhaseAnnotation    = "shipper.booking.com/release.dependsOn.phase"
 
 	SecretClusterSkipTlsVerifyAnnotation = "shipper.booking.com/cluster-secret.insecure-tls-skip-verify"
 
+	ClusterDrainAnnotation = "shipper.booking.com/cluster.drain"
+
 	RolloutBlocksOverrideAnnotation = "shipper.booking.com/rollout-block.override"
 
 	LBLabel         = "shipper-lb"
@@ -43,6 +54,14 @@ const (
 	True  = "true"
 	False = "false"
 
+	CompletionPolicyCapacity = "capacity"
+	CompletionPolicyTraffic  = "traffic"
+	CompletionPolicyBoth     = "both"
+
+	ReleasePriorityHigh   = "high"
+	ReleasePriorityNormal = "normal"
+	ReleasePriorityLow    = "low"
+
 	HelmReleaseLabel    = "release"
 	HelmWorkaroundLabel = "enable-helm-release-workaround"
 
@@ -149,6 +168,24 @@ type ClusterSpec struct {
 	Region       string                   `json:"region"`
 	APIMaster    string                   `json:"apiMaster"`
 	Scheduler    ClusterSchedulerSettings `json:"scheduler"`
+
+	// MaxTrafficPods caps how many pods can be labeled to receive traffic
+	// in this cluster, across all releases of an application combined,
+	// for downstreams that can't handle the full fleet. Zero means no
+	// cap.
+	MaxTrafficPods int32 `json:"maxTrafficPods,omitempty"`
+
+	// MaxReplicas caps how many replicas of a release can run in this
+	// cluster. Releases scheduled only on clusters that all set it get
+	// their replicas spread across them, instead of each cluster running
+	// all of them. Zero means no cap.
+	MaxReplicas int32 `json:"maxReplicas,omitempty"`
+
+	// CapacityHeadroom caps how many replicas a contender can grow by in
+	// this cluster at once. Bigger increases are spread over several
+	// reconciles, each waiting for the previous one to be achieved. Zero
+	// means no cap.
+	CapacityHeadroom int32 `json:"capacityHeadroom,omitempty"`
 }
 
 type ClusterSchedulerSettings struct {
@@ -193,9 +230,35 @@ type ReleaseSpec struct {
 
 // this will likely grow into a struct with interesting fields
 type ReleaseStatus struct {
-	AchievedStep *AchievedStep          `json:"achievedStep,omitempty"`
-	Strategy     *ReleaseStrategyStatus `json:"strategy,omitempty"`
-	Conditions   []ReleaseCondition     `json:"conditions,omitempty"`
+	// ObservedGeneration is the most recent generation of the release the
+	// release controller has successfully reconciled.
+	ObservedGeneration int64                  `json:"observedGeneration,omitempty"`
+	AchievedStep       *AchievedStep          `json:"achievedStep,omitempty"`
+	Strategy           *ReleaseStrategyStatus `json:"strategy,omitempty"`
+	Conditions         []ReleaseCondition     `json:"conditions,omitempty"`
+
+	// StepDurations records when each step of the strategy started and
+	// finished, as observed by the release controller.
+	StepDurations []StepDuration `json:"stepDurations,omitempty"`
+
+	// TrafficSummary rolls up the traffic the release has in each of its
+	// clusters, as reported by its TrafficTarget.
+	TrafficSummary *TrafficSummary `json:"trafficSummary,omitempty"`
+}
+
+// TrafficSummary is a concise view of the traffic a release has across its
+// clusters.
+type TrafficSummary struct {
+	// Distribution lists the achieved and desired weights of the release
+	// in every cluster, as in "cluster-a: 25/50, cluster-b: 50/50".
+	Distribution string                  `json:"distribution"`
+	Clusters     []ClusterTrafficSummary `json:"clusters,omitempty"`
+}
+
+type ClusterTrafficSummary struct {
+	Name           string `json:"name"`
+	DesiredWeight  uint32 `json:"desiredWeight"`
+	AchievedWeight uint32 `json:"achievedWeight"`
 }
 
 type AchievedStep struct {
@@ -203,13 +266,29 @@ type AchievedStep struct {
 	Name string `json:"name"`
 }
 
+// StepDuration is the time a release spent getting through a single step
+// of its strategy. Finished and Duration are only set once the step has
+// been achieved.
+type StepDuration struct {
+	Step     int32            `json:"step"`
+	Started  metav1.Time      `json:"started"`
+	Finished *metav1.Time     `json:"finished,omitempty"`
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
 type ReleaseConditionType string
 
 const (
-	ReleaseConditionTypeScheduled        ReleaseConditionType = "Scheduled"
-	ReleaseConditionTypeStrategyExecuted ReleaseConditionType = "StrategyExecuted"
-	ReleaseConditionTypeComplete         ReleaseConditionType = "Complete"
-	ReleaseConditionTypeBlocked          ReleaseConditionType = "Blocked"
+	ReleaseConditionTypeScheduled         ReleaseConditionType = "Scheduled"
+	ReleaseConditionTypeStrategyExecuted  ReleaseConditionType = "StrategyExecuted"
+	ReleaseConditionTypeComplete          ReleaseConditionType = "Complete"
+	ReleaseConditionTypeBlocked           ReleaseConditionType = "Blocked"
+	ReleaseConditionTypeTrafficConverged  ReleaseConditionType = "TrafficConverged"
+	ReleaseConditionTypeCapacityConverged ReleaseConditionType = "CapacityConverged"
+	ReleaseConditionTypeGateStuck         ReleaseConditionType = "GateStuck"
+	ReleaseConditionTypeParentPaused      ReleaseConditionType = "ParentPaused"
+	ReleaseConditionTypeStepAchieved      ReleaseConditionType = "StepAchieved"
+	ReleaseConditionTypeBaking            ReleaseConditionType = "Baking"
 )
 
 type ReleaseCondition struct {
@@ -218,6 +297,8 @@ type ReleaseCondition struct {
 	LastTransitionTime metav1.Time            `json:"lastTransitionTime,omitempty"`
 	Reason             string                 `json:"reason,omitempty"`
 	Message            string                 `json:"message,omitempty"`
+	// Step is the strategy step a StepAchieved condition is about.
+	Step int32 `json:"step,omitempty"`
 }
 
 type ReleaseEnvironment struct {
@@ -246,12 +327,50 @@ type RegionRequirement struct {
 
 type RolloutStrategy struct {
 	Steps []RolloutStrategyStep `json:"steps"`
+
+	// TrafficRamp, when set, has the contender's traffic weight move
+	// towards the weight of each step gradually instead of all at once.
+	TrafficRamp *TrafficRamp `json:"trafficRamp,omitempty"`
+
+	// ScaleDownIncumbent, when set, has the incumbent give up capacity as
+	// its contender gains traffic, keeping only what it needs for the
+	// traffic it's left with and some headroom, even when its step asks
+	// for more.
+	ScaleDownIncumbent bool `json:"scaleDownIncumbent,omitempty"`
+}
+
+type TrafficRampShape string
+
+const (
+	TrafficRampShapeLinear      TrafficRampShape = "linear"
+	TrafficRampShapeExponential TrafficRampShape = "exponential"
+	TrafficRampShapeFibonacci   TrafficRampShape = "fibonacci"
+)
+
+// TrafficRamp describes the intermediate weights a contender goes through
+// when the traffic weight of a strategy step is more than MaxDelta away
+// from the one it currently has. Every intermediate weight is only set once
+// traffic converged on the previous one.
+type TrafficRamp struct {
+	// Shape decides how intermediate weights grow: by MaxDelta every time
+	// for linear ramps, by doubling for exponential ones, and along the
+	// Fibonacci sequence for fibonacci ones.
+	Shape TrafficRampShape `json:"shape"`
+
+	// MaxDelta is the most the weight is allowed to grow in a single
+	// move. Exponential and fibonacci ramps are not capped when it's 0.
+	MaxDelta uint32 `json:"maxDelta,omitempty"`
 }
 
 type RolloutStrategyStep struct {
 	Name     string                   `json:"name"`
 	Capacity RolloutStrategyStepValue `json:"capacity"`
 	Traffic  RolloutStrategyStepValue `json:"traffic"`
+
+	// MinBakeTime, when set, keeps a release from achieving this step
+	// until it's been working on it for at least this long, even if it
+	// has already converged.
+	MinBakeTime *metav1.Duration `json:"minBakeTime,omitempty"`
 }
 
 type RolloutStrategyStepValue struct {
@@ -264,6 +383,20 @@ type TargetConditionType string
 const (
 	TargetConditionTypeOperational TargetConditionType = "Operational"
 	TargetConditionTypeReady       TargetConditionType = "Ready"
+
+	// TrafficTargetConditionTypeReady is set on a TrafficTarget once
+	// traffic has converged in all of its clusters.
+	TrafficTargetConditionTypeReady = TargetConditionTypeReady
+
+	// TrafficTargetConditionTypeTrafficDivergence is set on a
+	// TrafficTarget whose achieved weight has been diverging from the
+	// requested one for longer than the traffic controller tolerates.
+	TrafficTargetConditionTypeTrafficDivergence TargetConditionType = "TrafficDivergence"
+
+	// CapacityTargetConditionTypeCapacityStarved is set on a
+	// CapacityTarget that is short of replicas in some of its clusters
+	// because there is no room left in them to schedule its pods.
+	CapacityTargetConditionTypeCapacityStarved TargetConditionType = "CapacityStarved"
 )
 
 type TargetCondition struct {
@@ -460,6 +593,10 @@ type TrafficTargetStatus struct {
 	ObservedGeneration int64                   `json:"observedGeneration,omitempty"`
 	Clusters           []*ClusterTrafficStatus `json:"clusters,omitempty"`
 	Conditions         []TargetCondition       `json:"conditions,omitempty"`
+
+	// ShiftProgress is how much of the weight requested across all
+	// clusters has been achieved, as a percentage.
+	ShiftProgress int `json:"shiftProgress,omitempty"`
 }
 
 type ClusterTrafficStatus struct {
@@ -6,6 +6,7 @@ package v1alpha1
 
 import (
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -718,6 +719,22 @@ func (in *ClusterTrafficStatus) DeepCopy() *ClusterTrafficStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterTrafficSummary) DeepCopyInto(out *ClusterTrafficSummary) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTrafficSummary.
+func (in *ClusterTrafficSummary) DeepCopy() *ClusterTrafficSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterTrafficSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterTrafficTarget) DeepCopyInto(out *ClusterTrafficTarget) {
 	*out = *in
@@ -1055,6 +1072,18 @@ func (in *ReleaseStatus) DeepCopyInto(out *ReleaseStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.StepDurations != nil {
+		in, out := &in.StepDurations, &out.StepDurations
+		*out = make([]StepDuration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TrafficSummary != nil {
+		in, out := &in.TrafficSummary, &out.TrafficSummary
+		*out = new(TrafficSummary)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1258,7 +1287,14 @@ func (in *RolloutStrategy) DeepCopyInto(out *RolloutStrategy) {
 	if in.Steps != nil {
 		in, out := &in.Steps, &out.Steps
 		*out = make([]RolloutStrategyStep, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TrafficRamp != nil {
+		in, out := &in.TrafficRamp, &out.TrafficRamp
+		*out = new(TrafficRamp)
+		**out = **in
 	}
 	return
 }
@@ -1278,6 +1314,11 @@ func (in *RolloutStrategyStep) DeepCopyInto(out *RolloutStrategyStep) {
 	*out = *in
 	out.Capacity = in.Capacity
 	out.Traffic = in.Traffic
+	if in.MinBakeTime != nil {
+		in, out := &in.MinBakeTime, &out.MinBakeTime
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	return
 }
 
@@ -1307,6 +1348,33 @@ func (in *RolloutStrategyStepValue) DeepCopy() *RolloutStrategyStepValue {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepDuration) DeepCopyInto(out *StepDuration) {
+	*out = *in
+	in.Started.DeepCopyInto(&out.Started)
+	if in.Finished != nil {
+		in, out := &in.Finished, &out.Finished
+		*out = (*in).DeepCopy()
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepDuration.
+func (in *StepDuration) DeepCopy() *StepDuration {
+	if in == nil {
+		return nil
+	}
+	out := new(StepDuration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TargetCondition) DeepCopyInto(out *TargetCondition) {
 	*out = *in
@@ -1324,6 +1392,43 @@ func (in *TargetCondition) DeepCopy() *TargetCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficRamp) DeepCopyInto(out *TrafficRamp) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficRamp.
+func (in *TrafficRamp) DeepCopy() *TrafficRamp {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficRamp)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficSummary) DeepCopyInto(out *TrafficSummary) {
+	*out = *in
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]ClusterTrafficSummary, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficSummary.
+func (in *TrafficSummary) DeepCopy() *TrafficSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TrafficTarget) DeepCopyInto(out *TrafficTarget) {
 	*out = *in
@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	"sort"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/labels"
 
@@ -11,7 +12,13 @@ import (
 	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
 )
 
-type ReleaseListerExpansion interface{}
+// ReleaseListerExpansion allows custom methods to be added to
+// ReleaseLister.
+type ReleaseListerExpansion interface {
+	// ReleasesScheduledOnCluster returns the Releases, in all namespaces,
+	// that are scheduled on the given cluster.
+	ReleasesScheduledOnCluster(clusterName string) ([]*shipper.Release, error)
+}
 
 // ReleaseNamespaceListerExpansion allows custom methods to be added to
 // ReleaseNamespaceLister.
@@ -29,6 +36,28 @@ type ReleaseNamespaceListerExpansion interface {
 	IncumbentForApplication(appName string) (*shipper.Release, error)
 }
 
+func (s *releaseLister) ReleasesScheduledOnCluster(clusterName string) ([]*shipper.Release, error) {
+	selector := labels.Everything()
+	rels, err := s.List(selector)
+	if err != nil {
+		return nil, shippererrors.NewKubeclientListError(
+			shipper.SchemeGroupVersion.WithKind("Release"),
+			"", selector, err)
+	}
+
+	var onCluster []*shipper.Release
+	for _, rel := range rels {
+		for _, cluster := range strings.Split(rel.Annotations[shipper.ReleaseClustersAnnotation], ",") {
+			if cluster == clusterName {
+				onCluster = append(onCluster, rel)
+				break
+			}
+		}
+	}
+
+	return onCluster, nil
+}
+
 func (s releaseNamespaceLister) ReleasesForApplication(appName string) ([]*shipper.Release, error) {
 	selector := labels.Set{shipper.AppLabel: appName}.AsSelector()
 	selectedRels, err := s.List(selector)
@@ -1,6 +1,7 @@
 package capacity
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sort"
@@ -18,6 +19,7 @@ import (
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 
@@ -26,6 +28,7 @@ import (
 	informers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
 	listers "github.com/bookingcom/shipper/pkg/client/listers/shipper/v1alpha1"
 	"github.com/bookingcom/shipper/pkg/clusterclientstore"
+	shippercontroller "github.com/bookingcom/shipper/pkg/controller"
 	"github.com/bookingcom/shipper/pkg/controller/capacity/builder"
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
 	capacityutil "github.com/bookingcom/shipper/pkg/util/capacity"
@@ -46,6 +49,7 @@ const (
 	InternalError    = "InternalError"
 	PodsNotReady     = "PodsNotReady"
 	DeploymentStuck  = "DeploymentStuck"
+	CapacityStarved  = "CapacityStarved"
 
 	CapacityTargetConditionChanged  = "CapacityTargetConditionChanged"
 	ClusterCapacityConditionChanged = "ClusterCapacityConditionChanged"
@@ -61,16 +65,27 @@ type Controller struct {
 	releasesListerSynced  cache.InformerSynced
 	workqueue             workqueue.RateLimitingInterface
 	recorder              record.EventRecorder
+
+	// writeBudget is drawn from before every write, to capacity targets
+	// and deployments alike. It's shared with the other controllers in
+	// the process, so together they don't overwhelm the API server.
+	writeBudget flowcontrol.RateLimiter
 }
 
-// NewController returns a new CapacityTarget controller.
+// NewController returns a new CapacityTarget controller. Its writes aren't
+// limited when writeBudget is nil.
 func NewController(
 	shipperclientset clientset.Interface,
 	shipperInformerFactory informers.SharedInformerFactory,
 	store clusterclientstore.Interface,
 	recorder record.EventRecorder,
+	writeBudget flowcontrol.RateLimiter,
 ) *Controller {
 
+	if writeBudget == nil {
+		writeBudget = shippercontroller.NewWriteBudget(0, 0)
+	}
+
 	capacityTargetInformer := shipperInformerFactory.Shipper().V1alpha1().CapacityTargets()
 
 	releaseInformer := shipperInformerFactory.Shipper().V1alpha1().Releases()
@@ -84,6 +99,7 @@ func NewController(
 		workqueue:             workqueue.NewNamedRateLimitingQueue(shipperworkqueue.NewDefaultControllerRateLimiter(), "capacity_controller_capacitytargets"),
 		recorder:              recorder,
 		clusterClientStore:    store,
+		writeBudget:           writeBudget,
 	}
 
 	klog.Info("Setting up event handlers")
@@ -116,8 +132,11 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) {
 		return
 	}
 
+	ctx, cancel := shippercontroller.WorkerContext(stopCh)
+	defer cancel()
+
 	for i := 0; i < threadiness; i++ {
-		go wait.Until(c.runWorker, time.Second, stopCh)
+		go wait.Until(func() { c.runWorker(ctx) }, time.Second, stopCh)
 	}
 
 	klog.V(4).Info("Started Capacity controller")
@@ -125,12 +144,12 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) {
 	<-stopCh
 }
 
-func (c *Controller) runWorker() {
-	for c.processNextWorkItem() {
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
 	}
 }
 
-func (c *Controller) processNextWorkItem() bool {
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 	obj, shutdown := c.workqueue.Get()
 	if shutdown {
 		return false
@@ -150,7 +169,7 @@ func (c *Controller) processNextWorkItem() bool {
 	}
 
 	shouldRetry := false
-	err := c.capacityTargetSyncHandler(key)
+	err := c.capacityTargetSyncHandler(ctx, key)
 
 	if err != nil {
 		shouldRetry = shippererrors.ShouldRetry(err)
@@ -170,10 +189,11 @@ func (c *Controller) processNextWorkItem() bool {
 }
 
 func (c *Controller) processCapacityTargetOnCluster(
+	ctx context.Context,
 	ct *shipper.CapacityTarget,
 	spec *shipper.ClusterCapacityTarget,
 	status *shipper.ClusterCapacityStatus,
-) error {
+) (int, error) {
 	diff := diffutil.NewMultiDiff()
 	operationalCond := capacityutil.NewClusterCapacityCondition(
 		shipper.ClusterConditionTypeOperational,
@@ -214,7 +234,7 @@ func (c *Controller) processCapacityTargetOnCluster(
 			InternalError,
 			err.Error())
 
-		return err
+		return 0, err
 	}
 
 	operationalCond = capacityutil.NewClusterCapacityCondition(
@@ -232,7 +252,7 @@ func (c *Controller) processCapacityTargetOnCluster(
 
 	desiredReplicas := int32(replicas.CalculateDesiredReplicaCount(uint(spec.TotalReplicaCount), float64(spec.Percent)))
 	if deployment.Spec.Replicas == nil || desiredReplicas != *deployment.Spec.Replicas {
-		_, err = c.patchDeploymentWithReplicaCount(deployment, spec.Name, desiredReplicas)
+		_, err = c.patchDeploymentWithReplicaCount(ctx, deployment, spec.Name, desiredReplicas)
 		if err != nil {
 			readyCond = capacityutil.NewClusterCapacityCondition(
 				shipper.ClusterConditionTypeReady,
@@ -240,7 +260,7 @@ func (c *Controller) processCapacityTargetOnCluster(
 				InternalError,
 				err.Error(),
 			)
-			return err
+			return 0, err
 		} else {
 			readyCond = capacityutil.NewClusterCapacityCondition(
 				shipper.ClusterConditionTypeReady,
@@ -248,7 +268,7 @@ func (c *Controller) processCapacityTargetOnCluster(
 				InProgress,
 				"",
 			)
-			return shippererrors.NewCapacityInProgressError(ct.Name)
+			return 0, shippererrors.NewCapacityInProgressError(ct.Name)
 		}
 	}
 
@@ -263,7 +283,7 @@ func (c *Controller) processCapacityTargetOnCluster(
 			"",
 		)
 
-		return shippererrors.NewCapacityInProgressError(ct.Name)
+		return 0, shippererrors.NewCapacityInProgressError(ct.Name)
 	}
 
 	// If the number of available replicas matches what we want, the
@@ -276,7 +296,7 @@ func (c *Controller) processCapacityTargetOnCluster(
 			"",
 		)
 
-		return nil
+		return 0, nil
 	}
 
 	// Not all pods are availble, so we know for sure this cluster isn't
@@ -289,6 +309,11 @@ func (c *Controller) processCapacityTargetOnCluster(
 		sadPods = sadPods[:SadPodLimit]
 	}
 
+	// Sad pods are truncated and only tell the first condition each pod
+	// is failing, so unschedulable pods are counted from the pods
+	// themselves.
+	unschedulablePods := capacityutil.CountUnschedulablePods(pods)
+
 	replicaFailureCond := getDeploymentCondition(deployment.Status, appsv1.DeploymentReplicaFailure)
 	progressingCond := getDeploymentCondition(deployment.Status, appsv1.DeploymentProgressing)
 
@@ -331,13 +356,13 @@ func (c *Controller) processCapacityTargetOnCluster(
 	)
 
 	if reason == InProgress {
-		return shippererrors.NewCapacityInProgressError(ct.Name)
+		return unschedulablePods, shippererrors.NewCapacityInProgressError(ct.Name)
 	}
 
-	return nil
+	return unschedulablePods, nil
 }
 
-func (c *Controller) capacityTargetSyncHandler(key string) error {
+func (c *Controller) capacityTargetSyncHandler(ctx context.Context, key string) error {
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
 		return shippererrors.NewUnrecoverableError(err)
@@ -354,9 +379,14 @@ func (c *Controller) capacityTargetSyncHandler(key string) error {
 			WithShipperKind("CapacityTarget")
 	}
 
-	ct, err := c.processCapacityTarget(initialCT.DeepCopy())
+	ct, err := c.processCapacityTarget(ctx, initialCT.DeepCopy())
 
 	if !reflect.DeepEqual(initialCT, ct) {
+		if err := c.writeBudget.Wait(ctx); err != nil {
+			return shippererrors.NewKubeclientUpdateError(ct, err).
+				WithShipperKind("CapacityTarget")
+		}
+
 		_, err := c.shipperclientset.ShipperV1alpha1().CapacityTargets(namespace).
 			UpdateStatus(ct)
 		if err != nil {
@@ -368,7 +398,7 @@ func (c *Controller) capacityTargetSyncHandler(key string) error {
 	return err
 }
 
-func (c *Controller) processCapacityTarget(ct *shipper.CapacityTarget) (*shipper.CapacityTarget, error) {
+func (c *Controller) processCapacityTarget(ctx context.Context, ct *shipper.CapacityTarget) (*shipper.CapacityTarget, error) {
 	diff := diffutil.NewMultiDiff()
 	defer c.reportConditionChange(ct, CapacityTargetConditionChanged, diff)
 
@@ -385,6 +415,7 @@ func (c *Controller) processCapacityTarget(ct *shipper.CapacityTarget) (*shipper
 		curClusterStatuses[clusterStatus.Name] = clusterStatus
 	}
 
+	unschedulablePods := make(map[string]int, len(ct.Spec.Clusters))
 	for _, clusterSpec := range ct.Spec.Clusters {
 		clusterStatus, ok := curClusterStatuses[clusterSpec.Name]
 		if !ok {
@@ -393,7 +424,8 @@ func (c *Controller) processCapacityTarget(ct *shipper.CapacityTarget) (*shipper
 			}
 		}
 
-		err := c.processCapacityTargetOnCluster(ct, &clusterSpec, &clusterStatus)
+		unschedulable, err := c.processCapacityTargetOnCluster(ctx, ct, &clusterSpec, &clusterStatus)
+		unschedulablePods[clusterSpec.Name] = unschedulable
 		if err != nil {
 			clusterErrors.Append(err)
 		}
@@ -406,6 +438,8 @@ func (c *Controller) processCapacityTarget(ct *shipper.CapacityTarget) (*shipper
 	ct.Status.Clusters = newClusterStatuses
 	ct.Status.ObservedGeneration = ct.Generation
 
+	ct.Status.Conditions = transitionCapacityStarvation(diff, ct, unschedulablePods)
+
 	notReadyReasons := []string{}
 	for _, clusterStatus := range ct.Status.Clusters {
 		ready, reason := clusterstatusutil.IsClusterCapacityReady(clusterStatus.Conditions)
@@ -426,6 +460,44 @@ func (c *Controller) processCapacityTarget(ct *shipper.CapacityTarget) (*shipper
 	return ct, clusterErrors.Flatten()
 }
 
+// transitionCapacityStarvation sets the CapacityStarved condition on ct when
+// any of its clusters is starved of capacity, given how many unschedulable
+// pods each of them has, and clears it once none of them is. Capacity
+// targets that have never been starved don't get the condition at all.
+func transitionCapacityStarvation(
+	multidiff *diffutil.MultiDiff,
+	ct *shipper.CapacityTarget,
+	unschedulablePods map[string]int,
+) []shipper.TargetCondition {
+	conditions := ct.Status.Conditions
+
+	starved := capacityutil.DetectCapacityStarvation(ct, unschedulablePods)
+	if len(starved) == 0 {
+		cond := targetutil.GetTargetCondition(conditions, shipper.CapacityTargetConditionTypeCapacityStarved)
+		if cond == nil || cond.Status != corev1.ConditionTrue {
+			return conditions
+		}
+
+		newCond := targetutil.NewTargetCondition(
+			shipper.CapacityTargetConditionTypeCapacityStarved,
+			corev1.ConditionFalse, "", "")
+		conditions, diff := targetutil.SetTargetCondition(conditions, newCond)
+		multidiff.Append(diff)
+
+		return conditions
+	}
+
+	newCond := targetutil.NewTargetCondition(
+		shipper.CapacityTargetConditionTypeCapacityStarved,
+		corev1.ConditionTrue,
+		CapacityStarved,
+		fmt.Sprintf("clusters %v have no room left to schedule pods", starved))
+	conditions, diff := targetutil.SetTargetCondition(conditions, newCond)
+	multidiff.Append(diff)
+
+	return conditions
+}
+
 func (c *Controller) enqueueCapacityTarget(obj interface{}) {
 	key, err := cache.MetaNamespaceKeyFunc(obj)
 	if err != nil {
@@ -496,12 +568,16 @@ func (c Controller) getClusterObjects(cluster, ns, appName, release string) (*ap
 	return deployment, pods, nil
 }
 
-func (c *Controller) patchDeploymentWithReplicaCount(deployment *appsv1.Deployment, clusterName string, replicaCount int32) (*appsv1.Deployment, error) {
+func (c *Controller) patchDeploymentWithReplicaCount(ctx context.Context, deployment *appsv1.Deployment, clusterName string, replicaCount int32) (*appsv1.Deployment, error) {
 	targetClusterClient, err := c.clusterClientStore.GetClient(clusterName, AgentName)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := c.writeBudget.Wait(ctx); err != nil {
+		return nil, shippererrors.NewKubeclientUpdateError(deployment, err)
+	}
+
 	patch := []byte(fmt.Sprintf(`{"spec": {"replicas": %d}}`, replicaCount))
 
 	updatedDeployment, err := targetClusterClient.AppsV1().
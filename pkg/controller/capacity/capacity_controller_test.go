@@ -1,6 +1,7 @@
 package capacity
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"testing"
@@ -13,6 +14,7 @@ import (
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
 	shippertesting "github.com/bookingcom/shipper/pkg/testing"
 	capacityutil "github.com/bookingcom/shipper/pkg/util/capacity"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
 	targetutil "github.com/bookingcom/shipper/pkg/util/target"
 )
 
@@ -267,6 +269,50 @@ func TestCapacityShiftingSadPods(t *testing.T) {
 	)
 }
 
+// TestCapacityStarvationCondition verifies that capacity targets are marked
+// as starved while they have clusters short of replicas because their pods
+// can't be scheduled, and that the condition is cleared once they aren't.
+func TestCapacityStarvationCondition(t *testing.T) {
+	ct := buildCapacityTarget(shippertesting.TestApp, ctName, []shipper.ClusterCapacityTarget{
+		{
+			Name:              clusterA,
+			Percent:           100,
+			TotalReplicaCount: 10,
+		},
+	})
+	ct.Status.Clusters = []shipper.ClusterCapacityStatus{
+		{
+			Name:              clusterA,
+			AvailableReplicas: 6,
+		},
+	}
+
+	diff := diffutil.NewMultiDiff()
+	ct.Status.Conditions = transitionCapacityStarvation(diff, ct, map[string]int{clusterA: 4})
+	cond := targetutil.GetTargetCondition(ct.Status.Conditions, shipper.CapacityTargetConditionTypeCapacityStarved)
+	if cond == nil || cond.Status != corev1.ConditionTrue || cond.Reason != CapacityStarved {
+		t.Fatalf("expected capacity target to be starved, got condition %+v", cond)
+	}
+	if diff.IsEmpty() {
+		t.Errorf("expected starving to be reported as a change")
+	}
+
+	ct.Status.Clusters[0].AvailableReplicas = 10
+
+	diff = diffutil.NewMultiDiff()
+	ct.Status.Conditions = transitionCapacityStarvation(diff, ct, map[string]int{clusterA: 0})
+	cond = targetutil.GetTargetCondition(ct.Status.Conditions, shipper.CapacityTargetConditionTypeCapacityStarved)
+	if cond == nil || cond.Status != corev1.ConditionFalse {
+		t.Fatalf("expected starvation to be cleared, got condition %+v", cond)
+	}
+
+	neverStarved := buildCapacityTarget(shippertesting.TestApp, ctName, ct.Spec.Clusters)
+	neverStarved.Status.Conditions = transitionCapacityStarvation(diffutil.NewMultiDiff(), neverStarved, nil)
+	if cond := targetutil.GetTargetCondition(neverStarved.Status.Conditions, shipper.CapacityTargetConditionTypeCapacityStarved); cond != nil {
+		t.Errorf("expected capacity targets that were never starved not to get the condition, got %+v", cond)
+	}
+}
+
 func runCapacityControllerTest(
 	t *testing.T,
 	objectsByCluster map[string][]runtime.Object,
@@ -347,6 +393,7 @@ func runController(f *shippertesting.ControllerTestFixture) {
 		f.ShipperInformerFactory,
 		f.ClusterClientStore,
 		f.Recorder,
+		nil,
 	)
 
 	stopCh := make(chan struct{})
@@ -354,7 +401,7 @@ func runController(f *shippertesting.ControllerTestFixture) {
 
 	f.Run(stopCh)
 
-	for controller.processNextWorkItem() {
+	for controller.processNextWorkItem(context.Background()) {
 		if controller.workqueue.Len() == 0 {
 			time.Sleep(20 * time.Millisecond)
 		}
@@ -1,6 +1,7 @@
 package installation
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sort"
@@ -18,6 +19,7 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/helm/pkg/proto/hapi/chart"
 	"k8s.io/klog"
@@ -30,6 +32,7 @@ import (
 	"github.com/bookingcom/shipper/pkg/clusterclientstore"
 	shippercontroller "github.com/bookingcom/shipper/pkg/controller"
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	apputil "github.com/bookingcom/shipper/pkg/util/application"
 	clusterstatusutil "github.com/bookingcom/shipper/pkg/util/clusterstatus"
 	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
 	"github.com/bookingcom/shipper/pkg/util/filters"
@@ -74,9 +77,16 @@ type Controller struct {
 	chartFetcher shipperrepo.ChartFetcher
 
 	recorder record.EventRecorder
+
+	// writeBudget is drawn from before every write, to installation
+	// targets and the objects installed in application clusters alike.
+	// It's shared with the other controllers in the process, so together
+	// they don't overwhelm the API server.
+	writeBudget flowcontrol.RateLimiter
 }
 
-// NewController returns a new Installation controller.
+// NewController returns a new Installation controller. Its writes aren't
+// limited when writeBudget is nil.
 func NewController(
 	shipperclientset shipperclient.Interface,
 	shipperInformerFactory shipperinformers.SharedInformerFactory,
@@ -84,8 +94,13 @@ func NewController(
 	dynamicClientBuilderFunc DynamicClientBuilderFunc,
 	chartFetcher shipperrepo.ChartFetcher,
 	recorder record.EventRecorder,
+	writeBudget flowcontrol.RateLimiter,
 ) *Controller {
 
+	if writeBudget == nil {
+		writeBudget = shippercontroller.NewWriteBudget(0, 0)
+	}
+
 	installationTargetInformer := shipperInformerFactory.Shipper().V1alpha1().InstallationTargets()
 	clusterInformer := shipperInformerFactory.Shipper().V1alpha1().Clusters()
 	releaseInformer := shipperInformerFactory.Shipper().V1alpha1().Releases()
@@ -106,6 +121,7 @@ func NewController(
 		workqueue:                 workqueue.NewNamedRateLimitingQueue(shipperworkqueue.NewDefaultControllerRateLimiter(), "installation_controller_installationtargets"),
 		chartFetcher:              chartFetcher,
 		recorder:                  recorder,
+		writeBudget:               writeBudget,
 	}
 
 	installationTargetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -153,8 +169,11 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) {
 		return
 	}
 
+	ctx, cancel := shippercontroller.WorkerContext(stopCh)
+	defer cancel()
+
 	for i := 0; i < threadiness; i++ {
-		go wait.Until(c.runWorker, time.Second, stopCh)
+		go wait.Until(func() { c.runWorker(ctx) }, time.Second, stopCh)
 	}
 
 	klog.V(4).Info("Started Installation controller")
@@ -162,12 +181,12 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) {
 	<-stopCh
 }
 
-func (c *Controller) runWorker() {
-	for c.processNextWorkItem() {
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
 	}
 }
 
-func (c *Controller) processNextWorkItem() bool {
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 	obj, shutdown := c.workqueue.Get()
 	if shutdown {
 		return false
@@ -187,7 +206,7 @@ func (c *Controller) processNextWorkItem() bool {
 	}
 
 	shouldRetry := false
-	err := c.syncHandler(key)
+	err := c.syncHandler(ctx, key)
 
 	if err != nil {
 		shouldRetry = shippererrors.ShouldRetry(err)
@@ -206,7 +225,7 @@ func (c *Controller) processNextWorkItem() bool {
 	return true
 }
 
-func (c *Controller) syncHandler(key string) error {
+func (c *Controller) syncHandler(ctx context.Context, key string) error {
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
 		return shippererrors.NewUnrecoverableError(err)
@@ -223,11 +242,16 @@ func (c *Controller) syncHandler(key string) error {
 			WithShipperKind("InstallationTarget")
 	}
 
-	it, err := c.processInstallationTarget(initialIT.DeepCopy())
+	it, err := c.processInstallationTarget(ctx, initialIT.DeepCopy())
 
 	if !reflect.DeepEqual(initialIT, it) {
 		// NOTE(jgreff): we can't use .UpdateStatus() because we also
 		// need to update .Spec.CanOverride
+		if err := c.writeBudget.Wait(ctx); err != nil {
+			return shippererrors.NewKubeclientUpdateError(it, err).
+				WithShipperKind("InstallationTarget")
+		}
+
 		_, err := c.shipperclientset.ShipperV1alpha1().InstallationTargets(namespace).Update(it)
 		if err != nil {
 			return shippererrors.NewKubeclientUpdateError(it, err).
@@ -296,13 +320,43 @@ func (c *Controller) getInstallationTargetForReleaseAndNamespace(release, namesp
 	return installationTargets[0], nil
 }
 
+// productionServiceSelector returns the selector the production Service of
+// the application it belongs to is picked out of its chart by. Releases can
+// outlive their application, in which case it's the default one.
+func (c *Controller) productionServiceSelector(it *shipper.InstallationTarget) (labels.Selector, error) {
+	appName, ok := it.Labels[shipper.AppLabel]
+	if !ok {
+		return nil, shippererrors.NewUnrecoverableError(fmt.Errorf(
+			"InstallationTarget %q needs a %q label in order to find its application",
+			it.Name, shipper.AppLabel))
+	}
+
+	app, err := c.appLister.Applications(it.Namespace).Get(appName)
+	if kerrors.IsNotFound(err) {
+		return apputil.DefaultProductionServiceSelector(appName), nil
+	} else if err != nil {
+		return nil, shippererrors.NewKubeclientGetError(it.Namespace, appName, err).
+			WithShipperKind("Application")
+	}
+
+	return apputil.GetProductionServiceSelector(app)
+}
+
 // processInstallationTarget attempts to install the related InstallationTarget on
 // all target clusters.
-func (c *Controller) processInstallationTarget(it *shipper.InstallationTarget) (*shipper.InstallationTarget, error) {
+func (c *Controller) processInstallationTarget(ctx context.Context, it *shipper.InstallationTarget) (*shipper.InstallationTarget, error) {
 	diff := diffutil.NewMultiDiff()
 	defer c.reportConditionChange(it, InstallationTargetConditionChanged, diff)
 
-	objects, err := FetchAndRenderChart(c.chartFetcher, it)
+	serviceSelector, err := c.productionServiceSelector(it)
+	if err != nil {
+		it.Status.Conditions = targetutil.TransitionToNotOperational(
+			diff, it.Status.Conditions,
+			InternalError, err.Error())
+		return it, err
+	}
+
+	objects, err := FetchAndRenderChart(c.chartFetcher, it, serviceSelector)
 	if err != nil {
 		it.Status.Conditions = targetutil.TransitionToNotOperational(
 			diff, it.Status.Conditions,
@@ -312,7 +366,7 @@ func (c *Controller) processInstallationTarget(it *shipper.InstallationTarget) (
 
 	it.Status.Conditions = targetutil.TransitionToOperational(diff, it.Status.Conditions)
 
-	installer := NewInstaller(it, objects)
+	installer := NewInstaller(it, objects, c.writeBudget)
 	newClusterStatuses := make([]*shipper.ClusterInstallationStatus, 0, len(it.Spec.Clusters))
 	clusterErrors := shippererrors.NewMultiError()
 
@@ -329,7 +383,7 @@ func (c *Controller) processInstallationTarget(it *shipper.InstallationTarget) (
 			}
 		}
 
-		err := c.processInstallationTargetOnCluster(it, clusterName, clusterStatus, installer)
+		err := c.processInstallationTargetOnCluster(ctx, it, clusterName, clusterStatus, installer)
 		if err != nil {
 			clusterErrors.Append(err)
 		}
@@ -365,6 +419,7 @@ func (c *Controller) processInstallationTarget(it *shipper.InstallationTarget) (
 }
 
 func (c *Controller) processInstallationTargetOnCluster(
+	ctx context.Context,
 	it *shipper.InstallationTarget,
 	clusterName string,
 	status *shipper.ClusterInstallationStatus,
@@ -422,7 +477,7 @@ func (c *Controller) processInstallationTargetOnCluster(
 		"",
 	)
 
-	err = installer.install(cluster, client, restConfig, c.dynamicClientBuilderFunc)
+	err = installer.install(ctx, cluster, client, restConfig, c.dynamicClientBuilderFunc)
 	if err != nil {
 		readyCond = installationutil.NewClusterInstallationCondition(
 			shipper.ClusterConditionTypeReady,
@@ -1,6 +1,7 @@
 package installation
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"testing"
@@ -223,6 +224,7 @@ func runController(f *shippertesting.ControllerTestFixture) {
 		f.DynamicClientBuilder,
 		localFetchChart,
 		f.Recorder,
+		nil,
 	)
 
 	stopCh := make(chan struct{})
@@ -230,7 +232,7 @@ func runController(f *shippertesting.ControllerTestFixture) {
 
 	f.Run(stopCh)
 
-	for controller.processNextWorkItem() {
+	for controller.processNextWorkItem(context.Background()) {
 		if controller.workqueue.Len() == 0 {
 			time.Sleep(20 * time.Millisecond)
 		}
@@ -1,6 +1,7 @@
 package installation
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sort"
@@ -15,8 +16,10 @@ import (
 	"k8s.io/client-go/kubernetes"
 	kubescheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippercontroller "github.com/bookingcom/shipper/pkg/controller"
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
 	"github.com/bookingcom/shipper/pkg/util/anchor"
 )
@@ -28,16 +31,25 @@ type DynamicClientBuilderFunc func(gvk *schema.GroupVersionKind, restConfig *res
 type Installer struct {
 	installationTarget *shipper.InstallationTarget
 	objects            []runtime.Object
+	writeBudget        flowcontrol.RateLimiter
 }
 
-// NewInstaller returns a new Installer.
+// NewInstaller returns a new Installer, drawing from writeBudget before
+// every object it creates or updates. Its writes aren't limited when
+// writeBudget is nil.
 func NewInstaller(
 	it *shipper.InstallationTarget,
 	objects []runtime.Object,
+	writeBudget flowcontrol.RateLimiter,
 ) *Installer {
+	if writeBudget == nil {
+		writeBudget = shippercontroller.NewWriteBudget(0, 0)
+	}
+
 	return &Installer{
 		installationTarget: it,
 		objects:            objects,
+		writeBudget:        writeBudget,
 	}
 }
 
@@ -89,8 +101,10 @@ func (i *Installer) buildResourceClient(
 	}
 }
 
-// install attempts to install the manifests on the specified cluster.
+// install attempts to install the manifests on the specified cluster. It
+// gives up on writes held back by its write budget once ctx is done.
 func (i *Installer) install(
+	ctx context.Context,
 	cluster *shipper.Cluster,
 	client kubernetes.Interface,
 	restConfig *rest.Config,
@@ -107,6 +121,11 @@ func (i *Installer) install(
 		return shippererrors.NewKubeclientGetError(it.Name, configMap.Name, err).
 			WithCoreV1Kind("ConfigMap")
 	} else if err != nil { // errors.IsNotFound(err) == true
+		if err := i.writeBudget.Wait(ctx); err != nil {
+			return shippererrors.NewKubeclientCreateError(configMap, err).
+				WithCoreV1Kind("ConfigMap")
+		}
+
 		createdConfigMap, err = client.CoreV1().ConfigMaps(configMap.Namespace).Create(configMap)
 		if err != nil {
 			return shippererrors.NewKubeclientCreateError(configMap, err).
@@ -167,6 +186,12 @@ func (i *Installer) install(
 		// create the object on the application cluster.
 		if err != nil {
 			obj.SetOwnerReferences([]metav1.OwnerReference{ownerReference})
+			if err := i.writeBudget.Wait(ctx); err != nil {
+				return shippererrors.
+					NewKubeclientCreateError(obj, err).
+					WithKind(gvk)
+			}
+
 			_, err = resourceClient.Create(obj, metav1.CreateOptions{})
 			if err != nil {
 				return shippererrors.
@@ -224,6 +249,11 @@ func (i *Installer) install(
 		unstructured.SetNestedField(existingUnstructuredObj, newUnstructuredObj["spec"], "spec")
 		existingObj.SetUnstructuredContent(existingUnstructuredObj)
 
+		if err := i.writeBudget.Wait(ctx); err != nil {
+			return shippererrors.NewKubeclientUpdateError(obj, err).
+				WithKind(gvk)
+		}
+
 		if _, err := resourceClient.Update(existingObj, metav1.UpdateOptions{}); err != nil {
 			return shippererrors.NewKubeclientUpdateError(obj, err).
 				WithKind(gvk)
@@ -1,12 +1,15 @@
 package installation
 
 import (
+	"context"
+	"fmt"
 	"regexp"
 	"testing"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	kubescheme "k8s.io/client-go/kubernetes/scheme"
@@ -17,17 +20,18 @@ import (
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
 	shippertesting "github.com/bookingcom/shipper/pkg/testing"
 	"github.com/bookingcom/shipper/pkg/util/anchor"
+	apputil "github.com/bookingcom/shipper/pkg/util/application"
 )
 
 var restConfig *rest.Config
 
 func newInstaller(it *shipper.InstallationTarget) (*Installer, error) {
-	objects, err := FetchAndRenderChart(localFetchChart, it)
+	objects, err := FetchAndRenderChart(localFetchChart, it, apputil.DefaultProductionServiceSelector(it.Labels[shipper.AppLabel]))
 	if err != nil {
 		return nil, err
 	}
 
-	return NewInstaller(it, objects), nil
+	return NewInstaller(it, objects, nil), nil
 }
 
 // TestInstaller tests the installation process using a Installer directly.
@@ -75,7 +79,7 @@ func ImplTestInstaller(t *testing.T, kubeObjects []runtime.Object) {
 		kubetesting.NewCreateAction(schema.GroupVersionResource{Resource: "deployments", Version: "v1", Group: "apps"}, testNs, nil),
 	}
 
-	if err := installer.install(cluster, fakeCluster.Client, restConfig, f.DynamicClientBuilder); err != nil {
+	if err := installer.install(context.Background(), cluster, fakeCluster.Client, restConfig, f.DynamicClientBuilder); err != nil {
 		t.Fatal(err)
 	}
 
@@ -252,7 +256,7 @@ func TestInstallerServiceWithNoSelector(t *testing.T) {
 		shippertesting.NewDiscoveryAction("deployments"),
 	}
 
-	if err := installer.install(cluster, fakeCluster.Client, restConfig, f.DynamicClientBuilder); err != nil {
+	if err := installer.install(context.Background(), cluster, fakeCluster.Client, restConfig, f.DynamicClientBuilder); err != nil {
 		t.Fatal(err)
 	}
 
@@ -341,7 +345,7 @@ func TestInstallerSingleServiceNoLB(t *testing.T) {
 		shippertesting.NewDiscoveryAction("deployments"),
 	}
 
-	if err := installer.install(cluster, fakeCluster.Client, restConfig, f.DynamicClientBuilder); err != nil {
+	if err := installer.install(context.Background(), cluster, fakeCluster.Client, restConfig, f.DynamicClientBuilder); err != nil {
 		t.Fatal(err)
 	}
 
@@ -389,7 +393,7 @@ func TestInstallerSingleServiceWithLB(t *testing.T) {
 		shippertesting.NewDiscoveryAction("deployments"),
 	}
 
-	if err := installer.install(cluster, fakeCluster.Client, restConfig, f.DynamicClientBuilder); err != nil {
+	if err := installer.install(context.Background(), cluster, fakeCluster.Client, restConfig, f.DynamicClientBuilder); err != nil {
 		t.Fatal(err)
 	}
 
@@ -458,7 +462,7 @@ func TestInstallerMultiServiceWithLB(t *testing.T) {
 		shippertesting.NewDiscoveryAction("deployments"),
 	}
 
-	if err := installer.install(cluster, fakeCluster.Client, restConfig, f.DynamicClientBuilder); err != nil {
+	if err := installer.install(context.Background(), cluster, fakeCluster.Client, restConfig, f.DynamicClientBuilder); err != nil {
 		t.Fatal(err)
 	}
 
@@ -511,7 +515,7 @@ func TestInstallerMultiServiceWithLBOffTheShelf(t *testing.T) {
 		shippertesting.NewDiscoveryAction("deployments"),
 	}
 
-	if err := installer.install(cluster, fakeCluster.Client, restConfig, f.DynamicClientBuilder); err != nil {
+	if err := installer.install(context.Background(), cluster, fakeCluster.Client, restConfig, f.DynamicClientBuilder); err != nil {
 		t.Fatal(err)
 	}
 
@@ -594,10 +598,63 @@ func TestInstallerNoOverride(t *testing.T) {
 	}
 	fakeCluster := f.Clusters[cluster.Name]
 
-	if err := installer.install(cluster, fakeCluster.Client, restConfig, f.DynamicClientBuilder); err != nil {
+	if err := installer.install(context.Background(), cluster, fakeCluster.Client, restConfig, f.DynamicClientBuilder); err != nil {
 		t.Fatal(err)
 	}
 
 	shippertesting.ShallowCheckActions(expectedActions, fakeCluster.Client.Actions(), t)
 	shippertesting.ShallowCheckActions(expectedDynamicActions, fakeCluster.DynamicClient.Actions(), t)
 }
+
+// TestPrepareObjectsCustomServiceSelector verifies that the production
+// Service is picked by the selector of the application rather than by the
+// default one, and ends up labeled so it matches it.
+func TestPrepareObjectsCustomServiceSelector(t *testing.T) {
+	appName := "reviews-api"
+	chart := buildChart(appName, "0.0.1", repoUrl)
+	it := buildInstallationTarget("reviews-api", appName, []string{"minikube-a"}, &chart)
+
+	manifests := []string{
+		serviceManifest("reviews-api-canary", "tier: canary"),
+		serviceManifest("reviews-api-public", "tier: public"),
+	}
+
+	selector, err := labels.Parse("tier=public")
+	if err != nil {
+		t.Fatalf("unexpected error parsing selector: %s", err)
+	}
+
+	objects, err := prepareObjects(it, manifests, selector)
+	if err != nil {
+		t.Fatalf("unexpected error preparing objects: %s", err)
+	}
+
+	for _, obj := range objects {
+		svc := obj.(*corev1.Service)
+		_, patched := svc.Spec.Selector[shipper.PodTrafficStatusLabel]
+		if expected := svc.Name == "reviews-api-public"; patched != expected {
+			t.Errorf("expected service %q to be patched as the production one: %t, got %t",
+				svc.Name, expected, patched)
+		}
+	}
+
+	// The default selector doesn't match either of them, and there's
+	// more than one to pick from.
+	defaultSelector := apputil.DefaultProductionServiceSelector(appName)
+	if _, err := prepareObjects(it, manifests, defaultSelector); err == nil {
+		t.Fatal("expected an error preparing objects with the default selector, got none")
+	}
+}
+
+func serviceManifest(name, label string) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Service
+metadata:
+  name: %s
+  labels:
+    %s
+spec:
+  ports:
+  - port: 80
+`, name, label)
+}
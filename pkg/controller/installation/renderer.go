@@ -13,6 +13,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
 	kubescheme "k8s.io/client-go/kubernetes/scheme"
 )
 
@@ -22,9 +23,14 @@ type kubeobj interface {
 	SetLabels(map[string]string)
 }
 
+// FetchAndRenderChart renders the chart of it into the objects to install in
+// its clusters. serviceSelector picks the production Service of the
+// application out of the ones in the chart, as returned by
+// apputil.GetProductionServiceSelector.
 func FetchAndRenderChart(
 	chartFetcher shipperrepo.ChartFetcher,
 	it *shipper.InstallationTarget,
+	serviceSelector labels.Selector,
 ) ([]runtime.Object, error) {
 	chart, err := chartFetcher(it.Spec.Chart)
 	if err != nil {
@@ -42,10 +48,10 @@ func FetchAndRenderChart(
 		return nil, shippererrors.NewRenderManifestError(err)
 	}
 
-	return prepareObjects(it, manifests)
+	return prepareObjects(it, manifests, serviceSelector)
 }
 
-func prepareObjects(it *shipper.InstallationTarget, manifests []string) ([]runtime.Object, error) {
+func prepareObjects(it *shipper.InstallationTarget, manifests []string, serviceSelector labels.Selector) ([]runtime.Object, error) {
 	shipperLabels := labels.Merge(labels.Set(it.Labels), labels.Set{
 		shipper.InstallationTargetOwnerLabel: it.Name,
 	})
@@ -86,13 +92,6 @@ func prepareObjects(it *shipper.InstallationTarget, manifests []string) ([]runti
 			}
 
 			decodedObj = patchDeployment(obj, shipperLabels)
-		case *corev1.Service:
-			allServices = append(allServices, obj)
-
-			lbValue, ok := obj.Labels[shipper.LBLabel]
-			if ok && lbValue == shipper.LBForProduction {
-				productionLBServices = append(productionLBServices, obj)
-			}
 		}
 
 		obj := decodedObj.(kubeobj)
@@ -101,11 +100,22 @@ func prepareObjects(it *shipper.InstallationTarget, manifests []string) ([]runti
 			shipperLabels,
 		))
 
+		// Services are matched once they have the labels shipper
+		// stamps on every object, as the application label is part
+		// of the default production service selector.
+		if svc, ok := decodedObj.(*corev1.Service); ok {
+			allServices = append(allServices, svc)
+
+			if serviceSelector.Matches(labels.Set(svc.Labels)) {
+				productionLBServices = append(productionLBServices, svc)
+			}
+		}
+
 		preparedObjects = append(preparedObjects, obj)
 	}
 
-	// If we have observed only 1 Service object and it was not marked with
-	// shipper-lb=production label, we can do it ourselves.
+	// If we have observed only 1 Service object and it was not marked as
+	// the production one, we can do it ourselves.
 	if len(productionLBServices) == 0 && len(allServices) == 1 {
 		productionLBServices = allServices
 	}
@@ -116,11 +126,11 @@ func prepareObjects(it *shipper.InstallationTarget, manifests []string) ([]runti
 	if len(productionLBServices) != 1 {
 		return nil, shippererrors.NewInvalidChartError(
 			fmt.Sprintf(
-				"one and only one v1.Service object with label %q is required, but %d found instead",
-				shipper.LBLabel, len(productionLBServices)))
+				"one and only one v1.Service object matching %q is required, but %d found instead",
+				serviceSelector.String(), len(productionLBServices)))
 	}
 
-	err := patchService(it, productionLBServices[0])
+	err := patchService(it, productionLBServices[0], serviceSelector)
 	if err != nil {
 		return nil, err
 	}
@@ -155,7 +165,10 @@ func patchDeployment(d *appsv1.Deployment, labelsToInject map[string]string) run
 	return d
 }
 
-func patchService(it *shipper.InstallationTarget, s *corev1.Service) error {
+// patchService makes s the production Service of the application, and
+// labels it so serviceSelector finds it even if it wasn't labeled as such in
+// the chart.
+func patchService(it *shipper.InstallationTarget, s *corev1.Service, serviceSelector labels.Selector) error {
 	if relName, ok := s.Spec.Selector[shipper.HelmReleaseLabel]; ok {
 		v, ok := it.Labels[shipper.HelmWorkaroundLabel]
 		if ok && v == shipper.True {
@@ -179,7 +192,9 @@ func patchService(it *shipper.InstallationTarget, s *corev1.Service) error {
 		}
 	}
 
-	s.Labels[shipper.LBLabel] = shipper.LBForProduction
+	for key, value := range selectorLabels(serviceSelector) {
+		s.Labels[key] = value
+	}
 
 	// Make sure service selector is safely defined
 	if s.Spec.Selector == nil {
@@ -190,3 +205,22 @@ func patchService(it *shipper.InstallationTarget, s *corev1.Service) error {
 
 	return nil
 }
+
+// selectorLabels returns the labels an object needs to have for selector
+// to match it, as far as they can be told from the selector: those in its
+// equality requirements, and in its set based ones with a single value.
+func selectorLabels(selector labels.Selector) labels.Set {
+	set := labels.Set{}
+
+	requirements, _ := selector.Requirements()
+	for _, req := range requirements {
+		switch req.Operator() {
+		case selection.Equals, selection.DoubleEquals, selection.In:
+			if values := req.Values(); values.Len() == 1 {
+				set[req.Key()] = values.List()[0]
+			}
+		}
+	}
+
+	return set
+}
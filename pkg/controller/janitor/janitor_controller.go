@@ -268,8 +268,34 @@ func (c *Controller) removeAnchor(clusterName string, namespace string, name str
 }
 
 func (c *Controller) syncInstallationTarget(item *InstallationTargetWorkItem) error {
+	releaseName, ok := item.ObjectMeta.Labels[shipper.ReleaseLabel]
+	if !ok {
+		releaseName = item.Name
+	}
+
 	for _, clusterName := range item.Clusters {
 		installationTarget := &shipper.InstallationTarget{ObjectMeta: item.ObjectMeta}
+
+		// Pods of the release can outlive it for a while before they
+		// are garbage collected, so they stop getting traffic before
+		// anything else happens.
+		if client, err := c.clusterClientStore.GetClient(clusterName, AgentName); err != nil {
+			return err
+		} else if n, err := disableOrphanedPodTraffic(client, item.Namespace, releaseName); err != nil {
+			c.recorder.Eventf(installationTarget,
+				corev1.EventTypeWarning,
+				"PodTrafficDisableFailed",
+				"Failed to disable traffic to pods of release %q in cluster %q: %s",
+				releaseName, clusterName, err)
+			return err
+		} else if n > 0 {
+			c.recorder.Eventf(installationTarget,
+				corev1.EventTypeNormal,
+				"PodTrafficDisabled",
+				"Disabled traffic to %d pods of release %q left in cluster %q",
+				n, releaseName, clusterName)
+		}
+
 		if ok, err := c.removeAnchor(clusterName, item.Namespace, item.AnchorName); err != nil {
 			c.recorder.Eventf(installationTarget,
 				corev1.EventTypeWarning,
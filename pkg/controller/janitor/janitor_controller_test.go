@@ -1,10 +1,12 @@
 package janitor
 
 import (
+	"fmt"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	kubetesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
@@ -54,6 +56,54 @@ func TestSuccessfulDeleteInstallationTarget(t *testing.T) {
 	shippertesting.CheckActions(expectedActions, actual, t)
 }
 
+// TestDeleteInstallationTargetDisablesOrphanedPodTraffic checks that pods of
+// a deleted release that are still labeled to receive traffic stop getting
+// it, leaving pods of other releases alone.
+func TestDeleteInstallationTargetDisablesOrphanedPodTraffic(t *testing.T) {
+	installationTarget := buildInstallationTarget()
+	releaseName := installationTarget.Labels[shipper.ReleaseLabel]
+
+	f := shippertesting.NewControllerTestFixture()
+	cluster := f.AddNamedCluster(shippertesting.TestCluster)
+
+	orphaned := buildPod("orphaned", releaseName, shipper.Enabled)
+	alreadyDisabled := buildPod("already-disabled", releaseName, shipper.Disabled)
+	otherRelease := buildPod("other-release", "other-release", shipper.Enabled)
+	cluster.AddMany([]runtime.Object{orphaned, alreadyDisabled, otherRelease})
+
+	c := runController(f)
+
+	item := &InstallationTargetWorkItem{
+		ObjectMeta: *installationTarget.ObjectMeta.DeepCopy(),
+		AnchorName: anchor.CreateAnchorName(installationTarget),
+		Clusters:   installationTarget.Spec.Clusters,
+		Key:        fmt.Sprintf("%s/%s", installationTarget.Namespace, installationTarget.Name),
+		Name:       installationTarget.Name,
+		Namespace:  installationTarget.Namespace,
+	}
+
+	if err := c.syncInstallationTarget(item); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		orphaned.Name:        shipper.Disabled,
+		alreadyDisabled.Name: shipper.Disabled,
+		otherRelease.Name:    shipper.Enabled,
+	}
+
+	for name, value := range expected {
+		pod, err := cluster.Client.CoreV1().Pods(shippertesting.TestNamespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("failed to get pod %q: %s", name, err)
+		}
+
+		if actual := pod.Labels[shipper.PodTrafficStatusLabel]; actual != value {
+			t.Errorf("expected pod %q to have traffic status %q, got %q", name, value, actual)
+		}
+	}
+}
+
 // TestDeleteConfigMapAnchorInstallationTargetMatch should not delete anything,
 // since the installation target object's UID matches the anchor config map
 // synced from an application cluster.
@@ -201,6 +251,19 @@ func runController(f *shippertesting.ControllerTestFixture) *Controller {
 	return c
 }
 
+func buildPod(name, releaseName, trafficStatus string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: shippertesting.TestNamespace,
+			Name:      name,
+			Labels: map[string]string{
+				shipper.ReleaseLabel:          releaseName,
+				shipper.PodTrafficStatusLabel: trafficStatus,
+			},
+		},
+	}
+}
+
 func buildInstallationTarget() *shipper.InstallationTarget {
 	return &shipper.InstallationTarget{
 		ObjectMeta: metav1.ObjectMeta{
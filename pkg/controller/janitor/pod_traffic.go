@@ -0,0 +1,54 @@
+package janitor
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+// disableOrphanedPodTraffic takes traffic away from the pods of a release
+// that is gone but whose pods are still labeled to receive traffic, as they
+// can linger for a while before the objects they belong to are garbage
+// collected. It returns the number of pods it disabled.
+func disableOrphanedPodTraffic(client kubernetes.Interface, namespace, releaseName string) (int, error) {
+	selector := labels.Set{
+		shipper.ReleaseLabel:          releaseName,
+		shipper.PodTrafficStatusLabel: shipper.Enabled,
+	}.AsSelector()
+
+	pods, err := client.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return 0, shippererrors.NewKubeclientListError(
+			corev1.SchemeGroupVersion.WithKind("Pod"),
+			namespace, selector, err)
+	}
+
+	patch := []byte(fmt.Sprintf(`{"metadata":{"labels":{%q:%q}}}`,
+		shipper.PodTrafficStatusLabel, shipper.Disabled))
+
+	disabled := 0
+	for _, pod := range pods.Items {
+		_, err := client.CoreV1().Pods(namespace).Patch(pod.Name, types.MergePatchType, patch)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+
+			return disabled, shippererrors.
+				NewKubeclientPatchError(namespace, pod.Name, err).
+				WithCoreV1Kind("Pod")
+		}
+
+		disabled++
+	}
+
+	return disabled, nil
+}
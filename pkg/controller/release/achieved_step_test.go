@@ -0,0 +1,94 @@
+package release
+
+import (
+	gocontext "context"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+)
+
+// TestAchievedStepAdvancesWithStrategy walks a contender through every
+// step of its strategy, converging its targets each time, and checks the
+// achieved step on its status follows along, name included.
+func TestAchievedStepAdvancesWithStrategy(t *testing.T) {
+	var status shipper.ReleaseStatus
+	for i, step := range vanguard.Steps {
+		rel := executeStrategyOnConvergedStep(t, int32(i), status)
+
+		achieved := rel.Status.AchievedStep
+		if achieved == nil {
+			t.Fatalf("expected step %d to be achieved, got no achieved step", i)
+		}
+
+		if achieved.Step != int32(i) || achieved.Name != step.Name {
+			t.Errorf("expected achieved step to be %d (%q), got %d (%q)",
+				i, step.Name, achieved.Step, achieved.Name)
+		}
+
+		status = rel.Status
+	}
+}
+
+// TestAchievedStepNameFollowsStrategy checks that renaming a step after
+// a release achieved it is reflected on its status.
+func TestAchievedStepNameFollowsStrategy(t *testing.T) {
+	status := shipper.ReleaseStatus{
+		AchievedStep: &shipper.AchievedStep{Step: 1, Name: "half and half"},
+	}
+
+	rel := executeStrategyOnConvergedStep(t, 1, status)
+
+	expected := vanguard.Steps[1].Name
+	if achieved := rel.Status.AchievedStep; achieved == nil || achieved.Name != expected {
+		t.Errorf("expected achieved step to be named %q, got %v", expected, achieved)
+	}
+}
+
+func executeStrategyOnConvergedStep(t *testing.T, targetStep int32, status shipper.ReleaseStatus) *shipper.Release {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+	step := vanguard.Steps[targetStep]
+	contender := f.buildContender(namespace, "test-contender", 10)
+	contender.release.Spec.TargetStep = targetStep
+	if status.AchievedStep != nil {
+		contender.release.Status.AchievedStep = status.AchievedStep.DeepCopy()
+	}
+	contender.capacityTarget.Spec.Clusters[0].Percent = step.Capacity.Contender
+	contender.trafficTarget.Spec.Clusters[0].Weight = uint32(step.Traffic.Contender)
+
+	f.addObjects(
+		contender.release.DeepCopy(),
+		contender.installationTarget.DeepCopy(),
+		contender.capacityTarget.DeepCopy(),
+		contender.trafficTarget.DeepCopy(),
+	)
+
+	f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.informerFactory.Start(stopCh)
+	f.informerFactory.WaitForCacheSync(stopCh)
+
+	rel, _, _, err := c.executeReleaseStrategy(gocontext.Background(), contender, diffutil.NewMultiDiff())
+	if err != nil {
+		t.Fatalf("unexpected error executing strategy: %s", err)
+	}
+
+	return rel
+}
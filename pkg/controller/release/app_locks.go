@@ -0,0 +1,91 @@
+package release
+
+import (
+	gocontext "context"
+	"fmt"
+	"sync"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+// appLocks hands out a mutex per application, so releases of the same
+// application are never reconciled at the same time, no matter whether
+// they come out of the workqueue or from ReconcileOnce. Mutexes are only
+// kept around while someone holds or waits for them.
+type appLocks struct {
+	mu    sync.Mutex
+	locks map[string]*appLock
+}
+
+type appLock struct {
+	sync.Mutex
+	refs int
+}
+
+func newAppLocks() *appLocks {
+	return &appLocks{
+		locks: make(map[string]*appLock),
+	}
+}
+
+// Lock blocks until the lock for appKey is free and takes it. The returned
+// func releases it.
+func (l *appLocks) Lock(appKey string) func() {
+	l.mu.Lock()
+	lock, ok := l.locks[appKey]
+	if !ok {
+		lock = &appLock{}
+		l.locks[appKey] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		lock.refs--
+		if lock.refs == 0 {
+			delete(l.locks, appKey)
+		}
+	}
+}
+
+// lockApplicationForRelease takes the lock of the application the release
+// identified by key belongs to. Releases that can't be found or don't
+// belong to an application are locked on their own.
+func (c *Controller) lockApplicationForRelease(key string) func() {
+	lockKey := key
+	if namespace, name, err := cache.SplitMetaNamespaceKey(key); err == nil {
+		if rel, err := c.releaseLister.Releases(namespace).Get(name); err == nil {
+			if appKey, err := c.getAssociatedApplicationKey(rel); err == nil {
+				lockKey = appKey
+			}
+		}
+	}
+
+	return c.appLocks.Lock(lockKey)
+}
+
+// ReconcileOnce synchronously reconciles the release identified by
+// namespace and name, bypassing the workqueue, and returns whatever error
+// the reconcile ran into. It's safe to call while the controller is
+// running, as it waits for any other reconcile of a release of the same
+// application to be over first. The release is reconciled even if nothing
+// changed since the last time it was, but only if its namespace is in the
+// shard of this controller, as another one might be reconciling it
+// otherwise.
+func (c *Controller) ReconcileOnce(namespace, name string) error {
+	if !c.shard.Owns(namespace) {
+		return fmt.Errorf("namespace %q is not in the shard of this release controller", namespace)
+	}
+
+	key := namespace + "/" + name
+	c.observedTargets.Forget(key)
+
+	return c.syncOneReleaseHandler(gocontext.Background(), key)
+}
@@ -0,0 +1,95 @@
+package release
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+const (
+	MinBakeTime = "MinBakeTime"
+)
+
+// bakeStep returns how much longer rel has to keep working on step before
+// it's allowed to achieve it, according to the step's MinBakeTime, and
+// keeps the Baking condition of rel up to date with it. Steps are baked
+// from the moment rel started working on them.
+func bakeStep(rel *shipper.Release, step int32, strategyStep shipper.RolloutStrategyStep, diff *diffutil.MultiDiff) time.Duration {
+	if strategyStep.MinBakeTime == nil || strategyStep.MinBakeTime.Duration <= 0 {
+		if releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeBaking) != nil {
+			condition := releaseutil.NewReleaseCondition(
+				shipper.ReleaseConditionTypeBaking,
+				corev1.ConditionFalse,
+				"",
+				"",
+			)
+			diff.Append(releaseutil.SetReleaseCondition(&rel.Status, *condition))
+		}
+		return 0
+	}
+
+	// Steps that were already achieved are done baking.
+	if cond := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeStepAchieved); cond != nil &&
+		cond.Status == corev1.ConditionTrue && cond.Step == step {
+		return 0
+	}
+
+	recordStepStarted(rel, step, diff)
+
+	started, ok := stepStartedAt(rel, step)
+	if !ok {
+		return 0
+	}
+
+	minBakeTime := strategyStep.MinBakeTime.Duration
+	remaining := minBakeTime - releaseutil.Clock.Since(started)
+
+	var condition *shipper.ReleaseCondition
+	if remaining > 0 {
+		// Remaining time is rounded up to the minute, so the
+		// condition doesn't change every time the release is synced.
+		condition = releaseutil.NewReleaseCondition(
+			shipper.ReleaseConditionTypeBaking,
+			corev1.ConditionTrue,
+			MinBakeTime,
+			fmt.Sprintf("step [%d] has %s left to bake", step, (remaining+time.Minute-1).Truncate(time.Minute)),
+		)
+	} else {
+		remaining = 0
+		condition = releaseutil.NewReleaseCondition(
+			shipper.ReleaseConditionTypeBaking,
+			corev1.ConditionFalse,
+			MinBakeTime,
+			fmt.Sprintf("step [%d] baked for %s", step, minBakeTime),
+		)
+	}
+	diff.Append(releaseutil.SetReleaseCondition(&rel.Status, *condition))
+
+	return remaining
+}
+
+// stepStartedAt returns when rel started working on step, as recorded in
+// its StepAchieved condition. Releases that haven't achieved any step yet
+// started working on whatever their first step is when they were
+// scheduled.
+func stepStartedAt(rel *shipper.Release, step int32) (time.Time, bool) {
+	cond := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeStepAchieved)
+	if cond == nil {
+		scheduled := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeScheduled)
+		if scheduled == nil || scheduled.Status != corev1.ConditionTrue {
+			return time.Time{}, false
+		}
+		return scheduled.LastTransitionTime.Time, true
+	}
+
+	if !isWorkingOnStep(cond, step) {
+		return time.Time{}, false
+	}
+
+	return cond.LastTransitionTime.Time, true
+}
@@ -0,0 +1,130 @@
+package release
+
+import (
+	gocontext "context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+// TestStepBakesBeforeBeingAchieved runs the strategy for a contender that
+// converged on the first step of vanguard, which asks for a 10 minute bake,
+// and checks it's only achieved once the bake time is over, and that it
+// asks to be synced again by then.
+func TestStepBakesBeforeBeingAchieved(t *testing.T) {
+	scheduledAt := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		elapsed         time.Duration
+		achieved        bool
+		expectedBaking  corev1.ConditionStatus
+		expectedMessage string
+		expectedRequeue time.Duration
+	}{
+		{
+			name:            "half way through baking",
+			elapsed:         5 * time.Minute,
+			achieved:        false,
+			expectedBaking:  corev1.ConditionTrue,
+			expectedMessage: "step [0] has 5m0s left to bake",
+			expectedRequeue: 5 * time.Minute,
+		},
+		{
+			name:            "almost done baking",
+			elapsed:         9*time.Minute + 30*time.Second,
+			achieved:        false,
+			expectedBaking:  corev1.ConditionTrue,
+			expectedMessage: "step [0] has 1m0s left to bake",
+			expectedRequeue: 30 * time.Second,
+		},
+		{
+			name:            "done baking",
+			elapsed:         11 * time.Minute,
+			achieved:        true,
+			expectedBaking:  corev1.ConditionFalse,
+			expectedMessage: "step [0] baked for 10m0s",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			releaseutil.Clock = clock.NewFakeClock(scheduledAt.Add(test.elapsed))
+			defer func() { releaseutil.Clock = clock.RealClock{} }()
+
+			namespace := "test-namespace"
+			app := buildApplication(namespace, "test-app")
+			cluster := buildCluster("minikube")
+
+			f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+			strategy := vanguard.DeepCopy()
+			strategy.Steps[0].MinBakeTime = &metav1.Duration{Duration: 10 * time.Minute}
+
+			contender := f.buildContender(namespace, "test-contender", 10)
+			contender.release.Spec.Environment.Strategy = strategy
+			contender.release.Status.Conditions = []shipper.ReleaseCondition{
+				{
+					Type:               shipper.ReleaseConditionTypeScheduled,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(scheduledAt),
+				},
+			}
+			contender.capacityTarget.Spec.Clusters[0].Percent = 1
+
+			f.addObjects(
+				contender.release.DeepCopy(),
+				contender.installationTarget.DeepCopy(),
+				contender.capacityTarget.DeepCopy(),
+				contender.trafficTarget.DeepCopy(),
+			)
+
+			f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+			f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+			f.recorder = record.NewFakeRecorder(42)
+
+			c := f.newController()
+			defer c.releaseWorkqueue.ShutDown()
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			f.informerFactory.Start(stopCh)
+			f.informerFactory.WaitForCacheSync(stopCh)
+
+			rel, _, requeueAfter, err := c.executeReleaseStrategy(gocontext.Background(), contender, diffutil.NewMultiDiff())
+			if err != nil {
+				t.Fatalf("unexpected error executing strategy: %s", err)
+			}
+
+			if requeueAfter != test.expectedRequeue {
+				t.Errorf("expected release to be requeued in %s, got %s", test.expectedRequeue, requeueAfter)
+			}
+
+			if achieved := rel.Status.AchievedStep != nil; achieved != test.achieved {
+				t.Errorf("expected step to be achieved: %t, got %t", test.achieved, achieved)
+			}
+
+			baking := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeBaking)
+			if baking == nil {
+				t.Fatalf("expected release to have a %s condition", shipper.ReleaseConditionTypeBaking)
+			}
+
+			if baking.Status != test.expectedBaking || baking.Message != test.expectedMessage {
+				t.Errorf("expected %s condition to be %s with message %q, got %s with %q",
+					shipper.ReleaseConditionTypeBaking, test.expectedBaking, test.expectedMessage,
+					baking.Status, baking.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package release
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// TestStepCapacitySpec checks a contender going from 10% to 100% of 10
+// replicas in two clusters only gets as many more replicas as the headroom
+// of each of them allows, and only once it's achieved what it has.
+func TestStepCapacitySpec(t *testing.T) {
+	const clusterA, clusterB = "cluster-a", "cluster-b"
+
+	tests := []struct {
+		name     string
+		headroom map[string]int
+		ready    bool
+		expected map[string]int32
+	}{
+		{
+			name:     "unlimited",
+			ready:    true,
+			expected: map[string]int32{clusterA: 100, clusterB: 100},
+		},
+		{
+			name:     "limited in a single cluster",
+			headroom: map[string]int{clusterA: 4},
+			ready:    true,
+			expected: map[string]int32{clusterA: 50, clusterB: 100},
+		},
+		{
+			name:     "headroom big enough for the whole step",
+			headroom: map[string]int{clusterA: 9, clusterB: 20},
+			ready:    true,
+			expected: map[string]int32{clusterA: 100, clusterB: 100},
+		},
+		{
+			name:     "unlimited before achieving capacity",
+			ready:    false,
+			expected: map[string]int32{clusterA: 100, clusterB: 100},
+		},
+		{
+			name:     "limited before achieving capacity",
+			headroom: map[string]int{clusterA: 4},
+			ready:    false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status := corev1.ConditionTrue
+			if !test.ready {
+				status = corev1.ConditionFalse
+			}
+
+			ct := &shipper.CapacityTarget{
+				ObjectMeta: metav1.ObjectMeta{Generation: 1},
+				Spec: shipper.CapacityTargetSpec{
+					Clusters: []shipper.ClusterCapacityTarget{
+						{Name: clusterA, Percent: 10, TotalReplicaCount: 10},
+						{Name: clusterB, Percent: 10, TotalReplicaCount: 10},
+					},
+				},
+				Status: shipper.CapacityTargetStatus{
+					ObservedGeneration: 1,
+					Conditions: []shipper.TargetCondition{
+						{Type: shipper.TargetConditionTypeReady, Status: status},
+					},
+				},
+			}
+
+			_, newSpec, _ := checkCapacity(ct, 100)
+			stepped := stepCapacitySpec(ct, newSpec, test.headroom)
+
+			if test.expected == nil {
+				if stepped != nil {
+					t.Fatalf("expected capacity target not to be patched, got %v", stepped.Clusters)
+				}
+				return
+			}
+
+			if stepped == nil {
+				t.Fatalf("expected capacity target to be patched to %v, got no patch", test.expected)
+			}
+
+			for _, spec := range stepped.Clusters {
+				if spec.Percent != test.expected[spec.Name] {
+					t.Errorf("expected cluster %q to get %d%%, got %d%%", spec.Name, test.expected[spec.Name], spec.Percent)
+				}
+			}
+		})
+	}
+}
+
+// TestStepCapacitySpecLargeDeployment checks a contender with more replicas
+// than a percent of them fits in its headroom still works its way up to
+// 100%, rather than getting stuck at the same percentage forever.
+func TestStepCapacitySpecLargeDeployment(t *testing.T) {
+	const cluster = "cluster-a"
+
+	for _, headroom := range []int{1, 2} {
+		ct := &shipper.CapacityTarget{
+			ObjectMeta: metav1.ObjectMeta{Generation: 1},
+			Spec: shipper.CapacityTargetSpec{
+				Clusters: []shipper.ClusterCapacityTarget{
+					{Name: cluster, Percent: 10, TotalReplicaCount: 300},
+				},
+			},
+			Status: shipper.CapacityTargetStatus{
+				ObservedGeneration: 1,
+				Conditions: []shipper.TargetCondition{
+					{Type: shipper.TargetConditionTypeReady, Status: corev1.ConditionTrue},
+				},
+			},
+		}
+
+		for steps := 0; ct.Spec.Clusters[0].Percent < 100; steps++ {
+			if steps >= 90 {
+				t.Fatalf("headroom %d: expected capacity to reach 100%% in at most 90 steps, stuck at %d%%",
+					headroom, ct.Spec.Clusters[0].Percent)
+			}
+
+			_, newSpec, _ := checkCapacity(ct, 100)
+			stepped := stepCapacitySpec(ct, newSpec, map[string]int{cluster: headroom})
+			if stepped == nil {
+				t.Fatalf("headroom %d: expected capacity target to be patched at %d%%, got no patch",
+					headroom, ct.Spec.Clusters[0].Percent)
+			}
+
+			previous := ct.Spec.Clusters[0].Percent
+			if got := stepped.Clusters[0].Percent; got <= previous {
+				t.Fatalf("headroom %d: expected capacity to grow past %d%%, got %d%%", headroom, previous, got)
+			}
+
+			ct.Spec = *stepped
+		}
+	}
+}
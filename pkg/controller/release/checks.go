@@ -5,7 +5,9 @@ import (
 	"sort"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/util/replicas"
 	targetutil "github.com/bookingcom/shipper/pkg/util/target"
+	trafficutil "github.com/bookingcom/shipper/pkg/util/traffic"
 )
 
 func checkInstallation(it *shipper.InstallationTarget) (bool, string) {
@@ -140,3 +142,147 @@ func checkTraffic(
 
 	return canProceed, newSpec, reason
 }
+
+// rampTrafficSpec replaces the weights in newSpec, as returned by
+// checkTraffic, with the next weights tt has to go through to get there
+// according to ramp. Intermediate weights are only moved past once traffic
+// converged on them, so nothing is returned while tt isn't ready yet.
+func rampTrafficSpec(
+	tt *shipper.TrafficTarget,
+	newSpec *shipper.TrafficTargetSpec,
+	ramp *shipper.TrafficRamp,
+) *shipper.TrafficTargetSpec {
+	if ramp == nil || newSpec == nil {
+		return newSpec
+	}
+
+	if tt.Status.ObservedGeneration < tt.Generation {
+		return nil
+	}
+
+	if ready, _ := targetutil.IsReady(tt.Status.Conditions); !ready {
+		return nil
+	}
+
+	currentWeights := make(map[string]uint32)
+	for _, spec := range tt.Spec.Clusters {
+		currentWeights[spec.Name] = spec.Weight
+	}
+
+	rampedSpec := &shipper.TrafficTargetSpec{}
+	for _, spec := range newSpec.Clusters {
+		if steps := trafficutil.PlanWeightSteps(currentWeights[spec.Name], spec.Weight, ramp); len(steps) > 0 {
+			spec.Weight = steps[0]
+		}
+		rampedSpec.Clusters = append(rampedSpec.Clusters, spec)
+	}
+
+	return rampedSpec
+}
+
+// stepCapacitySpec replaces the percentages in newSpec, as returned by
+// checkCapacity, with ones that grow the replicas of ct by no more than the
+// headroom of each cluster, or by 1% of them when that's more. Capacity is
+// only grown further once it's been achieved, so nothing is returned while
+// a capped ct isn't ready yet.
+// Clusters missing from headroom aren't capped.
+func stepCapacitySpec(
+	ct *shipper.CapacityTarget,
+	newSpec *shipper.CapacityTargetSpec,
+	headroom map[string]int,
+) *shipper.CapacityTargetSpec {
+	if len(headroom) == 0 || newSpec == nil {
+		return newSpec
+	}
+
+	currentPercents := make(map[string]int32)
+	for _, spec := range ct.Spec.Clusters {
+		currentPercents[spec.Name] = spec.Percent
+	}
+
+	capped := false
+	steppedSpec := &shipper.CapacityTargetSpec{}
+	for _, spec := range newSpec.Clusters {
+		total := uint(spec.TotalReplicaCount)
+		current := int(replicas.CalculateDesiredReplicaCount(total, float64(currentPercents[spec.Name])))
+		desired := int(replicas.CalculateDesiredReplicaCount(total, float64(spec.Percent)))
+
+		if step := replicas.CapacityStep(current, desired, headroom[spec.Name]); step != desired {
+			// Percentages can't tell apart steps smaller than 1% of a
+			// big enough deployment, so every step takes at least
+			// 1% for capacity to keep growing at all.
+			percent := replicas.ReplicaCountPercentage(total, uint(step))
+			if currentPercent := currentPercents[spec.Name]; percent <= currentPercent {
+				percent = currentPercent + 1
+			}
+			spec.Percent = percent
+			capped = true
+		}
+		steppedSpec.Clusters = append(steppedSpec.Clusters, spec)
+	}
+
+	if !capped {
+		return newSpec
+	}
+
+	if ct.Status.ObservedGeneration < ct.Generation {
+		return nil
+	}
+
+	if ready, _ := targetutil.IsReady(ct.Status.Conditions); !ready {
+		return nil
+	}
+
+	return steppedSpec
+}
+
+// checkContenderConverged returns whether contender has achieved all of the
+// capacity and traffic it asks for in every cluster, along with the
+// clusters where it hasn't. It goes by the capacity and traffic each
+// cluster reports, so it catches clusters lagging behind even when the
+// target objects as a whole are ready. Capacity is judged by the replicas
+// available against the ones asked for. Clusters that haven't reported
+// anything yet haven't converged either.
+func checkContenderConverged(contender *releaseInfo) (bool, []string) {
+	notConverged := make(map[string]struct{})
+
+	if ct := contender.capacityTarget; ct != nil {
+		available := make(map[string]int32, len(ct.Status.Clusters))
+		for _, status := range ct.Status.Clusters {
+			available[status.Name] = status.AvailableReplicas
+		}
+
+		// AchievedPercent is rounded up, so a cluster a replica short of
+		// a big enough deployment would still report 100%. Going by
+		// available replicas doesn't let those through.
+		for _, spec := range ct.Spec.Clusters {
+			desired := int32(replicas.CalculateDesiredReplicaCount(
+				uint(spec.TotalReplicaCount), float64(spec.Percent)))
+			if got, ok := available[spec.Name]; !ok || got < desired {
+				notConverged[spec.Name] = struct{}{}
+			}
+		}
+	}
+
+	if tt := contender.trafficTarget; tt != nil {
+		achieved := make(map[string]uint32, len(tt.Status.Clusters))
+		for _, status := range tt.Status.Clusters {
+			achieved[status.Name] = status.AchievedTraffic
+		}
+
+		for _, spec := range tt.Spec.Clusters {
+			traffic, ok := achieved[spec.Name]
+			if !ok || trafficutil.ShiftProgress(traffic, spec.Weight) < 100 {
+				notConverged[spec.Name] = struct{}{}
+			}
+		}
+	}
+
+	clusters := make([]string, 0, len(notConverged))
+	for cluster := range notConverged {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+
+	return len(clusters) == 0, clusters
+}
@@ -0,0 +1,98 @@
+package release
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/controller"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+// completionPolicy returns what the application rel belongs to considers a
+// complete rollout, as set in its shipper.AppCompletionPolicyAnnotation.
+// Applications that don't say, or say something we don't understand, need
+// the whole last step to be achieved, incumbent included.
+func (c *Controller) completionPolicy(rel *shipper.Release) (string, error) {
+	appName, err := releaseutil.ApplicationNameForRelease(rel)
+	if err != nil {
+		return "", err
+	}
+
+	app, err := c.applicationLister.Applications(rel.Namespace).Get(appName)
+	if errors.IsNotFound(err) {
+		return shipper.CompletionPolicyBoth, nil
+	} else if err != nil {
+		return "", shippererrors.NewKubeclientGetError(rel.Namespace, appName, err).
+			WithShipperKind("Application")
+	}
+
+	policy, ok := app.Annotations[shipper.AppCompletionPolicyAnnotation]
+	if !ok {
+		return shipper.CompletionPolicyBoth, nil
+	}
+
+	switch policy {
+	case shipper.CompletionPolicyCapacity, shipper.CompletionPolicyTraffic, shipper.CompletionPolicyBoth:
+		return policy, nil
+	default:
+		klog.Warningf("Application %q has an unknown completion policy %q, falling back to %q",
+			controller.MetaKey(app), policy, shipper.CompletionPolicyBoth)
+		return shipper.CompletionPolicyBoth, nil
+	}
+}
+
+// convergedForPolicy returns whether the contender in relinfo has converged
+// on the last step of its strategy, strategyStep, as far as policy is
+// concerned. The contender itself always has to achieve both the capacity
+// and the traffic the step asks for. What policy decides is how much of
+// the incumbent in relinfoPrev, if any, has to wind down for the release
+// to be complete: its traffic, its capacity, or both of them, as when the
+// whole step is achieved.
+func convergedForPolicy(
+	policy string,
+	relinfoPrev, relinfo *releaseInfo,
+	strategyStep shipper.RolloutStrategyStep,
+) bool {
+	capacityAchieved := func(relinfo *releaseInfo, percent int32) bool {
+		achieved, _, _ := checkCapacity(relinfo.capacityTarget, percent)
+		return achieved
+	}
+
+	trafficAchieved := func(relinfo *releaseInfo, weight int32) bool {
+		achieved, _, _ := checkTraffic(relinfo.trafficTarget, uint32(weight))
+		return achieved
+	}
+
+	if !capacityAchieved(relinfo, strategyStep.Capacity.Contender) ||
+		!trafficAchieved(relinfo, strategyStep.Traffic.Contender) {
+		return false
+	}
+
+	if relinfoPrev == nil {
+		return true
+	}
+
+	switch policy {
+	case shipper.CompletionPolicyCapacity:
+		return capacityAchieved(relinfoPrev, strategyStep.Capacity.Incumbent)
+	case shipper.CompletionPolicyTraffic:
+		return trafficAchieved(relinfoPrev, strategyStep.Traffic.Incumbent)
+	default:
+		return capacityAchieved(relinfoPrev, strategyStep.Capacity.Incumbent) &&
+			trafficAchieved(relinfoPrev, strategyStep.Traffic.Incumbent)
+	}
+}
+
+func setReleaseComplete(rel *shipper.Release, diff *diffutil.MultiDiff) {
+	condition := releaseutil.NewReleaseCondition(
+		shipper.ReleaseConditionTypeComplete,
+		corev1.ConditionTrue,
+		"",
+		"",
+	)
+	diff.Append(releaseutil.SetReleaseCondition(&rel.Status, *condition))
+}
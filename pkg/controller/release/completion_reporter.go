@@ -0,0 +1,126 @@
+package release
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// CompletionRecord describes a release that just completed its strategy.
+type CompletionRecord struct {
+	Namespace   string `json:"namespace"`
+	Application string `json:"application"`
+	Release     string `json:"release"`
+
+	// Duration is the time it took the release to complete since it was
+	// created, in seconds.
+	Duration float64 `json:"duration"`
+
+	Clusters []string `json:"clusters"`
+
+	// Weights are the traffic weights the release ended up with, by
+	// cluster.
+	Weights map[string]uint32 `json:"weights"`
+}
+
+// CompletionReporter lets an external system know about releases completing
+// their strategy.
+type CompletionReporter interface {
+	Report(record CompletionRecord) error
+}
+
+// NoopCompletionReporter is a CompletionReporter that doesn't tell anyone.
+type NoopCompletionReporter struct{}
+
+var _ CompletionReporter = NoopCompletionReporter{}
+
+func (NoopCompletionReporter) Report(CompletionRecord) error { return nil }
+
+// HTTPCompletionReporter is a CompletionReporter that POSTs every
+// CompletionRecord as JSON to a URL, retrying with an exponential backoff
+// for as long as the server can't take it.
+type HTTPCompletionReporter struct {
+	url     string
+	client  *http.Client
+	backoff wait.Backoff
+}
+
+var _ CompletionReporter = (*HTTPCompletionReporter)(nil)
+
+func NewHTTPCompletionReporter(url string, timeout time.Duration) *HTTPCompletionReporter {
+	return &HTTPCompletionReporter{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+		backoff: wait.Backoff{
+			Duration: 500 * time.Millisecond,
+			Factor:   2,
+			Steps:    5,
+		},
+	}
+}
+
+// Report sends record over. Requests that fail to go through, or that the
+// server answers with a 5xx or 429, are retried until the backoff runs out.
+// Any other status is taken as the server refusing the record, and isn't
+// retried.
+func (r *HTTPCompletionReporter) Report(record CompletionRecord) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	err = wait.ExponentialBackoff(r.backoff, func() (bool, error) {
+		resp, err := r.client.Post(r.url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			klog.V(4).Infof("Failed to report completion of release %s/%s, will retry: %s",
+				record.Namespace, record.Release, err)
+			lastErr = err
+			return false, nil
+		}
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			return true, nil
+		case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+			lastErr = fmt.Errorf("completion report rejected with status %d", resp.StatusCode)
+			return false, nil
+		default:
+			return false, fmt.Errorf("completion report rejected with status %d", resp.StatusCode)
+		}
+	})
+
+	if err == wait.ErrWaitTimeout && lastErr != nil {
+		return lastErr
+	}
+
+	return err
+}
+
+// buildCompletionRecord puts together the CompletionRecord of rel, which is
+// expected to have just completed with the traffic in tt.
+func buildCompletionRecord(rel *shipper.Release, tt *shipper.TrafficTarget, now time.Time) CompletionRecord {
+	weights := map[string]uint32{}
+	if tt != nil {
+		for _, spec := range tt.Spec.Clusters {
+			weights[spec.Name] = spec.Weight
+		}
+	}
+
+	return CompletionRecord{
+		Namespace:   rel.Namespace,
+		Application: rel.Labels[shipper.AppLabel],
+		Release:     rel.Name,
+		Duration:    now.Sub(rel.CreationTimestamp.Time).Seconds(),
+		Clusters:    getReleaseClusters(rel),
+		Weights:     weights,
+	}
+}
@@ -0,0 +1,229 @@
+package release
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+)
+
+func TestHTTPCompletionReporterRetriesUntilAccepted(t *testing.T) {
+	var mu sync.Mutex
+	var attempts int
+	var received CompletionRecord
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected a JSON payload, got content type %q", ct)
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("unexpected error decoding payload: %s", err)
+		}
+	}))
+	defer server.Close()
+
+	reporter := newTestHTTPCompletionReporter(server.URL)
+
+	record := CompletionRecord{
+		Namespace:   "test-namespace",
+		Application: "test-app",
+		Release:     "test-contender",
+		Duration:    42,
+		Clusters:    []string{"cluster-a", "cluster-b"},
+		Weights:     map[string]uint32{"cluster-a": 100, "cluster-b": 50},
+	}
+
+	if err := reporter.Report(record); err != nil {
+		t.Fatalf("unexpected error reporting completion: %s", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if attempts != 2 {
+		t.Errorf("expected completion to be reported after 2 attempts, got %d", attempts)
+	}
+
+	if !reflect.DeepEqual(received, record) {
+		t.Errorf("expected payload %+v, got %+v", record, received)
+	}
+}
+
+func TestHTTPCompletionReporterDoesNotRetryRejectedRecords(t *testing.T) {
+	var mu sync.Mutex
+	var attempts int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	reporter := newTestHTTPCompletionReporter(server.URL)
+
+	if err := reporter.Report(CompletionRecord{}); err == nil {
+		t.Fatalf("expected an error for a rejected record, got none")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if attempts != 1 {
+		t.Errorf("expected rejected record to be sent once, got %d", attempts)
+	}
+}
+
+// TestCompletionIsReported syncs a contender that converged on the last step
+// of its strategy, and checks its completion is reported along with the
+// traffic it ended up with.
+func TestCompletionIsReported(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	reporter := &recordingCompletionReporter{}
+	f.completionReporter = reporter
+
+	contender := f.buildContender(namespace, "test-contender", 10)
+	contender.release.Spec.TargetStep = 2
+	contender.capacityTarget.Spec.Clusters[0].Percent = 100
+	contender.trafficTarget.Spec.Clusters[0].Weight = 100
+
+	f.addObjects(
+		contender.release.DeepCopy(),
+		contender.installationTarget.DeepCopy(),
+		contender.capacityTarget.DeepCopy(),
+		contender.trafficTarget.DeepCopy(),
+	)
+
+	f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.informerFactory.Start(stopCh)
+	f.informerFactory.WaitForCacheSync(stopCh)
+
+	key := namespace + "/" + contender.release.Name
+	if err := c.syncRelease(gocontext.Background(), key, &TraceEntry{}); err != nil {
+		t.Fatalf("unexpected error syncing release: %s", err)
+	}
+
+	if len(reporter.records) != 0 {
+		t.Fatalf("expected release completion to be reported away from the sync, got %d reports", len(reporter.records))
+	}
+
+	select {
+	case report := <-c.completionReports:
+		c.sendCompletionReport(report)
+	default:
+		t.Fatalf("expected release completion to be queued up to be reported")
+	}
+
+	if len(reporter.records) != 1 {
+		t.Fatalf("expected release completion to be reported once, got %d reports", len(reporter.records))
+	}
+
+	got := reporter.records[0]
+	if got.Application != app.Name || got.Release != contender.release.Name {
+		t.Errorf("expected completion of %s/%s to be reported, got %s/%s",
+			app.Name, contender.release.Name, got.Application, got.Release)
+	}
+
+	if expected := []string{"minikube"}; !reflect.DeepEqual(got.Clusters, expected) {
+		t.Errorf("expected clusters %v, got %v", expected, got.Clusters)
+	}
+
+	if expected := map[string]uint32{"minikube": 100}; !reflect.DeepEqual(got.Weights, expected) {
+		t.Errorf("expected weights %v, got %v", expected, got.Weights)
+	}
+}
+
+// TestCompletionReportsDontBlockSync checks completions are dropped rather
+// than holding up the sync they're reported from once too many of them are
+// waiting to be sent.
+func TestCompletionReportsDontBlockSync(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	f.clientset = shipperfake.NewSimpleClientset()
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	recorder := record.NewFakeRecorder(completionReportQueueSize + 1)
+	f.recorder = recorder
+
+	c := f.newController()
+	contender := f.buildContender(namespace, "test-contender", 10)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i <= completionReportQueueSize; i++ {
+			c.reportCompletion(contender.release, contender.trafficTarget)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatalf("expected reporting completions not to block")
+	}
+
+	if got := len(c.completionReports); got != completionReportQueueSize {
+		t.Errorf("expected %d completions to be queued up, got %d", completionReportQueueSize, got)
+	}
+
+	if got := len(recorder.Events); got != 1 {
+		t.Errorf("expected a single completion to be dropped with a warning, got %d events", got)
+	}
+}
+
+func newTestHTTPCompletionReporter(url string) *HTTPCompletionReporter {
+	reporter := NewHTTPCompletionReporter(url, time.Second)
+	reporter.backoff = wait.Backoff{
+		Duration: time.Millisecond,
+		Factor:   2,
+		Steps:    3,
+	}
+	return reporter
+}
+
+type recordingCompletionReporter struct {
+	records []CompletionRecord
+}
+
+func (r *recordingCompletionReporter) Report(record CompletionRecord) error {
+	r.records = append(r.records, record)
+	return nil
+}
+
+var _ CompletionReporter = (*recordingCompletionReporter)(nil)
@@ -0,0 +1,149 @@
+package release
+
+import (
+	gocontext "context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+	targetutil "github.com/bookingcom/shipper/pkg/util/target"
+)
+
+// TestCompletionPolicy runs the strategy for a contender on its last step
+// while it, or the incumbent it replaces, is yet to converge one way or
+// another, and checks the release is only marked as complete once the
+// contender has all of its capacity and traffic, and the incumbent has
+// wound down as much as its application asks for.
+func TestCompletionPolicy(t *testing.T) {
+	const (
+		nothingPending = iota
+		contenderTrafficPending
+		incumbentCapacityPending
+		incumbentTrafficPending
+	)
+
+	tests := []struct {
+		name     string
+		pending  int
+		policy   string
+		complete bool
+	}{
+		{"nothing pending, no policy", nothingPending, "", true},
+		{"nothing pending, capacity", nothingPending, shipper.CompletionPolicyCapacity, true},
+
+		{"contender traffic pending, no policy", contenderTrafficPending, "", false},
+		{"contender traffic pending, both", contenderTrafficPending, shipper.CompletionPolicyBoth, false},
+		{"contender traffic pending, capacity", contenderTrafficPending, shipper.CompletionPolicyCapacity, false},
+		{"contender traffic pending, traffic", contenderTrafficPending, shipper.CompletionPolicyTraffic, false},
+		{"contender traffic pending, bogus", contenderTrafficPending, "bogus", false},
+
+		{"incumbent capacity pending, both", incumbentCapacityPending, shipper.CompletionPolicyBoth, false},
+		{"incumbent capacity pending, capacity", incumbentCapacityPending, shipper.CompletionPolicyCapacity, false},
+		{"incumbent capacity pending, traffic", incumbentCapacityPending, shipper.CompletionPolicyTraffic, true},
+
+		{"incumbent traffic pending, both", incumbentTrafficPending, shipper.CompletionPolicyBoth, false},
+		{"incumbent traffic pending, capacity", incumbentTrafficPending, shipper.CompletionPolicyCapacity, true},
+		{"incumbent traffic pending, traffic", incumbentTrafficPending, shipper.CompletionPolicyTraffic, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rel := executeStrategyWithCompletionPolicy(t, test.policy, func(incumbent, contender *releaseInfo) {
+				switch test.pending {
+				case contenderTrafficPending:
+					setTrafficNotReady(contender.trafficTarget)
+				case incumbentCapacityPending:
+					incumbent.capacityTarget.Spec.Clusters[0].Percent = 100
+				case incumbentTrafficPending:
+					setTrafficNotReady(incumbent.trafficTarget)
+				}
+			})
+
+			if complete := releaseutil.ReleaseComplete(rel); complete != test.complete {
+				t.Errorf("expected release with completion policy %q to be complete: %t, got %t",
+					test.policy, test.complete, complete)
+			}
+		})
+	}
+}
+
+func setTrafficNotReady(tt *shipper.TrafficTarget) {
+	tt.Status.Conditions, _ = targetutil.SetTargetCondition(
+		tt.Status.Conditions,
+		targetutil.NewTargetCondition(
+			shipper.TargetConditionTypeReady,
+			corev1.ConditionFalse,
+			ClustersNotReady, "[minikube]"))
+}
+
+// executeStrategyWithCompletionPolicy runs the strategy for a contender on
+// the last step of its strategy, with both its and its incumbent's targets
+// converged on it other than for whatever pending changes.
+func executeStrategyWithCompletionPolicy(
+	t *testing.T,
+	policy string,
+	pending func(incumbent, contender *releaseInfo),
+) *shipper.Release {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	if policy != "" {
+		app.Annotations = map[string]string{shipper.AppCompletionPolicyAnnotation: policy}
+	}
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+	incumbent := f.buildIncumbent(namespace, "test-incumbent", 10)
+	incumbent.capacityTarget.Spec.Clusters[0].Percent = 0
+	incumbent.trafficTarget.Spec.Clusters[0].Weight = 0
+
+	contender := f.buildContender(namespace, "test-contender", 10)
+	contender.release.Spec.TargetStep = 2
+	contender.capacityTarget.Spec.Clusters[0].Percent = 100
+	contender.capacityTarget.Status.Clusters = []shipper.ClusterCapacityStatus{
+		{Name: cluster.Name, AvailableReplicas: 10, AchievedPercent: 100},
+	}
+	contender.trafficTarget.Spec.Clusters[0].Weight = 100
+	contender.trafficTarget.Status.Clusters = []*shipper.ClusterTrafficStatus{
+		{Name: cluster.Name, AchievedTraffic: 100},
+	}
+
+	pending(incumbent, contender)
+
+	f.addObjects(
+		incumbent.release.DeepCopy(),
+		incumbent.installationTarget.DeepCopy(),
+		incumbent.capacityTarget.DeepCopy(),
+		incumbent.trafficTarget.DeepCopy(),
+		contender.release.DeepCopy(),
+		contender.installationTarget.DeepCopy(),
+		contender.capacityTarget.DeepCopy(),
+		contender.trafficTarget.DeepCopy(),
+	)
+
+	f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.informerFactory.Start(stopCh)
+	f.informerFactory.WaitForCacheSync(stopCh)
+
+	rel, _, _, err := c.executeReleaseStrategy(gocontext.Background(), contender, diffutil.NewMultiDiff())
+	if err != nil {
+		t.Fatalf("unexpected error executing strategy: %s", err)
+	}
+
+	return rel
+}
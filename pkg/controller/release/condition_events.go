@@ -0,0 +1,83 @@
+package release
+
+import (
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+type conditionEventKey struct {
+	release  string
+	condType shipper.ReleaseConditionType
+	status   corev1.ConditionStatus
+	reason   string
+}
+
+// conditionEventDeduper keeps track of the release condition transitions we
+// recently emitted events for, so a flapping condition doesn't flood the
+// event stream with a new event on every single reconcile.
+type conditionEventDeduper struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	seen map[conditionEventKey]time.Time
+}
+
+func newConditionEventDeduper(window time.Duration) *conditionEventDeduper {
+	return &conditionEventDeduper{
+		window: window,
+		now:    time.Now,
+		seen:   make(map[conditionEventKey]time.Time),
+	}
+}
+
+// Filter returns the diffs in md that are worth an event for the release
+// identified by relKey. Release condition transitions identical to one
+// already let through within the dedup window are dropped; everything else
+// is passed along untouched. A zero window disables deduplication.
+func (d *conditionEventDeduper) Filter(relKey string, md diffutil.MultiDiff) diffutil.MultiDiff {
+	if d.window <= 0 || md.IsEmpty() {
+		return md
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for key, emitted := range d.seen {
+		if now.Sub(emitted) >= d.window {
+			delete(d.seen, key)
+		}
+	}
+
+	filtered := make(diffutil.MultiDiff, 0, len(md))
+	for _, diff := range md {
+		condDiff, ok := diff.(*releaseutil.ReleaseConditionDiff)
+		if !ok || condDiff.IsEmpty() || condDiff.Condition() == nil {
+			filtered = append(filtered, diff)
+			continue
+		}
+
+		cond := condDiff.Condition()
+		key := conditionEventKey{
+			release:  relKey,
+			condType: cond.Type,
+			status:   cond.Status,
+			reason:   cond.Reason,
+		}
+		if _, ok := d.seen[key]; ok {
+			continue
+		}
+
+		d.seen[key] = now
+		filtered = append(filtered, diff)
+	}
+
+	return filtered
+}
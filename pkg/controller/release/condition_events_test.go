@@ -0,0 +1,73 @@
+package release
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+func TestConditionEventDeduper(t *testing.T) {
+	const relKey = "test-namespace/test-release"
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := newConditionEventDeduper(time.Minute)
+	d.now = func() time.Time { return now }
+
+	blocked := releaseutil.NewReleaseCondition(
+		shipper.ReleaseConditionTypeBlocked,
+		corev1.ConditionTrue,
+		shipper.RolloutBlockReason,
+		"",
+	)
+	unblocked := releaseutil.NewReleaseCondition(
+		shipper.ReleaseConditionTypeBlocked,
+		corev1.ConditionFalse,
+		"",
+		"",
+	)
+
+	block := func() diffutil.MultiDiff {
+		return diffutil.MultiDiff{releaseutil.NewReleaseConditionDiff(unblocked, blocked)}
+	}
+
+	if d.Filter(relKey, block()).IsEmpty() {
+		t.Fatalf("expected the first transition to be emitted")
+	}
+
+	now = now.Add(30 * time.Second)
+	if filtered := d.Filter(relKey, block()); !filtered.IsEmpty() {
+		t.Fatalf("expected a repeated transition within the window to be suppressed, got %q", filtered.String())
+	}
+
+	if d.Filter("test-namespace/other-release", block()).IsEmpty() {
+		t.Fatalf("expected the same transition on another release to be emitted")
+	}
+
+	now = now.Add(time.Minute)
+	if d.Filter(relKey, block()).IsEmpty() {
+		t.Fatalf("expected a repeated transition after the window to be emitted")
+	}
+}
+
+func TestConditionEventDeduperDisabled(t *testing.T) {
+	d := newConditionEventDeduper(0)
+
+	blocked := releaseutil.NewReleaseCondition(
+		shipper.ReleaseConditionTypeBlocked,
+		corev1.ConditionTrue,
+		shipper.RolloutBlockReason,
+		"",
+	)
+	md := diffutil.MultiDiff{releaseutil.NewReleaseConditionDiff(nil, blocked)}
+
+	for i := 0; i < 2; i++ {
+		if d.Filter("test-namespace/test-release", md).IsEmpty() {
+			t.Fatalf("expected every transition to be emitted when deduplication is disabled")
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package release
+
+import (
+	gocontext "context"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/record"
+
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+)
+
+// TestBurstOfEnqueuesReconcilesOnce enqueues the same release three times
+// in a row, both directly and rate limited, as happens when its targets
+// change together, and checks only a single reconcile comes out of it once
+// the debounce window is over.
+func TestBurstOfEnqueuesReconcilesOnce(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	f.enqueueDebounce = 50 * time.Millisecond
+	contender := f.buildContender(namespace, "test-contender", 10)
+
+	const syncPeriod time.Duration = 0
+	f.clientset = shipperfake.NewSimpleClientset()
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, syncPeriod)
+	f.recorder = record.NewFakeRecorder(42)
+	c := f.newController()
+	defer c.releaseWorkqueue.ShutDown()
+
+	c.enqueueRelease(contender.release)
+	c.enqueueReleaseRateLimited(contender.release)
+	c.enqueueRelease(contender.release)
+
+	if n := c.releaseWorkqueue.Len(); n != 0 {
+		t.Fatalf("expected release to wait for the debounce window, got %d items queued", n)
+	}
+
+	time.Sleep(2 * f.enqueueDebounce)
+
+	if n := c.releaseWorkqueue.Len(); n != 1 {
+		t.Fatalf("expected a single reconcile after the debounce window, got %d items queued", n)
+	}
+
+	if !c.processNextReleaseWorkItem(gocontext.Background()) {
+		t.Fatalf("expected release to be reconciled")
+	}
+
+	time.Sleep(2 * f.enqueueDebounce)
+
+	if n := c.releaseWorkqueue.Len(); n != 0 {
+		t.Fatalf("expected no more reconciles, got %d items queued", n)
+	}
+}
@@ -0,0 +1,64 @@
+package release
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/runtime"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+// releaseDependsOnIndex indexes releases by the release they depend on, as
+// in namespace/name, so the ones waiting on a release can be found without
+// going through every release in its namespace.
+const releaseDependsOnIndex = "releaseDependsOn"
+
+// releaseDependsOnIndexFunc is the cache.IndexFunc behind
+// releaseDependsOnIndex. Releases that don't depend on any other aren't
+// indexed.
+func releaseDependsOnIndexFunc(obj interface{}) ([]string, error) {
+	rel, ok := obj.(*shipper.Release)
+	if !ok {
+		return nil, fmt.Errorf("not a shipper.Release: %#v", obj)
+	}
+
+	depName := rel.Annotations[shipper.ReleaseDependsOnAnnotation]
+	if depName == "" {
+		return nil, nil
+	}
+
+	return []string{rel.Namespace + "/" + depName}, nil
+}
+
+// dependencySatisfied returns whether rel is free to be reconciled, as far
+// as the release it depends on is concerned.
+func (c *Controller) dependencySatisfied(rel *shipper.Release) bool {
+	return releaseutil.DependencySatisfied(rel, func(name string) *shipper.Release {
+		dep, err := c.releaseLister.Releases(rel.Namespace).Get(name)
+		if err != nil {
+			return nil
+		}
+		return dep
+	})
+}
+
+// enqueueDependents enqueues every release that depends on the one in obj,
+// since they might have been waiting for it to get where it is now.
+func (c *Controller) enqueueDependents(obj interface{}) {
+	rel, ok := obj.(*shipper.Release)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.Release: %#v", obj))
+		return
+	}
+
+	dependents, err := c.releaseIndexer.ByIndex(releaseDependsOnIndex, rel.Namespace+"/"+rel.Name)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("error fetching releases depending on %s/%s: %s", rel.Namespace, rel.Name, err))
+		return
+	}
+
+	for _, dependent := range dependents {
+		c.enqueueRelease(dependent)
+	}
+}
@@ -0,0 +1,109 @@
+package release
+
+import (
+	gocontext "context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+// TestReleaseWithUnsatisfiedDependencyIsNotProcessed syncs a release that
+// depends on one that doesn't exist, and checks it isn't scheduled but is
+// marked as blocked on its dependency instead.
+func TestReleaseWithUnsatisfiedDependencyIsNotProcessed(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+	contender := f.buildContender(namespace, "test-contender", 1)
+	delete(contender.release.Annotations, shipper.ReleaseClustersAnnotation)
+	contender.release.Annotations[shipper.ReleaseDependsOnAnnotation] = "test-dependency"
+	f.addObjects(contender.release.DeepCopy())
+
+	f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.informerFactory.Start(stopCh)
+	f.informerFactory.WaitForCacheSync(stopCh)
+
+	key := namespace + "/" + contender.release.Name
+	if err := c.syncRelease(gocontext.Background(), key, &TraceEntry{}); err != nil {
+		t.Fatalf("unexpected error syncing release: %s", err)
+	}
+
+	rel, err := f.clientset.ShipperV1alpha1().Releases(namespace).Get(contender.release.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error getting release: %s", err)
+	}
+
+	if _, ok := rel.Annotations[shipper.ReleaseClustersAnnotation]; ok {
+		t.Errorf("expected release not to be scheduled while its dependency is missing")
+	}
+
+	cond := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeBlocked)
+	if cond == nil || cond.Status != corev1.ConditionTrue || cond.Reason != WaitingForDependency {
+		t.Fatalf("expected release to be blocked waiting for its dependency, got condition %+v", cond)
+	}
+
+	if !strings.Contains(cond.Message, "test-dependency") {
+		t.Errorf("expected blocked condition to name the dependency, got %q", cond.Message)
+	}
+}
+
+// TestDependentsAreEnqueued checks only the releases that depend on a
+// release are enqueued when it changes.
+func TestDependentsAreEnqueued(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+	dependency := f.buildIncumbent(namespace, "test-dependency", 1)
+	dependent := f.buildContender(namespace, "test-dependent", 1)
+	dependent.release.Annotations[shipper.ReleaseDependsOnAnnotation] = dependency.release.Name
+	other := f.buildContender(namespace, "test-other", 1)
+	other.release.Annotations[shipper.ReleaseDependsOnAnnotation] = "test-other-dependency"
+
+	f.clientset = shipperfake.NewSimpleClientset()
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+
+	// The informers aren't started, so that nothing but the dependents
+	// ends up in the queue.
+	for _, rel := range []*shipper.Release{dependency.release, dependent.release, other.release} {
+		if err := c.releaseIndexer.Add(rel.DeepCopy()); err != nil {
+			t.Fatalf("unexpected error indexing release: %s", err)
+		}
+	}
+
+	c.enqueueDependents(dependency.release)
+
+	if got := c.releaseWorkqueue.Len(); got != 1 {
+		t.Fatalf("expected a single release to be enqueued, got %d", got)
+	}
+
+	item, _ := c.releaseWorkqueue.Get()
+	if expected := namespace + "/" + dependent.release.Name; item != expected {
+		t.Errorf("expected %q to be enqueued, got %q", expected, item)
+	}
+}
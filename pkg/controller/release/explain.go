@@ -0,0 +1,187 @@
+package release
+
+import (
+	gocontext "context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+	rolloutblock "github.com/bookingcom/shipper/pkg/util/rolloutblock"
+)
+
+// ReconcileAction is what a reconcile would do for a release next.
+type ReconcileAction string
+
+const (
+	// ReconcileActionNone means the release is where its strategy wants
+	// it to be, so there's nothing left to do for it.
+	ReconcileActionNone ReconcileAction = "None"
+
+	// ReconcileActionWait means the release can't move on until
+	// something outside of it changes, as told by the reason.
+	ReconcileActionWait ReconcileAction = "Wait"
+
+	// ReconcileActionSchedule means the release still has to be
+	// scheduled before its strategy can be executed.
+	ReconcileActionSchedule ReconcileAction = "Schedule"
+
+	// ReconcileActionPatch means the release's strategy would patch its
+	// target objects, or the ones of its siblings, to move it along.
+	ReconcileActionPatch ReconcileAction = "Patch"
+)
+
+// ReconcileExplanation is the decision a reconcile of a release would come
+// to, worked out without acting on any of it.
+type ReconcileExplanation struct {
+	// Release is the key of the release that was explained.
+	Release string
+
+	// TargetStep and TargetStepName are the strategy step the release is
+	// asked to get to.
+	TargetStep     int32
+	TargetStepName string
+
+	// AchievedStep is the step the release would have achieved after the
+	// reconcile, if any.
+	AchievedStep *shipper.AchievedStep
+
+	// StepComplete tells whether the target step would be achieved.
+	StepComplete bool
+
+	// Action and Reason are what the reconcile would do and why.
+	Action ReconcileAction
+	Reason string
+
+	// Patches describes every patch the reconcile would send, as in
+	// "CapacityTarget foo-0: {...}".
+	Patches []string
+
+	// BlockingConditions lists the strategy conditions holding the
+	// release back from its target step, as in
+	// "ContenderAchievedCapacity: ...".
+	BlockingConditions []string
+}
+
+// ExplainReconcile works out what a reconcile of the release namespace/name
+// would do: the step it's after, the action it would take and the
+// conditions holding it back. It goes through the same checks and strategy
+// as syncRelease does, but nothing is ever written back to the cluster, no
+// events are recorded and the release isn't enqueued again.
+func (c *Controller) ExplainReconcile(namespace, name string) (ReconcileExplanation, error) {
+	rel, err := c.releaseLister.Releases(namespace).Get(name)
+	if err != nil {
+		return ReconcileExplanation{}, shippererrors.NewKubeclientGetError(namespace, name, err).
+			WithShipperKind("Release")
+	}
+
+	explanation := ReconcileExplanation{
+		Release:    fmt.Sprintf("%s/%s", namespace, name),
+		TargetStep: rel.Spec.TargetStep,
+	}
+
+	if releaseutil.HasEmptyEnvironment(rel) {
+		explanation.Action = ReconcileActionNone
+		explanation.Reason = "release has no environment"
+		return explanation, nil
+	}
+
+	strategy := rel.Spec.Environment.Strategy
+	if strategy != nil && int(rel.Spec.TargetStep) < len(strategy.Steps) {
+		explanation.TargetStepName = strategy.Steps[rel.Spec.TargetStep].Name
+	}
+	explanation.AchievedStep = rel.Status.AchievedStep
+
+	if appName, paused, err := c.parentPaused(rel); err != nil {
+		return ReconcileExplanation{}, err
+	} else if paused {
+		explanation.Action = ReconcileActionWait
+		explanation.Reason = fmt.Sprintf("application %q is paused", appName)
+		return explanation, nil
+	}
+
+	if !c.dependencySatisfied(rel) {
+		explanation.Action = ReconcileActionWait
+		explanation.Reason = fmt.Sprintf("release depends on release %q",
+			rel.Annotations[shipper.ReleaseDependsOnAnnotation])
+		return explanation, nil
+	}
+
+	if rolloutBlocked, _, err := rolloutblock.BlocksRollout(c.rolloutBlockLister, rel); rolloutBlocked {
+		explanation.Action = ReconcileActionWait
+		explanation.Reason = "release is blocked by a rollout block"
+		if err != nil {
+			explanation.Reason = err.Error()
+		}
+		return explanation, nil
+	}
+
+	// Scheduling a release creates its target objects, so a release
+	// missing any of them isn't taken any further.
+	relinfo, err := c.buildReleaseInfo(rel)
+	if err != nil {
+		explanation.Action = ReconcileActionSchedule
+		explanation.Reason = err.Error()
+		return explanation, nil
+	}
+
+	if msg, err := c.checkWillConverge(relinfo); err != nil {
+		return ReconcileExplanation{}, err
+	} else if msg != "" {
+		explanation.Action = ReconcileActionWait
+		explanation.Reason = msg
+		return explanation, nil
+	}
+
+	// The strategy is executed by a copy of the controller whose events
+	// go nowhere, so explaining a release doesn't leave a trace of it.
+	dry := *c
+	dry.recorder = &record.FakeRecorder{}
+
+	execRel, patches, _, err := dry.executeReleaseStrategy(gocontext.Background(), relinfo, diffutil.NewMultiDiff())
+	if err != nil {
+		return ReconcileExplanation{}, err
+	}
+
+	explanation.AchievedStep = execRel.Status.AchievedStep
+	explanation.StepComplete = execRel.Status.AchievedStep != nil &&
+		execRel.Status.AchievedStep.Step == rel.Spec.TargetStep
+
+	strategyStatus := execRel.Status.Strategy
+	for _, patch := range patches {
+		name, gvk, b := patch.PatchSpec()
+		explanation.Patches = append(explanation.Patches, fmt.Sprintf("%s %s: %s", gvk.Kind, name, b))
+
+		if p, ok := patch.(*ReleaseStrategyStatusPatch); ok && p.Name == rel.Name {
+			strategyStatus = p.NewStrategyStatus
+		}
+	}
+
+	if strategyStatus != nil {
+		for _, cond := range strategyStatus.Conditions {
+			if cond.Status == corev1.ConditionTrue {
+				continue
+			}
+			explanation.BlockingConditions = append(explanation.BlockingConditions,
+				fmt.Sprintf("%s: %s", cond.Type, cond.Message))
+		}
+	}
+
+	switch {
+	case len(patches) > 0:
+		explanation.Action = ReconcileActionPatch
+		explanation.Reason = fmt.Sprintf("%d patches to send", len(patches))
+	case !explanation.StepComplete:
+		explanation.Action = ReconcileActionWait
+		explanation.Reason = fmt.Sprintf("waiting for step [%d] to be achieved", rel.Spec.TargetStep)
+	default:
+		explanation.Action = ReconcileActionNone
+		explanation.Reason = fmt.Sprintf("step [%d] is achieved", rel.Spec.TargetStep)
+	}
+
+	return explanation, nil
+}
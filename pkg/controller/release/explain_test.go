@@ -0,0 +1,107 @@
+package release
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+)
+
+// TestExplainReconcileMidRollout explains a contender that has just been
+// asked to go to the 50/50 step of a vanguard strategy, and checks the
+// explanation tells its capacity has to be patched and is holding it back,
+// without anything being written back to the cluster.
+func TestExplainReconcileMidRollout(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+	totalReplicaCount := int32(10)
+	incumbent := f.buildIncumbent(namespace, "test-incumbent", totalReplicaCount)
+	contender := f.buildContender(namespace, "test-contender", totalReplicaCount)
+	contender.release.Spec.TargetStep = 1
+	contender.release.Status.AchievedStep = &shipper.AchievedStep{Step: 0, Name: vanguard.Steps[0].Name}
+	contender.capacityTarget.Spec.Clusters[0].Percent = 1
+
+	f.addObjects(
+		incumbent.release.DeepCopy(),
+		incumbent.installationTarget.DeepCopy(),
+		incumbent.capacityTarget.DeepCopy(),
+		incumbent.trafficTarget.DeepCopy(),
+		contender.release.DeepCopy(),
+		contender.installationTarget.DeepCopy(),
+		contender.capacityTarget.DeepCopy(),
+		contender.trafficTarget.DeepCopy(),
+	)
+
+	f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+	defer c.releaseWorkqueue.ShutDown()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.informerFactory.Start(stopCh)
+	f.informerFactory.WaitForCacheSync(stopCh)
+	f.clientset.ClearActions()
+	queued := c.releaseWorkqueue.Len()
+
+	explanation, err := c.ExplainReconcile(namespace, contender.release.Name)
+	if err != nil {
+		t.Fatalf("unexpected error explaining release: %s", err)
+	}
+
+	if explanation.TargetStep != 1 || explanation.TargetStepName != vanguard.Steps[1].Name {
+		t.Errorf("expected target step [1] %q, got [%d] %q",
+			vanguard.Steps[1].Name, explanation.TargetStep, explanation.TargetStepName)
+	}
+
+	if explanation.StepComplete {
+		t.Errorf("expected target step not to be complete")
+	}
+
+	if explanation.Action != ReconcileActionPatch {
+		t.Errorf("expected action %q, got %q: %s", ReconcileActionPatch, explanation.Action, explanation.Reason)
+	}
+
+	if !hasPrefixed(explanation.Patches, "CapacityTarget "+contender.release.Name+":") {
+		t.Errorf("expected the contender capacity target to be patched, got patches %v", explanation.Patches)
+	}
+
+	if !hasPrefixed(explanation.BlockingConditions, string(shipper.StrategyConditionContenderAchievedCapacity)+":") {
+		t.Errorf("expected the contender capacity to be blocking, got %v", explanation.BlockingConditions)
+	}
+
+	if actions := f.clientset.Actions(); len(actions) > 0 {
+		t.Errorf("expected explaining a release not to touch the cluster, got actions %v", actions)
+	}
+
+	if n := c.releaseWorkqueue.Len(); n != queued {
+		t.Errorf("expected explaining a release not to enqueue it, got %d items queued instead of %d", n, queued)
+	}
+
+	select {
+	case ev := <-f.recorder.Events:
+		t.Errorf("expected explaining a release not to record events, got %q", ev)
+	default:
+	}
+}
+
+func hasPrefixed(ss []string, prefix string) bool {
+	for _, s := range ss {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,268 @@
+package release
+
+import (
+	gocontext "context"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+)
+
+// TestIncumbentScalesDownWithContenderTraffic runs the 50/50 step of a
+// vanguard strategy that keeps all of the incumbent's capacity around,
+// once the contender has achieved its capacity and traffic, and checks the
+// incumbent is only scaled down when its strategy asks for it.
+func TestIncumbentScalesDownWithContenderTraffic(t *testing.T) {
+	tests := []struct {
+		name               string
+		scaleDownIncumbent bool
+		traffic            shipper.RolloutStrategyStepValue
+		expected           *int32
+	}{
+		{
+			name:               "incumbent keeps the capacity of the step",
+			scaleDownIncumbent: false,
+			traffic:            shipper.RolloutStrategyStepValue{Contender: 50, Incumbent: 50},
+		},
+		{
+			name:               "incumbent keeps capacity for its traffic and headroom",
+			scaleDownIncumbent: true,
+			traffic:            shipper.RolloutStrategyStepValue{Contender: 50, Incumbent: 50},
+			expected:           percentPtr(60),
+		},
+		{
+			name:               "traffic weights of 900 and 100",
+			scaleDownIncumbent: true,
+			traffic:            shipper.RolloutStrategyStepValue{Contender: 900, Incumbent: 100},
+			expected:           percentPtr(20),
+		},
+		{
+			name:               "traffic weights of 1 and 1",
+			scaleDownIncumbent: true,
+			traffic:            shipper.RolloutStrategyStepValue{Contender: 1, Incumbent: 1},
+			expected:           percentPtr(60),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			namespace := "test-namespace"
+			app := buildApplication(namespace, "test-app")
+			cluster := buildCluster("minikube")
+
+			f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+			strategy := vanguard.DeepCopy()
+			strategy.Steps[1].Capacity.Incumbent = 100
+			strategy.Steps[1].Traffic = test.traffic
+			strategy.ScaleDownIncumbent = test.scaleDownIncumbent
+
+			totalReplicaCount := int32(10)
+			incumbent := f.buildIncumbent(namespace, "test-incumbent", totalReplicaCount)
+			incumbent.trafficTarget.Spec.Clusters[0].Weight = uint32(test.traffic.Incumbent)
+
+			contender := f.buildContender(namespace, "test-contender", totalReplicaCount)
+			contender.release.Spec.Environment.Strategy = strategy
+			contender.release.Spec.TargetStep = 1
+			contender.capacityTarget.Spec.Clusters[0].Percent = 50
+			contender.trafficTarget.Spec.Clusters[0].Weight = uint32(test.traffic.Contender)
+
+			f.addObjects(
+				incumbent.release.DeepCopy(),
+				incumbent.installationTarget.DeepCopy(),
+				incumbent.capacityTarget.DeepCopy(),
+				incumbent.trafficTarget.DeepCopy(),
+				contender.release.DeepCopy(),
+				contender.installationTarget.DeepCopy(),
+				contender.capacityTarget.DeepCopy(),
+				contender.trafficTarget.DeepCopy(),
+			)
+
+			f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+			f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+			f.recorder = record.NewFakeRecorder(42)
+
+			c := f.newController()
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			f.informerFactory.Start(stopCh)
+			f.informerFactory.WaitForCacheSync(stopCh)
+
+			_, patches, _, err := c.executeReleaseStrategy(gocontext.Background(), contender, diffutil.NewMultiDiff())
+			if err != nil {
+				t.Fatalf("unexpected error executing strategy: %s", err)
+			}
+
+			var patched *int32
+			for _, patch := range patches {
+				if ctPatch, ok := patch.(*CapacityTargetSpecPatch); ok && ctPatch.Name == incumbent.release.Name {
+					patched = percentPtr(ctPatch.NewSpec.Clusters[0].Percent)
+				}
+			}
+
+			switch {
+			case test.expected == nil && patched != nil:
+				t.Errorf("expected incumbent capacity target not to be patched, got %d%%", *patched)
+			case test.expected != nil && patched == nil:
+				t.Errorf("expected incumbent capacity target to be patched to %d%%, got no patch", *test.expected)
+			case test.expected != nil && *patched != *test.expected:
+				t.Errorf("expected incumbent capacity target to be patched to %d%%, got %d%%", *test.expected, *patched)
+			}
+		})
+	}
+}
+
+func percentPtr(percent int32) *int32 {
+	return &percent
+}
+
+// TestIncumbentNotScaledToZeroBeforeContenderConverges runs the last step
+// of a vanguard strategy for a contender whose target objects are ready,
+// but one of its clusters still reports it short of its capacity or
+// traffic, or hasn't reported any yet, and checks the incumbent is only
+// scaled to zero once it's converged everywhere.
+func TestIncumbentNotScaledToZeroBeforeContenderConverges(t *testing.T) {
+	tests := []struct {
+		name              string
+		totalReplicaCount int32
+		availableReplicas int32
+		achievedPercent   int32
+		achievedTraffic   uint32
+		noCapacityStatus  bool
+		noTrafficStatus   bool
+		expectScaledDown  bool
+	}{
+		{
+			name:              "contender at 99% capacity",
+			totalReplicaCount: 100,
+			availableReplicas: 99,
+			achievedPercent:   99,
+			achievedTraffic:   100,
+		},
+		{
+			// 199 out of 200 replicas is reported as 100% once
+			// rounded up, but the contender is still a replica
+			// short.
+			name:              "contender a replica short of a rounded up 100%",
+			totalReplicaCount: 200,
+			availableReplicas: 199,
+			achievedPercent:   100,
+			achievedTraffic:   100,
+		},
+		{
+			name:              "contender at 99% traffic",
+			totalReplicaCount: 10,
+			availableReplicas: 10,
+			achievedPercent:   100,
+			achievedTraffic:   99,
+		},
+		{
+			name:              "contender with no capacity status for its cluster",
+			totalReplicaCount: 10,
+			availableReplicas: 10,
+			achievedPercent:   100,
+			achievedTraffic:   100,
+			noCapacityStatus:  true,
+		},
+		{
+			name:              "contender with no traffic status for its cluster",
+			totalReplicaCount: 10,
+			availableReplicas: 10,
+			achievedPercent:   100,
+			achievedTraffic:   100,
+			noTrafficStatus:   true,
+		},
+		{
+			name:              "contender converged",
+			totalReplicaCount: 10,
+			availableReplicas: 10,
+			achievedPercent:   100,
+			achievedTraffic:   100,
+			expectScaledDown:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			namespace := "test-namespace"
+			app := buildApplication(namespace, "test-app")
+			cluster := buildCluster("minikube")
+
+			f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+			totalReplicaCount := test.totalReplicaCount
+			incumbent := f.buildIncumbent(namespace, "test-incumbent", totalReplicaCount)
+			incumbent.trafficTarget.Spec.Clusters[0].Weight = 0
+
+			contender := f.buildContender(namespace, "test-contender", totalReplicaCount)
+			contender.release.Spec.TargetStep = 2
+			contender.capacityTarget.Spec.Clusters[0].Percent = 100
+			contender.capacityTarget.Status.Clusters = []shipper.ClusterCapacityStatus{
+				{
+					Name:              cluster.Name,
+					AvailableReplicas: test.availableReplicas,
+					AchievedPercent:   test.achievedPercent,
+				},
+			}
+			contender.trafficTarget.Spec.Clusters[0].Weight = 100
+			contender.trafficTarget.Status.Clusters = []*shipper.ClusterTrafficStatus{
+				{Name: cluster.Name, AchievedTraffic: test.achievedTraffic},
+			}
+			if test.noCapacityStatus {
+				contender.capacityTarget.Status.Clusters = nil
+			}
+			if test.noTrafficStatus {
+				contender.trafficTarget.Status.Clusters = nil
+			}
+
+			f.addObjects(
+				incumbent.release.DeepCopy(),
+				incumbent.installationTarget.DeepCopy(),
+				incumbent.capacityTarget.DeepCopy(),
+				incumbent.trafficTarget.DeepCopy(),
+				contender.release.DeepCopy(),
+				contender.installationTarget.DeepCopy(),
+				contender.capacityTarget.DeepCopy(),
+				contender.trafficTarget.DeepCopy(),
+			)
+
+			f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+			f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+			f.recorder = record.NewFakeRecorder(42)
+
+			c := f.newController()
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			f.informerFactory.Start(stopCh)
+			f.informerFactory.WaitForCacheSync(stopCh)
+
+			_, patches, _, err := c.executeReleaseStrategy(gocontext.Background(), contender, diffutil.NewMultiDiff())
+			if err != nil {
+				t.Fatalf("unexpected error executing strategy: %s", err)
+			}
+
+			var patched *int32
+			for _, patch := range patches {
+				if ctPatch, ok := patch.(*CapacityTargetSpecPatch); ok && ctPatch.Name == incumbent.release.Name {
+					patched = percentPtr(ctPatch.NewSpec.Clusters[0].Percent)
+				}
+			}
+
+			switch {
+			case !test.expectScaledDown && patched != nil:
+				t.Errorf("expected incumbent capacity target not to be patched, got %d%%", *patched)
+			case test.expectScaledDown && (patched == nil || *patched != 0):
+				t.Errorf("expected incumbent capacity target to be scaled to zero, got patch %v", patched)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// withManagedByLabel returns a merge patch that does everything patch does,
+// and also sets the shipper.ManagedByLabel of the patched object to
+// instanceID. This way, when more than one controller instance ends up
+// acting on the same objects, it's clear which one touched them last.
+// patch is returned as is if instanceID is empty.
+func withManagedByLabel(patch []byte, instanceID string) ([]byte, error) {
+	if instanceID == "" {
+		return patch, nil
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(patch, &obj); err != nil {
+		return nil, fmt.Errorf("failed to decode patch to add %q label: %s", shipper.ManagedByLabel, err)
+	}
+	if obj == nil {
+		obj = map[string]interface{}{}
+	}
+
+	metadata, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = map[string]interface{}{}
+		obj["metadata"] = metadata
+	}
+
+	labels, ok := metadata["labels"].(map[string]interface{})
+	if !ok {
+		labels = map[string]interface{}{}
+		metadata["labels"] = labels
+	}
+
+	labels[shipper.ManagedByLabel] = instanceID
+
+	return json.Marshal(obj)
+}
@@ -0,0 +1,96 @@
+package release
+
+import (
+	gocontext "context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+)
+
+func TestPatchedTargetObjectsAreLabeledWithInstanceID(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	f.instanceID = "shipper-0"
+	contender := f.buildContender(namespace, "test-contender", 10)
+	contender.capacityTarget.Labels = map[string]string{shipper.ReleaseLabel: contender.release.Name}
+
+	const syncPeriod time.Duration = 0
+	f.clientset = shipperfake.NewSimpleClientset(
+		contender.release.DeepCopy(),
+		contender.capacityTarget.DeepCopy(),
+	)
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, syncPeriod)
+	f.recorder = record.NewFakeRecorder(42)
+	controller := f.newController()
+
+	patch := &CapacityTargetSpecPatch{
+		Name: contender.capacityTarget.Name,
+		NewSpec: &shipper.CapacityTargetSpec{
+			Clusters: []shipper.ClusterCapacityTarget{
+				{Name: "minikube", Percent: 50, TotalReplicaCount: 10},
+			},
+		},
+	}
+
+	if err := controller.applyPatch(gocontext.Background(), namespace, patch); err != nil {
+		t.Fatalf("unexpected error applying patch: %s", err)
+	}
+
+	ct, err := f.clientset.ShipperV1alpha1().CapacityTargets(namespace).Get(contender.capacityTarget.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error getting capacity target: %s", err)
+	}
+
+	if got := ct.Labels[shipper.ManagedByLabel]; got != f.instanceID {
+		t.Errorf("expected capacity target to be labeled as managed by %q, got %q", f.instanceID, got)
+	}
+
+	if got := ct.Labels[shipper.ReleaseLabel]; got != contender.release.Name {
+		t.Errorf("expected existing labels to be left alone, got %v", ct.Labels)
+	}
+
+	if got := ct.Spec.Clusters[0].Percent; got != 50 {
+		t.Errorf("expected the patch itself to still be applied, got %d percent", got)
+	}
+
+	// Releases aren't target objects, so they are patched as they
+	// are.
+	relPatch := &ReleaseStrategyStatusPatch{
+		Name:              contender.release.Name,
+		NewStrategyStatus: &shipper.ReleaseStrategyStatus{},
+	}
+	if err := controller.applyPatch(gocontext.Background(), namespace, relPatch); err != nil {
+		t.Fatalf("unexpected error applying patch: %s", err)
+	}
+
+	rel, err := f.clientset.ShipperV1alpha1().Releases(namespace).Get(contender.release.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error getting release: %s", err)
+	}
+
+	if _, ok := rel.Labels[shipper.ManagedByLabel]; ok {
+		t.Errorf("expected release not to be labeled, got %v", rel.Labels)
+	}
+}
+
+func TestWithManagedByLabelWithoutInstanceID(t *testing.T) {
+	patch := []byte(`{"spec":{}}`)
+
+	actual, err := withManagedByLabel(patch, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if string(actual) != string(patch) {
+		t.Errorf("expected patch to be left untouched, got %s", actual)
+	}
+}
@@ -0,0 +1,108 @@
+package release
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+// observedTargets remembers, for every release we successfully reconciled,
+// a fingerprint of the objects that reconcile depended on. Together with
+// the release's observed generation, it allows us to tell apart a resync
+// that has nothing new to offer from one that has to be acted upon.
+type observedTargets struct {
+	mu           sync.Mutex
+	fingerprints map[string]string
+}
+
+func newObservedTargets() *observedTargets {
+	return &observedTargets{
+		fingerprints: make(map[string]string),
+	}
+}
+
+// Unchanged returns true if fingerprint is the same one last observed for
+// the release identified by relKey.
+func (o *observedTargets) Unchanged(relKey, fingerprint string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	observed, ok := o.fingerprints[relKey]
+	return ok && observed == fingerprint
+}
+
+func (o *observedTargets) Observe(relKey, fingerprint string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.fingerprints[relKey] = fingerprint
+}
+
+func (o *observedTargets) Forget(relKey string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	delete(o.fingerprints, relKey)
+}
+
+// targetsFingerprint builds a fingerprint out of the resource versions of
+// rel, its sibling releases and all of their target objects, as the
+// strategy executor takes all of them into account when reconciling rel.
+// Target objects that don't exist yet are fingerprinted as such.
+func (c *Controller) targetsFingerprint(rel *shipper.Release) (string, error) {
+	releases, err := c.applicationReleases(rel)
+	if err != nil {
+		return "", err
+	}
+
+	prev, succ, err := releaseutil.GetSiblingReleases(rel, releases)
+	if err != nil {
+		return "", err
+	}
+
+	var parts []string
+	for _, r := range []*shipper.Release{prev, rel, succ} {
+		if r == nil {
+			parts = append(parts, "-")
+			continue
+		}
+
+		ns, name := r.Namespace, r.Name
+		parts = append(parts, fmt.Sprintf("Release/%s=%s", name, r.ResourceVersion))
+
+		it, itErr := c.installationTargetLister.InstallationTargets(ns).Get(name)
+		ct, ctErr := c.capacityTargetLister.CapacityTargets(ns).Get(name)
+		tt, ttErr := c.trafficTargetLister.TrafficTargets(ns).Get(name)
+
+		targets := []struct {
+			kind string
+			obj  metav1.Object
+			err  error
+		}{
+			{"InstallationTarget", it, itErr},
+			{"CapacityTarget", ct, ctErr},
+			{"TrafficTarget", tt, ttErr},
+		}
+
+		for _, target := range targets {
+			if errors.IsNotFound(target.err) {
+				parts = append(parts, fmt.Sprintf("%s/%s=-", target.kind, name))
+				continue
+			} else if target.err != nil {
+				return "", shippererrors.NewKubeclientGetError(ns, name, target.err).
+					WithShipperKind(target.kind)
+			}
+
+			parts = append(parts, fmt.Sprintf("%s/%s=%s", target.kind, name, target.obj.GetResourceVersion()))
+		}
+	}
+
+	return strings.Join(parts, ","), nil
+}
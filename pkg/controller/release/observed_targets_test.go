@@ -0,0 +1,31 @@
+package release
+
+import (
+	"testing"
+)
+
+func TestObservedTargets(t *testing.T) {
+	const relKey = "test-namespace/test-release"
+
+	o := newObservedTargets()
+
+	if o.Unchanged(relKey, "a") {
+		t.Fatalf("expected a release never observed to be considered changed")
+	}
+
+	o.Observe(relKey, "a")
+	if !o.Unchanged(relKey, "a") {
+		t.Fatalf("expected the same fingerprint to be considered unchanged")
+	}
+	if o.Unchanged(relKey, "b") {
+		t.Fatalf("expected a different fingerprint to be considered changed")
+	}
+	if o.Unchanged("test-namespace/other-release", "a") {
+		t.Fatalf("expected fingerprints to be tracked per release")
+	}
+
+	o.Forget(relKey)
+	if o.Unchanged(relKey, "a") {
+		t.Fatalf("expected a forgotten release to be considered changed")
+	}
+}
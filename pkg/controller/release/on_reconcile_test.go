@@ -0,0 +1,58 @@
+package release
+
+import (
+	gocontext "context"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/record"
+
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+)
+
+func TestOnReconcile(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+	defer c.releaseWorkqueue.ShutDown()
+
+	type reconcile struct {
+		key string
+		err error
+	}
+	var reconciles []reconcile
+	c.OnReconcile = func(key string, err error) {
+		reconciles = append(reconciles, reconcile{key, err})
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.informerFactory.Start(stopCh)
+	f.informerFactory.WaitForCacheSync(stopCh)
+
+	c.releaseWorkqueue.Add("test-namespace/test-missing")
+	c.releaseWorkqueue.Add("not/a/valid/key")
+	c.processNextReleaseWorkItem(gocontext.Background())
+	c.processNextReleaseWorkItem(gocontext.Background())
+
+	if len(reconciles) != 2 {
+		t.Fatalf("expected 2 reconciles to be reported, got %d", len(reconciles))
+	}
+
+	if r := reconciles[0]; r.key != "test-namespace/test-missing" || r.err != nil {
+		t.Errorf("expected reconcile of a missing release to succeed, got key %q and error %v", r.key, r.err)
+	}
+
+	if r := reconciles[1]; r.key != "not/a/valid/key" || r.err == nil {
+		t.Errorf("expected reconcile of an invalid key to fail, got key %q and error %v", r.key, r.err)
+	}
+}
@@ -0,0 +1,128 @@
+package release
+
+import (
+	gocontext "context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	kubetesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+// TestSlowPatchTimesOutAndIsRetried sends a patch that takes longer than
+// the patch timeout, but well within the deadline of the sync it's sent
+// from, and checks it fails with a retriable error without taking the
+// whole sync down with it, and goes through once it's sent again.
+func TestSlowPatchTimesOutAndIsRetried(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	f.patchTimeout = 50 * time.Millisecond
+	contender := f.buildContender(namespace, "test-contender", 10)
+
+	const syncPeriod time.Duration = 0
+	clientset := shipperfake.NewSimpleClientset(contender.capacityTarget.DeepCopy())
+
+	// The first patch hangs until the test is done waiting for it. The
+	// fake clientset serializes its actions, so it has to be unblocked
+	// before the patch is sent again.
+	var attempts int32
+	unblock := make(chan struct{})
+	clientset.PrependReactor("patch", "capacitytargets", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			<-unblock
+		}
+		return false, nil, nil
+	})
+
+	f.clientset = clientset
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, syncPeriod)
+	f.recorder = record.NewFakeRecorder(42)
+	controller := f.newController()
+
+	patch := &CapacityTargetSpecPatch{
+		Name: contender.capacityTarget.Name,
+		NewSpec: &shipper.CapacityTargetSpec{
+			Clusters: []shipper.ClusterCapacityTarget{
+				{Name: "minikube", Percent: 50, TotalReplicaCount: 10},
+			},
+		},
+	}
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), time.Minute)
+	defer cancel()
+
+	err := controller.applyPatch(ctx, namespace, patch)
+	close(unblock)
+	if err == nil {
+		t.Fatalf("expected patch to time out, got no error")
+	}
+
+	if !shippererrors.ShouldRetry(err) {
+		t.Errorf("expected patch timeout to be retriable, got %s", err)
+	}
+
+	if ctx.Err() != nil {
+		t.Fatalf("expected the sync context to outlive the patch, got %s", ctx.Err())
+	}
+
+	if err := controller.applyPatch(ctx, namespace, patch); err != nil {
+		t.Fatalf("expected retried patch to go through, got %s", err)
+	}
+
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("expected patch to be sent twice, got %d", got)
+	}
+}
+
+// TestPatchesAreSentWithPatchClientset checks patches go through the
+// clientset meant for them rather than the one the controller reads and
+// writes everything else with, so they're timed out by it.
+func TestPatchesAreSentWithPatchClientset(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	f.patchTimeout = time.Minute
+	contender := f.buildContender(namespace, "test-contender", 10)
+
+	const syncPeriod time.Duration = 0
+	f.clientset = shipperfake.NewSimpleClientset()
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, syncPeriod)
+	f.recorder = record.NewFakeRecorder(42)
+
+	patchClientset := shipperfake.NewSimpleClientset(contender.capacityTarget.DeepCopy())
+	f.patchClientset = patchClientset
+	controller := f.newController()
+
+	patch := &CapacityTargetSpecPatch{
+		Name: contender.capacityTarget.Name,
+		NewSpec: &shipper.CapacityTargetSpec{
+			Clusters: []shipper.ClusterCapacityTarget{
+				{Name: "minikube", Percent: 50, TotalReplicaCount: 10},
+			},
+		},
+	}
+
+	if err := controller.applyPatch(gocontext.Background(), namespace, patch); err != nil {
+		t.Fatalf("unexpected error sending patch: %s", err)
+	}
+
+	if actions := f.clientset.Actions(); len(actions) != 0 {
+		t.Errorf("expected nothing to be sent with the controller clientset, got %v", actions)
+	}
+
+	if actions := patchClientset.Actions(); len(actions) != 1 || actions[0].GetVerb() != "patch" {
+		t.Errorf("expected the patch to be sent with the patch clientset, got %v", actions)
+	}
+}
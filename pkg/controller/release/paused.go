@@ -0,0 +1,109 @@
+package release
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/controller"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+const (
+	ApplicationPaused = "ApplicationPaused"
+)
+
+// applicationIsPaused returns whether app has all of its releases frozen.
+func applicationIsPaused(app *shipper.Application) bool {
+	return app.Annotations[shipper.AppPausedAnnotation] == shipper.True
+}
+
+// parentPaused returns the name of the application rel belongs to, and
+// whether that application is paused. Releases are never considered
+// paused unless the controller was asked to honor paused applications.
+func (c *Controller) parentPaused(rel *shipper.Release) (string, bool, error) {
+	if !c.honorPausedApplications {
+		return "", false, nil
+	}
+
+	appName, err := releaseutil.ApplicationNameForRelease(rel)
+	if err != nil {
+		return "", false, err
+	}
+
+	app, err := c.applicationLister.Applications(rel.Namespace).Get(appName)
+	if errors.IsNotFound(err) {
+		return appName, false, nil
+	} else if err != nil {
+		return "", false, shippererrors.NewKubeclientGetError(rel.Namespace, appName, err).
+			WithShipperKind("Application")
+	}
+
+	return appName, applicationIsPaused(app), nil
+}
+
+// setParentPaused sets the ParentPaused condition on rel according to
+// paused. The condition is only ever set to False on releases that were
+// paused before, so releases of applications that were never paused don't
+// carry it at all.
+func setParentPaused(rel *shipper.Release, appName string, paused bool, diff *diffutil.MultiDiff) {
+	if paused {
+		condition := releaseutil.NewReleaseCondition(
+			shipper.ReleaseConditionTypeParentPaused,
+			corev1.ConditionTrue,
+			ApplicationPaused,
+			fmt.Sprintf("application %q is paused", appName),
+		)
+		diff.Append(releaseutil.SetReleaseCondition(&rel.Status, *condition))
+		return
+	}
+
+	cond := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeParentPaused)
+	if cond == nil || cond.Status != corev1.ConditionTrue {
+		return
+	}
+
+	condition := releaseutil.NewReleaseCondition(
+		shipper.ReleaseConditionTypeParentPaused,
+		corev1.ConditionFalse,
+		"",
+		"",
+	)
+	diff.Append(releaseutil.SetReleaseCondition(&rel.Status, *condition))
+}
+
+// enqueueReleasesOnPauseChange enqueues all the releases of an application
+// that just got paused or unpaused.
+func (c *Controller) enqueueReleasesOnPauseChange(oldObj, newObj interface{}) {
+	oldApp, ok := oldObj.(*shipper.Application)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.Application: %#v", oldObj))
+		return
+	}
+
+	newApp, ok := newObj.(*shipper.Application)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.Application: %#v", newObj))
+		return
+	}
+
+	if applicationIsPaused(oldApp) == applicationIsPaused(newApp) {
+		return
+	}
+
+	releases, err := c.releaseLister.Releases(newApp.Namespace).ReleasesForApplication(newApp.Name)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("failed to list releases for shipper.Application %q: %s", newApp.Name, err))
+		return
+	}
+
+	for _, rel := range releases {
+		c.observedTargets.Forget(controller.MetaKey(rel))
+		c.enqueueRelease(rel)
+	}
+}
@@ -0,0 +1,75 @@
+package release
+
+import (
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	kubetesting "k8s.io/client-go/testing"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+func TestReleaseOfPausedApplicationIsNotProcessed(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	app.Annotations = map[string]string{shipper.AppPausedAnnotation: shipper.True}
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	f.cycles = 1
+	f.honorPausedApplications = true
+
+	contender := f.buildContender(namespace, "test-contender", 1)
+	delete(contender.release.Annotations, shipper.ReleaseClustersAnnotation)
+	f.addObjects(contender.release.DeepCopy())
+
+	expected := contender.release.DeepCopy()
+	condition := releaseutil.NewReleaseCondition(
+		shipper.ReleaseConditionTypeParentPaused,
+		corev1.ConditionTrue,
+		ApplicationPaused,
+		fmt.Sprintf("application %q is paused", app.Name),
+	)
+	releaseutil.SetReleaseCondition(&expected.Status, *condition)
+
+	// Nothing but the release itself is touched: it isn't scheduled,
+	// and none of its target objects get created.
+	f.actions = []kubetesting.Action{
+		kubetesting.NewUpdateSubresourceAction(
+			shipper.SchemeGroupVersion.WithResource("releases"),
+			"status",
+			namespace,
+			expected),
+	}
+
+	f.expectedEvents = []string{
+		fmt.Sprintf("Normal ReleaseConditionChanged [] -> [ParentPaused True %s application %q is paused]", ApplicationPaused, app.Name),
+	}
+
+	f.run()
+}
+
+func TestSetParentPaused(t *testing.T) {
+	rel := buildRelease()
+
+	diff := diffutil.NewMultiDiff()
+	setParentPaused(rel, "test-app", false, diff)
+	if cond := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeParentPaused); cond != nil {
+		t.Fatalf("expected releases that were never paused to not have a ParentPaused condition, got %v", cond)
+	}
+
+	setParentPaused(rel, "test-app", true, diff)
+	cond := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeParentPaused)
+	if cond == nil || cond.Status != corev1.ConditionTrue {
+		t.Fatalf("expected ParentPaused to be True, got %v", cond)
+	}
+
+	setParentPaused(rel, "test-app", false, diff)
+	cond = releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeParentPaused)
+	if cond == nil || cond.Status != corev1.ConditionFalse {
+		t.Fatalf("expected ParentPaused to be False once unpaused, got %v", cond)
+	}
+}
@@ -0,0 +1,44 @@
+package release
+
+import (
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperlisters "github.com/bookingcom/shipper/pkg/client/listers/shipper/v1alpha1"
+	shipperworkqueue "github.com/bookingcom/shipper/pkg/workqueue"
+)
+
+// newReleaseRateLimiter returns the rate limiter for the release workqueue.
+// Releases are retried faster or slower than usual depending on the
+// priority they ask for in their shipper.ReleasePriorityAnnotation.
+// Releases that don't say, or say something we don't understand, get the
+// normal priority.
+func newReleaseRateLimiter(releaseLister shipperlisters.ReleaseLister) workqueue.RateLimiter {
+	limiters := map[string]workqueue.RateLimiter{
+		shipper.ReleasePriorityHigh:   shipperworkqueue.NewJitteredFastSlowRateLimiter(time.Millisecond, time.Second, 5),
+		shipper.ReleasePriorityNormal: shipperworkqueue.NewDefaultControllerRateLimiter(),
+		shipper.ReleasePriorityLow:    shipperworkqueue.NewJitteredFastSlowRateLimiter(50*time.Millisecond, 30*time.Second, 3),
+	}
+
+	return shipperworkqueue.NewPriorityRateLimiter(limiters, shipper.ReleasePriorityNormal, func(item interface{}) string {
+		key, ok := item.(string)
+		if !ok {
+			return shipper.ReleasePriorityNormal
+		}
+
+		namespace, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			return shipper.ReleasePriorityNormal
+		}
+
+		rel, err := releaseLister.Releases(namespace).Get(name)
+		if err != nil {
+			return shipper.ReleasePriorityNormal
+		}
+
+		return rel.Annotations[shipper.ReleasePriorityAnnotation]
+	})
+}
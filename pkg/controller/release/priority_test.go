@@ -0,0 +1,63 @@
+package release
+
+import (
+	"testing"
+	"time"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+)
+
+// TestHighPriorityReleasesAreRetriedFaster checks that releases asking for a
+// high priority are retried with shorter delays than the rest, and that they
+// keep them after the first few fast retries.
+func TestHighPriorityReleasesAreRetriedFaster(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+	highPriority := f.buildContender(namespace, "test-high-priority", 1).release
+	highPriority.Annotations[shipper.ReleasePriorityAnnotation] = shipper.ReleasePriorityHigh
+	normalPriority := f.buildContender(namespace, "test-normal-priority", 1).release
+	bogusPriority := f.buildContender(namespace, "test-bogus-priority", 1).release
+	bogusPriority.Annotations[shipper.ReleasePriorityAnnotation] = "bogus"
+
+	informerFactory := shipperinformers.NewSharedInformerFactory(shipperfake.NewSimpleClientset(), time.Duration(0))
+	releaseInformer := informerFactory.Shipper().V1alpha1().Releases()
+	for _, rel := range []*shipper.Release{highPriority, normalPriority, bogusPriority} {
+		if err := releaseInformer.Informer().GetIndexer().Add(rel.DeepCopy()); err != nil {
+			t.Fatalf("failed to add release to informer cache: %s", err)
+		}
+	}
+
+	limiter := newReleaseRateLimiter(releaseInformer.Lister())
+
+	highKey := namespace + "/" + highPriority.Name
+	normalKey := namespace + "/" + normalPriority.Name
+	bogusKey := namespace + "/" + bogusPriority.Name
+
+	for i := 0; i < 5; i++ {
+		high, normal, bogus := limiter.When(highKey), limiter.When(normalKey), limiter.When(bogusKey)
+		if high >= normal {
+			t.Errorf("retry %d: expected high priority release to be retried sooner than %s, got %s", i, normal, high)
+		}
+
+		// The normal limiter never waits less than its fast delay,
+		// jitter only ever adds to it.
+		if bogus < 5*time.Millisecond {
+			t.Errorf("retry %d: expected release with an unknown priority to be retried like a normal one, got %s", i, bogus)
+		}
+	}
+
+	if n := limiter.NumRequeues(highKey); n != 5 {
+		t.Errorf("expected high priority release to have been requeued 5 times, got %d", n)
+	}
+
+	limiter.Forget(highKey)
+	if n := limiter.NumRequeues(highKey); n != 0 {
+		t.Errorf("expected high priority release to be forgotten, got %d requeues", n)
+	}
+}
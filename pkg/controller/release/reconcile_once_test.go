@@ -0,0 +1,175 @@
+package release
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/record"
+
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+)
+
+// TestReconcileOnce reconciles a contender that achieved the first step of
+// its strategy directly, without going through the workqueue, and checks
+// it's marked as waiting for a command right away, even though its targets
+// look just like they did the last time it was reconciled.
+func TestReconcileOnce(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	incumbentName, contenderName := "test-incumbent", "test-contender"
+	app.Status.History = []string{incumbentName, contenderName}
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+	totalReplicaCount := int32(10)
+	incumbent := f.buildIncumbent(namespace, incumbentName, totalReplicaCount)
+	contender := f.buildContender(namespace, contenderName, totalReplicaCount)
+
+	contender.capacityTarget.Spec.Clusters[0].Percent = 1
+	incumbent.capacityTarget.Spec.Clusters[0].Percent = 100
+
+	f.addObjects(
+		contender.release.DeepCopy(),
+		contender.installationTarget.DeepCopy(),
+		contender.capacityTarget.DeepCopy(),
+		contender.trafficTarget.DeepCopy(),
+
+		incumbent.release.DeepCopy(),
+		incumbent.installationTarget.DeepCopy(),
+		incumbent.capacityTarget.DeepCopy(),
+		incumbent.trafficTarget.DeepCopy(),
+	)
+	f.expectReleaseWaitingForCommand(contender.release, 0)
+
+	f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.informerFactory.Start(stopCh)
+	f.informerFactory.WaitForCacheSync(stopCh)
+
+	rel, err := c.releaseLister.Releases(namespace).Get(contenderName)
+	if err != nil {
+		t.Fatalf("unexpected error getting release: %s", err)
+	}
+
+	fingerprint, err := c.targetsFingerprint(rel)
+	if err != nil {
+		t.Fatalf("unexpected error fingerprinting release targets: %s", err)
+	}
+	c.observedTargets.Observe(namespace+"/"+contenderName, fingerprint)
+
+	if err := c.ReconcileOnce(namespace, contenderName); err != nil {
+		t.Fatalf("unexpected error reconciling release: %s", err)
+	}
+
+	actual := shippertesting.FilterActions(f.clientset.Actions())
+	actual = f.filter.DoFilter(actual)
+
+	shippertesting.CheckActions(f.actions, actual, f.t)
+}
+
+// TestReconcileOnceMissingRelease checks reconciling a release that's gone
+// is not an error, just like it isn't when it comes out of the workqueue.
+func TestReconcileOnceMissingRelease(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.informerFactory.Start(stopCh)
+	f.informerFactory.WaitForCacheSync(stopCh)
+
+	if err := c.ReconcileOnce(namespace, "test-missing"); err != nil {
+		t.Fatalf("expected no error reconciling a missing release, got: %s", err)
+	}
+}
+
+// TestReconcileOnceOtherShard checks releases in a namespace that belongs
+// to another shard aren't reconciled.
+func TestReconcileOnceOtherShard(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	f.shard = Shard{Index: 0, Count: 2}
+	if f.shard.Owns(namespace) {
+		f.shard.Index = 1
+	}
+
+	contender := f.buildContender(namespace, "test-contender", 10)
+	f.addObjects(contender.release.DeepCopy())
+
+	f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.informerFactory.Start(stopCh)
+	f.informerFactory.WaitForCacheSync(stopCh)
+
+	if err := c.ReconcileOnce(namespace, contender.release.Name); err == nil {
+		t.Fatalf("expected an error reconciling a release of another shard")
+	}
+
+	if actions := shippertesting.FilterActions(f.clientset.Actions()); len(actions) != 0 {
+		t.Errorf("expected nothing to be sent for a release of another shard, got %v", actions)
+	}
+}
+
+func TestAppLocksSerializeReconciles(t *testing.T) {
+	locks := newAppLocks()
+
+	unlock := locks.Lock("test-namespace/test-app")
+
+	locked := make(chan struct{})
+	go func() {
+		locks.Lock("test-namespace/test-app")()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatalf("expected a second reconcile of the same application to wait")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Reconciles of other applications don't have to wait.
+	locks.Lock("test-namespace/other-app")()
+
+	unlock()
+
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the second reconcile to go ahead once the first one is over")
+	}
+
+	locks.mu.Lock()
+	defer locks.mu.Unlock()
+	if n := len(locks.locks); n != 0 {
+		t.Errorf("expected no locks to be kept around once they're released, got %d", n)
+	}
+}
@@ -0,0 +1,138 @@
+package release
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TraceEntry summarizes a single reconcile of a release: what the release
+// controller did about it, the patches it applied and the error it ran
+// into, if any.
+type TraceEntry struct {
+	Time    time.Time       `json:"time"`
+	Action  ReconcileAction `json:"action"`
+	Reason  string          `json:"reason,omitempty"`
+	Patches []string        `json:"patches,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// ReconcileTraceLog keeps the last few reconciles of every release in
+// memory, so what the release controller did to a release recently can be
+// looked at after the fact without verbose logging being on all the time.
+// It can be exposed as an HTTP endpoint that returns those entries as JSON.
+type ReconcileTraceLog struct {
+	size int
+
+	mu     sync.Mutex
+	traces map[string]*traceRing
+}
+
+var _ http.Handler = (*ReconcileTraceLog)(nil)
+
+// NewReconcileTraceLog returns a ReconcileTraceLog keeping the last size
+// reconciles of every release. Nothing is kept when size is 0.
+func NewReconcileTraceLog(size int) *ReconcileTraceLog {
+	return &ReconcileTraceLog{
+		size:   size,
+		traces: make(map[string]*traceRing),
+	}
+}
+
+// Record stores entry in the trace of the release identified by key,
+// overwriting its oldest one if the trace is full.
+func (l *ReconcileTraceLog) Record(key string, entry TraceEntry) {
+	if l.size <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ring, ok := l.traces[key]
+	if !ok {
+		ring = &traceRing{entries: make([]TraceEntry, l.size)}
+		l.traces[key] = ring
+	}
+
+	ring.add(entry)
+}
+
+// Trace returns the entries in the trace of the release identified by
+// key, oldest first.
+func (l *ReconcileTraceLog) Trace(key string) []TraceEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ring, ok := l.traces[key]
+	if !ok {
+		return nil
+	}
+
+	return ring.list()
+}
+
+// Forget drops the trace of the release identified by key, as when it's
+// been deleted.
+func (l *ReconcileTraceLog) Forget(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.traces, key)
+}
+
+// ServeHTTP returns the trace of the release named by the "release" query
+// parameter, as in ?release=namespace/name, or the traces of all releases
+// keyed by release when there's none.
+func (l *ReconcileTraceLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body interface{}
+	if key := r.URL.Query().Get("release"); key != "" {
+		body = l.Trace(key)
+	} else {
+		l.mu.Lock()
+		traces := make(map[string][]TraceEntry, len(l.traces))
+		for key, ring := range l.traces {
+			traces[key] = ring.list()
+		}
+		l.mu.Unlock()
+		body = traces
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type traceRing struct {
+	entries []TraceEntry
+	next    int
+	full    bool
+}
+
+func (r *traceRing) add(entry TraceEntry) {
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+func (r *traceRing) list() []TraceEntry {
+	if !r.full {
+		return append([]TraceEntry(nil), r.entries[:r.next]...)
+	}
+
+	entries := make([]TraceEntry, 0, len(r.entries))
+	entries = append(entries, r.entries[r.next:]...)
+	entries = append(entries, r.entries[:r.next]...)
+
+	return entries
+}
+
+// ReconcileTrace returns the last few reconciles of the release identified
+// by key, oldest first.
+func (c *Controller) ReconcileTrace(key string) []TraceEntry {
+	return c.reconcileTraces.Trace(key)
+}
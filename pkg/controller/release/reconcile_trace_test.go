@@ -0,0 +1,114 @@
+package release
+
+import (
+	gocontext "context"
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+)
+
+func TestReconcileTraceLogTrimsOldEntries(t *testing.T) {
+	const key = "test-namespace/test-release"
+
+	log := NewReconcileTraceLog(3)
+	for i := 0; i < 5; i++ {
+		log.Record(key, TraceEntry{Reason: fmt.Sprintf("reconcile-%d", i)})
+	}
+	log.Record("test-namespace/other-release", TraceEntry{Reason: "other"})
+
+	entries := log.Trace(key)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+
+	for i, entry := range entries {
+		expectedReason := fmt.Sprintf("reconcile-%d", i+2)
+		if entry.Reason != expectedReason {
+			t.Errorf("expected entry %d to be %q, got %q", i, expectedReason, entry.Reason)
+		}
+	}
+
+	log.Forget(key)
+	if entries := log.Trace(key); len(entries) != 0 {
+		t.Errorf("expected a forgotten release to have no trace, got %v", entries)
+	}
+
+	if entries := log.Trace("test-namespace/other-release"); len(entries) != 1 {
+		t.Errorf("expected forgetting a release not to touch the others, got %v", entries)
+	}
+}
+
+func TestReconcileTraceLogDisabled(t *testing.T) {
+	const key = "test-namespace/test-release"
+
+	log := NewReconcileTraceLog(0)
+	log.Record(key, TraceEntry{Reason: "reconcile-0"})
+
+	if entries := log.Trace(key); len(entries) != 0 {
+		t.Errorf("expected nothing to be kept, got %v", entries)
+	}
+}
+
+// TestReconcileTraceRecordsPatches reconciles a contender that has to be
+// moved on to its next step, and checks the patches sent for it end up in
+// its trace.
+func TestReconcileTraceRecordsPatches(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	f.reconcileTraces = NewReconcileTraceLog(5)
+
+	contender := f.buildContender(namespace, "test-contender", 10)
+	contender.release.Spec.TargetStep = 1
+	contender.release.Status.AchievedStep = &shipper.AchievedStep{Step: 0, Name: vanguard.Steps[0].Name}
+
+	f.addObjects(
+		contender.release.DeepCopy(),
+		contender.installationTarget.DeepCopy(),
+		contender.capacityTarget.DeepCopy(),
+		contender.trafficTarget.DeepCopy(),
+	)
+
+	f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.informerFactory.Start(stopCh)
+	f.informerFactory.WaitForCacheSync(stopCh)
+
+	key := namespace + "/" + contender.release.Name
+	if err := c.syncOneReleaseHandler(gocontext.Background(), key); err != nil {
+		t.Fatalf("unexpected error syncing release: %s", err)
+	}
+
+	trace := c.ReconcileTrace(key)
+	if len(trace) != 1 {
+		t.Fatalf("expected a single reconcile to be traced, got %d", len(trace))
+	}
+
+	entry := trace[0]
+	if entry.Action != ReconcileActionPatch {
+		t.Errorf("expected action %q, got %q: %s", ReconcileActionPatch, entry.Action, entry.Reason)
+	}
+
+	if entry.Error != "" {
+		t.Errorf("expected no error to be traced, got %q", entry.Error)
+	}
+
+	if !hasPrefixed(entry.Patches, "CapacityTarget "+contender.release.Name) {
+		t.Errorf("expected the contender capacity target patch to be traced, got %v", entry.Patches)
+	}
+}
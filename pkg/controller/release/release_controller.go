@@ -1,7 +1,9 @@
 package release
 
 import (
+	gocontext "context"
 	"fmt"
+	"strings"
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -10,14 +12,19 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 
+	apitrace "go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/label"
+
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
 	shipperrepo "github.com/bookingcom/shipper/pkg/chart/repo"
 	shipperclient "github.com/bookingcom/shipper/pkg/client/clientset/versioned"
@@ -30,6 +37,7 @@ import (
 	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
 	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
 	rolloutblock "github.com/bookingcom/shipper/pkg/util/rolloutblock"
+	trafficutil "github.com/bookingcom/shipper/pkg/util/traffic"
 	shipperworkqueue "github.com/bookingcom/shipper/pkg/workqueue"
 )
 
@@ -38,9 +46,24 @@ const (
 )
 
 const (
-	ClustersNotReady = "ClustersNotReady"
+	ClustersNotReady       = "ClustersNotReady"
+	PatchTooLarge          = "PatchTooLarge"
+	MismatchedApplication  = "MismatchedApplication"
+	TrafficWithoutCapacity = "TrafficWithoutCapacity"
+	CompletionReportFailed = "CompletionReportFailed"
+	WillNotConverge        = "WillNotConverge"
+	ContenderNotConverged  = "ContenderNotConverged"
+	WaitingForDependency   = "WaitingForDependency"
 )
 
+// DefaultMaxPatchSize is the default limit, in bytes, for the size of a
+// single patch the release controller is willing to send to the API server.
+const DefaultMaxPatchSize = 1024 * 1024
+
+// completionReportQueueSize is how many completions can be waiting to be
+// reported at once. Any more than that are dropped.
+const completionReportQueueSize = 100
+
 // Controller is a Kubernetes controller whose role is to pick up a newly created
 // release and progress it forward by scheduling the release on a set of
 // selected clusters, creating a set of associated objects and executing the
@@ -52,6 +75,7 @@ type Controller struct {
 	applicationsSynced cache.InformerSynced
 
 	releaseLister  shipperlisters.ReleaseLister
+	releaseIndexer cache.Indexer
 	releasesSynced cache.InformerSynced
 
 	clusterLister  shipperlisters.ClusterLister
@@ -74,6 +98,60 @@ type Controller struct {
 	chartFetcher shipperrepo.ChartFetcher
 
 	recorder record.EventRecorder
+
+	maxPatchSize int
+
+	conditionEvents *conditionEventDeduper
+
+	observedTargets *observedTargets
+
+	stuckGates *stuckGateTracker
+
+	trafficWithoutCapacity *controller.OnceReporter
+
+	appLocks *appLocks
+
+	honorPausedApplications bool
+
+	// instanceID is stamped as the shipper.ManagedByLabel on every target
+	// object this controller patches. Nothing is stamped when it's empty.
+	instanceID string
+
+	// patchTimeout bounds every single patch this controller sends, so a
+	// slow one can't eat up the whole time a sync has. Patches are only
+	// bound by the context they're sent with when it's 0.
+	patchTimeout time.Duration
+
+	// patchClientset is what patches are sent with. It has requests time
+	// out on their own after patchTimeout, as they can't be cancelled
+	// once they're sent.
+	patchClientset shipperclient.Interface
+
+	completionReporter CompletionReporter
+
+	// completionReports holds the completions waiting to be sent to
+	// completionReporter, up to completionReportQueueSize of them.
+	completionReports chan completionReport
+
+	// shard is the share of namespaces this controller reconciles
+	// releases in. Releases in any other namespace are never enqueued.
+	shard Shard
+
+	// writeBudget is drawn from before sending every write. It's shared
+	// with the other controllers in the process, so together they don't
+	// overwhelm the API server.
+	writeBudget flowcontrol.RateLimiter
+
+	tracer apitrace.Tracer
+
+	// reconcileTraces keeps a summary of the last few reconciles of every
+	// release.
+	reconcileTraces *ReconcileTraceLog
+
+	// OnReconcile, when set, is called with the key of every release
+	// right after it's been reconciled, along with the error the
+	// reconcile ran into, if any.
+	OnReconcile func(key string, err error)
 }
 
 type releaseInfo struct {
@@ -89,13 +167,87 @@ type ReleaseStrategyStateTransition struct {
 	New      shipper.StrategyState
 }
 
+// Config holds the knobs of a release controller. Its zero value is a
+// controller with every optional behaviour disabled.
+type Config struct {
+	// MaxPatchSize is the limit, in bytes, for the size of a single
+	// patch the controller sends. DefaultMaxPatchSize is used when it's
+	// 0.
+	MaxPatchSize int
+
+	// ConditionEventDedupWindow is how long the same condition change
+	// of a release isn't reported again for. Nothing is deduplicated
+	// when it's 0.
+	ConditionEventDedupWindow time.Duration
+
+	// GateStuckThreshold is how long a release can wait on the same gate
+	// before it's marked as stuck. Disabled when 0.
+	GateStuckThreshold time.Duration
+
+	// HonorPausedApplications freezes the releases of paused
+	// applications in place.
+	HonorPausedApplications bool
+
+	// InstanceID is stamped as the shipper.ManagedByLabel on every
+	// target object the controller patches. Nothing is stamped when
+	// it's empty.
+	InstanceID string
+
+	// PatchTimeout bounds every single patch the controller sends.
+	// Disabled when 0.
+	PatchTimeout time.Duration
+
+	// PatchClientset is the clientset patches are sent with. It's meant
+	// to time its requests out after PatchTimeout, so patches the
+	// controller gives up on don't carry on in the background. The
+	// controller's clientset is used when it's nil.
+	PatchClientset shipperclient.Interface
+
+	// CompletionReporter gets every release that completes its
+	// strategy. Completions aren't reported anywhere when it's nil.
+	CompletionReporter CompletionReporter
+
+	// EnqueueDebounce is how long releases are held on to before being
+	// reconciled. Disabled when 0.
+	EnqueueDebounce time.Duration
+
+	// Shard is the share of namespaces the controller reconciles
+	// releases in. Its zero value covers all of them.
+	Shard Shard
+
+	// WriteBudget is drawn from before every write. Writes aren't
+	// limited when it's nil.
+	WriteBudget flowcontrol.RateLimiter
+
+	// ReconcileTraces keeps a summary of the last few reconciles of
+	// every release. Nothing is kept when it's nil.
+	ReconcileTraces *ReconcileTraceLog
+}
+
 func NewController(
 	clientset shipperclient.Interface,
 	informerFactory shipperinformers.SharedInformerFactory,
 	chartFetcher shipperrepo.ChartFetcher,
 	recorder record.EventRecorder,
+	cfg Config,
 ) *Controller {
 
+	if cfg.MaxPatchSize == 0 {
+		cfg.MaxPatchSize = DefaultMaxPatchSize
+	}
+	if cfg.CompletionReporter == nil {
+		cfg.CompletionReporter = NoopCompletionReporter{}
+	}
+	if cfg.WriteBudget == nil {
+		cfg.WriteBudget = shippercontroller.NewWriteBudget(0, 0)
+	}
+	if cfg.ReconcileTraces == nil {
+		cfg.ReconcileTraces = NewReconcileTraceLog(0)
+	}
+	if cfg.PatchClientset == nil {
+		cfg.PatchClientset = clientset
+	}
+
 	applicationInformer := informerFactory.Shipper().V1alpha1().Applications()
 	releaseInformer := informerFactory.Shipper().V1alpha1().Releases()
 	clusterInformer := informerFactory.Shipper().V1alpha1().Clusters()
@@ -113,6 +265,7 @@ func NewController(
 		applicationsSynced: applicationInformer.Informer().HasSynced,
 
 		releaseLister:  releaseInformer.Lister(),
+		releaseIndexer: releaseInformer.Informer().GetIndexer(),
 		releasesSynced: releaseInformer.Informer().HasSynced,
 
 		clusterLister:  clusterInformer.Lister(),
@@ -130,14 +283,54 @@ func NewController(
 		rolloutBlockLister: rolloutBlockInformer.Lister(),
 		rolloutBlockSynced: rolloutBlockInformer.Informer().HasSynced,
 
-		releaseWorkqueue: workqueue.NewNamedRateLimitingQueue(
-			shipperworkqueue.NewDefaultControllerRateLimiter(),
+		releaseWorkqueue: shipperworkqueue.NewNamedDebouncingRateLimitingQueue(
+			newReleaseRateLimiter(releaseInformer.Lister()),
 			"release_controller_releases",
+			cfg.EnqueueDebounce,
 		),
 
 		chartFetcher: chartFetcher,
 
 		recorder: recorder,
+
+		maxPatchSize: cfg.MaxPatchSize,
+
+		conditionEvents: newConditionEventDeduper(cfg.ConditionEventDedupWindow),
+
+		observedTargets: newObservedTargets(),
+
+		stuckGates: newStuckGateTracker(cfg.GateStuckThreshold),
+
+		trafficWithoutCapacity: controller.NewOnceReporter(),
+
+		appLocks: newAppLocks(),
+
+		honorPausedApplications: cfg.HonorPausedApplications,
+
+		instanceID: cfg.InstanceID,
+
+		patchTimeout: cfg.PatchTimeout,
+
+		patchClientset: cfg.PatchClientset,
+
+		completionReporter: cfg.CompletionReporter,
+
+		completionReports: make(chan completionReport, completionReportQueueSize),
+
+		shard: cfg.Shard,
+
+		writeBudget: cfg.WriteBudget,
+
+		tracer: defaultTracer(),
+
+		reconcileTraces: cfg.ReconcileTraces,
+	}
+
+	err := releaseInformer.Informer().AddIndexers(cache.Indexers{
+		releaseDependsOnIndex: releaseDependsOnIndexFunc,
+	})
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("error indexing releases by dependency: %s", err))
 	}
 
 	klog.Info("Setting up event handlers")
@@ -147,6 +340,9 @@ func NewController(
 			AddFunc: controller.enqueueReleaseAndNeighbours,
 			UpdateFunc: func(oldObj, newObj interface{}) {
 				controller.enqueueReleaseAndNeighbours(newObj)
+				controller.enqueueIncumbentOnCompletion(oldObj, newObj)
+				controller.warnOnPhaseRegression(oldObj, newObj)
+				controller.enqueueDependents(newObj)
 			},
 			DeleteFunc: controller.enqueueReleaseAndNeighbours,
 		})
@@ -168,6 +364,24 @@ func NewController(
 	capacityTargetInformer.Informer().AddEventHandler(eventHandler)
 	trafficTargetInformer.Informer().AddEventHandler(eventHandler)
 
+	applicationInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			UpdateFunc: controller.enqueueContenderOnApplicationChange,
+		})
+
+	clusterInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			UpdateFunc: controller.enqueueReleasesOnClusterChange,
+			DeleteFunc: controller.enqueueReleasesOnCluster,
+		})
+
+	if cfg.HonorPausedApplications {
+		applicationInformer.Informer().AddEventHandler(
+			cache.ResourceEventHandlerFuncs{
+				UpdateFunc: controller.enqueueReleasesOnPauseChange,
+			})
+	}
+
 	return controller
 }
 
@@ -193,24 +407,36 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) {
 		return
 	}
 
+	if releases, err := c.releaseLister.List(labels.Everything()); err == nil {
+		if recommended := controller.RecommendThreadiness(len(releases)); threadiness < recommended {
+			klog.Infof("Running Release controller with %d workers, %d are recommended for %d releases",
+				threadiness, recommended, len(releases))
+		}
+	}
+
+	ctx, cancel := controller.WorkerContext(stopCh)
+	defer cancel()
+
 	for i := 0; i < threadiness; i++ {
-		go wait.Until(c.runReleaseWorker, time.Second, stopCh)
+		go wait.Until(func() { c.runReleaseWorker(ctx) }, time.Second, stopCh)
 	}
 
+	go c.runCompletionReporter(stopCh)
+
 	klog.V(4).Info("Started Release controller")
 
 	<-stopCh
 }
 
-func (c *Controller) runReleaseWorker() {
-	for c.processNextReleaseWorkItem() {
+func (c *Controller) runReleaseWorker(ctx gocontext.Context) {
+	for c.processNextReleaseWorkItem(ctx) {
 	}
 }
 
 // processNextReleaseWorkItem pops an element from the head of the workqueue and
 // passes to the sync release handler. It returns bool indicating if the
 // execution process should go on.
-func (c *Controller) processNextReleaseWorkItem() bool {
+func (c *Controller) processNextReleaseWorkItem(ctx gocontext.Context) bool {
 	obj, shutdown := c.releaseWorkqueue.Get()
 	if shutdown {
 		return false
@@ -230,7 +456,7 @@ func (c *Controller) processNextReleaseWorkItem() bool {
 	}
 
 	shouldRetry := false
-	err := c.syncOneReleaseHandler(key)
+	err := c.syncOneReleaseHandler(ctx, key)
 
 	if err != nil {
 		shouldRetry = shippererrors.ShouldRetry(err)
@@ -251,7 +477,34 @@ func (c *Controller) processNextReleaseWorkItem() bool {
 // syncOneReleaseHandler processes release keys one-by-one. This stage progresses
 // the release through a scheduler: assigns a set of chosen clusters, creates
 // required associated objects and marks the release as scheduled.
-func (c *Controller) syncOneReleaseHandler(key string) error {
+func (c *Controller) syncOneReleaseHandler(ctx gocontext.Context, key string) error {
+	unlock := c.lockApplicationForRelease(key)
+	defer unlock()
+
+	ctx, span := c.startSpan(ctx, "syncOneRelease", key)
+	trace := TraceEntry{Time: time.Now()}
+	err := c.syncRelease(ctx, key, &trace)
+	endSpan(ctx, span, err)
+
+	// Reconciles that didn't get as far as deciding anything, as when
+	// nothing changed since the last one, aren't worth keeping around.
+	if err != nil {
+		trace.Error = err.Error()
+	}
+	if trace.Action != "" || trace.Error != "" {
+		c.reconcileTraces.Record(key, trace)
+	}
+
+	if c.OnReconcile != nil {
+		c.OnReconcile(key, err)
+	}
+
+	return err
+}
+
+// syncRelease reconciles the release identified by key, and fills in trace
+// with what it did about it.
+func (c *Controller) syncRelease(ctx gocontext.Context, key string, trace *TraceEntry) error {
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
 		return shippererrors.NewUnrecoverableError(err)
@@ -261,6 +514,10 @@ func (c *Controller) syncOneReleaseHandler(key string) error {
 	if err != nil {
 		if errors.IsNotFound(err) {
 			klog.V(3).Infof("Release %q not found", key)
+			c.observedTargets.Forget(key)
+			c.stuckGates.Forget(key)
+			c.trafficWithoutCapacity.Forget(key)
+			c.reconcileTraces.Forget(key)
 			return nil
 		}
 
@@ -269,6 +526,18 @@ func (c *Controller) syncOneReleaseHandler(key string) error {
 	}
 
 	if releaseutil.HasEmptyEnvironment(rel) {
+		trace.Action = ReconcileActionNone
+		trace.Reason = "release has no environment"
+		return nil
+	}
+
+	fingerprint, err := c.targetsFingerprint(rel)
+	if err != nil {
+		return err
+	}
+
+	if rel.Status.ObservedGeneration == rel.Generation && c.observedTargets.Unchanged(key, fingerprint) {
+		klog.V(4).Infof("Release %q and its target objects have not changed since the last sync, skipping", key)
 		return nil
 	}
 
@@ -276,6 +545,7 @@ func (c *Controller) syncOneReleaseHandler(key string) error {
 	var relinfo *releaseInfo
 	var patches []StrategyPatch
 	var execRel *shipper.Release
+	var requeueAfter time.Duration
 
 	// we keep baseRel as a comparison baseline in order to figure out if
 	// we even have to send an update
@@ -283,13 +553,33 @@ func (c *Controller) syncOneReleaseHandler(key string) error {
 
 	diff := diffutil.NewMultiDiff()
 	defer func() {
-		if !diff.IsEmpty() {
+		if d := c.conditionEvents.Filter(key, *diff); !d.IsEmpty() {
 			// baseRel is kept for the sake of safety: it's
 			// guaranteed to not convert to nil during the execution
-			c.recorder.Event(baseRel, corev1.EventTypeNormal, "ReleaseConditionChanged", diff.String())
+			c.recorder.Event(baseRel, corev1.EventTypeNormal, "ReleaseConditionChanged", d.String())
 		}
 	}()
 
+	// A paused application freezes all of its releases in place. Their
+	// fingerprint is never recorded while paused, as unpausing doesn't
+	// touch any of the objects that make it up.
+	if appName, paused, err := c.parentPaused(rel); err != nil {
+		return err
+	} else if paused {
+		c.observedTargets.Forget(key)
+		setParentPaused(rel, appName, true, diff)
+		if err := c.updateRelease(ctx, rel, baseRel); err != nil {
+			return err
+		}
+
+		trace.Action = ReconcileActionWait
+		trace.Reason = fmt.Sprintf("application %q is paused", appName)
+		klog.V(4).Infof("Application %q is paused, not processing Release %q", appName, key)
+		return nil
+	} else {
+		setParentPaused(rel, appName, false, diff)
+	}
+
 	scheduler := NewScheduler(
 		c.clientset,
 		c.clusterLister,
@@ -299,6 +589,7 @@ func (c *Controller) syncOneReleaseHandler(key string) error {
 		c.rolloutBlockLister,
 		c.chartFetcher,
 		c.recorder,
+		c.writeBudget,
 	)
 
 	rolloutBlocked, events, err := rolloutblock.BlocksRollout(c.rolloutBlockLister, rel)
@@ -320,18 +611,42 @@ func (c *Controller) syncOneReleaseHandler(key string) error {
 		)
 		diff.Append(releaseutil.SetReleaseCondition(&rel.Status, *condition))
 
+		trace.Action = ReconcileActionWait
+		trace.Reason = "release is blocked by a rollout block"
+		if msg != "" {
+			trace.Reason = msg
+		}
+
 		goto ApplyChanges
 	}
 
-	condition = releaseutil.NewReleaseCondition(
-		shipper.ReleaseConditionTypeBlocked,
-		corev1.ConditionFalse,
-		"",
-		"",
-	)
-	diff.Append(releaseutil.SetReleaseCondition(&rel.Status, *condition))
+	diff.Append(releaseutil.ClearBlockedIfResolved(&rel.Status, false, shipper.RolloutBlockReason))
+
+	// Releases that depend on another one are left alone until it gets
+	// far enough along. Their dependency getting there enqueues them
+	// again.
+	if !c.dependencySatisfied(rel) {
+		depName := rel.Annotations[shipper.ReleaseDependsOnAnnotation]
+		c.observedTargets.Forget(key)
+
+		condition = releaseutil.NewReleaseCondition(
+			shipper.ReleaseConditionTypeBlocked,
+			corev1.ConditionTrue,
+			WaitingForDependency,
+			fmt.Sprintf("release is waiting for release %q", depName),
+		)
+		diff.Append(releaseutil.SetReleaseCondition(&rel.Status, *condition))
+
+		trace.Action = ReconcileActionWait
+		trace.Reason = fmt.Sprintf("release depends on release %q", depName)
+		klog.V(4).Infof("Release %q is waiting for release %q, not processing it", key, depName)
 
-	relinfo, err = scheduler.ScheduleRelease(rel.DeepCopy())
+		goto ApplyChanges
+	}
+
+	diff.Append(releaseutil.ClearBlockedIfResolved(&rel.Status, false, WaitingForDependency))
+
+	relinfo, err = scheduler.ScheduleRelease(ctx, rel.DeepCopy())
 	if err != nil {
 		reason := reasonForReleaseCondition(err)
 		condition := releaseutil.NewReleaseCondition(
@@ -342,6 +657,9 @@ func (c *Controller) syncOneReleaseHandler(key string) error {
 		)
 		diff.Append(releaseutil.SetReleaseCondition(&rel.Status, *condition))
 
+		trace.Action = ReconcileActionSchedule
+		trace.Reason = err.Error()
+
 		goto ApplyChanges
 	}
 	rel = relinfo.release
@@ -355,7 +673,57 @@ func (c *Controller) syncOneReleaseHandler(key string) error {
 	)
 	diff.Append(releaseutil.SetReleaseCondition(&rel.Status, *condition))
 
-	execRel, patches, err = c.executeReleaseStrategy(relinfo, diff)
+	if relinfo.trafficTarget != nil {
+		summary := releaseutil.SummarizeForRelease(relinfo.trafficTarget)
+		rel.Status.TrafficSummary = &summary
+	}
+
+	if clusters := c.trafficWithoutCapacity.UnreportedNames(
+		key, "", releaseutil.TrafficWithoutCapacity(relinfo.trafficTarget, relinfo.capacityTarget),
+	); len(clusters) > 0 {
+		c.recorder.Eventf(
+			rel,
+			corev1.EventTypeWarning,
+			TrafficWithoutCapacity,
+			"Release has traffic weight but no capacity in clusters %v",
+			clusters,
+		)
+	}
+
+	if msg, err := c.checkWillConverge(relinfo); err != nil {
+		return err
+	} else if msg != "" {
+		condition = releaseutil.NewReleaseCondition(
+			shipper.ReleaseConditionTypeBlocked,
+			corev1.ConditionTrue,
+			WillNotConverge,
+			msg,
+		)
+		diff.Append(releaseutil.SetReleaseCondition(&rel.Status, *condition))
+
+		trace.Action = ReconcileActionWait
+		trace.Reason = msg
+
+		goto ApplyChanges
+	}
+	diff.Append(releaseutil.ClearBlockedIfResolved(&rel.Status, false, WillNotConverge))
+
+	execRel, patches, requeueAfter, err = c.executeReleaseStrategy(ctx, relinfo, diff)
+	if shippererrors.IsMismatchedApplicationError(err) {
+		condition = releaseutil.NewReleaseCondition(
+			shipper.ReleaseConditionTypeBlocked,
+			corev1.ConditionTrue,
+			MismatchedApplication,
+			err.Error(),
+		)
+		diff.Append(releaseutil.SetReleaseCondition(&rel.Status, *condition))
+
+		trace.Action = ReconcileActionWait
+		trace.Reason = err.Error()
+
+		goto ApplyChanges
+	}
+
 	if err != nil {
 		releaseStrategyExecutedCond := releaseutil.NewReleaseCondition(
 			shipper.ReleaseConditionTypeStrategyExecuted,
@@ -365,22 +733,69 @@ func (c *Controller) syncOneReleaseHandler(key string) error {
 		)
 		diff.Append(releaseutil.SetReleaseCondition(&rel.Status, *releaseStrategyExecutedCond))
 
+		trace.Action = ReconcileActionWait
+		trace.Reason = "failed to execute strategy"
+
 		goto ApplyChanges
 	}
 	rel = execRel
+	diff.Append(releaseutil.ClearBlockedIfResolved(&rel.Status, false, MismatchedApplication))
+	for _, condition := range releaseutil.ComputeReleaseConditions(
+		relinfo.installationTarget,
+		relinfo.trafficTarget,
+		relinfo.capacityTarget,
+	) {
+		diff.Append(releaseutil.SetReleaseCondition(&rel.Status, condition))
+	}
+	if remaining := c.checkStuckGate(key, rel, patches, diff); remaining > 0 && (requeueAfter <= 0 || remaining < requeueAfter) {
+		requeueAfter = remaining
+	}
+	patches = c.dropOversizedPatches(rel, patches, diff)
+
+	if len(patches) > 0 {
+		trace.Action = ReconcileActionPatch
+		trace.Reason = fmt.Sprintf("%d patches to send", len(patches))
+	} else if releaseutil.ReleaseAchievedTargetStep(rel) {
+		trace.Action = ReconcileActionNone
+		trace.Reason = fmt.Sprintf("step [%d] is achieved", rel.Spec.TargetStep)
+	} else {
+		trace.Action = ReconcileActionWait
+		trace.Reason = fmt.Sprintf("waiting for step [%d] to be achieved", rel.Spec.TargetStep)
+	}
 
 ApplyChanges:
 
+	if err == nil {
+		rel.Status.ObservedGeneration = rel.Generation
+	}
+
 	if !equality.Semantic.DeepEqual(rel, baseRel) {
-		if _, updErr := c.clientset.ShipperV1alpha1().Releases(rel.Namespace).Update(rel); updErr != nil {
+		if updErr := c.updateRelease(ctx, rel, baseRel); updErr != nil {
 			return updErr
 		}
+
+		if !releaseutil.ReleaseComplete(baseRel) && releaseutil.ReleaseComplete(rel) && relinfo != nil {
+			c.reportCompletion(rel, relinfo.trafficTarget)
+		}
 	}
 
 	for _, patch := range patches {
-		if err := c.applyPatch(namespace, patch); err != nil {
+		if err := c.applyPatch(ctx, namespace, patch); err != nil {
 			return err
 		}
+
+		name, gvk, _ := patch.PatchSpec()
+		trace.Patches = append(trace.Patches, fmt.Sprintf("%s %s", gvk.Kind, name))
+	}
+
+	// A blocked release doesn't get its fingerprint recorded: lifting
+	// the block doesn't touch any of the objects that make it up. Neither
+	// does one that asked to be synced again later, as nothing might
+	// have changed by then either.
+	if err == nil && requeueAfter > 0 {
+		c.requeueReleaseAfter(key, requeueAfter)
+	} else if err == nil && !releaseutil.ReleaseBlocked(rel) {
+		c.observedTargets.Observe(key, fingerprint)
 	}
 
 	klog.V(4).Infof("Done processing Release %q", key)
@@ -388,6 +803,41 @@ ApplyChanges:
 	return err
 }
 
+// updateRelease writes whatever changed in rel since baseRel. Its metadata
+// goes through the release itself and its status through the status
+// subresource, so recording the observed generation doesn't bump the
+// generation in turn. Both draw from the write budget first.
+func (c *Controller) updateRelease(ctx gocontext.Context, rel, baseRel *shipper.Release) error {
+	client := c.clientset.ShipperV1alpha1().Releases(rel.Namespace)
+	status := rel.Status
+
+	if !equality.Semantic.DeepEqual(rel.ObjectMeta, baseRel.ObjectMeta) ||
+		!equality.Semantic.DeepEqual(rel.Spec, baseRel.Spec) {
+		if err := c.writeBudget.Wait(ctx); err != nil {
+			return err
+		}
+
+		updated, err := client.Update(rel)
+		if err != nil {
+			return err
+		}
+		rel = updated
+		rel.Status = status
+	}
+
+	if !equality.Semantic.DeepEqual(status, baseRel.Status) {
+		if err := c.writeBudget.Wait(ctx); err != nil {
+			return err
+		}
+
+		if _, err := client.UpdateStatus(rel); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (c *Controller) applicationReleases(rel *shipper.Release) ([]*shipper.Release, error) {
 	appName, err := releaseutil.ApplicationNameForRelease(rel)
 	if err != nil {
@@ -400,23 +850,35 @@ func (c *Controller) applicationReleases(rel *shipper.Release) ([]*shipper.Relea
 	return releases, nil
 }
 
-func (c *Controller) executeReleaseStrategy(relinfo *releaseInfo, diff *diffutil.MultiDiff) (*shipper.Release, []StrategyPatch, error) {
+func (c *Controller) executeReleaseStrategy(ctx gocontext.Context, relinfo *releaseInfo, diff *diffutil.MultiDiff) (_ *shipper.Release, _ []StrategyPatch, _ time.Duration, err error) {
 	rel := relinfo.release.DeepCopy()
 
+	ctx, span := c.startSpan(ctx, "buildStrategy", controller.MetaKey(rel))
+	defer func() { endSpan(ctx, span, err) }()
+
 	releases, err := c.applicationReleases(rel)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, 0, err
 	}
 	prev, succ, err := releaseutil.GetSiblingReleases(rel, releases)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, 0, err
+	}
+
+	for _, sibling := range []*shipper.Release{prev, succ} {
+		if sibling == nil {
+			continue
+		}
+		if err := releaseutil.ValidateSameApplication(rel, sibling); err != nil {
+			return nil, nil, 0, err
+		}
 	}
 
 	var relinfoPrev, relinfoSucc *releaseInfo
 	if prev != nil {
 		relinfoPrev, err = c.buildReleaseInfo(prev)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, 0, err
 		}
 	}
 	if succ != nil {
@@ -427,7 +889,7 @@ func (c *Controller) executeReleaseStrategy(relinfo *releaseInfo, diff *diffutil
 			// external modification to the current release object,
 			// which can potentially cause some harmful consequences
 			// like: a historical release gets activated.
-			return nil, nil, shippererrors.NewInconsistentReleaseTargetStep(
+			return nil, nil, 0, shippererrors.NewInconsistentReleaseTargetStep(
 				controller.MetaKey(relinfo.release),
 				relinfo.release.Spec.TargetStep,
 				int32(len(relinfo.release.Spec.Environment.Strategy.Steps)-1),
@@ -436,7 +898,7 @@ func (c *Controller) executeReleaseStrategy(relinfo *releaseInfo, diff *diffutil
 
 		relinfoSucc, err = c.buildReleaseInfo(succ)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, 0, err
 		}
 	}
 
@@ -457,12 +919,23 @@ func (c *Controller) executeReleaseStrategy(relinfo *releaseInfo, diff *diffutil
 	if targetStep >= int32(len(strategy.Steps)) {
 		err := fmt.Errorf("no step %d in strategy for Release %q",
 			targetStep, controller.MetaKey(rel))
-		return nil, nil, shippererrors.NewUnrecoverableError(err)
+		return nil, nil, 0, shippererrors.NewUnrecoverableError(err)
 	}
 
 	executor := NewStrategyExecutor(strategy, targetStep)
+	executor.capacityHeadroom, err = c.clusterCapacityHeadroom()
+	if err != nil {
+		return nil, nil, 0, err
+	}
 
+	_, executeSpan := c.startSpan(ctx, "executeStrategy", controller.MetaKey(rel),
+		label.Int32("shipper.release.target_step", targetStep))
 	complete, patches, trans := executor.Execute(relinfoPrev, relinfo, relinfoSucc)
+	executeSpan.SetAttributes(
+		label.Bool("shipper.release.step_complete", complete),
+		label.Int("shipper.release.patches", len(patches)),
+	)
+	executeSpan.End()
 
 	if len(patches) == 0 {
 		klog.V(4).Infof("Strategy verified for release %q, nothing to patch", controller.MetaKey(rel))
@@ -481,6 +954,16 @@ func (c *Controller) executeReleaseStrategy(relinfo *releaseInfo, diff *diffutil
 	isLastStep := int(targetStep) == len(strategy.Steps)-1
 	prevStep := rel.Status.AchievedStep
 
+	// A step has to bake for as long as it asks to before the release
+	// can achieve it, no matter how soon it converged.
+	var bakeRemaining time.Duration
+	if isHead {
+		bakeRemaining = bakeStep(rel, targetStep, strategy.Steps[targetStep], diff)
+		if bakeRemaining > 0 {
+			complete = false
+		}
+	}
+
 	if complete {
 		var achievedStep int32
 		var achievedStepName string
@@ -503,16 +986,36 @@ func (c *Controller) executeReleaseStrategy(relinfo *releaseInfo, diff *diffutil
 				"step [%d] finished",
 				achievedStep,
 			)
+			recordStepFinished(rel, achievedStep, diff)
+		} else if achievedStepName != prevStep.Name {
+			// The step was renamed in the strategy after it was
+			// achieved, so only its name needs catching up.
+			rel.Status.AchievedStep = &shipper.AchievedStep{
+				Step: achievedStep,
+				Name: achievedStepName,
+			}
 		}
 
 		if isLastStep {
-			condition := releaseutil.NewReleaseCondition(
-				shipper.ReleaseConditionTypeComplete,
-				corev1.ConditionTrue,
-				"",
-				"",
-			)
-			diff.Append(releaseutil.SetReleaseCondition(&rel.Status, *condition))
+			setReleaseComplete(rel, diff)
+		}
+	} else if isHead {
+		recordStepStarted(rel, targetStep, diff)
+
+		// Applications can consider a rollout complete before the
+		// whole strategy step is: once the contender has all of the
+		// capacity and traffic of the last step, without waiting for
+		// the incumbent to give up either its traffic or its capacity.
+		if isLastStep && bakeRemaining <= 0 {
+			policy, err := c.completionPolicy(rel)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+
+			if policy != shipper.CompletionPolicyBoth &&
+				convergedForPolicy(policy, relinfoPrev, relinfo, strategy.Steps[targetStep]) {
+				setReleaseComplete(rel, diff)
+			}
 		}
 	}
 
@@ -528,22 +1031,71 @@ func (c *Controller) executeReleaseStrategy(relinfo *releaseInfo, diff *diffutil
 		)
 	}
 
-	return rel, patches, nil
+	return rel, patches, bakeRemaining, nil
 }
 
-func (c *Controller) applyPatch(namespace string, patch StrategyPatch) error {
+func (c *Controller) applyPatch(ctx gocontext.Context, namespace string, patch StrategyPatch) (err error) {
 	name, gvk, b := patch.PatchSpec()
 
+	ctx, span := c.tracer.Start(ctx, "applyPatch", apitrace.WithAttributes(
+		label.String("shipper.patch.kind", gvk.Kind),
+		label.String("shipper.patch.object", fmt.Sprintf("%s/%s", namespace, name)),
+	))
+	defer func() { endSpan(ctx, span, err) }()
+
+	if gvk.Kind != "Release" {
+		b, err = withManagedByLabel(b, c.instanceID)
+		if err != nil {
+			return shippererrors.NewUnrecoverableError(err)
+		}
+	}
+
+	if err := c.writeBudget.Wait(ctx); err != nil {
+		return shippererrors.NewKubeclientPatchError(namespace, name, err).WithKind(gvk)
+	}
+
+	if c.patchTimeout > 0 {
+		return c.patchWithTimeout(ctx, namespace, name, gvk, b)
+	}
+
+	return c.patchObject(namespace, name, gvk, b)
+}
+
+// patchWithTimeout sends a patch the same way patchObject does, but gives up
+// on it once c.patchTimeout has elapsed or ctx is done, whichever comes
+// first. The patch is reported as a retriable error in that case, so the
+// release gets back in the queue and the patch is sent again. The request
+// itself is left to c.patchClientset to time out.
+func (c *Controller) patchWithTimeout(ctx gocontext.Context, namespace, name string, gvk schema.GroupVersionKind, b []byte) error {
+	ctx, cancel := gocontext.WithTimeout(ctx, c.patchTimeout)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.patchObject(namespace, name, gvk, b)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return shippererrors.NewKubeclientPatchError(namespace, name, ctx.Err()).WithKind(gvk)
+	}
+}
+
+func (c *Controller) patchObject(namespace, name string, gvk schema.GroupVersionKind, b []byte) error {
 	var err error
 	switch gvk.Kind {
 	case "Release":
-		_, err = c.clientset.ShipperV1alpha1().Releases(namespace).Patch(name, types.MergePatchType, b)
+		// Releases are only ever patched by the strategy executor, and
+		// only their status, which lives in its own subresource.
+		_, err = c.patchClientset.ShipperV1alpha1().Releases(namespace).Patch(name, types.MergePatchType, b, "status")
 	case "InstallationTarget":
-		_, err = c.clientset.ShipperV1alpha1().InstallationTargets(namespace).Patch(name, types.MergePatchType, b)
+		_, err = c.patchClientset.ShipperV1alpha1().InstallationTargets(namespace).Patch(name, types.MergePatchType, b)
 	case "CapacityTarget":
-		_, err = c.clientset.ShipperV1alpha1().CapacityTargets(namespace).Patch(name, types.MergePatchType, b)
+		_, err = c.patchClientset.ShipperV1alpha1().CapacityTargets(namespace).Patch(name, types.MergePatchType, b)
 	case "TrafficTarget":
-		_, err = c.clientset.ShipperV1alpha1().TrafficTargets(namespace).Patch(name, types.MergePatchType, b)
+		_, err = c.patchClientset.ShipperV1alpha1().TrafficTargets(namespace).Patch(name, types.MergePatchType, b)
 	default:
 		return shippererrors.NewUnrecoverableError(fmt.Errorf("error syncing Release %q (will not retry): unknown GVK resource name: %s", name, gvk.Kind))
 	}
@@ -554,6 +1106,162 @@ func (c *Controller) applyPatch(namespace string, patch StrategyPatch) error {
 	return nil
 }
 
+// checkWillConverge returns why the traffic weights of the step the release
+// in relinfo is working towards can never be achieved, if that's the case.
+// That can only happen in clusters that cap how many pods receive traffic,
+// so the rest are not looked at. Only releases leading their application's
+// rollout are checked, as the others follow whatever the leader does.
+func (c *Controller) checkWillConverge(relinfo *releaseInfo) (string, error) {
+	rel := relinfo.release
+
+	releases, err := c.applicationReleases(rel)
+	if err != nil {
+		return "", err
+	}
+	prev, succ, err := releaseutil.GetSiblingReleases(rel, releases)
+	if err != nil {
+		return "", err
+	}
+
+	strategy := rel.Spec.Environment.Strategy
+	if succ != nil || strategy == nil || rel.Spec.TargetStep < 0 || int(rel.Spec.TargetStep) >= len(strategy.Steps) {
+		return "", nil
+	}
+
+	step := strategy.Steps[rel.Spec.TargetStep]
+	weights := map[string]uint32{rel.Name: uint32(step.Traffic.Contender)}
+	if prev != nil {
+		weights[prev.Name] = uint32(step.Traffic.Incumbent)
+	}
+
+	for _, spec := range relinfo.trafficTarget.Spec.Clusters {
+		cluster, err := c.clusterLister.Get(spec.Name)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return "", shippererrors.NewKubeclientGetError("", spec.Name, err).
+				WithShipperKind("Cluster")
+		}
+
+		if cluster.Spec.MaxTrafficPods == 0 {
+			continue
+		}
+
+		if ok, reason := trafficutil.WillConverge(weights, int(cluster.Spec.MaxTrafficPods)); !ok {
+			return fmt.Sprintf("step %q will never converge in cluster %q: %s",
+				step.Name, cluster.Name, reason), nil
+		}
+	}
+
+	return "", nil
+}
+
+// clusterCapacityHeadroom returns the capacity headroom of every cluster
+// that caps it, keyed by cluster name.
+func (c *Controller) clusterCapacityHeadroom() (map[string]int, error) {
+	selector := labels.Everything()
+	clusters, err := c.clusterLister.List(selector)
+	if err != nil {
+		return nil, shippererrors.NewKubeclientListError(
+			shipper.SchemeGroupVersion.WithKind("Cluster"),
+			"", selector, err)
+	}
+
+	headroom := make(map[string]int)
+	for _, cluster := range clusters {
+		if cluster.Spec.CapacityHeadroom > 0 {
+			headroom[cluster.Name] = int(cluster.Spec.CapacityHeadroom)
+		}
+	}
+
+	return headroom, nil
+}
+
+// completionReport is a completion waiting in c.completionReports to be
+// sent to c.completionReporter.
+type completionReport struct {
+	rel    *shipper.Release
+	record CompletionRecord
+}
+
+// reportCompletion queues rel up to be reported to c.completionReporter as
+// just completed. Reporting can take a while, so it's done away from the
+// workers by runCompletionReporter. The release is already marked as
+// complete by now, so failing to report it, or finding the queue full, is
+// only worth a warning.
+func (c *Controller) reportCompletion(rel *shipper.Release, tt *shipper.TrafficTarget) {
+	report := completionReport{
+		rel:    rel,
+		record: buildCompletionRecord(rel, tt, time.Now()),
+	}
+
+	select {
+	case c.completionReports <- report:
+	default:
+		klog.Warningf("Dropped completion report of Release %q, too many are waiting to be sent", controller.MetaKey(rel))
+		c.recorder.Event(rel, corev1.EventTypeWarning, CompletionReportFailed,
+			"Failed to report release completion: too many reports are waiting to be sent")
+	}
+}
+
+// runCompletionReporter sends the completions queued up by reportCompletion
+// one at a time, until stopCh is closed.
+func (c *Controller) runCompletionReporter(stopCh <-chan struct{}) {
+	for {
+		select {
+		case report := <-c.completionReports:
+			c.sendCompletionReport(report)
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func (c *Controller) sendCompletionReport(report completionReport) {
+	if err := c.completionReporter.Report(report.record); err != nil {
+		klog.Warningf("Failed to report completion of Release %q: %s", controller.MetaKey(report.rel), err)
+		c.recorder.Eventf(report.rel, corev1.EventTypeWarning, CompletionReportFailed,
+			"Failed to report release completion: %s", err)
+	}
+}
+
+// dropOversizedPatches filters out patches bigger than c.maxPatchSize.
+// Sending those over would only put pressure on the API server, so instead
+// we emit a warning and mark the release as blocked for as long as the
+// strategy keeps producing them.
+func (c *Controller) dropOversizedPatches(rel *shipper.Release, patches []StrategyPatch, diff *diffutil.MultiDiff) []StrategyPatch {
+	filtered := make([]StrategyPatch, 0, len(patches))
+	oversized := []string{}
+
+	for _, patch := range patches {
+		name, gvk, b := patch.PatchSpec()
+		if len(b) <= c.maxPatchSize {
+			filtered = append(filtered, patch)
+			continue
+		}
+
+		msg := fmt.Sprintf("patch for %s %q is %d bytes, exceeding the maximum of %d bytes",
+			gvk.Kind, name, len(b), c.maxPatchSize)
+		c.recorder.Event(rel, corev1.EventTypeWarning, PatchTooLarge, msg)
+		oversized = append(oversized, msg)
+	}
+
+	if len(oversized) == 0 {
+		diff.Append(releaseutil.ClearBlockedIfResolved(&rel.Status, false, PatchTooLarge))
+		return filtered
+	}
+
+	condition := releaseutil.NewReleaseCondition(
+		shipper.ReleaseConditionTypeBlocked,
+		corev1.ConditionTrue,
+		PatchTooLarge,
+		strings.Join(oversized, "; "),
+	)
+	diff.Append(releaseutil.SetReleaseCondition(&rel.Status, *condition))
+
+	return filtered
+}
+
 // getAssociatedApplicationKey returns an application key in the format:
 // <namespace>/<application name>
 func (c *Controller) getAssociatedApplicationKey(rel *shipper.Release) (string, error) {
@@ -642,6 +1350,151 @@ func (c *Controller) enqueueReleaseAndNeighbours(obj interface{}) {
 	}
 }
 
+// enqueueIncumbentOnCompletion enqueues the incumbent of a contender that
+// just became complete, so it gets torn down according to the last step of
+// the strategy. The incumbent is reconciled in full even if none of the
+// objects it depends on changed since the last time it was synced.
+func (c *Controller) enqueueIncumbentOnCompletion(oldObj, newObj interface{}) {
+	oldRel, ok := oldObj.(*shipper.Release)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.Release: %#v", oldObj))
+		return
+	}
+
+	newRel, ok := newObj.(*shipper.Release)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.Release: %#v", newObj))
+		return
+	}
+
+	if releaseutil.ReleaseComplete(oldRel) || !releaseutil.ReleaseComplete(newRel) {
+		return
+	}
+
+	releases, err := c.applicationReleases(newRel)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("failed to list application releases for shipper.Release %#v: %s", newRel, err))
+		return
+	}
+
+	incumbent, _, err := releaseutil.GetSiblingReleases(newRel, releases)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	} else if incumbent == nil {
+		return
+	}
+
+	c.observedTargets.Forget(controller.MetaKey(incumbent))
+	c.enqueueRelease(incumbent)
+}
+
+// warnOnPhaseRegression logs a warning when a release goes back to an
+// earlier phase while still working towards the same strategy step, as
+// that hints at something undoing its progress behind our back.
+func (c *Controller) warnOnPhaseRegression(oldObj, newObj interface{}) {
+	oldRel, ok := oldObj.(*shipper.Release)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.Release: %#v", oldObj))
+		return
+	}
+
+	newRel, ok := newObj.(*shipper.Release)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.Release: %#v", newObj))
+		return
+	}
+
+	if oldRel.Spec.TargetStep != newRel.Spec.TargetStep {
+		return
+	}
+
+	prev, curr := releaseutil.GetReleasePhase(oldRel), releaseutil.GetReleasePhase(newRel)
+	if releaseutil.PhaseRegressed(prev, curr) {
+		klog.Warningf("Release %q regressed from phase %s to %s on target step %d",
+			controller.MetaKey(newRel), prev, curr, newRel.Spec.TargetStep)
+	}
+}
+
+// enqueueContenderOnApplicationChange enqueues the contender of an
+// application whose spec or annotations changed, as those decide how its
+// strategy is executed. Like the incumbent on completion, the contender is
+// reconciled in full even if none of its target objects changed.
+func (c *Controller) enqueueContenderOnApplicationChange(oldObj, newObj interface{}) {
+	oldApp, ok := oldObj.(*shipper.Application)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.Application: %#v", oldObj))
+		return
+	}
+
+	newApp, ok := newObj.(*shipper.Application)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.Application: %#v", newObj))
+		return
+	}
+
+	if equality.Semantic.DeepEqual(oldApp.Spec, newApp.Spec) &&
+		equality.Semantic.DeepEqual(oldApp.Annotations, newApp.Annotations) {
+		return
+	}
+
+	contender, err := c.releaseLister.Releases(newApp.Namespace).ContenderForApplication(newApp.Name)
+	if err != nil {
+		if !shippererrors.IsContenderNotFoundError(err) {
+			runtime.HandleError(fmt.Errorf("failed to get contender for shipper.Application %q: %s", newApp.Name, err))
+		}
+		return
+	}
+
+	c.observedTargets.Forget(controller.MetaKey(contender))
+	c.enqueueRelease(contender)
+}
+
+// enqueueReleasesOnClusterChange enqueues the releases scheduled on a
+// cluster whose spec changed, for instance because it was marked as
+// unschedulable.
+func (c *Controller) enqueueReleasesOnClusterChange(oldObj, newObj interface{}) {
+	oldCluster, ok := oldObj.(*shipper.Cluster)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.Cluster: %#v", oldObj))
+		return
+	}
+
+	newCluster, ok := newObj.(*shipper.Cluster)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.Cluster: %#v", newObj))
+		return
+	}
+
+	if equality.Semantic.DeepEqual(oldCluster.Spec, newCluster.Spec) {
+		return
+	}
+
+	c.enqueueReleasesOnCluster(newCluster)
+}
+
+// enqueueReleasesOnCluster enqueues all the releases scheduled on a cluster.
+// Nothing about their own target objects changed, so they are reconciled in
+// full.
+func (c *Controller) enqueueReleasesOnCluster(obj interface{}) {
+	cluster, ok := obj.(*shipper.Cluster)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.Cluster: %#v", obj))
+		return
+	}
+
+	releases, err := c.releaseLister.ReleasesScheduledOnCluster(cluster.Name)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("failed to list releases on shipper.Cluster %q: %s", cluster.Name, err))
+		return
+	}
+
+	for _, rel := range releases {
+		c.observedTargets.Forget(controller.MetaKey(rel))
+		c.enqueueRelease(rel)
+	}
+}
+
 func (c *Controller) enqueueRelease(obj interface{}) {
 	rel, ok := obj.(*shipper.Release)
 	if !ok {
@@ -655,9 +1508,21 @@ func (c *Controller) enqueueRelease(obj interface{}) {
 		return
 	}
 
+	if !c.shard.Owns(rel.Namespace) {
+		return
+	}
+
 	c.releaseWorkqueue.Add(key)
 }
 
+// requeueReleaseAfter has the release identified by key synced again once
+// delay has passed. Its fingerprint is forgotten, so that sync isn't
+// skipped for nothing having changed in the meantime.
+func (c *Controller) requeueReleaseAfter(key string, delay time.Duration) {
+	c.observedTargets.Forget(key)
+	c.releaseWorkqueue.AddAfter(key, delay)
+}
+
 func (c *Controller) enqueueReleaseRateLimited(obj interface{}) {
 	rel, ok := obj.(*shipper.Release)
 	if !ok {
@@ -671,6 +1536,10 @@ func (c *Controller) enqueueReleaseRateLimited(obj interface{}) {
 		return
 	}
 
+	if !c.shard.Owns(rel.Namespace) {
+		return
+	}
+
 	c.releaseWorkqueue.AddRateLimited(key)
 }
 
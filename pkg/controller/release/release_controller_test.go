@@ -1,6 +1,7 @@
 package release
 
 import (
+	gocontext "context"
 	"encoding/json"
 	"fmt"
 	"math/rand"
@@ -13,15 +14,21 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 
 	"k8s.io/apimachinery/pkg/util/wait"
 	kubetesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+
+	apitrace "go.opentelemetry.io/otel/api/trace"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperclient "github.com/bookingcom/shipper/pkg/client/clientset/versioned"
 	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
 	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
 	shippertesting "github.com/bookingcom/shipper/pkg/testing"
 	apputil "github.com/bookingcom/shipper/pkg/util/application"
 	"github.com/bookingcom/shipper/pkg/util/conditions"
@@ -153,6 +160,20 @@ type fixture struct {
 	filter         actionfilter
 	receivedEvents []string
 	expectedEvents []string
+
+	maxPatchSize              int
+	conditionEventDedupWindow time.Duration
+	gateStuckThreshold        time.Duration
+	honorPausedApplications   bool
+	instanceID                string
+	patchTimeout              time.Duration
+	patchClientset            shipperclient.Interface
+	completionReporter        CompletionReporter
+	enqueueDebounce           time.Duration
+	shard                     Shard
+	writeBudget               flowcontrol.RateLimiter
+	reconcileTraces           *ReconcileTraceLog
+	tracer                    apitrace.Tracer
 }
 
 func newFixture(t *testing.T, objects ...runtime.Object) *fixture {
@@ -166,6 +187,8 @@ func newFixture(t *testing.T, objects ...runtime.Object) *fixture {
 		filter:         actionfilter{},
 		receivedEvents: make([]string, 0),
 		expectedEvents: make([]string, 0),
+
+		maxPatchSize: DefaultMaxPatchSize,
 	}
 }
 
@@ -208,7 +231,7 @@ func (f *fixture) run() {
 
 	cycles := 0
 	for (f.cycles < 0 || cycles < f.cycles) && controller.releaseWorkqueue.Len() > 0 {
-		controller.processNextReleaseWorkItem()
+		controller.processNextReleaseWorkItem(gocontext.Background())
 		cycles++
 	}
 	close(f.recorder.Events)
@@ -222,12 +245,32 @@ func (f *fixture) run() {
 }
 
 func (f *fixture) newController() *Controller {
-	return NewController(
+	c := NewController(
 		f.clientset,
 		f.informerFactory,
 		localFetchChart,
 		f.recorder,
+		Config{
+			MaxPatchSize:              f.maxPatchSize,
+			ConditionEventDedupWindow: f.conditionEventDedupWindow,
+			GateStuckThreshold:        f.gateStuckThreshold,
+			HonorPausedApplications:   f.honorPausedApplications,
+			InstanceID:                f.instanceID,
+			PatchTimeout:              f.patchTimeout,
+			CompletionReporter:        f.completionReporter,
+			EnqueueDebounce:           f.enqueueDebounce,
+			Shard:                     f.shard,
+			WriteBudget:               f.writeBudget,
+			ReconcileTraces:           f.reconcileTraces,
+			PatchClientset:            f.patchClientset,
+		},
 	)
+
+	if f.tracer != nil {
+		c.tracer = f.tracer
+	}
+
+	return c
 }
 
 func newRolloutBlock(name string, namespace string) *shipper.RolloutBlock {
@@ -753,7 +796,7 @@ func (f *fixture) expectReleaseWaitingForCommand(rel *shipper.Release, step int3
 	}
 
 	patch, _ := json.Marshal(newStatus)
-	action := kubetesting.NewPatchAction(gvr, rel.GetNamespace(), rel.GetName(), types.MergePatchType, patch)
+	action := kubetesting.NewPatchSubresourceAction(gvr, rel.GetNamespace(), rel.GetName(), types.MergePatchType, patch, "status")
 	f.actions = append(f.actions, action)
 
 	relKey := fmt.Sprintf("%s/%s", rel.GetNamespace(), rel.GetName())
@@ -763,10 +806,32 @@ func (f *fixture) expectReleaseWaitingForCommand(rel *shipper.Release, step int3
 		fmt.Sprintf(`Normal ReleaseStateTransitioned Release "%s" had its state "WaitingForCommand" transitioned to "True"`, relKey),
 		fmt.Sprintf(`Normal ReleaseStateTransitioned Release "%s" had its state "WaitingForInstallation" transitioned to "False"`, relKey),
 		fmt.Sprintf(`Normal ReleaseStateTransitioned Release "%s" had its state "WaitingForTraffic" transitioned to "False"`, relKey),
-		`Normal ReleaseConditionChanged [] -> [Scheduled True], [] -> [StrategyExecuted True]`,
+		"Normal ReleaseConditionChanged [] -> [Scheduled True], [] -> [StrategyExecuted True], [] -> [StepAchieved True], " +
+			convergedConditionsEvent("True", "True"),
 	}
 }
 
+// convergedConditionsEvent renders the TrafficConverged and CapacityConverged
+// transitions that follow every successful strategy execution.
+func convergedConditionsEvent(traffic, capacity string) string {
+	return fmt.Sprintf("[] -> [TrafficConverged %s], [] -> [CapacityConverged %s]", traffic, capacity)
+}
+
+// buildTrafficSummary returns the TrafficSummary of a release whose traffic
+// target wants the given weights, none of which have been achieved yet.
+func buildTrafficSummary(desiredWeights map[string]uint32) *shipper.TrafficSummary {
+	tt := &shipper.TrafficTarget{}
+	for name, weight := range desiredWeights {
+		tt.Spec.Clusters = append(tt.Spec.Clusters, shipper.ClusterTrafficTarget{
+			Name:   name,
+			Weight: weight,
+		})
+	}
+
+	summary := releaseutil.SummarizeForRelease(tt)
+	return &summary
+}
+
 func buildExpectedActions(release *shipper.Release, clusters []*shipper.Cluster) []kubetesting.Action {
 
 	clusterNames := make([]string, 0, len(clusters))
@@ -903,7 +968,8 @@ func (f *fixture) expectAssociatedObjectsCreated(release *shipper.Release, clust
 			"Normal ReleaseScheduled Created CapacityTarget \"%s\"",
 			relKey,
 		),
-		"Normal ReleaseConditionChanged [] -> [Scheduled True], [] -> [StrategyExecuted True]",
+		"Normal ReleaseConditionChanged [] -> [Scheduled True], [] -> [StrategyExecuted True], "+
+			convergedConditionsEvent("Unknown", "Unknown"),
 	)
 }
 
@@ -919,16 +985,29 @@ func (f *fixture) expectReleaseScheduled(release *shipper.Release, clusters []*s
 	expected.Annotations[shipper.ReleaseClustersAnnotation] = clusterNamesStr
 	expected.Status.Conditions = []shipper.ReleaseCondition{
 		{Type: shipper.ReleaseConditionTypeBlocked, Status: corev1.ConditionFalse},
+		{Type: shipper.ReleaseConditionTypeCapacityConverged, Status: corev1.ConditionUnknown},
 		{Type: shipper.ReleaseConditionTypeScheduled, Status: corev1.ConditionTrue},
 		{Type: shipper.ReleaseConditionTypeStrategyExecuted, Status: corev1.ConditionTrue},
+		{Type: shipper.ReleaseConditionTypeTrafficConverged, Status: corev1.ConditionUnknown},
+	}
+	desiredWeights := make(map[string]uint32, len(clusterNames))
+	for _, name := range clusterNames {
+		desiredWeights[name] = 0
 	}
+	expected.Status.TrafficSummary = buildTrafficSummary(desiredWeights)
 
 	f.filter = f.filter.Extend(actionfilter{[]string{"update"}, []string{"releases"}})
 	f.actions = append(f.actions, buildExpectedActions(expected, clusters)...)
-	f.actions = append(f.actions, kubetesting.NewUpdateAction(
-		shipper.SchemeGroupVersion.WithResource("releases"),
-		release.GetNamespace(),
-		expected))
+	f.actions = append(f.actions,
+		kubetesting.NewUpdateAction(
+			shipper.SchemeGroupVersion.WithResource("releases"),
+			release.GetNamespace(),
+			expected),
+		kubetesting.NewUpdateSubresourceAction(
+			shipper.SchemeGroupVersion.WithResource("releases"),
+			"status",
+			release.GetNamespace(),
+			expected))
 
 	relKey := fmt.Sprintf("%s/%s", release.GetNamespace(), release.GetName())
 	f.expectedEvents = append(f.expectedEvents,
@@ -1026,16 +1105,18 @@ func (f *fixture) expectCapacityStatusPatch(step int32, ct *shipper.CapacityTarg
 		},
 	}
 	patch, _ = json.Marshal(newStatus)
-	action = kubetesting.NewPatchAction(
+	action = kubetesting.NewPatchSubresourceAction(
 		shipper.SchemeGroupVersion.WithResource("releases"),
 		r.GetNamespace(),
 		r.GetName(),
 		types.MergePatchType,
-		patch)
+		patch,
+		"status")
 	f.actions = append(f.actions, action)
 
 	f.expectedEvents = []string{
-		"Normal ReleaseConditionChanged [] -> [Scheduled True], [] -> [StrategyExecuted True]",
+		"Normal ReleaseConditionChanged [] -> [Scheduled True], [] -> [StrategyExecuted True], " +
+			convergedConditionsEvent("True", "True"),
 	}
 }
 
@@ -1057,6 +1138,30 @@ func (f *fixture) expectTrafficStatusPatch(step int32, tt *shipper.TrafficTarget
 	action := kubetesting.NewPatchAction(gvr, tt.GetNamespace(), tt.GetName(), types.MergePatchType, patch)
 	f.actions = append(f.actions, action)
 
+	newStatus := map[string]interface{}{
+		"status": shipper.ReleaseStatus{
+			Strategy: buildTrafficStrategyStatus(step, tt, role),
+		},
+	}
+	patch, _ = json.Marshal(newStatus)
+	action = kubetesting.NewPatchSubresourceAction(
+		shipper.SchemeGroupVersion.WithResource("releases"),
+		r.GetNamespace(),
+		r.GetName(),
+		types.MergePatchType,
+		patch,
+		"status")
+	f.actions = append(f.actions, action)
+
+	f.expectedEvents = []string{
+		"Normal ReleaseConditionChanged [] -> [Scheduled True], [] -> [StrategyExecuted True], " +
+			convergedConditionsEvent("True", "True"),
+	}
+}
+
+// buildTrafficStrategyStatus returns the strategy status of a release at
+// step that is still waiting for tt to achieve traffic, as either role.
+func buildTrafficStrategyStatus(step int32, tt *shipper.TrafficTarget, role role) *shipper.ReleaseStrategyStatus {
 	var strategyConditions conditions.StrategyConditionsMap
 
 	if role == Contender {
@@ -1116,25 +1221,9 @@ func (f *fixture) expectTrafficStatusPatch(step int32, tt *shipper.TrafficTarget
 		)
 	}
 
-	newStatus := map[string]interface{}{
-		"status": shipper.ReleaseStatus{
-			Strategy: &shipper.ReleaseStrategyStatus{
-				Conditions: strategyConditions.AsReleaseStrategyConditions(),
-				State:      strategyConditions.AsReleaseStrategyState(step, true, false, true),
-			},
-		},
-	}
-	patch, _ = json.Marshal(newStatus)
-	action = kubetesting.NewPatchAction(
-		shipper.SchemeGroupVersion.WithResource("releases"),
-		r.GetNamespace(),
-		r.GetName(),
-		types.MergePatchType,
-		patch)
-	f.actions = append(f.actions, action)
-
-	f.expectedEvents = []string{
-		"Normal ReleaseConditionChanged [] -> [Scheduled True], [] -> [StrategyExecuted True]",
+	return &shipper.ReleaseStrategyStatus{
+		Conditions: strategyConditions.AsReleaseStrategyConditions(),
+		State:      strategyConditions.AsReleaseStrategyState(step, true, false, true),
 	}
 }
 
@@ -1187,7 +1276,7 @@ func (f *fixture) expectReleaseReleased(rel *shipper.Release, targetStep int32)
 	}
 
 	patch, _ := json.Marshal(newStatus)
-	action := kubetesting.NewPatchAction(gvr, rel.GetNamespace(), rel.GetName(), types.MergePatchType, patch)
+	action := kubetesting.NewPatchSubresourceAction(gvr, rel.GetNamespace(), rel.GetName(), types.MergePatchType, patch, "status")
 
 	f.actions = append(f.actions, action)
 
@@ -1197,7 +1286,8 @@ func (f *fixture) expectReleaseReleased(rel *shipper.Release, targetStep int32)
 		fmt.Sprintf(`Normal ReleaseStateTransitioned Release "%s/%s" had its state "WaitingForCommand" transitioned to "False"`, rel.GetNamespace(), rel.GetName()),
 		fmt.Sprintf(`Normal ReleaseStateTransitioned Release "%s/%s" had its state "WaitingForInstallation" transitioned to "False"`, rel.GetNamespace(), rel.GetName()),
 		fmt.Sprintf(`Normal ReleaseStateTransitioned Release "%s/%s" had its state "WaitingForTraffic" transitioned to "False"`, rel.GetNamespace(), rel.GetName()),
-		"Normal ReleaseConditionChanged [] -> [Scheduled True], [] -> [StrategyExecuted True], [] -> [Complete True]",
+		"Normal ReleaseConditionChanged [] -> [Scheduled True], [] -> [StrategyExecuted True], [] -> [StepAchieved True], [] -> [Complete True], " +
+			convergedConditionsEvent("True", "True"),
 	}
 }
 
@@ -1242,12 +1332,13 @@ func (f *fixture) expectInstallationNotReady(rel *shipper.Release, achievedStepI
 	}
 
 	patch, _ := json.Marshal(newStatus)
-	action := kubetesting.NewPatchAction(gvr, rel.GetNamespace(), rel.GetName(), types.MergePatchType, patch)
+	action := kubetesting.NewPatchSubresourceAction(gvr, rel.GetNamespace(), rel.GetName(), types.MergePatchType, patch, "status")
 
 	f.actions = append(f.actions, action)
 
 	f.expectedEvents = []string{
-		fmt.Sprintf(`Normal ReleaseConditionChanged [] -> [Scheduled True], [] -> [StrategyExecuted True]`),
+		"Normal ReleaseConditionChanged [] -> [Scheduled True], [] -> [StrategyExecuted True], " +
+			convergedConditionsEvent("True", "True"),
 	}
 }
 
@@ -1353,16 +1444,27 @@ func (f *fixture) expectCapacityNotReady(relpair releaseInfoPair, targetStep, ac
 	}
 
 	patch, _ := json.Marshal(newStatus)
-	action := kubetesting.NewPatchAction(gvr, rel.GetNamespace(), rel.GetName(), types.MergePatchType, patch)
+	action := kubetesting.NewPatchSubresourceAction(gvr, rel.GetNamespace(), rel.GetName(), types.MergePatchType, patch, "status")
 
 	f.actions = append(f.actions, action)
 
+	capacityConverged := "True"
+	if role == Contender {
+		capacityConverged = fmt.Sprintf("False ClustersNotReady [%s]", brokenClusterName)
+	}
+
 	f.expectedEvents = []string{
-		fmt.Sprintf(`Normal ReleaseConditionChanged [] -> [Scheduled True], [] -> [StrategyExecuted True]`),
+		"Normal ReleaseConditionChanged [] -> [Scheduled True], [] -> [StrategyExecuted True], " +
+			convergedConditionsEvent("True", capacityConverged),
 	}
 }
 
 func (f *fixture) expectTrafficNotReady(relpair releaseInfoPair, targetStep, achievedStepIndex int32, role role, brokenClusterName string) {
+	f.filter = f.filter.Extend(actionfilter{
+		[]string{"patch"},
+		[]string{"releases"},
+	})
+
 	gvr := shipper.SchemeGroupVersion.WithResource("releases")
 	var newStatus map[string]interface{}
 
@@ -1458,11 +1560,18 @@ func (f *fixture) expectTrafficNotReady(relpair releaseInfoPair, targetStep, ach
 	}
 
 	patch, _ := json.Marshal(newStatus)
-	action := kubetesting.NewPatchAction(gvr, rel.GetNamespace(), rel.GetName(), types.MergePatchType, patch)
+	action := kubetesting.NewPatchSubresourceAction(gvr, rel.GetNamespace(), rel.GetName(), types.MergePatchType, patch, "status")
 
 	f.actions = append(f.actions, action)
 
-	f.expectedEvents = []string{}
+	trafficConverged := "True"
+	if role == Contender {
+		trafficConverged = fmt.Sprintf("False ClustersNotReady [%s]", brokenClusterName)
+	}
+
+	f.expectedEvents = []string{
+		"Normal ReleaseConditionChanged " + convergedConditionsEvent(trafficConverged, "True"),
+	}
 }
 
 func TestControllerComputeTargetClustersAndCreateAssociatedObjects(t *testing.T) {
@@ -1796,8 +1905,9 @@ func TestContenderCapacityShouldNotIncreaseWithRolloutBlock(t *testing.T) {
 		rolloutBlockMessage)
 	releaseutil.SetReleaseCondition(&expectedContender.Status, *condBlocked)
 
-	action := kubetesting.NewUpdateAction(
+	action := kubetesting.NewUpdateSubresourceAction(
 		shipper.SchemeGroupVersion.WithResource("releases"),
+		"status",
 		namespace,
 		expectedContender)
 	f.actions = append(f.actions, action)
@@ -1848,6 +1958,274 @@ func TestContenderTrafficShouldIncrease(t *testing.T) {
 	f.run()
 }
 
+func TestOversizedPatchesAreNotSent(t *testing.T) {
+	namespace := "test-namespace"
+	incumbentName, contenderName := "test-incumbent", "test-contender"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	f.cycles = 1 // It only runs a single cycle of processNextReleaseWorkItem
+	f.maxPatchSize = 128
+
+	totalReplicaCount := int32(10)
+	contender := f.buildContender(namespace, contenderName, totalReplicaCount)
+	incumbent := f.buildIncumbent(namespace, incumbentName, totalReplicaCount)
+
+	contender.release.Spec.TargetStep = 1
+	contender.capacityTarget.Spec.Clusters[0].Percent = 50
+	contender.capacityTarget.Spec.Clusters[0].TotalReplicaCount = totalReplicaCount
+
+	incumbent.trafficTarget.Spec.Clusters[0].Weight = 50
+	incumbent.capacityTarget.Spec.Clusters[0].Percent = 50
+	incumbent.capacityTarget.Spec.Clusters[0].TotalReplicaCount = totalReplicaCount
+
+	f.addObjects(
+		contender.release.DeepCopy(),
+		contender.installationTarget.DeepCopy(),
+		contender.capacityTarget.DeepCopy(),
+		contender.trafficTarget.DeepCopy(),
+
+		incumbent.release.DeepCopy(),
+		incumbent.installationTarget.DeepCopy(),
+		incumbent.capacityTarget.DeepCopy(),
+		incumbent.trafficTarget.DeepCopy(),
+	)
+
+	// The traffic target patch is small enough to make it through, but
+	// the release strategy status patch is not.
+	tt := contender.trafficTarget.DeepCopy()
+	ttPatch := &TrafficTargetSpecPatch{
+		Name: tt.Name,
+		NewSpec: &shipper.TrafficTargetSpec{
+			Clusters: []shipper.ClusterTrafficTarget{
+				{Name: "minikube", Weight: 50},
+			},
+		},
+	}
+	_, _, b := ttPatch.PatchSpec()
+	f.actions = append(f.actions, kubetesting.NewPatchAction(
+		shipper.SchemeGroupVersion.WithResource("traffictargets"),
+		tt.GetNamespace(), tt.GetName(), types.MergePatchType, b))
+	f.filter = f.filter.Extend(actionfilter{
+		[]string{"patch"},
+		[]string{"releases", "traffictargets"},
+	})
+
+	relPatch := &ReleaseStrategyStatusPatch{
+		Name:              contenderName,
+		NewStrategyStatus: buildTrafficStrategyStatus(contender.release.Spec.TargetStep, tt, Contender),
+	}
+	_, _, b = relPatch.PatchSpec()
+	msg := fmt.Sprintf("patch for Release %q is %d bytes, exceeding the maximum of %d bytes",
+		contenderName, len(b), f.maxPatchSize)
+	f.expectedEvents = []string{
+		fmt.Sprintf("Warning PatchTooLarge %s", msg),
+		fmt.Sprintf("Normal ReleaseConditionChanged [] -> [Scheduled True], [] -> [StrategyExecuted True], %s, [Blocked False] -> [Blocked True PatchTooLarge %s]",
+			convergedConditionsEvent("True", "True"), msg),
+	}
+
+	f.run()
+}
+
+func TestMismatchedApplicationBlocksStrategy(t *testing.T) {
+	namespace := "test-namespace"
+	incumbentName, contenderName := "test-incumbent", "test-contender"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+	totalReplicaCount := int32(10)
+	contender := f.buildContender(namespace, contenderName, totalReplicaCount)
+	incumbent := f.buildIncumbent(namespace, incumbentName, totalReplicaCount)
+
+	// The contender is still owned by test-app, so it gets paired with
+	// the incumbent, but its label claims it belongs elsewhere.
+	contender.release.Labels[shipper.AppLabel] = "other-app"
+
+	f.addObjects(
+		contender.release.DeepCopy(),
+		contender.installationTarget.DeepCopy(),
+		contender.capacityTarget.DeepCopy(),
+		contender.trafficTarget.DeepCopy(),
+
+		incumbent.release.DeepCopy(),
+		incumbent.installationTarget.DeepCopy(),
+		incumbent.capacityTarget.DeepCopy(),
+		incumbent.trafficTarget.DeepCopy(),
+	)
+
+	f.filter = f.filter.Extend(actionfilter{
+		[]string{"patch"},
+		[]string{"traffictargets", "capacitytargets", "installationtargets"},
+	})
+
+	msg := fmt.Sprintf(
+		"Release %s/%s belongs to application %q, but its sibling %s/%s belongs to application %q",
+		namespace, contenderName, "other-app", namespace, incumbentName, "test-app")
+	f.expectedEvents = []string{
+		fmt.Sprintf("Normal ReleaseConditionChanged [] -> [Scheduled True], [Blocked False] -> [Blocked True MismatchedApplication %s]", msg),
+		// The incumbent itself is fine, and goes on converging.
+		"Normal ReleaseConditionChanged " + convergedConditionsEvent("True", "True"),
+	}
+
+	f.run()
+}
+
+// TestMismatchedApplicationBlockIsLifted syncs a release that was blocked
+// on a mismatched application once it no longer is, and checks the block
+// is lifted in the release that gets written back.
+func TestMismatchedApplicationBlockIsLifted(t *testing.T) {
+	namespace := "test-namespace"
+	incumbentName, contenderName := "test-incumbent", "test-contender"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+	totalReplicaCount := int32(10)
+	contender := f.buildContender(namespace, contenderName, totalReplicaCount)
+	incumbent := f.buildIncumbent(namespace, incumbentName, totalReplicaCount)
+
+	blocked := releaseutil.NewReleaseCondition(
+		shipper.ReleaseConditionTypeBlocked,
+		corev1.ConditionTrue,
+		MismatchedApplication,
+		"belongs to another application",
+	)
+	releaseutil.SetReleaseCondition(&contender.release.Status, *blocked)
+
+	f.addObjects(
+		contender.release.DeepCopy(),
+		contender.installationTarget.DeepCopy(),
+		contender.capacityTarget.DeepCopy(),
+		contender.trafficTarget.DeepCopy(),
+
+		incumbent.release.DeepCopy(),
+		incumbent.installationTarget.DeepCopy(),
+		incumbent.capacityTarget.DeepCopy(),
+		incumbent.trafficTarget.DeepCopy(),
+	)
+
+	f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.informerFactory.Start(stopCh)
+	f.informerFactory.WaitForCacheSync(stopCh)
+
+	key := namespace + "/" + contenderName
+	if err := c.syncRelease(gocontext.Background(), key, &TraceEntry{}); err != nil {
+		t.Fatalf("unexpected error syncing release: %s", err)
+	}
+
+	rel, err := f.clientset.ShipperV1alpha1().Releases(namespace).Get(contenderName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error getting release: %s", err)
+	}
+
+	cond := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeBlocked)
+	if cond == nil || cond.Status != corev1.ConditionFalse {
+		t.Errorf("expected the release not to be blocked anymore, got condition %+v", cond)
+	}
+}
+
+type unknownKindPatch struct{}
+
+func (p unknownKindPatch) PatchSpec() (string, schema.GroupVersionKind, []byte) {
+	return "foo", schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, []byte("{}")
+}
+
+func (p unknownKindPatch) Alters(interface{}) bool { return true }
+
+func (p unknownKindPatch) IsEmpty() bool { return false }
+
+func TestApplyPatchUsesTypedClientForTrafficTarget(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	contender := f.buildContender(namespace, "test-contender", 10)
+
+	const syncPeriod time.Duration = 0
+	f.clientset = shipperfake.NewSimpleClientset(contender.trafficTarget.DeepCopy())
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, syncPeriod)
+	f.recorder = record.NewFakeRecorder(42)
+	controller := f.newController()
+
+	patch := &TrafficTargetSpecPatch{
+		Name: contender.trafficTarget.Name,
+		NewSpec: &shipper.TrafficTargetSpec{
+			Clusters: []shipper.ClusterTrafficTarget{
+				{Name: "minikube", Weight: 50},
+			},
+		},
+	}
+
+	if err := controller.applyPatch(gocontext.Background(), namespace, patch); err != nil {
+		t.Fatalf("unexpected error applying patch: %s", err)
+	}
+
+	_, _, b := patch.PatchSpec()
+	expected := []kubetesting.Action{
+		kubetesting.NewPatchAction(
+			shipper.SchemeGroupVersion.WithResource("traffictargets"),
+			namespace, contender.trafficTarget.Name, types.MergePatchType, b),
+	}
+	shippertesting.CheckActions(expected, f.clientset.Actions(), t)
+
+	err := controller.applyPatch(gocontext.Background(), namespace, unknownKindPatch{})
+	if err == nil || shippererrors.ShouldRetry(err) {
+		t.Errorf("expected an unrecoverable error patching an unknown kind, got %v", err)
+	}
+}
+
+// TestApplyPatchSendsReleaseStatusToStatusSubresource checks strategy
+// status patches go to the status subresource of releases, as the API
+// server drops any status sent along with the rest of the object.
+func TestApplyPatchSendsReleaseStatusToStatusSubresource(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	contender := f.buildContender(namespace, "test-contender", 10)
+
+	const syncPeriod time.Duration = 0
+	f.clientset = shipperfake.NewSimpleClientset(contender.release.DeepCopy())
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, syncPeriod)
+	f.recorder = record.NewFakeRecorder(42)
+	controller := f.newController()
+
+	patch := &ReleaseStrategyStatusPatch{
+		Name: contender.release.Name,
+		NewStrategyStatus: &shipper.ReleaseStrategyStatus{
+			State: shipper.ReleaseStrategyState{
+				WaitingForCommand: shipper.StrategyStateTrue,
+			},
+		},
+	}
+
+	if err := controller.applyPatch(gocontext.Background(), namespace, patch); err != nil {
+		t.Fatalf("unexpected error applying patch: %s", err)
+	}
+
+	_, _, b := patch.PatchSpec()
+	expected := []kubetesting.Action{
+		kubetesting.NewPatchSubresourceAction(
+			shipper.SchemeGroupVersion.WithResource("releases"),
+			namespace, contender.release.Name, types.MergePatchType, b, "status"),
+	}
+	shippertesting.CheckActions(expected, f.clientset.Actions(), t)
+}
+
 func TestContenderTrafficShouldIncreaseWithRolloutBlockOverride(t *testing.T) {
 	namespace := "test-namespace"
 	incumbentName, contenderName := "test-incumbent", "test-contender"
@@ -1928,8 +2306,9 @@ func TestContenderTrafficShouldNotIncreaseWithRolloutBlock(t *testing.T) {
 		rolloutBlockMessage)
 	releaseutil.SetReleaseCondition(&expectedContender.Status, *condBlocked)
 
-	action := kubetesting.NewUpdateAction(
+	action := kubetesting.NewUpdateSubresourceAction(
 		shipper.SchemeGroupVersion.WithResource("releases"),
+		"status",
 		namespace,
 		expectedContender)
 	f.actions = append(f.actions, action)
@@ -2055,8 +2434,9 @@ func TestIncumbentTrafficShouldNotDecreaseWithRolloutBlock(t *testing.T) {
 		rolloutBlockMessage)
 	releaseutil.SetReleaseCondition(&expectedContender.Status, *condBlocked)
 
-	action := kubetesting.NewUpdateAction(
+	action := kubetesting.NewUpdateSubresourceAction(
 		shipper.SchemeGroupVersion.WithResource("releases"),
+		"status",
 		namespace,
 		expectedContender)
 	f.actions = append(f.actions, action)
@@ -2186,8 +2566,9 @@ func TestIncumbentCapacityShouldNotDecreaseWithRolloutBlock(t *testing.T) {
 		rolloutBlockMessage)
 	releaseutil.SetReleaseCondition(&expectedContender.Status, *condBlocked)
 
-	action := kubetesting.NewUpdateAction(
+	action := kubetesting.NewUpdateSubresourceAction(
 		shipper.SchemeGroupVersion.WithResource("releases"),
+		"status",
 		namespace,
 		expectedContender)
 	f.actions = append(f.actions, action)
@@ -2326,6 +2707,7 @@ func TestApplicationExposesStrategyFailureIndexOutOfBounds(t *testing.T) {
 			Message: missingStepMsg,
 		},
 	}
+	expectedRel.Status.TrafficSummary = buildTrafficSummary(map[string]uint32{"minikube": 0})
 
 	f.addObjects(
 		contender.release.DeepCopy(),
@@ -2339,8 +2721,9 @@ func TestApplicationExposesStrategyFailureIndexOutOfBounds(t *testing.T) {
 		incumbent.trafficTarget.DeepCopy(),
 	)
 
-	f.actions = append(f.actions, kubetesting.NewUpdateAction(
+	f.actions = append(f.actions, kubetesting.NewUpdateSubresourceAction(
 		shipper.SchemeGroupVersion.WithResource("releases"),
+		"status",
 		namespace,
 		expectedRel))
 
@@ -2414,6 +2797,7 @@ func TestApplicationExposesStrategyFailureSuccessorIndexOutOfBounds(t *testing.T
 			Message: fmt.Sprintf(`failed to execute strategy: "no step 2 in strategy for Release \"%s/%s\""`, namespace, incumbentName),
 		},
 	}
+	expectedIncumbent.Status.TrafficSummary = buildTrafficSummary(map[string]uint32{"minikube": 100})
 
 	expectedContender := contender.release.DeepCopy()
 	expectedContender.Status.Conditions = []shipper.ReleaseCondition{
@@ -2432,6 +2816,7 @@ func TestApplicationExposesStrategyFailureSuccessorIndexOutOfBounds(t *testing.T
 			Message: fmt.Sprintf(`failed to execute strategy: "no step 2 in strategy for Release \"%s/%s\""`, namespace, contenderName),
 		},
 	}
+	expectedContender.Status.TrafficSummary = buildTrafficSummary(map[string]uint32{"minikube": 0})
 
 	// we change the order of incumbent and contender here: we want to
 	// ensure we're safe when an incumbent steps in first and then triggers
@@ -2449,13 +2834,15 @@ func TestApplicationExposesStrategyFailureSuccessorIndexOutOfBounds(t *testing.T
 	)
 
 	f.actions = append(f.actions,
-		kubetesting.NewUpdateAction(
+		kubetesting.NewUpdateSubresourceAction(
 			shipper.SchemeGroupVersion.WithResource("releases"),
+			"status",
 			namespace,
 			expectedIncumbent,
 		),
-		kubetesting.NewUpdateAction(
+		kubetesting.NewUpdateSubresourceAction(
 			shipper.SchemeGroupVersion.WithResource("releases"),
+			"status",
 			namespace,
 			expectedContender,
 		),
@@ -2710,16 +3097,28 @@ func TestControllerChooseClusters(t *testing.T) {
 
 	expected := contender.DeepCopy()
 	expected.Annotations[shipper.ReleaseClustersAnnotation] = fmt.Sprintf("%s,%s", clusterA.Name, clusterB.Name)
+	expected.Status.TrafficSummary = buildTrafficSummary(map[string]uint32{clusterA.Name: 0, clusterB.Name: 0})
 	condScheduled := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeScheduled, corev1.ConditionTrue, "", "")
 	releaseutil.SetReleaseCondition(&expected.Status, *condScheduled)
 	condStrategyExecuted := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeStrategyExecuted, corev1.ConditionTrue, "", "")
 	releaseutil.SetReleaseCondition(&expected.Status, *condStrategyExecuted)
+	condTrafficConverged := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeTrafficConverged, corev1.ConditionUnknown, "", "")
+	releaseutil.SetReleaseCondition(&expected.Status, *condTrafficConverged)
+	condCapacityConverged := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeCapacityConverged, corev1.ConditionUnknown, "", "")
+	releaseutil.SetReleaseCondition(&expected.Status, *condCapacityConverged)
 
+	// Picking clusters annotates the release, which has to go through
+	// the release itself rather than its status.
 	f.actions = []kubetesting.Action{
 		kubetesting.NewUpdateAction(
 			shipper.SchemeGroupVersion.WithResource("releases"),
 			contender.GetNamespace(),
 			expected),
+		kubetesting.NewUpdateSubresourceAction(
+			shipper.SchemeGroupVersion.WithResource("releases"),
+			"status",
+			contender.GetNamespace(),
+			expected),
 	}
 
 	f.filter = f.filter.Extend(actionfilter{[]string{"update"}, []string{"releases"}})
@@ -2744,7 +3143,8 @@ func TestControllerChooseClusters(t *testing.T) {
 			"Normal ReleaseScheduled Created CapacityTarget \"%s\"",
 			relKey,
 		),
-		"Normal ReleaseConditionChanged [Scheduled False] -> [Scheduled True], [] -> [StrategyExecuted True]",
+		"Normal ReleaseConditionChanged [Scheduled False] -> [Scheduled True], [] -> [StrategyExecuted True], "+
+			convergedConditionsEvent("Unknown", "Unknown"),
 	)
 
 	f.run()
@@ -2766,17 +3166,29 @@ func TestControllerChooseClustersSkipsUnschedulable(t *testing.T) {
 
 	expected := contender.DeepCopy()
 	expected.Annotations[shipper.ReleaseClustersAnnotation] = clusterA.Name
+	expected.Status.TrafficSummary = buildTrafficSummary(map[string]uint32{clusterA.Name: 0})
 
 	condScheduled := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeScheduled, corev1.ConditionTrue, "", "")
 	releaseutil.SetReleaseCondition(&expected.Status, *condScheduled)
 	condStrategyExecuted := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeStrategyExecuted, corev1.ConditionTrue, "", "")
 	releaseutil.SetReleaseCondition(&expected.Status, *condStrategyExecuted)
+	condTrafficConverged := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeTrafficConverged, corev1.ConditionUnknown, "", "")
+	releaseutil.SetReleaseCondition(&expected.Status, *condTrafficConverged)
+	condCapacityConverged := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeCapacityConverged, corev1.ConditionUnknown, "", "")
+	releaseutil.SetReleaseCondition(&expected.Status, *condCapacityConverged)
 
+	// Picking clusters annotates the release, which has to go through
+	// the release itself rather than its status.
 	f.actions = []kubetesting.Action{
 		kubetesting.NewUpdateAction(
 			shipper.SchemeGroupVersion.WithResource("releases"),
 			contender.GetNamespace(),
 			expected),
+		kubetesting.NewUpdateSubresourceAction(
+			shipper.SchemeGroupVersion.WithResource("releases"),
+			"status",
+			contender.GetNamespace(),
+			expected),
 	}
 
 	f.filter = f.filter.Extend(actionfilter{[]string{"update"}, []string{"releases"}})
@@ -2800,7 +3212,63 @@ func TestControllerChooseClustersSkipsUnschedulable(t *testing.T) {
 			"Normal ReleaseScheduled Created CapacityTarget \"%s\"",
 			relKey,
 		),
-		"Normal ReleaseConditionChanged [Scheduled False] -> [Scheduled True], [] -> [StrategyExecuted True]",
+		"Normal ReleaseConditionChanged [Scheduled False] -> [Scheduled True], [] -> [StrategyExecuted True], "+
+			convergedConditionsEvent("Unknown", "Unknown"),
+	)
+
+	f.run()
+}
+
+func TestReleaseObservedGenerationAdvancesAfterReconcile(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	f.cycles = 1
+
+	contender := buildRelease()
+	contender.Generation = 3
+	contender.Status.ObservedGeneration = 2
+	f.addObjects(contender.DeepCopy())
+
+	expected := contender.DeepCopy()
+	expected.Annotations[shipper.ReleaseClustersAnnotation] = cluster.Name
+	expected.Status.ObservedGeneration = 3
+	expected.Status.TrafficSummary = buildTrafficSummary(map[string]uint32{cluster.Name: 0})
+	condScheduled := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeScheduled, corev1.ConditionTrue, "", "")
+	releaseutil.SetReleaseCondition(&expected.Status, *condScheduled)
+	condStrategyExecuted := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeStrategyExecuted, corev1.ConditionTrue, "", "")
+	releaseutil.SetReleaseCondition(&expected.Status, *condStrategyExecuted)
+	condTrafficConverged := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeTrafficConverged, corev1.ConditionUnknown, "", "")
+	releaseutil.SetReleaseCondition(&expected.Status, *condTrafficConverged)
+	condCapacityConverged := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeCapacityConverged, corev1.ConditionUnknown, "", "")
+	releaseutil.SetReleaseCondition(&expected.Status, *condCapacityConverged)
+
+	// Picking clusters annotates the release, which has to go through
+	// the release itself rather than its status.
+	f.actions = []kubetesting.Action{
+		kubetesting.NewUpdateAction(
+			shipper.SchemeGroupVersion.WithResource("releases"),
+			contender.GetNamespace(),
+			expected),
+		kubetesting.NewUpdateSubresourceAction(
+			shipper.SchemeGroupVersion.WithResource("releases"),
+			"status",
+			contender.GetNamespace(),
+			expected),
+	}
+
+	f.filter = f.filter.Extend(actionfilter{[]string{"update"}, []string{"releases"}})
+
+	relKey := fmt.Sprintf("%s/%s", contender.GetNamespace(), contender.GetName())
+	f.expectedEvents = append(f.expectedEvents,
+		fmt.Sprintf("Normal ClustersSelected Set clusters for \"%s\" to %s", relKey, cluster.Name),
+		fmt.Sprintf("Normal ReleaseScheduled Created InstallationTarget \"%s\"", relKey),
+		fmt.Sprintf("Normal ReleaseScheduled Created TrafficTarget \"%s\"", relKey),
+		fmt.Sprintf("Normal ReleaseScheduled Created CapacityTarget \"%s\"", relKey),
+		"Normal ReleaseConditionChanged [Scheduled False] -> [Scheduled True], [] -> [StrategyExecuted True], "+
+			convergedConditionsEvent("Unknown", "Unknown"),
 	)
 
 	f.run()
@@ -2956,6 +3424,20 @@ func TestUnhealthyTrafficAndCapacityIncumbentConvergesConsistently(t *testing.T)
 	releaseutil.SetReleaseCondition(&expected.Status, *condScheduled)
 	condStrategyExecuted := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeStrategyExecuted, corev1.ConditionTrue, "", "")
 	releaseutil.SetReleaseCondition(&expected.Status, *condStrategyExecuted)
+	condTrafficConverged := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeTrafficConverged, corev1.ConditionTrue, "", "")
+	releaseutil.SetReleaseCondition(&expected.Status, *condTrafficConverged)
+	condCapacityConverged := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeCapacityConverged, corev1.ConditionTrue, "", "")
+	releaseutil.SetReleaseCondition(&expected.Status, *condCapacityConverged)
+	expected.Status.TrafficSummary = buildTrafficSummary(map[string]uint32{"minikube": 100})
+
+	// The incumbent's own targets are still converging on the broken
+	// cluster, and its conditions should say so.
+	expectedIncumbent := incumbent.release.DeepCopy()
+	condTrafficNotConverged := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeTrafficConverged, corev1.ConditionFalse, ClustersNotReady, "[broken-cluster]")
+	releaseutil.SetReleaseCondition(&expectedIncumbent.Status, *condTrafficNotConverged)
+	condCapacityNotConverged := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeCapacityConverged, corev1.ConditionFalse, ClustersNotReady, "[broken-cluster]")
+	releaseutil.SetReleaseCondition(&expectedIncumbent.Status, *condCapacityNotConverged)
+	expectedIncumbent.Status.TrafficSummary = buildTrafficSummary(map[string]uint32{"broken-cluster": 0, "minikube": 0})
 
 	f.addObjects(
 		contender.release.DeepCopy(),
@@ -2970,8 +3452,9 @@ func TestUnhealthyTrafficAndCapacityIncumbentConvergesConsistently(t *testing.T)
 	)
 
 	f.actions = append(f.actions,
-		kubetesting.NewUpdateAction(
+		kubetesting.NewUpdateSubresourceAction(
 			shipper.SchemeGroupVersion.WithResource("releases"),
+			"status",
 			contender.release.GetNamespace(),
 			expected))
 
@@ -3015,16 +3498,27 @@ func TestUnhealthyTrafficAndCapacityIncumbentConvergesConsistently(t *testing.T)
 	}
 	patch, _ = json.Marshal(newContenderStatus)
 
-	f.actions = append(f.actions, kubetesting.NewPatchAction(
+	f.actions = append(f.actions, kubetesting.NewPatchSubresourceAction(
 		shipper.SchemeGroupVersion.WithResource("releases"),
 		contender.release.GetNamespace(),
 		contender.release.GetName(),
 		types.MergePatchType,
 		patch,
+		"status",
 	))
 
+	f.actions = append(f.actions,
+		kubetesting.NewUpdateSubresourceAction(
+			shipper.SchemeGroupVersion.WithResource("releases"),
+			"status",
+			incumbent.release.GetNamespace(),
+			expectedIncumbent))
+
 	f.expectedEvents = append(f.expectedEvents,
-		`Normal ReleaseConditionChanged [] -> [StrategyExecuted True]`)
+		`Normal ReleaseConditionChanged [] -> [StrategyExecuted True], `+
+			convergedConditionsEvent("True", "True"),
+		`Normal ReleaseConditionChanged `+
+			convergedConditionsEvent("False ClustersNotReady [broken-cluster]", "False ClustersNotReady [broken-cluster]"))
 
 	f.run()
 }
@@ -3066,10 +3560,12 @@ func TestControllerDetectInconsistentTargetStep(t *testing.T) {
 		"failed to execute strategy: \"Release test-namespace/test-incumbent target step is inconsistent: unexpected value 1 (expected: 2)\"",
 	)
 	releaseutil.SetReleaseCondition(&expected.Status, *condStrategyExecuted)
+	expected.Status.TrafficSummary = buildTrafficSummary(map[string]uint32{"minikube": 100})
 
 	f.actions = []kubetesting.Action{
-		kubetesting.NewUpdateAction(
+		kubetesting.NewUpdateSubresourceAction(
 			shipper.SchemeGroupVersion.WithResource("releases"),
+			"status",
 			incumbent.release.GetNamespace(),
 			expected),
 	}
@@ -3194,20 +3690,192 @@ func TestUpdatesHistoricalReleaseStrategyStateConditions(t *testing.T) {
 		},
 	}
 	patch, _ := json.Marshal(expectedStatus)
-	f.actions = append(f.actions, kubetesting.NewPatchAction(
+	f.actions = append(f.actions, kubetesting.NewPatchSubresourceAction(
 		shipper.SchemeGroupVersion.WithResource("releases"),
 		preincumbent.GetNamespace(),
 		preincumbent.GetName(),
 		types.MergePatchType,
 		patch,
+		"status",
 	))
 
+	// the release update carrying the converged conditions is not what
+	// this test is about
+	f.filter = f.filter.Extend(actionfilter{
+		[]string{"patch"},
+		[]string{"releases"},
+	})
+
 	key := fmt.Sprintf("%s/%s", preincumbent.GetNamespace(), preincumbent.GetName())
 
 	f.expectedEvents = append(f.expectedEvents,
 		fmt.Sprintf(
 			"Normal ReleaseStateTransitioned Release %q had its state \"WaitingForCapacity\" transitioned to \"False\"",
-			key))
+			key),
+		"Normal ReleaseConditionChanged "+convergedConditionsEvent("True", "True"))
 
 	f.run()
 }
+
+func TestIncumbentIsEnqueuedOnContenderCompletion(t *testing.T) {
+	namespace := "test-namespace"
+	incumbentName, contenderName := "test-incumbent", "test-contender"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+	totalReplicaCount := int32(1)
+	contender := f.buildContender(namespace, contenderName, totalReplicaCount)
+	incumbent := f.buildIncumbent(namespace, incumbentName, totalReplicaCount)
+
+	f.clientset = shipperfake.NewSimpleClientset()
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+
+	// We feed the informer cache directly instead of starting the
+	// informers, so the only keys showing up in the workqueue are the
+	// ones this test is about.
+	releaseIndexer := f.informerFactory.Shipper().V1alpha1().Releases().Informer().GetIndexer()
+	for _, rel := range []*shipper.Release{contender.release, incumbent.release} {
+		if err := releaseIndexer.Add(rel.DeepCopy()); err != nil {
+			t.Fatalf("failed to add release to informer cache: %s", err)
+		}
+	}
+
+	incumbentKey := fmt.Sprintf("%s/%s", namespace, incumbentName)
+	c.observedTargets.Observe(incumbentKey, "fingerprint")
+
+	completed := contender.release.DeepCopy()
+	releaseutil.SetReleaseCondition(&completed.Status, *releaseutil.NewReleaseCondition(
+		shipper.ReleaseConditionTypeComplete, corev1.ConditionTrue, "", ""))
+
+	// Updates that don't complete the contender leave the incumbent
+	// alone.
+	c.enqueueIncumbentOnCompletion(contender.release, contender.release.DeepCopy())
+	c.enqueueIncumbentOnCompletion(completed, completed.DeepCopy())
+	if n := c.releaseWorkqueue.Len(); n != 0 {
+		t.Fatalf("expected no releases to be enqueued, got %d", n)
+	}
+
+	c.enqueueIncumbentOnCompletion(contender.release, completed)
+	if n := c.releaseWorkqueue.Len(); n != 1 {
+		t.Fatalf("expected exactly one release to be enqueued, got %d", n)
+	}
+
+	key, _ := c.releaseWorkqueue.Get()
+	if key != incumbentKey {
+		t.Errorf("expected incumbent %q to be enqueued, got %q", incumbentKey, key)
+	}
+
+	if c.observedTargets.Unchanged(incumbentKey, "fingerprint") {
+		t.Errorf("expected incumbent to be reconciled in full on its next sync")
+	}
+}
+
+func TestContenderIsEnqueuedOnApplicationChange(t *testing.T) {
+	namespace := "test-namespace"
+	incumbentName, contenderName := "test-incumbent", "test-contender"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+	totalReplicaCount := int32(1)
+	contender := f.buildContender(namespace, contenderName, totalReplicaCount)
+	incumbent := f.buildIncumbent(namespace, incumbentName, totalReplicaCount)
+
+	f.clientset = shipperfake.NewSimpleClientset()
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+
+	releaseIndexer := f.informerFactory.Shipper().V1alpha1().Releases().Informer().GetIndexer()
+	for _, rel := range []*shipper.Release{contender.release, incumbent.release} {
+		if err := releaseIndexer.Add(rel.DeepCopy()); err != nil {
+			t.Fatalf("failed to add release to informer cache: %s", err)
+		}
+	}
+
+	contenderKey := fmt.Sprintf("%s/%s", namespace, contenderName)
+	c.observedTargets.Observe(contenderKey, "fingerprint")
+
+	stepChanged := app.DeepCopy()
+	stepChanged.Spec.Template.Strategy = &fullon
+
+	// Resyncs don't change anything about the application.
+	c.enqueueContenderOnApplicationChange(app, app.DeepCopy())
+	if n := c.releaseWorkqueue.Len(); n != 0 {
+		t.Fatalf("expected no releases to be enqueued, got %d", n)
+	}
+
+	c.enqueueContenderOnApplicationChange(app, stepChanged)
+	if n := c.releaseWorkqueue.Len(); n != 1 {
+		t.Fatalf("expected exactly one release to be enqueued, got %d", n)
+	}
+
+	key, _ := c.releaseWorkqueue.Get()
+	if key != contenderKey {
+		t.Errorf("expected contender %q to be enqueued, got %q", contenderKey, key)
+	}
+
+	if c.observedTargets.Unchanged(contenderKey, "fingerprint") {
+		t.Errorf("expected contender to be reconciled in full on its next sync")
+	}
+}
+
+func TestReleasesAreEnqueuedOnClusterChange(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+	otherCluster := buildCluster("other")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+	totalReplicaCount := int32(1)
+	contender := f.buildContender(namespace, "test-contender", totalReplicaCount)
+	incumbent := f.buildIncumbent(namespace, "test-incumbent", totalReplicaCount)
+	incumbent.release.Annotations[shipper.ReleaseClustersAnnotation] = otherCluster.Name
+
+	f.clientset = shipperfake.NewSimpleClientset()
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+
+	releaseIndexer := f.informerFactory.Shipper().V1alpha1().Releases().Informer().GetIndexer()
+	for _, rel := range []*shipper.Release{contender.release, incumbent.release} {
+		if err := releaseIndexer.Add(rel.DeepCopy()); err != nil {
+			t.Fatalf("failed to add release to informer cache: %s", err)
+		}
+	}
+
+	contenderKey := fmt.Sprintf("%s/%s", namespace, contender.release.Name)
+	c.observedTargets.Observe(contenderKey, "fingerprint")
+
+	// Resyncs don't change anything about the cluster.
+	c.enqueueReleasesOnClusterChange(cluster, cluster.DeepCopy())
+	if n := c.releaseWorkqueue.Len(); n != 0 {
+		t.Fatalf("expected no releases to be enqueued, got %d", n)
+	}
+
+	unschedulable := cluster.DeepCopy()
+	unschedulable.Spec.Scheduler.Unschedulable = true
+
+	c.enqueueReleasesOnClusterChange(cluster, unschedulable)
+	if n := c.releaseWorkqueue.Len(); n != 1 {
+		t.Fatalf("expected exactly one release to be enqueued, got %d", n)
+	}
+
+	key, _ := c.releaseWorkqueue.Get()
+	if key != contenderKey {
+		t.Errorf("expected release %q on the cluster to be enqueued, got %q", contenderKey, key)
+	}
+
+	if c.observedTargets.Unchanged(contenderKey, "fingerprint") {
+		t.Errorf("expected release to be reconciled in full on its next sync")
+	}
+}
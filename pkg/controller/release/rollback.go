@@ -0,0 +1,72 @@
+package release
+
+import (
+	"fmt"
+)
+
+// RollbackAction is a single step in safely returning all of the traffic
+// of an application from its contender back to its incumbent.
+type RollbackAction struct {
+	// Description tells what the action does, as in "scale incumbent
+	// foo-0 up to 100%".
+	Description string
+
+	// Patch is the patch that carries the action out.
+	Patch StrategyPatch
+}
+
+// RollbackPlan returns the actions that take all of the traffic away from
+// contender and give it back to incumbent, in the order they have to be
+// carried out in, each one only once the previous one has been achieved:
+//
+//  1. the incumbent is scaled up to full capacity,
+//  2. the incumbent gets all of the traffic back,
+//  3. the contender gets no traffic anymore,
+//  4. the contender is scaled down to no capacity.
+//
+// That way no release ever gets traffic it doesn't have the capacity for,
+// and capacity is never taken away before the traffic it serves is. Actions
+// that are already in place are left out, and there's nothing to roll back
+// to without an incumbent.
+func RollbackPlan(contender, incumbent *releaseInfo) []RollbackAction {
+	if incumbent == nil {
+		return nil
+	}
+
+	weights := map[string]uint32{
+		contender.release.Name: 0,
+		incumbent.release.Name: 100,
+	}
+
+	var plan []RollbackAction
+
+	if _, newSpec, _ := checkCapacity(incumbent.capacityTarget, 100); newSpec != nil {
+		plan = append(plan, RollbackAction{
+			Description: fmt.Sprintf("scale incumbent %s up to 100%%", incumbent.release.Name),
+			Patch:       &CapacityTargetSpecPatch{Name: incumbent.release.Name, NewSpec: newSpec},
+		})
+	}
+
+	if _, newSpec, _ := checkTraffic(incumbent.trafficTarget, weights[incumbent.release.Name]); newSpec != nil {
+		plan = append(plan, RollbackAction{
+			Description: fmt.Sprintf("set traffic weight of incumbent %s to %d", incumbent.release.Name, weights[incumbent.release.Name]),
+			Patch:       &TrafficTargetSpecPatch{Name: incumbent.release.Name, NewSpec: newSpec},
+		})
+	}
+
+	if _, newSpec, _ := checkTraffic(contender.trafficTarget, weights[contender.release.Name]); newSpec != nil {
+		plan = append(plan, RollbackAction{
+			Description: fmt.Sprintf("set traffic weight of contender %s to %d", contender.release.Name, weights[contender.release.Name]),
+			Patch:       &TrafficTargetSpecPatch{Name: contender.release.Name, NewSpec: newSpec},
+		})
+	}
+
+	if _, newSpec, _ := checkCapacity(contender.capacityTarget, 0); newSpec != nil {
+		plan = append(plan, RollbackAction{
+			Description: fmt.Sprintf("scale contender %s down to 0%%", contender.release.Name),
+			Patch:       &CapacityTargetSpecPatch{Name: contender.release.Name, NewSpec: newSpec},
+		})
+	}
+
+	return plan
+}
@@ -0,0 +1,118 @@
+package release
+
+import (
+	"testing"
+)
+
+func TestRollbackPlan(t *testing.T) {
+	type action struct {
+		kind    string
+		release string
+		value   int
+	}
+
+	tests := []struct {
+		name              string
+		incumbentCapacity int32
+		incumbentWeight   uint32
+		contenderCapacity int32
+		contenderWeight   uint32
+		expected          []action
+	}{
+		{
+			name:              "half way through",
+			incumbentCapacity: 50,
+			incumbentWeight:   50,
+			contenderCapacity: 50,
+			contenderWeight:   50,
+			expected: []action{
+				{"capacity", "test-incumbent", 100},
+				{"traffic", "test-incumbent", 100},
+				{"traffic", "test-contender", 0},
+				{"capacity", "test-contender", 0},
+			},
+		},
+		{
+			name:              "incumbent kept its capacity",
+			incumbentCapacity: 100,
+			incumbentWeight:   10,
+			contenderCapacity: 100,
+			contenderWeight:   90,
+			expected: []action{
+				{"traffic", "test-incumbent", 100},
+				{"traffic", "test-contender", 0},
+				{"capacity", "test-contender", 0},
+			},
+		},
+		{
+			name:              "contender only has capacity",
+			incumbentCapacity: 100,
+			incumbentWeight:   100,
+			contenderCapacity: 10,
+			contenderWeight:   0,
+			expected: []action{
+				{"capacity", "test-contender", 0},
+			},
+		},
+		{
+			name:              "already rolled back",
+			incumbentCapacity: 100,
+			incumbentWeight:   100,
+			contenderCapacity: 0,
+			contenderWeight:   0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			namespace := "test-namespace"
+			app := buildApplication(namespace, "test-app")
+			cluster := buildCluster("minikube")
+
+			f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+			incumbent := f.buildIncumbent(namespace, "test-incumbent", 10)
+			incumbent.capacityTarget.Spec.Clusters[0].Percent = test.incumbentCapacity
+			incumbent.trafficTarget.Spec.Clusters[0].Weight = test.incumbentWeight
+
+			contender := f.buildContender(namespace, "test-contender", 10)
+			contender.capacityTarget.Spec.Clusters[0].Percent = test.contenderCapacity
+			contender.trafficTarget.Spec.Clusters[0].Weight = test.contenderWeight
+
+			var actual []action
+			for _, a := range RollbackPlan(contender, incumbent) {
+				switch patch := a.Patch.(type) {
+				case *CapacityTargetSpecPatch:
+					actual = append(actual, action{"capacity", patch.Name, int(patch.NewSpec.Clusters[0].Percent)})
+				case *TrafficTargetSpecPatch:
+					actual = append(actual, action{"traffic", patch.Name, int(patch.NewSpec.Clusters[0].Weight)})
+				default:
+					t.Fatalf("unexpected patch %T in rollback plan", a.Patch)
+				}
+			}
+
+			if len(actual) != len(test.expected) {
+				t.Fatalf("expected %d actions, got %d: %v", len(test.expected), len(actual), actual)
+			}
+
+			for i := range actual {
+				if actual[i] != test.expected[i] {
+					t.Errorf("expected action %d to be %v, got %v", i, test.expected[i], actual[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRollbackPlanWithoutIncumbent(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	contender := f.buildContender(namespace, "test-contender", 10)
+
+	if plan := RollbackPlan(contender, nil); len(plan) != 0 {
+		t.Errorf("expected nothing to roll back to without an incumbent, got %d actions", len(plan))
+	}
+}
@@ -1,6 +1,7 @@
 package release
 
 import (
+	gocontext "context"
 	"fmt"
 	"sort"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 	helmchart "k8s.io/helm/pkg/proto/hapi/chart"
 	"k8s.io/klog"
 
@@ -21,6 +23,7 @@ import (
 	"github.com/bookingcom/shipper/pkg/controller"
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
 	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+	replicasutil "github.com/bookingcom/shipper/pkg/util/replicas"
 )
 
 type Scheduler struct {
@@ -35,6 +38,10 @@ type Scheduler struct {
 	chartFetcher shipperrepo.ChartFetcher
 
 	recorder record.EventRecorder
+
+	// writeBudget is drawn from before creating or updating every
+	// target object.
+	writeBudget flowcontrol.RateLimiter
 }
 
 func NewScheduler(
@@ -46,6 +53,7 @@ func NewScheduler(
 	rolloutBlockLister listers.RolloutBlockLister,
 	chartFetcher shipperrepo.ChartFetcher,
 	recorder record.EventRecorder,
+	writeBudget flowcontrol.RateLimiter,
 ) *Scheduler {
 	return &Scheduler{
 		clientset: clientset,
@@ -59,6 +67,8 @@ func NewScheduler(
 		chartFetcher: chartFetcher,
 
 		recorder: recorder,
+
+		writeBudget: writeBudget,
 	}
 }
 
@@ -86,7 +96,7 @@ func (s *Scheduler) ChooseClusters(rel *shipper.Release) (*shipper.Release, erro
 	return rel, nil
 }
 
-func (s *Scheduler) ScheduleRelease(rel *shipper.Release) (*releaseInfo, error) {
+func (s *Scheduler) ScheduleRelease(ctx gocontext.Context, rel *shipper.Release) (*releaseInfo, error) {
 	metaKey := controller.MetaKey(rel)
 	klog.V(4).Infof("Processing release %q", metaKey)
 	defer klog.V(4).Infof("Finished processing %q", metaKey)
@@ -112,19 +122,47 @@ func (s *Scheduler) ScheduleRelease(rel *shipper.Release) (*releaseInfo, error)
 		return nil, err
 	}
 
+	existing, err := s.existingReleaseInfo(rel)
+	if err != nil {
+		return nil, err
+	}
+	toCreate, toUpdate := ClassifyTargets(existing)
+	klog.V(4).Infof("Release %q has target objects to create %v and to bring up to date %v",
+		metaKey, toCreate, toUpdate)
+
+	create := make(map[string]bool, len(toCreate))
+	for _, kind := range toCreate {
+		create[kind] = true
+	}
+
 	releaseErrors := shippererrors.NewMultiError()
 
-	it, err := s.CreateOrUpdateInstallationTarget(rel)
+	var it *shipper.InstallationTarget
+	if create["InstallationTarget"] {
+		it, err = s.createInstallationTarget(ctx, rel)
+	} else {
+		it, err = s.updateInstallationTarget(ctx, rel, existing.installationTarget)
+	}
 	if err != nil {
 		releaseErrors.Append(err)
 	}
 
-	tt, err := s.CreateOrUpdateTrafficTarget(rel)
+	var tt *shipper.TrafficTarget
+	if create["TrafficTarget"] {
+		tt, err = s.createTrafficTarget(ctx, rel)
+	} else {
+		tt, err = s.updateTrafficTarget(ctx, rel, existing.trafficTarget)
+	}
 	if err != nil {
 		releaseErrors.Append(err)
 	}
 
-	ct, err := s.CreateOrUpdateCapacityTarget(rel, replicaCount)
+	var ct *shipper.CapacityTarget
+	if create["CapacityTarget"] {
+		ct, err = s.createCapacityTarget(ctx, rel, replicaCount)
+	} else {
+		ct, err = s.updateCapacityTarget(ctx, rel, existing.capacityTarget, replicaCount)
+	}
 	if err != nil {
 		releaseErrors.Append(err)
 	}
@@ -213,7 +251,7 @@ func setInstallationTargetClusters(it *shipper.InstallationTarget, clusters []st
 	it.Spec.Clusters = clusters
 }
 
-func setCapacityTargetClusters(ct *shipper.CapacityTarget, clusters []string, totalReplicaCount int32) {
+func setCapacityTargetClusters(ct *shipper.CapacityTarget, clusters []string, replicaCounts map[string]int32) {
 	capacityTargetClusters := make([]shipper.ClusterCapacityTarget, 0, len(clusters))
 	for _, cluster := range clusters {
 		capacityTargetClusters = append(
@@ -221,7 +259,7 @@ func setCapacityTargetClusters(ct *shipper.CapacityTarget, clusters []string, to
 			shipper.ClusterCapacityTarget{
 				Name:              cluster,
 				Percent:           0,
-				TotalReplicaCount: totalReplicaCount,
+				TotalReplicaCount: replicaCounts[cluster],
 			})
 	}
 	ct.Spec.Clusters = capacityTargetClusters
@@ -240,9 +278,7 @@ func setTrafficTargetClusters(tt *shipper.TrafficTarget, clusters []string) {
 	tt.Spec.Clusters = trafficTargetClusters
 }
 
-func (s *Scheduler) CreateOrUpdateInstallationTarget(rel *shipper.Release) (*shipper.InstallationTarget, error) {
-	clusters := getReleaseClusters(rel)
-
+func (s *Scheduler) CreateOrUpdateInstallationTarget(ctx gocontext.Context, rel *shipper.Release) (*shipper.InstallationTarget, error) {
 	it, err := s.installationTargetLister.InstallationTargets(rel.GetNamespace()).Get(rel.GetName())
 	if err != nil {
 		if !errors.IsNotFound(err) {
@@ -251,38 +287,58 @@ func (s *Scheduler) CreateOrUpdateInstallationTarget(rel *shipper.Release) (*shi
 				err)
 			return nil, err
 		}
-		it := &shipper.InstallationTarget{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      rel.Name,
-				Namespace: rel.Namespace,
-				Labels:    rel.Labels,
-				OwnerReferences: []metav1.OwnerReference{
-					createOwnerRefFromRelease(rel),
-				},
-			},
-			Spec: shipper.InstallationTargetSpec{
-				Chart:       rel.Spec.Environment.Chart.DeepCopy(),
-				Values:      rel.Spec.Environment.Values,
-				CanOverride: true,
-			},
-		}
-		setInstallationTargetClusters(it, clusters)
+		return s.createInstallationTarget(ctx, rel)
+	}
 
-		updIt, err := s.clientset.ShipperV1alpha1().InstallationTargets(rel.GetNamespace()).Create(it)
-		if err != nil {
-			return nil, shippererrors.NewKubeclientCreateError(it, err)
-		}
+	return s.updateInstallationTarget(ctx, rel, it)
+}
 
-		s.recorder.Eventf(
-			rel,
-			corev1.EventTypeNormal,
-			"ReleaseScheduled",
-			"Created InstallationTarget %q",
-			controller.MetaKey(updIt),
-		)
+// createInstallationTarget creates the InstallationTarget of rel, set up for its
+// clusters.
+func (s *Scheduler) createInstallationTarget(ctx gocontext.Context, rel *shipper.Release) (*shipper.InstallationTarget, error) {
+	clusters := getReleaseClusters(rel)
 
-		return updIt, nil
+	it := &shipper.InstallationTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rel.Name,
+			Namespace: rel.Namespace,
+			Labels:    rel.Labels,
+			OwnerReferences: []metav1.OwnerReference{
+				createOwnerRefFromRelease(rel),
+			},
+		},
+		Spec: shipper.InstallationTargetSpec{
+			Chart:       rel.Spec.Environment.Chart.DeepCopy(),
+			Values:      rel.Spec.Environment.Values,
+			CanOverride: true,
+		},
 	}
+	setInstallationTargetClusters(it, clusters)
+
+	if err := s.writeBudget.Wait(ctx); err != nil {
+		return nil, shippererrors.NewKubeclientCreateError(it, err)
+	}
+
+	updIt, err := s.clientset.ShipperV1alpha1().InstallationTargets(rel.GetNamespace()).Create(it)
+	if err != nil {
+		return nil, shippererrors.NewKubeclientCreateError(it, err)
+	}
+
+	s.recorder.Eventf(
+		rel,
+		corev1.EventTypeNormal,
+		"ReleaseScheduled",
+		"Created InstallationTarget %q",
+		controller.MetaKey(updIt),
+	)
+
+	return updIt, nil
+}
+
+// updateInstallationTarget brings the clusters of it, the existing
+// InstallationTarget of rel, up to date.
+func (s *Scheduler) updateInstallationTarget(ctx gocontext.Context, rel *shipper.Release, it *shipper.InstallationTarget) (*shipper.InstallationTarget, error) {
+	clusters := getReleaseClusters(rel)
 
 	if !objectBelongsToRelease(it, rel) {
 		return nil, shippererrors.NewWrongOwnerReferenceError(it, rel)
@@ -293,6 +349,9 @@ func (s *Scheduler) CreateOrUpdateInstallationTarget(rel *shipper.Release) (*shi
 			controller.MetaKey(it),
 			strings.Join(clusters, ","))
 		setInstallationTargetClusters(it, clusters)
+		if err := s.writeBudget.Wait(ctx); err != nil {
+			return nil, err
+		}
 		updIt, err := s.clientset.ShipperV1alpha1().InstallationTargets(rel.GetNamespace()).Update(it)
 		if err != nil {
 			klog.Errorf("Failed to update InstallationTarget %q clusters: %s",
@@ -313,9 +372,47 @@ func (s *Scheduler) CreateOrUpdateInstallationTarget(rel *shipper.Release) (*shi
 	return it, nil
 }
 
-func (s *Scheduler) CreateOrUpdateCapacityTarget(rel *shipper.Release, totalReplicaCount int32) (*shipper.CapacityTarget, error) {
-	clusters := getReleaseClusters(rel)
+// clusterReplicaCounts returns the total number of replicas each of clusters
+// runs for a release with totalReplicaCount replicas. That's all of them,
+// unless every cluster caps its replicas, in which case they're spread
+// across clusters according to their caps. Clusters that are gone are
+// considered to have no cap.
+func (s *Scheduler) clusterReplicaCounts(clusters []string, totalReplicaCount int32) (map[string]int32, error) {
+	replicaCounts := make(map[string]int32, len(clusters))
+	clusterCaps := make(map[string]int, len(clusters))
+	for _, name := range clusters {
+		replicaCounts[name] = totalReplicaCount
+
+		cluster, err := s.clusterLister.Get(name)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, shippererrors.NewKubeclientGetError("", name, err).
+				WithShipperKind("Cluster")
+		}
 
+		if cluster.Spec.MaxReplicas > 0 {
+			clusterCaps[name] = int(cluster.Spec.MaxReplicas)
+		}
+	}
+
+	if len(clusters) == 0 || len(clusterCaps) < len(clusters) {
+		return replicaCounts, nil
+	}
+
+	distribution, err := replicasutil.DistributeCapacity(int(totalReplicaCount), clusterCaps)
+	if err != nil {
+		return nil, shippererrors.NewUnrecoverableError(err)
+	}
+
+	for name, replicas := range distribution {
+		replicaCounts[name] = int32(replicas)
+	}
+
+	return replicaCounts, nil
+}
+
+func (s *Scheduler) CreateOrUpdateCapacityTarget(ctx gocontext.Context, rel *shipper.Release, totalReplicaCount int32) (*shipper.CapacityTarget, error) {
 	ct, err := s.capacityTargetLister.CapacityTargets(rel.GetNamespace()).Get(rel.GetName())
 	if err != nil {
 		if !errors.IsNotFound(err) {
@@ -324,33 +421,57 @@ func (s *Scheduler) CreateOrUpdateCapacityTarget(rel *shipper.Release, totalRepl
 				err)
 			return nil, err
 		}
-		ct := &shipper.CapacityTarget{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      rel.Name,
-				Namespace: rel.Namespace,
-				Labels:    rel.Labels,
-				OwnerReferences: []metav1.OwnerReference{
-					createOwnerRefFromRelease(rel),
-				},
-			},
-		}
-		setCapacityTargetClusters(ct, clusters, totalReplicaCount)
+		return s.createCapacityTarget(ctx, rel, totalReplicaCount)
+	}
 
-		updCt, err := s.clientset.ShipperV1alpha1().CapacityTargets(rel.GetNamespace()).Create(ct)
-		if err != nil {
-			return nil, shippererrors.NewKubeclientCreateError(ct, err)
-		}
+	return s.updateCapacityTarget(ctx, rel, ct, totalReplicaCount)
+}
 
-		s.recorder.Eventf(
-			rel,
-			corev1.EventTypeNormal,
-			"ReleaseScheduled",
-			"Created CapacityTarget %q",
-			controller.MetaKey(updCt),
-		)
+// createCapacityTarget creates the CapacityTarget of rel, set up for its
+// clusters.
+func (s *Scheduler) createCapacityTarget(ctx gocontext.Context, rel *shipper.Release, totalReplicaCount int32) (*shipper.CapacityTarget, error) {
+	clusters := getReleaseClusters(rel)
 
-		return updCt, nil
+	ct := &shipper.CapacityTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rel.Name,
+			Namespace: rel.Namespace,
+			Labels:    rel.Labels,
+			OwnerReferences: []metav1.OwnerReference{
+				createOwnerRefFromRelease(rel),
+			},
+		},
+	}
+	replicaCounts, err := s.clusterReplicaCounts(clusters, totalReplicaCount)
+	if err != nil {
+		return nil, err
 	}
+	setCapacityTargetClusters(ct, clusters, replicaCounts)
+
+	if err := s.writeBudget.Wait(ctx); err != nil {
+		return nil, shippererrors.NewKubeclientCreateError(ct, err)
+	}
+
+	updCt, err := s.clientset.ShipperV1alpha1().CapacityTargets(rel.GetNamespace()).Create(ct)
+	if err != nil {
+		return nil, shippererrors.NewKubeclientCreateError(ct, err)
+	}
+
+	s.recorder.Eventf(
+		rel,
+		corev1.EventTypeNormal,
+		"ReleaseScheduled",
+		"Created CapacityTarget %q",
+		controller.MetaKey(updCt),
+	)
+
+	return updCt, nil
+}
+
+// updateCapacityTarget brings the clusters of ct, the existing
+// CapacityTarget of rel, up to date.
+func (s *Scheduler) updateCapacityTarget(ctx gocontext.Context, rel *shipper.Release, ct *shipper.CapacityTarget, totalReplicaCount int32) (*shipper.CapacityTarget, error) {
+	clusters := getReleaseClusters(rel)
 
 	if !objectBelongsToRelease(ct, rel) {
 		return nil, shippererrors.NewWrongOwnerReferenceError(ct, rel)
@@ -360,7 +481,14 @@ func (s *Scheduler) CreateOrUpdateCapacityTarget(rel *shipper.Release, totalRepl
 		klog.V(4).Infof("Updating CapacityTarget %q clusters to %s",
 			controller.MetaKey(ct),
 			strings.Join(clusters, ","))
-		setCapacityTargetClusters(ct, clusters, totalReplicaCount)
+		replicaCounts, err := s.clusterReplicaCounts(clusters, totalReplicaCount)
+		if err != nil {
+			return nil, err
+		}
+		setCapacityTargetClusters(ct, clusters, replicaCounts)
+		if err := s.writeBudget.Wait(ctx); err != nil {
+			return nil, err
+		}
 		updCt, err := s.clientset.ShipperV1alpha1().CapacityTargets(rel.GetNamespace()).Update(ct)
 		if err != nil {
 			klog.Errorf("Failed to update CapacityTarget %q clusters: %s",
@@ -381,9 +509,7 @@ func (s *Scheduler) CreateOrUpdateCapacityTarget(rel *shipper.Release, totalRepl
 	return ct, nil
 }
 
-func (s *Scheduler) CreateOrUpdateTrafficTarget(rel *shipper.Release) (*shipper.TrafficTarget, error) {
-	clusters := getReleaseClusters(rel)
-
+func (s *Scheduler) CreateOrUpdateTrafficTarget(ctx gocontext.Context, rel *shipper.Release) (*shipper.TrafficTarget, error) {
 	tt, err := s.trafficTargetLister.TrafficTargets(rel.GetNamespace()).Get(rel.GetName())
 	if err != nil {
 		if !errors.IsNotFound(err) {
@@ -392,34 +518,54 @@ func (s *Scheduler) CreateOrUpdateTrafficTarget(rel *shipper.Release) (*shipper.
 				err)
 			return nil, err
 		}
-		tt := &shipper.TrafficTarget{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      rel.Name,
-				Namespace: rel.Namespace,
-				Labels:    rel.Labels,
-				OwnerReferences: []metav1.OwnerReference{
-					createOwnerRefFromRelease(rel),
-				},
-			},
-		}
-		setTrafficTargetClusters(tt, clusters)
+		return s.createTrafficTarget(ctx, rel)
+	}
 
-		updTt, err := s.clientset.ShipperV1alpha1().TrafficTargets(rel.GetNamespace()).Create(tt)
-		if err != nil {
-			return nil, shippererrors.NewKubeclientCreateError(tt, err)
-		}
+	return s.updateTrafficTarget(ctx, rel, tt)
+}
 
-		s.recorder.Eventf(
-			rel,
-			corev1.EventTypeNormal,
-			"ReleaseScheduled",
-			"Created TrafficTarget %q",
-			controller.MetaKey(updTt),
-		)
+// createTrafficTarget creates the TrafficTarget of rel, set up for its
+// clusters.
+func (s *Scheduler) createTrafficTarget(ctx gocontext.Context, rel *shipper.Release) (*shipper.TrafficTarget, error) {
+	clusters := getReleaseClusters(rel)
 
-		return updTt, nil
+	tt := &shipper.TrafficTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rel.Name,
+			Namespace: rel.Namespace,
+			Labels:    rel.Labels,
+			OwnerReferences: []metav1.OwnerReference{
+				createOwnerRefFromRelease(rel),
+			},
+		},
+	}
+	setTrafficTargetClusters(tt, clusters)
+
+	if err := s.writeBudget.Wait(ctx); err != nil {
+		return nil, shippererrors.NewKubeclientCreateError(tt, err)
+	}
+
+	updTt, err := s.clientset.ShipperV1alpha1().TrafficTargets(rel.GetNamespace()).Create(tt)
+	if err != nil {
+		return nil, shippererrors.NewKubeclientCreateError(tt, err)
 	}
 
+	s.recorder.Eventf(
+		rel,
+		corev1.EventTypeNormal,
+		"ReleaseScheduled",
+		"Created TrafficTarget %q",
+		controller.MetaKey(updTt),
+	)
+
+	return updTt, nil
+}
+
+// updateTrafficTarget brings the clusters of tt, the existing
+// TrafficTarget of rel, up to date.
+func (s *Scheduler) updateTrafficTarget(ctx gocontext.Context, rel *shipper.Release, tt *shipper.TrafficTarget) (*shipper.TrafficTarget, error) {
+	clusters := getReleaseClusters(rel)
+
 	if !objectBelongsToRelease(tt, rel) {
 		return nil, shippererrors.NewWrongOwnerReferenceError(tt, rel)
 	}
@@ -429,6 +575,9 @@ func (s *Scheduler) CreateOrUpdateTrafficTarget(rel *shipper.Release) (*shipper.
 			controller.MetaKey(tt),
 			strings.Join(clusters, ","))
 		setTrafficTargetClusters(tt, clusters)
+		if err := s.writeBudget.Wait(ctx); err != nil {
+			return nil, err
+		}
 		updTt, err := s.clientset.ShipperV1alpha1().TrafficTargets(rel.GetNamespace()).Update(tt)
 		if err != nil {
 			klog.Errorf("Failed to update TrafficTarget %q clusters: %s",
@@ -2,6 +2,7 @@ package release
 
 import (
 	"bytes"
+	gocontext "context"
 	"fmt"
 	"io/ioutil"
 	"path"
@@ -21,6 +22,7 @@ import (
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
 	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
 	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	shippercontroller "github.com/bookingcom/shipper/pkg/controller"
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
 	shippertesting "github.com/bookingcom/shipper/pkg/testing"
 	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
@@ -210,7 +212,8 @@ func newScheduler(
 		trafficTargetLister,
 		rolloutBlockLister,
 		localFetchChart,
-		record.NewFakeRecorder(42))
+		record.NewFakeRecorder(42),
+		shippercontroller.NewWriteBudget(0, 0))
 
 	stopCh := make(chan struct{})
 	defer close(stopCh)
@@ -426,7 +429,7 @@ func TestCreateAssociatedObjects(t *testing.T) {
 	expectedActions := buildExpectedActions(expected.DeepCopy(), []*shipper.Cluster{cluster.DeepCopy()})
 
 	c, clientset := newScheduler(fixtures)
-	if _, err := c.ScheduleRelease(release.DeepCopy()); err != nil {
+	if _, err := c.ScheduleRelease(gocontext.Background(), release.DeepCopy()); err != nil {
 		t.Fatal(err)
 	}
 
@@ -494,7 +497,7 @@ func TestCreateAssociatedObjectsDuplicateInstallationTargetMismatchingClusters(t
 	}
 
 	c, clientset := newScheduler(fixtures)
-	if _, err := c.ScheduleRelease(release.DeepCopy()); err != nil {
+	if _, err := c.ScheduleRelease(gocontext.Background(), release.DeepCopy()); err != nil {
 		t.Fatal(err)
 	}
 
@@ -557,7 +560,7 @@ func TestCreateAssociatedObjectsDuplicateTrafficTargetMismatchingClusters(t *tes
 	}
 
 	c, clientset := newScheduler(fixtures)
-	if _, err := c.ScheduleRelease(release.DeepCopy()); err != nil {
+	if _, err := c.ScheduleRelease(gocontext.Background(), release.DeepCopy()); err != nil {
 		t.Fatal(err)
 	}
 
@@ -620,7 +623,7 @@ func TestCreateAssociatedObjectsDuplicateCapacityTargetMismatchingClusters(t *te
 	}
 
 	c, clientset := newScheduler(fixtures)
-	if _, err := c.ScheduleRelease(release.DeepCopy()); err != nil {
+	if _, err := c.ScheduleRelease(gocontext.Background(), release.DeepCopy()); err != nil {
 		t.Fatal(err)
 	}
 
@@ -677,7 +680,7 @@ func TestCreateAssociatedObjectsDuplicateInstallationTargetSameOwner(t *testing.
 	}
 
 	c, clientset := newScheduler(fixtures)
-	if _, err := c.ScheduleRelease(release.DeepCopy()); err != nil {
+	if _, err := c.ScheduleRelease(gocontext.Background(), release.DeepCopy()); err != nil {
 		t.Fatal(err)
 	}
 
@@ -716,7 +719,7 @@ func TestCreateAssociatedObjectsDuplicateInstallationTargetNoOwner(t *testing.T)
 
 	c, _ := newScheduler(fixtures)
 
-	_, err := c.CreateOrUpdateInstallationTarget(release.DeepCopy())
+	_, err := c.CreateOrUpdateInstallationTarget(gocontext.Background(), release.DeepCopy())
 	if err == nil {
 		t.Fatalf("Expected an error here, none received")
 	}
@@ -773,7 +776,7 @@ func TestCreateAssociatedObjectsDuplicateTrafficTargetSameOwner(t *testing.T) {
 	}
 
 	c, clientset := newScheduler(fixtures)
-	if _, err := c.ScheduleRelease(release.DeepCopy()); err != nil {
+	if _, err := c.ScheduleRelease(gocontext.Background(), release.DeepCopy()); err != nil {
 		t.Fatal(err)
 	}
 
@@ -813,7 +816,7 @@ func TestCreateAssociatedObjectsDuplicateTrafficTargetNoOwner(t *testing.T) {
 
 	c, _ := newScheduler(fixtures)
 
-	_, err := c.CreateOrUpdateTrafficTarget(release.DeepCopy())
+	_, err := c.CreateOrUpdateTrafficTarget(gocontext.Background(), release.DeepCopy())
 	if err == nil {
 		t.Fatalf("Expected an error here, none received")
 	}
@@ -843,7 +846,7 @@ func TestCreateAssociatedObjectsDuplicateCapacityTargetSameOwner(t *testing.T) {
 			},
 		},
 	}
-	setCapacityTargetClusters(capacitytarget, []string{cluster.Name}, totalReplicaCount)
+	setCapacityTargetClusters(capacitytarget, []string{cluster.Name}, map[string]int32{cluster.Name: totalReplicaCount})
 	fixtures := []runtime.Object{cluster, release, capacitytarget}
 
 	// Expected release and actions. Even with an existing capacitytarget object
@@ -870,7 +873,7 @@ func TestCreateAssociatedObjectsDuplicateCapacityTargetSameOwner(t *testing.T) {
 	}
 
 	c, clientset := newScheduler(fixtures)
-	if _, err := c.ScheduleRelease(release.DeepCopy()); err != nil {
+	if _, err := c.ScheduleRelease(gocontext.Background(), release.DeepCopy()); err != nil {
 		t.Fatal(err)
 	}
 
@@ -909,7 +912,7 @@ func TestCreateAssociatedObjectsDuplicateCapacityTargetNoOwner(t *testing.T) {
 
 	c, _ := newScheduler(fixtures)
 
-	_, err := c.CreateOrUpdateCapacityTarget(release.DeepCopy(), 1)
+	_, err := c.CreateOrUpdateCapacityTarget(gocontext.Background(), release.DeepCopy(), 1)
 	if err == nil {
 		t.Fatalf("Expected an error here, none received")
 	}
@@ -919,6 +922,50 @@ func TestCreateAssociatedObjectsDuplicateCapacityTargetNoOwner(t *testing.T) {
 	}
 }
 
+// TestCreateCapacityTargetSpreadsReplicasAcrossCappedClusters checks that a
+// release scheduled on clusters that all cap their replicas has them spread
+// across those clusters, and that it runs all of them in every cluster as
+// soon as one of them doesn't have a cap.
+func TestCreateCapacityTargetSpreadsReplicasAcrossCappedClusters(t *testing.T) {
+	tests := []struct {
+		name     string
+		caps     []int32
+		expected []int32
+	}{
+		{name: "all clusters capped", caps: []int32{4, 12}, expected: []int32{3, 7}},
+		{name: "one cluster without a cap", caps: []int32{4, 0}, expected: []int32{10, 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := buildRelease()
+			fixtures := []runtime.Object{release}
+			names := []string{}
+			for i, maxReplicas := range tt.caps {
+				cluster := buildCluster(fmt.Sprintf("minikube-%d", i))
+				cluster.Spec.MaxReplicas = maxReplicas
+				fixtures = append(fixtures, cluster)
+				names = append(names, cluster.Name)
+			}
+			release.Annotations[shipper.ReleaseClustersAnnotation] = strings.Join(names, ",")
+
+			c, _ := newScheduler(fixtures)
+
+			ct, err := c.CreateOrUpdateCapacityTarget(gocontext.Background(), release.DeepCopy(), 10)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			for i, spec := range ct.Spec.Clusters {
+				if spec.TotalReplicaCount != tt.expected[i] {
+					t.Errorf("expected cluster %q to run %d replicas, got %d",
+						spec.Name, tt.expected[i], spec.TotalReplicaCount)
+				}
+			}
+		})
+	}
+}
+
 // TestComputeTargetClusters works the core of the scheduler logic: matching
 // regions and capabilities between releases and clusters NOTE: the "expected"
 // clusters are due to the particular prefList outcomes, and as such should be
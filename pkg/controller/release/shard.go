@@ -0,0 +1,30 @@
+package release
+
+import (
+	"hash/fnv"
+)
+
+// Shard is the share of namespaces a release controller is responsible
+// for, when the work is split across several of them. Namespaces are
+// assigned to shards by hash, so every controller agrees on who owns what
+// as long as they're all given the same Count.
+type Shard struct {
+	// Index is the shard this controller owns, out of Count.
+	Index int
+
+	// Count is the number of shards namespaces are split into. A
+	// controller owns every namespace when it's 1 or less.
+	Count int
+}
+
+// Owns returns whether releases in namespace belong to s.
+func (s Shard) Owns(namespace string) bool {
+	if s.Count <= 1 {
+		return true
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(namespace))
+
+	return int(h.Sum32()%uint32(s.Count)) == s.Index
+}
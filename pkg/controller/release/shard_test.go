@@ -0,0 +1,82 @@
+package release
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+)
+
+func TestShardOwnsEveryNamespaceOnce(t *testing.T) {
+	const count = 3
+
+	for i := 0; i < 100; i++ {
+		namespace := fmt.Sprintf("namespace-%d", i)
+
+		owners := 0
+		for index := 0; index < count; index++ {
+			if (Shard{Index: index, Count: count}).Owns(namespace) {
+				owners++
+			}
+		}
+
+		if owners != 1 {
+			t.Errorf("expected namespace %q to be owned by exactly one shard, got %d", namespace, owners)
+		}
+
+		if !(Shard{}).Owns(namespace) {
+			t.Errorf("expected an unsharded controller to own namespace %q", namespace)
+		}
+	}
+}
+
+// TestEnqueueReleaseOnlyInShard enqueues releases from a bunch of
+// namespaces and checks only the ones in the controller's shard make it
+// into its workqueue.
+func TestEnqueueReleaseOnlyInShard(t *testing.T) {
+	app := buildApplication("test-namespace", "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	f.shard = Shard{Index: 1, Count: 2}
+	f.clientset = shipperfake.NewSimpleClientset()
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+	defer c.releaseWorkqueue.ShutDown()
+
+	expected := 0
+	for i := 0; i < 20; i++ {
+		namespace := fmt.Sprintf("namespace-%d", i)
+		if f.shard.Owns(namespace) {
+			expected++
+		}
+
+		contender := f.buildContender(namespace, "test-contender", 10)
+		c.enqueueRelease(contender.release)
+	}
+
+	if expected == 0 || expected == 20 {
+		t.Fatalf("expected namespaces to be split across shards, got %d out of 20 in this one", expected)
+	}
+
+	if n := c.releaseWorkqueue.Len(); n != expected {
+		t.Fatalf("expected %d releases in this shard to be enqueued, got %d", expected, n)
+	}
+
+	for c.releaseWorkqueue.Len() > 0 {
+		key, _ := c.releaseWorkqueue.Get()
+		c.releaseWorkqueue.Done(key)
+
+		namespace, _, _ := cache.SplitMetaNamespaceKey(key.(string))
+		if !f.shard.Owns(namespace) {
+			t.Errorf("expected only releases in this shard to be enqueued, got %q", key)
+		}
+	}
+}
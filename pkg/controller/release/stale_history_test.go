@@ -0,0 +1,87 @@
+package release
+
+import (
+	gocontext "context"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/record"
+
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+)
+
+// TestStrategyIgnoresStaleApplicationHistory runs a strategy step that
+// scales the incumbent down for an application whose history has been
+// edited by hand to point at the wrong releases, and checks the strategy
+// still acts on the actual incumbent. The release controller never trusts
+// anything but the releases of the application to tell its contender from
+// its incumbent, and works them out again on every sync, so there's
+// nothing recorded that can go stale and has to be healed.
+func TestStrategyIgnoresStaleApplicationHistory(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	app.Status.History = []string{"test-contender", "test-stale"}
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+	strategy := vanguard.DeepCopy()
+	strategy.Steps[1].Capacity.Incumbent = 100
+	strategy.ScaleDownIncumbent = true
+
+	totalReplicaCount := int32(10)
+	incumbent := f.buildIncumbent(namespace, "test-incumbent", totalReplicaCount)
+	incumbent.trafficTarget.Spec.Clusters[0].Weight = 50
+
+	contender := f.buildContender(namespace, "test-contender", totalReplicaCount)
+	contender.release.Spec.Environment.Strategy = strategy
+	contender.release.Spec.TargetStep = 1
+	contender.capacityTarget.Spec.Clusters[0].Percent = 50
+	contender.trafficTarget.Spec.Clusters[0].Weight = 50
+
+	f.addObjects(
+		incumbent.release.DeepCopy(),
+		incumbent.installationTarget.DeepCopy(),
+		incumbent.capacityTarget.DeepCopy(),
+		incumbent.trafficTarget.DeepCopy(),
+		contender.release.DeepCopy(),
+		contender.installationTarget.DeepCopy(),
+		contender.capacityTarget.DeepCopy(),
+		contender.trafficTarget.DeepCopy(),
+	)
+
+	f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.informerFactory.Start(stopCh)
+	f.informerFactory.WaitForCacheSync(stopCh)
+
+	_, patches, _, err := c.executeReleaseStrategy(gocontext.Background(), contender, diffutil.NewMultiDiff())
+	if err != nil {
+		t.Fatalf("unexpected error executing strategy: %s", err)
+	}
+
+	var patchedIncumbent bool
+	for _, patch := range patches {
+		name, gvk, _ := patch.PatchSpec()
+		if name != contender.release.Name && name != incumbent.release.Name {
+			t.Errorf("expected only the actual contender and incumbent to be patched, got %s %q", gvk.Kind, name)
+		}
+
+		if _, ok := patch.(*CapacityTargetSpecPatch); ok && name == incumbent.release.Name {
+			patchedIncumbent = true
+		}
+	}
+
+	if !patchedIncumbent {
+		t.Errorf("expected the actual incumbent's capacity to be scaled down, got patches %v", patches)
+	}
+}
@@ -0,0 +1,69 @@
+package release
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+// recordStepStarted marks rel as working towards step. Whatever step a
+// release works on first is considered started as soon as it's scheduled,
+// so nothing is recorded until it has achieved at least one step.
+func recordStepStarted(rel *shipper.Release, step int32, diff *diffutil.MultiDiff) {
+	cond := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeStepAchieved)
+	if cond == nil || isWorkingOnStep(cond, step) {
+		return
+	}
+
+	recordStepTransition(rel, []shipper.ReleaseCondition{*releaseutil.NewStepStartedCondition(step)}, diff)
+}
+
+// recordStepFinished marks step as achieved by rel. A step that is achieved
+// without the controller ever seeing the release work towards it is taken
+// to have started and finished at the same time.
+func recordStepFinished(rel *shipper.Release, step int32, diff *diffutil.MultiDiff) {
+	var transitions []shipper.ReleaseCondition
+
+	cond := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeStepAchieved)
+	if cond != nil && !isWorkingOnStep(cond, step) {
+		transitions = append(transitions, *releaseutil.NewStepStartedCondition(step))
+	}
+	transitions = append(transitions, *releaseutil.NewStepFinishedCondition(step))
+
+	recordStepTransition(rel, transitions, diff)
+}
+
+// recordStepTransition sets the StepAchieved condition on rel to the last
+// of transitions, and updates the release's step durations with what can
+// be learned from them.
+func recordStepTransition(rel *shipper.Release, transitions []shipper.ReleaseCondition, diff *diffutil.MultiDiff) {
+	// The timeline is put together from the conditions before they are
+	// set, as setting them replaces the transitions they went through.
+	// Being scheduled only says anything about the first step a release
+	// works on.
+	var timeline []shipper.ReleaseCondition
+	if cond := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeStepAchieved); cond != nil {
+		timeline = append(timeline, *cond)
+	} else if cond := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeScheduled); cond != nil {
+		timeline = append(timeline, *cond)
+	}
+	timeline = append(timeline, transitions...)
+
+	d := releaseutil.SetReleaseCondition(&rel.Status, transitions[len(transitions)-1])
+	if d.IsEmpty() {
+		return
+	}
+
+	for _, duration := range releaseutil.ComputeStepDurations(timeline) {
+		releaseutil.SetStepDuration(&rel.Status, duration)
+	}
+	diff.Append(d)
+}
+
+// isWorkingOnStep returns whether cond, a StepAchieved condition, says its
+// release is working towards step without having achieved it yet.
+func isWorkingOnStep(cond *shipper.ReleaseCondition, step int32) bool {
+	return cond.Status == corev1.ConditionFalse && cond.Step == step
+}
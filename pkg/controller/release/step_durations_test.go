@@ -0,0 +1,110 @@
+package release
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+func TestStepDurationsAreRecordedAsTheReleaseAdvances(t *testing.T) {
+	rel := buildRelease()
+	scheduled := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeScheduled, corev1.ConditionTrue, "", "")
+	releaseutil.SetReleaseCondition(&rel.Status, *scheduled)
+
+	diff := diffutil.NewMultiDiff()
+
+	// Working on the first step is implied by the release being
+	// scheduled.
+	recordStepStarted(rel, 0, diff)
+	if len(rel.Status.StepDurations) != 0 || !diff.IsEmpty() {
+		t.Fatalf("expected nothing to be recorded before the first step is achieved, got %v", rel.Status.StepDurations)
+	}
+
+	recordStepFinished(rel, 0, diff)
+	assertStepDurations(t, rel, map[int32]bool{0: true})
+
+	recordStepStarted(rel, 1, diff)
+	assertStepDurations(t, rel, map[int32]bool{0: true, 1: false})
+
+	cond := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeStepAchieved)
+	if cond == nil || cond.Status != corev1.ConditionFalse {
+		t.Fatalf("expected StepAchieved to be False while working on a step, got %v", cond)
+	}
+
+	// Syncing the same step over and over doesn't restart it.
+	started := rel.Status.StepDurations[1].Started
+	recordStepStarted(rel, 1, diff)
+	if !rel.Status.StepDurations[1].Started.Equal(&started) {
+		t.Fatalf("expected step start to stay at %v, got %v", started, rel.Status.StepDurations[1].Started)
+	}
+
+	recordStepFinished(rel, 1, diff)
+	assertStepDurations(t, rel, map[int32]bool{0: true, 1: true})
+
+	// Steps achieved right away are recorded too.
+	recordStepFinished(rel, 2, diff)
+	assertStepDurations(t, rel, map[int32]bool{0: true, 1: true, 2: true})
+
+	if diff.IsEmpty() {
+		t.Errorf("expected StepAchieved transitions to be reported")
+	}
+}
+
+// TestStepDurationsOfLaterSteps checks that only the first step a release
+// achieves is taken to have started when the release was scheduled: later
+// steps the release jumps straight to start and finish together.
+func TestStepDurationsOfLaterSteps(t *testing.T) {
+	scheduledAt := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFakeClock(scheduledAt)
+	releaseutil.Clock = fakeClock
+	releaseutil.ConditionsShouldDiscardTimestamps = false
+	defer func() {
+		releaseutil.Clock = clock.RealClock{}
+		releaseutil.ConditionsShouldDiscardTimestamps = true
+	}()
+
+	rel := buildRelease()
+	scheduled := releaseutil.NewReleaseCondition(shipper.ReleaseConditionTypeScheduled, corev1.ConditionTrue, "", "")
+	releaseutil.SetReleaseCondition(&rel.Status, *scheduled)
+
+	diff := diffutil.NewMultiDiff()
+
+	fakeClock.Step(5 * time.Minute)
+	recordStepFinished(rel, 0, diff)
+
+	fakeClock.Step(5 * time.Minute)
+	recordStepFinished(rel, 2, diff)
+
+	expected := map[int32]time.Duration{0: 5 * time.Minute, 2: 0}
+	for step, duration := range expected {
+		d := releaseutil.GetStepDuration(rel.Status, step)
+		if d == nil || d.Duration == nil || d.Duration.Duration != duration {
+			t.Errorf("expected step %d to take %s, got %v", step, duration, d)
+		}
+	}
+}
+
+func assertStepDurations(t *testing.T, rel *shipper.Release, expected map[int32]bool) {
+	t.Helper()
+
+	if len(rel.Status.StepDurations) != len(expected) {
+		t.Fatalf("expected %d step durations, got %v", len(expected), rel.Status.StepDurations)
+	}
+
+	for _, d := range rel.Status.StepDurations {
+		finished, ok := expected[d.Step]
+		if !ok {
+			t.Fatalf("unexpected duration for step %d", d.Step)
+		}
+
+		if finished != (d.Finished != nil && d.Duration != nil) {
+			t.Errorf("expected step %d to be finished: %t, got %v", d.Step, finished, d)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/bookingcom/shipper/pkg/controller"
 	"github.com/bookingcom/shipper/pkg/util/conditions"
 	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+	"github.com/bookingcom/shipper/pkg/util/replicas"
 )
 
 type PipelineContinuation bool
@@ -20,20 +21,26 @@ const (
 )
 
 type context struct {
-	release    *shipper.Release
-	step       int32
-	isHead     bool
-	isLastStep bool
-	hasTail    bool
+	release            *shipper.Release
+	step               int32
+	isHead             bool
+	isLastStep         bool
+	hasTail            bool
+	trafficRamp        *shipper.TrafficRamp
+	scaleDownIncumbent bool
+	capacityHeadroom   map[string]int
 }
 
 func (ctx *context) Copy() *context {
 	return &context{
-		release:    ctx.release,
-		step:       ctx.step,
-		isHead:     ctx.isHead,
-		isLastStep: ctx.isLastStep,
-		hasTail:    ctx.hasTail,
+		release:            ctx.release,
+		step:               ctx.step,
+		isHead:             ctx.isHead,
+		isLastStep:         ctx.isLastStep,
+		hasTail:            ctx.hasTail,
+		trafficRamp:        ctx.trafficRamp,
+		scaleDownIncumbent: ctx.scaleDownIncumbent,
+		capacityHeadroom:   ctx.capacityHeadroom,
 	}
 }
 
@@ -69,6 +76,10 @@ func (p *Pipeline) Process(strategyStep shipper.RolloutStrategyStep, cond condit
 type StrategyExecutor struct {
 	strategy *shipper.RolloutStrategy
 	step     int32
+
+	// capacityHeadroom caps, by cluster name, how many replicas the
+	// contender can grow by at once.
+	capacityHeadroom map[string]int
 }
 
 func NewStrategyExecutor(strategy *shipper.RolloutStrategy, step int32) *StrategyExecutor {
@@ -137,11 +148,14 @@ func (e *StrategyExecutor) Execute(prev, curr, succ *releaseInfo) (bool, []Strat
 	isLastStep := int(e.step) == len(e.strategy.Steps)-1
 
 	ctx := &context{
-		release:    curr.release,
-		hasTail:    hasTail,
-		isLastStep: isLastStep,
-		step:       e.step,
-		isHead:     isHead,
+		release:            curr.release,
+		hasTail:            hasTail,
+		isLastStep:         isLastStep,
+		step:               e.step,
+		isHead:             isHead,
+		trafficRamp:        e.strategy.TrafficRamp,
+		scaleDownIncumbent: e.strategy.ScaleDownIncumbent,
+		capacityHeadroom:   e.capacityHeadroom,
 	}
 
 	pipeline := NewPipeline()
@@ -223,11 +237,52 @@ func genCapacityEnforcer(ctx *context, curr, succ *releaseInfo) PipelineStep {
 			capacityWeight = strategyStep.Capacity.Contender
 		} else {
 			capacityWeight = strategyStep.Capacity.Incumbent
+
+			// The incumbent's capacity follows the traffic its
+			// contender took from it, but never grows past the one
+			// of the step.
+			if ctx.scaleDownIncumbent {
+				trafficPercent := contenderTrafficPercent(strategyStep.Traffic)
+				if percent := replicas.IncumbentCapacityPercentForStep(trafficPercent); percent < capacityWeight {
+					capacityWeight = percent
+				}
+			}
 		}
 
 		if achieved, newSpec, clustersNotReady := checkCapacity(curr.capacityTarget, capacityWeight); !achieved {
 			klog.Infof("Release %q %s", controller.MetaKey(curr.release), "hasn't achieved capacity yet")
 
+			// Tearing the incumbent down while its contender is
+			// still short of capacity or traffic anywhere would
+			// leave a gap, so it's held back until it isn't.
+			if !isHead && capacityWeight == 0 {
+				if converged, clusters := checkContenderConverged(succ); !converged {
+					cond.SetFalse(
+						condType,
+						conditions.StrategyConditionsUpdate{
+							Reason:             ContenderNotConverged,
+							Message:            fmt.Sprintf("release %q is not scaled down until release %q has converged in clusters: %v", curr.release.GetName(), succ.release.GetName(), clusters),
+							Step:               ctx.step,
+							LastTransitionTime: time.Now(),
+						},
+					)
+
+					patches := make([]StrategyPatch, 0, 1)
+					relPatch := buildContenderStrategyConditionsPatch(ctx, cond)
+					if relPatch.Alters(ctx.release) {
+						patches = append(patches, relPatch)
+					}
+
+					return PipelineBreak, patches, nil
+				}
+			}
+
+			// Only the contender is scaled up gradually, scaling
+			// the incumbent down is never held back.
+			if isHead {
+				newSpec = stepCapacitySpec(curr.capacityTarget, newSpec, ctx.capacityHeadroom)
+			}
+
 			patches := make([]StrategyPatch, 0, 2)
 
 			cond.SetFalse(
@@ -272,10 +327,22 @@ func genCapacityEnforcer(ctx *context, curr, succ *releaseInfo) PipelineStep {
 	}
 }
 
+// contenderTrafficPercent returns the share of the traffic of a strategy
+// step that goes to the contender, as a percentage. Step weights are only
+// relative to each other, so they can't be read as percentages directly.
+func contenderTrafficPercent(traffic shipper.RolloutStrategyStepValue) uint32 {
+	total := int64(traffic.Contender) + int64(traffic.Incumbent)
+	if total <= 0 {
+		return 0
+	}
+
+	return uint32(int64(traffic.Contender) * 100 / total)
+}
+
 func genTrafficEnforcer(ctx *context, curr, succ *releaseInfo) PipelineStep {
 	return func(strategyStep shipper.RolloutStrategyStep, cond conditions.StrategyConditionsMap) (PipelineContinuation, []StrategyPatch, []ReleaseStrategyStateTransition) {
 		var condType shipper.StrategyConditionType
-		var trafficWeight int32
+		var trafficWeight uint32
 		isHead := succ == nil
 		isInitiator := releasesIdentical(ctx.release, curr.release)
 
@@ -284,15 +351,22 @@ func genTrafficEnforcer(ctx *context, curr, succ *releaseInfo) PipelineStep {
 		} else {
 			condType = shipper.StrategyConditionIncumbentAchievedTraffic
 		}
+
 		if isHead {
-			trafficWeight = strategyStep.Traffic.Contender
+			trafficWeight = uint32(strategyStep.Traffic.Contender)
 		} else {
-			trafficWeight = strategyStep.Traffic.Incumbent
+			trafficWeight = uint32(strategyStep.Traffic.Incumbent)
 		}
 
-		if achieved, newSpec, reason := checkTraffic(curr.trafficTarget, uint32(trafficWeight)); !achieved {
+		if achieved, newSpec, reason := checkTraffic(curr.trafficTarget, trafficWeight); !achieved {
 			klog.Infof("Release %q %s", controller.MetaKey(curr.release), "hasn't achieved traffic yet")
 
+			// Only the contender ramps up, the incumbent keeps its
+			// weight until the contender reaches the one of the step.
+			if isHead {
+				newSpec = rampTrafficSpec(curr.trafficTarget, newSpec, ctx.trafficRamp)
+			}
+
 			patches := make([]StrategyPatch, 0, 2)
 
 			cond.SetFalse(
@@ -0,0 +1,157 @@
+package release
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+const (
+	GateStuck = "GateStuck"
+)
+
+// deniedGate is the strategy condition keeping a release from advancing,
+// along with when it started doing so for that particular reason.
+type deniedGate struct {
+	condType shipper.StrategyConditionType
+	reason   string
+	message  string
+	since    time.Time
+}
+
+// stuckGateTracker keeps track of how long releases have been denied
+// advancement by their strategy. Every step of the strategy is a gate that
+// can deny advancement while the release waits for installation, capacity
+// or traffic. Waiting is perfectly normal, but waiting for longer than a
+// threshold usually means the gate will never pass.
+type stuckGateTracker struct {
+	threshold time.Duration
+	now       func() time.Time
+
+	mu     sync.Mutex
+	denied map[string]deniedGate
+}
+
+func newStuckGateTracker(threshold time.Duration) *stuckGateTracker {
+	return &stuckGateTracker{
+		threshold: threshold,
+		now:       time.Now,
+		denied:    make(map[string]deniedGate),
+	}
+}
+
+// Observe records that the release identified by relKey is being denied
+// advancement by cond, or that it isn't denied at all if cond is nil. It
+// returns whether the release has been denied for the same reason for
+// longer than the threshold and, if it hasn't yet, how long until it will
+// have. The timer is reset every time the reason changes. A zero threshold
+// disables the tracker altogether.
+func (t *stuckGateTracker) Observe(relKey string, cond *shipper.ReleaseStrategyCondition) (bool, time.Duration) {
+	if t.threshold <= 0 {
+		return false, 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if cond == nil {
+		delete(t.denied, relKey)
+		return false, 0
+	}
+
+	now := t.now()
+	gate, ok := t.denied[relKey]
+	if !ok || gate.condType != cond.Type || gate.reason != cond.Reason || gate.message != cond.Message {
+		gate = deniedGate{
+			condType: cond.Type,
+			reason:   cond.Reason,
+			message:  cond.Message,
+			since:    now,
+		}
+		t.denied[relKey] = gate
+	}
+
+	if remaining := t.threshold - now.Sub(gate.since); remaining > 0 {
+		return false, remaining
+	}
+
+	return true, 0
+}
+
+func (t *stuckGateTracker) Forget(relKey string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.denied, relKey)
+}
+
+// deniedStrategyCondition returns the first condition in status that is
+// keeping the release from advancing, if any.
+func deniedStrategyCondition(status *shipper.ReleaseStrategyStatus) *shipper.ReleaseStrategyCondition {
+	if status == nil {
+		return nil
+	}
+
+	for i := range status.Conditions {
+		if status.Conditions[i].Status == corev1.ConditionFalse {
+			return &status.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
+// checkStuckGate sets the GateStuck condition on rel when its strategy has
+// been denying it advancement for longer than the configured threshold,
+// and emits a warning when it first gets stuck. patches are the ones the
+// strategy executor just came up with for rel, as they carry a fresher
+// strategy status than rel itself. It returns how long until rel would be
+// stuck if it's still waiting at a gate, so it can be synced again by
+// then.
+func (c *Controller) checkStuckGate(relKey string, rel *shipper.Release, patches []StrategyPatch, diff *diffutil.MultiDiff) time.Duration {
+	strategyStatus := rel.Status.Strategy
+	for _, patch := range patches {
+		if p, ok := patch.(*ReleaseStrategyStatusPatch); ok && p.Name == rel.Name {
+			strategyStatus = p.NewStrategyStatus
+		}
+	}
+
+	denied := deniedStrategyCondition(strategyStatus)
+	if stuck, remaining := c.stuckGates.Observe(relKey, denied); !stuck {
+		cond := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeGateStuck)
+		if cond != nil && cond.Status == corev1.ConditionTrue {
+			condition := releaseutil.NewReleaseCondition(
+				shipper.ReleaseConditionTypeGateStuck,
+				corev1.ConditionFalse,
+				"",
+				"",
+			)
+			diff.Append(releaseutil.SetReleaseCondition(&rel.Status, *condition))
+		}
+
+		return remaining
+	}
+
+	msg := fmt.Sprintf("%s has been %s for longer than %s: %s",
+		denied.Type, denied.Status, c.stuckGates.threshold, denied.Message)
+	condition := releaseutil.NewReleaseCondition(
+		shipper.ReleaseConditionTypeGateStuck,
+		corev1.ConditionTrue,
+		denied.Reason,
+		msg,
+	)
+
+	d := releaseutil.SetReleaseCondition(&rel.Status, *condition)
+	if !d.IsEmpty() {
+		c.recorder.Event(rel, corev1.EventTypeWarning, GateStuck, msg)
+	}
+	diff.Append(d)
+
+	return 0
+}
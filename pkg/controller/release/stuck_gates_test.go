@@ -0,0 +1,197 @@
+package release
+
+import (
+	gocontext "context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+func deniedCapacity(message string) *shipper.ReleaseStrategyCondition {
+	return &shipper.ReleaseStrategyCondition{
+		Type:    shipper.StrategyConditionContenderAchievedCapacity,
+		Status:  corev1.ConditionFalse,
+		Reason:  ClustersNotReady,
+		Message: message,
+	}
+}
+
+func TestStuckGateTracker(t *testing.T) {
+	const relKey = "test-namespace/test-release"
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := newStuckGateTracker(time.Minute)
+	tracker.now = func() time.Time { return now }
+
+	if stuck, remaining := tracker.Observe(relKey, deniedCapacity("[minikube]")); stuck || remaining != time.Minute {
+		t.Fatalf("expected a freshly denied release not to be stuck for another minute, got %t and %s", stuck, remaining)
+	}
+
+	now = now.Add(20 * time.Second)
+	if stuck, remaining := tracker.Observe(relKey, deniedCapacity("[minikube]")); stuck || remaining != 40*time.Second {
+		t.Fatalf("expected the release not to be stuck for another 40s, got %t and %s", stuck, remaining)
+	}
+
+	now = now.Add(40 * time.Second)
+	if stuck, _ := tracker.Observe(relKey, deniedCapacity("[minikube]")); !stuck {
+		t.Fatalf("expected a release denied for longer than the threshold to be stuck")
+	}
+
+	// A different reason means the gate is making some sort of
+	// progress, so the timer starts over.
+	if stuck, _ := tracker.Observe(relKey, deniedCapacity("[minikube kube-2]")); stuck {
+		t.Fatalf("expected the timer to be reset when the reason changes")
+	}
+
+	now = now.Add(time.Minute)
+	if stuck, _ := tracker.Observe(relKey, deniedCapacity("[minikube kube-2]")); !stuck {
+		t.Fatalf("expected a release denied for longer than the threshold to be stuck")
+	}
+
+	if stuck, remaining := tracker.Observe(relKey, nil); stuck || remaining != 0 {
+		t.Fatalf("expected a release that is no longer denied not to be stuck, got %t and %s", stuck, remaining)
+	}
+
+	if stuck, _ := tracker.Observe(relKey, deniedCapacity("[minikube kube-2]")); stuck {
+		t.Fatalf("expected the timer to be reset once the release got past the gate")
+	}
+}
+
+func TestStuckGateTrackerDisabled(t *testing.T) {
+	const relKey = "test-namespace/test-release"
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := newStuckGateTracker(0)
+	tracker.now = func() time.Time { return now }
+
+	tracker.Observe(relKey, deniedCapacity("[minikube]"))
+	now = now.Add(24 * time.Hour)
+	if stuck, remaining := tracker.Observe(relKey, deniedCapacity("[minikube]")); stuck || remaining != 0 {
+		t.Fatalf("expected a zero threshold to never report releases as stuck, got %t and %s", stuck, remaining)
+	}
+}
+
+func TestReleaseDeniedForLongerThanThresholdIsGateStuck(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	f.gateStuckThreshold = time.Minute
+	f.clientset = shipperfake.NewSimpleClientset()
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.stuckGates.now = func() time.Time { return now }
+
+	contender := f.buildContender(namespace, "test-contender", 1)
+	rel := contender.release
+	relKey := fmt.Sprintf("%s/%s", namespace, rel.Name)
+	rel.Status.Strategy = &shipper.ReleaseStrategyStatus{
+		Conditions: []shipper.ReleaseStrategyCondition{
+			*deniedCapacity("[minikube]"),
+		},
+	}
+
+	diff := diffutil.NewMultiDiff()
+	if remaining := c.checkStuckGate(relKey, rel, nil, diff); remaining != time.Minute {
+		t.Errorf("expected the release to be synced again in %s, got %s", time.Minute, remaining)
+	}
+	if cond := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeGateStuck); cond != nil {
+		t.Fatalf("expected no GateStuck condition before the threshold, got %v", cond)
+	}
+
+	now = now.Add(2 * time.Minute)
+	c.checkStuckGate(relKey, rel, nil, diff)
+
+	cond := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeGateStuck)
+	if cond == nil || cond.Status != corev1.ConditionTrue || cond.Reason != ClustersNotReady {
+		t.Fatalf("expected GateStuck to be True with reason %q, got %v", ClustersNotReady, cond)
+	}
+
+	expectedMsg := "ContenderAchievedCapacity has been False for longer than 1m0s: [minikube]"
+	if cond.Message != expectedMsg {
+		t.Errorf("expected GateStuck message %q, got %q", expectedMsg, cond.Message)
+	}
+
+	select {
+	case event := <-f.recorder.Events:
+		expectedEvent := fmt.Sprintf("Warning %s %s", GateStuck, expectedMsg)
+		if event != expectedEvent {
+			t.Errorf("expected event %q, got %q", expectedEvent, event)
+		}
+	default:
+		t.Errorf("expected a warning event for the stuck gate")
+	}
+
+	// Once the strategy moves past the gate, the condition is lifted.
+	rel.Status.Strategy.Conditions[0].Status = corev1.ConditionTrue
+	c.checkStuckGate(relKey, rel, nil, diff)
+
+	cond = releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeGateStuck)
+	if cond == nil || cond.Status != corev1.ConditionFalse {
+		t.Errorf("expected GateStuck to be False once past the gate, got %v", cond)
+	}
+}
+
+// TestReleaseWaitingAtGateIsRequeued syncs a contender that has to wait
+// for its capacity, and checks its fingerprint isn't recorded, so the sync
+// it's requeued for by the time it would be stuck isn't skipped.
+func TestReleaseWaitingAtGateIsRequeued(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	f.gateStuckThreshold = time.Hour
+
+	contender := f.buildContender(namespace, "test-contender", 10)
+	contender.release.Spec.TargetStep = 1
+	contender.release.Status.AchievedStep = &shipper.AchievedStep{Step: 0, Name: vanguard.Steps[0].Name}
+
+	f.addObjects(
+		contender.release.DeepCopy(),
+		contender.installationTarget.DeepCopy(),
+		contender.capacityTarget.DeepCopy(),
+		contender.trafficTarget.DeepCopy(),
+	)
+
+	f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+	f.recorder = record.NewFakeRecorder(42)
+
+	c := f.newController()
+	defer c.releaseWorkqueue.ShutDown()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.informerFactory.Start(stopCh)
+	f.informerFactory.WaitForCacheSync(stopCh)
+
+	key := fmt.Sprintf("%s/%s", namespace, contender.release.Name)
+	if err := c.syncOneReleaseHandler(gocontext.Background(), key); err != nil {
+		t.Fatalf("unexpected error syncing release: %s", err)
+	}
+
+	fingerprint, err := c.targetsFingerprint(contender.release)
+	if err != nil {
+		t.Fatalf("unexpected error fingerprinting release: %s", err)
+	}
+
+	if c.observedTargets.Unchanged(key, fingerprint) {
+		t.Errorf("expected the fingerprint of a release waiting at a gate not to be recorded")
+	}
+}
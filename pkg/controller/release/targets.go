@@ -0,0 +1,67 @@
+package release
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+// ClassifyTargets splits the target objects of a release, by kind, into the
+// ones that are missing from info and need to be created, and the ones that
+// are already there and might need to be updated. Both come in
+// InstallationTarget, TrafficTarget, CapacityTarget order.
+func ClassifyTargets(info *releaseInfo) (toCreate, toUpdate []string) {
+	targets := []struct {
+		kind    string
+		present bool
+	}{
+		{"InstallationTarget", info.installationTarget != nil},
+		{"TrafficTarget", info.trafficTarget != nil},
+		{"CapacityTarget", info.capacityTarget != nil},
+	}
+
+	for _, target := range targets {
+		if target.present {
+			toUpdate = append(toUpdate, target.kind)
+		} else {
+			toCreate = append(toCreate, target.kind)
+		}
+	}
+
+	return toCreate, toUpdate
+}
+
+// existingReleaseInfo returns rel along with whichever of its target
+// objects already exist. Unlike buildReleaseInfo, missing target objects
+// are not an error, and are just left out.
+func (s *Scheduler) existingReleaseInfo(rel *shipper.Release) (*releaseInfo, error) {
+	ns, name := rel.Namespace, rel.Name
+	info := &releaseInfo{release: rel}
+
+	it, err := s.installationTargetLister.InstallationTargets(ns).Get(name)
+	if err == nil {
+		info.installationTarget = it
+	} else if !errors.IsNotFound(err) {
+		return nil, shippererrors.NewKubeclientGetError(ns, name, err).
+			WithShipperKind("InstallationTarget")
+	}
+
+	tt, err := s.trafficTargetLister.TrafficTargets(ns).Get(name)
+	if err == nil {
+		info.trafficTarget = tt
+	} else if !errors.IsNotFound(err) {
+		return nil, shippererrors.NewKubeclientGetError(ns, name, err).
+			WithShipperKind("TrafficTarget")
+	}
+
+	ct, err := s.capacityTargetLister.CapacityTargets(ns).Get(name)
+	if err == nil {
+		info.capacityTarget = ct
+	} else if !errors.IsNotFound(err) {
+		return nil, shippererrors.NewKubeclientGetError(ns, name, err).
+			WithShipperKind("CapacityTarget")
+	}
+
+	return info, nil
+}
@@ -0,0 +1,58 @@
+package release
+
+import (
+	"reflect"
+	"testing"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestClassifyTargets(t *testing.T) {
+	rel := &shipper.Release{}
+	it := &shipper.InstallationTarget{}
+	tt := &shipper.TrafficTarget{}
+	ct := &shipper.CapacityTarget{}
+
+	tests := []struct {
+		name             string
+		info             *releaseInfo
+		expectedToCreate []string
+		expectedToUpdate []string
+	}{
+		{
+			name:             "fully missing",
+			info:             &releaseInfo{release: rel},
+			expectedToCreate: []string{"InstallationTarget", "TrafficTarget", "CapacityTarget"},
+		},
+		{
+			name: "partially missing",
+			info: &releaseInfo{
+				release:            rel,
+				installationTarget: it,
+				capacityTarget:     ct,
+			},
+			expectedToCreate: []string{"TrafficTarget"},
+			expectedToUpdate: []string{"InstallationTarget", "CapacityTarget"},
+		},
+		{
+			name: "fully present",
+			info: &releaseInfo{
+				release:            rel,
+				installationTarget: it,
+				trafficTarget:      tt,
+				capacityTarget:     ct,
+			},
+			expectedToUpdate: []string{"InstallationTarget", "TrafficTarget", "CapacityTarget"},
+		},
+	}
+
+	for _, test := range tests {
+		toCreate, toUpdate := ClassifyTargets(test.info)
+		if !reflect.DeepEqual(toCreate, test.expectedToCreate) {
+			t.Errorf("%s: expected to create %v, got %v", test.name, test.expectedToCreate, toCreate)
+		}
+		if !reflect.DeepEqual(toUpdate, test.expectedToUpdate) {
+			t.Errorf("%s: expected to update %v, got %v", test.name, test.expectedToUpdate, toUpdate)
+		}
+	}
+}
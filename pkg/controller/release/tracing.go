@@ -0,0 +1,38 @@
+package release
+
+import (
+	gocontext "context"
+
+	"go.opentelemetry.io/otel/api/global"
+	apitrace "go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/label"
+)
+
+// TracerName is the name of the tracer the release controller creates its
+// spans with. Nothing gets traced unless a tracer provider is registered
+// globally, as the default one is a no-op.
+const TracerName = "github.com/bookingcom/shipper/pkg/controller/release"
+
+// ReleaseKeyAttribute is the span attribute carrying the key of the
+// release being reconciled.
+const ReleaseKeyAttribute = label.Key("shipper.release.key")
+
+func defaultTracer() apitrace.Tracer {
+	return global.Tracer(TracerName)
+}
+
+// startSpan starts a span called name for the release identified by relKey,
+// as a child of whatever span is in ctx.
+func (c *Controller) startSpan(ctx gocontext.Context, name, relKey string, attrs ...label.KeyValue) (gocontext.Context, apitrace.Span) {
+	attrs = append([]label.KeyValue{ReleaseKeyAttribute.String(relKey)}, attrs...)
+	return c.tracer.Start(ctx, name, apitrace.WithAttributes(attrs...))
+}
+
+// endSpan ends span, marking it as failed if err is not nil.
+func endSpan(ctx gocontext.Context, span apitrace.Span, err error) {
+	if err != nil {
+		span.RecordError(ctx, err, apitrace.WithErrorStatus(codes.Error))
+	}
+	span.End()
+}
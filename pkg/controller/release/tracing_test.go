@@ -0,0 +1,127 @@
+package release
+
+import (
+	"fmt"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	exporttrace "go.opentelemetry.io/otel/sdk/export/trace"
+	"go.opentelemetry.io/otel/sdk/export/trace/tracetest"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestReconcileIsTraced(t *testing.T) {
+	namespace := "test-namespace"
+	incumbentName, contenderName := "test-incumbent", "test-contender"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithConfig(sdktrace.Config{DefaultSampler: sdktrace.AlwaysSample()}),
+		sdktrace.WithSyncer(exporter),
+	)
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	f.cycles = 1
+	f.tracer = provider.Tracer(TracerName)
+
+	totalReplicaCount := int32(10)
+	contender := f.buildContender(namespace, contenderName, totalReplicaCount)
+	incumbent := f.buildIncumbent(namespace, incumbentName, totalReplicaCount)
+
+	contender.release.Spec.TargetStep = 1
+
+	f.addObjects(
+		contender.release.DeepCopy(),
+		contender.installationTarget.DeepCopy(),
+		contender.capacityTarget.DeepCopy(),
+		contender.trafficTarget.DeepCopy(),
+
+		incumbent.release.DeepCopy(),
+		incumbent.installationTarget.DeepCopy(),
+		incumbent.capacityTarget.DeepCopy(),
+		incumbent.trafficTarget.DeepCopy(),
+	)
+
+	ct := contender.capacityTarget.DeepCopy()
+	r := contender.release.DeepCopy()
+	f.expectCapacityStatusPatch(contender.release.Spec.TargetStep, ct, r, 50, uint(totalReplicaCount), Contender)
+	f.run()
+
+	relKey := fmt.Sprintf("%s/%s", namespace, contenderName)
+	patchKey := fmt.Sprintf("%s/%s", namespace, contender.capacityTarget.Name)
+
+	spans := make(map[string][]*exporttrace.SpanData)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = append(spans[span.Name], span)
+	}
+
+	root := onlySpan(t, spans, "syncOneRelease")
+	if root.ParentSpanID.IsValid() {
+		t.Errorf("expected syncOneRelease to be a root span")
+	}
+	assertSpanKey(t, root, relKey)
+
+	buildStrategy := onlySpan(t, spans, "buildStrategy")
+	assertChildOf(t, buildStrategy, root)
+	assertSpanKey(t, buildStrategy, relKey)
+
+	execute := onlySpan(t, spans, "executeStrategy")
+	assertChildOf(t, execute, buildStrategy)
+	assertSpanKey(t, execute, relKey)
+
+	// Every patch gets its own span, right under the reconcile.
+	patchedObjects := make(map[string]bool)
+	for _, patch := range spans["applyPatch"] {
+		assertChildOf(t, patch, root)
+		for _, attr := range patch.Attributes {
+			if attr.Key == "shipper.patch.object" {
+				patchedObjects[attr.Value.AsString()] = true
+			}
+		}
+	}
+
+	if !patchedObjects[patchKey] {
+		t.Errorf("expected an applyPatch span for %q, got %v", patchKey, patchedObjects)
+	}
+
+	for _, span := range exporter.GetSpans() {
+		if span.StatusCode == codes.Error {
+			t.Errorf("expected span %q not to have failed: %s", span.Name, span.StatusMessage)
+		}
+	}
+}
+
+func onlySpan(t *testing.T, spans map[string][]*exporttrace.SpanData, name string) *exporttrace.SpanData {
+	t.Helper()
+
+	if len(spans[name]) != 1 {
+		t.Fatalf("expected exactly one %q span, got %d", name, len(spans[name]))
+	}
+
+	return spans[name][0]
+}
+
+func assertChildOf(t *testing.T, child, parent *exporttrace.SpanData) {
+	t.Helper()
+
+	if child.SpanContext.TraceID != parent.SpanContext.TraceID || child.ParentSpanID != parent.SpanContext.SpanID {
+		t.Errorf("expected span %q to be a child of %q", child.Name, parent.Name)
+	}
+}
+
+func assertSpanKey(t *testing.T, span *exporttrace.SpanData, key string) {
+	t.Helper()
+
+	for _, attr := range span.Attributes {
+		if attr.Key == ReleaseKeyAttribute {
+			if attr.Value.AsString() != key {
+				t.Errorf("expected span %q to have key %q, got %q", span.Name, key, attr.Value.AsString())
+			}
+			return
+		}
+	}
+
+	t.Errorf("expected span %q to have a %s attribute", span.Name, ReleaseKeyAttribute)
+}
@@ -0,0 +1,203 @@
+package release
+
+import (
+	gocontext "context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+)
+
+// TestContenderTrafficRamps runs the strategy for a contender that has all
+// of its capacity for the 50/50 step of vanguard but none of its traffic,
+// and checks the traffic it's given follows the ramp of its strategy, only
+// moving on once its traffic target is ready.
+func TestContenderTrafficRamps(t *testing.T) {
+	tests := []struct {
+		name     string
+		ramp     *shipper.TrafficRamp
+		weight   uint32
+		ready    bool
+		expected *uint32
+	}{
+		{
+			name:     "no ramp",
+			weight:   0,
+			ready:    true,
+			expected: weightPtr(50),
+		},
+		{
+			name:     "exponential ramp from no traffic",
+			ramp:     &shipper.TrafficRamp{Shape: shipper.TrafficRampShapeExponential},
+			weight:   0,
+			ready:    true,
+			expected: weightPtr(1),
+		},
+		{
+			name:     "exponential ramp half way",
+			ramp:     &shipper.TrafficRamp{Shape: shipper.TrafficRampShapeExponential},
+			weight:   16,
+			ready:    true,
+			expected: weightPtr(32),
+		},
+		{
+			name:     "linear ramp close to the step weight",
+			ramp:     &shipper.TrafficRamp{Shape: shipper.TrafficRampShapeLinear, MaxDelta: 20},
+			weight:   40,
+			ready:    true,
+			expected: weightPtr(50),
+		},
+		{
+			name:   "ramp waits for traffic to converge",
+			ramp:   &shipper.TrafficRamp{Shape: shipper.TrafficRampShapeExponential},
+			weight: 16,
+			ready:  false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			namespace := "test-namespace"
+			app := buildApplication(namespace, "test-app")
+			cluster := buildCluster("minikube")
+
+			f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+			strategy := vanguard.DeepCopy()
+			strategy.TrafficRamp = test.ramp
+
+			contender := f.buildContender(namespace, "test-contender", 10)
+			contender.release.Spec.Environment.Strategy = strategy
+			contender.release.Spec.TargetStep = 1
+			contender.capacityTarget.Spec.Clusters[0].Percent = 50
+			contender.trafficTarget.Spec.Clusters[0].Weight = test.weight
+			if !test.ready {
+				contender.trafficTarget.Status.Conditions[0].Status = corev1.ConditionFalse
+			}
+
+			f.addObjects(
+				contender.release.DeepCopy(),
+				contender.installationTarget.DeepCopy(),
+				contender.capacityTarget.DeepCopy(),
+				contender.trafficTarget.DeepCopy(),
+			)
+
+			f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+			f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+			f.recorder = record.NewFakeRecorder(42)
+
+			c := f.newController()
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			f.informerFactory.Start(stopCh)
+			f.informerFactory.WaitForCacheSync(stopCh)
+
+			_, patches, _, err := c.executeReleaseStrategy(gocontext.Background(), contender, diffutil.NewMultiDiff())
+			if err != nil {
+				t.Fatalf("unexpected error executing strategy: %s", err)
+			}
+
+			var patched *uint32
+			for _, patch := range patches {
+				if ttPatch, ok := patch.(*TrafficTargetSpecPatch); ok {
+					patched = weightPtr(ttPatch.NewSpec.Clusters[0].Weight)
+				}
+			}
+
+			switch {
+			case test.expected == nil && patched != nil:
+				t.Errorf("expected traffic target not to be patched, got weight %d", *patched)
+			case test.expected != nil && patched == nil:
+				t.Errorf("expected traffic target to be patched to weight %d, got no patch", *test.expected)
+			case test.expected != nil && *patched != *test.expected:
+				t.Errorf("expected traffic target to be patched to weight %d, got %d", *test.expected, *patched)
+			}
+		})
+	}
+}
+
+// TestContenderTrafficUsesRelativeStepWeights checks the traffic weight a
+// contender is given is the one of its strategy step as it is. Step weights
+// are relative to each other, not percentages.
+func TestContenderTrafficUsesRelativeStepWeights(t *testing.T) {
+	tests := []struct {
+		name      string
+		contender int32
+		incumbent int32
+	}{
+		{name: "canary", contender: 9, incumbent: 1},
+		{name: "weights over 100", contender: 900, incumbent: 100},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			namespace := "test-namespace"
+			app := buildApplication(namespace, "test-app")
+			cluster := buildCluster("minikube")
+
+			f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+			strategy := vanguard.DeepCopy()
+			strategy.Steps[1].Traffic = shipper.RolloutStrategyStepValue{
+				Contender: test.contender,
+				Incumbent: test.incumbent,
+			}
+
+			contender := f.buildContender(namespace, "test-contender", 10)
+			contender.release.Spec.Environment.Strategy = strategy
+			contender.release.Spec.TargetStep = 1
+			contender.capacityTarget.Spec.Clusters[0].Percent = 50
+
+			f.addObjects(
+				contender.release.DeepCopy(),
+				contender.installationTarget.DeepCopy(),
+				contender.capacityTarget.DeepCopy(),
+				contender.trafficTarget.DeepCopy(),
+			)
+
+			f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+			f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+			f.recorder = record.NewFakeRecorder(42)
+
+			c := f.newController()
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			f.informerFactory.Start(stopCh)
+			f.informerFactory.WaitForCacheSync(stopCh)
+
+			_, patches, _, err := c.executeReleaseStrategy(gocontext.Background(), contender, diffutil.NewMultiDiff())
+			if err != nil {
+				t.Fatalf("unexpected error executing strategy: %s", err)
+			}
+
+			var patched *uint32
+			for _, patch := range patches {
+				if ttPatch, ok := patch.(*TrafficTargetSpecPatch); ok {
+					patched = weightPtr(ttPatch.NewSpec.Clusters[0].Weight)
+				}
+			}
+
+			if patched == nil {
+				t.Fatalf("expected traffic target to be patched to weight %d, got no patch", test.contender)
+			}
+
+			if *patched != uint32(test.contender) {
+				t.Errorf("expected traffic target to be patched to weight %d, got %d", test.contender, *patched)
+			}
+		})
+	}
+}
+
+func weightPtr(weight uint32) *uint32 {
+	return &weight
+}
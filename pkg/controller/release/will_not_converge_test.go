@@ -0,0 +1,88 @@
+package release
+
+import (
+	gocontext "context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+// TestReleaseIsBlockedWhenTrafficWillNotConverge syncs a contender working
+// towards a 50/50 split of traffic with its incumbent, in a cluster that lets
+// only so many pods receive traffic, and checks it's only blocked when one of
+// them would never get any.
+func TestReleaseIsBlockedWhenTrafficWillNotConverge(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxTrafficPods int32
+		blocked        bool
+	}{
+		{name: "no cap", maxTrafficPods: 0, blocked: false},
+		{name: "room for both releases", maxTrafficPods: 2, blocked: false},
+		{name: "room for one release only", maxTrafficPods: 1, blocked: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			namespace := "test-namespace"
+			app := buildApplication(namespace, "test-app")
+			cluster := buildCluster("minikube")
+			cluster.Spec.MaxTrafficPods = test.maxTrafficPods
+
+			f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+
+			totalReplicaCount := int32(10)
+			incumbent := f.buildIncumbent(namespace, "test-incumbent", totalReplicaCount)
+			contender := f.buildContender(namespace, "test-contender", totalReplicaCount)
+			contender.release.Spec.TargetStep = 1
+
+			f.addObjects(
+				incumbent.release.DeepCopy(),
+				incumbent.installationTarget.DeepCopy(),
+				incumbent.capacityTarget.DeepCopy(),
+				incumbent.trafficTarget.DeepCopy(),
+				contender.release.DeepCopy(),
+				contender.installationTarget.DeepCopy(),
+				contender.capacityTarget.DeepCopy(),
+				contender.trafficTarget.DeepCopy(),
+			)
+
+			f.clientset = shipperfake.NewSimpleClientset(f.objects...)
+			f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, time.Duration(0))
+			f.recorder = record.NewFakeRecorder(42)
+
+			c := f.newController()
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			f.informerFactory.Start(stopCh)
+			f.informerFactory.WaitForCacheSync(stopCh)
+
+			key := namespace + "/" + contender.release.Name
+			if err := c.syncRelease(gocontext.Background(), key, &TraceEntry{}); err != nil {
+				t.Fatalf("unexpected error syncing release: %s", err)
+			}
+
+			rel, err := f.clientset.ShipperV1alpha1().Releases(namespace).Get(contender.release.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error getting release: %s", err)
+			}
+
+			cond := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeBlocked)
+			blocked := cond != nil && cond.Status == corev1.ConditionTrue && cond.Reason == WillNotConverge
+			if blocked != test.blocked {
+				t.Errorf("expected release to be blocked as it will not converge: %t, got condition %+v",
+					test.blocked, cond)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package release
+
+import (
+	gocontext "context"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/record"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+	shippercontroller "github.com/bookingcom/shipper/pkg/controller"
+)
+
+// TestPatchWaitsForSharedWriteBudget has another controller use up all of
+// the write budget shared with the release controller, and checks the
+// release controller holds on to its patch until a token frees up instead
+// of sending it right away.
+func TestPatchWaitsForSharedWriteBudget(t *testing.T) {
+	namespace := "test-namespace"
+	app := buildApplication(namespace, "test-app")
+	cluster := buildCluster("minikube")
+
+	f := newFixture(t, app.DeepCopy(), cluster.DeepCopy())
+	f.writeBudget = shippercontroller.NewWriteBudget(5, 1)
+	contender := f.buildContender(namespace, "test-contender", 10)
+
+	const syncPeriod time.Duration = 0
+	f.clientset = shipperfake.NewSimpleClientset(contender.capacityTarget.DeepCopy())
+	f.informerFactory = shipperinformers.NewSharedInformerFactory(f.clientset, syncPeriod)
+	f.recorder = record.NewFakeRecorder(42)
+	controller := f.newController()
+
+	// Some other controller in the process takes the only token there
+	// is, and the next one is a fifth of a second away.
+	if !f.writeBudget.TryAccept() {
+		t.Fatalf("expected the write budget to start out with a token")
+	}
+
+	patch := &CapacityTargetSpecPatch{
+		Name: contender.capacityTarget.Name,
+		NewSpec: &shipper.CapacityTargetSpec{
+			Clusters: []shipper.ClusterCapacityTarget{
+				{Name: "minikube", Percent: 50, TotalReplicaCount: 10},
+			},
+		},
+	}
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := controller.applyPatch(ctx, namespace, patch); err == nil {
+		t.Fatalf("expected patch to be held back while the write budget is exhausted")
+	}
+
+	if actions := f.clientset.Actions(); len(actions) > 0 {
+		t.Fatalf("expected no writes while the write budget is exhausted, got %v", actions)
+	}
+
+	if err := controller.applyPatch(gocontext.Background(), namespace, patch); err != nil {
+		t.Fatalf("unexpected error sending patch once the write budget refilled: %s", err)
+	}
+
+	if actions := f.clientset.Actions(); len(actions) != 1 || actions[0].GetVerb() != "patch" {
+		t.Fatalf("expected a single patch once the write budget refilled, got %v", actions)
+	}
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"sort"
+	"sync"
+)
+
+// OnceReporter remembers what has already been reported about the objects
+// a controller works on, so problems are warned about once instead of on
+// every sync. A problem is reported again when its details change, or once
+// it's gone away and then comes back.
+type OnceReporter struct {
+	mu       sync.Mutex
+	reported map[string]map[string]map[string]string
+}
+
+func NewOnceReporter() *OnceReporter {
+	return &OnceReporter{
+		reported: make(map[string]map[string]map[string]string),
+	}
+}
+
+// Unreported records problems, keyed by what they're about and holding
+// whatever details make them worth reporting again when they change, as
+// the problems the object identified by key currently has in scope. It
+// returns the sorted names of the ones that haven't been reported yet.
+// Objects can have problems in any number of scopes, such as one per
+// cluster, and every scope is kept track of on its own.
+func (r *OnceReporter) Unreported(key, scope string, problems map[string]string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	scopes, ok := r.reported[key]
+	if !ok {
+		scopes = make(map[string]map[string]string)
+		r.reported[key] = scopes
+	}
+
+	previous := scopes[scope]
+	current := make(map[string]string, len(problems))
+	unreported := []string{}
+	for name, details := range problems {
+		current[name] = details
+		if reported, ok := previous[name]; !ok || reported != details {
+			unreported = append(unreported, name)
+		}
+	}
+
+	if len(current) > 0 {
+		scopes[scope] = current
+	} else {
+		delete(scopes, scope)
+	}
+
+	if len(scopes) == 0 {
+		delete(r.reported, key)
+	}
+
+	sort.Strings(unreported)
+
+	return unreported
+}
+
+// UnreportedNames is Unreported for problems that have no details besides
+// what they're about.
+func (r *OnceReporter) UnreportedNames(key, scope string, names []string) []string {
+	problems := make(map[string]string, len(names))
+	for _, name := range names {
+		problems[name] = ""
+	}
+
+	return r.Unreported(key, scope, problems)
+}
+
+// Forget drops everything reported about the object identified by key, in
+// every scope.
+func (r *OnceReporter) Forget(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.reported, key)
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOnceReporterOnlyReportsChanges(t *testing.T) {
+	const (
+		key      = "test-namespace/test-object"
+		clusterA = "cluster-a"
+		clusterB = "cluster-b"
+	)
+
+	reporter := NewOnceReporter()
+	problems := map[string]string{"pod-a": "contender,incumbent"}
+
+	if got := reporter.Unreported(key, clusterA, problems); !reflect.DeepEqual(got, []string{"pod-a"}) {
+		t.Fatalf("expected a new problem to be reported, got %v", got)
+	}
+
+	if got := reporter.Unreported(key, clusterA, problems); len(got) != 0 {
+		t.Fatalf("expected a problem not to be reported twice, got %v", got)
+	}
+
+	// Scopes keep track of their own problems.
+	if got := reporter.Unreported(key, clusterB, problems); !reflect.DeepEqual(got, []string{"pod-a"}) {
+		t.Fatalf("expected the problem to be reported in another scope, got %v", got)
+	}
+
+	problems = map[string]string{"pod-a": "contender,other", "pod-b": ""}
+	if got := reporter.Unreported(key, clusterA, problems); !reflect.DeepEqual(got, []string{"pod-a", "pod-b"}) {
+		t.Fatalf("expected changed and new problems to be reported, got %v", got)
+	}
+
+	// A problem that goes away is reported again if it comes back.
+	reporter.Unreported(key, clusterA, map[string]string{"pod-a": "contender,other"})
+	if got := reporter.Unreported(key, clusterA, problems); !reflect.DeepEqual(got, []string{"pod-b"}) {
+		t.Fatalf("expected a problem to be reported again once it's back, got %v", got)
+	}
+
+	if got := reporter.UnreportedNames(key, clusterA, []string{"pod-a", "pod-c"}); !reflect.DeepEqual(got, []string{"pod-a", "pod-c"}) {
+		t.Fatalf("expected names without details to be reported as changed, got %v", got)
+	}
+
+	reporter.Forget(key)
+	if got := reporter.Unreported(key, clusterB, problems); !reflect.DeepEqual(got, []string{"pod-a", "pod-b"}) {
+		t.Fatalf("expected a forgotten object to be reported again, got %v", got)
+	}
+}
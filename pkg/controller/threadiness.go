@@ -0,0 +1,25 @@
+package controller
+
+const (
+	releasesPerWorker = 50
+	minThreadiness    = 1
+	maxThreadiness    = 16
+)
+
+// RecommendThreadiness returns how many workers a controller should run
+// to keep up with releaseCount releases: one for every 50 of them, but no
+// fewer than 1 and no more than 16. It's only advice, there's nothing
+// wrong with running a different number of workers.
+func RecommendThreadiness(releaseCount int) int {
+	threadiness := (releaseCount + releasesPerWorker - 1) / releasesPerWorker
+
+	if threadiness < minThreadiness {
+		return minThreadiness
+	}
+
+	if threadiness > maxThreadiness {
+		return maxThreadiness
+	}
+
+	return threadiness
+}
@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"testing"
+)
+
+func TestRecommendThreadiness(t *testing.T) {
+	tests := []struct {
+		releaseCount int
+		expected     int
+	}{
+		{0, 1},
+		{1, 1},
+		{50, 1},
+		{51, 2},
+		{100, 2},
+		{420, 9},
+		{800, 16},
+		{10000, 16},
+	}
+
+	for _, tt := range tests {
+		got := RecommendThreadiness(tt.releaseCount)
+		if got != tt.expected {
+			t.Errorf("expected %d workers for %d releases, got %d", tt.expected, tt.releaseCount, got)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package traffic
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	trafficutil "github.com/bookingcom/shipper/pkg/util/traffic"
+)
+
+// ReleaseAudit compares how many pods of a release are labeled to receive
+// traffic with how many should be according to its weight.
+type ReleaseAudit struct {
+	Release  string
+	Pods     int
+	Serving  int
+	Expected int
+}
+
+// AuditReport holds a ReleaseAudit for every release of an application in
+// a cluster, sorted by release name.
+type AuditReport struct {
+	Releases []ReleaseAudit
+}
+
+// Balanced returns whether every release in r has exactly as many pods
+// labeled to receive traffic as it should.
+func (r AuditReport) Balanced() bool {
+	for _, audit := range r.Releases {
+		if audit.Serving != audit.Expected {
+			return false
+		}
+	}
+	return true
+}
+
+// AuditTrafficLabels compares the shipper.PodTrafficStatusLabel of pods,
+// grouped by release, with the number of pods of each release that should
+// get traffic according to weights, out of the totalPods of the
+// application in a cluster. It works out the expected number of pods the
+// same way the controller does when shifting traffic, leaving out
+// minimum serving pods. Releases with weight but no pods are audited too.
+func AuditTrafficLabels(pods []*corev1.Pod, weights map[string]uint32, totalPods int) AuditReport {
+	audits := make(map[string]*ReleaseAudit)
+	auditFor := func(release string) *ReleaseAudit {
+		audit, ok := audits[release]
+		if !ok {
+			audit = &ReleaseAudit{Release: release}
+			audits[release] = audit
+		}
+		return audit
+	}
+
+	for _, pod := range pods {
+		audit := auditFor(pod.Labels[shipper.ReleaseLabel])
+		audit.Pods++
+		if getsTraffic(pod) {
+			audit.Serving++
+		}
+	}
+
+	var totalWeight uint32
+	for release, weight := range weights {
+		auditFor(release)
+		totalWeight += weight
+	}
+
+	report := AuditReport{Releases: make([]ReleaseAudit, 0, len(audits))}
+	for release, audit := range audits {
+		audit.Expected = trafficutil.ReleasePodTarget(audit.Pods, weights[release], totalPods, totalWeight)
+		report.Releases = append(report.Releases, *audit)
+	}
+
+	sort.Slice(report.Releases, func(i, j int) bool {
+		return report.Releases[i].Release < report.Releases[j].Release
+	})
+
+	return report
+}
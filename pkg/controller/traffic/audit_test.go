@@ -0,0 +1,91 @@
+package traffic
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+)
+
+func TestAuditTrafficLabels(t *testing.T) {
+	concat := func(podSets ...[]*corev1.Pod) []*corev1.Pod {
+		var pods []*corev1.Pod
+		for _, set := range podSets {
+			pods = append(pods, set...)
+		}
+		return pods
+	}
+
+	tests := []struct {
+		name     string
+		pods     []*corev1.Pod
+		weights  map[string]uint32
+		expected []ReleaseAudit
+		balanced bool
+	}{
+		{
+			name: "balanced",
+			pods: concat(
+				buildPods(shippertesting.TestApp, "incumbent", 5, withTraffic),
+				buildPods(shippertesting.TestApp, "contender", 5, withTraffic),
+			),
+			weights: map[string]uint32{"incumbent": 50, "contender": 50},
+			expected: []ReleaseAudit{
+				{Release: "contender", Pods: 5, Serving: 5, Expected: 5},
+				{Release: "incumbent", Pods: 5, Serving: 5, Expected: 5},
+			},
+			balanced: true,
+		},
+		{
+			name: "skewed towards the incumbent",
+			pods: concat(
+				buildPods(shippertesting.TestApp, "incumbent", 10, withTraffic),
+				buildPods(shippertesting.TestApp, "contender", 1, withTraffic),
+				buildPods(shippertesting.TestApp, "contender", 9, noTraffic),
+			),
+			weights: map[string]uint32{"incumbent": 50, "contender": 50},
+			expected: []ReleaseAudit{
+				{Release: "contender", Pods: 10, Serving: 1, Expected: 10},
+				{Release: "incumbent", Pods: 10, Serving: 10, Expected: 10},
+			},
+			balanced: false,
+		},
+		{
+			name:    "release with weight but no pods",
+			pods:    buildPods(shippertesting.TestApp, "incumbent", 4, withTraffic),
+			weights: map[string]uint32{"incumbent": 75, "contender": 25},
+			expected: []ReleaseAudit{
+				{Release: "contender", Pods: 0, Serving: 0, Expected: 0},
+				{Release: "incumbent", Pods: 4, Serving: 4, Expected: 3},
+			},
+			balanced: false,
+		},
+		{
+			name: "serving pods without weight",
+			pods: concat(
+				buildPods(shippertesting.TestApp, "incumbent", 2, withTraffic),
+				buildPods(shippertesting.TestApp, "historical", 2, withTraffic),
+			),
+			weights: map[string]uint32{"incumbent": 100},
+			expected: []ReleaseAudit{
+				{Release: "historical", Pods: 2, Serving: 2, Expected: 0},
+				{Release: "incumbent", Pods: 2, Serving: 2, Expected: 2},
+			},
+			balanced: false,
+		},
+	}
+
+	for _, tt := range tests {
+		report := AuditTrafficLabels(tt.pods, tt.weights, len(tt.pods))
+
+		if !reflect.DeepEqual(report.Releases, tt.expected) {
+			t.Errorf("%s: expected audit %+v, got %+v", tt.name, tt.expected, report.Releases)
+		}
+
+		if balanced := report.Balanced(); balanced != tt.balanced {
+			t.Errorf("%s: expected report to be balanced: %t, got %t", tt.name, tt.balanced, balanced)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package traffic
+
+import (
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// BlastRadius sums up how much of an application's traffic the release
+// behind tt is in for: the number of clusters it has any weight in, the
+// weight it has in all of them combined, and the fraction of the traffic of
+// its application across the fleet it gets. The fleet is every cluster with
+// any weight for the application, with each of them counting the same, and
+// the release's share in each of them worked out against the weights of
+// appTTs, the traffic targets of the other releases of the application.
+// Without them, the release gets all of the traffic wherever it has weight.
+func BlastRadius(tt *shipper.TrafficTarget, appTTs ...*shipper.TrafficTarget) (int, uint32, float64, error) {
+	releaseName := tt.Labels[shipper.ReleaseLabel]
+
+	tts := []*shipper.TrafficTarget{tt}
+	for _, appTT := range appTTs {
+		if appTT.Namespace == tt.Namespace && appTT.Name == tt.Name {
+			continue
+		}
+		tts = append(tts, appTT)
+	}
+
+	clusterReleaseWeights, err := buildClusterReleaseWeights(tts, noTrafficWeightFallback)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	var clusters, fleet int
+	var totalWeight uint32
+	var shares float64
+	for _, releaseWeights := range clusterReleaseWeights {
+		var clusterWeight uint32
+		for _, weight := range releaseWeights {
+			clusterWeight += weight
+		}
+
+		if clusterWeight == 0 {
+			continue
+		}
+		fleet++
+
+		weight := releaseWeights[releaseName]
+		if weight == 0 {
+			continue
+		}
+
+		clusters++
+		totalWeight += weight
+		shares += float64(weight) / float64(clusterWeight)
+	}
+
+	if fleet == 0 {
+		return 0, 0, 0, nil
+	}
+
+	return clusters, totalWeight, shares / float64(fleet), nil
+}
@@ -0,0 +1,76 @@
+package traffic
+
+import (
+	"math"
+	"testing"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+)
+
+func TestBlastRadius(t *testing.T) {
+	const clusterC = "cluster-c"
+
+	contender := buildTrafficTarget(shippertesting.TestApp, "contender", map[string]uint32{clusterA: 10})
+
+	tests := []struct {
+		name             string
+		tt               *shipper.TrafficTarget
+		appTTs           []*shipper.TrafficTarget
+		expectedClusters int
+		expectedWeight   uint32
+		expectedFraction float64
+	}{
+		{
+			name:             "single cluster on its own",
+			tt:               contender,
+			expectedClusters: 1,
+			expectedWeight:   10,
+			expectedFraction: 1,
+		},
+		{
+			name: "single cluster next to an incumbent",
+			tt:   contender,
+			appTTs: []*shipper.TrafficTarget{
+				contender,
+				buildTrafficTarget(shippertesting.TestApp, "incumbent", map[string]uint32{clusterA: 90}),
+			},
+			expectedClusters: 1,
+			expectedWeight:   10,
+			expectedFraction: 0.1,
+		},
+		{
+			name: "multiple clusters next to an incumbent",
+			tt: buildTrafficTarget(shippertesting.TestApp, "contender",
+				map[string]uint32{clusterA: 50, clusterB: 25, clusterC: 0}),
+			appTTs: []*shipper.TrafficTarget{
+				buildTrafficTarget(shippertesting.TestApp, "incumbent",
+					map[string]uint32{clusterA: 50, clusterB: 75, clusterC: 100}),
+			},
+			expectedClusters: 2,
+			expectedWeight:   75,
+			// (50% + 25% + 0%) / 3 clusters
+			expectedFraction: 0.25,
+		},
+		{
+			name: "no weight anywhere",
+			tt: buildTrafficTarget(shippertesting.TestApp, "contender",
+				map[string]uint32{clusterA: 0, clusterB: 0}),
+		},
+	}
+
+	for _, tt := range tests {
+		clusters, weight, fraction, err := BlastRadius(tt.tt, tt.appTTs...)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.name, err)
+			continue
+		}
+
+		if clusters != tt.expectedClusters || weight != tt.expectedWeight ||
+			math.Abs(fraction-tt.expectedFraction) > 1e-9 {
+			t.Errorf("%s: expected %d clusters, weight %d and %.2f of the fleet, got %d, %d and %.2f",
+				tt.name, tt.expectedClusters, tt.expectedWeight, tt.expectedFraction,
+				clusters, weight, fraction)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package traffic
+
+import (
+	"sort"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperclient "github.com/bookingcom/shipper/pkg/client/clientset/versioned"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+// ReleasesOnCluster returns the releases in namespace ns (or in every
+// namespace, if ns is empty) that have either traffic or capacity in
+// clusterName, sorted by namespace and name. These are the releases that
+// would be affected by removing that cluster, so operators can find out
+// what they're in for before deleting a Cluster object.
+func ReleasesOnCluster(
+	clusterName, ns string,
+	shipperClient shipperclient.Interface,
+) ([]*shipper.Release, error) {
+	ttList, err := shipperClient.ShipperV1alpha1().TrafficTargets(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, shippererrors.NewKubeclientListError(
+			shipper.SchemeGroupVersion.WithKind("TrafficTarget"),
+			ns, labels.Everything(), err)
+	}
+
+	ctList, err := shipperClient.ShipperV1alpha1().CapacityTargets(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, shippererrors.NewKubeclientListError(
+			shipper.SchemeGroupVersion.WithKind("CapacityTarget"),
+			ns, labels.Everything(), err)
+	}
+
+	// Release names are only unique within a namespace, so traffic
+	// weights are worked out for each namespace on its own.
+	ttsByNamespace := map[string][]*shipper.TrafficTarget{}
+	for i := range ttList.Items {
+		tt := &ttList.Items[i]
+		ttsByNamespace[tt.Namespace] = append(ttsByNamespace[tt.Namespace], tt)
+	}
+
+	present := map[string]map[string]struct{}{}
+	markPresent := func(namespace, release string) {
+		if _, ok := present[namespace]; !ok {
+			present[namespace] = map[string]struct{}{}
+		}
+		present[namespace][release] = struct{}{}
+	}
+
+	for namespace, tts := range ttsByNamespace {
+		clusterReleaseWeights, err := buildClusterReleaseWeights(tts, noTrafficWeightFallback)
+		if err != nil {
+			return nil, err
+		}
+
+		for release, weight := range clusterReleaseWeights[clusterName] {
+			if weight > 0 {
+				markPresent(namespace, release)
+			}
+		}
+	}
+
+	for i := range ctList.Items {
+		ct := &ctList.Items[i]
+		release, ok := ct.Labels[shipper.ReleaseLabel]
+		if !ok {
+			// There's no telling which release this belongs to,
+			// so there's no release to report either.
+			continue
+		}
+
+		for _, cluster := range ct.Spec.Clusters {
+			if cluster.Name == clusterName && cluster.Percent > 0 {
+				markPresent(ct.Namespace, release)
+			}
+		}
+	}
+
+	releases := []*shipper.Release{}
+	for namespace, names := range present {
+		for name := range names {
+			rel, err := shipperClient.ShipperV1alpha1().Releases(namespace).Get(name, metav1.GetOptions{})
+			if kerrors.IsNotFound(err) {
+				// Targets can outlive their release for a little
+				// while, until the garbage collector catches up.
+				continue
+			} else if err != nil {
+				return nil, shippererrors.NewKubeclientGetError(namespace, name, err).
+					WithShipperKind("Release")
+			}
+
+			releases = append(releases, rel)
+		}
+	}
+
+	sort.Slice(releases, func(i, j int) bool {
+		if releases[i].Namespace != releases[j].Namespace {
+			return releases[i].Namespace < releases[j].Namespace
+		}
+		return releases[i].Name < releases[j].Name
+	})
+
+	return releases, nil
+}
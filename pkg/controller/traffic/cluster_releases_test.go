@@ -0,0 +1,107 @@
+package traffic
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+)
+
+func TestReleasesOnCluster(t *testing.T) {
+	objects := []runtime.Object{
+		// Serving traffic in cluster A.
+		buildReleaseObject("foobar-a"),
+		buildTrafficTarget(shippertesting.TestApp, "foobar-a", map[string]uint32{clusterA: 50}),
+
+		// Only has capacity in cluster A, no traffic yet.
+		buildReleaseObject("foobar-b"),
+		buildTrafficTarget(shippertesting.TestApp, "foobar-b", map[string]uint32{clusterA: 0}),
+		buildCapacityTargetObject("foobar-b", map[string]int32{clusterA: 50}),
+
+		// Listed in cluster A, but with neither traffic nor capacity.
+		buildReleaseObject("foobar-c"),
+		buildTrafficTarget(shippertesting.TestApp, "foobar-c", map[string]uint32{clusterA: 0}),
+		buildCapacityTargetObject("foobar-c", map[string]int32{clusterA: 0}),
+
+		// Only present in cluster B.
+		buildReleaseObject("foobar-d"),
+		buildTrafficTarget(shippertesting.TestApp, "foobar-d", map[string]uint32{clusterB: 100}),
+		buildCapacityTargetObject("foobar-d", map[string]int32{clusterB: 100}),
+	}
+
+	client := shipperfake.NewSimpleClientset(objects...)
+
+	tests := []struct {
+		cluster  string
+		expected []string
+	}{
+		{cluster: clusterA, expected: []string{"foobar-a", "foobar-b"}},
+		{cluster: clusterB, expected: []string{"foobar-d"}},
+		{cluster: "cluster-c", expected: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.cluster, func(t *testing.T) {
+			releases, err := ReleasesOnCluster(test.cluster, shippertesting.TestNamespace, client)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			actual := make([]string, 0, len(releases))
+			for _, rel := range releases {
+				actual = append(actual, rel.Name)
+			}
+
+			if len(actual) != len(test.expected) {
+				t.Fatalf("expected releases %v, got %v", test.expected, actual)
+			}
+			for i := range actual {
+				if actual[i] != test.expected[i] {
+					t.Fatalf("expected releases %v, got %v", test.expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func buildReleaseObject(name string) *shipper.Release {
+	return &shipper.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: shippertesting.TestNamespace,
+			Labels: map[string]string{
+				shipper.AppLabel:     shippertesting.TestApp,
+				shipper.ReleaseLabel: name,
+			},
+		},
+	}
+}
+
+func buildCapacityTargetObject(release string, clusterPercents map[string]int32) *shipper.CapacityTarget {
+	clusters := make([]shipper.ClusterCapacityTarget, 0, len(clusterPercents))
+	for cluster, percent := range clusterPercents {
+		clusters = append(clusters, shipper.ClusterCapacityTarget{
+			Name:              cluster,
+			Percent:           percent,
+			TotalReplicaCount: 2,
+		})
+	}
+
+	return &shipper.CapacityTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      release,
+			Namespace: shippertesting.TestNamespace,
+			Labels: map[string]string{
+				shipper.AppLabel:     shippertesting.TestApp,
+				shipper.ReleaseLabel: release,
+			},
+		},
+		Spec: shipper.CapacityTargetSpec{
+			Clusters: clusters,
+		},
+	}
+}
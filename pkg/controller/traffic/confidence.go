@@ -0,0 +1,70 @@
+package traffic
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+type releasePodCounts struct {
+	total   int
+	enabled int
+}
+
+// achievedWeightConfidence tells, for every release with pods in either
+// before or after, whether the weight it achieved can be trusted. Achieved
+// weights are worked out from a single snapshot of the informer cache, so
+// they're only trusted if the cache had fully synced and the release's
+// pods, and how many of them are labeled to receive traffic, didn't change
+// between two reads of it.
+func achievedWeightConfidence(synced bool, before, after []*corev1.Pod) map[string]bool {
+	countsBefore := countPodsByRelease(before)
+	countsAfter := countPodsByRelease(after)
+
+	confidence := make(map[string]bool, len(countsBefore))
+	for release, counts := range countsBefore {
+		confidence[release] = synced && counts == countsAfter[release]
+	}
+	for release := range countsAfter {
+		if _, ok := countsBefore[release]; !ok {
+			confidence[release] = false
+		}
+	}
+
+	return confidence
+}
+
+func countPodsByRelease(pods []*corev1.Pod) map[string]releasePodCounts {
+	counts := make(map[string]releasePodCounts)
+	for _, pod := range pods {
+		release := pod.Labels[shipper.ReleaseLabel]
+		c := counts[release]
+		c.total++
+		if pod.Labels[shipper.PodTrafficStatusLabel] == shipper.Enabled {
+			c.enabled++
+		}
+		counts[release] = c
+	}
+
+	return counts
+}
+
+// listAppPods lists every pod of an application straight from the cache of
+// the pod informer of its cluster, along with whether it had synced at the time. Reads
+// from before and after working out achieved weights are compared to tell
+// whether the cache was stable in between.
+func listAppPods(podInformer PodInformer, ns, appName string) ([]*corev1.Pod, bool, error) {
+	synced := podInformer.Informer().HasSynced()
+
+	appSelector := labels.Set{shipper.AppLabel: appName}.AsSelector()
+	appPods, err := podInformer.Lister().Pods(ns).List(appSelector)
+	if err != nil {
+		return nil, false, shippererrors.NewKubeclientListError(
+			corev1.SchemeGroupVersion.WithKind("Pod"),
+			ns, appSelector, err)
+	}
+
+	return appPods, synced, nil
+}
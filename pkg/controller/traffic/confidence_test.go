@@ -0,0 +1,75 @@
+package traffic
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+)
+
+func TestAchievedWeightConfidence(t *testing.T) {
+	contenderPods := buildPods(shippertesting.TestApp, "contender", 2, true)
+	incumbentPods := buildPods(shippertesting.TestApp, "incumbent", 2, true)
+	stable := append(append([]*corev1.Pod{}, contenderPods...), incumbentPods...)
+
+	disabledIncumbentPod := incumbentPods[1].DeepCopy()
+	disabledIncumbentPod.Labels[shipper.PodTrafficStatusLabel] = shipper.Disabled
+	relabeled := []*corev1.Pod{contenderPods[0], contenderPods[1], incumbentPods[0], disabledIncumbentPod}
+
+	newcomerPods := buildPods(shippertesting.TestApp, "newcomer", 1, false)
+	withNewcomer := append(append([]*corev1.Pod{}, stable...), newcomerPods...)
+
+	tests := []struct {
+		name     string
+		synced   bool
+		before   []*corev1.Pod
+		after    []*corev1.Pod
+		expected map[string]bool
+	}{
+		{
+			name:     "synced and stable",
+			synced:   true,
+			before:   stable,
+			after:    stable,
+			expected: map[string]bool{"contender": true, "incumbent": true},
+		},
+		{
+			name:     "not synced",
+			synced:   false,
+			before:   stable,
+			after:    stable,
+			expected: map[string]bool{"contender": false, "incumbent": false},
+		},
+		{
+			name:     "pod lost its traffic in between",
+			synced:   true,
+			before:   stable,
+			after:    relabeled,
+			expected: map[string]bool{"contender": true, "incumbent": false},
+		},
+		{
+			name:     "pod deleted in between",
+			synced:   true,
+			before:   stable,
+			after:    stable[1:],
+			expected: map[string]bool{"contender": false, "incumbent": true},
+		},
+		{
+			name:     "release showed up in between",
+			synced:   true,
+			before:   stable,
+			after:    withNewcomer,
+			expected: map[string]bool{"contender": true, "incumbent": true, "newcomer": false},
+		},
+	}
+
+	for _, tt := range tests {
+		confidence := achievedWeightConfidence(tt.synced, tt.before, tt.after)
+		if !reflect.DeepEqual(confidence, tt.expected) {
+			t.Errorf("%s: expected confidence %v, got %v", tt.name, tt.expected, confidence)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package traffic
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DecisionLogEntry describes a single traffic shifting decision taken by the
+// traffic controller: how many pods of a release were labeled to receive
+// traffic in a cluster, and how many it decided should be.
+type DecisionLogEntry struct {
+	Seq           uint64    `json:"seq"`
+	Time          time.Time `json:"time"`
+	TrafficTarget string    `json:"trafficTarget"`
+	Cluster       string    `json:"cluster"`
+	Release       string    `json:"release"`
+	FromPods      int       `json:"fromPods"`
+	ToPods        int       `json:"toPods"`
+	Reason        string    `json:"reason"`
+}
+
+// DecisionLog is a sink for traffic shifting decisions, so they can be
+// inspected and replayed after the fact.
+type DecisionLog interface {
+	Record(entry DecisionLogEntry)
+}
+
+// NoopDecisionLog is a DecisionLog that throws everything away.
+type NoopDecisionLog struct{}
+
+var _ DecisionLog = NoopDecisionLog{}
+
+func (NoopDecisionLog) Record(DecisionLogEntry) {}
+
+// RingBufferDecisionLog is a DecisionLog that keeps the last few entries in
+// memory, in the order they were recorded. It can be exposed as an HTTP
+// endpoint that returns those entries as JSON.
+type RingBufferDecisionLog struct {
+	mu      sync.Mutex
+	entries []DecisionLogEntry
+	next    int
+	full    bool
+	seq     uint64
+}
+
+var _ DecisionLog = (*RingBufferDecisionLog)(nil)
+var _ http.Handler = (*RingBufferDecisionLog)(nil)
+
+func NewRingBufferDecisionLog(size int) *RingBufferDecisionLog {
+	return &RingBufferDecisionLog{
+		entries: make([]DecisionLogEntry, size),
+	}
+}
+
+// Record stores entry in the log, overwriting the oldest one if the log is
+// full. Entries get a sequence number reflecting the order they were recorded
+// in.
+func (l *RingBufferDecisionLog) Record(entry DecisionLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.entries) == 0 {
+		return
+	}
+
+	l.seq++
+	entry.Seq = l.seq
+
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Entries returns the entries currently in the log, oldest first.
+func (l *RingBufferDecisionLog) Entries() []DecisionLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.full {
+		return append([]DecisionLogEntry(nil), l.entries[:l.next]...)
+	}
+
+	entries := make([]DecisionLogEntry, 0, len(l.entries))
+	entries = append(entries, l.entries[l.next:]...)
+	entries = append(entries, l.entries[:l.next]...)
+
+	return entries
+}
+
+func (l *RingBufferDecisionLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(l.Entries()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
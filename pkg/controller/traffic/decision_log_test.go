@@ -0,0 +1,41 @@
+package traffic
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRingBufferDecisionLogKeepsLatestEntriesInOrder(t *testing.T) {
+	log := NewRingBufferDecisionLog(3)
+
+	for i := 0; i < 5; i++ {
+		log.Record(DecisionLogEntry{Release: fmt.Sprintf("release-%d", i)})
+	}
+
+	entries := log.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+
+	for i, entry := range entries {
+		expectedRelease := fmt.Sprintf("release-%d", i+2)
+		if entry.Release != expectedRelease {
+			t.Errorf("expected entry %d to be for %q, got %q", i, expectedRelease, entry.Release)
+		}
+
+		expectedSeq := uint64(i + 3)
+		if entry.Seq != expectedSeq {
+			t.Errorf("expected entry %d to have seq %d, got %d", i, expectedSeq, entry.Seq)
+		}
+	}
+}
+
+func TestRingBufferDecisionLogNotFull(t *testing.T) {
+	log := NewRingBufferDecisionLog(3)
+	log.Record(DecisionLogEntry{Release: "release-0"})
+
+	entries := log.Entries()
+	if len(entries) != 1 || entries[0].Release != "release-0" {
+		t.Errorf("expected a single entry for %q, got %v", "release-0", entries)
+	}
+}
@@ -0,0 +1,135 @@
+package traffic
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+	targetutil "github.com/bookingcom/shipper/pkg/util/target"
+	trafficutil "github.com/bookingcom/shipper/pkg/util/traffic"
+)
+
+const (
+	TrafficDivergence = "TrafficDivergence"
+)
+
+// divergenceTracker keeps track of how long traffic targets have been
+// achieving a different weight than the one they request. Diverging for a
+// while is what shifting traffic looks like, but diverging for longer than
+// a threshold usually means the release will never get there, as when it
+// doesn't have enough pods.
+type divergenceTracker struct {
+	threshold time.Duration
+	now       func() time.Time
+
+	mu    sync.Mutex
+	since map[string]time.Time
+}
+
+func newDivergenceTracker(threshold time.Duration) *divergenceTracker {
+	return &divergenceTracker{
+		threshold: threshold,
+		now:       time.Now,
+		since:     make(map[string]time.Time),
+	}
+}
+
+// Observe records whether the traffic target identified by ttKey is
+// diverging from its requested weight, and returns whether it has been
+// doing so for longer than the threshold and, if it hasn't yet, how long
+// until it will have. The timer is reset as soon as the traffic target
+// converges. A zero threshold disables the tracker altogether.
+func (t *divergenceTracker) Observe(ttKey string, diverging bool) (bool, time.Duration) {
+	if t.threshold <= 0 {
+		return false, 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !diverging {
+		delete(t.since, ttKey)
+		return false, 0
+	}
+
+	now := t.now()
+	since, ok := t.since[ttKey]
+	if !ok {
+		since = now
+		t.since[ttKey] = since
+	}
+
+	if remaining := t.threshold - now.Sub(since); remaining > 0 {
+		return false, remaining
+	}
+
+	return true, 0
+}
+
+func (t *divergenceTracker) Forget(ttKey string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.since, ttKey)
+}
+
+// weightDivergence returns the weight tt requests and the weight it
+// achieved across all of its clusters.
+func weightDivergence(tt *shipper.TrafficTarget) (uint32, uint32) {
+	var achieved, requested uint32
+	for _, spec := range tt.Spec.Clusters {
+		requested += spec.Weight
+	}
+	for _, status := range tt.Status.Clusters {
+		achieved += status.AchievedTraffic
+	}
+
+	return achieved, requested
+}
+
+// checkDivergence sets the TrafficDivergence condition on tt when the
+// weight it achieved has been diverging from the one it requests for
+// longer than the configured threshold, and emits a warning when it first
+// does. The condition is cleared as soon as tt converges. A diverging tt is
+// synced again once the threshold is crossed, as nothing else might
+// change about it by then.
+func (c *Controller) checkDivergence(ttKey string, tt *shipper.TrafficTarget, diff *diffutil.MultiDiff) {
+	achieved, requested := weightDivergence(tt)
+	if diverged, remaining := c.divergence.Observe(ttKey, achieved != requested); !diverged {
+		if remaining > 0 {
+			c.workqueue.AddAfter(ttKey, remaining)
+		}
+
+		cond := trafficutil.GetTrafficTargetCondition(tt.Status, shipper.TrafficTargetConditionTypeTrafficDivergence)
+		if cond != nil && cond.Status == corev1.ConditionTrue {
+			condition := targetutil.NewTargetCondition(
+				shipper.TrafficTargetConditionTypeTrafficDivergence,
+				corev1.ConditionFalse,
+				"",
+				"",
+			)
+			diff.Append(trafficutil.SetTrafficTargetCondition(&tt.Status, condition))
+		}
+
+		return
+	}
+
+	msg := fmt.Sprintf("achieved weight %d has been diverging from requested weight %d for longer than %s",
+		achieved, requested, c.divergence.threshold)
+	condition := targetutil.NewTargetCondition(
+		shipper.TrafficTargetConditionTypeTrafficDivergence,
+		corev1.ConditionTrue,
+		TrafficDivergence,
+		msg,
+	)
+
+	d := trafficutil.SetTrafficTargetCondition(&tt.Status, condition)
+	if !d.IsEmpty() {
+		c.recorder.Event(tt, corev1.EventTypeWarning, TrafficDivergence, msg)
+	}
+	diff.Append(d)
+}
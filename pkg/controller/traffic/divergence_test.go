@@ -0,0 +1,120 @@
+package traffic
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
+	trafficutil "github.com/bookingcom/shipper/pkg/util/traffic"
+)
+
+func TestDivergenceTrackerDisabled(t *testing.T) {
+	const ttKey = "test-namespace/foobar"
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := newDivergenceTracker(0)
+	tracker.now = func() time.Time { return now }
+
+	tracker.Observe(ttKey, true)
+	now = now.Add(24 * time.Hour)
+	if diverged, remaining := tracker.Observe(ttKey, true); diverged || remaining != 0 {
+		t.Fatalf("expected a zero threshold to never report traffic targets as diverging")
+	}
+}
+
+// TestPersistentDivergenceCrossesThreshold keeps a traffic target short of
+// its requested weight across syncs, and checks it's only marked as
+// diverging once it's been so for longer than the threshold, and that the
+// condition is cleared as soon as it converges.
+func TestPersistentDivergenceCrossesThreshold(t *testing.T) {
+	tt := buildTrafficTarget(shippertesting.TestApp, ttName, map[string]uint32{clusterA: 50})
+	tt.Status.Clusters = []*shipper.ClusterTrafficStatus{
+		{Name: clusterA, AchievedTraffic: 20},
+	}
+	ttKey := tt.Namespace + "/" + tt.Name
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	recorder := record.NewFakeRecorder(42)
+	queue := &delayRecordingQueue{
+		RateLimitingInterface: workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		delays:                make(map[interface{}]time.Duration),
+	}
+	defer queue.ShutDown()
+
+	c := &Controller{
+		recorder:   recorder,
+		workqueue:  queue,
+		divergence: newDivergenceTracker(10 * time.Minute),
+	}
+	c.divergence.now = func() time.Time { return now }
+
+	divergenceStatus := func() corev1.ConditionStatus {
+		cond := trafficutil.GetTrafficTargetCondition(tt.Status, shipper.TrafficTargetConditionTypeTrafficDivergence)
+		if cond == nil {
+			return corev1.ConditionUnknown
+		}
+		return cond.Status
+	}
+
+	for _, elapsed := range []time.Duration{0, 5 * time.Minute, 9 * time.Minute} {
+		now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).Add(elapsed)
+		c.checkDivergence(ttKey, tt, diffutil.NewMultiDiff())
+		if status := divergenceStatus(); status != corev1.ConditionUnknown {
+			t.Fatalf("expected no divergence after %s, got condition %s", elapsed, status)
+		}
+
+		// The traffic target has to be synced again by the time
+		// it crosses the threshold.
+		if delay := queue.delays[ttKey]; delay != 10*time.Minute-elapsed {
+			t.Errorf("expected traffic target to be requeued in %s after %s, got %s", 10*time.Minute-elapsed, elapsed, delay)
+		}
+	}
+
+	now = now.Add(time.Minute)
+	c.checkDivergence(ttKey, tt, diffutil.NewMultiDiff())
+	if status := divergenceStatus(); status != corev1.ConditionTrue {
+		t.Fatalf("expected divergence once over the threshold, got condition %s", status)
+	}
+
+	select {
+	case event := <-recorder.Events:
+		expected := "Warning TrafficDivergence achieved weight 20 has been diverging from requested weight 50 for longer than 10m0s"
+		if event != expected {
+			t.Errorf("expected event %q, got %q", expected, event)
+		}
+	default:
+		t.Errorf("expected a warning about the divergence")
+	}
+
+	tt.Status.Clusters[0].AchievedTraffic = 50
+	now = now.Add(time.Minute)
+	c.checkDivergence(ttKey, tt, diffutil.NewMultiDiff())
+	if status := divergenceStatus(); status != corev1.ConditionFalse {
+		t.Fatalf("expected divergence to be cleared on convergence, got condition %s", status)
+	}
+
+	// Diverging again starts the timer over.
+	tt.Status.Clusters[0].AchievedTraffic = 20
+	now = now.Add(time.Minute)
+	c.checkDivergence(ttKey, tt, diffutil.NewMultiDiff())
+	if status := divergenceStatus(); status != corev1.ConditionFalse {
+		t.Fatalf("expected the timer to be reset after converging, got condition %s", status)
+	}
+}
+
+// delayRecordingQueue is a work queue that remembers the last delay every
+// item was added with.
+type delayRecordingQueue struct {
+	workqueue.RateLimitingInterface
+	delays map[interface{}]time.Duration
+}
+
+func (q *delayRecordingQueue) AddAfter(item interface{}, duration time.Duration) {
+	q.delays[item] = duration
+}
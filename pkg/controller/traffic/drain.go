@@ -0,0 +1,149 @@
+package traffic
+
+import (
+	"sync"
+	"time"
+)
+
+// drainedWeightKey is where clusterDrainer keeps the weight it took away
+// from releases in a cluster's weights. No pods ever belong to it, so it
+// just holds on to its share of the cluster's total weight, and the pods of
+// actual releases are labeled for the traffic they have left.
+const drainedWeightKey = ""
+
+// DrainRequeueInterval is how long the traffic target of a release that
+// still has weight left in a cluster being drained waits before it's
+// synced again and taken another step closer to zero.
+const DrainRequeueInterval = 10 * time.Second
+
+// clusterDrainer ramps the traffic weights of all releases in clusters that
+// are being drained down to zero, taking at most maxShiftPerSync away from a
+// release every time its traffic target is synced.
+type clusterDrainer struct {
+	maxShiftPerSync uint32
+
+	mu sync.Mutex
+	// weights holds, for every cluster being drained, the weight each
+	// release was last handed out, keyed by namespace/release.
+	weights map[string]map[string]uint32
+}
+
+func newClusterDrainer(maxShiftPerSync uint32) *clusterDrainer {
+	return &clusterDrainer{
+		maxShiftPerSync: maxShiftPerSync,
+		weights:         make(map[string]map[string]uint32),
+	}
+}
+
+// Drain starts draining clusterName. Calling it for a cluster that's
+// already being drained doesn't restart its drain.
+func (d *clusterDrainer) Drain(clusterName string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.weights[clusterName]; !ok {
+		d.weights[clusterName] = make(map[string]uint32)
+	}
+}
+
+// Stop gives clusterName back its weights in full.
+func (d *clusterDrainer) Stop(clusterName string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.weights, clusterName)
+}
+
+// Draining returns whether clusterName is being drained.
+func (d *clusterDrainer) Draining(clusterName string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, ok := d.weights[clusterName]
+	return ok
+}
+
+// Drained returns whether every release that was handed out weight in
+// clusterName since it started being drained is down to zero. Clusters no
+// release has been synced in yet have nothing to drain.
+func (d *clusterDrainer) Drained(clusterName string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	weights, ok := d.weights[clusterName]
+	if !ok {
+		return false
+	}
+
+	for _, weight := range weights {
+		if weight > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Apply lowers the weights of releases in namespace in all the clusters
+// being drained. Only releaseName, the release whose traffic target is
+// being synced, is taken another step closer to zero: the others keep the
+// weight they were last handed out, so releases drain at the pace of their
+// own syncs. As weights are relative to each other, whatever is taken away
+// is kept under drainedWeightKey, so the total weight in the cluster stays
+// the same and releases really lose pods. It returns whether releaseName
+// still has weight left in any of the clusters being drained, and so needs
+// to be synced again for its drain to carry on.
+func (d *clusterDrainer) Apply(namespace, releaseName string, weights clusterReleaseWeights) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	pending := false
+
+	for clusterName, drained := range d.weights {
+		releaseWeights, ok := weights[clusterName]
+		if !ok {
+			continue
+		}
+
+		var taken uint32
+		for release, weight := range releaseWeights {
+			key := namespace + "/" + release
+
+			last, ok := drained[key]
+			if !ok || last > weight {
+				last = weight
+			}
+
+			if release == releaseName {
+				if d.maxShiftPerSync == 0 || last < d.maxShiftPerSync {
+					last = 0
+				} else {
+					last -= d.maxShiftPerSync
+				}
+				pending = pending || last > 0
+			}
+
+			drained[key] = last
+			releaseWeights[release] = last
+			taken += weight - last
+		}
+
+		if taken > 0 {
+			releaseWeights[drainedWeightKey] = taken
+		}
+	}
+
+	return pending
+}
+
+// Forget drops the weights handed out to the release identified by key, as
+// in namespace/release, in every cluster being drained, as when its traffic
+// target is deleted. Releases that are gone don't hold up Drained.
+func (d *clusterDrainer) Forget(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, drained := range d.weights {
+		delete(drained, key)
+	}
+}
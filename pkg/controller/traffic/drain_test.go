@@ -0,0 +1,145 @@
+package traffic
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	shippercontroller "github.com/bookingcom/shipper/pkg/controller"
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+)
+
+// TestClusterDrainerRampsDown syncs the traffic targets of two releases in
+// turn while one of their clusters is drained, and checks their weights go
+// down by at most maxShiftPerSync every time, leaving the other cluster
+// alone.
+func TestClusterDrainerRampsDown(t *testing.T) {
+	const namespace = "test-namespace"
+
+	buildWeights := func() clusterReleaseWeights {
+		return clusterReleaseWeights{
+			clusterA: {"incumbent": 60, "contender": 40},
+			clusterB: {"incumbent": 60, "contender": 40},
+		}
+	}
+
+	drainer := newClusterDrainer(25)
+	drainer.Drain(clusterA)
+
+	syncs := []struct {
+		release  string
+		expected map[string]uint32
+		pending  bool
+	}{
+		{"incumbent", map[string]uint32{"incumbent": 35, "contender": 40, drainedWeightKey: 25}, true},
+		{"contender", map[string]uint32{"incumbent": 35, "contender": 15, drainedWeightKey: 50}, true},
+		{"incumbent", map[string]uint32{"incumbent": 10, "contender": 15, drainedWeightKey: 75}, true},
+		{"contender", map[string]uint32{"incumbent": 10, "contender": 0, drainedWeightKey: 90}, false},
+		{"incumbent", map[string]uint32{"incumbent": 0, "contender": 0, drainedWeightKey: 100}, false},
+	}
+
+	for i, sync := range syncs {
+		weights := buildWeights()
+		if pending := drainer.Apply(namespace, sync.release, weights); pending != sync.pending {
+			t.Errorf("sync %d of %s: expected release to have weight left to drain: %t, got %t",
+				i, sync.release, sync.pending, pending)
+		}
+
+		if !reflect.DeepEqual(weights[clusterA], sync.expected) {
+			t.Errorf("sync %d of %s: expected weights %v in drained cluster, got %v",
+				i, sync.release, sync.expected, weights[clusterA])
+		}
+
+		if expected := buildWeights()[clusterB]; !reflect.DeepEqual(weights[clusterB], expected) {
+			t.Errorf("sync %d of %s: expected weights %v in other cluster, got %v",
+				i, sync.release, expected, weights[clusterB])
+		}
+
+		if drained := drainer.Drained(clusterA); drained != (i == len(syncs)-1) {
+			t.Errorf("sync %d of %s: expected cluster to be drained: %t, got %t",
+				i, sync.release, i == len(syncs)-1, drained)
+		}
+	}
+
+	drainer.Stop(clusterA)
+	weights := buildWeights()
+	drainer.Apply(namespace, "incumbent", weights)
+	if expected := buildWeights(); !reflect.DeepEqual(weights, expected) {
+		t.Errorf("expected weights %v once the cluster is no longer drained, got %v", expected, weights)
+	}
+}
+
+// TestClusterDrainerForget checks releases whose traffic targets are gone
+// don't keep a cluster from being drained.
+func TestClusterDrainerForget(t *testing.T) {
+	const namespace = "test-namespace"
+
+	drainer := newClusterDrainer(25)
+	drainer.Drain(clusterA)
+
+	drainer.Apply(namespace, "contender", clusterReleaseWeights{
+		clusterA: {"incumbent": 60, "contender": 20},
+	})
+
+	if drainer.Drained(clusterA) {
+		t.Fatalf("expected cluster not to be drained while the incumbent has weight in it")
+	}
+
+	drainer.Forget(namespace + "/incumbent")
+
+	if !drainer.Drained(clusterA) {
+		t.Errorf("expected cluster to be drained once the incumbent is forgotten")
+	}
+}
+
+// TestDrainClusterTakesPodsOutOfTraffic syncs a traffic target in a cluster
+// being drained, and checks its pods are taken out of traffic according to
+// the weight it has left rather than all at once, and that it's synced
+// again later on to keep draining.
+func TestDrainClusterTakesPodsOutOfTraffic(t *testing.T) {
+	podCount := 10
+	tt := buildTrafficTarget(shippertesting.TestApp, ttName, map[string]uint32{clusterA: 100})
+
+	f := shippertesting.NewControllerTestFixture()
+	cluster := addCluster(f, clusterA)
+	cluster.AddMany(buildWorldWithPods(shippertesting.TestApp, ttName, podCount, withTraffic))
+	f.ShipperClient.Tracker().Add(tt)
+
+	controller := NewController(
+		f.ShipperClient,
+		f.ShipperInformerFactory,
+		f.ClusterClientStore,
+		f.Recorder,
+		Config{
+			MaxShiftPerSync: 30,
+		},
+	)
+
+	queue := &delayRecordingQueue{
+		RateLimitingInterface: controller.workqueue,
+		delays:                make(map[interface{}]time.Duration),
+	}
+	controller.workqueue = queue
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.Run(stopCh)
+
+	controller.DrainCluster(clusterA)
+
+	if _, err := controller.processTrafficTarget(context.Background(), tt.DeepCopy()); err != nil {
+		t.Fatalf("unexpected error processing traffic target: %s", err)
+	}
+
+	assertPodTraffic(t, tt, f.Clusters[clusterA], podStatus{withTraffic: 7, withoutTraffic: 3})
+
+	if controller.ClusterDrained(clusterA) {
+		t.Errorf("expected cluster to still be draining after a single sync")
+	}
+
+	if delay, ok := queue.delays[shippercontroller.MetaKey(tt)]; !ok || delay != DrainRequeueInterval {
+		t.Errorf("expected traffic target to be synced again in %s, got %s", DrainRequeueInterval, delay)
+	}
+}
@@ -0,0 +1,226 @@
+package traffic
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	trafficutil "github.com/bookingcom/shipper/pkg/util/traffic"
+)
+
+// labelConflictInterval is how long after patching a pod's
+// shipper.PodTrafficStatusLabel we consider it suspicious for the label to
+// change back to something else.
+const labelConflictInterval = 30 * time.Second
+
+const (
+	// labelFlapWindow and labelFlapThreshold are how many times a pod can
+	// have its traffic label flipped back over how long before we stop
+	// patching it. Once the window is over, we try again.
+	labelFlapWindow    = 5 * time.Minute
+	labelFlapThreshold = 3
+)
+
+type patchedLabel struct {
+	value string
+
+	// beforeVersion and afterVersion are the resource versions of the
+	// pod right before and right after we patched it. Seeing either of
+	// them means our informer simply hasn't caught up with the patch
+	// yet, and not that someone else changed the pod.
+	beforeVersion string
+	afterVersion  string
+
+	at time.Time
+
+	// flippedBack is whether the label has already been counted as
+	// flipped back, so a single flip isn't counted once per sync.
+	flippedBack bool
+}
+
+// labelFlips counts how many times a pod had its traffic label flipped
+// back since a point in time.
+type labelFlips struct {
+	count int
+	since time.Time
+}
+
+// labelConflictDetector keeps track of the traffic labels we recently set
+// on pods, so we can tell when another controller keeps flipping them back.
+// Both of us would keep fighting over the label forever, and traffic would
+// never settle.
+type labelConflictDetector struct {
+	interval time.Duration
+	now      func() time.Time
+
+	flapWindow    time.Duration
+	flapThreshold int
+
+	mu      sync.Mutex
+	patched map[string]patchedLabel
+	flips   map[string]labelFlips
+}
+
+func newLabelConflictDetector(interval time.Duration) *labelConflictDetector {
+	return &labelConflictDetector{
+		interval:      interval,
+		now:           time.Now,
+		flapWindow:    labelFlapWindow,
+		flapThreshold: labelFlapThreshold,
+		patched:       make(map[string]patchedLabel),
+		flips:         make(map[string]labelFlips),
+	}
+}
+
+func labelConflictKey(cluster string, pod *corev1.Pod) string {
+	return fmt.Sprintf("%s/%s/%s", cluster, pod.Namespace, pod.Name)
+}
+
+// Record remembers the labels we just set on pods in cluster.
+func (d *labelConflictDetector) Record(cluster string, shifted []shiftedPod) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for key, patched := range d.patched {
+		if now.Sub(patched.at) >= d.interval {
+			delete(d.patched, key)
+		}
+	}
+	for key, flips := range d.flips {
+		if now.Sub(flips.since) >= d.flapWindow {
+			delete(d.flips, key)
+		}
+	}
+
+	for _, s := range shifted {
+		d.patched[labelConflictKey(cluster, s.before)] = patchedLabel{
+			value:         s.after.Labels[shipper.PodTrafficStatusLabel],
+			beforeVersion: s.before.ResourceVersion,
+			afterVersion:  s.after.ResourceVersion,
+			at:            now,
+		}
+	}
+}
+
+// FlippedBack returns the pods in cluster that we set a traffic label on
+// less than an interval ago, and that have been changed to carry a
+// different one since then. Every time a label we set is flipped back
+// counts towards the pod flapping.
+func (d *labelConflictDetector) FlippedBack(cluster string, pods []*corev1.Pod) []*corev1.Pod {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	var flipped []*corev1.Pod
+	for _, pod := range pods {
+		key := labelConflictKey(cluster, pod)
+		patched, ok := d.patched[key]
+		if !ok || now.Sub(patched.at) >= d.interval {
+			continue
+		}
+
+		if pod.ResourceVersion == patched.beforeVersion ||
+			pod.ResourceVersion == patched.afterVersion {
+			continue
+		}
+
+		if pod.Labels[shipper.PodTrafficStatusLabel] != patched.value {
+			flipped = append(flipped, pod)
+
+			if !patched.flippedBack {
+				patched.flippedBack = true
+				d.patched[key] = patched
+
+				flips := d.flips[key]
+				if flips.count == 0 || now.Sub(flips.since) >= d.flapWindow {
+					flips = labelFlips{since: now}
+				}
+				flips.count++
+				d.flips[key] = flips
+			}
+		}
+	}
+
+	return flipped
+}
+
+// Flapping returns the pods in cluster that had their traffic label
+// flipped back at least as many times as the flap threshold within the
+// flap window. Patching them again would only start another round of the
+// same fight.
+func (d *labelConflictDetector) Flapping(cluster string, pods []*corev1.Pod) []*corev1.Pod {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	var flapping []*corev1.Pod
+	for _, pod := range pods {
+		flips, ok := d.flips[labelConflictKey(cluster, pod)]
+		if !ok || now.Sub(flips.since) >= d.flapWindow {
+			continue
+		}
+
+		if flips.count >= d.flapThreshold {
+			flapping = append(flapping, pod)
+		}
+	}
+
+	return flapping
+}
+
+// allPods flattens podsToShift into a single list of pods.
+func allPods(podsToShift map[string][]*corev1.Pod) []*corev1.Pod {
+	var pods []*corev1.Pod
+	for _, p := range podsToShift {
+		pods = append(pods, p...)
+	}
+
+	return pods
+}
+
+// withoutPods returns podsToShift without any of the pods in excluded.
+func withoutPods(podsToShift map[string][]*corev1.Pod, excluded []*corev1.Pod) map[string][]*corev1.Pod {
+	if len(excluded) == 0 {
+		return podsToShift
+	}
+
+	skip := make(map[string]struct{}, len(excluded))
+	for _, pod := range excluded {
+		skip[pod.Namespace+"/"+pod.Name] = struct{}{}
+	}
+
+	filtered := make(map[string][]*corev1.Pod, len(podsToShift))
+	for value, pods := range podsToShift {
+		for _, pod := range pods {
+			if _, ok := skip[pod.Namespace+"/"+pod.Name]; !ok {
+				filtered[value] = append(filtered[value], pod)
+			}
+		}
+	}
+
+	return filtered
+}
+
+// trafficLabelFlappingCondition returns the Ready condition for a cluster
+// where we gave up on patching the traffic label of some pods for a while.
+func trafficLabelFlappingCondition(flapping []*corev1.Pod) *shipper.ClusterTrafficCondition {
+	names := make([]string, 0, len(flapping))
+	for _, pod := range flapping {
+		names = append(names, pod.Name)
+	}
+	sort.Strings(names)
+
+	return trafficutil.NewClusterTrafficCondition(
+		shipper.ClusterConditionTypeReady,
+		corev1.ConditionFalse,
+		TrafficLabelFlapping,
+		fmt.Sprintf("stopped patching the %q label of pods %s for a while, as something keeps changing it back",
+			shipper.PodTrafficStatusLabel, strings.Join(names, ", ")),
+	)
+}
@@ -0,0 +1,112 @@
+package traffic
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestLabelConflictDetectorSpotsExternalFlipBack(t *testing.T) {
+	lbl := shipper.PodTrafficStatusLabel
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := newLabelConflictDetector(time.Minute)
+	d.now = func() time.Time { return now }
+
+	withVersion := func(p *corev1.Pod, version string) *corev1.Pod {
+		p.ResourceVersion = version
+		return p
+	}
+
+	before := withVersion(pod("foobar", map[string]string{lbl: shipper.Disabled}), "1")
+	after := withVersion(pod("foobar", map[string]string{lbl: shipper.Enabled}), "2")
+	d.Record(clusterA, []shiftedPod{{before: before, after: after}})
+
+	// Our informer hasn't caught up with our own patch yet.
+	if flipped := d.FlippedBack(clusterA, []*corev1.Pod{before}); len(flipped) != 0 {
+		t.Errorf("expected a stale pod not to be reported, got %d pods", len(flipped))
+	}
+
+	// Our informer has caught up with our own patch.
+	if flipped := d.FlippedBack(clusterA, []*corev1.Pod{after}); len(flipped) != 0 {
+		t.Errorf("expected a pod with our own label not to be reported, got %d pods", len(flipped))
+	}
+
+	// Something else has flipped the label back.
+	flippedBack := withVersion(pod("foobar", map[string]string{lbl: shipper.Disabled}), "3")
+	if flipped := d.FlippedBack(clusterA, []*corev1.Pod{flippedBack}); len(flipped) != 1 {
+		t.Errorf("expected a pod flipped back by someone else to be reported, got %d pods", len(flipped))
+	}
+
+	// ... but the same pod in a different cluster is not ours.
+	if flipped := d.FlippedBack(clusterB, []*corev1.Pod{flippedBack}); len(flipped) != 0 {
+		t.Errorf("expected a pod in another cluster not to be reported, got %d pods", len(flipped))
+	}
+
+	now = now.Add(time.Minute)
+	if flipped := d.FlippedBack(clusterA, []*corev1.Pod{flippedBack}); len(flipped) != 0 {
+		t.Errorf("expected a label change after the interval not to be reported, got %d pods", len(flipped))
+	}
+}
+
+func TestLabelConflictDetectorBreaksFlappingLoop(t *testing.T) {
+	lbl := shipper.PodTrafficStatusLabel
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := newLabelConflictDetector(time.Minute)
+	d.now = func() time.Time { return now }
+
+	version := 0
+	podWithLabel := func(value string) *corev1.Pod {
+		version++
+		p := pod("foobar", map[string]string{lbl: value})
+		p.ResourceVersion = fmt.Sprintf("%d", version)
+		return p
+	}
+
+	// Every round, we enable traffic on the pod, and something else
+	// disables it again right away.
+	for i := 1; i <= labelFlapThreshold; i++ {
+		if flapping := d.Flapping(clusterA, []*corev1.Pod{podWithLabel(shipper.Disabled)}); len(flapping) != 0 {
+			t.Fatalf("round %d: expected pod not to be flapping yet, got %d pods", i, len(flapping))
+		}
+
+		before := podWithLabel(shipper.Disabled)
+		after := podWithLabel(shipper.Enabled)
+		d.Record(clusterA, []shiftedPod{{before: before, after: after}})
+
+		now = now.Add(time.Second)
+
+		reverted := podWithLabel(shipper.Disabled)
+		// Seeing the same reversion over and over again only counts
+		// once.
+		for j := 0; j < 2; j++ {
+			if flipped := d.FlippedBack(clusterA, []*corev1.Pod{reverted}); len(flipped) != 1 {
+				t.Fatalf("round %d: expected reverted pod to be reported, got %d pods", i, len(flipped))
+			}
+		}
+	}
+
+	reverted := podWithLabel(shipper.Disabled)
+	if flapping := d.Flapping(clusterA, []*corev1.Pod{reverted}); len(flapping) != 1 {
+		t.Fatalf("expected pod reverted %d times to be flapping, got %d pods", labelFlapThreshold, len(flapping))
+	}
+
+	podsToShift := map[string][]*corev1.Pod{shipper.Enabled: {reverted}}
+	if pods := withoutPods(podsToShift, []*corev1.Pod{reverted})[shipper.Enabled]; len(pods) != 0 {
+		t.Errorf("expected flapping pod not to be patched again, got %d pods to shift", len(pods))
+	}
+
+	if flapping := d.Flapping(clusterB, []*corev1.Pod{reverted}); len(flapping) != 0 {
+		t.Errorf("expected the same pod in another cluster not to be flapping, got %d pods", len(flapping))
+	}
+
+	now = now.Add(labelFlapWindow)
+	if flapping := d.Flapping(clusterA, []*corev1.Pod{reverted}); len(flapping) != 0 {
+		t.Errorf("expected pod to get another chance after the flap window, got %d pods", len(flapping))
+	}
+}
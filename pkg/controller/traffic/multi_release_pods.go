@@ -0,0 +1,50 @@
+package traffic
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// releaseLikeLabels are the pod labels services might select a release's
+// pods by. Charts bootstrapped with helm carry their own release label next
+// to the one shipper puts on every pod.
+var releaseLikeLabels = []string{
+	shipper.ReleaseLabel,
+	shipper.HelmReleaseLabel,
+}
+
+// DetectMultiReleasePods returns the pods that could be selected as part of
+// more than one release, because their release-like labels don't agree on
+// which release they belong to. These usually come from charts setting
+// their own release label in pod templates. They're keyed by pod name,
+// with the sorted names of every release they claim to belong to.
+func DetectMultiReleasePods(pods []*corev1.Pod) map[string][]string {
+	offenders := make(map[string][]string)
+	for _, pod := range pods {
+		seen := make(map[string]struct{})
+		releases := []string{}
+		for _, label := range releaseLikeLabels {
+			release, ok := pod.Labels[label]
+			if !ok || release == "" {
+				continue
+			}
+
+			if _, ok := seen[release]; ok {
+				continue
+			}
+
+			seen[release] = struct{}{}
+			releases = append(releases, release)
+		}
+
+		if len(releases) > 1 {
+			sort.Strings(releases)
+			offenders[pod.Name] = releases
+		}
+	}
+
+	return offenders
+}
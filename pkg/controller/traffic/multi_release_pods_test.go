@@ -0,0 +1,27 @@
+package traffic
+
+import (
+	"reflect"
+	"testing"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+)
+
+func TestDetectMultiReleasePods(t *testing.T) {
+	pods := buildPods(shippertesting.TestApp, "contender", 3, true)
+
+	// Agrees with the shipper release label, so it's harmless.
+	pods[0].Labels[shipper.HelmReleaseLabel] = "contender"
+	// Would be selected along with the pods of the incumbent.
+	pods[1].Labels[shipper.HelmReleaseLabel] = "incumbent"
+
+	expected := map[string][]string{
+		pods[1].Name: {"contender", "incumbent"},
+	}
+
+	offenders := DetectMultiReleasePods(pods)
+	if !reflect.DeepEqual(offenders, expected) {
+		t.Fatalf("expected multi-release pods %v, got %v", expected, offenders)
+	}
+}
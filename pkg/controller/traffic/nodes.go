@@ -0,0 +1,21 @@
+package traffic
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// nodeIsHealthy returns whether pods on a node are fit to receive traffic,
+// which is only the case for nodes that are Ready and not cordoned.
+func nodeIsHealthy(node *corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
@@ -1,12 +1,14 @@
 package traffic
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/flowcontrol"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
@@ -21,12 +23,23 @@ type patchOperation struct {
 	Value string `json:"value"`
 }
 
+// shiftedPod pairs a pod as we saw it right before patching its
+// shipper.PodTrafficStatusLabel with the pod the patch resulted in.
+type shiftedPod struct {
+	before, after *corev1.Pod
+}
+
 // shiftPodLabels ensures that the pods in podsToShift have the
-// shipper.PodTrafficStatusLabel label set to the specified values.
+// shipper.PodTrafficStatusLabel label set to the specified values, and
+// returns the pods it had to patch to get there. Every patch draws from
+// budget first, giving up once ctx is done.
 func shiftPodLabels(
+	ctx context.Context,
 	clientset kubernetes.Interface,
+	budget flowcontrol.RateLimiter,
 	podsToShift map[string][]*corev1.Pod,
-) error {
+) ([]shiftedPod, error) {
+	var shifted []shiftedPod
 	for value, pods := range podsToShift {
 		for _, pod := range pods {
 			v, ok := pod.Labels[shipper.PodTrafficStatusLabel]
@@ -34,18 +47,45 @@ func shiftPodLabels(
 				continue
 			}
 
+			if err := budget.Wait(ctx); err != nil {
+				return shifted, shippererrors.
+					NewKubeclientPatchError(pod.Namespace, pod.Name, err).
+					WithCoreV1Kind("Pod")
+			}
+
 			patch := patchPodTrafficStatusLabel(pod, value)
-			_, err := clientset.CoreV1().Pods(pod.Namespace).
+			patched, err := clientset.CoreV1().Pods(pod.Namespace).
 				Patch(pod.Name, types.JSONPatchType, patch)
 			if err != nil {
-				return shippererrors.
+				return shifted, shippererrors.
 					NewKubeclientPatchError(pod.Namespace, pod.Name, err).
 					WithCoreV1Kind("Pod")
 			}
+
+			shifted = append(shifted, shiftedPod{before: pod, after: patched})
 		}
 	}
 
-	return nil
+	return shifted, nil
+}
+
+// DivergentPods returns the pods whose shipper.PodTrafficStatusLabel doesn't
+// match desiredTraffic, that is, pods that should be receiving traffic but
+// aren't labeled for it, or the other way around.
+func DivergentPods(pods []*corev1.Pod, desiredTraffic bool) []*corev1.Pod {
+	var divergent []*corev1.Pod
+	for _, pod := range pods {
+		if getsTraffic(pod) != desiredTraffic {
+			divergent = append(divergent, pod)
+		}
+	}
+	return divergent
+}
+
+// getsTraffic returns whether a pod is labeled to receive traffic. Pods
+// without a shipper.PodTrafficStatusLabel get no traffic.
+func getsTraffic(pod *corev1.Pod) bool {
+	return pod.Labels[shipper.PodTrafficStatusLabel] == shipper.Enabled
 }
 
 // patchPodTrafficStatusLabel returns a JSON Patch that modifies the
@@ -1,10 +1,12 @@
 package traffic
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippercontroller "github.com/bookingcom/shipper/pkg/controller"
 	shippertesting "github.com/bookingcom/shipper/pkg/testing"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -39,7 +41,7 @@ func TestShiftPodLabels(t *testing.T) {
 		}
 	}
 
-	err := shiftPodLabels(clientset, podsToShift)
+	_, err := shiftPodLabels(context.Background(), clientset, shippercontroller.NewWriteBudget(0, 0), podsToShift)
 	if err != nil {
 		t.Fatalf("unable to shift pod labels: %s", err)
 	}
@@ -66,6 +68,40 @@ func TestShiftPodLabels(t *testing.T) {
 	}
 }
 
+// TestShiftPodLabelsGivesUpOnExhaustedBudget checks pods aren't patched
+// while the write budget is exhausted, and that waiting for it stops once
+// the controller is asked to stop.
+func TestShiftPodLabelsGivesUpOnExhaustedBudget(t *testing.T) {
+	podsToShift := map[string][]*corev1.Pod{
+		shipper.Enabled: {pod("empty-to-enabled", map[string]string{})},
+	}
+
+	clientset := kubefake.NewSimpleClientset(podsToShift[shipper.Enabled][0])
+
+	budget := shippercontroller.NewWriteBudget(0.001, 1)
+	if !budget.TryAccept() {
+		t.Fatalf("expected the write budget to start out with a token")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	shifted, err := shiftPodLabels(ctx, clientset, budget, podsToShift)
+	if err == nil {
+		t.Fatalf("expected an error shifting pod labels with no write budget left")
+	}
+
+	if len(shifted) > 0 {
+		t.Errorf("expected no pods to be shifted, got %d", len(shifted))
+	}
+
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "patch" {
+			t.Fatalf("expected no pods to be patched, got %v", action)
+		}
+	}
+}
+
 func pod(name string, labels map[string]string) *corev1.Pod {
 	// NOTE: apiserver's implementation of json patch differs from the one
 	// in client-go's test reactor, so we have to cheat a little bit by
@@ -85,3 +121,34 @@ func pod(name string, labels map[string]string) *corev1.Pod {
 		},
 	}
 }
+
+func TestDivergentPods(t *testing.T) {
+	lbl := shipper.PodTrafficStatusLabel
+	pods := []*corev1.Pod{
+		pod("missing", map[string]string{}),
+		pod("enabled", map[string]string{lbl: shipper.Enabled}),
+		pod("disabled", map[string]string{lbl: shipper.Disabled}),
+	}
+
+	tests := []struct {
+		desiredTraffic bool
+		expected       []string
+	}{
+		{true, []string{"missing", "disabled"}},
+		{false, []string{"enabled"}},
+	}
+
+	for _, tt := range tests {
+		divergent := DivergentPods(pods, tt.desiredTraffic)
+
+		names := make([]string, 0, len(divergent))
+		for _, p := range divergent {
+			names = append(names, p.Name)
+		}
+
+		eq, diff := shippertesting.DeepEqualDiff(tt.expected, names)
+		if !eq {
+			t.Errorf("divergent pods for desired traffic %t differ from expected:\n%s", tt.desiredTraffic, diff)
+		}
+	}
+}
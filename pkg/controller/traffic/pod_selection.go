@@ -0,0 +1,179 @@
+package traffic
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SelectionPolicy decides which pod gets its traffic label toggled next
+// when there are more candidates than pods that need toggling.
+type SelectionPolicy string
+
+const (
+	// SelectionPolicyInOrder picks candidates in the order they come in,
+	// which is name order for pods coming from summarizePods.
+	SelectionPolicyInOrder SelectionPolicy = ""
+
+	// SelectionPolicyOldestFirst picks the candidate created first.
+	SelectionPolicyOldestFirst SelectionPolicy = "oldest-first"
+
+	// SelectionPolicyNewestFirst picks the candidate created last.
+	SelectionPolicyNewestFirst SelectionPolicy = "newest-first"
+
+	// SelectionPolicyNodeSpread picks pods to stop receiving traffic
+	// from the node with the most candidates left, so the pods that keep
+	// serving stay spread across as many nodes as possible. Pods to start
+	// receiving traffic are picked in order.
+	SelectionPolicyNodeSpread SelectionPolicy = "node-spread"
+)
+
+// NextPodToToggle returns which of candidates should have its traffic label
+// toggled next according to policy, or nil if there are no candidates.
+// enabling tells whether candidates are about to start receiving traffic or
+// to stop receiving it. Ties are always broken by the order candidates come
+// in, so calling it in a loop and taking the returned pod out of candidates
+// every time picks the same pods for the same input.
+func NextPodToToggle(candidates []*corev1.Pod, enabling bool, policy SelectionPolicy) *corev1.Pod {
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	switch policy {
+	case SelectionPolicyOldestFirst:
+		next := candidates[0]
+		for _, pod := range candidates[1:] {
+			if pod.CreationTimestamp.Before(&next.CreationTimestamp) {
+				next = pod
+			}
+		}
+		return next
+
+	case SelectionPolicyNewestFirst:
+		next := candidates[0]
+		for _, pod := range candidates[1:] {
+			if next.CreationTimestamp.Before(&pod.CreationTimestamp) {
+				next = pod
+			}
+		}
+		return next
+
+	case SelectionPolicyNodeSpread:
+		if enabling {
+			return candidates[0]
+		}
+		return firstPodOnBusiestNode(candidates)
+
+	default:
+		return candidates[0]
+	}
+}
+
+// firstPodOnBusiestNode returns the first of pods on the node that has the
+// most of them. Ties are broken by node name.
+func firstPodOnBusiestNode(pods []*corev1.Pod) *corev1.Pod {
+	podsByNode := make(map[string][]*corev1.Pod)
+	var nodes []string
+	for _, pod := range pods {
+		node := pod.Spec.NodeName
+		if _, ok := podsByNode[node]; !ok {
+			nodes = append(nodes, node)
+		}
+		podsByNode[node] = append(podsByNode[node], pod)
+	}
+
+	sort.Strings(nodes)
+
+	busiest := nodes[0]
+	for _, node := range nodes[1:] {
+		if len(podsByNode[node]) > len(podsByNode[busiest]) {
+			busiest = node
+		}
+	}
+
+	return podsByNode[busiest][0]
+}
+
+// PodScorer rates how good a pod is at serving traffic. Pods with the
+// highest score are the first to start receiving traffic, and the ones with
+// the lowest score are the first to stop. Only pods that score the same are
+// left for the SelectionPolicy to pick from.
+type PodScorer func(*corev1.Pod) float64
+
+// ConstantPodScorer scores every pod the same, leaving it all up to the
+// SelectionPolicy.
+func ConstantPodScorer(*corev1.Pod) float64 {
+	return 0
+}
+
+// bestScoredPods returns the candidates that are the best for enabling
+// traffic on, or disabling it, according to scorer, in the order they come
+// in.
+func bestScoredPods(candidates []*corev1.Pod, enabling bool, scorer PodScorer) []*corev1.Pod {
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	scores := make([]float64, len(candidates))
+	best := 0
+	for i, pod := range candidates {
+		scores[i] = scorer(pod)
+		if (enabling && scores[i] > scores[best]) || (!enabling && scores[i] < scores[best]) {
+			best = i
+		}
+	}
+
+	var pods []*corev1.Pod
+	for i, pod := range candidates {
+		if scores[i] == scores[best] {
+			pods = append(pods, pod)
+		}
+	}
+
+	return pods
+}
+
+// pickPodsToToggle calls NextPodToToggle on the best scored candidates
+// until n pods are picked out of them, or there are no candidates left. A
+// nil scorer scores every pod the same.
+func pickPodsToToggle(candidates []*corev1.Pod, n int, enabling bool, policy SelectionPolicy, scorer PodScorer) []*corev1.Pod {
+	if scorer == nil {
+		scorer = ConstantPodScorer
+	}
+
+	remaining := make([]*corev1.Pod, len(candidates))
+	copy(remaining, candidates)
+
+	picked := make([]*corev1.Pod, 0, n)
+	for len(picked) < n {
+		next := NextPodToToggle(bestScoredPods(remaining, enabling, scorer), enabling, policy)
+		if next == nil {
+			break
+		}
+
+		picked = append(picked, next)
+		for i, pod := range remaining {
+			if pod == next {
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return picked
+}
+
+// selectionPolicyFor returns the SelectionPolicy the controller uses: policy
+// when it was given one, and otherwise depending on whether it was asked to
+// preserve the node spread of serving pods.
+func selectionPolicyFor(policy SelectionPolicy, preserveNodeSpread bool) SelectionPolicy {
+	if policy != SelectionPolicyInOrder {
+		return policy
+	}
+
+	if preserveNodeSpread {
+		return SelectionPolicyNodeSpread
+	}
+
+	return SelectionPolicyInOrder
+}
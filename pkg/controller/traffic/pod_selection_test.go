@@ -0,0 +1,165 @@
+package traffic
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func buildCandidate(name, node string, age time.Duration) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).Add(-age)),
+		},
+		Spec: corev1.PodSpec{
+			NodeName: node,
+		},
+	}
+}
+
+func TestNextPodToToggle(t *testing.T) {
+	candidates := []*corev1.Pod{
+		buildCandidate("pod-a", "node-a", 2*time.Hour),
+		buildCandidate("pod-b", "node-b", 3*time.Hour),
+		buildCandidate("pod-c", "node-b", 1*time.Hour),
+		buildCandidate("pod-d", "node-b", 1*time.Hour),
+	}
+
+	tests := []struct {
+		name     string
+		policy   SelectionPolicy
+		enabling bool
+		expected string
+	}{
+		{"in order", SelectionPolicyInOrder, true, "pod-a"},
+		{"oldest first", SelectionPolicyOldestFirst, false, "pod-b"},
+		{"newest first", SelectionPolicyNewestFirst, true, "pod-c"},
+		{"node spread when disabling", SelectionPolicyNodeSpread, false, "pod-b"},
+		{"node spread when enabling", SelectionPolicyNodeSpread, true, "pod-a"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := NextPodToToggle(candidates, test.enabling, test.policy)
+			if pod == nil || pod.Name != test.expected {
+				t.Errorf("expected %q to be picked, got %v", test.expected, pod)
+			}
+		})
+	}
+
+	if pod := NextPodToToggle(nil, true, SelectionPolicyOldestFirst); pod != nil {
+		t.Errorf("expected no pod to be picked out of no candidates, got %q", pod.Name)
+	}
+}
+
+func TestPickPodsToToggle(t *testing.T) {
+	candidates := []*corev1.Pod{
+		buildCandidate("pod-a", "node-a", 2*time.Hour),
+		buildCandidate("pod-b", "node-b", 3*time.Hour),
+		buildCandidate("pod-c", "node-b", 1*time.Hour),
+		buildCandidate("pod-d", "node-b", 4*time.Hour),
+	}
+
+	tests := []struct {
+		name     string
+		policy   SelectionPolicy
+		expected []string
+	}{
+		{"oldest first", SelectionPolicyOldestFirst, []string{"pod-d", "pod-b", "pod-a"}},
+		{"newest first", SelectionPolicyNewestFirst, []string{"pod-c", "pod-a", "pod-b"}},
+		{"node spread", SelectionPolicyNodeSpread, []string{"pod-b", "pod-c", "pod-a"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			picked := pickPodsToToggle(candidates, 3, false, test.policy, nil)
+			if len(picked) != len(test.expected) {
+				t.Fatalf("expected %d pods to be picked, got %d", len(test.expected), len(picked))
+			}
+
+			for i, pod := range picked {
+				if pod.Name != test.expected[i] {
+					t.Errorf("expected pod %d to be %q, got %q", i, test.expected[i], pod.Name)
+				}
+			}
+		})
+	}
+
+	if len(candidates) != 4 {
+		t.Errorf("expected candidates to be left untouched, got %d of them", len(candidates))
+	}
+}
+
+func TestPickPodsToToggleWithScorer(t *testing.T) {
+	const zoneLabel = "topology.kubernetes.io/zone"
+
+	inZone := func(pod *corev1.Pod, zone string) *corev1.Pod {
+		pod.Labels = map[string]string{zoneLabel: zone}
+		return pod
+	}
+
+	candidates := []*corev1.Pod{
+		inZone(buildCandidate("pod-a", "node-a", 2*time.Hour), "far"),
+		inZone(buildCandidate("pod-b", "node-b", 3*time.Hour), "near"),
+		inZone(buildCandidate("pod-c", "node-b", 1*time.Hour), "far"),
+		inZone(buildCandidate("pod-d", "node-b", 4*time.Hour), "near"),
+	}
+
+	// Pods in the same zone as the caller are the best at serving it.
+	sameZone := func(pod *corev1.Pod) float64 {
+		if pod.Labels[zoneLabel] == "near" {
+			return 1
+		}
+		return 0
+	}
+
+	tests := []struct {
+		name     string
+		enabling bool
+		scorer   PodScorer
+		expected []string
+	}{
+		{"constant scorer enabling", true, ConstantPodScorer, []string{"pod-d", "pod-b", "pod-a"}},
+		{"constant scorer disabling", false, ConstantPodScorer, []string{"pod-d", "pod-b", "pod-a"}},
+		{"same zone enabling", true, sameZone, []string{"pod-d", "pod-b", "pod-a"}},
+		{"same zone disabling", false, sameZone, []string{"pod-a", "pod-c", "pod-d"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			picked := pickPodsToToggle(candidates, 3, test.enabling, SelectionPolicyOldestFirst, test.scorer)
+			if len(picked) != len(test.expected) {
+				t.Fatalf("expected %d pods to be picked, got %d", len(test.expected), len(picked))
+			}
+
+			for i, pod := range picked {
+				if pod.Name != test.expected[i] {
+					t.Errorf("expected pod %d to be %q, got %q", i, test.expected[i], pod.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectionPolicyFor(t *testing.T) {
+	tests := []struct {
+		name               string
+		policy             SelectionPolicy
+		preserveNodeSpread bool
+		expected           SelectionPolicy
+	}{
+		{"default", SelectionPolicyInOrder, false, SelectionPolicyInOrder},
+		{"preserve node spread", SelectionPolicyInOrder, true, SelectionPolicyNodeSpread},
+		{"explicit policy", SelectionPolicyOldestFirst, false, SelectionPolicyOldestFirst},
+		{"explicit policy wins over node spread", SelectionPolicyNewestFirst, true, SelectionPolicyNewestFirst},
+	}
+
+	for _, tt := range tests {
+		if got := selectionPolicyFor(tt.policy, tt.preserveNodeSpread); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
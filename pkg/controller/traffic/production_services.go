@@ -0,0 +1,69 @@
+package traffic
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	apputil "github.com/bookingcom/shipper/pkg/util/application"
+)
+
+// ValidateProductionServices checks that every cluster in tt has the
+// production Service traffic is shifted through, going by the same
+// selector the controller uses when syncing a cluster, and returns the
+// problems it found keyed by cluster name. app is the application of tt, if
+// it still exists, and is what the selector is taken from. clients holds a
+// client for every cluster in tt; clusters without one are reported as
+// problems too. Nothing is ever modified.
+func ValidateProductionServices(tt *shipper.TrafficTarget, app *shipper.Application, clients map[string]kubernetes.Interface) map[string]error {
+	problems := make(map[string]error)
+
+	appName, ok := tt.Labels[shipper.AppLabel]
+	if !ok {
+		err := shippererrors.NewMissingShipperLabelError(tt, shipper.AppLabel)
+		for _, spec := range tt.Spec.Clusters {
+			problems[spec.Name] = err
+		}
+		return problems
+	}
+
+	serviceSelector := apputil.DefaultProductionServiceSelector(appName)
+	if app != nil {
+		var err error
+		serviceSelector, err = apputil.GetProductionServiceSelector(app)
+		if err != nil {
+			for _, spec := range tt.Spec.Clusters {
+				problems[spec.Name] = err
+			}
+			return problems
+		}
+	}
+	serviceGVK := corev1.SchemeGroupVersion.WithKind("Service")
+
+	for _, spec := range tt.Spec.Clusters {
+		client, ok := clients[spec.Name]
+		if !ok {
+			problems[spec.Name] = fmt.Errorf("no client for cluster %q", spec.Name)
+			continue
+		}
+
+		services, err := client.CoreV1().Services(tt.Namespace).
+			List(metav1.ListOptions{LabelSelector: serviceSelector.String()})
+		if err != nil {
+			problems[spec.Name] = shippererrors.NewKubeclientListError(
+				serviceGVK, tt.Namespace, serviceSelector, err)
+			continue
+		}
+
+		if len(services.Items) == 0 {
+			problems[spec.Name] = shippererrors.NewUnexpectedObjectCountFromSelectorError(
+				serviceSelector, serviceGVK, 1, len(services.Items))
+		}
+	}
+
+	return problems
+}
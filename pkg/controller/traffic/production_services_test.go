@@ -0,0 +1,41 @@
+package traffic
+
+import (
+	"testing"
+
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+)
+
+func TestValidateProductionServices(t *testing.T) {
+	const clusterC = "cluster-c"
+
+	tt := buildTrafficTarget(shippertesting.TestApp, "contender",
+		map[string]uint32{clusterA: 50, clusterB: 50, clusterC: 50})
+
+	clusterAClient := kubefake.NewSimpleClientset(buildService(shippertesting.TestApp))
+	clients := map[string]kubernetes.Interface{
+		clusterA: clusterAClient,
+		clusterB: kubefake.NewSimpleClientset(buildService("another-app")),
+	}
+
+	problems := ValidateProductionServices(tt, nil, clients)
+
+	if err, ok := problems[clusterA]; ok {
+		t.Errorf("expected no problems in cluster %q, got: %s", clusterA, err)
+	}
+	if _, ok := problems[clusterB]; !ok {
+		t.Errorf("expected cluster %q without a production service for the application to be reported", clusterB)
+	}
+	if _, ok := problems[clusterC]; !ok {
+		t.Errorf("expected cluster %q without a client to be reported", clusterC)
+	}
+
+	for _, action := range clusterAClient.Actions() {
+		if action.GetVerb() != "list" {
+			t.Errorf("expected validation to only list objects, got a %q on %s", action.GetVerb(), action.GetResource().Resource)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package traffic
+
+import (
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// DefaultReadinessWaitTimeout is the longest a cluster holds its weight
+// waiting for the pods labeled to receive traffic in it to be ready, when
+// the controller isn't configured with any other.
+const DefaultReadinessWaitTimeout = 5 * time.Minute
+
+// readinessWaitTracker keeps track of how long every cluster of a traffic
+// target has been holding its weight until its pods are ready. Pods that
+// don't get ready in a reasonable amount of time might never do, and
+// shouldn't keep the release from getting the rest of its traffic.
+type readinessWaitTracker struct {
+	timeout time.Duration
+	now     func() time.Time
+
+	mu    sync.Mutex
+	since map[string]map[string]time.Time
+}
+
+func newReadinessWaitTracker(timeout time.Duration) *readinessWaitTracker {
+	if timeout <= 0 {
+		timeout = DefaultReadinessWaitTimeout
+	}
+
+	return &readinessWaitTracker{
+		timeout: timeout,
+		now:     time.Now,
+		since:   make(map[string]map[string]time.Time),
+	}
+}
+
+// Observe records whether cluster in the traffic target identified by
+// ttKey has pods labeled to receive traffic that aren't ready, and returns
+// whether it should keep holding its weight and, if so, for how long at
+// most. The timer is reset as soon as all of those pods are ready.
+func (t *readinessWaitTracker) Observe(ttKey, cluster string, waiting bool) (bool, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	clusters, ok := t.since[ttKey]
+	if !waiting {
+		if ok {
+			delete(clusters, cluster)
+			if len(clusters) == 0 {
+				delete(t.since, ttKey)
+			}
+		}
+		return false, 0
+	}
+
+	if !ok {
+		clusters = make(map[string]time.Time)
+		t.since[ttKey] = clusters
+	}
+
+	now := t.now()
+	since, ok := clusters[cluster]
+	if !ok {
+		since = now
+		clusters[cluster] = since
+	}
+
+	if remaining := t.timeout - now.Sub(since); remaining > 0 {
+		return true, remaining
+	}
+
+	return false, 0
+}
+
+func (t *readinessWaitTracker) Forget(ttKey string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.since, ttKey)
+}
+
+// labeledPodsNotReady returns how many of the pods in releaseSelector that
+// are labeled to receive traffic endpoints reports as not ready. Pods of
+// other releases, and pods that aren't meant to receive traffic, don't
+// count.
+func labeledPodsNotReady(pods []*corev1.Pod, endpoints *corev1.Endpoints, releaseSelector labels.Selector) int {
+	podReadiness := make(map[string]bool)
+	for _, subset := range endpoints.Subsets {
+		markAddressReadiness(podReadiness, subset.Addresses, true)
+		markAddressReadiness(podReadiness, subset.NotReadyAddresses, false)
+	}
+
+	notReady := 0
+	for _, pod := range pods {
+		if !releaseSelector.Matches(labels.Set(pod.Labels)) ||
+			pod.Labels[shipper.PodTrafficStatusLabel] != shipper.Enabled {
+			continue
+		}
+
+		if ready, ok := podReadiness[pod.Name]; ok && !ready {
+			notReady++
+		}
+	}
+
+	return notReady
+}
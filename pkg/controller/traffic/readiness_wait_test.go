@@ -0,0 +1,79 @@
+package traffic
+
+import (
+	"testing"
+	"time"
+
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+)
+
+func TestReadinessWaitTrackerExpires(t *testing.T) {
+	const ttKey = "test-namespace/foobar"
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := newReadinessWaitTracker(time.Minute)
+	tracker.now = func() time.Time { return now }
+
+	if hold, holdFor := tracker.Observe(ttKey, clusterA, true); !hold || holdFor != time.Minute {
+		t.Fatalf("expected a cluster with pods not ready to hold its weight for a minute, got %t and %s", hold, holdFor)
+	}
+
+	// Clusters are timed on their own.
+	now = now.Add(30 * time.Second)
+	if hold, holdFor := tracker.Observe(ttKey, clusterB, true); !hold || holdFor != time.Minute {
+		t.Fatalf("expected another cluster to start its own timer, got %t and %s", hold, holdFor)
+	}
+
+	now = now.Add(30 * time.Second)
+	if hold, _ := tracker.Observe(ttKey, clusterA, true); hold {
+		t.Fatalf("expected a cluster to stop holding its weight once it waited for long enough")
+	}
+
+	if hold, holdFor := tracker.Observe(ttKey, clusterB, true); !hold || holdFor != 30*time.Second {
+		t.Fatalf("expected the other cluster to hold its weight for another 30s, got %t and %s", hold, holdFor)
+	}
+
+	// Pods getting ready starts the timer over.
+	tracker.Observe(ttKey, clusterA, false)
+	if hold, holdFor := tracker.Observe(ttKey, clusterA, true); !hold || holdFor != time.Minute {
+		t.Fatalf("expected the timer to be reset once pods were ready, got %t and %s", hold, holdFor)
+	}
+}
+
+func TestReadinessWaitTrackerDefaultTimeout(t *testing.T) {
+	if tracker := newReadinessWaitTracker(0); tracker.timeout != DefaultReadinessWaitTimeout {
+		t.Errorf("expected a zero timeout to mean %s, got %s", DefaultReadinessWaitTimeout, tracker.timeout)
+	}
+}
+
+// TestLabeledPodsNotReadyOnlyCountsRelease verifies that only the pods of
+// the release being shifted that are labeled to receive traffic can hold
+// its weight back.
+func TestLabeledPodsNotReadyOnlyCountsRelease(t *testing.T) {
+	const otherRelease = "other-release"
+
+	notReady := buildPods(shippertesting.TestApp, ttName, 1, withTraffic)
+	notReady[0].Labels[podReadinessLabel] = podNotReady
+
+	otherNotReady := buildPods(shippertesting.TestApp, otherRelease, 2, withTraffic)
+	for _, pod := range otherNotReady {
+		pod.Labels[podReadinessLabel] = podNotReady
+	}
+
+	ready := buildPods(shippertesting.TestApp, ttName, 2, withTraffic)
+	unlabeled := buildPods(shippertesting.TestApp, ttName, 2, noTraffic)
+
+	endpoints := buildEndpoints(shippertesting.TestApp)
+	pods := append(append(append(notReady, otherNotReady...), ready...), unlabeled...)
+	for _, pod := range pods {
+		endpoints = shiftPodInEndpoints(pod, endpoints)
+	}
+
+	if got := labeledPodsNotReady(pods, endpoints, releaseSelectorFor(shippertesting.TestApp, ttName)); got != 1 {
+		t.Errorf("expected 1 pod of the release not to be ready, got %d", got)
+	}
+
+	if got := labeledPodsNotReady(pods, endpoints, releaseSelectorFor(shippertesting.TestApp, otherRelease)); got != 2 {
+		t.Errorf("expected 2 pods of the other release not to be ready, got %d", got)
+	}
+}
@@ -0,0 +1,173 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippercontroller "github.com/bookingcom/shipper/pkg/controller"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+// TrafficShifter gets a release the traffic weight it should have in an
+// application cluster by some other means than labeling its pods. When the
+// controller has one, it leaves pods alone and trusts the shifter to both
+// apply weights and report how much of them have been achieved.
+type TrafficShifter interface {
+	// Shift makes sure the release behind tt gets weight in the cluster
+	// described by spec, and returns the weight it's been observed to
+	// achieve so far, along with whether that's all of it. ctx is done
+	// once the controller is asked to stop.
+	Shift(ctx context.Context, tt *shipper.TrafficTarget, spec *shipper.ClusterTrafficTarget, weight uint32) (uint32, bool, error)
+}
+
+// TrafficShifterRequeueInterval is how long the controller waits before
+// checking on a traffic target again when its TrafficShifter hasn't
+// achieved all of its weight yet.
+const TrafficShifterRequeueInterval = 10 * time.Second
+
+// DynamicClientFunc returns a dynamic client for the application cluster
+// with the given name.
+type DynamicClientFunc func(clusterName string) (dynamic.Interface, error)
+
+type cachedDynamicClient struct {
+	config *rest.Config
+	client dynamic.Interface
+}
+
+// CachedDynamicClientFunc returns a DynamicClientFunc that builds a dynamic
+// client out of the rest.Config configFor returns for a cluster, and keeps
+// reusing it for as long as that cluster's config stays the same.
+func CachedDynamicClientFunc(configFor func(clusterName string) (*rest.Config, error)) DynamicClientFunc {
+	var mu sync.Mutex
+	clients := make(map[string]cachedDynamicClient)
+
+	return func(clusterName string) (dynamic.Interface, error) {
+		config, err := configFor(clusterName)
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if cached, ok := clients[clusterName]; ok && cached.config == config {
+			return cached.client, nil
+		}
+
+		client, err := dynamic.NewForConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		clients[clusterName] = cachedDynamicClient{config: config, client: client}
+
+		return client, nil
+	}
+}
+
+// RouteWeightsShifter is a TrafficShifter for applications routed by a
+// custom resource instead of by pod labels. Every application is expected
+// to have one object of that resource, named after it, in its namespace on
+// each cluster. Shipper writes the weight of each release in its spec, and
+// expects whatever does the routing to report back the weights it applied
+// in its status:
+//
+//	spec:
+//	  weights:
+//	    <release name>: <desired weight>
+//	status:
+//	  weights:
+//	    <release name>: <achieved weight>
+type RouteWeightsShifter struct {
+	clientFor   DynamicClientFunc
+	resource    schema.GroupVersionResource
+	writeBudget flowcontrol.RateLimiter
+}
+
+var _ TrafficShifter = (*RouteWeightsShifter)(nil)
+
+// NewRouteWeightsShifter returns a RouteWeightsShifter that draws from
+// writeBudget before every update of the routing objects. Updates aren't
+// limited when it's nil.
+func NewRouteWeightsShifter(
+	clientFor DynamicClientFunc,
+	resource schema.GroupVersionResource,
+	writeBudget flowcontrol.RateLimiter,
+) *RouteWeightsShifter {
+	if writeBudget == nil {
+		writeBudget = shippercontroller.NewWriteBudget(0, 0)
+	}
+
+	return &RouteWeightsShifter{
+		clientFor:   clientFor,
+		resource:    resource,
+		writeBudget: writeBudget,
+	}
+}
+
+func (s *RouteWeightsShifter) Shift(ctx context.Context, tt *shipper.TrafficTarget, spec *shipper.ClusterTrafficTarget, weight uint32) (uint32, bool, error) {
+	client, err := s.clientFor(spec.Name)
+	if err != nil {
+		return 0, false, err
+	}
+
+	appName := tt.Labels[shipper.AppLabel]
+	releaseName := tt.Labels[shipper.ReleaseLabel]
+	routes := client.Resource(s.resource).Namespace(tt.Namespace)
+
+	obj, err := routes.Get(appName, metav1.GetOptions{})
+	if err != nil {
+		return 0, false, shippererrors.NewKubeclientGetError(tt.Namespace, appName, err).
+			WithKind(s.resource.GroupVersion().WithKind(s.resource.Resource))
+	}
+
+	desired, found, err := unstructured.NestedInt64(obj.Object, "spec", "weights", releaseName)
+	if err != nil {
+		return 0, false, shippererrors.NewUnrecoverableError(err)
+	}
+
+	if !found || desired != int64(weight) {
+		obj = obj.DeepCopy()
+		if err := setRouteWeight(obj, releaseName, weight); err != nil {
+			return 0, false, shippererrors.NewUnrecoverableError(err)
+		}
+
+		if err := s.writeBudget.Wait(ctx); err != nil {
+			return 0, false, shippererrors.NewKubeclientUpdateError(obj, err).
+				WithKind(obj.GroupVersionKind())
+		}
+
+		if _, err := routes.Update(obj, metav1.UpdateOptions{}); err != nil {
+			return 0, false, shippererrors.NewKubeclientUpdateError(obj, err).
+				WithKind(obj.GroupVersionKind())
+		}
+
+		// Whatever was achieved so far was for a weight we no
+		// longer want, so wait for the status to catch up.
+		return 0, false, nil
+	}
+
+	achieved, _, err := unstructured.NestedInt64(obj.Object, "status", "weights", releaseName)
+	if err != nil {
+		return 0, false, shippererrors.NewUnrecoverableError(err)
+	}
+
+	return uint32(achieved), achieved == int64(weight), nil
+}
+
+func setRouteWeight(obj *unstructured.Unstructured, releaseName string, weight uint32) error {
+	if err := unstructured.SetNestedField(obj.Object, int64(weight), "spec", "weights", releaseName); err != nil {
+		return fmt.Errorf("failed to set weight of release %q in %s %q: %s",
+			releaseName, obj.GetKind(), obj.GetName(), err)
+	}
+	return nil
+}
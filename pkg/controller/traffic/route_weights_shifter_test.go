@@ -0,0 +1,306 @@
+package traffic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+	trafficutil "github.com/bookingcom/shipper/pkg/util/traffic"
+)
+
+var routeWeightsResource = schema.GroupVersionResource{
+	Group:    "routing.example.com",
+	Version:  "v1",
+	Resource: "routeweights",
+}
+
+func TestRouteWeightsShifter(t *testing.T) {
+	tests := []struct {
+		name             string
+		spec             map[string]interface{}
+		status           map[string]interface{}
+		weight           uint32
+		expectedAchieved uint32
+		expectedReady    bool
+		expectUpdate     bool
+	}{
+		{
+			name:         "release not routed yet",
+			spec:         map[string]interface{}{"other-release": int64(100)},
+			weight:       50,
+			expectUpdate: true,
+		},
+		{
+			name:             "weight changed",
+			spec:             map[string]interface{}{ttName: int64(10)},
+			status:           map[string]interface{}{ttName: int64(10)},
+			weight:           50,
+			expectedAchieved: 0,
+			expectUpdate:     true,
+		},
+		{
+			name:             "weight partially achieved",
+			spec:             map[string]interface{}{ttName: int64(50)},
+			status:           map[string]interface{}{ttName: int64(20)},
+			weight:           50,
+			expectedAchieved: 20,
+		},
+		{
+			name:             "weight achieved",
+			spec:             map[string]interface{}{ttName: int64(50)},
+			status:           map[string]interface{}{ttName: int64(50)},
+			weight:           50,
+			expectedAchieved: 50,
+			expectedReady:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		client := newRouteWeightsClient(t, buildRouteWeights(shippertesting.TestApp, tt.spec, tt.status))
+		shifter := NewRouteWeightsShifter(
+			func(string) (dynamic.Interface, error) { return client, nil },
+			routeWeightsResource,
+			nil,
+		)
+
+		trafficTarget := buildTrafficTarget(shippertesting.TestApp, ttName, map[string]uint32{clusterA: tt.weight})
+		achieved, ready, err := shifter.Shift(context.Background(), trafficTarget, &trafficTarget.Spec.Clusters[0], tt.weight)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.name, err)
+			continue
+		}
+
+		if achieved != tt.expectedAchieved || ready != tt.expectedReady {
+			t.Errorf("%s: expected achieved weight %d and ready %t, got %d and %t",
+				tt.name, tt.expectedAchieved, tt.expectedReady, achieved, ready)
+		}
+
+		updated := false
+		for _, action := range client.Actions() {
+			if action.GetVerb() == "update" {
+				updated = true
+			}
+		}
+		if updated != tt.expectUpdate {
+			t.Errorf("%s: expected route weights to be updated: %t, got %t", tt.name, tt.expectUpdate, updated)
+		}
+
+		obj, err := client.Resource(routeWeightsResource).Namespace(shippertesting.TestNamespace).
+			Get(shippertesting.TestApp, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("%s: unexpected error getting route weights: %s", tt.name, err)
+		}
+
+		desired, _, _ := unstructured.NestedInt64(obj.Object, "spec", "weights", ttName)
+		if desired != int64(tt.weight) {
+			t.Errorf("%s: expected route weights to want %d for release, got %d", tt.name, tt.weight, desired)
+		}
+
+		if _, ok, _ := unstructured.NestedInt64(obj.Object, "spec", "weights", "other-release"); tt.spec["other-release"] != nil && !ok {
+			t.Errorf("%s: expected weights of other releases to be left alone", tt.name)
+		}
+	}
+}
+
+func TestRouteWeightsShifterMissingObject(t *testing.T) {
+	client := newRouteWeightsClient(t)
+	shifter := NewRouteWeightsShifter(
+		func(string) (dynamic.Interface, error) { return client, nil },
+		routeWeightsResource,
+		nil,
+	)
+
+	tt := buildTrafficTarget(shippertesting.TestApp, ttName, map[string]uint32{clusterA: 50})
+	if _, _, err := shifter.Shift(context.Background(), tt, &tt.Spec.Clusters[0], 50); err == nil {
+		t.Errorf("expected an error for an application without route weights, got none")
+	}
+}
+
+// TestTrafficShifterLeavesPodsAlone verifies that the controller hands
+// clusters over to its TrafficShifter when it has one, reporting the weight
+// it achieved without ever touching pod labels.
+func TestTrafficShifterLeavesPodsAlone(t *testing.T) {
+	podCount := 2
+	tt := buildTrafficTarget(shippertesting.TestApp, ttName, map[string]uint32{clusterA: 50})
+
+	f := shippertesting.NewControllerTestFixture()
+	cluster := addCluster(f, clusterA)
+	cluster.AddMany(buildWorldWithPods(shippertesting.TestApp, ttName, podCount, noTraffic))
+	f.ShipperClient.Tracker().Add(tt)
+
+	client := newRouteWeightsClient(t,
+		buildRouteWeights(shippertesting.TestApp,
+			map[string]interface{}{ttName: int64(50)},
+			map[string]interface{}{ttName: int64(50)}))
+
+	controller := NewController(
+		f.ShipperClient,
+		f.ShipperInformerFactory,
+		f.ClusterClientStore,
+		f.Recorder,
+		Config{
+			TrafficShifter: NewRouteWeightsShifter(
+				func(string) (dynamic.Interface, error) { return client, nil },
+				routeWeightsResource,
+				nil,
+			),
+		},
+	)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.Run(stopCh)
+
+	weights, err := buildClusterReleaseWeights([]*shipper.TrafficTarget{tt}, noTrafficWeightFallback)
+	if err != nil {
+		t.Fatalf("unexpected error building cluster release weights: %s", err)
+	}
+
+	statuses, clusterErrors := controller.processTrafficTargetOnClusters(context.Background(), tt, weights)
+	if len(clusterErrors.Errors) > 0 {
+		t.Fatalf("unexpected cluster errors: %v", clusterErrors.Errors)
+	}
+
+	if got := statuses[0].AchievedTraffic; got != 50 {
+		t.Errorf("expected cluster to achieve a weight of 50, got %d", got)
+	}
+
+	cond := trafficutil.GetClusterTrafficCondition(*statuses[0], shipper.ClusterConditionTypeReady)
+	if cond == nil || cond.Status != corev1.ConditionTrue {
+		t.Errorf("expected cluster to be ready, got %v", cond)
+	}
+
+	assertPodTraffic(t, tt, f.Clusters[clusterA], podStatus{withoutTraffic: podCount})
+}
+
+// TestTrafficShifterRequeuesUntilReady verifies that the controller checks
+// back on a traffic target whose TrafficShifter hasn't achieved all of its
+// weight yet, as nothing else would tell it when it has.
+func TestTrafficShifterRequeuesUntilReady(t *testing.T) {
+	tt := buildTrafficTarget(shippertesting.TestApp, ttName, map[string]uint32{clusterA: 50})
+
+	f := shippertesting.NewControllerTestFixture()
+	addCluster(f, clusterA)
+	f.ShipperClient.Tracker().Add(tt)
+
+	client := newRouteWeightsClient(t,
+		buildRouteWeights(shippertesting.TestApp,
+			map[string]interface{}{ttName: int64(50)},
+			map[string]interface{}{ttName: int64(20)}))
+
+	controller := NewController(
+		f.ShipperClient,
+		f.ShipperInformerFactory,
+		f.ClusterClientStore,
+		f.Recorder,
+		Config{
+			TrafficShifter: NewRouteWeightsShifter(
+				func(string) (dynamic.Interface, error) { return client, nil },
+				routeWeightsResource,
+				nil,
+			),
+		},
+	)
+
+	queue := &delayRecordingQueue{
+		RateLimitingInterface: controller.workqueue,
+		delays:                make(map[interface{}]time.Duration),
+	}
+	controller.workqueue = queue
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.Run(stopCh)
+
+	weights, err := buildClusterReleaseWeights([]*shipper.TrafficTarget{tt}, noTrafficWeightFallback)
+	if err != nil {
+		t.Fatalf("unexpected error building cluster release weights: %s", err)
+	}
+
+	if _, clusterErrors := controller.processTrafficTargetOnClusters(context.Background(), tt, weights); len(clusterErrors.Errors) > 0 {
+		t.Fatalf("unexpected cluster errors: %v", clusterErrors.Errors)
+	}
+
+	key := tt.Namespace + "/" + tt.Name
+	if delay, ok := queue.delays[key]; !ok || delay != TrafficShifterRequeueInterval {
+		t.Errorf("expected traffic target to be requeued in %s, got %s", TrafficShifterRequeueInterval, delay)
+	}
+}
+
+func TestCachedDynamicClientFunc(t *testing.T) {
+	configs := map[string]*rest.Config{
+		clusterA: {Host: "https://cluster-a"},
+		clusterB: {Host: "https://cluster-b"},
+	}
+	clientFor := CachedDynamicClientFunc(func(clusterName string) (*rest.Config, error) {
+		return configs[clusterName], nil
+	})
+
+	mustClient := func(clusterName string) dynamic.Interface {
+		client, err := clientFor(clusterName)
+		if err != nil {
+			t.Fatalf("unexpected error building client for %q: %s", clusterName, err)
+		}
+		return client
+	}
+
+	first := mustClient(clusterA)
+	if again := mustClient(clusterA); again != first {
+		t.Errorf("expected the client for %q to be reused", clusterA)
+	}
+
+	if other := mustClient(clusterB); other == first {
+		t.Errorf("expected every cluster to get its own client")
+	}
+
+	// The store hands out a new config when a cluster's secret
+	// changes, so the client has to be built again.
+	configs[clusterA] = &rest.Config{Host: "https://cluster-a"}
+	if rebuilt := mustClient(clusterA); rebuilt == first {
+		t.Errorf("expected the client for %q to be rebuilt for its new config", clusterA)
+	}
+}
+
+// newRouteWeightsClient returns a fake dynamic client serving objects. They
+// are created through the client rather than handed over to its tracker, as
+// the tracker can't guess the resource of kinds ending in "s".
+func newRouteWeightsClient(t *testing.T, objects ...*unstructured.Unstructured) *fakedynamic.FakeDynamicClient {
+	client := fakedynamic.NewSimpleDynamicClient(scheme.Scheme)
+	for _, obj := range objects {
+		_, err := client.Resource(routeWeightsResource).Namespace(obj.GetNamespace()).
+			Create(obj, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error creating route weights: %s", err)
+		}
+	}
+	client.ClearActions()
+	return client
+}
+
+func buildRouteWeights(name string, spec, status map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(routeWeightsResource.GroupVersion().String())
+	obj.SetKind("RouteWeights")
+	obj.SetNamespace(shippertesting.TestNamespace)
+	obj.SetName(name)
+
+	unstructured.SetNestedMap(obj.Object, spec, "spec", "weights")
+	if status != nil {
+		unstructured.SetNestedMap(obj.Object, status, "status", "weights")
+	}
+
+	return obj
+}
@@ -0,0 +1,141 @@
+package traffic
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// podsForServices returns the pods that are selected by every one of
+// services. The traffic status label is left out of their selectors, since
+// that is exactly the label we shift to have pods enter or leave them.
+func podsForServices(pods []*corev1.Pod, services []*corev1.Service) []*corev1.Pod {
+	selectors := make([]labels.Selector, 0, len(services))
+	for _, svc := range services {
+		selector := labels.Set{}
+		for k, v := range svc.Spec.Selector {
+			if k != shipper.PodTrafficStatusLabel {
+				selector[k] = v
+			}
+		}
+		selectors = append(selectors, selector.AsSelector())
+	}
+
+	selected := make([]*corev1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		matchesAll := true
+		for _, selector := range selectors {
+			if !selector.Matches(labels.Set(pod.Labels)) {
+				matchesAll = false
+				break
+			}
+		}
+
+		if matchesAll {
+			selected = append(selected, pod)
+		}
+	}
+
+	return selected
+}
+
+// mergeEndpoints combines the Endpoints of several services into a single
+// one, in which a pod is only ready if it is ready in all of them. A pod
+// ready for one service but not (yet) for another one isn't serving all of
+// the traffic it's meant to, so it's listed as not ready instead.
+func mergeEndpoints(endpointsList []*corev1.Endpoints) *corev1.Endpoints {
+	if len(endpointsList) == 1 {
+		return endpointsList[0]
+	}
+
+	// A pod exposing several ports can appear in more than one subset
+	// of the same Endpoints, so readiness is first worked out for each
+	// of them on its own.
+	readyCount := make(map[string]int)
+	addresses := make(map[string]corev1.EndpointAddress)
+	var podNames []string
+
+	for _, endpoints := range endpointsList {
+		ready := make(map[string]bool)
+		for _, subset := range endpoints.Subsets {
+			for _, address := range subset.Addresses {
+				podNames = seeAddress(addresses, podNames, address)
+				if address.TargetRef != nil {
+					if _, ok := ready[address.TargetRef.Name]; !ok {
+						ready[address.TargetRef.Name] = true
+					}
+				}
+			}
+
+			for _, address := range subset.NotReadyAddresses {
+				podNames = seeAddress(addresses, podNames, address)
+				if address.TargetRef != nil {
+					ready[address.TargetRef.Name] = false
+				}
+			}
+		}
+
+		for name, isReady := range ready {
+			if isReady {
+				readyCount[name]++
+			}
+		}
+	}
+
+	subset := corev1.EndpointSubset{}
+	for _, name := range podNames {
+		if readyCount[name] == len(endpointsList) {
+			subset.Addresses = append(subset.Addresses, addresses[name])
+		} else {
+			subset.NotReadyAddresses = append(subset.NotReadyAddresses, addresses[name])
+		}
+	}
+
+	merged := endpointsList[0].DeepCopy()
+	merged.Subsets = []corev1.EndpointSubset{subset}
+
+	return merged
+}
+
+// seeAddress keeps track of the first address seen for every pod, in the
+// order pods are first seen in.
+func seeAddress(
+	addresses map[string]corev1.EndpointAddress,
+	podNames []string,
+	address corev1.EndpointAddress,
+) []string {
+	if address.TargetRef == nil {
+		return podNames
+	}
+
+	name := address.TargetRef.Name
+	if _, ok := addresses[name]; !ok {
+		addresses[name] = address
+		podNames = append(podNames, name)
+	}
+
+	return podNames
+}
+
+// ServingPods returns the pods that are selected by trafficSelector, the
+// selector of the Service traffic goes through, and labeled to receive
+// traffic, that is, the pods actually behind the Service.
+func ServingPods(pods []*corev1.Pod, trafficSelector map[string]string) []*corev1.Pod {
+	set := labels.Set{}
+	for k, v := range trafficSelector {
+		if k != shipper.PodTrafficStatusLabel {
+			set[k] = v
+		}
+	}
+	selector := set.AsSelector()
+
+	serving := make([]*corev1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if selector.Matches(labels.Set(pod.Labels)) && getsTraffic(pod) {
+			serving = append(serving, pod)
+		}
+	}
+
+	return serving
+}
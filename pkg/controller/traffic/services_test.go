@@ -0,0 +1,101 @@
+package traffic
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+)
+
+func TestPodsForServices(t *testing.T) {
+	pods := buildPods(shippertesting.TestApp, "foobar", 3, noTraffic)
+	pods[0].Labels["tier"] = "web"
+	pods[1].Labels["tier"] = "web"
+	pods[1].Labels["metrics"] = "true"
+
+	web := buildService(shippertesting.TestApp)
+	web.Spec.Selector["tier"] = "web"
+
+	metrics := buildService(shippertesting.TestApp)
+	metrics.Spec.Selector["metrics"] = "true"
+
+	// Pods don't need to have traffic enabled yet to be part of
+	// services selecting on it.
+	selected := podsForServices(pods, []*corev1.Service{web})
+	if len(selected) != 2 {
+		t.Errorf("expected 2 pods selected by a single service, got %d", len(selected))
+	}
+
+	selected = podsForServices(pods, []*corev1.Service{web, metrics})
+	if len(selected) != 1 || selected[0] != pods[1] {
+		t.Errorf("expected only pod %q to be selected by both services, got %d pods", pods[1].Name, len(selected))
+	}
+}
+
+func TestServingPods(t *testing.T) {
+	serving := buildPods(shippertesting.TestApp, "foobar", 3, withTraffic)
+	idle := buildPods(shippertesting.TestApp, "foobar", 2, noTraffic)
+	otherApp := buildPods("another-app", "foobaz", 2, withTraffic)
+
+	var pods []*corev1.Pod
+	for i := range idle {
+		pods = append(pods, serving[i], idle[i])
+	}
+	pods = append(pods, serving[2])
+	pods = append(pods, otherApp...)
+
+	selected := ServingPods(pods, buildService(shippertesting.TestApp).Spec.Selector)
+	if len(selected) != len(serving) {
+		t.Fatalf("expected %d serving pods, got %d", len(serving), len(selected))
+	}
+
+	for i, pod := range selected {
+		if pod != serving[i] {
+			t.Errorf("expected pod %q to be serving, got %q", serving[i].Name, pod.Name)
+		}
+	}
+}
+
+func TestMergeEndpoints(t *testing.T) {
+	pods := buildPods(shippertesting.TestApp, "foobar", 3, withTraffic)
+
+	a := buildEndpoints(shippertesting.TestApp)
+	for _, pod := range pods {
+		a = shiftPodInEndpoints(pod, a)
+	}
+
+	b := buildEndpoints(shippertesting.TestApp)
+	b = shiftPodInEndpoints(pods[0], b)
+	pods[1].Labels[podReadinessLabel] = podNotReady
+	b = shiftPodInEndpoints(pods[1], b)
+
+	merged := mergeEndpoints([]*corev1.Endpoints{a, b})
+
+	readiness := make(map[string]bool)
+	for _, subset := range merged.Subsets {
+		markAddressReadiness(readiness, subset.Addresses, true)
+		markAddressReadiness(readiness, subset.NotReadyAddresses, false)
+	}
+
+	expected := map[string]bool{
+		pods[0].Name: true,
+		pods[1].Name: false,
+		pods[2].Name: false,
+	}
+
+	for name, ready := range expected {
+		if actual, ok := readiness[name]; !ok || actual != ready {
+			t.Errorf("expected pod %q to have readiness %t, got %t (present: %t)", name, ready, actual, ok)
+		}
+	}
+
+	if single := mergeEndpoints([]*corev1.Endpoints{a}); single != a {
+		t.Errorf("expected a single Endpoints to be returned as is")
+	}
+
+	if len(merged.Labels) == 0 || merged.Labels[shipper.AppLabel] != shippertesting.TestApp {
+		t.Errorf("expected merged Endpoints to keep the metadata of the first one")
+	}
+}
@@ -0,0 +1,101 @@
+package traffic
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/flowcontrol"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+// ShiftPolicy decides how the controller is allowed to get a pod to start
+// or stop receiving traffic.
+type ShiftPolicy string
+
+const (
+	// ShiftPolicyRelabel only ever patches the traffic label of pods.
+	ShiftPolicyRelabel ShiftPolicy = "relabel"
+
+	// ShiftPolicyRecreate deletes pods that need to start receiving
+	// traffic instead of relabeling them, so they come back from their
+	// controller with whatever configuration is current. Their
+	// replacements show up without a traffic label and are labeled to
+	// receive traffic, just like any other pod missing one.
+	ShiftPolicyRecreate ShiftPolicy = "recreate"
+)
+
+// ShiftAction is what has to happen to a single pod for it to end up with
+// the traffic label it should have.
+type ShiftAction int
+
+const (
+	ShiftRelabel ShiftAction = iota
+	ShiftRecreate
+)
+
+// ShiftMethod returns how pod should be shifted to get desiredTraffic
+// according to policy. Pods are only ever recreated if policy allows for
+// it, they are meant to start receiving traffic, and they have a
+// controller that will bring them back. Everything else is relabeled:
+// recreating a pod that should stop receiving traffic would get its
+// replacement labeled to receive it.
+func ShiftMethod(pod *corev1.Pod, desiredTraffic bool, policy ShiftPolicy) ShiftAction {
+	if policy != ShiftPolicyRecreate || !desiredTraffic {
+		return ShiftRelabel
+	}
+
+	if pod.DeletionTimestamp != nil || metav1.GetControllerOf(pod) == nil {
+		return ShiftRelabel
+	}
+
+	return ShiftRecreate
+}
+
+// recreatePods deletes the pods in podsToShift that ShiftMethod says
+// should be recreated, and returns the ones left to be relabeled, along
+// with the pods it deleted. Every delete draws from budget first, giving up
+// once ctx is done.
+func recreatePods(
+	ctx context.Context,
+	clientset kubernetes.Interface,
+	budget flowcontrol.RateLimiter,
+	podsToShift map[string][]*corev1.Pod,
+	policy ShiftPolicy,
+) (map[string][]*corev1.Pod, []*corev1.Pod, error) {
+	if policy != ShiftPolicyRecreate {
+		return podsToShift, nil, nil
+	}
+
+	toRelabel := make(map[string][]*corev1.Pod, len(podsToShift))
+	var recreated []*corev1.Pod
+	for value, pods := range podsToShift {
+		for _, pod := range pods {
+			if ShiftMethod(pod, value == shipper.Enabled, policy) == ShiftRelabel {
+				toRelabel[value] = append(toRelabel[value], pod)
+				continue
+			}
+
+			if err := budget.Wait(ctx); err != nil {
+				return toRelabel, recreated, shippererrors.
+					NewKubeclientDeleteError(pod.Namespace, pod.Name, err).
+					WithCoreV1Kind("Pod")
+			}
+
+			err := clientset.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{})
+			if err != nil && !kerrors.IsNotFound(err) {
+				return toRelabel, recreated, shippererrors.
+					NewKubeclientDeleteError(pod.Namespace, pod.Name, err).
+					WithCoreV1Kind("Pod")
+			}
+
+			recreated = append(recreated, pod)
+		}
+	}
+
+	return toRelabel, recreated, nil
+}
@@ -0,0 +1,104 @@
+package traffic
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippercontroller "github.com/bookingcom/shipper/pkg/controller"
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+)
+
+func controlledPod(name string, labels map[string]string) *corev1.Pod {
+	p := pod(name, labels)
+	controller := true
+	p.OwnerReferences = []metav1.OwnerReference{
+		{
+			APIVersion: "apps/v1",
+			Kind:       "ReplicaSet",
+			Name:       "test-replicaset",
+			Controller: &controller,
+		},
+	}
+	return p
+}
+
+func TestShiftMethod(t *testing.T) {
+	lbl := shipper.PodTrafficStatusLabel
+	disabled := map[string]string{lbl: shipper.Disabled}
+
+	deleting := controlledPod("deleting", disabled)
+	now := metav1.Now()
+	deleting.DeletionTimestamp = &now
+
+	tests := []struct {
+		name           string
+		pod            *corev1.Pod
+		desiredTraffic bool
+		policy         ShiftPolicy
+		expected       ShiftAction
+	}{
+		{"relabel policy", controlledPod("pod", disabled), true, ShiftPolicyRelabel, ShiftRelabel},
+		{"unknown policy", controlledPod("pod", disabled), true, ShiftPolicy("bogus"), ShiftRelabel},
+		{"recreate to enable", controlledPod("pod", disabled), true, ShiftPolicyRecreate, ShiftRecreate},
+		{"recreate to disable", controlledPod("pod", map[string]string{lbl: shipper.Enabled}), false, ShiftPolicyRecreate, ShiftRelabel},
+		{"recreate without controller", pod("pod", disabled), true, ShiftPolicyRecreate, ShiftRelabel},
+		{"recreate while deleting", deleting, true, ShiftPolicyRecreate, ShiftRelabel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := ShiftMethod(tt.pod, tt.desiredTraffic, tt.policy); actual != tt.expected {
+				t.Errorf("expected shift action %d, got %d", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRecreatePods(t *testing.T) {
+	lbl := shipper.PodTrafficStatusLabel
+	podsToShift := map[string][]*corev1.Pod{
+		shipper.Enabled: {
+			controlledPod("controlled-to-enabled", map[string]string{lbl: shipper.Disabled}),
+			pod("bare-to-enabled", map[string]string{lbl: shipper.Disabled}),
+		},
+		shipper.Disabled: {
+			controlledPod("controlled-to-disabled", map[string]string{lbl: shipper.Enabled}),
+		},
+	}
+
+	clientset := kubefake.NewSimpleClientset()
+	tracker := clientset.Tracker()
+	for _, pods := range podsToShift {
+		for _, pod := range pods {
+			tracker.Add(pod)
+		}
+	}
+
+	toRelabel, recreated, err := recreatePods(context.Background(), clientset, shippercontroller.NewWriteBudget(0, 0), podsToShift, ShiftPolicyRecreate)
+	if err != nil {
+		t.Fatalf("unable to recreate pods: %s", err)
+	}
+
+	if len(recreated) != 1 || recreated[0].Name != "controlled-to-enabled" {
+		t.Errorf("expected only controlled-to-enabled to be recreated, got %v", recreated)
+	}
+
+	gvr := corev1.SchemeGroupVersion.WithResource("pods")
+	if _, err := tracker.Get(gvr, shippertesting.TestNamespace, "controlled-to-enabled"); !kerrors.IsNotFound(err) {
+		t.Errorf("expected controlled-to-enabled to be deleted, got %v", err)
+	}
+
+	if len(toRelabel[shipper.Enabled]) != 1 || toRelabel[shipper.Enabled][0].Name != "bare-to-enabled" {
+		t.Errorf("expected bare-to-enabled to be left to relabel, got %v", toRelabel[shipper.Enabled])
+	}
+
+	if len(toRelabel[shipper.Disabled]) != 1 || toRelabel[shipper.Disabled][0].Name != "controlled-to-disabled" {
+		t.Errorf("expected controlled-to-disabled to be left to relabel, got %v", toRelabel[shipper.Disabled])
+	}
+}
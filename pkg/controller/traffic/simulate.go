@@ -0,0 +1,140 @@
+package traffic
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+// SimulateTrafficShift runs trafficTargets through the same weight math the
+// traffic controller uses, without talking to any cluster. The application
+// is assumed to have podCountsPerCluster[cluster][release] pods, none of
+// them receiving traffic yet, and all of them becoming ready as soon as
+// they're labeled for it.
+//
+// It returns the weight each release would achieve in each cluster once
+// traffic shifting is done, keyed by cluster and release name. Clusters
+// that can't be simulated are left out of the result, and the reason is
+// returned in the list of errors.
+func SimulateTrafficShift(
+	trafficTargets []*shipper.TrafficTarget,
+	podCountsPerCluster map[string]map[string]int,
+) (map[string]map[string]uint32, []error) {
+	var appName string
+	for _, tt := range trafficTargets {
+		app, ok := tt.Labels[shipper.AppLabel]
+		if !ok {
+			return nil, []error{shippererrors.NewMissingShipperLabelError(tt, shipper.AppLabel)}
+		}
+
+		if appName != "" && app != appName {
+			return nil, []error{fmt.Errorf(
+				"traffic targets belong to more than one application: %q and %q",
+				appName, app)}
+		}
+		appName = app
+	}
+
+	clusterReleaseWeights, err := buildClusterReleaseWeights(trafficTargets, noTrafficWeightFallback)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	clusters := make([]string, 0, len(clusterReleaseWeights))
+	for cluster := range clusterReleaseWeights {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+
+	var errs []error
+	achievedWeights := make(map[string]map[string]uint32)
+	for _, cluster := range clusters {
+		podCounts, ok := podCountsPerCluster[cluster]
+		if !ok {
+			errs = append(errs, fmt.Errorf("no pod counts for cluster %q", cluster))
+			continue
+		}
+
+		releases := make([]string, 0, len(clusterReleaseWeights[cluster]))
+		for release := range clusterReleaseWeights[cluster] {
+			releases = append(releases, release)
+		}
+		sort.Strings(releases)
+
+		pods := simulatedPods(appName, podCounts)
+
+		// Nothing is ready before any pods get labeled, so this
+		// first pass only tells us which pods to label.
+		endpoints := &corev1.Endpoints{}
+		for _, release := range releases {
+			status := buildTrafficShiftingStatus(
+				cluster, appName, release, clusterReleaseWeights,
+				endpoints, pods, 0, nil, SelectionPolicyInOrder, nil, 0)
+
+			for _, pod := range status.podsToShift[shipper.Enabled] {
+				pod.Labels[shipper.PodTrafficStatusLabel] = shipper.Enabled
+			}
+		}
+
+		endpoints = simulatedEndpoints(pods)
+		achievedWeights[cluster] = make(map[string]uint32)
+		for _, release := range releases {
+			status := buildTrafficShiftingStatus(
+				cluster, appName, release, clusterReleaseWeights,
+				endpoints, pods, 0, nil, SelectionPolicyInOrder, nil, 0)
+
+			achievedWeights[cluster][release] = status.achievedTrafficWeight
+		}
+	}
+
+	return achievedWeights, errs
+}
+
+func simulatedPods(appName string, podCounts map[string]int) []*corev1.Pod {
+	var pods []*corev1.Pod
+	for release, count := range podCounts {
+		for i := 0; i < count; i++ {
+			pods = append(pods, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: fmt.Sprintf("%s-%d", release, i),
+					Labels: map[string]string{
+						shipper.AppLabel:              appName,
+						shipper.ReleaseLabel:          release,
+						shipper.PodTrafficStatusLabel: shipper.Disabled,
+					},
+				},
+			})
+		}
+	}
+
+	return pods
+}
+
+// simulatedEndpoints returns the Endpoints object we'd get if every pod
+// labeled to receive traffic was ready.
+func simulatedEndpoints(pods []*corev1.Pod) *corev1.Endpoints {
+	var addresses []corev1.EndpointAddress
+	for _, pod := range pods {
+		if !getsTraffic(pod) {
+			continue
+		}
+
+		addresses = append(addresses, corev1.EndpointAddress{
+			TargetRef: &corev1.ObjectReference{
+				Kind: "Pod",
+				Name: pod.Name,
+			},
+		})
+	}
+
+	return &corev1.Endpoints{
+		Subsets: []corev1.EndpointSubset{
+			{Addresses: addresses},
+		},
+	}
+}
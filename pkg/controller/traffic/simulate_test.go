@@ -0,0 +1,151 @@
+package traffic
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+)
+
+// TestSimulateTrafficShiftMatchesController verifies that the weights
+// SimulateTrafficShift comes up with are the same ones the traffic
+// controller reports once it's done shifting traffic around.
+func TestSimulateTrafficShiftMatchesController(t *testing.T) {
+	tests := []struct {
+		name      string
+		weights   map[string]map[string]uint32
+		podCounts map[string]map[string]int
+	}{
+		{
+			name: "single release",
+			weights: map[string]map[string]uint32{
+				"foobar": {clusterA: 10},
+			},
+			podCounts: map[string]map[string]int{
+				clusterA: {"foobar": 3},
+			},
+		},
+		{
+			name: "not enough capacity for the desired split",
+			weights: map[string]map[string]uint32{
+				"foobar-a": {clusterA: 60},
+				"foobar-b": {clusterA: 40},
+			},
+			podCounts: map[string]map[string]int{
+				clusterA: {"foobar-a": 5, "foobar-b": 5},
+			},
+		},
+		{
+			name: "uneven pods across clusters",
+			weights: map[string]map[string]uint32{
+				"foobar-a": {clusterA: 90, clusterB: 50},
+				"foobar-b": {clusterA: 10, clusterB: 50},
+			},
+			podCounts: map[string]map[string]int{
+				clusterA: {"foobar-a": 7, "foobar-b": 3},
+				clusterB: {"foobar-a": 4, "foobar-b": 4},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trafficTargets := make([]*shipper.TrafficTarget, 0, len(tt.weights))
+			for release, clusterWeights := range tt.weights {
+				trafficTargets = append(trafficTargets,
+					buildTrafficTarget(shippertesting.TestApp, release, clusterWeights))
+			}
+
+			simulated, errs := SimulateTrafficShift(trafficTargets, tt.podCounts)
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors simulating traffic shift: %v", errs)
+			}
+
+			actual := runControllerForAchievedWeights(t, trafficTargets, tt.podCounts)
+
+			eq, diff := shippertesting.DeepEqualDiff(actual, simulated)
+			if !eq {
+				t.Errorf("simulated weights differ from the ones achieved by the controller:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSimulateTrafficShiftMissingPodCounts(t *testing.T) {
+	trafficTargets := []*shipper.TrafficTarget{
+		buildTrafficTarget(shippertesting.TestApp, "foobar",
+			map[string]uint32{clusterA: 10, clusterB: 10}),
+	}
+
+	simulated, errs := SimulateTrafficShift(trafficTargets, map[string]map[string]int{
+		clusterA: {"foobar": 1},
+	})
+
+	if len(errs) != 1 {
+		t.Fatalf("expected exactly one error, got %v", errs)
+	}
+
+	if _, ok := simulated[clusterB]; ok {
+		t.Errorf("expected cluster %q to be left out of the simulation", clusterB)
+	}
+
+	if simulated[clusterA]["foobar"] != 10 {
+		t.Errorf("expected release to achieve weight 10 in cluster %q, got %d",
+			clusterA, simulated[clusterA]["foobar"])
+	}
+}
+
+// runControllerForAchievedWeights runs the traffic controller against a
+// world with podCounts pods per release, none of which receives traffic
+// to begin with, and returns the weights reported in the status of each
+// traffic target.
+func runControllerForAchievedWeights(
+	t *testing.T,
+	trafficTargets []*shipper.TrafficTarget,
+	podCounts map[string]map[string]int,
+) map[string]map[string]uint32 {
+	f := shippertesting.NewControllerTestFixture()
+
+	for clusterName, releasePods := range podCounts {
+		objects := []runtime.Object{
+			buildService(shippertesting.TestApp),
+			buildEndpoints(shippertesting.TestApp),
+		}
+		for release, count := range releasePods {
+			objects = addPodsToList(objects,
+				buildPods(shippertesting.TestApp, release, count, noTraffic))
+		}
+
+		cluster := addCluster(f, clusterName)
+		cluster.AddMany(objects)
+	}
+
+	for _, tt := range trafficTargets {
+		f.ShipperClient.Tracker().Add(tt)
+	}
+
+	runController(f)
+
+	ttGVR := shipper.SchemeGroupVersion.WithResource("traffictargets")
+	achieved := make(map[string]map[string]uint32)
+	for _, initialTT := range trafficTargets {
+		object, err := f.ShipperClient.Tracker().Get(ttGVR, initialTT.Namespace, initialTT.Name)
+		if err != nil {
+			t.Fatalf("could not Get TrafficTarget %q: %s", initialTT.Name, err)
+		}
+
+		tt := object.(*shipper.TrafficTarget)
+		for _, clusterStatus := range tt.Status.Clusters {
+			weights, ok := achieved[clusterStatus.Name]
+			if !ok {
+				weights = make(map[string]uint32)
+				achieved[clusterStatus.Name] = weights
+			}
+			weights[tt.Labels[shipper.ReleaseLabel]] = clusterStatus.AchievedTraffic
+		}
+	}
+
+	return achieved
+}
@@ -0,0 +1,88 @@
+package traffic
+
+import (
+	"sort"
+	"sync"
+
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// PodInformer is what syncing a cluster needs to know about the pods in
+// it. The pod informers of the cluster client store satisfy it.
+type PodInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() corev1listers.PodLister
+}
+
+// ClusterSyncFunc syncs a traffic target on the application cluster named
+// clusterName, using its clientset and pod informer, and returns the
+// traffic weight achieved there by each release.
+type ClusterSyncFunc func(clusterName string, client kubernetes.Interface, informer PodInformer) (map[string]uint32, error)
+
+// ClusterSyncer fans SyncCluster out to a number of application clusters.
+type ClusterSyncer struct {
+	SyncCluster ClusterSyncFunc
+
+	// MaxConcurrency caps how many clusters are synced at the same time.
+	// Anything below 1 syncs them one at a time.
+	MaxConcurrency int
+}
+
+// SyncAllClusters runs SyncCluster for every cluster in clients, with the
+// pod informer informers has for it, if any. Clusters are synced
+// concurrently, so a single slow cluster doesn't hold up all the others,
+// and independently: a cluster that fails to sync is left out of the
+// achieved weights, keyed by cluster name, and has its error returned
+// instead, while every other cluster still gets synced. Errors are sorted
+// by the name of the cluster they come from.
+func (s ClusterSyncer) SyncAllClusters(
+	clients map[string]kubernetes.Interface,
+	informers map[string]PodInformer,
+) (map[string]map[string]uint32, []error) {
+	clusterNames := make([]string, 0, len(clients))
+	for clusterName := range clients {
+		clusterNames = append(clusterNames, clusterName)
+	}
+	sort.Strings(clusterNames)
+
+	maxConcurrency := s.MaxConcurrency
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+
+	weights := make([]map[string]uint32, len(clusterNames))
+	errs := make([]error, len(clusterNames))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrency)
+
+	for i, clusterName := range clusterNames {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, clusterName string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			weights[i], errs[i] = s.SyncCluster(clusterName, clients[clusterName], informers[clusterName])
+		}(i, clusterName)
+	}
+
+	wg.Wait()
+
+	achieved := make(map[string]map[string]uint32, len(clusterNames))
+	var clusterErrs []error
+	for i, clusterName := range clusterNames {
+		if errs[i] != nil {
+			clusterErrs = append(clusterErrs, errs[i])
+			continue
+		}
+
+		achieved[clusterName] = weights[i]
+	}
+
+	return achieved, clusterErrs
+}
@@ -0,0 +1,93 @@
+package traffic
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/labels"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+)
+
+// TestSyncAllClusters verifies that SyncAllClusters syncs every cluster
+// with its own clientset and pod informer, aggregates what was achieved in
+// each of them, and keeps a cluster that fails to sync from affecting the
+// others.
+func TestSyncAllClusters(t *testing.T) {
+	const (
+		clusterC       = "cluster-c"
+		syncErrCluster = clusterB
+	)
+
+	podCounts := map[string]int{clusterA: 3, clusterB: 2, clusterC: 1}
+
+	clients := make(map[string]kubernetes.Interface)
+	informers := make(map[string]PodInformer)
+	clusterFor := make(map[kubernetes.Interface]string)
+	for clusterName, podCount := range podCounts {
+		client := kubefake.NewSimpleClientset()
+		informer := kubeinformers.NewSharedInformerFactory(client, 0).Core().V1().Pods()
+		for _, pod := range buildPods(shippertesting.TestApp, ttName, podCount, withTraffic) {
+			if err := informer.Informer().GetIndexer().Add(pod); err != nil {
+				t.Fatalf("unexpected error adding pod to informer: %s", err)
+			}
+		}
+
+		clients[clusterName] = client
+		informers[clusterName] = informer
+		clusterFor[client] = clusterName
+	}
+
+	var mu sync.Mutex
+	var synced []string
+
+	syncer := ClusterSyncer{
+		MaxConcurrency: 2,
+		SyncCluster: func(clusterName string, client kubernetes.Interface, informer PodInformer) (map[string]uint32, error) {
+			mu.Lock()
+			synced = append(synced, clusterName)
+			mu.Unlock()
+
+			if clusterFor[client] != clusterName {
+				return nil, fmt.Errorf("cluster %q got the clientset of %q", clusterName, clusterFor[client])
+			}
+
+			if clusterName == syncErrCluster {
+				return nil, fmt.Errorf("cluster %q is unreachable", clusterName)
+			}
+
+			selector := labels.Set{shipper.PodTrafficStatusLabel: shipper.Enabled}.AsSelector()
+			pods, err := informer.Lister().Pods(shippertesting.TestNamespace).List(selector)
+			if err != nil {
+				return nil, err
+			}
+
+			return map[string]uint32{ttName: uint32(len(pods))}, nil
+		},
+	}
+
+	achieved, errs := syncer.SyncAllClusters(clients, informers)
+
+	if len(synced) != len(podCounts) {
+		t.Fatalf("expected all %d clusters to be synced, got %v", len(podCounts), synced)
+	}
+
+	expected := map[string]map[string]uint32{
+		clusterA: {ttName: 3},
+		clusterC: {ttName: 1},
+	}
+	if !reflect.DeepEqual(achieved, expected) {
+		t.Errorf("expected achieved weights %v, got %v", expected, achieved)
+	}
+
+	expectedErr := fmt.Sprintf("cluster %q is unreachable", syncErrCluster)
+	if len(errs) != 1 || errs[0].Error() != expectedErr {
+		t.Errorf("expected only error %q, got %v", expectedErr, errs)
+	}
+}
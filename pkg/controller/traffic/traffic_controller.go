@@ -1,6 +1,7 @@
 package traffic
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sort"
@@ -14,8 +15,10 @@ import (
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 
@@ -26,9 +29,11 @@ import (
 	"github.com/bookingcom/shipper/pkg/clusterclientstore"
 	shippercontroller "github.com/bookingcom/shipper/pkg/controller"
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+	apputil "github.com/bookingcom/shipper/pkg/util/application"
 	clusterstatusutil "github.com/bookingcom/shipper/pkg/util/clusterstatus"
 	diffutil "github.com/bookingcom/shipper/pkg/util/diff"
 	"github.com/bookingcom/shipper/pkg/util/filters"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
 	targetutil "github.com/bookingcom/shipper/pkg/util/target"
 	trafficutil "github.com/bookingcom/shipper/pkg/util/traffic"
 	shipperworkqueue "github.com/bookingcom/shipper/pkg/workqueue"
@@ -37,24 +42,128 @@ import (
 const (
 	AgentName = "traffic-controller"
 
-	ClustersNotReady   = "ClustersNotReady"
-	InProgress         = "InProgress"
-	InternalError      = "InternalError"
-	PodsNotInEndpoints = "PodsNotInEndpoints"
-	PodsNotReady       = "PodsNotReady"
+	ClustersNotReady     = "ClustersNotReady"
+	InProgress           = "InProgress"
+	InternalError        = "InternalError"
+	PodsNotInEndpoints   = "PodsNotInEndpoints"
+	PodsNotReady         = "PodsNotReady"
+	TrafficLabelFlapping = "TrafficLabelFlapping"
+	UnknownCluster       = "UnknownCluster"
+	WaitingForReadiness  = "WaitingForReadiness"
 
 	TrafficTargetConditionChanged  = "TrafficTargetConditionChanged"
 	ClusterTrafficConditionChanged = "ClusterTrafficConditionChanged"
+	ConflictingTrafficManager      = "ConflictingTrafficManager"
+	ProductionServiceError         = "ProductionServiceError"
+	PodTrafficLabelRepaired        = "PodTrafficLabelRepaired"
+	PodsRecreatedForTraffic        = "PodsRecreatedForTraffic"
+	MultiReleasePod                = "MultiReleasePod"
+	NewClusterJoined               = "NewClusterJoined"
+
+	// maxConcurrentClusterSyncs caps how many clusters a single traffic
+	// target is processed on at the same time.
+	maxConcurrentClusterSyncs = 8
 )
 
 // Controller is the controller implementation for TrafficTarget resources.
 type Controller struct {
 	shipperclientset     shipperclient.Interface
 	clusterClientStore   clusterclientstore.Interface
+	applicationsLister   listers.ApplicationLister
+	applicationsSynced   cache.InformerSynced
+	clustersLister       listers.ClusterLister
+	clustersSynced       cache.InformerSynced
+	releasesLister       listers.ReleaseLister
+	releasesSynced       cache.InformerSynced
 	trafficTargetsLister listers.TrafficTargetLister
 	trafficTargetsSynced cache.InformerSynced
 	workqueue            workqueue.RateLimitingInterface
 	recorder             record.EventRecorder
+	decisionLog          DecisionLog
+	minServingPods       int
+
+	excludeUnhealthyNodes bool
+	selectionPolicy       SelectionPolicy
+	podScorer             PodScorer
+	rejectUnknownClusters bool
+	shiftPolicy           ShiftPolicy
+	trafficShifter        TrafficShifter
+	drainer               *clusterDrainer
+
+	labelConflicts *labelConflictDetector
+
+	multiReleasePods *shippercontroller.OnceReporter
+	unknownClusters  *shippercontroller.OnceReporter
+
+	divergence *divergenceTracker
+
+	readinessWaits *readinessWaitTracker
+
+	// writeBudget is drawn from before every write, to traffic targets
+	// and pods alike.
+	// It's shared with the other controllers in the process, so together
+	// they don't overwhelm the API server.
+	writeBudget flowcontrol.RateLimiter
+}
+
+// Config holds the knobs of a traffic controller. Its zero value is a
+// controller that shifts traffic by relabeling pods, with every optional
+// behaviour disabled.
+type Config struct {
+	// DecisionLog gets every traffic shifting decision. Decisions are
+	// thrown away when it's nil.
+	DecisionLog DecisionLog
+
+	// MinServingPods is the least number of pods a release with any
+	// weight in a cluster keeps receiving traffic.
+	MinServingPods int
+
+	// ExcludeUnhealthyNodes keeps pods on unhealthy nodes out of
+	// traffic.
+	ExcludeUnhealthyNodes bool
+
+	// PreserveNodeSpread picks the pods to shift so they stay spread
+	// across nodes.
+	PreserveNodeSpread bool
+
+	// SelectionPolicy decides which pod has its traffic label toggled
+	// next. PreserveNodeSpread decides when it's empty.
+	SelectionPolicy SelectionPolicy
+
+	// RejectUnknownClusters fails the syncs of traffic targets that
+	// mention clusters shipper doesn't know about. They're only warned
+	// about otherwise.
+	RejectUnknownClusters bool
+
+	// ShiftPolicy is how pods are made to start receiving traffic.
+	// ShiftPolicyRelabel is used when it's empty.
+	ShiftPolicy ShiftPolicy
+
+	// TrafficShifter, when set, shifts traffic instead of labeling pods.
+	TrafficShifter TrafficShifter
+
+	// MaxShiftPerSync is the most weight taken away from a release in a
+	// draining cluster on every sync. Clusters are drained in one go
+	// when it's 0.
+	MaxShiftPerSync uint32
+
+	// PodScorer rates the pods to shift. Every pod is rated the same
+	// when it's nil.
+	PodScorer PodScorer
+
+	// DivergenceThreshold is how long a traffic target can diverge from
+	// its requested weight before it's marked as diverging. Disabled
+	// when 0.
+	DivergenceThreshold time.Duration
+
+	// ReadinessWaitTimeout is the longest a cluster holds its weight
+	// until the pods labeled to receive traffic in it are ready.
+	// DefaultReadinessWaitTimeout is used when it's 0.
+	ReadinessWaitTimeout time.Duration
+
+	// WriteBudget is drawn from before every write. Writes aren't
+	// limited when it's nil.
+	WriteBudget flowcontrol.RateLimiter
 }
 
 // NewController returns a new TrafficTarget controller.
@@ -63,19 +172,63 @@ func NewController(
 	shipperInformerFactory informers.SharedInformerFactory,
 	store clusterclientstore.Interface,
 	recorder record.EventRecorder,
+	cfg Config,
 ) *Controller {
 
+	if cfg.DecisionLog == nil {
+		cfg.DecisionLog = NoopDecisionLog{}
+	}
+	if cfg.ShiftPolicy == "" {
+		cfg.ShiftPolicy = ShiftPolicyRelabel
+	}
+	if cfg.WriteBudget == nil {
+		cfg.WriteBudget = shippercontroller.NewWriteBudget(0, 0)
+	}
+
 	// Obtain references to shared index informers for the TrafficTarget type.
 	trafficTargetInformer := shipperInformerFactory.Shipper().V1alpha1().TrafficTargets()
+	applicationInformer := shipperInformerFactory.Shipper().V1alpha1().Applications()
+	clusterInformer := shipperInformerFactory.Shipper().V1alpha1().Clusters()
+	releaseInformer := shipperInformerFactory.Shipper().V1alpha1().Releases()
 
 	controller := &Controller{
 		shipperclientset:   shipperclientset,
 		clusterClientStore: store,
 
+		applicationsLister: applicationInformer.Lister(),
+		applicationsSynced: applicationInformer.Informer().HasSynced,
+
+		clustersLister: clusterInformer.Lister(),
+		clustersSynced: clusterInformer.Informer().HasSynced,
+
+		releasesLister: releaseInformer.Lister(),
+		releasesSynced: releaseInformer.Informer().HasSynced,
+
 		trafficTargetsLister: trafficTargetInformer.Lister(),
 		trafficTargetsSynced: trafficTargetInformer.Informer().HasSynced,
 		workqueue:            workqueue.NewNamedRateLimitingQueue(shipperworkqueue.NewDefaultControllerRateLimiter(), "traffic_controller_traffictargets"),
 		recorder:             recorder,
+		decisionLog:          cfg.DecisionLog,
+		minServingPods:       cfg.MinServingPods,
+
+		excludeUnhealthyNodes: cfg.ExcludeUnhealthyNodes,
+		selectionPolicy:       selectionPolicyFor(cfg.SelectionPolicy, cfg.PreserveNodeSpread),
+		podScorer:             cfg.PodScorer,
+		rejectUnknownClusters: cfg.RejectUnknownClusters,
+		shiftPolicy:           cfg.ShiftPolicy,
+		trafficShifter:        cfg.TrafficShifter,
+		drainer:               newClusterDrainer(cfg.MaxShiftPerSync),
+
+		labelConflicts: newLabelConflictDetector(labelConflictInterval),
+
+		multiReleasePods: shippercontroller.NewOnceReporter(),
+		unknownClusters:  shippercontroller.NewOnceReporter(),
+
+		divergence: newDivergenceTracker(cfg.DivergenceThreshold),
+
+		readinessWaits: newReadinessWaitTracker(cfg.ReadinessWaitTimeout),
+
+		writeBudget: cfg.WriteBudget,
 	}
 
 	klog.Info("Setting up event handlers")
@@ -87,6 +240,22 @@ func NewController(
 		DeleteFunc: controller.enqueueAllTrafficTargets,
 	})
 
+	// Traffic targets following their application's default traffic
+	// policy get their weights from their release's strategy.
+	releaseInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			controller.enqueueAllTrafficTargets(new)
+		},
+	})
+
+	clusterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.syncClusterDrain,
+		UpdateFunc: func(old, new interface{}) {
+			controller.syncClusterDrain(new)
+		},
+		DeleteFunc: controller.forgetClusterDrain,
+	})
+
 	store.AddSubscriptionCallback(controller.subscribeToAppClusterEvents)
 	store.AddEventHandlerCallback(controller.registerAppClusterEventHandlers)
 
@@ -120,12 +289,28 @@ func (c *Controller) registerAppClusterEventHandlers(informerFactory kubeinforme
 			DeleteFunc: c.enqueueTrafficTargetFromPod,
 		},
 	})
+
+	if c.excludeUnhealthyNodes {
+		informerFactory.Core().V1().Nodes().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				oldNode, oldOk := oldObj.(*corev1.Node)
+				newNode, newOk := newObj.(*corev1.Node)
+				if oldOk && newOk && nodeIsHealthy(oldNode) != nodeIsHealthy(newNode) {
+					c.enqueueEveryTrafficTarget()
+				}
+			},
+		})
+	}
 }
 
 func (c *Controller) subscribeToAppClusterEvents(informerFactory kubeinformers.SharedInformerFactory) {
 	informerFactory.Core().V1().Pods().Informer()
 	informerFactory.Core().V1().Services().Informer()
 	informerFactory.Core().V1().Endpoints().Informer()
+
+	if c.excludeUnhealthyNodes {
+		informerFactory.Core().V1().Nodes().Informer()
+	}
 }
 
 // Run will set up the event handlers for types we are interested in, as well as
@@ -139,13 +324,16 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) {
 	klog.V(2).Info("Starting Traffic controller")
 	defer klog.V(2).Info("Shutting down Traffic controller")
 
-	if ok := cache.WaitForCacheSync(stopCh, c.trafficTargetsSynced); !ok {
+	if ok := cache.WaitForCacheSync(stopCh, c.applicationsSynced, c.clustersSynced, c.releasesSynced, c.trafficTargetsSynced); !ok {
 		runtime.HandleError(fmt.Errorf("failed to wait for caches to sync"))
 		return
 	}
 
+	ctx, cancel := shippercontroller.WorkerContext(stopCh)
+	defer cancel()
+
 	for i := 0; i < threadiness; i++ {
-		go wait.Until(c.runWorker, time.Second, stopCh)
+		go wait.Until(func() { c.runWorker(ctx) }, time.Second, stopCh)
 	}
 
 	klog.V(4).Info("Started Traffic controller")
@@ -153,12 +341,12 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) {
 	<-stopCh
 }
 
-func (c *Controller) runWorker() {
-	for c.processNextWorkItem() {
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
 	}
 }
 
-func (c *Controller) processNextWorkItem() bool {
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 	obj, shutdown := c.workqueue.Get()
 	if shutdown {
 		return false
@@ -178,7 +366,7 @@ func (c *Controller) processNextWorkItem() bool {
 	}
 
 	shouldRetry := false
-	err := c.syncHandler(key)
+	err := c.syncHandler(ctx, key)
 
 	if err != nil {
 		shouldRetry = shippererrors.ShouldRetry(err)
@@ -197,7 +385,7 @@ func (c *Controller) processNextWorkItem() bool {
 	return true
 }
 
-func (c *Controller) syncHandler(key string) error {
+func (c *Controller) syncHandler(ctx context.Context, key string) error {
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
 		return shippererrors.NewUnrecoverableError(err)
@@ -207,6 +395,12 @@ func (c *Controller) syncHandler(key string) error {
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			klog.V(3).Infof("TrafficTarget %q has been deleted", key)
+			c.divergence.Forget(key)
+			c.readinessWaits.Forget(key)
+			c.multiReleasePods.Forget(key)
+			c.unknownClusters.Forget(key)
+			// Traffic targets are named after their release.
+			c.drainer.Forget(key)
 			return nil
 		}
 
@@ -214,9 +408,14 @@ func (c *Controller) syncHandler(key string) error {
 			WithShipperKind("TrafficTarget")
 	}
 
-	tt, err := c.processTrafficTarget(initialTT.DeepCopy())
+	tt, err := c.processTrafficTarget(ctx, initialTT.DeepCopy())
 
 	if !reflect.DeepEqual(initialTT, tt) {
+		if err := c.writeBudget.Wait(ctx); err != nil {
+			return shippererrors.NewKubeclientUpdateError(tt, err).
+				WithShipperKind("TrafficTarget")
+		}
+
 		if _, err := c.shipperclientset.ShipperV1alpha1().TrafficTargets(namespace).UpdateStatus(tt); err != nil {
 			return shippererrors.NewKubeclientUpdateError(tt, err).
 				WithShipperKind("TrafficTarget")
@@ -226,7 +425,7 @@ func (c *Controller) syncHandler(key string) error {
 	return err
 }
 
-func (c *Controller) processTrafficTarget(tt *shipper.TrafficTarget) (*shipper.TrafficTarget, error) {
+func (c *Controller) processTrafficTarget(ctx context.Context, tt *shipper.TrafficTarget) (*shipper.TrafficTarget, error) {
 	diff := diffutil.NewMultiDiff()
 	defer c.reportConditionChange(tt, TrafficTargetConditionChanged, diff)
 
@@ -251,7 +450,7 @@ func (c *Controller) processTrafficTarget(tt *shipper.TrafficTarget) (*shipper.T
 		return tt, err
 	}
 
-	clusterReleaseWeights, err := buildClusterReleaseWeights(allTTs)
+	clusterReleaseWeights, err := buildClusterReleaseWeights(allTTs, c.applicationTrafficWeightFallback)
 	if err != nil {
 		tt.Status.Conditions = targetutil.TransitionToNotOperational(
 			diff, tt.Status.Conditions,
@@ -259,37 +458,33 @@ func (c *Controller) processTrafficTarget(tt *shipper.TrafficTarget) (*shipper.T
 		return tt, err
 	}
 
-	tt.Status.Conditions = targetutil.TransitionToOperational(diff, tt.Status.Conditions)
-
-	clusterErrors := shippererrors.NewMultiError()
-	newClusterStatuses := make([]*shipper.ClusterTrafficStatus, 0, len(tt.Spec.Clusters))
-
-	// This algorithm assumes cluster names are unique
-	curClusterStatuses := make(map[string]*shipper.ClusterTrafficStatus)
-	for _, clusterStatus := range tt.Status.Clusters {
-		curClusterStatuses[clusterStatus.Name] = clusterStatus
+	if c.drainer.Apply(tt.Namespace, tt.Labels[shipper.ReleaseLabel], clusterReleaseWeights) {
+		// Nothing else might trigger another sync before the release
+		// is drained.
+		c.workqueue.AddAfter(shippercontroller.MetaKey(tt), DrainRequeueInterval)
 	}
 
-	for _, clusterSpec := range tt.Spec.Clusters {
-		clusterStatus, ok := curClusterStatuses[clusterSpec.Name]
-		if !ok {
-			clusterStatus = &shipper.ClusterTrafficStatus{
-				Name: clusterSpec.Name,
-			}
-		}
-
-		err := c.processTrafficTargetOnCluster(tt, &clusterSpec, clusterStatus, clusterReleaseWeights)
-		if err != nil {
-			clusterErrors.Append(err)
+	if err := c.checkClusterNames(tt); err != nil {
+		reason := UnknownCluster
+		if shippererrors.IsKubeclientError(err) {
+			reason = InternalError
 		}
 
-		newClusterStatuses = append(newClusterStatuses, clusterStatus)
+		tt.Status.Conditions = targetutil.TransitionToNotOperational(
+			diff, tt.Status.Conditions,
+			reason, err.Error())
+		return tt, err
 	}
 
+	tt.Status.Conditions = targetutil.TransitionToOperational(diff, tt.Status.Conditions)
+
+	newClusterStatuses, clusterErrors := c.processTrafficTargetOnClusters(ctx, tt, clusterReleaseWeights)
+
 	sort.Sort(byClusterName(newClusterStatuses))
 
 	tt.Status.Clusters = newClusterStatuses
 	tt.Status.ObservedGeneration = tt.Generation
+	tt.Status.ShiftProgress = shiftProgress(tt)
 
 	notReadyReasons := []string{}
 	for _, clusterStatus := range tt.Status.Clusters {
@@ -300,22 +495,142 @@ func (c *Controller) processTrafficTarget(tt *shipper.TrafficTarget) (*shipper.T
 		}
 	}
 
+	var readyCond shipper.TargetCondition
 	if len(notReadyReasons) == 0 {
-		tt.Status.Conditions = targetutil.TransitionToReady(diff, tt.Status.Conditions)
+		readyCond = targetutil.NewTargetCondition(
+			shipper.TrafficTargetConditionTypeReady,
+			corev1.ConditionTrue, "", "")
 	} else {
-		tt.Status.Conditions = targetutil.TransitionToNotReady(
-			diff, tt.Status.Conditions,
+		readyCond = targetutil.NewTargetCondition(
+			shipper.TrafficTargetConditionTypeReady,
+			corev1.ConditionFalse,
 			ClustersNotReady, strings.Join(notReadyReasons, "; "))
 	}
+	diff.Append(trafficutil.SetTrafficTargetCondition(&tt.Status, readyCond))
+
+	c.checkDivergence(shippercontroller.MetaKey(tt), tt, diff)
 
 	return tt, clusterErrors.Flatten()
 }
 
+// shiftProgress sums up the weight tt requests and the weight it achieved
+// across all of its clusters, and returns how far along it is.
+func shiftProgress(tt *shipper.TrafficTarget) int {
+	achieved, requested := weightDivergence(tt)
+	return trafficutil.ShiftProgress(achieved, requested)
+}
+
+// processTrafficTargetOnClusters runs processTrafficTargetOnCluster for every
+// cluster the traffic target is present in, through SyncAllClusters. Clusters
+// are processed concurrently, with at most maxConcurrentClusterSyncs of them
+// in flight at the same time, so a single slow cluster doesn't hold up
+// shifting traffic in all of the others. Each cluster only ever touches its
+// own status, and errors are collected per cluster so a failure in one of
+// them doesn't prevent the remaining ones from making progress.
+func (c *Controller) processTrafficTargetOnClusters(
+	ctx context.Context,
+	tt *shipper.TrafficTarget,
+	clusterReleaseWeights clusterReleaseWeights,
+) ([]*shipper.ClusterTrafficStatus, *shippererrors.MultiError) {
+	// This algorithm assumes cluster names are unique
+	curClusterStatuses := make(map[string]*shipper.ClusterTrafficStatus)
+	for _, clusterStatus := range tt.Status.Clusters {
+		curClusterStatuses[clusterStatus.Name] = clusterStatus
+	}
+
+	clusterSpecs := clusterTrafficTargetsFor(tt, clusterReleaseWeights)
+	newClusterStatuses := make([]*shipper.ClusterTrafficStatus, len(clusterSpecs))
+	specIndex := make(map[string]int, len(clusterSpecs))
+
+	clients := make(map[string]kubernetes.Interface, len(clusterSpecs))
+	informers := make(map[string]PodInformer, len(clusterSpecs))
+	clientErrs := make(map[string]error)
+
+	for i := range clusterSpecs {
+		clusterSpec := &clusterSpecs[i]
+		clusterStatus, ok := curClusterStatuses[clusterSpec.Name]
+		if !ok {
+			clusterStatus = &shipper.ClusterTrafficStatus{
+				Name: clusterSpec.Name,
+			}
+
+			// A cluster added to a traffic target that's already
+			// been shifting traffic elsewhere starts from nothing
+			// achieved, and gets ramped up to its weight just like
+			// the others.
+			if len(curClusterStatuses) > 0 {
+				c.recorder.Eventf(
+					tt,
+					corev1.EventTypeNormal,
+					NewClusterJoined,
+					"Cluster %q joined mid-rollout, shifting traffic for weight %d to it",
+					clusterSpec.Name, clusterSpec.Weight,
+				)
+			}
+		}
+		newClusterStatuses[i] = clusterStatus
+		specIndex[clusterSpec.Name] = i
+
+		// Clusters we can't talk to are still synced, so they get to
+		// report why in their own status.
+		clientset, podInformer, err := c.getClusterClients(clusterSpec.Name)
+		clients[clusterSpec.Name] = clientset
+		if podInformer != nil {
+			informers[clusterSpec.Name] = podInformer
+		}
+		if err != nil {
+			clientErrs[clusterSpec.Name] = err
+		}
+	}
+
+	releaseName := tt.Labels[shipper.ReleaseLabel]
+	syncer := ClusterSyncer{
+		MaxConcurrency: maxConcurrentClusterSyncs,
+		SyncCluster: func(clusterName string, client kubernetes.Interface, informer PodInformer) (map[string]uint32, error) {
+			i := specIndex[clusterName]
+			status := newClusterStatuses[i]
+			err := c.processTrafficTargetOnCluster(ctx, tt, &clusterSpecs[i], status, clusterReleaseWeights,
+				client, informer, clientErrs[clusterName])
+
+			return map[string]uint32{releaseName: status.AchievedTraffic}, err
+		},
+	}
+
+	_, errs := syncer.SyncAllClusters(clients, informers)
+
+	clusterErrors := shippererrors.NewMultiError()
+	for _, err := range errs {
+		clusterErrors.Append(err)
+	}
+
+	return newClusterStatuses, clusterErrors
+}
+
+// getClusterClients returns the clientset and the pod informer the traffic
+// controller uses for cluster, or why it can't have them.
+func (c *Controller) getClusterClients(cluster string) (kubernetes.Interface, PodInformer, error) {
+	clientset, err := c.clusterClientStore.GetClient(cluster, AgentName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	informerFactory, err := c.clusterClientStore.GetInformerFactory(cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return clientset, informerFactory.Core().V1().Pods(), nil
+}
+
 func (c *Controller) processTrafficTargetOnCluster(
+	ctx context.Context,
 	tt *shipper.TrafficTarget,
 	spec *shipper.ClusterTrafficTarget,
 	status *shipper.ClusterTrafficStatus,
 	clusterReleaseWeights clusterReleaseWeights,
+	clientset kubernetes.Interface,
+	podInformer PodInformer,
+	clientErr error,
 ) error {
 	diff := diffutil.NewMultiDiff()
 	operationalCond := trafficutil.NewClusterTrafficCondition(
@@ -338,22 +653,93 @@ func (c *Controller) processTrafficTargetOnCluster(
 		c.reportConditionChange(tt, ClusterTrafficConditionChanged, diff)
 	}()
 
-	clientset, err := c.clusterClientStore.GetClient(spec.Name, AgentName)
-	if err != nil {
+	if c.trafficShifter != nil {
+		weight := clusterReleaseWeights[spec.Name][tt.Labels[shipper.ReleaseLabel]]
+		achieved, ready, err := c.trafficShifter.Shift(ctx, tt, spec, weight)
+		achievedTraffic = achieved
+		if err != nil {
+			operationalCond = trafficutil.NewClusterTrafficCondition(
+				shipper.ClusterConditionTypeOperational,
+				corev1.ConditionFalse,
+				InternalError,
+				err.Error(),
+			)
+
+			return err
+		}
+
+		operationalCond = trafficutil.NewClusterTrafficCondition(
+			shipper.ClusterConditionTypeOperational,
+			corev1.ConditionTrue,
+			"",
+			"",
+		)
+
+		if ready {
+			readyCond = trafficutil.NewClusterTrafficCondition(
+				shipper.ClusterConditionTypeReady,
+				corev1.ConditionTrue,
+				"",
+				"",
+			)
+		} else {
+			readyCond = trafficutil.NewClusterTrafficCondition(
+				shipper.ClusterConditionTypeReady,
+				corev1.ConditionFalse,
+				InProgress,
+				fmt.Sprintf("%d/%d weight achieved", achieved, weight),
+			)
+
+			// Nothing tells us when whatever does the routing
+			// gets around to applying the weight, so we check
+			// back in a little while.
+			c.workqueue.AddAfter(shippercontroller.MetaKey(tt), TrafficShifterRequeueInterval)
+		}
+
+		return nil
+	}
+
+	if clientErr != nil {
 		operationalCond = trafficutil.NewClusterTrafficCondition(
 			shipper.ClusterConditionTypeOperational,
 			corev1.ConditionFalse,
 			InternalError,
-			err.Error(),
+			clientErr.Error(),
 		)
 
-		return err
+		return clientErr
 	}
 
 	appName := tt.Labels[shipper.AppLabel]
 	releaseName := tt.Labels[shipper.ReleaseLabel]
 
-	appPods, endpoints, err := c.getClusterObjects(spec.Name, tt.Namespace, appName)
+	// The pods of the application are read once before and once after
+	// working out the weight achieved from them, to tell whether the
+	// cache was stable enough in between for it to be trusted.
+	podsBefore, syncedBefore, errBefore := listAppPods(podInformer, tt.Namespace, appName)
+
+	serviceSelector, err := c.productionServiceSelector(tt)
+	if err != nil {
+		operationalCond = trafficutil.NewClusterTrafficCondition(
+			shipper.ClusterConditionTypeOperational,
+			corev1.ConditionFalse,
+			InternalError,
+			err.Error(),
+		)
+
+		return err
+	}
+
+	appPods, endpoints, serviceErrs, err := c.getClusterObjects(spec.Name, tt.Namespace, appName, serviceSelector)
+	for _, serviceErr := range serviceErrs {
+		c.recorder.Eventf(
+			tt,
+			corev1.EventTypeWarning,
+			ProductionServiceError,
+			"Ignoring a production service in cluster %q: %s",
+			spec.Name, serviceErr,
+		)
+	}
 	if err != nil {
 		operationalCond = trafficutil.NewClusterTrafficCondition(
 			shipper.ClusterConditionTypeOperational,
@@ -372,10 +758,68 @@ func (c *Controller) processTrafficTargetOnCluster(
 		"",
 	)
 
+	multiReleasePods := DetectMultiReleasePods(appPods)
+	claimedReleases := make(map[string]string, len(multiReleasePods))
+	for podName, releases := range multiReleasePods {
+		claimedReleases[podName] = strings.Join(releases, ",")
+	}
+	for _, podName := range c.multiReleasePods.Unreported(shippercontroller.MetaKey(tt), spec.Name, claimedReleases) {
+		c.recorder.Eventf(
+			tt,
+			corev1.EventTypeWarning,
+			MultiReleasePod,
+			"Pod %q in cluster %q is labeled as part of releases %s",
+			podName, spec.Name, strings.Join(multiReleasePods[podName], ", "),
+		)
+	}
+
+	var unhealthyNodes map[string]struct{}
+	if c.excludeUnhealthyNodes {
+		unhealthyNodes, err = c.getUnhealthyNodes(spec.Name)
+		if err != nil {
+			operationalCond = trafficutil.NewClusterTrafficCondition(
+				shipper.ClusterConditionTypeOperational,
+				corev1.ConditionFalse,
+				InternalError,
+				err.Error(),
+			)
+
+			return err
+		}
+	}
+
+	// Clusters that are not registered have already been warned about
+	// by checkClusterNames, so they just don't get a cap.
+	maxTrafficPods := 0
+	if cluster, err := c.clustersLister.Get(spec.Name); err == nil {
+		maxTrafficPods = int(cluster.Spec.MaxTrafficPods)
+	}
+
 	trafficStatus := buildTrafficShiftingStatus(
 		spec.Name, appName, releaseName,
 		clusterReleaseWeights,
-		endpoints, appPods)
+		endpoints, appPods,
+		c.minServingPods, unhealthyNodes, c.selectionPolicy, c.podScorer,
+		maxTrafficPods)
+
+	podsAfter, syncedAfter, errAfter := listAppPods(podInformer, tt.Namespace, appName)
+	synced := syncedBefore && syncedAfter && errBefore == nil && errAfter == nil
+	confidence := achievedWeightConfidence(synced, podsBefore, podsAfter)
+	if confident, ok := confidence[releaseName]; !synced || (ok && !confident) {
+		// Pods changed while we were looking at them, so whatever we
+		// worked out from them is likely to be wrong already. Keep
+		// reporting what was achieved before, and wait for the events
+		// about those pods to come back to it.
+		achievedTraffic = status.AchievedTraffic
+		readyCond = trafficutil.NewClusterTrafficCondition(
+			shipper.ClusterConditionTypeReady,
+			corev1.ConditionFalse,
+			InProgress,
+			"pods changed while their achieved weight was being worked out",
+		)
+
+		return nil
+	}
 
 	// achievedTraffic is used by the defer at the top of this func
 	achievedTraffic = trafficStatus.achievedTrafficWeight
@@ -391,11 +835,26 @@ func (c *Controller) processTrafficTargetOnCluster(
 		return nil
 	}
 
-	if trafficStatus.podsToShift != nil {
-		// If we have pods to shift, our job can only be done after the
-		// change is made and observed, so we definitely still in
-		// progress.
-		err := shiftPodLabels(clientset, trafficStatus.podsToShift)
+	// Pods that lost their traffic label altogether, usually because
+	// they were recreated, are put back to serving before anything else
+	// is shifted around. This gets them back behind the load balancer
+	// without waiting for the regular ramp to pick them.
+	if len(trafficStatus.podsMissingLabel) > 0 {
+		podsToRepair := map[string][]*corev1.Pod{
+			shipper.Enabled: trafficStatus.podsMissingLabel,
+		}
+
+		repaired, err := shiftPodLabels(ctx, clientset, c.writeBudget, podsToRepair)
+		c.labelConflicts.Record(spec.Name, repaired)
+		if len(repaired) > 0 {
+			c.recorder.Eventf(
+				tt,
+				corev1.EventTypeNormal,
+				PodTrafficLabelRepaired,
+				"Labeled %d pods missing a traffic label in cluster %q to receive traffic",
+				len(repaired), spec.Name,
+			)
+		}
 		if err != nil {
 			readyCond = trafficutil.NewClusterTrafficCondition(
 				shipper.ClusterConditionTypeReady,
@@ -413,6 +872,88 @@ func (c *Controller) processTrafficTargetOnCluster(
 			InProgress,
 			"",
 		)
+
+		return nil
+	}
+
+	// Clusters are gated on their own: as long as some of the pods of
+	// this release already labeled to receive traffic in this cluster
+	// aren't ready, no more of them are labeled here, no matter how far
+	// along other clusters are. That holds the achieved weight where it
+	// is until they catch up, or until they've been given long enough
+	// to. Taking traffic away is never held back.
+	ttKey := shippercontroller.MetaKey(tt)
+	podsNotReady := labeledPodsNotReady(appPods, endpoints, releaseSelectorFor(appName, releaseName))
+	waitingForReadiness, holdFor := c.readinessWaits.Observe(ttKey, spec.Name,
+		podsNotReady > 0 && len(trafficStatus.podsToShift[shipper.Enabled]) > 0)
+	if waitingForReadiness {
+		trafficStatus.podsToShift = withoutPodsToEnable(trafficStatus.podsToShift)
+		c.workqueue.AddAfter(ttKey, holdFor)
+	}
+
+	if trafficStatus.podsToShift != nil {
+		c.recordDecision(tt, spec.Name, releaseName, trafficStatus, clusterReleaseWeights[spec.Name])
+
+		// If we have pods to shift, our job can only be done after the
+		// change is made and observed, so we definitely still in
+		// progress.
+		c.reportFlippedBackPods(tt, spec.Name, trafficStatus.podsToShift)
+
+		// Pods that keep having their label flipped back are left
+		// alone for a while, instead of patching them over and over
+		// again for as long as whatever else is fighting over them
+		// keeps at it.
+		flapping := c.labelConflicts.Flapping(spec.Name, allPods(trafficStatus.podsToShift))
+		trafficStatus.podsToShift = withoutPods(trafficStatus.podsToShift, flapping)
+
+		podsToRelabel, recreated, err := recreatePods(ctx, clientset, c.writeBudget, trafficStatus.podsToShift, c.shiftPolicy)
+		if len(recreated) > 0 {
+			c.recorder.Eventf(
+				tt,
+				corev1.EventTypeNormal,
+				PodsRecreatedForTraffic,
+				"Deleted %d pods in cluster %q for their replacements to receive traffic",
+				len(recreated), spec.Name,
+			)
+		}
+		if err != nil {
+			readyCond = trafficutil.NewClusterTrafficCondition(
+				shipper.ClusterConditionTypeReady,
+				corev1.ConditionFalse,
+				InternalError,
+				err.Error(),
+			)
+
+			return err
+		}
+
+		shifted, err := shiftPodLabels(ctx, clientset, c.writeBudget, podsToRelabel)
+		c.labelConflicts.Record(spec.Name, shifted)
+		if err != nil {
+			readyCond = trafficutil.NewClusterTrafficCondition(
+				shipper.ClusterConditionTypeReady,
+				corev1.ConditionFalse,
+				InternalError,
+				err.Error(),
+			)
+
+			return err
+		}
+
+		if len(flapping) > 0 {
+			readyCond = trafficLabelFlappingCondition(flapping)
+		} else if waitingForReadiness {
+			readyCond = waitingForReadinessCondition(podsNotReady, trafficStatus)
+		} else {
+			readyCond = trafficutil.NewClusterTrafficCondition(
+				shipper.ClusterConditionTypeReady,
+				corev1.ConditionFalse,
+				InProgress,
+				"",
+			)
+		}
+	} else if waitingForReadiness {
+		readyCond = waitingForReadinessCondition(podsNotReady, trafficStatus)
 	} else if trafficStatus.podsNotReady > 0 {
 		// All the pods have been shifted, made it to endpoints, but
 		// some aren't ready.
@@ -444,49 +985,273 @@ func (c *Controller) processTrafficTargetOnCluster(
 	return nil
 }
 
-func (c *Controller) getClusterObjects(cluster, ns, appName string) ([]*corev1.Pod, *corev1.Endpoints, error) {
+// waitingForReadinessCondition returns the Ready condition for a cluster
+// that is holding its weight until the podsNotReady pods already labeled
+// to receive traffic in it are ready.
+func waitingForReadinessCondition(podsNotReady int, trafficStatus trafficShiftingStatus) *shipper.ClusterTrafficCondition {
+	msg := fmt.Sprintf(
+		"%d/%d pods designated to receive traffic are not ready, holding weight at %d",
+		podsNotReady, trafficStatus.podsLabeled, trafficStatus.achievedTrafficWeight)
+
+	return trafficutil.NewClusterTrafficCondition(
+		shipper.ClusterConditionTypeReady,
+		corev1.ConditionFalse,
+		WaitingForReadiness,
+		msg,
+	)
+}
+
+// checkClusterNames emits a warning for every cluster tt starts referencing
+// that is not registered in the management cluster. Unknown clusters are
+// only an error if the controller was told to reject them, otherwise
+// whatever traffic was meant for them is left unserved.
+func (c *Controller) checkClusterNames(tt *shipper.TrafficTarget) error {
+	clusters, err := c.clustersLister.List(labels.Everything())
+	if err != nil {
+		return shippererrors.NewKubeclientListError(
+			shipper.SchemeGroupVersion.WithKind("Cluster"),
+			"", labels.Everything(), err)
+	}
+
+	unknown := validateClusterNames(tt, clusters)
+	unknownNames := make([]string, 0, len(unknown))
+	for _, err := range unknown {
+		unknownNames = append(unknownNames, err.ClusterName())
+	}
+	for _, name := range c.unknownClusters.UnreportedNames(shippercontroller.MetaKey(tt), "", unknownNames) {
+		c.recorder.Event(tt, corev1.EventTypeWarning, UnknownCluster,
+			shippererrors.NewUnknownClusterError(tt, name).Error())
+	}
+
+	errs := shippererrors.NewMultiError()
+	for _, err := range unknown {
+		errs.Append(err)
+	}
+
+	if c.rejectUnknownClusters {
+		return errs.Flatten()
+	}
+
+	return nil
+}
+
+// reportFlippedBackPods emits a warning for every pod in podsToShift that
+// had its traffic label changed back shortly after we set it, as that most
+// likely means something other than shipper is managing it as well.
+func (c *Controller) reportFlippedBackPods(
+	tt *shipper.TrafficTarget,
+	cluster string,
+	podsToShift map[string][]*corev1.Pod,
+) {
+	for _, pod := range c.labelConflicts.FlippedBack(cluster, allPods(podsToShift)) {
+		c.recorder.Eventf(
+			tt,
+			corev1.EventTypeWarning,
+			ConflictingTrafficManager,
+			"Pod %q in cluster %q had its %q label changed to %q shortly after we set it: is something other than shipper managing it?",
+			shippercontroller.MetaKey(pod), cluster,
+			shipper.PodTrafficStatusLabel, pod.Labels[shipper.PodTrafficStatusLabel],
+		)
+	}
+}
+
+// recordDecision writes the traffic shifting decision described by
+// trafficStatus to the controller's decision log.
+func (c *Controller) recordDecision(
+	tt *shipper.TrafficTarget,
+	cluster, releaseName string,
+	trafficStatus trafficShiftingStatus,
+	releaseWeights map[string]uint32,
+) {
+	toPods := trafficStatus.podsLabeled +
+		len(trafficStatus.podsToShift[shipper.Enabled]) -
+		len(trafficStatus.podsToShift[shipper.Disabled])
+
+	totalWeight := uint32(0)
+	for _, weight := range releaseWeights {
+		totalWeight += weight
+	}
+
+	c.decisionLog.Record(DecisionLogEntry{
+		Time:          time.Now(),
+		TrafficTarget: shippercontroller.MetaKey(tt),
+		Cluster:       cluster,
+		Release:       releaseName,
+		FromPods:      trafficStatus.podsLabeled,
+		ToPods:        toPods,
+		Reason: fmt.Sprintf("release weight is %d out of %d",
+			releaseWeights[releaseName], totalWeight),
+	})
+}
+
+// applicationTrafficWeightFallback is a trafficWeightFallback that follows
+// the default traffic policy of the traffic target's application, if it has
+// one. The only policy there is gives the application's contender the
+// traffic weight of the target step of its strategy, and nothing to any
+// other release.
+func (c *Controller) applicationTrafficWeightFallback(tt *shipper.TrafficTarget) (uint32, bool, error) {
+	appName := tt.Labels[shipper.AppLabel]
+	app, err := c.applicationsLister.Applications(tt.Namespace).Get(appName)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return 0, false, nil
+		}
+
+		return 0, false, shippererrors.NewKubeclientGetError(tt.Namespace, appName, err).
+			WithShipperKind("Application")
+	}
+
+	if _, ok, err := apputil.GetDefaultTrafficPolicy(app); err != nil || !ok {
+		return 0, false, err
+	}
+
+	selector := labels.Set{shipper.AppLabel: appName}.AsSelector()
+	rels, err := c.releasesLister.Releases(tt.Namespace).List(selector)
+	if err != nil {
+		return 0, false, shippererrors.NewKubeclientListError(
+			shipper.SchemeGroupVersion.WithKind("Release"),
+			tt.Namespace, selector, err)
+	}
+
+	contender, err := apputil.GetContender(appName, releaseutil.SortByGenerationDescending(rels))
+	if err != nil || contender.Name != tt.Labels[shipper.ReleaseLabel] {
+		return 0, false, nil
+	}
+
+	strategy := contender.Spec.Environment.Strategy
+	step := contender.Spec.TargetStep
+	if strategy == nil || step < 0 || int(step) >= len(strategy.Steps) {
+		return 0, false, nil
+	}
+
+	return uint32(strategy.Steps[step].Traffic.Contender), true, nil
+}
+
+// productionServiceSelector returns the selector the production services of
+// the traffic target's application are found by. Traffic targets whose
+// application is gone get the default one.
+func (c *Controller) productionServiceSelector(tt *shipper.TrafficTarget) (labels.Selector, error) {
+	appName := tt.Labels[shipper.AppLabel]
+	app, err := c.applicationsLister.Applications(tt.Namespace).Get(appName)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return apputil.DefaultProductionServiceSelector(appName), nil
+		}
+
+		return nil, shippererrors.NewKubeclientGetError(tt.Namespace, appName, err).
+			WithShipperKind("Application")
+	}
+
+	return apputil.GetProductionServiceSelector(app)
+}
+
+// getClusterObjects returns the pods of an application in cluster that are
+// selected by all of its production services, as found by serviceSelector,
+// along with the Endpoints of those services merged into one. Production
+// services whose Endpoints can't be retrieved are left out and reported in
+// the returned slice of errors, and only fail the whole thing if there are
+// none left.
+func (c *Controller) getClusterObjects(cluster, ns, appName string, serviceSelector labels.Selector) ([]*corev1.Pod, *corev1.Endpoints, []error, error) {
 	informerFactory, err := c.clusterClientStore.GetInformerFactory(cluster)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
+	}
+
+	// Listing from an informer that hasn't synced yet would give us a
+	// partial view of the cluster, and we'd end up shifting traffic based
+	// on it. Bail out and try again later instead.
+	corev1Informers := informerFactory.Core().V1()
+	for kind, informer := range map[string]cache.SharedIndexInformer{
+		"Pod":       corev1Informers.Pods().Informer(),
+		"Service":   corev1Informers.Services().Informer(),
+		"Endpoints": corev1Informers.Endpoints().Informer(),
+	} {
+		if !informer.HasSynced() {
+			return nil, nil, nil, shippererrors.NewTargetClusterCacheNotSyncedError(
+				cluster, corev1.SchemeGroupVersion.WithKind(kind))
+		}
 	}
 
 	appSelector := labels.Set{shipper.AppLabel: appName}.AsSelector()
 	appPods, err := informerFactory.Core().V1().Pods().Lister().
 		Pods(ns).List(appSelector)
 	if err != nil {
-		return nil, nil, shippererrors.NewKubeclientListError(
+		return nil, nil, nil, shippererrors.NewKubeclientListError(
 			corev1.SchemeGroupVersion.WithKind("Pod"),
 			ns, appSelector, err)
 	}
 
-	serviceSelector := labels.Set(map[string]string{
-		shipper.AppLabel: appName,
-		shipper.LBLabel:  shipper.LBForProduction,
-	}).AsSelector()
 	serviceGVK := corev1.SchemeGroupVersion.WithKind("Service")
 	services, err := informerFactory.Core().V1().Services().Lister().
 		Services(ns).List(serviceSelector)
 	if err != nil {
-		return nil, nil, shippererrors.NewKubeclientListError(
+		return nil, nil, nil, shippererrors.NewKubeclientListError(
 			serviceGVK, ns, serviceSelector, err)
 	}
 
-	if len(services) != 1 {
+	if len(services) == 0 {
 		err := shippererrors.NewUnexpectedObjectCountFromSelectorError(
 			serviceSelector, serviceGVK, 1, len(services))
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	svc := services[0]
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+
+	var (
+		usableServices []*corev1.Service
+		endpointsList  []*corev1.Endpoints
+	)
+	serviceErrs := shippererrors.NewMultiError()
+	for _, svc := range services {
+		endpoints, err := informerFactory.Core().V1().Endpoints().Lister().
+			Endpoints(svc.Namespace).Get(svc.Name)
+		if err != nil {
+			serviceErrs.Append(shippererrors.NewKubeclientGetError(svc.Namespace, svc.Name, err).
+				WithCoreV1Kind("Endpoints"))
+			continue
+		}
+
+		usableServices = append(usableServices, svc)
+		endpointsList = append(endpointsList, endpoints)
+	}
 
-	endpoints, err := informerFactory.Core().V1().Endpoints().Lister().
-		Endpoints(svc.Namespace).Get(svc.Name)
+	if len(endpointsList) == 0 {
+		return nil, nil, nil, serviceErrs.Flatten()
+	}
+
+	return podsForServices(appPods, usableServices), mergeEndpoints(endpointsList), serviceErrs.Errors, nil
+}
+
+// getUnhealthyNodes returns the names of the nodes in a cluster that are
+// either cordoned or not Ready.
+func (c *Controller) getUnhealthyNodes(cluster string) (map[string]struct{}, error) {
+	informerFactory, err := c.clusterClientStore.GetInformerFactory(cluster)
 	if err != nil {
-		return nil, nil, shippererrors.NewKubeclientGetError(svc.Namespace, svc.Name, err).
-			WithCoreV1Kind("Endpoints")
+		return nil, err
+	}
+
+	nodeGVK := corev1.SchemeGroupVersion.WithKind("Node")
+	nodeInformer := informerFactory.Core().V1().Nodes()
+	if !nodeInformer.Informer().HasSynced() {
+		return nil, shippererrors.NewTargetClusterCacheNotSyncedError(cluster, nodeGVK)
 	}
 
-	return appPods, endpoints, nil
+	nodes, err := nodeInformer.Lister().List(labels.Everything())
+	if err != nil {
+		return nil, shippererrors.NewKubeclientListError(
+			nodeGVK, "", labels.Everything(), err)
+	}
+
+	unhealthyNodes := make(map[string]struct{})
+	for _, node := range nodes {
+		if !nodeIsHealthy(node) {
+			unhealthyNodes[node.Name] = struct{}{}
+		}
+	}
+
+	return unhealthyNodes, nil
 }
 
 // enqueueTrafficTarget takes a TrafficTarget resource and converts it into a
@@ -531,6 +1296,78 @@ func (c *Controller) enqueueAllTrafficTargets(obj interface{}) {
 	}
 }
 
+// DrainCluster gradually takes traffic away from every release in
+// clusterName, ramping their weights down to zero by at most
+// maxShiftPerSync every time their traffic targets are synced, until
+// StopDrainingCluster is called.
+func (c *Controller) DrainCluster(clusterName string) {
+	klog.V(2).Infof("Draining traffic from cluster %q", clusterName)
+	c.drainer.Drain(clusterName)
+	c.enqueueEveryTrafficTarget()
+}
+
+// StopDrainingCluster gives back all releases in clusterName the weights
+// their traffic targets ask for.
+func (c *Controller) StopDrainingCluster(clusterName string) {
+	klog.V(2).Infof("No longer draining traffic from cluster %q", clusterName)
+	c.drainer.Stop(clusterName)
+	c.enqueueEveryTrafficTarget()
+}
+
+// ClusterDrained returns whether clusterName is being drained, and all of
+// its releases are down to zero weight, so it's safe to remove.
+func (c *Controller) ClusterDrained(clusterName string) bool {
+	return c.drainer.Drained(clusterName)
+}
+
+// syncClusterDrain drains clusters annotated with
+// shipper.ClusterDrainAnnotation, and stops draining them once the
+// annotation is gone.
+func (c *Controller) syncClusterDrain(obj interface{}) {
+	cluster, ok := obj.(*shipper.Cluster)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.Cluster: %#v", obj))
+		return
+	}
+
+	drain := cluster.Annotations[shipper.ClusterDrainAnnotation] == "true"
+	if draining := c.drainer.Draining(cluster.Name); drain && !draining {
+		c.DrainCluster(cluster.Name)
+	} else if !drain && draining {
+		c.StopDrainingCluster(cluster.Name)
+	}
+}
+
+// forgetClusterDrain stops draining clusters that are gone.
+func (c *Controller) forgetClusterDrain(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	cluster, ok := obj.(*shipper.Cluster)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("not a shipper.Cluster: %#v", obj))
+		return
+	}
+
+	c.drainer.Stop(cluster.Name)
+}
+
+// enqueueEveryTrafficTarget enqueues all traffic targets in all namespaces.
+// It's meant for changes that aren't tied to any application in particular,
+// such as a node becoming unhealthy.
+func (c *Controller) enqueueEveryTrafficTarget() {
+	trafficTargets, err := c.trafficTargetsLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("cannot list traffic targets: %s", err))
+		return
+	}
+
+	for _, tt := range trafficTargets {
+		c.enqueueTrafficTarget(tt)
+	}
+}
+
 func (c *Controller) enqueueTrafficTargetFromPod(obj interface{}) {
 	pod, ok := obj.(*corev1.Pod)
 	if !ok {
@@ -1,8 +1,10 @@
 package traffic
 
 import (
+	"context"
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -12,10 +14,14 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubetesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
 	shippertesting "github.com/bookingcom/shipper/pkg/testing"
+	apputil "github.com/bookingcom/shipper/pkg/util/application"
 	targetutil "github.com/bookingcom/shipper/pkg/util/target"
 	trafficutil "github.com/bookingcom/shipper/pkg/util/traffic"
 )
@@ -129,6 +135,7 @@ func TestMultipleTrafficTargets(t *testing.T) {
 	// the circumstances.
 	foobarAStatus := buildSuccessStatus(foobarA.Spec.Clusters)
 	foobarAStatus.Clusters[0].AchievedTraffic = 50
+	foobarAStatus.ShiftProgress = 83
 	foobarBStatus := buildSuccessStatus(foobarB.Spec.Clusters)
 	foobarBStatus.Clusters[0].AchievedTraffic = 40
 
@@ -231,6 +238,7 @@ func TestTrafficShiftingWithPodsNotReady(t *testing.T) {
 				Message: fmt.Sprintf("%s: PodsNotReady 1/3 pods designated to receive traffic are not ready", clusterA),
 			},
 		},
+		ShiftProgress: 70,
 	}
 
 	runTrafficControllerTest(t,
@@ -249,16 +257,793 @@ func TestTrafficShiftingWithPodsNotReady(t *testing.T) {
 	)
 }
 
+// TestClusterWaitsForReadinessOfItsOwnPods verifies that a cluster with
+// pods labeled for traffic that aren't ready yet holds its weight where it
+// is, instead of labeling any more pods, even when the other clusters in
+// the same traffic target have ramped up all the way.
+func TestClusterWaitsForReadinessOfItsOwnPods(t *testing.T) {
+	tt := buildTrafficTarget(shippertesting.TestApp, ttName,
+		map[string]uint32{clusterA: 10, clusterB: 10})
+
+	notReady := buildPods(shippertesting.TestApp, ttName, 1, withTraffic)
+	notReady[0].Labels[podReadinessLabel] = podNotReady
+	endpoints := shiftPodInEndpoints(notReady[0], buildEndpoints(shippertesting.TestApp))
+
+	objectsA := []runtime.Object{
+		buildService(shippertesting.TestApp),
+		endpoints,
+	}
+	objectsA = addPodsToList(objectsA, notReady)
+	objectsA = addPodsToList(objectsA, buildPods(shippertesting.TestApp, ttName, 2, noTraffic))
+
+	msg := "1/1 pods designated to receive traffic are not ready, holding weight at 0"
+	status := shipper.TrafficTargetStatus{
+		Clusters: []*shipper.ClusterTrafficStatus{
+			{
+				Name:            clusterA,
+				AchievedTraffic: 0,
+				Conditions: []shipper.ClusterTrafficCondition{
+					ClusterTrafficOperational,
+					{
+						Type:    shipper.ClusterConditionTypeReady,
+						Status:  corev1.ConditionFalse,
+						Reason:  WaitingForReadiness,
+						Message: msg,
+					},
+				},
+			},
+			{
+				Name:            clusterB,
+				AchievedTraffic: 10,
+				Conditions: []shipper.ClusterTrafficCondition{
+					ClusterTrafficOperational,
+					ClusterTrafficReady,
+				},
+			},
+		},
+		Conditions: []shipper.TargetCondition{
+			TargetConditionOperational,
+			{
+				Type:    shipper.TargetConditionTypeReady,
+				Status:  corev1.ConditionFalse,
+				Reason:  ClustersNotReady,
+				Message: fmt.Sprintf("%s: %s %s", clusterA, WaitingForReadiness, msg),
+			},
+		},
+		ShiftProgress: 50,
+	}
+
+	runTrafficControllerTest(t,
+		map[string][]runtime.Object{
+			clusterA: objectsA,
+			clusterB: buildWorldWithPods(shippertesting.TestApp, ttName, 3, noTraffic),
+		},
+		[]trafficTargetTestExpectation{
+			{
+				trafficTarget: tt,
+				status:        status,
+				podsByCluster: map[string]podStatus{
+					clusterA: {withTraffic: 1, withoutTraffic: 2},
+					clusterB: {withTraffic: 3},
+				},
+			},
+		},
+	)
+}
+
+// TestProcessTrafficTargetOnClustersIsolatesErrors verifies that clusters
+// are processed independently of each other: a cluster that can't be reached
+// reports its own error and Operational condition, while all the other
+// clusters in the same traffic target still get their statuses computed.
+func TestProcessTrafficTargetOnClustersIsolatesErrors(t *testing.T) {
+	const (
+		clusterC       = "cluster-c"
+		clusterMissing = "cluster-missing"
+	)
+
+	podCount := 2
+	tt := buildTrafficTarget(shippertesting.TestApp, ttName,
+		map[string]uint32{clusterA: 10, clusterB: 10, clusterC: 10, clusterMissing: 10})
+
+	f := shippertesting.NewControllerTestFixture()
+	for _, clusterName := range []string{clusterA, clusterB, clusterC} {
+		cluster := addCluster(f, clusterName)
+		cluster.AddMany(buildWorldWithPods(shippertesting.TestApp, ttName, podCount, noTraffic))
+	}
+	f.ShipperClient.Tracker().Add(tt)
+
+	controller := NewController(
+		f.ShipperClient,
+		f.ShipperInformerFactory,
+		f.ClusterClientStore,
+		f.Recorder,
+		Config{},
+	)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.Run(stopCh)
+
+	weights, err := buildClusterReleaseWeights([]*shipper.TrafficTarget{tt}, noTrafficWeightFallback)
+	if err != nil {
+		t.Fatalf("unexpected error building cluster release weights: %s", err)
+	}
+
+	statuses, clusterErrors := controller.processTrafficTargetOnClusters(context.Background(), tt, weights)
+
+	if got := len(clusterErrors.Errors); got != 1 {
+		t.Fatalf("expected exactly 1 cluster error, got %d: %v", got, clusterErrors.Errors)
+	}
+
+	if len(statuses) != len(tt.Spec.Clusters) {
+		t.Fatalf("expected %d cluster statuses, got %d", len(tt.Spec.Clusters), len(statuses))
+	}
+
+	for i, status := range statuses {
+		if status.Name != tt.Spec.Clusters[i].Name {
+			t.Errorf("expected status %d to belong to cluster %q, got %q",
+				i, tt.Spec.Clusters[i].Name, status.Name)
+		}
+
+		expected := corev1.ConditionTrue
+		if status.Name == clusterMissing {
+			expected = corev1.ConditionFalse
+		}
+
+		cond := trafficutil.GetClusterTrafficCondition(*status, shipper.ClusterConditionTypeOperational)
+		if cond == nil || cond.Status != expected {
+			t.Errorf("expected cluster %q to have Operational condition %s, got %v",
+				status.Name, expected, cond)
+		}
+	}
+
+	for _, clusterName := range []string{clusterA, clusterB, clusterC} {
+		assertPodTraffic(t, tt, f.Clusters[clusterName], podStatus{withTraffic: podCount})
+	}
+}
+
+// TestUnsyncedClusterCacheIsNotTrusted verifies that the traffic controller
+// refuses to make any traffic decisions for a cluster whose informer caches
+// haven't synced yet, and that it reports a retriable error instead.
+func TestUnsyncedClusterCacheIsNotTrusted(t *testing.T) {
+	tt := buildTrafficTarget(shippertesting.TestApp, ttName,
+		map[string]uint32{clusterA: 10})
+
+	f := shippertesting.NewControllerTestFixture()
+	cluster := addCluster(f, clusterA)
+	cluster.AddMany(buildWorldWithPods(shippertesting.TestApp, ttName, 1, noTraffic))
+	f.ShipperClient.Tracker().Add(tt)
+
+	controller := NewController(
+		f.ShipperClient,
+		f.ShipperInformerFactory,
+		f.ClusterClientStore,
+		f.Recorder,
+		Config{},
+	)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	// Deliberately don't go through f.Run, so the informers for the
+	// application cluster never get started.
+	f.ShipperInformerFactory.Start(stopCh)
+	f.ShipperInformerFactory.WaitForCacheSync(stopCh)
+
+	weights, err := buildClusterReleaseWeights([]*shipper.TrafficTarget{tt}, noTrafficWeightFallback)
+	if err != nil {
+		t.Fatalf("unexpected error building cluster release weights: %s", err)
+	}
+
+	clientset, podInformer, err := controller.getClusterClients(clusterA)
+	status := &shipper.ClusterTrafficStatus{Name: clusterA}
+	err = controller.processTrafficTargetOnCluster(context.Background(), tt, &tt.Spec.Clusters[0], status, weights,
+		clientset, podInformer, err)
+	if !shippererrors.IsTargetClusterCacheNotSyncedError(err) {
+		t.Fatalf("expected a TargetClusterCacheNotSyncedError, got %v", err)
+	}
+
+	if !shippererrors.ShouldRetry(err) {
+		t.Errorf("expected error %q to be retriable", err)
+	}
+
+	for _, action := range cluster.Client.Actions() {
+		if action.GetVerb() == "patch" {
+			t.Errorf("expected no patches to be issued, got %#v", action)
+		}
+	}
+}
+
+// TestTrafficTargetWithUnknownCluster verifies that a traffic target
+// referencing a cluster that is not registered in the management cluster
+// gets a single warning, and is only considered broken when unknown
+// clusters are to be rejected.
+func TestTrafficTargetWithUnknownCluster(t *testing.T) {
+	const bogusCluster = "bogus-cluster"
+
+	tests := []struct {
+		name                  string
+		rejectUnknownClusters bool
+		expectedOperational   corev1.ConditionStatus
+	}{
+		{
+			name:                  "unknown clusters are a warning",
+			rejectUnknownClusters: false,
+			expectedOperational:   corev1.ConditionTrue,
+		},
+		{
+			name:                  "unknown clusters are rejected",
+			rejectUnknownClusters: true,
+			expectedOperational:   corev1.ConditionFalse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trafficTarget := buildTrafficTarget(shippertesting.TestApp, ttName,
+				map[string]uint32{clusterA: 10, bogusCluster: 10})
+
+			f := shippertesting.NewControllerTestFixture()
+			cluster := addCluster(f, clusterA)
+			cluster.AddMany(buildWorldWithPods(shippertesting.TestApp, ttName, 1, noTraffic))
+			f.ShipperClient.Tracker().Add(trafficTarget)
+
+			controller := NewController(
+				f.ShipperClient,
+				f.ShipperInformerFactory,
+				f.ClusterClientStore,
+				f.Recorder,
+				Config{
+					RejectUnknownClusters: tt.rejectUnknownClusters,
+				},
+			)
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+
+			f.Run(stopCh)
+
+			processed, err := controller.processTrafficTarget(context.Background(), trafficTarget.DeepCopy())
+			if tt.rejectUnknownClusters && !shippererrors.IsUnknownClusterError(err) {
+				t.Errorf("expected an UnknownClusterError, got %v", err)
+			}
+
+			cond := targetutil.GetTargetCondition(processed.Status.Conditions, shipper.TargetConditionTypeOperational)
+			if cond == nil || cond.Status != tt.expectedOperational {
+				t.Errorf("expected Operational condition %s, got %v", tt.expectedOperational, cond)
+			} else if tt.rejectUnknownClusters && cond.Reason != UnknownCluster {
+				t.Errorf("expected Operational condition reason %q, got %q", UnknownCluster, cond.Reason)
+			}
+
+			expectedEvent := fmt.Sprintf("Warning %s %s", UnknownCluster,
+				shippererrors.NewUnknownClusterError(trafficTarget, bogusCluster).Error())
+			select {
+			case event := <-f.Recorder.Events:
+				if event != expectedEvent {
+					t.Errorf("expected event %q, got %q", expectedEvent, event)
+				}
+			default:
+				t.Errorf("expected a warning about the unknown cluster")
+			}
+
+			controller.processTrafficTarget(context.Background(), processed.DeepCopy())
+			for len(f.Recorder.Events) > 0 {
+				if event := <-f.Recorder.Events; event == expectedEvent {
+					t.Errorf("expected the unknown cluster to be warned about once, got %q again", event)
+				}
+			}
+		})
+	}
+}
+
+// TestMultipleProductionServices verifies that traffic is shifted for all
+// of an application's production services at once: pods only count as
+// ready once all of them agree, and a service whose Endpoints are missing
+// is warned about and ignored instead of failing the whole cluster.
+func TestMultipleProductionServices(t *testing.T) {
+	const podCount = 4
+
+	tt := buildTrafficTarget(shippertesting.TestApp, ttName,
+		map[string]uint32{clusterA: 100})
+
+	pods := buildPods(shippertesting.TestApp, ttName, podCount, withTraffic)
+
+	mainService := buildService(shippertesting.TestApp)
+	mainEndpoints := buildEndpoints(shippertesting.TestApp)
+	for _, pod := range pods {
+		mainEndpoints = shiftPodInEndpoints(pod, mainEndpoints)
+	}
+
+	// The admin service has only caught up with half of the pods.
+	adminService := buildService(shippertesting.TestApp)
+	adminService.Name = fmt.Sprintf("%s-admin", mainService.Name)
+	adminEndpoints := buildEndpoints(shippertesting.TestApp)
+	adminEndpoints.Name = adminService.Name
+	for _, pod := range pods[:podCount/2] {
+		adminEndpoints = shiftPodInEndpoints(pod, adminEndpoints)
+	}
+
+	// The metrics service doesn't have any Endpoints at all.
+	metricsService := buildService(shippertesting.TestApp)
+	metricsService.Name = fmt.Sprintf("%s-metrics", mainService.Name)
+
+	f := shippertesting.NewControllerTestFixture()
+	cluster := addCluster(f, clusterA)
+	cluster.AddMany([]runtime.Object{
+		mainService, mainEndpoints,
+		adminService, adminEndpoints,
+		metricsService,
+	})
+	cluster.AddMany(addPodsToList(nil, pods))
+	f.ShipperClient.Tracker().Add(tt)
+
+	controller := NewController(
+		f.ShipperClient,
+		f.ShipperInformerFactory,
+		f.ClusterClientStore,
+		f.Recorder,
+		Config{},
+	)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.Run(stopCh)
+
+	weights, err := buildClusterReleaseWeights([]*shipper.TrafficTarget{tt}, noTrafficWeightFallback)
+	if err != nil {
+		t.Fatalf("unexpected error building cluster release weights: %s", err)
+	}
+
+	clientset, podInformer, err := controller.getClusterClients(clusterA)
+	status := &shipper.ClusterTrafficStatus{Name: clusterA}
+	err = controller.processTrafficTargetOnCluster(context.Background(), tt, &tt.Spec.Clusters[0], status, weights,
+		clientset, podInformer, err)
+	if err != nil {
+		t.Fatalf("expected a missing Endpoints not to be fatal, got %s", err)
+	}
+
+	if expected := uint32(50); status.AchievedTraffic != expected {
+		t.Errorf("expected achieved traffic to only count pods ready for every service (%d), got %d",
+			expected, status.AchievedTraffic)
+	}
+
+	select {
+	case event := <-f.Recorder.Events:
+		if !strings.HasPrefix(event, fmt.Sprintf("Warning %s", ProductionServiceError)) ||
+			!strings.Contains(event, metricsService.Name) {
+			t.Errorf("expected a warning about service %q, got %q", metricsService.Name, event)
+		}
+	default:
+		t.Errorf("expected a warning about service %q", metricsService.Name)
+	}
+}
+
+// TestPodMissingTrafficLabelIsRepaired verifies that a pod that should be
+// serving traffic but lost its traffic label altogether, as it happens when
+// pods are recreated, is labeled to receive traffic before any other pod is
+// picked by the regular ramp.
+func TestPodMissingTrafficLabelIsRepaired(t *testing.T) {
+	tt := buildTrafficTarget(shippertesting.TestApp, ttName,
+		map[string]uint32{clusterA: 100})
+
+	endpoints := buildEndpoints(shippertesting.TestApp)
+
+	serving := buildPods(shippertesting.TestApp, ttName, 2, withTraffic)
+	for _, pod := range serving {
+		endpoints = shiftPodInEndpoints(pod, endpoints)
+	}
+
+	// The disabled pod sorts first, so it's the one the ramp would
+	// have picked.
+	disabled := buildPods(shippertesting.TestApp, ttName, 1, noTraffic)[0]
+	disabled.Name = fmt.Sprintf("%s-a", ttName)
+
+	recreated := buildPods(shippertesting.TestApp, ttName, 1, withTraffic)[0]
+	recreated.Name = fmt.Sprintf("%s-z", ttName)
+	delete(recreated.Labels, shipper.PodTrafficStatusLabel)
+
+	f := shippertesting.NewControllerTestFixture()
+	cluster := addCluster(f, clusterA)
+	cluster.AddMany([]runtime.Object{buildService(shippertesting.TestApp), endpoints})
+	cluster.AddMany(addPodsToList(nil, append(serving, disabled, recreated)))
+	f.ShipperClient.Tracker().Add(tt)
+
+	controller := NewController(
+		f.ShipperClient,
+		f.ShipperInformerFactory,
+		f.ClusterClientStore,
+		f.Recorder,
+		Config{},
+	)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.Run(stopCh)
+
+	weights, err := buildClusterReleaseWeights([]*shipper.TrafficTarget{tt}, noTrafficWeightFallback)
+	if err != nil {
+		t.Fatalf("unexpected error building cluster release weights: %s", err)
+	}
+
+	clientset, podInformer, err := controller.getClusterClients(clusterA)
+	status := &shipper.ClusterTrafficStatus{Name: clusterA}
+	err = controller.processTrafficTargetOnCluster(context.Background(), tt, &tt.Spec.Clusters[0], status, weights,
+		clientset, podInformer, err)
+	if err != nil {
+		t.Fatalf("unexpected error processing traffic target: %s", err)
+	}
+
+	var patched []string
+	for _, action := range cluster.Client.Actions() {
+		if action.GetVerb() == "patch" && action.GetResource().Resource == "pods" {
+			patched = append(patched, action.(kubetesting.PatchAction).GetName())
+		}
+	}
+
+	if len(patched) != 1 || patched[0] != recreated.Name {
+		t.Fatalf("expected only pod %q to be patched, got %v", recreated.Name, patched)
+	}
+
+	pod, err := cluster.Client.CoreV1().Pods(recreated.Namespace).Get(recreated.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error getting pod %q: %s", recreated.Name, err)
+	}
+
+	if !getsTraffic(pod) {
+		t.Errorf("expected pod %q to be labeled to receive traffic, got labels %v", pod.Name, pod.Labels)
+	}
+
+	cond := trafficutil.GetClusterTrafficCondition(*status, shipper.ClusterConditionTypeReady)
+	if cond == nil || cond.Status != corev1.ConditionFalse || cond.Reason != InProgress {
+		t.Errorf("expected cluster to be in progress, got %v", cond)
+	}
+
+	select {
+	case event := <-f.Recorder.Events:
+		if !strings.HasPrefix(event, fmt.Sprintf("Normal %s", PodTrafficLabelRepaired)) {
+			t.Errorf("expected a %s event, got %q", PodTrafficLabelRepaired, event)
+		}
+	default:
+		t.Errorf("expected a %s event", PodTrafficLabelRepaired)
+	}
+}
+
+// TestApplicationDefaultTrafficPolicy verifies that a traffic target without
+// any clusters in its spec follows the application's default traffic policy,
+// getting the contender weight of its release's target step in every cluster
+// the application is present in.
+func TestApplicationDefaultTrafficPolicy(t *testing.T) {
+	app := &shipper.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      shippertesting.TestApp,
+			Namespace: shippertesting.TestNamespace,
+			Annotations: map[string]string{
+				shipper.AppDefaultTrafficPolicyAnnotation: apputil.DefaultTrafficPolicyStep,
+			},
+		},
+	}
+
+	incumbent := buildReleaseObject("foobar-a")
+	incumbent.Annotations = map[string]string{shipper.ReleaseGenerationAnnotation: "0"}
+
+	contender := buildReleaseObject("foobar-b")
+	contender.Annotations = map[string]string{shipper.ReleaseGenerationAnnotation: "1"}
+	contender.Spec.TargetStep = 1
+	contender.Spec.Environment.Strategy = &shipper.RolloutStrategy{
+		Steps: []shipper.RolloutStrategyStep{
+			{
+				Name:     "staging",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 1},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 100, Contender: 0},
+			},
+			{
+				Name:     "50/50",
+				Capacity: shipper.RolloutStrategyStepValue{Incumbent: 50, Contender: 50},
+				Traffic:  shipper.RolloutStrategyStepValue{Incumbent: 50, Contender: 50},
+			},
+		},
+	}
+
+	foobarA := buildTrafficTarget(
+		shippertesting.TestApp, "foobar-a",
+		map[string]uint32{clusterA: 50},
+	)
+	foobarB := buildTrafficTarget(
+		shippertesting.TestApp, "foobar-b",
+		map[string]uint32{},
+	)
+
+	podCount := 5
+	clusterObjects := []runtime.Object{
+		buildService(shippertesting.TestApp),
+		buildEndpoints(shippertesting.TestApp),
+	}
+	clusterObjects = addPodsToList(clusterObjects,
+		buildPods(shippertesting.TestApp, foobarA.Name, podCount, noTraffic))
+	clusterObjects = addPodsToList(clusterObjects,
+		buildPods(shippertesting.TestApp, foobarB.Name, podCount, noTraffic))
+
+	runTrafficControllerTestWithShipperObjects(t,
+		[]runtime.Object{app, incumbent, contender},
+		map[string][]runtime.Object{clusterA: clusterObjects},
+		[]trafficTargetTestExpectation{
+			{
+				trafficTarget: foobarA,
+				status:        buildSuccessStatus(foobarA.Spec.Clusters),
+				podsByCluster: map[string]podStatus{
+					clusterA: {withTraffic: podCount},
+				},
+			},
+			{
+				trafficTarget: foobarB,
+				status: buildSuccessStatus([]shipper.ClusterTrafficTarget{
+					{Name: clusterA, Weight: 50},
+				}),
+				podsByCluster: map[string]podStatus{
+					clusterA: {withTraffic: podCount},
+				},
+			},
+		},
+	)
+}
+
+// TestApplicationProductionServiceSelector verifies that traffic is shifted
+// through the production service an application picks with its service
+// selector annotation, even when it isn't labeled the default way.
+func TestApplicationProductionServiceSelector(t *testing.T) {
+	const selectorLabel = "team.example.com/entrypoint"
+
+	app := &shipper.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      shippertesting.TestApp,
+			Namespace: shippertesting.TestNamespace,
+			Annotations: map[string]string{
+				shipper.AppTrafficServiceSelectorAnnotation: selectorLabel + "=" + shippertesting.TestApp,
+			},
+		},
+	}
+
+	tt := buildTrafficTarget(
+		shippertesting.TestApp, ttName,
+		map[string]uint32{clusterA: 10},
+	)
+
+	svc := buildService(shippertesting.TestApp)
+	svc.Labels = map[string]string{selectorLabel: shippertesting.TestApp}
+	endpoints := buildEndpoints(shippertesting.TestApp)
+	endpoints.Labels = map[string]string{selectorLabel: shippertesting.TestApp}
+
+	podCount := 5
+	clusterObjects := []runtime.Object{svc, endpoints}
+	clusterObjects = addPodsToList(clusterObjects,
+		buildPods(shippertesting.TestApp, tt.Name, podCount, noTraffic))
+
+	runTrafficControllerTestWithShipperObjects(t,
+		[]runtime.Object{app},
+		map[string][]runtime.Object{clusterA: clusterObjects},
+		[]trafficTargetTestExpectation{
+			{
+				trafficTarget: tt,
+				status:        buildSuccessStatus(tt.Spec.Clusters),
+				podsByCluster: map[string]podStatus{
+					clusterA: {withTraffic: podCount},
+				},
+			},
+		},
+	)
+}
+
+// TestDecisionLogFollowsReconcileOrder verifies that traffic shifting
+// decisions are written to the decision log in the order the traffic targets
+// get reconciled.
+func TestDecisionLogFollowsReconcileOrder(t *testing.T) {
+	foobarA := buildTrafficTarget(
+		shippertesting.TestApp, "foobar-a",
+		map[string]uint32{clusterA: 60},
+	)
+	foobarB := buildTrafficTarget(
+		shippertesting.TestApp, "foobar-b",
+		map[string]uint32{clusterA: 40},
+	)
+
+	podCount := 5
+	f := shippertesting.NewControllerTestFixture()
+	cluster := addCluster(f, clusterA)
+	cluster.AddMany(buildWorldWithPods(shippertesting.TestApp, foobarA.Name, podCount, noTraffic))
+	cluster.AddMany(addPodsToList(nil, buildPods(shippertesting.TestApp, foobarB.Name, podCount, noTraffic)))
+	f.ShipperClient.Tracker().Add(foobarA)
+	f.ShipperClient.Tracker().Add(foobarB)
+
+	decisionLog := NewRingBufferDecisionLog(10)
+	controller := NewController(
+		f.ShipperClient,
+		f.ShipperInformerFactory,
+		f.ClusterClientStore,
+		f.Recorder,
+		Config{
+			DecisionLog: decisionLog,
+		},
+	)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.Run(stopCh)
+
+	for _, tt := range []*shipper.TrafficTarget{foobarB, foobarA} {
+		key := fmt.Sprintf("%s/%s", tt.Namespace, tt.Name)
+		if err := controller.syncHandler(context.Background(), key); err != nil {
+			t.Fatalf("unexpected error syncing %q: %s", key, err)
+		}
+	}
+
+	expected := []DecisionLogEntry{
+		{
+			Seq:           1,
+			TrafficTarget: fmt.Sprintf("%s/%s", foobarB.Namespace, foobarB.Name),
+			Cluster:       clusterA,
+			Release:       foobarB.Name,
+			FromPods:      0,
+			ToPods:        4,
+			Reason:        "release weight is 40 out of 100",
+		},
+		{
+			Seq:           2,
+			TrafficTarget: fmt.Sprintf("%s/%s", foobarA.Namespace, foobarA.Name),
+			Cluster:       clusterA,
+			Release:       foobarA.Name,
+			FromPods:      0,
+			ToPods:        5,
+			Reason:        "release weight is 60 out of 100",
+		},
+	}
+
+	actual := decisionLog.Entries()
+	for i := range actual {
+		actual[i].Time = time.Time{}
+	}
+
+	eq, diff := shippertesting.DeepEqualDiff(expected, actual)
+	if !eq {
+		t.Errorf("decision log differs from expected:\n%s", diff)
+	}
+}
+
+// TestClusterJoiningMidRollout adds a cluster to a traffic target in
+// between two reconciles, and checks the new cluster gets its traffic
+// shifted just like the one that was there from the start, along with a
+// note about it joining.
+func TestClusterJoiningMidRollout(t *testing.T) {
+	podCount := 1
+	tt := buildTrafficTarget(shippertesting.TestApp, ttName,
+		map[string]uint32{clusterA: 10})
+
+	f := shippertesting.NewControllerTestFixture()
+	for _, clusterName := range []string{clusterA, clusterB} {
+		cluster := addCluster(f, clusterName)
+		cluster.AddMany(buildWorldWithPods(shippertesting.TestApp, ttName, podCount, noTraffic))
+	}
+	f.ShipperClient.Tracker().Add(tt)
+
+	controller := NewController(
+		f.ShipperClient,
+		f.ShipperInformerFactory,
+		f.ClusterClientStore,
+		f.Recorder,
+		Config{},
+	)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	f.Run(stopCh)
+
+	shiftEndpointsWithPods(f)
+
+	processed := reconcileUntilReady(t, controller, tt)
+	drainEvents(f.Recorder.Events)
+
+	processed.Spec.Clusters = append(processed.Spec.Clusters,
+		shipper.ClusterTrafficTarget{Name: clusterB, Weight: 10})
+	if _, err := f.ShipperClient.ShipperV1alpha1().TrafficTargets(tt.Namespace).Update(processed); err != nil {
+		t.Fatalf("unexpected error updating traffic target: %s", err)
+	}
+
+	err := wait.PollImmediate(10*time.Millisecond, time.Second, func() (bool, error) {
+		cached, err := controller.trafficTargetsLister.TrafficTargets(tt.Namespace).Get(tt.Name)
+		if err != nil {
+			return false, err
+		}
+		return len(cached.Spec.Clusters) == 2, nil
+	})
+	if err != nil {
+		t.Fatalf("traffic target with the new cluster never made it to the cache: %s", err)
+	}
+
+	processed = reconcileUntilReady(t, controller, processed)
+
+	eq, diff := shippertesting.DeepEqualDiff(buildSuccessStatus(processed.Spec.Clusters).Clusters, processed.Status.Clusters)
+	if !eq {
+		t.Errorf("cluster statuses differ from expected:\n%s", diff)
+	}
+
+	assertPodTraffic(t, processed, f.Clusters[clusterB], podStatus{withTraffic: podCount})
+
+	expectedEvent := fmt.Sprintf("Normal %s Cluster %q joined mid-rollout, shifting traffic for weight 10 to it",
+		NewClusterJoined, clusterB)
+	if events := drainEvents(f.Recorder.Events); !containsString(events, expectedEvent) {
+		t.Errorf("expected event %q, got %v", expectedEvent, events)
+	}
+}
+
+// reconcileUntilReady processes tt until all of its clusters have
+// achieved their traffic, and returns it as it was last processed.
+func reconcileUntilReady(t *testing.T, controller *Controller, tt *shipper.TrafficTarget) *shipper.TrafficTarget {
+	processed := tt.DeepCopy()
+	err := wait.PollImmediate(20*time.Millisecond, 2*time.Second, func() (bool, error) {
+		var err error
+		processed, err = controller.processTrafficTarget(context.Background(), processed.DeepCopy())
+		if err != nil {
+			return false, err
+		}
+
+		cond := trafficutil.GetTrafficTargetCondition(processed.Status, shipper.TrafficTargetConditionTypeReady)
+		return cond != nil && cond.Status == corev1.ConditionTrue, nil
+	})
+	if err != nil {
+		t.Fatalf("traffic target never got ready: %s", err)
+	}
+
+	return processed
+}
+
+func drainEvents(ch chan string) []string {
+	var events []string
+	for {
+		select {
+		case event := <-ch:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func containsString(ss []string, s string) bool {
+	for _, candidate := range ss {
+		if candidate == s {
+			return true
+		}
+	}
+	return false
+}
+
 func runTrafficControllerTest(
 	t *testing.T,
 	objectsByCluster map[string][]runtime.Object,
 	expectations []trafficTargetTestExpectation,
+) {
+	runTrafficControllerTestWithShipperObjects(t, nil, objectsByCluster, expectations)
+}
+
+func runTrafficControllerTestWithShipperObjects(
+	t *testing.T,
+	shipperObjects []runtime.Object,
+	objectsByCluster map[string][]runtime.Object,
+	expectations []trafficTargetTestExpectation,
 ) {
 	f := shippertesting.NewControllerTestFixture()
 
+	for _, object := range shipperObjects {
+		f.ShipperClient.Tracker().Add(object)
+	}
+
 	clusterNames := []string{}
 	for clusterName, objects := range objectsByCluster {
-		cluster := f.AddNamedCluster(clusterName)
+		cluster := addCluster(f, clusterName)
 		cluster.AddMany(objects)
 		clusterNames = append(clusterNames, clusterName)
 	}
@@ -373,6 +1158,7 @@ func runController(f *shippertesting.ControllerTestFixture) {
 		f.ShipperInformerFactory,
 		f.ClusterClientStore,
 		f.Recorder,
+		Config{},
 	)
 
 	stopCh := make(chan struct{})
@@ -380,6 +1166,22 @@ func runController(f *shippertesting.ControllerTestFixture) {
 
 	f.Run(stopCh)
 
+	shiftEndpointsWithPods(f)
+
+	for controller.processNextWorkItem(context.Background()) {
+		if controller.workqueue.Len() == 0 {
+			time.Sleep(20 * time.Millisecond)
+		}
+		if controller.workqueue.Len() == 0 {
+			return
+		}
+	}
+}
+
+// shiftEndpointsWithPods keeps the Endpoints of every cluster in f up to
+// date with the traffic labels of their pods, as the endpoints controller
+// would.
+func shiftEndpointsWithPods(f *shippertesting.ControllerTestFixture) {
 	for _, cluster := range f.Clusters {
 		kubeclient := cluster.Client
 		corev1Informers := cluster.InformerFactory.Core().V1()
@@ -416,13 +1218,4 @@ func runController(f *shippertesting.ControllerTestFixture) {
 			},
 		)
 	}
-
-	for controller.processNextWorkItem() {
-		if controller.workqueue.Len() == 0 {
-			time.Sleep(20 * time.Millisecond)
-		}
-		if controller.workqueue.Len() == 0 {
-			return
-		}
-	}
 }
@@ -9,11 +9,20 @@ import (
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
 	shippererrors "github.com/bookingcom/shipper/pkg/errors"
-	"github.com/bookingcom/shipper/pkg/util/replicas"
+	trafficutil "github.com/bookingcom/shipper/pkg/util/traffic"
 )
 
 type clusterReleaseWeights map[string]map[string]uint32
 
+// trafficWeightFallback returns the weight the release behind a traffic
+// target should get when the traffic target itself doesn't specify any
+// per-cluster weights, and whether there is such a default at all.
+type trafficWeightFallback func(tt *shipper.TrafficTarget) (uint32, bool, error)
+
+func noTrafficWeightFallback(*shipper.TrafficTarget) (uint32, bool, error) {
+	return 0, false, nil
+}
+
 type trafficShiftingStatus struct {
 	ready                 bool
 	achievedTrafficWeight uint32
@@ -21,6 +30,12 @@ type trafficShiftingStatus struct {
 	podsNotReady          int
 	podsLabeled           int
 	podsToShift           map[string][]*corev1.Pod
+
+	// podsMissingLabel are pods without a traffic label at all that
+	// should be serving traffic. They are usually pods that have just
+	// been recreated, and are to be labeled before any other pods are
+	// shifted around.
+	podsMissingLabel []*corev1.Pod
 }
 
 // buildTrafficShiftingStatus looks at the current state of a cluster regarding
@@ -30,26 +45,45 @@ type trafficShiftingStatus struct {
 // achieved weight for a release. If the current state is different from the
 // desired one, it also returns which pods need to receive which labels to move
 // forward.
+//
+// As long as a release is meant to receive any traffic at all, traffic is
+// never disabled on its pods if that would leave it with less than
+// minServingPods pods labeled to receive traffic. Draining the rest is left
+// for when its weight drops to zero.
+//
+// The floor wins over the weight, so a release can be left with more
+// traffic than it asked for. Releases that don't have more than
+// minServingPods pods in the cluster keep all of them serving, and lose no
+// traffic at all until their weight is zero.
+//
+// Pods scheduled on any of unhealthyNodes are never picked to start
+// receiving traffic. Which pods are picked to have their traffic label
+// toggled is up to podScorer first, and selectionPolicy among the pods
+// that score the same.
+//
+// When maxTrafficPods is not zero, no more than that many pods are ever
+// labeled to receive traffic across all releases combined. If the targets
+// of all releases add up to more than that, each of them is scaled down
+// proportionally.
 func buildTrafficShiftingStatus(
 	cluster, appName, releaseName string,
 	clusterReleaseWeights clusterReleaseWeights,
 	endpoints *corev1.Endpoints,
 	appPods []*corev1.Pod,
+	minServingPods int,
+	unhealthyNodes map[string]struct{},
+	selectionPolicy SelectionPolicy,
+	podScorer PodScorer,
+	maxTrafficPods int,
 ) trafficShiftingStatus {
 	releaseTargetWeights, ok := clusterReleaseWeights[cluster]
 	if !ok {
 		return trafficShiftingStatus{}
 	}
 
-	releaseSelector := labels.Set(map[string]string{
-		shipper.AppLabel:     appName,
-		shipper.ReleaseLabel: releaseName,
-	}).AsSelector()
-
 	podsByTrafficStatus, podsInRelease, podsReady, podsNotReady := summarizePods(
-		appPods, endpoints, releaseSelector)
+		appPods, endpoints, releaseSelectorFor(appName, releaseName), unhealthyNodes)
 
-	releaseTargetWeight := releaseTargetWeights[releaseName]
 	totalTargetWeight := uint32(0)
 	for _, weight := range releaseTargetWeights {
 		totalTargetWeight += weight
@@ -57,8 +91,27 @@ func buildTrafficShiftingStatus(
 
 	podsInApp := len(appPods)
 	podsLabeledForTraffic := len(podsByTrafficStatus[shipper.Enabled])
-	podsToLabel := calculateReleasePodTarget(
-		podsInRelease, releaseTargetWeight, podsInApp, totalTargetWeight)
+	podsToLabel := calculateMinServingPodTarget(
+		podsByTrafficStatus, podsInRelease, releaseTargetWeights[releaseName],
+		podsInApp, totalTargetWeight, minServingPods)
+
+	if maxTrafficPods > 0 {
+		podTargets := make(map[string]int, len(releaseTargetWeights))
+		for release, weight := range releaseTargetWeights {
+			if release == releaseName {
+				podTargets[release] = podsToLabel
+				continue
+			}
+
+			podsByTrafficStatus, podsInRelease, _, _ := summarizePods(
+				appPods, endpoints, releaseSelectorFor(appName, release), unhealthyNodes)
+			podTargets[release] = calculateMinServingPodTarget(
+				podsByTrafficStatus, podsInRelease, weight,
+				podsInApp, totalTargetWeight, minServingPods)
+		}
+
+		podsToLabel = trafficutil.CapReleasePodTargets(podTargets, maxTrafficPods)[releaseName]
+	}
 
 	// A TrafficTarget is ready when it has achieved a certain number of
 	// pods, not a certain weight. That's because its number of pods is
@@ -67,17 +120,15 @@ func buildTrafficShiftingStatus(
 	ready := podsReady == podsToLabel
 
 	var podsToShift map[string][]*corev1.Pod
+	var podsMissingLabel []*corev1.Pod
 	if !ready {
-		podsToShift = buildPodsToShift(podsByTrafficStatus, podsToLabel)
+		podsToShift = buildPodsToShift(podsByTrafficStatus, podsToLabel, selectionPolicy, podScorer)
+		podsMissingLabel = podsMissingTrafficLabel(
+			appPods, releaseSelectorFor(appName, releaseName), unhealthyNodes,
+			podsToLabel-podsLabeledForTraffic)
 	}
 
-	var achievedPercentage float64
-	if podsInApp == 0 {
-		achievedPercentage = 0
-	} else {
-		achievedPercentage = float64(podsReady) / float64(podsInApp)
-	}
-	achievedWeight := uint32(math.Round(achievedPercentage * float64(totalTargetWeight)))
+	achievedWeight := trafficutil.EffectiveWeight(podsReady, podsInApp, totalTargetWeight)
 
 	return trafficShiftingStatus{
 		achievedTrafficWeight: achievedWeight,
@@ -86,7 +137,92 @@ func buildTrafficShiftingStatus(
 		podsLabeled:           podsLabeledForTraffic,
 		ready:                 ready,
 		podsToShift:           podsToShift,
+		podsMissingLabel:      podsMissingLabel,
+	}
+}
+
+// withoutPodsToEnable returns podsToShift with only the pods that are to
+// stop receiving traffic, or nil if there are none of those.
+func withoutPodsToEnable(podsToShift map[string][]*corev1.Pod) map[string][]*corev1.Pod {
+	if len(podsToShift[shipper.Disabled]) == 0 {
+		return nil
+	}
+
+	return map[string][]*corev1.Pod{
+		shipper.Disabled: podsToShift[shipper.Disabled],
+	}
+}
+
+// podsMissingTrafficLabel returns up to n of the pods in releaseSelector
+// that have no shipper.PodTrafficStatusLabel at all, in name order. Pods on
+// unhealthy nodes are left out, as they wouldn't be picked to receive
+// traffic either.
+func podsMissingTrafficLabel(
+	pods []*corev1.Pod,
+	releaseSelector labels.Selector,
+	unhealthyNodes map[string]struct{},
+	n int,
+) []*corev1.Pod {
+	if n <= 0 {
+		return nil
+	}
+
+	var missing []*corev1.Pod
+	for _, pod := range pods {
+		if !releaseSelector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+
+		if _, ok := pod.Labels[shipper.PodTrafficStatusLabel]; ok {
+			continue
+		}
+
+		if _, unhealthy := unhealthyNodes[pod.Spec.NodeName]; unhealthy {
+			continue
+		}
+
+		missing = append(missing, pod)
+	}
+
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].Name < missing[j].Name
+	})
+
+	if len(missing) > n {
+		missing = missing[:n]
+	}
+
+	return missing
+}
+
+func releaseSelectorFor(appName, releaseName string) labels.Selector {
+	return labels.Set(map[string]string{
+		shipper.AppLabel:     appName,
+		shipper.ReleaseLabel: releaseName,
+	}).AsSelector()
+}
+
+// calculateMinServingPodTarget returns how many pods of a release should be
+// labeled to receive traffic according to its weight, while keeping at
+// least minServingPods of them labeled for as long as it has any weight,
+// even when that's more pods than the weight accounts for.
+func calculateMinServingPodTarget(
+	podsByTrafficStatus map[string][]*corev1.Pod,
+	podsInRelease int,
+	releaseWeight uint32,
+	podsInApp int,
+	totalWeight uint32,
+	minServingPods int,
+) int {
+	podsLabeledForTraffic := len(podsByTrafficStatus[shipper.Enabled])
+	podsToLabel := trafficutil.ReleasePodTarget(
+		podsInRelease, releaseWeight, podsInApp, totalWeight)
+
+	if podsToLabel > 0 && podsToLabel < minServingPods && podsLabeledForTraffic > podsToLabel {
+		podsToLabel = int(math.Min(float64(minServingPods), float64(podsLabeledForTraffic)))
 	}
+
+	return podsToLabel
 }
 
 // buildPodsToShift returns a map of which label has to applied to which pods
@@ -94,6 +230,8 @@ func buildTrafficShiftingStatus(
 func buildPodsToShift(
 	podsByTrafficStatus map[string][]*corev1.Pod,
 	podsToLabel int,
+	selectionPolicy SelectionPolicy,
+	podScorer PodScorer,
 ) map[string][]*corev1.Pod {
 	var oldStatus, newStatus string
 	var podsToTake int
@@ -118,23 +256,29 @@ func buildPodsToShift(
 		podsToTake = len(podsByTrafficStatus[oldStatus])
 	}
 
-	if podsToTake > 0 {
-		return map[string][]*corev1.Pod{
-			newStatus: podsByTrafficStatus[oldStatus][:podsToTake],
-		}
+	if podsToTake <= 0 {
+		return nil
 	}
 
-	return nil
+	pods := pickPodsToToggle(
+		podsByTrafficStatus[oldStatus], podsToTake,
+		newStatus == shipper.Enabled, selectionPolicy, podScorer)
+
+	return map[string][]*corev1.Pod{
+		newStatus: pods,
+	}
 }
 
 // summarizePods returns an aggregated summary of the current state of pods:
 // which pods are labeled to receive (or not receive) traffic, how many belong
 // to the specified release, and how many are ready according to the Endpoints
-// object.
+// object. Pods not receiving traffic that run on one of unhealthyNodes are
+// left out entirely, as they are not eligible to start receiving it.
 func summarizePods(
 	pods []*corev1.Pod,
 	endpoints *corev1.Endpoints,
 	releaseSelector labels.Selector,
+	unhealthyNodes map[string]struct{},
 ) (map[string][]*corev1.Pod, int, int, int) {
 	podsInRelease := make(map[string]struct{})
 	podsByTrafficStatus := make(map[string][]*corev1.Pod)
@@ -148,13 +292,17 @@ func summarizePods(
 			continue
 		}
 
-		podsInRelease[pod.Name] = struct{}{}
-
 		v, ok := pod.Labels[shipper.PodTrafficStatusLabel]
 		if !ok {
 			v = shipper.Disabled
 		}
 
+		if _, unhealthy := unhealthyNodes[pod.Spec.NodeName]; unhealthy && v == shipper.Disabled {
+			continue
+		}
+
+		podsInRelease[pod.Name] = struct{}{}
+
 		podsByTrafficStatus[v] = append(podsByTrafficStatus[v], pod)
 	}
 
@@ -203,6 +351,25 @@ func markAddressReadiness(
 	}
 }
 
+// validateClusterNames returns an UnknownClusterError for every cluster tt
+// sends traffic to that is not among clusters. Traffic meant for a cluster
+// that doesn't exist would otherwise just silently vanish.
+func validateClusterNames(tt *shipper.TrafficTarget, clusters []*shipper.Cluster) []shippererrors.UnknownClusterError {
+	known := make(map[string]struct{}, len(clusters))
+	for _, cluster := range clusters {
+		known[cluster.Name] = struct{}{}
+	}
+
+	var errs []shippererrors.UnknownClusterError
+	for _, cluster := range tt.Spec.Clusters {
+		if _, ok := known[cluster.Name]; !ok {
+			errs = append(errs, shippererrors.NewUnknownClusterError(tt, cluster.Name))
+		}
+	}
+
+	return errs
+}
+
 /*
 	Transform this (a list of each release's traffic target object in this namespace):
 	[
@@ -219,8 +386,18 @@ func markAddressReadiness(
 			reviewsapi-3: 5,
 		}
 	}
+
+	Traffic targets that don't list any clusters get the weight returned by
+	fallback in every cluster that the other traffic targets are present in.
+	Only an empty cluster list counts as not having a weight: traffic
+	targets that list a cluster with no weight keep it at zero, which is
+	how the release controller creates them, so the default never kicks in
+	for releases it manages.
 */
-func buildClusterReleaseWeights(trafficTargets []*shipper.TrafficTarget) (clusterReleaseWeights, error) {
+func buildClusterReleaseWeights(
+	trafficTargets []*shipper.TrafficTarget,
+	fallback trafficWeightFallback,
+) (clusterReleaseWeights, error) {
 	clusterReleases := map[string]map[string]uint32{}
 	releaseTT := map[string]*shipper.TrafficTarget{}
 
@@ -248,23 +425,55 @@ func buildClusterReleaseWeights(trafficTargets []*shipper.TrafficTarget) (cluste
 		}
 	}
 
+	for release, tt := range releaseTT {
+		if len(tt.Spec.Clusters) > 0 {
+			continue
+		}
+
+		weight, ok, err := fallback(tt)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+
+		for _, weights := range clusterReleases {
+			weights[release] += weight
+		}
+	}
+
 	return clusterReleaseWeights(clusterReleases), nil
 }
 
-func calculateReleasePodTarget(releasePods int, releaseWeight uint32, totalPods int, totalWeight uint32) int {
-	// What percentage of the entire fleet (across all releases) should
-	// this set of pods represent.
-	var targetPercent float64
-	if totalWeight == 0 {
-		targetPercent = 0
-	} else {
-		targetPercent = float64(releaseWeight) / float64(totalWeight) * 100
+// clusterTrafficTargetsFor returns the clusters a traffic target should be
+// processed on. That's usually just what its spec says, but traffic targets
+// that don't list any clusters fall back to whatever weight they ended up
+// with in clusterReleaseWeights.
+func clusterTrafficTargetsFor(
+	tt *shipper.TrafficTarget,
+	clusterReleaseWeights clusterReleaseWeights,
+) []shipper.ClusterTrafficTarget {
+	if len(tt.Spec.Clusters) > 0 {
+		return tt.Spec.Clusters
 	}
 
-	// Round up to the nearest pod, clamped to the number of pods this
-	// release has.
-	targetPods := int(replicas.CalculateDesiredReplicaCount(uint(totalPods), float64(targetPercent)))
-	targetPods = int(math.Min(float64(releasePods), float64(targetPods)))
+	release := tt.Labels[shipper.ReleaseLabel]
+	clusters := []shipper.ClusterTrafficTarget{}
+	for cluster, weights := range clusterReleaseWeights {
+		weight, ok := weights[release]
+		if !ok {
+			continue
+		}
+
+		clusters = append(clusters, shipper.ClusterTrafficTarget{
+			Name:   cluster,
+			Weight: weight,
+		})
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Name < clusters[j].Name
+	})
 
-	return targetPods
+	return clusters
 }
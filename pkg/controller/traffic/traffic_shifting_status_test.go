@@ -2,12 +2,14 @@ package traffic
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
 	shippertesting "github.com/bookingcom/shipper/pkg/testing"
 )
 
@@ -190,6 +192,70 @@ func TestTrafficShiftingUnevedPodsEqualWeights(t *testing.T) {
 	})
 }
 
+func TestTrafficShiftingRespectsMinServingPods(t *testing.T) {
+	// Without a floor, the first release would go down to 2 pods in a
+	// single step.
+	runBuildTestTrafficShiftingStatusWithMinServingPods(t, 5, []trafficShiftingStatusTestExpectation{
+		{
+			Release:               release{weight: 1, podCount: podStatus{withTraffic: 10}},
+			Ready:                 false,
+			AchievedTrafficWeight: 5,
+			PodsReady:             10,
+			PodsLabeled:           10,
+			PodsToShift:           podsToShift{0, 5},
+		},
+		{
+			Release:               release{weight: 9, podCount: podStatus{withTraffic: 10}},
+			Ready:                 true,
+			AchievedTrafficWeight: 5,
+			PodsReady:             10,
+			PodsLabeled:           10,
+		},
+	})
+}
+
+func TestTrafficShiftingMinServingPodsAboveReleaseSize(t *testing.T) {
+	// Releases with fewer pods than the floor keep all of them serving,
+	// and with them more traffic than their weight asks for, until their
+	// weight drops to zero.
+	runBuildTestTrafficShiftingStatusWithMinServingPods(t, 5, []trafficShiftingStatusTestExpectation{
+		{
+			Release:               release{weight: 1, podCount: podStatus{withTraffic: 3}},
+			Ready:                 true,
+			AchievedTrafficWeight: 5,
+			PodsReady:             3,
+			PodsLabeled:           3,
+		},
+		{
+			Release:               release{weight: 9, podCount: podStatus{withTraffic: 3}},
+			Ready:                 true,
+			AchievedTrafficWeight: 5,
+			PodsReady:             3,
+			PodsLabeled:           3,
+		},
+	})
+}
+
+func TestTrafficShiftingMinServingPodsDoesNotPreventDraining(t *testing.T) {
+	runBuildTestTrafficShiftingStatusWithMinServingPods(t, 5, []trafficShiftingStatusTestExpectation{
+		{
+			Release:               release{weight: 0, podCount: podStatus{withTraffic: 10}},
+			Ready:                 false,
+			AchievedTrafficWeight: 5,
+			PodsReady:             10,
+			PodsLabeled:           10,
+			PodsToShift:           podsToShift{0, 10},
+		},
+		{
+			Release:               release{weight: 10, podCount: podStatus{withTraffic: 10}},
+			Ready:                 true,
+			AchievedTrafficWeight: 5,
+			PodsReady:             10,
+			PodsLabeled:           10,
+		},
+	})
+}
+
 func TestTrafficShiftingPodsLabeledButNotReady(t *testing.T) {
 	releaseName := "foobar"
 	releaseWeight := uint32(10)
@@ -232,7 +298,7 @@ func TestTrafficShiftingPodsLabeledButNotReady(t *testing.T) {
 				releaseName: releaseWeight,
 			},
 		},
-		endpoints, appPods,
+		endpoints, appPods, 0, nil, SelectionPolicyInOrder, nil, 0,
 	)
 
 	assertTrafficShiftingStatusExpectation(t, releaseName,
@@ -270,7 +336,7 @@ func TestTrafficShiftingUnmanagedPods(t *testing.T) {
 				releaseName: releaseWeight,
 			},
 		},
-		endpoints, appPods,
+		endpoints, appPods, 0, nil, SelectionPolicyInOrder, nil, 0,
 	)
 
 	assertTrafficShiftingStatusExpectation(t, releaseName,
@@ -281,9 +347,147 @@ func TestTrafficShiftingUnmanagedPods(t *testing.T) {
 		}, trafficStatus)
 }
 
+func TestTrafficShiftingExcludesPodsOnUnhealthyNodes(t *testing.T) {
+	releaseName := "foobar"
+	releaseWeight := uint32(10)
+
+	appPods := buildPods(shippertesting.TestApp, releaseName, 2, noTraffic)
+	appPods[0].Spec.NodeName = "healthy-node"
+	appPods[1].Spec.NodeName = "unhealthy-node"
+
+	// Both pods are Ready themselves, it's the node of the second one
+	// that isn't.
+	for _, pod := range appPods {
+		pod.Status.Conditions = []corev1.PodCondition{
+			{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+		}
+	}
+
+	endpoints := buildEndpoints(shippertesting.TestApp)
+
+	trafficStatus := buildTrafficShiftingStatus(
+		shippertesting.TestCluster, shippertesting.TestApp, releaseName,
+		clusterReleaseWeights{
+			shippertesting.TestCluster: map[string]uint32{
+				releaseName: releaseWeight,
+			},
+		},
+		endpoints, appPods, 0,
+		map[string]struct{}{"unhealthy-node": {}},
+		SelectionPolicyInOrder, nil, 0,
+	)
+
+	assertTrafficShiftingStatusExpectation(t, releaseName,
+		trafficShiftingStatusTestExpectation{
+			Release:     release{weight: releaseWeight},
+			Ready:       false,
+			PodsToShift: podsToShift{1, 0},
+		}, trafficStatus)
+
+	enabled := trafficStatus.podsToShift[shipper.Enabled]
+	if len(enabled) != 1 || enabled[0].Name != appPods[0].Name {
+		t.Errorf("expected only pod %q to be picked to receive traffic", appPods[0].Name)
+	}
+}
+
+func TestPodsMissingTrafficLabel(t *testing.T) {
+	releaseName := "foobar"
+	selector := releaseSelectorFor(shippertesting.TestApp, releaseName)
+
+	pods := buildPods(shippertesting.TestApp, releaseName, 4, noTraffic)
+	for i, pod := range pods {
+		pod.Name = fmt.Sprintf("%s-%d", releaseName, i)
+	}
+
+	pods[0].Spec.NodeName = "unhealthy-node"
+	for _, pod := range pods[:3] {
+		delete(pod.Labels, shipper.PodTrafficStatusLabel)
+	}
+
+	unhealthyNodes := map[string]struct{}{"unhealthy-node": {}}
+
+	missing := podsMissingTrafficLabel(pods, selector, unhealthyNodes, 10)
+	if len(missing) != 2 || missing[0].Name != pods[1].Name || missing[1].Name != pods[2].Name {
+		t.Errorf("expected pods %q and %q to be missing a label, got %v", pods[1].Name, pods[2].Name, missing)
+	}
+
+	if missing := podsMissingTrafficLabel(pods, selector, unhealthyNodes, 1); len(missing) != 1 {
+		t.Errorf("expected at most 1 pod to be returned, got %d", len(missing))
+	}
+
+	if missing := podsMissingTrafficLabel(pods, selector, unhealthyNodes, 0); len(missing) != 0 {
+		t.Errorf("expected no pods to be returned when none should serve, got %d", len(missing))
+	}
+}
+
+func TestBuildClusterReleaseWeightsWithFallback(t *testing.T) {
+	const (
+		clusterA = "cluster-a"
+		clusterB = "cluster-b"
+	)
+
+	trafficTargets := []*shipper.TrafficTarget{
+		buildTrafficTarget(shippertesting.TestApp, "release-a",
+			map[string]uint32{clusterA: 60, clusterB: 40}),
+		buildTrafficTarget(shippertesting.TestApp, "release-b",
+			map[string]uint32{}),
+		// Traffic targets that list clusters, even at no weight, never
+		// get the default.
+		buildTrafficTarget(shippertesting.TestApp, "release-c",
+			map[string]uint32{clusterA: 0}),
+	}
+
+	fallback := func(tt *shipper.TrafficTarget) (uint32, bool, error) {
+		return 10, true, nil
+	}
+
+	tests := []struct {
+		name     string
+		fallback trafficWeightFallback
+		expected clusterReleaseWeights
+	}{
+		{
+			"without a default",
+			noTrafficWeightFallback,
+			clusterReleaseWeights{
+				clusterA: {"release-a": 60, "release-c": 0},
+				clusterB: {"release-a": 40},
+			},
+		},
+		{
+			"with a default",
+			fallback,
+			clusterReleaseWeights{
+				clusterA: {"release-a": 60, "release-b": 10, "release-c": 0},
+				clusterB: {"release-a": 40, "release-b": 10},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		weights, err := buildClusterReleaseWeights(trafficTargets, tt.fallback)
+		if err != nil {
+			t.Fatalf("%s: cannot build cluster release weights: %s", tt.name, err)
+		}
+
+		eq, diff := shippertesting.DeepEqualDiff(tt.expected, weights)
+		if !eq {
+			t.Errorf("%s: cluster release weights differ from expected:\n%s", tt.name, diff)
+		}
+	}
+}
+
 func runBuildTestTrafficShiftingStatus(
 	t *testing.T,
 	expectations []trafficShiftingStatusTestExpectation,
+) {
+	runBuildTestTrafficShiftingStatusWithMinServingPods(t, 0, expectations)
+}
+
+func runBuildTestTrafficShiftingStatusWithMinServingPods(
+	t *testing.T,
+	minServingPods int,
+	expectations []trafficShiftingStatusTestExpectation,
 ) {
 	var appPods []*corev1.Pod
 	endpoints := buildEndpoints(shippertesting.TestApp)
@@ -318,7 +522,7 @@ func runBuildTestTrafficShiftingStatus(
 		appPods = append(appPods, podsWithoutTraffic...)
 	}
 
-	clusterReleaseWeights, err := buildClusterReleaseWeights(trafficTargets)
+	clusterReleaseWeights, err := buildClusterReleaseWeights(trafficTargets, noTrafficWeightFallback)
 	if err != nil {
 		t.Fatalf("cannot build cluster release weights: %s", err)
 	}
@@ -330,6 +534,7 @@ func runBuildTestTrafficShiftingStatus(
 			shippertesting.TestCluster, shippertesting.TestApp, relName,
 			clusterReleaseWeights,
 			endpoints, appPods,
+			minServingPods, nil, SelectionPolicyInOrder, nil, 0,
 		)
 
 		assertTrafficShiftingStatusExpectation(t, relName, expectation, trafficStatus)
@@ -361,3 +566,125 @@ func assertTrafficShiftingStatusExpectation(
 			relName, diff)
 	}
 }
+
+func TestBuildPodsToShiftPreservesNodeSpread(t *testing.T) {
+	pods := buildPods(shippertesting.TestApp, "foobar", 6, withTraffic)
+	for i, node := range []string{"node-b", "node-c", "node-a", "node-a", "node-a", "node-a"} {
+		pods[i].Spec.NodeName = node
+	}
+
+	podsByTrafficStatus := map[string][]*corev1.Pod{
+		shipper.Enabled: pods,
+	}
+
+	servingNodes := func(disabled []*corev1.Pod) map[string]int {
+		demoted := make(map[string]struct{})
+		for _, pod := range disabled {
+			demoted[pod.Name] = struct{}{}
+		}
+
+		nodes := make(map[string]int)
+		for _, pod := range pods {
+			if _, ok := demoted[pod.Name]; !ok {
+				nodes[pod.Spec.NodeName]++
+			}
+		}
+
+		return nodes
+	}
+
+	// Without any node awareness, pods are demoted in the order they
+	// come in, which leaves every serving pod on the same node.
+	podsToShift := buildPodsToShift(podsByTrafficStatus, 3, SelectionPolicyInOrder, nil)
+	if nodes := servingNodes(podsToShift[shipper.Disabled]); len(nodes) != 1 {
+		t.Fatalf("expected serving pods to end up on a single node, got %v", nodes)
+	}
+
+	podsToShift = buildPodsToShift(podsByTrafficStatus, 3, SelectionPolicyNodeSpread, nil)
+	disabled := podsToShift[shipper.Disabled]
+	if len(disabled) != 3 {
+		t.Fatalf("expected 3 pods to be demoted, got %d", len(disabled))
+	}
+
+	for _, pod := range disabled {
+		if pod.Spec.NodeName != "node-a" {
+			t.Errorf("expected pod %q on %q not to be demoted", pod.Name, pod.Spec.NodeName)
+		}
+	}
+
+	expected := map[string]int{"node-a": 1, "node-b": 1, "node-c": 1}
+	if nodes := servingNodes(disabled); !reflect.DeepEqual(expected, nodes) {
+		t.Errorf("expected serving pods to be spread as %v, got %v", expected, nodes)
+	}
+
+	// Spread only matters when demoting pods: promoting them is left
+	// untouched.
+	podsByTrafficStatus = map[string][]*corev1.Pod{
+		shipper.Disabled: pods,
+	}
+	podsToShift = buildPodsToShift(podsByTrafficStatus, 3, SelectionPolicyNodeSpread, nil)
+	if enabled := podsToShift[shipper.Enabled]; len(enabled) != 3 || enabled[0] != pods[0] {
+		t.Errorf("expected the first 3 pods to be promoted")
+	}
+}
+
+func TestValidateClusterNames(t *testing.T) {
+	tt := buildTrafficTarget(shippertesting.TestApp, "foobar",
+		map[string]uint32{"cluster-a": 50, "bogus-cluster": 50})
+
+	clusters := []*shipper.Cluster{buildCluster("cluster-a"), buildCluster("cluster-b")}
+
+	errs := validateClusterNames(tt, clusters)
+	if len(errs) != 1 {
+		t.Fatalf("expected exactly 1 error, got %d: %v", len(errs), errs)
+	}
+
+	expected := shippererrors.NewUnknownClusterError(tt, "bogus-cluster")
+	if !shippererrors.IsUnknownClusterError(errs[0]) || errs[0].Error() != expected.Error() {
+		t.Errorf("expected error %q, got %q", expected, errs[0])
+	}
+
+	clusters = append(clusters, buildCluster("bogus-cluster"))
+	if errs := validateClusterNames(tt, clusters); len(errs) != 0 {
+		t.Errorf("expected no errors once every cluster is registered, got %v", errs)
+	}
+}
+
+func TestTrafficShiftingCapsTotalTrafficPods(t *testing.T) {
+	const maxTrafficPods = 4
+
+	// Without a cap, both releases would get all of their 6 pods
+	// labeled, 12 in total.
+	appPods := append(
+		buildPods(shippertesting.TestApp, "incumbent", 6, noTraffic),
+		buildPods(shippertesting.TestApp, "contender", 6, noTraffic)...)
+
+	weights := clusterReleaseWeights{
+		shippertesting.TestCluster: map[string]uint32{
+			"incumbent": 50,
+			"contender": 50,
+		},
+	}
+
+	endpoints := buildEndpoints(shippertesting.TestApp)
+
+	total := 0
+	for _, releaseName := range []string{"incumbent", "contender"} {
+		trafficStatus := buildTrafficShiftingStatus(
+			shippertesting.TestCluster, shippertesting.TestApp, releaseName,
+			weights, endpoints, appPods, 0, nil, SelectionPolicyInOrder, nil, maxTrafficPods,
+		)
+
+		enabled := len(trafficStatus.podsToShift[shipper.Enabled])
+		if enabled != maxTrafficPods/2 {
+			t.Errorf("expected release %q to get %d pods labeled for traffic, got %d",
+				releaseName, maxTrafficPods/2, enabled)
+		}
+
+		total += enabled
+	}
+
+	if total > maxTrafficPods {
+		t.Errorf("expected at most %d pods labeled for traffic, got %d", maxTrafficPods, total)
+	}
+}
@@ -99,6 +99,24 @@ func shiftPodInEndpoints(pod *corev1.Pod, endpoints *corev1.Endpoints) *corev1.E
 	return endpoints
 }
 
+// addCluster adds a cluster to f, both as a cluster the controller can
+// reach and as a Cluster object registered in the management cluster.
+func addCluster(f *shippertesting.ControllerTestFixture, name string) *shippertesting.FakeCluster {
+	f.ShipperClient.Tracker().Add(buildCluster(name))
+	return f.AddNamedCluster(name)
+}
+
+func buildCluster(name string) *shipper.Cluster {
+	return &shipper.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Status: shipper.ClusterStatus{
+			InService: true,
+		},
+	}
+}
+
 func buildTrafficTarget(app, release string, clusterWeights map[string]uint32) *shipper.TrafficTarget {
 	clusters := make([]shipper.ClusterTrafficTarget, 0, len(clusterWeights))
 
@@ -146,6 +164,7 @@ func buildSuccessStatus(clusters []shipper.ClusterTrafficTarget) shipper.Traffic
 			TargetConditionOperational,
 			TargetConditionReady,
 		},
+		ShiftProgress: 100,
 	}
 }
 
@@ -0,0 +1,42 @@
+package traffic
+
+import (
+	"fmt"
+	"sort"
+
+	trafficutil "github.com/bookingcom/shipper/pkg/util/traffic"
+)
+
+// ValidateWeightsAgainstFleet checks whether the traffic weights of the
+// releases sharing a cluster can be honoured by a fleet of podCount pods,
+// using the same rounding the controller applies when it picks how many
+// pods of each release should receive traffic. It returns an error for
+// every release whose non-zero weight would round to zero pods, sorted by
+// release name.
+func ValidateWeightsAgainstFleet(weights map[string]uint32, podCount int) []error {
+	var totalWeight uint32
+	releases := make([]string, 0, len(weights))
+	for release, weight := range weights {
+		totalWeight += weight
+		releases = append(releases, release)
+	}
+
+	sort.Strings(releases)
+
+	var errs []error
+	for _, release := range releases {
+		weight := weights[release]
+		if weight == 0 {
+			continue
+		}
+
+		pods := trafficutil.ReleasePodTarget(podCount, weight, podCount, totalWeight)
+		if pods == 0 {
+			errs = append(errs, fmt.Errorf(
+				"release %q has weight %d out of %d, but would get none of the %d pods in the fleet",
+				release, weight, totalWeight, podCount))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,62 @@
+package traffic
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateWeightsAgainstFleet(t *testing.T) {
+	tests := []struct {
+		name     string
+		weights  map[string]uint32
+		podCount int
+		invalid  []string
+	}{
+		{
+			name:     "even split",
+			weights:  map[string]uint32{"foo-a": 50, "foo-b": 50},
+			podCount: 10,
+		},
+		{
+			name:     "weights matching pod count",
+			weights:  map[string]uint32{"foo-a": 3, "foo-b": 7},
+			podCount: 10,
+		},
+		{
+			name:     "release without weight",
+			weights:  map[string]uint32{"foo-a": 0, "foo-b": 100},
+			podCount: 3,
+		},
+		{
+			name:     "weights rounding up",
+			weights:  map[string]uint32{"foo-a": 1, "foo-b": 2},
+			podCount: 2,
+		},
+		{
+			name:     "small weight rounding up to a pod",
+			weights:  map[string]uint32{"foo-a": 1, "foo-b": 99},
+			podCount: 10,
+		},
+		{
+			name:     "empty fleet",
+			weights:  map[string]uint32{"foo-a": 0, "foo-b": 10},
+			podCount: 0,
+			invalid:  []string{"foo-b"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs := ValidateWeightsAgainstFleet(test.weights, test.podCount)
+			if len(errs) != len(test.invalid) {
+				t.Fatalf("expected %d errors, got %d: %v", len(test.invalid), len(errs), errs)
+			}
+
+			for i, err := range errs {
+				if !strings.Contains(err.Error(), `"`+test.invalid[i]+`"`) {
+					t.Errorf("expected error %d to be about release %q, got %q", i, test.invalid[i], err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"context"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// NewWriteBudget returns the token bucket controllers in the same process
+// share and draw from before every write they send to the API server, so
+// all of them together never send more than qps writes a second, with
+// bursts of up to burst of them. Writes are never held back when qps is 0.
+func NewWriteBudget(qps float32, burst int) flowcontrol.RateLimiter {
+	if qps <= 0 {
+		return flowcontrol.NewFakeAlwaysRateLimiter()
+	}
+
+	return flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+}
+
+// WorkerContext returns a context that's done as soon as stopCh is closed.
+// Controller workers wait on their write budget with it, so they give up
+// on writes they're holding back once they're asked to stop.
+func WorkerContext(stopCh <-chan struct{}) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
@@ -50,6 +50,14 @@ var Cluster = &apiextensionv1beta1.CustomResourceDefinition{
 									},
 								},
 							},
+							"maxTrafficPods": apiextensionv1beta1.JSONSchemaProps{
+								Type:    "integer",
+								Minimum: &zero,
+							},
+							"maxReplicas": apiextensionv1beta1.JSONSchemaProps{
+								Type:    "integer",
+								Minimum: &zero,
+							},
 							"scheduler": apiextensionv1beta1.JSONSchemaProps{
 								Type: "object",
 								Properties: map[string]apiextensionv1beta1.JSONSchemaProps{
@@ -25,6 +25,9 @@ var Release = &apiextensionv1beta1.CustomResourceDefinition{
 			ShortNames: []string{"rel"},
 			Categories: []string{"all", "shipper"},
 		},
+		Subresources: &apiextensionv1beta1.CustomResourceSubresources{
+			Status: &apiextensionv1beta1.CustomResourceSubresourceStatus{},
+		},
 		Validation: &apiextensionv1beta1.CustomResourceValidation{
 			OpenAPIV3Schema: &apiextensionv1beta1.JSONSchemaProps{
 				Properties: map[string]apiextensionv1beta1.JSONSchemaProps{
@@ -64,6 +67,12 @@ var Release = &apiextensionv1beta1.CustomResourceDefinition{
 				Description: "The list of clusters where a release is supposed to be rolled out as per strategy.",
 				JSONPath:    ".metadata.annotations.shipper\\.booking\\.com\\/release\\.clusters",
 			},
+			apiextensionv1beta1.CustomResourceColumnDefinition{
+				Name:        "Traffic",
+				Type:        "string",
+				Description: "The achieved and desired traffic weights of a release in each of its clusters.",
+				JSONPath:    ".status.trafficSummary.distribution",
+			},
 			apiextensionv1beta1.CustomResourceColumnDefinition{
 				Name:        "Waiting",
 				Type:        "string",
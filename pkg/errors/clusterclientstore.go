@@ -2,6 +2,8 @@ package errors
 
 import (
 	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 type ClusterNotInStoreError struct {
@@ -74,3 +76,28 @@ func IsClusterClientStoreError(err error) bool {
 
 	return false
 }
+
+type TargetClusterCacheNotSyncedError struct {
+	clusterName string
+	gvk         schema.GroupVersionKind
+}
+
+func (e TargetClusterCacheNotSyncedError) Error() string {
+	return fmt.Sprintf("informer cache for %s in cluster %q has not synced yet", e.gvk.Kind, e.clusterName)
+}
+
+func (e TargetClusterCacheNotSyncedError) ShouldRetry() bool {
+	return true
+}
+
+func NewTargetClusterCacheNotSyncedError(clusterName string, gvk schema.GroupVersionKind) error {
+	return TargetClusterCacheNotSyncedError{
+		clusterName: clusterName,
+		gvk:         gvk,
+	}
+}
+
+func IsTargetClusterCacheNotSyncedError(err error) bool {
+	_, ok := err.(TargetClusterCacheNotSyncedError)
+	return ok
+}
@@ -217,3 +217,57 @@ func NewInconsistentReleaseTargetStep(relKey string, gotTargetStep, wantTargetSt
 		wantTargetStep: wantTargetStep,
 	}
 }
+
+type MismatchedApplicationError struct {
+	relKey     string
+	relApp     string
+	siblingKey string
+	siblingApp string
+}
+
+func (e MismatchedApplicationError) Error() string {
+	return fmt.Sprintf("Release %s belongs to application %q, but its sibling %s belongs to application %q",
+		e.relKey, e.relApp, e.siblingKey, e.siblingApp)
+}
+
+func (e MismatchedApplicationError) ShouldRetry() bool {
+	return false
+}
+
+func IsMismatchedApplicationError(err error) bool {
+	_, ok := err.(MismatchedApplicationError)
+	return ok
+}
+
+func NewMismatchedApplicationError(relKey, relApp, siblingKey, siblingApp string) MismatchedApplicationError {
+	return MismatchedApplicationError{
+		relKey:     relKey,
+		relApp:     relApp,
+		siblingKey: siblingKey,
+		siblingApp: siblingApp,
+	}
+}
+
+type MissingAppLabelError struct {
+	relKey string
+}
+
+func (e MissingAppLabelError) Error() string {
+	return fmt.Sprintf("Release %s has no %q label, so there is no telling which application it belongs to",
+		e.relKey, shipper.AppLabel)
+}
+
+func (e MissingAppLabelError) ShouldRetry() bool {
+	return false
+}
+
+func IsMissingAppLabelError(err error) bool {
+	_, ok := err.(MissingAppLabelError)
+	return ok
+}
+
+func NewMissingAppLabelError(relKey string) MissingAppLabelError {
+	return MissingAppLabelError{
+		relKey: relKey,
+	}
+}
@@ -50,3 +50,72 @@ func NewMultipleTrafficTargetsForReleaseError(ns, releaseName string, ttNames []
 		ttNames:     ttNames,
 	}
 }
+
+type UnknownClusterError struct {
+	tt          *shipper.TrafficTarget
+	clusterName string
+}
+
+func (e UnknownClusterError) Error() string {
+	return fmt.Sprintf(
+		`TrafficTarget "%s/%s" references cluster %q, which is not registered in the management cluster`,
+		e.tt.GetNamespace(), e.tt.GetName(), e.clusterName)
+}
+
+func (e UnknownClusterError) ShouldRetry() bool {
+	return false
+}
+
+// ClusterName returns the name of the cluster that isn't registered.
+func (e UnknownClusterError) ClusterName() string {
+	return e.clusterName
+}
+
+func NewUnknownClusterError(tt *shipper.TrafficTarget, clusterName string) UnknownClusterError {
+	return UnknownClusterError{
+		tt:          tt,
+		clusterName: clusterName,
+	}
+}
+
+func IsUnknownClusterError(err error) bool {
+	_, ok := err.(UnknownClusterError)
+	return ok
+}
+
+type ConflictingTrafficWeightsError struct {
+	primary     *shipper.TrafficTarget
+	extra       *shipper.TrafficTarget
+	clusterName string
+	weights     [2]uint32
+}
+
+func (e ConflictingTrafficWeightsError) Error() string {
+	return fmt.Sprintf(
+		`TrafficTargets "%s/%s" and "%s/%s" want different weights in cluster %q: %d and %d`,
+		e.primary.GetNamespace(), e.primary.GetName(),
+		e.extra.GetNamespace(), e.extra.GetName(),
+		e.clusterName, e.weights[0], e.weights[1])
+}
+
+func (e ConflictingTrafficWeightsError) ShouldRetry() bool {
+	return false
+}
+
+func NewConflictingTrafficWeightsError(
+	primary, extra *shipper.TrafficTarget,
+	clusterName string,
+	primaryWeight, extraWeight uint32,
+) ConflictingTrafficWeightsError {
+	return ConflictingTrafficWeightsError{
+		primary:     primary,
+		extra:       extra,
+		clusterName: clusterName,
+		weights:     [2]uint32{primaryWeight, extraWeight},
+	}
+}
+
+func IsConflictingTrafficWeightsError(err error) bool {
+	_, ok := err.(ConflictingTrafficWeightsError)
+	return ok
+}
@@ -0,0 +1,34 @@
+package prometheus
+
+import (
+	prom "github.com/prometheus/client_golang/prometheus"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+const unknownLabelValue = "unknown"
+
+// ReleaseLabelNames are the names of the labels MetricLabels returns, for
+// declaring metric vectors about releases with.
+var ReleaseLabelNames = []string{"application", "release", "namespace", "phase"}
+
+// MetricLabels returns the labels every metric about rel should carry, so
+// they're the same across controllers. Anything that can't be told from
+// rel is "unknown".
+func MetricLabels(rel *shipper.Release) prom.Labels {
+	labels := prom.Labels{
+		"application": rel.Labels[shipper.AppLabel],
+		"release":     rel.Name,
+		"namespace":   rel.Namespace,
+		"phase":       string(releaseutil.GetReleasePhase(rel)),
+	}
+
+	for name, value := range labels {
+		if value == "" {
+			labels[name] = unknownLabelValue
+		}
+	}
+
+	return labels
+}
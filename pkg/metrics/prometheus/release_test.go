@@ -0,0 +1,69 @@
+package prometheus
+
+import (
+	"reflect"
+	"testing"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestMetricLabels(t *testing.T) {
+	tests := []struct {
+		name     string
+		rel      *shipper.Release
+		expected prom.Labels
+	}{
+		{
+			name: "everything there",
+			rel: &shipper.Release{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-release",
+					Namespace: "test-namespace",
+					Labels:    map[string]string{shipper.AppLabel: "test-app"},
+				},
+				Status: shipper.ReleaseStatus{
+					Conditions: []shipper.ReleaseCondition{
+						{Type: shipper.ReleaseConditionTypeComplete, Status: corev1.ConditionTrue},
+					},
+				},
+			},
+			expected: prom.Labels{
+				"application": "test-app",
+				"release":     "test-release",
+				"namespace":   "test-namespace",
+				"phase":       "Complete",
+			},
+		},
+		{
+			name: "missing application and namespace",
+			rel: &shipper.Release{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-release",
+				},
+			},
+			expected: prom.Labels{
+				"application": "unknown",
+				"release":     "test-release",
+				"namespace":   "unknown",
+				"phase":       "WaitingForScheduling",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		labels := MetricLabels(tt.rel)
+		if !reflect.DeepEqual(labels, tt.expected) {
+			t.Errorf("%s: expected labels %v, got %v", tt.name, tt.expected, labels)
+		}
+
+		for _, name := range ReleaseLabelNames {
+			if _, ok := labels[name]; !ok {
+				t.Errorf("%s: expected label %q to be set", tt.name, name)
+			}
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package application
 
 import (
+	"fmt"
 	"strconv"
 
+	"k8s.io/apimachinery/pkg/labels"
+
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
 	"github.com/bookingcom/shipper/pkg/errors"
 )
@@ -25,6 +28,58 @@ func SetHighestObservedGeneration(app *shipper.Application, generation int) {
 	app.Annotations[shipper.AppHighestObservedGenerationAnnotation] = strconv.Itoa(generation)
 }
 
+// DefaultTrafficPolicyStep is the default traffic policy that gives the
+// contender of an application the traffic weight the target step of its
+// strategy asks for, in every cluster.
+const DefaultTrafficPolicyStep = "step"
+
+// GetDefaultTrafficPolicy returns the traffic policy an application wants
+// its releases to follow when their traffic targets don't specify any
+// weights, and whether it has such a default at all.
+func GetDefaultTrafficPolicy(app *shipper.Application) (string, bool, error) {
+	policy, ok := app.Annotations[shipper.AppDefaultTrafficPolicyAnnotation]
+	if !ok {
+		return "", false, nil
+	}
+
+	if policy != DefaultTrafficPolicyStep {
+		err := fmt.Errorf("unknown traffic policy %q", policy)
+		return "", false, errors.NewApplicationAnnotationError(app.Name, shipper.AppDefaultTrafficPolicyAnnotation, err)
+	}
+
+	return policy, true, nil
+}
+
+// DefaultProductionServiceSelector returns the selector the production
+// services of appName are found by unless the application says otherwise.
+func DefaultProductionServiceSelector(appName string) labels.Selector {
+	return labels.Set{
+		shipper.AppLabel: appName,
+		shipper.LBLabel:  shipper.LBForProduction,
+	}.AsSelector()
+}
+
+// GetProductionServiceSelector returns the selector the production services
+// of app are found by: the one in its traffic service selector annotation,
+// if any, or DefaultProductionServiceSelector otherwise. The annotation
+// can't be empty, as that would select every service in the namespace.
+func GetProductionServiceSelector(app *shipper.Application) (labels.Selector, error) {
+	rawSelector, ok := app.Annotations[shipper.AppTrafficServiceSelectorAnnotation]
+	if !ok {
+		return DefaultProductionServiceSelector(app.Name), nil
+	}
+
+	selector, err := labels.Parse(rawSelector)
+	if err == nil && selector.Empty() {
+		err = fmt.Errorf("selector must not be empty")
+	}
+	if err != nil {
+		return nil, errors.NewApplicationAnnotationError(app.Name, shipper.AppTrafficServiceSelectorAnnotation, err)
+	}
+
+	return selector, nil
+}
+
 func CopyEnvironment(app *shipper.Application, rel *shipper.Release) {
 	app.Spec.Template = *(rel.Spec.Environment.DeepCopy())
 }
@@ -0,0 +1,72 @@
+package application
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestGetProductionServiceSelector(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    string
+		expectErr   bool
+	}{
+		{
+			name:     "no annotation",
+			expected: "shipper-app=test-app,shipper-lb=production",
+		},
+		{
+			name: "custom selector",
+			annotations: map[string]string{
+				shipper.AppTrafficServiceSelectorAnnotation: "team.example.com/entrypoint=test-app",
+			},
+			expected: "team.example.com/entrypoint=test-app",
+		},
+		{
+			name: "empty selector",
+			annotations: map[string]string{
+				shipper.AppTrafficServiceSelectorAnnotation: "",
+			},
+			expectErr: true,
+		},
+		{
+			name: "malformed selector",
+			annotations: map[string]string{
+				shipper.AppTrafficServiceSelectorAnnotation: "app in (",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &shipper.Application{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-app",
+					Namespace:   "test-namespace",
+					Annotations: tt.annotations,
+				},
+			}
+
+			selector, err := GetProductionServiceSelector(app)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got selector %q", selector)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got := selector.String(); got != tt.expected {
+				t.Errorf("expected selector %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package application
+
+import (
+	"fmt"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+// ValidateReleaseGraph checks that rels, the releases of app, are in a state
+// shipper can make sense of, and returns everything it finds wrong with them:
+//
+//   - every release belongs to app and has a generation of its own;
+//   - there's at most one contender, that is, a single release with the
+//     highest generation, and app has observed it;
+//   - the incumbent, if any, is older than the contender;
+//   - the history of app chains all the releases together in the order of
+//     their generations.
+func ValidateReleaseGraph(app *shipper.Application, rels []*shipper.Release) []error {
+	var errs []error
+
+	generations := make(map[int][]string, len(rels))
+	relGenerations := make(map[string]int, len(rels))
+	for _, rel := range rels {
+		if owner := rel.Labels[shipper.AppLabel]; owner != app.Name {
+			errs = append(errs, fmt.Errorf("release %q belongs to application %q, not %q",
+				rel.Name, owner, app.Name))
+		}
+
+		generation, err := releaseutil.GetGeneration(rel)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		generations[generation] = append(generations[generation], rel.Name)
+		relGenerations[rel.Name] = generation
+	}
+
+	// Releases without a generation can't be put in order, so there's no
+	// telling which role they play.
+	if len(relGenerations) < len(rels) || len(rels) == 0 {
+		return errs
+	}
+
+	sorted := releaseutil.SortByGenerationDescending(rels)
+
+	contender, err := GetContender(app.Name, sorted)
+	if err != nil {
+		return append(errs, err)
+	}
+
+	contenderGeneration := relGenerations[contender.Name]
+	for generation, names := range generations {
+		if len(names) < 2 {
+			continue
+		}
+
+		if generation == contenderGeneration {
+			errs = append(errs, fmt.Errorf("releases %v all claim to be the contender with generation %d",
+				names, generation))
+		} else {
+			errs = append(errs, fmt.Errorf("releases %v share generation %d", names, generation))
+		}
+	}
+
+	if observed, err := GetHighestObservedGeneration(app); err != nil {
+		errs = append(errs, err)
+	} else if observed < contenderGeneration {
+		errs = append(errs, fmt.Errorf("contender %q has generation %d, past the highest generation %d observed by the application",
+			contender.Name, contenderGeneration, observed))
+	}
+
+	if incumbent, err := GetIncumbent(app.Name, sorted); err == nil &&
+		relGenerations[incumbent.Name] >= contenderGeneration {
+		errs = append(errs, fmt.Errorf("incumbent %q is not older than contender %q",
+			incumbent.Name, contender.Name))
+	}
+
+	history := ReleasesToApplicationHistory(rels)
+	if len(app.Status.History) != len(history) {
+		errs = append(errs, fmt.Errorf("application history %v does not chain releases %v",
+			app.Status.History, history))
+		return errs
+	}
+
+	for i := range history {
+		if app.Status.History[i] != history[i] {
+			errs = append(errs, fmt.Errorf("application history %v does not chain releases %v: expected %q at position %d, got %q",
+				app.Status.History, history, history[i], i, app.Status.History[i]))
+			break
+		}
+	}
+
+	return errs
+}
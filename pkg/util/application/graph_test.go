@@ -0,0 +1,139 @@
+package application
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestValidateReleaseGraph(t *testing.T) {
+	const namespace = "test-namespace"
+
+	buildApp := func(highestObserved string, history ...string) *shipper.Application {
+		return &shipper.Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: namespace,
+				Annotations: map[string]string{
+					shipper.AppHighestObservedGenerationAnnotation: highestObserved,
+				},
+			},
+			Status: shipper.ApplicationStatus{
+				History: history,
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		app      *shipper.Application
+		releases []*shipper.Release
+		// expected holds a fragment of every error we expect, in no
+		// particular order.
+		expected []string
+	}{
+		{
+			name: "consistent graph",
+			app:  buildApp("2", "test-app-0", "test-app-1", "test-app-2"),
+			releases: []*shipper.Release{
+				buildRelease(namespace, "test-app", "test-app-0", 0, shipper.ReleaseConditionTypeComplete),
+				buildRelease(namespace, "test-app", "test-app-2", 2),
+				buildRelease(namespace, "test-app", "test-app-1", 1, shipper.ReleaseConditionTypeComplete),
+			},
+		},
+		{
+			name: "two contenders",
+			app:  buildApp("1", "test-app-0", "test-app-1", "test-app-1-bis"),
+			releases: []*shipper.Release{
+				buildRelease(namespace, "test-app", "test-app-0", 0, shipper.ReleaseConditionTypeComplete),
+				buildRelease(namespace, "test-app", "test-app-1", 1),
+				buildRelease(namespace, "test-app", "test-app-1-bis", 1),
+			},
+			expected: []string{"claim to be the contender"},
+		},
+		{
+			name: "broken chain",
+			app:  buildApp("2", "test-app-0", "test-app-2"),
+			releases: []*shipper.Release{
+				buildRelease(namespace, "test-app", "test-app-0", 0, shipper.ReleaseConditionTypeComplete),
+				buildRelease(namespace, "test-app", "test-app-1", 1, shipper.ReleaseConditionTypeComplete),
+				buildRelease(namespace, "test-app", "test-app-2", 2),
+			},
+			expected: []string{"does not chain releases"},
+		},
+		{
+			name: "chain out of order",
+			app:  buildApp("1", "test-app-1", "test-app-0"),
+			releases: []*shipper.Release{
+				buildRelease(namespace, "test-app", "test-app-0", 0, shipper.ReleaseConditionTypeComplete),
+				buildRelease(namespace, "test-app", "test-app-1", 1),
+			},
+			expected: []string{`expected "test-app-0" at position 0`},
+		},
+		{
+			name: "shared generation",
+			app:  buildApp("2", "test-app-0", "test-app-0-bis", "test-app-2"),
+			releases: []*shipper.Release{
+				buildRelease(namespace, "test-app", "test-app-0", 0, shipper.ReleaseConditionTypeComplete),
+				buildRelease(namespace, "test-app", "test-app-0-bis", 0),
+				buildRelease(namespace, "test-app", "test-app-2", 2),
+			},
+			expected: []string{"share generation 0"},
+		},
+		{
+			name: "contender not observed",
+			app:  buildApp("0", "test-app-0", "test-app-1"),
+			releases: []*shipper.Release{
+				buildRelease(namespace, "test-app", "test-app-0", 0, shipper.ReleaseConditionTypeComplete),
+				buildRelease(namespace, "test-app", "test-app-1", 1),
+			},
+			expected: []string{"past the highest generation 0"},
+		},
+		{
+			name: "release of another application",
+			app:  buildApp("1", "test-app-0", "other-app-1"),
+			releases: []*shipper.Release{
+				buildRelease(namespace, "test-app", "test-app-0", 0, shipper.ReleaseConditionTypeComplete),
+				buildRelease(namespace, "other-app", "other-app-1", 1),
+			},
+			expected: []string{`belongs to application "other-app"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateReleaseGraph(tt.app, tt.releases)
+
+			if len(errs) != len(tt.expected) {
+				t.Fatalf("expected %d errors, got %d: %v", len(tt.expected), len(errs), errs)
+			}
+
+			for _, fragment := range tt.expected {
+				found := false
+				for _, err := range errs {
+					if strings.Contains(err.Error(), fragment) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("expected an error containing %q, got %v", fragment, errs)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateReleaseGraphMissingGeneration(t *testing.T) {
+	app := &shipper.Application{ObjectMeta: metav1.ObjectMeta{Name: "test-app"}}
+
+	rel := buildRelease("test-namespace", app.Name, "test-app-0", 0)
+	delete(rel.Annotations, shipper.ReleaseGenerationAnnotation)
+
+	if errs := ValidateReleaseGraph(app, []*shipper.Release{rel}); len(errs) != 1 {
+		t.Errorf("expected a single error for a release without a generation, got %v", errs)
+	}
+}
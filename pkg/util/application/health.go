@@ -0,0 +1,77 @@
+package application
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperclient "github.com/bookingcom/shipper/pkg/client/clientset/versioned"
+	"github.com/bookingcom/shipper/pkg/errors"
+	releaseutil "github.com/bookingcom/shipper/pkg/util/release"
+)
+
+// Health summarizes how the rollout of an application is going.
+type Health string
+
+const (
+	// HealthHealthy means the contender of an application completed its
+	// strategy.
+	HealthHealthy Health = "Healthy"
+	// HealthProgressing means the contender of an application is still
+	// working its way through its strategy.
+	HealthProgressing Health = "Progressing"
+	// HealthBlocked means the rollout of an application can't move
+	// forward on its own: either its contender is blocked, or one of its
+	// releases has been stuck behind the same strategy gate for too long.
+	HealthBlocked Health = "Blocked"
+)
+
+// ApplicationRolloutHealth returns the Health of app's rollout, looking at
+// the conditions of all of its releases.
+func ApplicationRolloutHealth(app *shipper.Application, shipperClient shipperclient.Interface) (Health, error) {
+	selector := labels.Set{shipper.AppLabel: app.Name}.AsSelector()
+	releaseList, err := shipperClient.ShipperV1alpha1().Releases(app.Namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return "", errors.NewKubeclientListError(
+			shipper.SchemeGroupVersion.WithKind("Release"),
+			app.Namespace, selector, err)
+	}
+
+	rels := make([]*shipper.Release, len(releaseList.Items))
+	for i := range releaseList.Items {
+		rels[i] = &releaseList.Items[i]
+	}
+
+	return rolloutHealth(app.Name, releaseutil.SortByGenerationDescending(rels))
+}
+
+// rolloutHealth works out the Health of the rollout of appName out of its
+// releases. The slice is expected to be sorted by descending generation.
+func rolloutHealth(appName string, rels []*shipper.Release) (Health, error) {
+	contender, err := GetContender(appName, rels)
+	if err != nil {
+		return "", err
+	}
+
+	if releaseutil.ReleaseBlocked(contender) {
+		return HealthBlocked, nil
+	}
+
+	for _, rel := range rels {
+		if releaseStalled(rel) {
+			return HealthBlocked, nil
+		}
+	}
+
+	if releaseutil.ReleaseProgressing(contender) {
+		return HealthProgressing, nil
+	}
+
+	return HealthHealthy, nil
+}
+
+func releaseStalled(rel *shipper.Release) bool {
+	cond := releaseutil.GetReleaseCondition(rel.Status, shipper.ReleaseConditionTypeGateStuck)
+	return cond != nil && cond.Status == corev1.ConditionTrue
+}
@@ -0,0 +1,121 @@
+package application
+
+import (
+	"strconv"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+)
+
+func TestApplicationRolloutHealth(t *testing.T) {
+	const namespace = "test-namespace"
+
+	app := &shipper.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-app",
+			Namespace: namespace,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		releases []*shipper.Release
+		expected Health
+	}{
+		{
+			name: "complete contender",
+			releases: []*shipper.Release{
+				buildRelease(namespace, app.Name, "test-incumbent", 0, shipper.ReleaseConditionTypeComplete),
+				buildRelease(namespace, app.Name, "test-contender", 1, shipper.ReleaseConditionTypeComplete),
+			},
+			expected: HealthHealthy,
+		},
+		{
+			name: "contender in progress",
+			releases: []*shipper.Release{
+				buildRelease(namespace, app.Name, "test-incumbent", 0, shipper.ReleaseConditionTypeComplete),
+				buildRelease(namespace, app.Name, "test-contender", 1),
+			},
+			expected: HealthProgressing,
+		},
+		{
+			name: "blocked contender",
+			releases: []*shipper.Release{
+				buildRelease(namespace, app.Name, "test-incumbent", 0, shipper.ReleaseConditionTypeComplete),
+				buildRelease(namespace, app.Name, "test-contender", 1, shipper.ReleaseConditionTypeBlocked),
+			},
+			expected: HealthBlocked,
+		},
+		{
+			name: "stalled incumbent",
+			releases: []*shipper.Release{
+				buildRelease(namespace, app.Name, "test-incumbent", 0,
+					shipper.ReleaseConditionTypeComplete, shipper.ReleaseConditionTypeGateStuck),
+				buildRelease(namespace, app.Name, "test-contender", 1),
+			},
+			expected: HealthBlocked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := []runtime.Object{}
+			for _, rel := range tt.releases {
+				objects = append(objects, rel)
+			}
+
+			health, err := ApplicationRolloutHealth(app, shipperfake.NewSimpleClientset(objects...))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if health != tt.expected {
+				t.Errorf("expected application to be %s, got %s", tt.expected, health)
+			}
+		})
+	}
+}
+
+func TestApplicationRolloutHealthWithoutReleases(t *testing.T) {
+	app := &shipper.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-app",
+			Namespace: "test-namespace",
+		},
+	}
+
+	if _, err := ApplicationRolloutHealth(app, shipperfake.NewSimpleClientset()); err == nil {
+		t.Errorf("expected an error for an application without releases, got none")
+	}
+}
+
+// buildRelease returns a release of app with the given generation, that has
+// every condition in trueConditions set to True.
+func buildRelease(namespace, app, name string, generation int, trueConditions ...shipper.ReleaseConditionType) *shipper.Release {
+	rel := &shipper.Release{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				shipper.AppLabel: app,
+			},
+			Annotations: map[string]string{
+				shipper.ReleaseGenerationAnnotation: strconv.Itoa(generation),
+			},
+		},
+	}
+
+	for _, condType := range trueConditions {
+		rel.Status.Conditions = append(rel.Status.Conditions, shipper.ReleaseCondition{
+			Type:   condType,
+			Status: corev1.ConditionTrue,
+		})
+	}
+
+	return rel
+}
@@ -0,0 +1,65 @@
+package capacity
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	"github.com/bookingcom/shipper/pkg/util/replicas"
+)
+
+// DetectCapacityStarvation returns the names of the clusters in ct that are
+// starved of capacity: those whose status reports fewer available replicas
+// than the spec asks for, and that have unschedulable pods to account for
+// the difference, as counted by CountUnschedulablePods and keyed by cluster
+// name in unschedulablePods. Clusters that are merely short of replicas are
+// still getting there, so they aren't considered starved. The names are
+// sorted.
+func DetectCapacityStarvation(ct *shipper.CapacityTarget, unschedulablePods map[string]int) []string {
+	statuses := make(map[string]shipper.ClusterCapacityStatus, len(ct.Status.Clusters))
+	for _, status := range ct.Status.Clusters {
+		statuses[status.Name] = status
+	}
+
+	var starved []string
+	for _, spec := range ct.Spec.Clusters {
+		status, ok := statuses[spec.Name]
+		if !ok {
+			continue
+		}
+
+		desired := int32(replicas.CalculateDesiredReplicaCount(uint(spec.TotalReplicaCount), float64(spec.Percent)))
+		if status.AvailableReplicas >= desired {
+			continue
+		}
+
+		if unschedulablePods[spec.Name] > 0 {
+			starved = append(starved, spec.Name)
+		}
+	}
+
+	sort.Strings(starved)
+
+	return starved
+}
+
+// CountUnschedulablePods returns how many of pods the scheduler couldn't
+// find a node for. Every condition of every pod is looked at, not only the
+// first one that is False, as pods can be not ready for other reasons on
+// top of not being scheduled.
+func CountUnschedulablePods(pods []*corev1.Pod) int {
+	count := 0
+	for _, pod := range pods {
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodScheduled &&
+				cond.Status == corev1.ConditionFalse &&
+				cond.Reason == corev1.PodReasonUnschedulable {
+				count++
+				break
+			}
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,125 @@
+package capacity
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestDetectCapacityStarvation(t *testing.T) {
+	tests := []struct {
+		name          string
+		statuses      []shipper.ClusterCapacityStatus
+		unschedulable map[string]int
+		expected      []string
+	}{
+		{
+			name: "all clusters satisfied",
+			statuses: []shipper.ClusterCapacityStatus{
+				{Name: "cluster-a", AvailableReplicas: 10},
+				{Name: "cluster-b", AvailableReplicas: 5},
+			},
+			expected: nil,
+		},
+		{
+			name: "satisfied cluster with leftover unschedulable pods",
+			statuses: []shipper.ClusterCapacityStatus{
+				{Name: "cluster-a", AvailableReplicas: 10},
+				{Name: "cluster-b", AvailableReplicas: 5},
+			},
+			unschedulable: map[string]int{"cluster-a": 1},
+			expected:      nil,
+		},
+		{
+			name: "short cluster whose pods are still starting",
+			statuses: []shipper.ClusterCapacityStatus{
+				{Name: "cluster-a", AvailableReplicas: 3},
+				{Name: "cluster-b", AvailableReplicas: 5},
+			},
+			unschedulable: map[string]int{"cluster-a": 0},
+			expected:      nil,
+		},
+		{
+			name: "starved clusters",
+			statuses: []shipper.ClusterCapacityStatus{
+				{Name: "cluster-b", AvailableReplicas: 1},
+				{Name: "cluster-a", AvailableReplicas: 3},
+			},
+			unschedulable: map[string]int{"cluster-a": 7, "cluster-b": 1},
+			expected:      []string{"cluster-a", "cluster-b"},
+		},
+		{
+			name: "cluster without a status yet",
+			statuses: []shipper.ClusterCapacityStatus{
+				{Name: "cluster-a", AvailableReplicas: 3},
+			},
+			unschedulable: map[string]int{"cluster-a": 7},
+			expected:      []string{"cluster-a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := &shipper.CapacityTarget{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foobar",
+					Namespace: "test-namespace",
+				},
+				Spec: shipper.CapacityTargetSpec{
+					Clusters: []shipper.ClusterCapacityTarget{
+						{Name: "cluster-a", Percent: 100, TotalReplicaCount: 10},
+						{Name: "cluster-b", Percent: 50, TotalReplicaCount: 10},
+					},
+				},
+				Status: shipper.CapacityTargetStatus{
+					Clusters: tt.statuses,
+				},
+			}
+
+			starved := DetectCapacityStarvation(ct, tt.unschedulable)
+			if !reflect.DeepEqual(starved, tt.expected) {
+				t.Errorf("expected starved clusters %v, got %v", tt.expected, starved)
+			}
+		})
+	}
+}
+
+func TestCountUnschedulablePods(t *testing.T) {
+	unschedulable := corev1.PodCondition{
+		Type:   corev1.PodScheduled,
+		Status: corev1.ConditionFalse,
+		Reason: corev1.PodReasonUnschedulable,
+	}
+	notReady := corev1.PodCondition{
+		Type:   corev1.PodReady,
+		Status: corev1.ConditionFalse,
+		Reason: "ContainersNotReady",
+	}
+	scheduled := corev1.PodCondition{
+		Type:   corev1.PodScheduled,
+		Status: corev1.ConditionTrue,
+	}
+
+	buildPod := func(conditions ...corev1.PodCondition) *corev1.Pod {
+		return &corev1.Pod{
+			Status: corev1.PodStatus{Conditions: conditions},
+		}
+	}
+
+	pods := []*corev1.Pod{
+		buildPod(unschedulable),
+		// The pod is failing another condition first, which is the one
+		// that makes it to its sad pod status.
+		buildPod(notReady, unschedulable),
+		buildPod(scheduled, notReady),
+		buildPod(),
+	}
+
+	if got := CountUnschedulablePods(pods); got != 2 {
+		t.Errorf("expected 2 unschedulable pods, got %d", got)
+	}
+}
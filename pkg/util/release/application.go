@@ -15,3 +15,19 @@ func ApplicationNameForRelease(rel *shipper.Release) (string, error) {
 
 	return rel.OwnerReferences[0].Name, nil
 }
+
+// ValidateSameApplication ensures that rel and sibling carry the same
+// application label, so that the strategy never pairs a contender with an
+// incumbent that belongs to an unrelated application.
+func ValidateSameApplication(rel, sibling *shipper.Release) error {
+	relApp := rel.Labels[shipper.AppLabel]
+	siblingApp := sibling.Labels[shipper.AppLabel]
+	if relApp == siblingApp {
+		return nil
+	}
+
+	relKey, _ := cache.MetaNamespaceKeyFunc(rel)
+	siblingKey, _ := cache.MetaNamespaceKeyFunc(sibling)
+
+	return shippererrors.NewMismatchedApplicationError(relKey, relApp, siblingKey, siblingApp)
+}
@@ -6,6 +6,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
 
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
 	"github.com/bookingcom/shipper/pkg/util/conditions"
@@ -14,6 +15,13 @@ import (
 
 var ConditionsShouldDiscardTimestamps = false
 
+var realClock clock.Clock = clock.RealClock{}
+
+// Clock is where NewReleaseCondition gets the transition time of new
+// conditions from. Tests can replace it with a fake clock to get
+// deterministic timestamps, and should put realClock back when done.
+var Clock = realClock
+
 type ReleaseConditionDiff struct {
 	c1, c2 *shipper.ReleaseCondition
 }
@@ -37,7 +45,13 @@ func (d *ReleaseConditionDiff) IsEmpty() bool {
 	return d.c1.Type == d.c2.Type &&
 		d.c1.Status == d.c2.Status &&
 		d.c1.Reason == d.c2.Reason &&
-		d.c1.Message == d.c2.Message
+		d.c1.Message == d.c2.Message &&
+		d.c1.Step == d.c2.Step
+}
+
+// Condition returns the condition the diff transitions to, if any.
+func (d *ReleaseConditionDiff) Condition() *shipper.ReleaseCondition {
+	return d.c2
 }
 
 func (d *ReleaseConditionDiff) String() string {
@@ -49,7 +63,7 @@ func (d *ReleaseConditionDiff) String() string {
 }
 
 func NewReleaseCondition(condType shipper.ReleaseConditionType, status corev1.ConditionStatus, reason, message string) *shipper.ReleaseCondition {
-	now := metav1.Now()
+	now := metav1.NewTime(Clock.Now())
 	if ConditionsShouldDiscardTimestamps {
 		now = metav1.Time{}
 	}
@@ -67,7 +81,9 @@ func SetReleaseCondition(status *shipper.ReleaseStatus, condition shipper.Releas
 
 	diff := NewReleaseConditionDiff(currentCond, &condition)
 	if !diff.IsEmpty() {
-		if currentCond != nil && currentCond.Status == condition.Status {
+		// A condition moving on to another step is a transition of
+		// its own, even if its status stays the same.
+		if currentCond != nil && currentCond.Status == condition.Status && currentCond.Step == condition.Step {
 			condition.LastTransitionTime = currentCond.LastTransitionTime
 		}
 		newConditions := filterOutCondition(status.Conditions, condition.Type)
@@ -94,6 +110,31 @@ func RemoveReleaseCondition(status shipper.ReleaseStatus, condType shipper.Relea
 	status.Conditions = filterOutCondition(status.Conditions, condType)
 }
 
+// ClearBlockedIfResolved flips the Blocked condition in status back to False
+// once the controller has determined that whatever blocked the release for
+// reason is gone. Releases that are stillBlocked, or blocked for any reason
+// other than the given one, are left untouched: those blocks are not ours to
+// clear.
+func ClearBlockedIfResolved(status *shipper.ReleaseStatus, stillBlocked bool, reason string) diff.Diff {
+	currentCond := GetReleaseCondition(*status, shipper.ReleaseConditionTypeBlocked)
+	blockedForOtherReason := currentCond != nil &&
+		currentCond.Status == corev1.ConditionTrue &&
+		currentCond.Reason != reason
+
+	if stillBlocked || blockedForOtherReason {
+		return NewReleaseConditionDiff(nil, nil)
+	}
+
+	condition := NewReleaseCondition(
+		shipper.ReleaseConditionTypeBlocked,
+		corev1.ConditionFalse,
+		"",
+		"",
+	)
+
+	return SetReleaseCondition(status, *condition)
+}
+
 func ReleaseScheduled(release *shipper.Release) bool {
 	scheduledCond := GetReleaseCondition(release.Status, shipper.ReleaseConditionTypeScheduled)
 	return scheduledCond != nil && scheduledCond.Status == corev1.ConditionTrue
@@ -109,6 +150,11 @@ func ReleaseComplete(release *shipper.Release) bool {
 	return releasedCond != nil && releasedCond.Status == corev1.ConditionTrue
 }
 
+func ReleaseBlocked(release *shipper.Release) bool {
+	blockedCond := GetReleaseCondition(release.Status, shipper.ReleaseConditionTypeBlocked)
+	return blockedCond != nil && blockedCond.Status == corev1.ConditionTrue
+}
+
 func ReleaseProgressing(release *shipper.Release) bool {
 	return !(ReleaseComplete(release))
 }
@@ -0,0 +1,159 @@
+package release
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestClearBlockedIfResolved(t *testing.T) {
+	const (
+		reason      = "SomeReason"
+		otherReason = "SomeOtherReason"
+	)
+
+	tests := []struct {
+		name         string
+		current      *shipper.ReleaseCondition
+		stillBlocked bool
+		expected     corev1.ConditionStatus
+		expectDiff   bool
+	}{
+		{
+			name:       "no condition yet",
+			current:    nil,
+			expected:   corev1.ConditionFalse,
+			expectDiff: true,
+		},
+		{
+			name: "block is resolved",
+			current: &shipper.ReleaseCondition{
+				Type:   shipper.ReleaseConditionTypeBlocked,
+				Status: corev1.ConditionTrue,
+				Reason: reason,
+			},
+			expected:   corev1.ConditionFalse,
+			expectDiff: true,
+		},
+		{
+			name: "block is still valid",
+			current: &shipper.ReleaseCondition{
+				Type:   shipper.ReleaseConditionTypeBlocked,
+				Status: corev1.ConditionTrue,
+				Reason: reason,
+			},
+			stillBlocked: true,
+			expected:     corev1.ConditionTrue,
+			expectDiff:   false,
+		},
+		{
+			name: "blocked for another reason",
+			current: &shipper.ReleaseCondition{
+				Type:   shipper.ReleaseConditionTypeBlocked,
+				Status: corev1.ConditionTrue,
+				Reason: otherReason,
+			},
+			expected:   corev1.ConditionTrue,
+			expectDiff: false,
+		},
+		{
+			name: "already not blocked",
+			current: &shipper.ReleaseCondition{
+				Type:   shipper.ReleaseConditionTypeBlocked,
+				Status: corev1.ConditionFalse,
+			},
+			expected:   corev1.ConditionFalse,
+			expectDiff: false,
+		},
+	}
+
+	for _, tt := range tests {
+		status := &shipper.ReleaseStatus{}
+		if tt.current != nil {
+			status.Conditions = []shipper.ReleaseCondition{*tt.current}
+		}
+
+		diff := ClearBlockedIfResolved(status, tt.stillBlocked, reason)
+		if diff.IsEmpty() == tt.expectDiff {
+			t.Errorf("%s: expected diff to be non-empty: %t, got %q", tt.name, tt.expectDiff, diff.String())
+		}
+
+		cond := GetReleaseCondition(*status, shipper.ReleaseConditionTypeBlocked)
+		if cond == nil || cond.Status != tt.expected {
+			t.Errorf("%s: expected Blocked condition to be %s, got %v", tt.name, tt.expected, cond)
+		}
+	}
+}
+
+func TestNewReleaseConditionUsesClock(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFakeClock(now)
+	Clock = fakeClock
+	defer func() { Clock = realClock }()
+
+	status := &shipper.ReleaseStatus{}
+
+	cond := NewReleaseCondition(shipper.ReleaseConditionTypeScheduled, corev1.ConditionFalse, "", "")
+	if !cond.LastTransitionTime.Time.Equal(now) {
+		t.Fatalf("expected condition to transition at %s, got %s", now, cond.LastTransitionTime)
+	}
+	SetReleaseCondition(status, *cond)
+
+	// Only the reason changes, so the condition keeps its original
+	// transition time.
+	fakeClock.Step(time.Minute)
+	cond = NewReleaseCondition(shipper.ReleaseConditionTypeScheduled, corev1.ConditionFalse, "SomeReason", "")
+	SetReleaseCondition(status, *cond)
+
+	got := GetReleaseCondition(*status, shipper.ReleaseConditionTypeScheduled)
+	if !got.LastTransitionTime.Time.Equal(now) {
+		t.Errorf("expected condition to keep its transition time of %s, got %s", now, got.LastTransitionTime)
+	}
+
+	fakeClock.Step(time.Minute)
+	cond = NewReleaseCondition(shipper.ReleaseConditionTypeScheduled, corev1.ConditionTrue, "", "")
+	SetReleaseCondition(status, *cond)
+
+	got = GetReleaseCondition(*status, shipper.ReleaseConditionTypeScheduled)
+	if expected := now.Add(2 * time.Minute); !got.LastTransitionTime.Time.Equal(expected) {
+		t.Errorf("expected condition to transition at %s, got %s", expected, got.LastTransitionTime)
+	}
+}
+
+func TestSetReleaseConditionTransitionsOnStepChange(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFakeClock(now)
+	Clock = fakeClock
+	defer func() { Clock = realClock }()
+
+	status := &shipper.ReleaseStatus{}
+	SetReleaseCondition(status, *NewStepFinishedCondition(0))
+
+	fakeClock.Step(time.Minute)
+	if diff := SetReleaseCondition(status, *NewStepFinishedCondition(1)); diff.IsEmpty() {
+		t.Fatalf("expected achieving another step to be a transition")
+	}
+
+	got := GetReleaseCondition(*status, shipper.ReleaseConditionTypeStepAchieved)
+	if expected := now.Add(time.Minute); got.Step != 1 || !got.LastTransitionTime.Time.Equal(expected) {
+		t.Errorf("expected step 1 to be achieved at %s, got step %d at %s", expected, got.Step, got.LastTransitionTime)
+	}
+}
+
+func TestNewReleaseConditionCanDiscardTimestamps(t *testing.T) {
+	Clock = clock.NewFakeClock(time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC))
+	ConditionsShouldDiscardTimestamps = true
+	defer func() {
+		Clock = realClock
+		ConditionsShouldDiscardTimestamps = false
+	}()
+
+	cond := NewReleaseCondition(shipper.ReleaseConditionTypeScheduled, corev1.ConditionTrue, "", "")
+	if !cond.LastTransitionTime.IsZero() {
+		t.Errorf("expected condition to have no transition time, got %s", cond.LastTransitionTime)
+	}
+}
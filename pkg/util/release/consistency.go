@@ -0,0 +1,59 @@
+package release
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// DetectPhaseConditionMismatch returns a description of every way the
+// conditions of rel contradict the phase its strategy status puts it in,
+// such as "phase=Complete but Scheduled=False". None of these states are
+// ever left behind by the release controller, so they point at corrupted
+// objects or bugs. A consistent release gets no descriptions.
+func DetectPhaseConditionMismatch(rel *shipper.Release) []string {
+	var mismatches []string
+
+	complete := ReleaseComplete(rel)
+	scheduled := ReleaseScheduled(rel)
+
+	if complete && !scheduled {
+		mismatches = append(mismatches, fmt.Sprintf("phase=%s but %s=%s",
+			ReleasePhaseComplete, shipper.ReleaseConditionTypeScheduled,
+			conditionStatus(rel, shipper.ReleaseConditionTypeScheduled)))
+	}
+
+	// Nothing completes a release before it's installed, not even
+	// completion policies that only wait for one of capacity or traffic.
+	if strategy := rel.Status.Strategy; complete && strategy != nil &&
+		strategy.State.WaitingForInstallation == shipper.StrategyStateTrue {
+		mismatches = append(mismatches, fmt.Sprintf("phase=%s but %s=%s",
+			ReleasePhaseWaitingForInstallation, shipper.ReleaseConditionTypeComplete,
+			corev1.ConditionTrue))
+	}
+
+	// Achieving the last step of its strategy, while it's still the one
+	// targeted, is what makes a release complete.
+	if achieved := rel.Status.AchievedStep; !complete && achieved != nil &&
+		rel.Spec.Environment.Strategy != nil && IsLastStrategyStep(rel) &&
+		achieved.Step == rel.Spec.TargetStep {
+		mismatches = append(mismatches, fmt.Sprintf("achievedStep=%d (last) but %s=%s",
+			achieved.Step, shipper.ReleaseConditionTypeComplete,
+			conditionStatus(rel, shipper.ReleaseConditionTypeComplete)))
+	}
+
+	return mismatches
+}
+
+// conditionStatus returns the status of the condType condition of rel,
+// assuming missing conditions are False.
+func conditionStatus(rel *shipper.Release, condType shipper.ReleaseConditionType) corev1.ConditionStatus {
+	cond := GetReleaseCondition(rel.Status, condType)
+	if cond == nil {
+		return corev1.ConditionFalse
+	}
+
+	return cond.Status
+}
@@ -0,0 +1,103 @@
+package release
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestDetectPhaseConditionMismatch(t *testing.T) {
+	scheduled := shipper.ReleaseCondition{Type: shipper.ReleaseConditionTypeScheduled, Status: corev1.ConditionTrue}
+	complete := shipper.ReleaseCondition{Type: shipper.ReleaseConditionTypeComplete, Status: corev1.ConditionTrue}
+	incomplete := shipper.ReleaseCondition{Type: shipper.ReleaseConditionTypeComplete, Status: corev1.ConditionFalse}
+
+	steps := []shipper.RolloutStrategyStep{{Name: "staging"}, {Name: "full on"}}
+
+	tests := []struct {
+		name         string
+		targetStep   int32
+		achievedStep *shipper.AchievedStep
+		state        *shipper.ReleaseStrategyState
+		conditions   []shipper.ReleaseCondition
+		expected     []string
+	}{
+		{
+			name:         "waiting for command",
+			targetStep:   0,
+			achievedStep: &shipper.AchievedStep{Step: 0, Name: "staging"},
+			state:        &shipper.ReleaseStrategyState{WaitingForCommand: shipper.StrategyStateTrue},
+			conditions:   []shipper.ReleaseCondition{scheduled, incomplete},
+		},
+		{
+			name:         "complete",
+			targetStep:   1,
+			achievedStep: &shipper.AchievedStep{Step: 1, Name: "full on"},
+			state:        &shipper.ReleaseStrategyState{WaitingForCommand: shipper.StrategyStateFalse},
+			conditions:   []shipper.ReleaseCondition{scheduled, complete},
+		},
+		{
+			name:         "complete without being scheduled",
+			targetStep:   1,
+			achievedStep: &shipper.AchievedStep{Step: 1, Name: "full on"},
+			conditions:   []shipper.ReleaseCondition{complete},
+			expected:     []string{"phase=Complete but Scheduled=False"},
+		},
+		{
+			name:       "complete while waiting for installation",
+			targetStep: 1,
+			state:      &shipper.ReleaseStrategyState{WaitingForInstallation: shipper.StrategyStateTrue},
+			conditions: []shipper.ReleaseCondition{scheduled, complete},
+			expected:   []string{"phase=WaitingForInstallation but Complete=True"},
+		},
+		{
+			name:         "achieved the last step without completing",
+			targetStep:   1,
+			achievedStep: &shipper.AchievedStep{Step: 1, Name: "full on"},
+			conditions:   []shipper.ReleaseCondition{scheduled, incomplete},
+			expected:     []string{"achievedStep=1 (last) but Complete=False"},
+		},
+		{
+			name:         "achieved the last step without a complete condition",
+			targetStep:   1,
+			achievedStep: &shipper.AchievedStep{Step: 1, Name: "full on"},
+			conditions:   []shipper.ReleaseCondition{scheduled},
+			expected:     []string{"achievedStep=1 (last) but Complete=False"},
+		},
+		{
+			name:         "several contradictions",
+			targetStep:   1,
+			achievedStep: &shipper.AchievedStep{Step: 1, Name: "full on"},
+			state:        &shipper.ReleaseStrategyState{WaitingForInstallation: shipper.StrategyStateTrue},
+			conditions:   []shipper.ReleaseCondition{complete},
+			expected: []string{
+				"phase=Complete but Scheduled=False",
+				"phase=WaitingForInstallation but Complete=True",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		rel := &shipper.Release{
+			Spec: shipper.ReleaseSpec{
+				TargetStep: tt.targetStep,
+				Environment: shipper.ReleaseEnvironment{
+					Strategy: &shipper.RolloutStrategy{Steps: steps},
+				},
+			},
+			Status: shipper.ReleaseStatus{
+				AchievedStep: tt.achievedStep,
+				Conditions:   tt.conditions,
+			},
+		}
+		if tt.state != nil {
+			rel.Status.Strategy = &shipper.ReleaseStrategyStatus{State: *tt.state}
+		}
+
+		if got := DetectPhaseConditionMismatch(rel); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected mismatches %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
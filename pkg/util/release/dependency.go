@@ -0,0 +1,31 @@
+package release
+
+import (
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// DependencySatisfied returns whether the release rel depends on, if any,
+// has reached the phase rel is waiting for. The dependency is named by the
+// release.dependsOn annotation, and the phase it has to reach by the
+// release.dependsOn.phase one, which defaults to Complete, as does any phase
+// that isn't known. depGetter returns the release with the given name in the
+// namespace of rel, or nil if there is none, in which case the dependency
+// is not satisfied.
+func DependencySatisfied(rel *shipper.Release, depGetter func(name string) *shipper.Release) bool {
+	depName, ok := rel.Annotations[shipper.ReleaseDependsOnAnnotation]
+	if !ok || depName == "" {
+		return true
+	}
+
+	dep := depGetter(depName)
+	if dep == nil {
+		return false
+	}
+
+	required, ok := phaseOrder[ReleasePhase(rel.Annotations[shipper.ReleaseDependsOnPhaseAnnotation])]
+	if !ok {
+		required = phaseOrder[ReleasePhaseComplete]
+	}
+
+	return phaseOrder[GetReleasePhase(dep)] >= required
+}
@@ -0,0 +1,73 @@
+package release
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestDependencySatisfied(t *testing.T) {
+	scheduled := shipper.ReleaseCondition{Type: shipper.ReleaseConditionTypeScheduled, Status: corev1.ConditionTrue}
+	complete := shipper.ReleaseCondition{Type: shipper.ReleaseConditionTypeComplete, Status: corev1.ConditionTrue}
+
+	releases := map[string]*shipper.Release{
+		"complete": {
+			Status: shipper.ReleaseStatus{
+				Conditions: []shipper.ReleaseCondition{scheduled, complete},
+			},
+		},
+		"waiting-for-traffic": {
+			Status: shipper.ReleaseStatus{
+				Conditions: []shipper.ReleaseCondition{scheduled},
+				Strategy: &shipper.ReleaseStrategyStatus{
+					State: shipper.ReleaseStrategyState{
+						WaitingForInstallation: shipper.StrategyStateFalse,
+						WaitingForCapacity:     shipper.StrategyStateFalse,
+						WaitingForTraffic:      shipper.StrategyStateTrue,
+					},
+				},
+			},
+		},
+	}
+
+	depGetter := func(name string) *shipper.Release {
+		return releases[name]
+	}
+
+	tests := []struct {
+		name      string
+		dependsOn string
+		phase     string
+		expected  bool
+	}{
+		{"no dependency", "", "", true},
+		{"missing dependency", "missing", "", false},
+		{"dependency complete", "complete", "", true},
+		{"dependency not complete", "waiting-for-traffic", "", false},
+		{"dependency reached the phase", "waiting-for-traffic", string(ReleasePhaseWaitingForCapacity), true},
+		{"dependency is in the phase", "waiting-for-traffic", string(ReleasePhaseWaitingForTraffic), true},
+		{"dependency did not reach the phase", "waiting-for-traffic", string(ReleasePhaseWaitingForCommand), false},
+		{"unknown phase", "waiting-for-traffic", "Whenever", false},
+	}
+
+	for _, tt := range tests {
+		rel := &shipper.Release{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{},
+			},
+		}
+		if tt.dependsOn != "" {
+			rel.Annotations[shipper.ReleaseDependsOnAnnotation] = tt.dependsOn
+		}
+		if tt.phase != "" {
+			rel.Annotations[shipper.ReleaseDependsOnPhaseAnnotation] = tt.phase
+		}
+
+		if got := DependencySatisfied(rel, depGetter); got != tt.expected {
+			t.Errorf("%s: expected dependency to be satisfied: %t, got %t", tt.name, tt.expected, got)
+		}
+	}
+}
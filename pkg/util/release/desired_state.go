@@ -0,0 +1,78 @@
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// desiredState is everything that goes into DesiredStateHash. Targets that
+// don't exist yet are left nil.
+type desiredState struct {
+	Release            *shipper.ReleaseSpec            `json:"release"`
+	InstallationTarget *shipper.InstallationTargetSpec `json:"installationTarget"`
+	TrafficTarget      *shipper.TrafficTargetSpec      `json:"trafficTarget"`
+	CapacityTarget     *shipper.CapacityTargetSpec     `json:"capacityTarget"`
+}
+
+// DesiredStateHash returns a hash of the spec of rel and of its targets,
+// which changes only when what the release is meant to look like does. Any
+// of the targets can be nil. Lists whose order carries no meaning, such as
+// the clusters in each target, are sorted before hashing, and maps always
+// are, so semantically equal states always hash the same. An error is only
+// returned if the state can't be marshaled, as when chart values hold
+// something JSON can't represent.
+func DesiredStateHash(
+	rel *shipper.Release,
+	it *shipper.InstallationTarget,
+	tt *shipper.TrafficTarget,
+	ct *shipper.CapacityTarget,
+) (string, error) {
+	state := desiredState{}
+
+	if rel != nil {
+		spec := rel.Spec.DeepCopy()
+		reqs := &spec.Environment.ClusterRequirements
+		sort.Strings(reqs.Capabilities)
+		sort.Slice(reqs.Regions, func(i, j int) bool {
+			return reqs.Regions[i].Name < reqs.Regions[j].Name
+		})
+		state.Release = spec
+	}
+
+	if it != nil {
+		spec := it.Spec.DeepCopy()
+		sort.Strings(spec.Clusters)
+		state.InstallationTarget = spec
+	}
+
+	if tt != nil {
+		spec := tt.Spec.DeepCopy()
+		sort.Slice(spec.Clusters, func(i, j int) bool {
+			return spec.Clusters[i].Name < spec.Clusters[j].Name
+		})
+		state.TrafficTarget = spec
+	}
+
+	if ct != nil {
+		spec := ct.Spec.DeepCopy()
+		sort.Slice(spec.Clusters, func(i, j int) bool {
+			return spec.Clusters[i].Name < spec.Clusters[j].Name
+		})
+		state.CapacityTarget = spec
+	}
+
+	// encoding/json writes map keys in sorted order, so chart values
+	// don't need any special treatment.
+	b, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal desired state: %s", err)
+	}
+
+	hash := fnv.New32a()
+	hash.Write(b)
+	return fmt.Sprintf("%x", hash.Sum32()), nil
+}
@@ -0,0 +1,155 @@
+package release
+
+import (
+	"math"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func buildDesiredState() (*shipper.Release, *shipper.InstallationTarget, *shipper.TrafficTarget, *shipper.CapacityTarget) {
+	rel := buildRelease("test-namespace", "test-release", "0")
+	rel.Spec.Environment.Values = &shipper.ChartValues{
+		"replicaCount": int64(3),
+		"image": map[string]interface{}{
+			"repository": "nginx",
+			"tag":        "latest",
+		},
+	}
+	rel.Spec.Environment.ClusterRequirements.Capabilities = []string{"gpu", "ssd"}
+
+	it := &shipper.InstallationTarget{
+		Spec: shipper.InstallationTargetSpec{
+			Clusters: []string{"cluster-a", "cluster-b"},
+		},
+	}
+
+	tt := &shipper.TrafficTarget{
+		Spec: shipper.TrafficTargetSpec{
+			Clusters: []shipper.ClusterTrafficTarget{
+				{Name: "cluster-a", Weight: 50},
+				{Name: "cluster-b", Weight: 50},
+			},
+		},
+	}
+
+	ct := &shipper.CapacityTarget{
+		Spec: shipper.CapacityTargetSpec{
+			Clusters: []shipper.ClusterCapacityTarget{
+				{Name: "cluster-a", Percent: 50, TotalReplicaCount: 3},
+				{Name: "cluster-b", Percent: 50, TotalReplicaCount: 3},
+			},
+		},
+	}
+
+	return rel, it, tt, ct
+}
+
+func desiredStateHash(
+	t *testing.T,
+	rel *shipper.Release,
+	it *shipper.InstallationTarget,
+	tt *shipper.TrafficTarget,
+	ct *shipper.CapacityTarget,
+) string {
+	hash, err := DesiredStateHash(rel, it, tt, ct)
+	if err != nil {
+		t.Fatalf("unexpected error hashing desired state: %s", err)
+	}
+
+	return hash
+}
+
+func TestDesiredStateHashIsOrderIndependent(t *testing.T) {
+	rel, it, tt, ct := buildDesiredState()
+	expected := desiredStateHash(t, rel, it, tt, ct)
+
+	rel, it, tt, ct = buildDesiredState()
+	rel.Spec.Environment.ClusterRequirements.Capabilities = []string{"ssd", "gpu"}
+	it.Spec.Clusters = []string{"cluster-b", "cluster-a"}
+	tt.Spec.Clusters[0], tt.Spec.Clusters[1] = tt.Spec.Clusters[1], tt.Spec.Clusters[0]
+	ct.Spec.Clusters[0], ct.Spec.Clusters[1] = ct.Spec.Clusters[1], ct.Spec.Clusters[0]
+
+	// Neither metadata nor status are part of the desired state.
+	rel.ResourceVersion = "42"
+	rel.Status.Conditions = []shipper.ReleaseCondition{
+		{Type: shipper.ReleaseConditionTypeComplete},
+	}
+	tt.ObjectMeta = metav1.ObjectMeta{Name: "test-release"}
+
+	if actual := desiredStateHash(t, rel, it, tt, ct); actual != expected {
+		t.Errorf("expected semantically equal states to hash to %q, got %q", expected, actual)
+	}
+
+	// Calculating the hash must not reorder the objects themselves.
+	if it.Spec.Clusters[0] != "cluster-b" {
+		t.Errorf("expected installation target clusters to be left untouched, got %v", it.Spec.Clusters)
+	}
+}
+
+func TestDesiredStateHashChangesWithSpec(t *testing.T) {
+	rel, it, tt, ct := buildDesiredState()
+	base := desiredStateHash(t, rel, it, tt, ct)
+
+	tests := []struct {
+		name   string
+		mutate func(*shipper.Release, *shipper.InstallationTarget, *shipper.TrafficTarget, *shipper.CapacityTarget)
+	}{
+		{
+			name: "target step",
+			mutate: func(rel *shipper.Release, _ *shipper.InstallationTarget, _ *shipper.TrafficTarget, _ *shipper.CapacityTarget) {
+				rel.Spec.TargetStep = 1
+			},
+		},
+		{
+			name: "chart values",
+			mutate: func(rel *shipper.Release, _ *shipper.InstallationTarget, _ *shipper.TrafficTarget, _ *shipper.CapacityTarget) {
+				(*rel.Spec.Environment.Values)["replicaCount"] = int64(4)
+			},
+		},
+		{
+			name: "traffic weight",
+			mutate: func(_ *shipper.Release, _ *shipper.InstallationTarget, tt *shipper.TrafficTarget, _ *shipper.CapacityTarget) {
+				tt.Spec.Clusters[0].Weight = 100
+			},
+		},
+		{
+			name: "capacity percent",
+			mutate: func(_ *shipper.Release, _ *shipper.InstallationTarget, _ *shipper.TrafficTarget, ct *shipper.CapacityTarget) {
+				ct.Spec.Clusters[1].Percent = 100
+			},
+		},
+		{
+			name: "installation clusters",
+			mutate: func(_ *shipper.Release, it *shipper.InstallationTarget, _ *shipper.TrafficTarget, _ *shipper.CapacityTarget) {
+				it.Spec.Clusters = []string{"cluster-a"}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rel, it, tt, ct := buildDesiredState()
+			test.mutate(rel, it, tt, ct)
+
+			if actual := desiredStateHash(t, rel, it, tt, ct); actual == base {
+				t.Errorf("expected a change in %s to change the hash", test.name)
+			}
+		})
+	}
+
+	if desiredStateHash(t, rel, it, tt, nil) == base {
+		t.Errorf("expected a missing capacity target to change the hash")
+	}
+}
+
+func TestDesiredStateHashUnmarshalableValues(t *testing.T) {
+	rel, it, tt, ct := buildDesiredState()
+	(*rel.Spec.Environment.Values)["replicaCount"] = math.NaN()
+
+	if _, err := DesiredStateHash(rel, it, tt, ct); err == nil {
+		t.Errorf("expected an error hashing values that can't be marshaled")
+	}
+}
@@ -83,3 +83,34 @@ func GetSiblingReleases(release *shipper.Release, appReleases []*shipper.Release
 
 	return predecessor, ancestor, nil
 }
+
+// IsSuperseded returns true if allRels holds a release of the same
+// application with a higher generation than rel that has already been
+// scheduled, meaning rollouts have moved on from rel.
+func IsSuperseded(rel *shipper.Release, allRels []*shipper.Release) bool {
+	relgen, err := GetGeneration(rel)
+	if err != nil {
+		return false
+	}
+
+	appName := rel.Labels[shipper.AppLabel]
+	for _, other := range SortByGenerationDescending(allRels) {
+		gen, err := GetGeneration(other)
+		if err != nil {
+			continue
+		}
+		if gen <= relgen {
+			// Releases are sorted, nothing beyond this point is newer
+			// than rel.
+			break
+		}
+		if other.Namespace != rel.Namespace || other.Labels[shipper.AppLabel] != appName {
+			continue
+		}
+		if ReleaseScheduled(other) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -134,3 +134,43 @@ func TestGetSiblingReleasesOriginAbsent(t *testing.T) {
 		}
 	}
 }
+
+func TestIsSuperseded(t *testing.T) {
+	scheduled := []shipper.ReleaseCondition{
+		{Type: shipper.ReleaseConditionTypeScheduled, Status: corev1.ConditionTrue},
+	}
+
+	oldest := buildRelease("test-namespace", "release-1", "1")
+	oldest.Status.Conditions = scheduled
+	middle := buildRelease("test-namespace", "release-2", "2")
+	middle.Status.Conditions = scheduled
+	latest := buildRelease("test-namespace", "release-3", "3")
+	latest.Status.Conditions = scheduled
+
+	unscheduled := buildRelease("test-namespace", "release-4", "4")
+
+	otherApp := buildRelease("test-namespace", "other-release", "5")
+	otherApp.Labels[shipper.AppLabel] = "other-application"
+	otherApp.Status.Conditions = scheduled
+
+	allRels := []*shipper.Release{middle, otherApp, latest, unscheduled, oldest}
+
+	tests := []struct {
+		name     string
+		rel      *shipper.Release
+		expected bool
+	}{
+		{"oldest release", oldest, true},
+		{"older release", middle, true},
+		{"latest scheduled release", latest, false},
+		{"latest release, not scheduled yet", unscheduled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSuperseded(tt.rel, allRels); got != tt.expected {
+				t.Errorf("expected IsSuperseded to be %t, got %t", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package release
+
+import (
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// ReleasePhase is where a release is in the execution of its current
+// strategy step, as told by its strategy state.
+type ReleasePhase string
+
+const (
+	ReleasePhaseWaitingForScheduling   ReleasePhase = "WaitingForScheduling"
+	ReleasePhaseWaitingForInstallation ReleasePhase = "WaitingForInstallation"
+	ReleasePhaseWaitingForCapacity     ReleasePhase = "WaitingForCapacity"
+	ReleasePhaseWaitingForTraffic      ReleasePhase = "WaitingForTraffic"
+	ReleasePhaseWaitingForCommand      ReleasePhase = "WaitingForCommand"
+	ReleasePhaseComplete               ReleasePhase = "Complete"
+)
+
+// phaseOrder is the order a release goes through phases in while it works
+// towards a strategy step.
+var phaseOrder = map[ReleasePhase]int{
+	ReleasePhaseWaitingForScheduling:   0,
+	ReleasePhaseWaitingForInstallation: 1,
+	ReleasePhaseWaitingForCapacity:     2,
+	ReleasePhaseWaitingForTraffic:      3,
+	ReleasePhaseWaitingForCommand:      4,
+	ReleasePhaseComplete:               5,
+}
+
+// GetReleasePhase returns the phase rel is in.
+func GetReleasePhase(rel *shipper.Release) ReleasePhase {
+	if ReleaseComplete(rel) {
+		return ReleasePhaseComplete
+	}
+
+	if !ReleaseScheduled(rel) || rel.Status.Strategy == nil {
+		return ReleasePhaseWaitingForScheduling
+	}
+
+	state := rel.Status.Strategy.State
+	switch {
+	case state.WaitingForInstallation == shipper.StrategyStateTrue:
+		return ReleasePhaseWaitingForInstallation
+	case state.WaitingForCapacity == shipper.StrategyStateTrue:
+		return ReleasePhaseWaitingForCapacity
+	case state.WaitingForTraffic == shipper.StrategyStateTrue:
+		return ReleasePhaseWaitingForTraffic
+	default:
+		return ReleasePhaseWaitingForCommand
+	}
+}
+
+// PhaseRegressed returns true if going from prev to curr takes a release
+// back to an earlier phase. Unknown phases are never considered to regress.
+func PhaseRegressed(prev, curr ReleasePhase) bool {
+	prevOrder, ok := phaseOrder[prev]
+	if !ok {
+		return false
+	}
+
+	currOrder, ok := phaseOrder[curr]
+	if !ok {
+		return false
+	}
+
+	return currOrder < prevOrder
+}
@@ -0,0 +1,75 @@
+package release
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestPhaseRegressed(t *testing.T) {
+	tests := []struct {
+		name      string
+		prev      ReleasePhase
+		curr      ReleasePhase
+		regressed bool
+	}{
+		{"same phase", ReleasePhaseWaitingForCapacity, ReleasePhaseWaitingForCapacity, false},
+		{"moves forward", ReleasePhaseWaitingForCapacity, ReleasePhaseWaitingForTraffic, false},
+		{"gets scheduled", ReleasePhaseWaitingForScheduling, ReleasePhaseWaitingForCommand, false},
+		{"completes", ReleasePhaseWaitingForCommand, ReleasePhaseComplete, false},
+		{"back to installation", ReleasePhaseWaitingForTraffic, ReleasePhaseWaitingForInstallation, true},
+		{"back from command", ReleasePhaseWaitingForCommand, ReleasePhaseWaitingForTraffic, true},
+		{"no longer complete", ReleasePhaseComplete, ReleasePhaseWaitingForCommand, true},
+		{"unscheduled", ReleasePhaseWaitingForCapacity, ReleasePhaseWaitingForScheduling, true},
+		{"unknown previous phase", ReleasePhase("Unknown"), ReleasePhaseWaitingForScheduling, false},
+		{"unknown current phase", ReleasePhaseComplete, ReleasePhase("Unknown"), false},
+	}
+
+	for _, tt := range tests {
+		if got := PhaseRegressed(tt.prev, tt.curr); got != tt.regressed {
+			t.Errorf("%s: expected going from %s to %s to be a regression: %t, got %t",
+				tt.name, tt.prev, tt.curr, tt.regressed, got)
+		}
+	}
+}
+
+func TestGetReleasePhase(t *testing.T) {
+	scheduled := shipper.ReleaseCondition{Type: shipper.ReleaseConditionTypeScheduled, Status: corev1.ConditionTrue}
+	complete := shipper.ReleaseCondition{Type: shipper.ReleaseConditionTypeComplete, Status: corev1.ConditionTrue}
+
+	state := func(installation, capacity, traffic shipper.StrategyState) *shipper.ReleaseStrategyStatus {
+		return &shipper.ReleaseStrategyStatus{
+			State: shipper.ReleaseStrategyState{
+				WaitingForInstallation: installation,
+				WaitingForCapacity:     capacity,
+				WaitingForTraffic:      traffic,
+				WaitingForCommand:      shipper.StrategyStateFalse,
+			},
+		}
+	}
+
+	yes, no := shipper.StrategyStateTrue, shipper.StrategyStateFalse
+
+	tests := []struct {
+		name     string
+		status   shipper.ReleaseStatus
+		expected ReleasePhase
+	}{
+		{"not scheduled", shipper.ReleaseStatus{}, ReleasePhaseWaitingForScheduling},
+		{"no strategy yet", shipper.ReleaseStatus{Conditions: []shipper.ReleaseCondition{scheduled}}, ReleasePhaseWaitingForScheduling},
+		{"installing", shipper.ReleaseStatus{Conditions: []shipper.ReleaseCondition{scheduled}, Strategy: state(yes, yes, yes)}, ReleasePhaseWaitingForInstallation},
+		{"scaling", shipper.ReleaseStatus{Conditions: []shipper.ReleaseCondition{scheduled}, Strategy: state(no, yes, yes)}, ReleasePhaseWaitingForCapacity},
+		{"shifting traffic", shipper.ReleaseStatus{Conditions: []shipper.ReleaseCondition{scheduled}, Strategy: state(no, no, yes)}, ReleasePhaseWaitingForTraffic},
+		{"step achieved", shipper.ReleaseStatus{Conditions: []shipper.ReleaseCondition{scheduled}, Strategy: state(no, no, no)}, ReleasePhaseWaitingForCommand},
+		{"complete", shipper.ReleaseStatus{Conditions: []shipper.ReleaseCondition{complete, scheduled}, Strategy: state(no, no, no)}, ReleasePhaseComplete},
+	}
+
+	for _, tt := range tests {
+		rel := &shipper.Release{Status: tt.status}
+		if got := GetReleasePhase(rel); got != tt.expected {
+			t.Errorf("%s: expected phase %s, got %s", tt.name, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// ReleaseProgress is a machine readable summary of how far along its
+// strategy a release is.
+type ReleaseProgress struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// CurrentStep is the last step the release achieved, if any.
+	CurrentStep *ProgressStep `json:"currentStep,omitempty"`
+	TargetStep  ProgressStep  `json:"targetStep"`
+	TotalSteps  int           `json:"totalSteps"`
+
+	// ProgressPercent is the share of the strategy's steps the
+	// release has achieved so far.
+	ProgressPercent int `json:"progressPercent"`
+
+	Conditions []ProgressCondition `json:"conditions"`
+}
+
+type ProgressStep struct {
+	Index int32  `json:"index"`
+	Name  string `json:"name"`
+}
+
+type ProgressCondition struct {
+	Type    shipper.ReleaseConditionType `json:"type"`
+	Status  corev1.ConditionStatus       `json:"status"`
+	Reason  string                       `json:"reason,omitempty"`
+	Message string                       `json:"message,omitempty"`
+}
+
+// ReleaseProgressJSON returns rel's progress through strategy, encoded as a
+// JSON ReleaseProgress object. A nil strategy means the release's own.
+func ReleaseProgressJSON(rel *shipper.Release, strategy *shipper.RolloutStrategy) ([]byte, error) {
+	progress, err := buildReleaseProgress(rel, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(progress)
+}
+
+func buildReleaseProgress(rel *shipper.Release, strategy *shipper.RolloutStrategy) (*ReleaseProgress, error) {
+	if strategy == nil {
+		strategy = rel.Spec.Environment.Strategy
+	}
+
+	if strategy == nil || len(strategy.Steps) == 0 {
+		return nil, fmt.Errorf("release %s/%s has no strategy steps", rel.Namespace, rel.Name)
+	}
+
+	totalSteps := len(strategy.Steps)
+	targetStep := rel.Spec.TargetStep
+	if targetStep < 0 || int(targetStep) >= totalSteps {
+		return nil, fmt.Errorf("no step %d in strategy for release %s/%s",
+			targetStep, rel.Namespace, rel.Name)
+	}
+
+	progress := &ReleaseProgress{
+		Namespace: rel.Namespace,
+		Name:      rel.Name,
+		TargetStep: ProgressStep{
+			Index: targetStep,
+			Name:  strategy.Steps[targetStep].Name,
+		},
+		TotalSteps: totalSteps,
+		Conditions: []ProgressCondition{},
+	}
+
+	if achieved := rel.Status.AchievedStep; achieved != nil && int(achieved.Step) < totalSteps {
+		progress.CurrentStep = &ProgressStep{
+			Index: achieved.Step,
+			Name:  achieved.Name,
+		}
+		progress.ProgressPercent = int(achieved.Step+1) * 100 / totalSteps
+	}
+
+	for _, cond := range rel.Status.Conditions {
+		progress.Conditions = append(progress.Conditions, ProgressCondition{
+			Type:    cond.Type,
+			Status:  cond.Status,
+			Reason:  cond.Reason,
+			Message: cond.Message,
+		})
+	}
+
+	return progress, nil
+}
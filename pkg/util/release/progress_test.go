@@ -0,0 +1,150 @@
+package release
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+var progressTestStrategy = &shipper.RolloutStrategy{
+	Steps: []shipper.RolloutStrategyStep{
+		{Name: "staging"},
+		{Name: "50/50"},
+		{Name: "full on"},
+	},
+}
+
+func TestReleaseProgressJSON(t *testing.T) {
+	tests := []struct {
+		name         string
+		targetStep   int32
+		achievedStep *shipper.AchievedStep
+		expected     map[string]interface{}
+	}{
+		{
+			name:       "nothing achieved yet",
+			targetStep: 0,
+			expected: map[string]interface{}{
+				"namespace":       "test-namespace",
+				"name":            "test-release",
+				"targetStep":      map[string]interface{}{"index": 0.0, "name": "staging"},
+				"totalSteps":      3.0,
+				"progressPercent": 0.0,
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Blocked", "status": "False"},
+					map[string]interface{}{"type": "Scheduled", "status": "False"},
+				},
+			},
+		},
+		{
+			name:         "halfway through",
+			targetStep:   1,
+			achievedStep: &shipper.AchievedStep{Step: 0, Name: "staging"},
+			expected: map[string]interface{}{
+				"namespace":       "test-namespace",
+				"name":            "test-release",
+				"currentStep":     map[string]interface{}{"index": 0.0, "name": "staging"},
+				"targetStep":      map[string]interface{}{"index": 1.0, "name": "50/50"},
+				"totalSteps":      3.0,
+				"progressPercent": 33.0,
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Blocked", "status": "False"},
+					map[string]interface{}{"type": "Scheduled", "status": "False"},
+				},
+			},
+		},
+		{
+			name:         "last step achieved",
+			targetStep:   2,
+			achievedStep: &shipper.AchievedStep{Step: 2, Name: "full on"},
+			expected: map[string]interface{}{
+				"namespace":       "test-namespace",
+				"name":            "test-release",
+				"currentStep":     map[string]interface{}{"index": 2.0, "name": "full on"},
+				"targetStep":      map[string]interface{}{"index": 2.0, "name": "full on"},
+				"totalSteps":      3.0,
+				"progressPercent": 100.0,
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Blocked", "status": "False"},
+					map[string]interface{}{"type": "Scheduled", "status": "False"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rel := buildRelease("test-namespace", "test-release", "0")
+			rel.Spec.TargetStep = tt.targetStep
+			rel.Status.AchievedStep = tt.achievedStep
+
+			b, err := ReleaseProgressJSON(rel, progressTestStrategy)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var actual map[string]interface{}
+			if err := json.Unmarshal(b, &actual); err != nil {
+				t.Fatalf("progress is not valid JSON: %s", err)
+			}
+
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Errorf("expected progress %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestReleaseProgressJSONConditionDetails(t *testing.T) {
+	rel := buildRelease("test-namespace", "test-release", "0")
+	rel.Spec.Environment.Strategy = progressTestStrategy
+	rel.Status.Conditions = []shipper.ReleaseCondition{
+		{
+			Type:    shipper.ReleaseConditionTypeBlocked,
+			Status:  corev1.ConditionTrue,
+			Reason:  shipper.RolloutBlockReason,
+			Message: "rollouts blocked by: test-namespace/test-block",
+		},
+	}
+
+	// A nil strategy falls back to the release's own.
+	b, err := ReleaseProgressJSON(rel, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var actual ReleaseProgress
+	if err := json.Unmarshal(b, &actual); err != nil {
+		t.Fatalf("progress is not valid JSON: %s", err)
+	}
+
+	expected := []ProgressCondition{
+		{
+			Type:    shipper.ReleaseConditionTypeBlocked,
+			Status:  corev1.ConditionTrue,
+			Reason:  shipper.RolloutBlockReason,
+			Message: "rollouts blocked by: test-namespace/test-block",
+		},
+	}
+
+	if !reflect.DeepEqual(expected, actual.Conditions) {
+		t.Errorf("expected conditions %v, got %v", expected, actual.Conditions)
+	}
+}
+
+func TestReleaseProgressJSONInvalidStrategy(t *testing.T) {
+	rel := buildRelease("test-namespace", "test-release", "0")
+
+	if _, err := ReleaseProgressJSON(rel, nil); err == nil {
+		t.Errorf("expected an error for a release without a strategy")
+	}
+
+	rel.Spec.TargetStep = 3
+	if _, err := ReleaseProgressJSON(rel, progressTestStrategy); err == nil {
+		t.Errorf("expected an error for a target step out of range")
+	}
+}
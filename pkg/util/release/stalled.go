@@ -0,0 +1,57 @@
+package release
+
+import (
+	"time"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// MostStalledRelease returns the progressing release out of rels that has
+// gone the longest without any of its conditions transitioning, and for how
+// long it's been that way. Releases without any transition times to go by
+// are left out, and if none are left, it returns nil.
+func MostStalledRelease(rels []*shipper.Release) (*shipper.Release, time.Duration) {
+	var (
+		stalled      *shipper.Release
+		stalledSince time.Time
+	)
+
+	for _, rel := range rels {
+		if !ReleaseProgressing(rel) {
+			continue
+		}
+
+		lastTransition, ok := lastConditionTransition(rel)
+		if !ok {
+			continue
+		}
+
+		if stalled == nil || lastTransition.Before(stalledSince) {
+			stalled = rel
+			stalledSince = lastTransition
+		}
+	}
+
+	if stalled == nil {
+		return nil, 0
+	}
+
+	return stalled, Clock.Since(stalledSince)
+}
+
+// lastConditionTransition returns the latest transition time out of all of
+// rel's conditions, and whether there was any.
+func lastConditionTransition(rel *shipper.Release) (time.Time, bool) {
+	var last time.Time
+	for _, cond := range rel.Status.Conditions {
+		if cond.LastTransitionTime.IsZero() {
+			continue
+		}
+
+		if cond.LastTransitionTime.Time.After(last) {
+			last = cond.LastTransitionTime.Time
+		}
+	}
+
+	return last, !last.IsZero()
+}
@@ -0,0 +1,92 @@
+package release
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestMostStalledRelease(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	Clock = clock.NewFakeClock(now)
+	defer func() { Clock = realClock }()
+
+	buildRelease := func(name string, complete bool, transitionsAgo ...time.Duration) *shipper.Release {
+		rel := &shipper.Release{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+		}
+
+		for _, ago := range transitionsAgo {
+			rel.Status.Conditions = append(rel.Status.Conditions, shipper.ReleaseCondition{
+				Type:               shipper.ReleaseConditionTypeScheduled,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(now.Add(-ago)),
+			})
+		}
+
+		if complete {
+			rel.Status.Conditions = append(rel.Status.Conditions, shipper.ReleaseCondition{
+				Type:   shipper.ReleaseConditionTypeComplete,
+				Status: corev1.ConditionTrue,
+			})
+		}
+
+		return rel
+	}
+
+	tests := []struct {
+		name             string
+		rels             []*shipper.Release
+		expectedRelease  string
+		expectedDuration time.Duration
+	}{
+		{
+			name: "no releases",
+		},
+		{
+			name: "only complete releases",
+			rels: []*shipper.Release{
+				buildRelease("done", true, 10*time.Hour),
+			},
+		},
+		{
+			name: "only releases without transition times",
+			rels: []*shipper.Release{
+				buildRelease("new", false),
+			},
+		},
+		{
+			name: "several progressing releases",
+			rels: []*shipper.Release{
+				buildRelease("fresh", false, time.Minute),
+				// Its latest transition is what counts, so it's
+				// only been stalled for a couple of minutes.
+				buildRelease("moving", false, 5*time.Hour, 2*time.Minute),
+				buildRelease("stuck", false, time.Hour, 3*time.Hour),
+				buildRelease("done", true, 10*time.Hour),
+				buildRelease("new", false),
+			},
+			expectedRelease:  "stuck",
+			expectedDuration: time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		rel, stalledFor := MostStalledRelease(tt.rels)
+
+		var relName string
+		if rel != nil {
+			relName = rel.Name
+		}
+
+		if relName != tt.expectedRelease || stalledFor != tt.expectedDuration {
+			t.Errorf("%s: expected %q to be stalled for %s, got %q stalled for %s",
+				tt.name, tt.expectedRelease, tt.expectedDuration, relName, stalledFor)
+		}
+	}
+}
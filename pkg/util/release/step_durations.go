@@ -0,0 +1,122 @@
+package release
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// NewStepStartedCondition returns a StepAchieved condition marking the
+// moment a release started working towards step.
+func NewStepStartedCondition(step int32) *shipper.ReleaseCondition {
+	cond := NewReleaseCondition(shipper.ReleaseConditionTypeStepAchieved, corev1.ConditionFalse, "", "")
+	cond.Step = step
+	return cond
+}
+
+// NewStepFinishedCondition returns a StepAchieved condition marking the
+// moment a release achieved step.
+func NewStepFinishedCondition(step int32) *shipper.ReleaseCondition {
+	cond := NewReleaseCondition(shipper.ReleaseConditionTypeStepAchieved, corev1.ConditionTrue, "", "")
+	cond.Step = step
+	return cond
+}
+
+// ComputeStepDurations works out how long each step took from a timeline
+// of release condition transitions, in any order. A release starts working
+// on whatever its first step is as soon as it's scheduled, and on every
+// other one when its StepAchieved condition transitions to False. A step is
+// finished when StepAchieved transitions back to True. Steps that were
+// started but not finished yet are returned without a Finished time, and
+// steps that were finished without their start being part of the timeline
+// are left out altogether.
+func ComputeStepDurations(conditions []shipper.ReleaseCondition) []shipper.StepDuration {
+	timeline := make([]shipper.ReleaseCondition, len(conditions))
+	copy(timeline, conditions)
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].LastTransitionTime.Before(&timeline[j].LastTransitionTime)
+	})
+
+	var durations []shipper.StepDuration
+	var current *shipper.StepDuration
+
+	// Until the first step is finished, there's no telling which one it
+	// was: releases can be created with any target step.
+	firstStep := false
+
+	for _, cond := range timeline {
+		switch {
+		case cond.Type == shipper.ReleaseConditionTypeScheduled && cond.Status == corev1.ConditionTrue:
+			if current == nil && len(durations) == 0 {
+				current = &shipper.StepDuration{Started: cond.LastTransitionTime}
+				firstStep = true
+			}
+
+		case cond.Type == shipper.ReleaseConditionTypeStepAchieved && cond.Status == corev1.ConditionFalse:
+			current = &shipper.StepDuration{Step: cond.Step, Started: cond.LastTransitionTime}
+			firstStep = false
+
+		case cond.Type == shipper.ReleaseConditionTypeStepAchieved && cond.Status == corev1.ConditionTrue:
+			if current == nil || (!firstStep && current.Step != cond.Step) {
+				current = nil
+				continue
+			}
+
+			d := NewStepDuration(cond.Step, current.Started, &cond.LastTransitionTime)
+			durations = append(durations, d)
+			current = nil
+			firstStep = false
+		}
+	}
+
+	if current != nil && !firstStep {
+		durations = append(durations, *current)
+	}
+
+	return durations
+}
+
+// NewStepDuration returns the StepDuration of a release that started
+// working towards step at started, and achieved it at finished unless
+// that's nil.
+func NewStepDuration(step int32, started metav1.Time, finished *metav1.Time) shipper.StepDuration {
+	d := shipper.StepDuration{Step: step, Started: started}
+	if finished != nil {
+		finishedAt := *finished
+		d.Finished = &finishedAt
+		d.Duration = &metav1.Duration{Duration: finishedAt.Sub(started.Time)}
+	}
+
+	return d
+}
+
+// GetStepDuration returns what status knows about how long step took, or
+// nil if it knows nothing about it.
+func GetStepDuration(status shipper.ReleaseStatus, step int32) *shipper.StepDuration {
+	for i := range status.StepDurations {
+		if status.StepDurations[i].Step == step {
+			return &status.StepDurations[i]
+		}
+	}
+
+	return nil
+}
+
+// SetStepDuration records d in status, replacing whatever was known about
+// the same step before. Steps can be worked on more than once when a
+// release's target step is moved back, and only the latest attempt is
+// kept. Step durations are kept sorted by step.
+func SetStepDuration(status *shipper.ReleaseStatus, d shipper.StepDuration) {
+	if existing := GetStepDuration(*status, d.Step); existing != nil {
+		*existing = d
+		return
+	}
+
+	status.StepDurations = append(status.StepDurations, d)
+	sort.Slice(status.StepDurations, func(i, j int) bool {
+		return status.StepDurations[i].Step < status.StepDurations[j].Step
+	})
+}
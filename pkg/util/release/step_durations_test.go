@@ -0,0 +1,209 @@
+package release
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+var stepsEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func at(minutes int) metav1.Time {
+	return metav1.NewTime(stepsEpoch.Add(time.Duration(minutes) * time.Minute))
+}
+
+func scheduledAt(minutes int) shipper.ReleaseCondition {
+	return shipper.ReleaseCondition{
+		Type:               shipper.ReleaseConditionTypeScheduled,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: at(minutes),
+	}
+}
+
+func stepStartedAt(step int32, minutes int) shipper.ReleaseCondition {
+	cond := *NewStepStartedCondition(step)
+	cond.LastTransitionTime = at(minutes)
+	return cond
+}
+
+func stepFinishedAt(step int32, minutes int) shipper.ReleaseCondition {
+	cond := *NewStepFinishedCondition(step)
+	cond.LastTransitionTime = at(minutes)
+	return cond
+}
+
+func finishedStep(step int32, started, finished int) shipper.StepDuration {
+	finishedAt := at(finished)
+	return shipper.StepDuration{
+		Step:     step,
+		Started:  at(started),
+		Finished: &finishedAt,
+		Duration: &metav1.Duration{Duration: time.Duration(finished-started) * time.Minute},
+	}
+}
+
+func TestComputeStepDurations(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []shipper.ReleaseCondition
+		expected   []shipper.StepDuration
+	}{
+		{
+			name:       "only scheduled",
+			conditions: []shipper.ReleaseCondition{scheduledAt(0)},
+			expected:   nil,
+		},
+		{
+			name: "first step achieved",
+			conditions: []shipper.ReleaseCondition{
+				scheduledAt(0),
+				stepFinishedAt(0, 5),
+			},
+			expected: []shipper.StepDuration{finishedStep(0, 0, 5)},
+		},
+		{
+			name: "release created past its first step",
+			conditions: []shipper.ReleaseCondition{
+				scheduledAt(0),
+				stepFinishedAt(2, 3),
+			},
+			expected: []shipper.StepDuration{finishedStep(2, 0, 3)},
+		},
+		{
+			name: "whole strategy, out of order",
+			conditions: []shipper.ReleaseCondition{
+				stepFinishedAt(2, 40),
+				stepStartedAt(1, 10),
+				scheduledAt(0),
+				stepStartedAt(2, 30),
+				stepFinishedAt(1, 25),
+				stepFinishedAt(0, 5),
+			},
+			expected: []shipper.StepDuration{
+				finishedStep(0, 0, 5),
+				finishedStep(1, 10, 25),
+				finishedStep(2, 30, 40),
+			},
+		},
+		{
+			name: "step in progress",
+			conditions: []shipper.ReleaseCondition{
+				scheduledAt(0),
+				stepFinishedAt(0, 5),
+				stepStartedAt(1, 10),
+			},
+			expected: []shipper.StepDuration{
+				finishedStep(0, 0, 5),
+				{Step: 1, Started: at(10)},
+			},
+		},
+		{
+			name: "target step changed before the step was achieved",
+			conditions: []shipper.ReleaseCondition{
+				stepStartedAt(1, 10),
+				stepStartedAt(2, 15),
+				stepFinishedAt(2, 20),
+			},
+			expected: []shipper.StepDuration{finishedStep(2, 15, 20)},
+		},
+		{
+			name: "finished step without a start",
+			conditions: []shipper.ReleaseCondition{
+				stepStartedAt(1, 10),
+				stepFinishedAt(2, 20),
+			},
+			expected: nil,
+		},
+		{
+			name: "unrelated conditions",
+			conditions: []shipper.ReleaseCondition{
+				scheduledAt(0),
+				{
+					Type:               shipper.ReleaseConditionTypeComplete,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: at(2),
+				},
+				stepFinishedAt(0, 5),
+			},
+			expected: []shipper.StepDuration{finishedStep(0, 0, 5)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := ComputeStepDurations(test.conditions)
+			if len(actual) != len(test.expected) {
+				t.Fatalf("expected %d step durations, got %d: %v", len(test.expected), len(actual), actual)
+			}
+
+			for i := range test.expected {
+				if !stepDurationsEqual(actual[i], test.expected[i]) {
+					t.Errorf("expected step duration %d to be %v, got %v", i, test.expected[i], actual[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNewStepDuration(t *testing.T) {
+	finishedAt := at(5)
+	if d := NewStepDuration(0, at(0), &finishedAt); !stepDurationsEqual(d, finishedStep(0, 0, 5)) {
+		t.Errorf("expected a finished step to know its duration, got %v", d)
+	}
+
+	expected := shipper.StepDuration{Step: 1, Started: at(10)}
+	if d := NewStepDuration(1, at(10), nil); !stepDurationsEqual(d, expected) {
+		t.Errorf("expected a step in progress to have no duration, got %v", d)
+	}
+}
+
+func TestSetStepDuration(t *testing.T) {
+	status := &shipper.ReleaseStatus{}
+
+	SetStepDuration(status, shipper.StepDuration{Step: 1, Started: at(10)})
+	SetStepDuration(status, finishedStep(0, 0, 5))
+
+	if d := GetStepDuration(*status, 1); d == nil || d.Finished != nil {
+		t.Fatalf("expected step 1 to be in progress, got %v", d)
+	}
+
+	// A step worked on again only keeps its latest attempt.
+	SetStepDuration(status, finishedStep(1, 10, 25))
+
+	expected := []shipper.StepDuration{
+		finishedStep(0, 0, 5),
+		finishedStep(1, 10, 25),
+	}
+	if len(status.StepDurations) != len(expected) {
+		t.Fatalf("expected %d step durations, got %v", len(expected), status.StepDurations)
+	}
+	for i := range expected {
+		if !stepDurationsEqual(status.StepDurations[i], expected[i]) {
+			t.Errorf("expected step duration %d to be %v, got %v", i, expected[i], status.StepDurations[i])
+		}
+	}
+
+	if d := GetStepDuration(*status, 2); d != nil {
+		t.Errorf("expected nothing to be known about step 2, got %v", d)
+	}
+}
+
+func stepDurationsEqual(a, b shipper.StepDuration) bool {
+	if a.Step != b.Step || !a.Started.Equal(&b.Started) {
+		return false
+	}
+
+	if (a.Finished == nil) != (b.Finished == nil) || (a.Duration == nil) != (b.Duration == nil) {
+		return false
+	}
+
+	if a.Finished != nil && !a.Finished.Equal(b.Finished) {
+		return false
+	}
+
+	return a.Duration == nil || a.Duration.Duration == b.Duration.Duration
+}
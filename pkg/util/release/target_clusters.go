@@ -0,0 +1,110 @@
+package release
+
+import (
+	"sort"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// TrafficWithoutCapacity returns the sorted list of clusters where tt gives
+// the release some traffic weight, but ct requests no capacity for it,
+// either because it asks for 0% or because it doesn't list the cluster at
+// all. Traffic in those clusters has no pods to go to.
+func TrafficWithoutCapacity(tt *shipper.TrafficTarget, ct *shipper.CapacityTarget) []string {
+	if tt == nil {
+		return nil
+	}
+
+	requested := make(map[string]bool)
+	if ct != nil {
+		for _, spec := range ct.Spec.Clusters {
+			if spec.Percent > 0 && spec.TotalReplicaCount > 0 {
+				requested[spec.Name] = true
+			}
+		}
+	}
+
+	var clusters []string
+	for _, spec := range tt.Spec.Clusters {
+		if spec.Weight > 0 && !requested[spec.Name] {
+			clusters = append(clusters, spec.Name)
+		}
+	}
+
+	sort.Strings(clusters)
+
+	return clusters
+}
+
+// EffectiveClusters returns the sorted list of every cluster referenced by
+// any of the target objects of a release, whether it's being installed,
+// given capacity or given traffic in it. Missing target objects reference
+// no clusters.
+func EffectiveClusters(it *shipper.InstallationTarget, tt *shipper.TrafficTarget, ct *shipper.CapacityTarget) []string {
+	counts := targetClusterCounts(it, tt, ct)
+
+	clusters := make([]string, 0, len(counts))
+	for cluster := range counts {
+		clusters = append(clusters, cluster)
+	}
+
+	sort.Strings(clusters)
+
+	return clusters
+}
+
+// CommonClusters returns the sorted list of clusters referenced by all
+// three target objects of a release, the only clusters the release is
+// fully present in. It's empty when any of the target objects is missing.
+func CommonClusters(it *shipper.InstallationTarget, tt *shipper.TrafficTarget, ct *shipper.CapacityTarget) []string {
+	if it == nil || tt == nil || ct == nil {
+		return []string{}
+	}
+
+	clusters := []string{}
+	for cluster, count := range targetClusterCounts(it, tt, ct) {
+		if count == 3 {
+			clusters = append(clusters, cluster)
+		}
+	}
+
+	sort.Strings(clusters)
+
+	return clusters
+}
+
+// targetClusterCounts returns how many of the target objects of a release
+// reference each of the clusters any of them does.
+func targetClusterCounts(it *shipper.InstallationTarget, tt *shipper.TrafficTarget, ct *shipper.CapacityTarget) map[string]int {
+	var referenced [][]string
+	if it != nil {
+		referenced = append(referenced, it.Spec.Clusters)
+	}
+	if tt != nil {
+		names := make([]string, 0, len(tt.Spec.Clusters))
+		for _, spec := range tt.Spec.Clusters {
+			names = append(names, spec.Name)
+		}
+		referenced = append(referenced, names)
+	}
+	if ct != nil {
+		names := make([]string, 0, len(ct.Spec.Clusters))
+		for _, spec := range ct.Spec.Clusters {
+			names = append(names, spec.Name)
+		}
+		referenced = append(referenced, names)
+	}
+
+	counts := make(map[string]int)
+	for _, names := range referenced {
+		seen := make(map[string]bool)
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				counts[name]++
+			}
+		}
+	}
+
+	return counts
+}
@@ -0,0 +1,154 @@
+package release
+
+import (
+	"reflect"
+	"testing"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestTrafficWithoutCapacity(t *testing.T) {
+	tt := func(weights map[string]uint32) *shipper.TrafficTarget {
+		tt := &shipper.TrafficTarget{}
+		for cluster, weight := range weights {
+			tt.Spec.Clusters = append(tt.Spec.Clusters, shipper.ClusterTrafficTarget{Name: cluster, Weight: weight})
+		}
+		return tt
+	}
+
+	ct := func(percents map[string]int32) *shipper.CapacityTarget {
+		ct := &shipper.CapacityTarget{}
+		for cluster, percent := range percents {
+			ct.Spec.Clusters = append(ct.Spec.Clusters, shipper.ClusterCapacityTarget{Name: cluster, Percent: percent, TotalReplicaCount: 10})
+		}
+		return ct
+	}
+
+	tests := []struct {
+		name     string
+		tt       *shipper.TrafficTarget
+		ct       *shipper.CapacityTarget
+		expected []string
+	}{
+		{
+			name: "consistent",
+			tt:   tt(map[string]uint32{"cluster-a": 50, "cluster-b": 0}),
+			ct:   ct(map[string]int32{"cluster-a": 50, "cluster-b": 0}),
+		},
+		{
+			name:     "traffic where capacity is zero",
+			tt:       tt(map[string]uint32{"cluster-a": 50, "cluster-b": 10}),
+			ct:       ct(map[string]int32{"cluster-a": 50, "cluster-b": 0}),
+			expected: []string{"cluster-b"},
+		},
+		{
+			name:     "traffic where capacity is not requested at all",
+			tt:       tt(map[string]uint32{"cluster-a": 50, "cluster-c": 10, "cluster-b": 10}),
+			ct:       ct(map[string]int32{"cluster-a": 50}),
+			expected: []string{"cluster-b", "cluster-c"},
+		},
+		{
+			name:     "no capacity target",
+			tt:       tt(map[string]uint32{"cluster-a": 50}),
+			expected: []string{"cluster-a"},
+		},
+		{
+			name: "no traffic target",
+			ct:   ct(map[string]int32{"cluster-a": 50}),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := TrafficWithoutCapacity(test.tt, test.ct)
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected clusters %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestEffectiveAndCommonClusters(t *testing.T) {
+	it := func(clusters ...string) *shipper.InstallationTarget {
+		return &shipper.InstallationTarget{Spec: shipper.InstallationTargetSpec{Clusters: clusters}}
+	}
+
+	tt := func(clusters ...string) *shipper.TrafficTarget {
+		tt := &shipper.TrafficTarget{}
+		for _, cluster := range clusters {
+			tt.Spec.Clusters = append(tt.Spec.Clusters, shipper.ClusterTrafficTarget{Name: cluster, Weight: 50})
+		}
+		return tt
+	}
+
+	ct := func(clusters ...string) *shipper.CapacityTarget {
+		ct := &shipper.CapacityTarget{}
+		for _, cluster := range clusters {
+			ct.Spec.Clusters = append(ct.Spec.Clusters, shipper.ClusterCapacityTarget{Name: cluster, Percent: 50, TotalReplicaCount: 10})
+		}
+		return ct
+	}
+
+	tests := []struct {
+		name              string
+		it                *shipper.InstallationTarget
+		tt                *shipper.TrafficTarget
+		ct                *shipper.CapacityTarget
+		expectedEffective []string
+		expectedCommon    []string
+	}{
+		{
+			name:              "same clusters everywhere",
+			it:                it("cluster-b", "cluster-a"),
+			tt:                tt("cluster-a", "cluster-b"),
+			ct:                ct("cluster-b", "cluster-a"),
+			expectedEffective: []string{"cluster-a", "cluster-b"},
+			expectedCommon:    []string{"cluster-a", "cluster-b"},
+		},
+		{
+			name:              "differing clusters",
+			it:                it("cluster-a", "cluster-b", "cluster-c"),
+			tt:                tt("cluster-a", "cluster-d"),
+			ct:                ct("cluster-c", "cluster-a"),
+			expectedEffective: []string{"cluster-a", "cluster-b", "cluster-c", "cluster-d"},
+			expectedCommon:    []string{"cluster-a"},
+		},
+		{
+			name:              "no cluster in common",
+			it:                it("cluster-a"),
+			tt:                tt("cluster-b"),
+			ct:                ct("cluster-a", "cluster-b"),
+			expectedEffective: []string{"cluster-a", "cluster-b"},
+			expectedCommon:    []string{},
+		},
+		{
+			name:              "duplicate clusters",
+			it:                it("cluster-a", "cluster-a"),
+			tt:                tt("cluster-a", "cluster-b"),
+			ct:                ct("cluster-b", "cluster-b"),
+			expectedEffective: []string{"cluster-a", "cluster-b"},
+			expectedCommon:    []string{},
+		},
+		{
+			name:              "missing target object",
+			it:                it("cluster-a"),
+			ct:                ct("cluster-a"),
+			expectedEffective: []string{"cluster-a"},
+			expectedCommon:    []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			effective := EffectiveClusters(test.it, test.tt, test.ct)
+			if !reflect.DeepEqual(effective, test.expectedEffective) {
+				t.Errorf("expected effective clusters %v, got %v", test.expectedEffective, effective)
+			}
+
+			common := CommonClusters(test.it, test.tt, test.ct)
+			if !reflect.DeepEqual(common, test.expectedCommon) {
+				t.Errorf("expected common clusters %v, got %v", test.expectedCommon, common)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package release
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	targetutil "github.com/bookingcom/shipper/pkg/util/target"
+)
+
+const TargetObjectsMissing = "TargetObjectsMissing"
+
+// ComputeReleaseConditions derives the release conditions that only depend on
+// the state of the release's target objects. A release is Scheduled once all
+// of them exist, and its TrafficConverged and CapacityConverged conditions
+// follow the Ready condition of the traffic and capacity targets.
+//
+// Complete is not derived here: whether a release is complete depends on the
+// step its strategy is targeting, which its target objects know nothing
+// about.
+func ComputeReleaseConditions(
+	it *shipper.InstallationTarget,
+	tt *shipper.TrafficTarget,
+	ct *shipper.CapacityTarget,
+) []shipper.ReleaseCondition {
+	scheduled := NewReleaseCondition(
+		shipper.ReleaseConditionTypeScheduled,
+		corev1.ConditionTrue, "", "")
+	if it == nil || tt == nil || ct == nil {
+		scheduled = NewReleaseCondition(
+			shipper.ReleaseConditionTypeScheduled,
+			corev1.ConditionFalse, TargetObjectsMissing,
+			"release is missing at least one of its target objects")
+	}
+
+	conditions := []shipper.ReleaseCondition{*scheduled}
+
+	var ttConditions, ctConditions []shipper.TargetCondition
+	if tt != nil {
+		ttConditions = tt.Status.Conditions
+	}
+	if ct != nil {
+		ctConditions = ct.Status.Conditions
+	}
+
+	conditions = append(conditions,
+		*convergedCondition(shipper.ReleaseConditionTypeTrafficConverged, ttConditions),
+		*convergedCondition(shipper.ReleaseConditionTypeCapacityConverged, ctConditions),
+	)
+
+	return conditions
+}
+
+// convergedCondition mirrors the Ready condition out of a target object's
+// conditions into a release condition of type condType.
+func convergedCondition(
+	condType shipper.ReleaseConditionType,
+	targetConditions []shipper.TargetCondition,
+) *shipper.ReleaseCondition {
+	ready := targetutil.GetTargetCondition(targetConditions, shipper.TargetConditionTypeReady)
+	if ready == nil {
+		return NewReleaseCondition(condType, corev1.ConditionUnknown, "", "")
+	}
+
+	return NewReleaseCondition(condType, ready.Status, ready.Reason, ready.Message)
+}
@@ -0,0 +1,108 @@
+package release
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestComputeReleaseConditions(t *testing.T) {
+	ready := func(status corev1.ConditionStatus, reason, message string) []shipper.TargetCondition {
+		return []shipper.TargetCondition{
+			{
+				Type:    shipper.TargetConditionTypeReady,
+				Status:  status,
+				Reason:  reason,
+				Message: message,
+			},
+		}
+	}
+
+	type expectedCondition struct {
+		status corev1.ConditionStatus
+		reason string
+	}
+
+	tests := []struct {
+		name      string
+		it        *shipper.InstallationTarget
+		tt        *shipper.TrafficTarget
+		ct        *shipper.CapacityTarget
+		scheduled expectedCondition
+		traffic   expectedCondition
+		capacity  expectedCondition
+	}{
+		{
+			name:      "no target objects",
+			scheduled: expectedCondition{corev1.ConditionFalse, TargetObjectsMissing},
+			traffic:   expectedCondition{corev1.ConditionUnknown, ""},
+			capacity:  expectedCondition{corev1.ConditionUnknown, ""},
+		},
+		{
+			name:      "missing capacity target",
+			it:        &shipper.InstallationTarget{},
+			tt:        &shipper.TrafficTarget{Status: shipper.TrafficTargetStatus{Conditions: ready(corev1.ConditionTrue, "", "")}},
+			scheduled: expectedCondition{corev1.ConditionFalse, TargetObjectsMissing},
+			traffic:   expectedCondition{corev1.ConditionTrue, ""},
+			capacity:  expectedCondition{corev1.ConditionUnknown, ""},
+		},
+		{
+			name:      "targets without conditions",
+			it:        &shipper.InstallationTarget{},
+			tt:        &shipper.TrafficTarget{},
+			ct:        &shipper.CapacityTarget{},
+			scheduled: expectedCondition{corev1.ConditionTrue, ""},
+			traffic:   expectedCondition{corev1.ConditionUnknown, ""},
+			capacity:  expectedCondition{corev1.ConditionUnknown, ""},
+		},
+		{
+			name:      "all targets ready",
+			it:        &shipper.InstallationTarget{},
+			tt:        &shipper.TrafficTarget{Status: shipper.TrafficTargetStatus{Conditions: ready(corev1.ConditionTrue, "", "")}},
+			ct:        &shipper.CapacityTarget{Status: shipper.CapacityTargetStatus{Conditions: ready(corev1.ConditionTrue, "", "")}},
+			scheduled: expectedCondition{corev1.ConditionTrue, ""},
+			traffic:   expectedCondition{corev1.ConditionTrue, ""},
+			capacity:  expectedCondition{corev1.ConditionTrue, ""},
+		},
+		{
+			name:      "capacity target not ready",
+			it:        &shipper.InstallationTarget{},
+			tt:        &shipper.TrafficTarget{Status: shipper.TrafficTargetStatus{Conditions: ready(corev1.ConditionTrue, "", "")}},
+			ct:        &shipper.CapacityTarget{Status: shipper.CapacityTargetStatus{Conditions: ready(corev1.ConditionFalse, "ClustersNotReady", "[minikube]")}},
+			scheduled: expectedCondition{corev1.ConditionTrue, ""},
+			traffic:   expectedCondition{corev1.ConditionTrue, ""},
+			capacity:  expectedCondition{corev1.ConditionFalse, "ClustersNotReady"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conditions := ComputeReleaseConditions(tt.it, tt.tt, tt.ct)
+
+			expected := map[shipper.ReleaseConditionType]expectedCondition{
+				shipper.ReleaseConditionTypeScheduled:         tt.scheduled,
+				shipper.ReleaseConditionTypeTrafficConverged:  tt.traffic,
+				shipper.ReleaseConditionTypeCapacityConverged: tt.capacity,
+			}
+
+			if len(conditions) != len(expected) {
+				t.Fatalf("expected %d conditions, got %d: %v", len(expected), len(conditions), conditions)
+			}
+
+			for _, cond := range conditions {
+				want, ok := expected[cond.Type]
+				if !ok {
+					t.Errorf("unexpected condition %q", cond.Type)
+					continue
+				}
+
+				if cond.Status != want.status || cond.Reason != want.reason {
+					t.Errorf("expected condition %q to be %s %q, got %s %q",
+						cond.Type, want.status, want.reason, cond.Status, cond.Reason)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package release
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// SummarizeForRelease rolls up the weights desired in the spec of tt and the
+// ones achieved according to its status into a TrafficSummary, sorted by
+// cluster name. Clusters that have no status yet have achieved no traffic.
+func SummarizeForRelease(tt *shipper.TrafficTarget) shipper.TrafficSummary {
+	achieved := make(map[string]uint32, len(tt.Status.Clusters))
+	for _, status := range tt.Status.Clusters {
+		if status == nil {
+			continue
+		}
+		achieved[status.Name] = status.AchievedTraffic
+	}
+
+	clusters := make([]shipper.ClusterTrafficSummary, 0, len(tt.Spec.Clusters))
+	for _, spec := range tt.Spec.Clusters {
+		clusters = append(clusters, shipper.ClusterTrafficSummary{
+			Name:           spec.Name,
+			DesiredWeight:  spec.Weight,
+			AchievedWeight: achieved[spec.Name],
+		})
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Name < clusters[j].Name
+	})
+
+	parts := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		parts = append(parts, fmt.Sprintf("%s: %d/%d",
+			cluster.Name, cluster.AchievedWeight, cluster.DesiredWeight))
+	}
+
+	summary := shipper.TrafficSummary{
+		Distribution: strings.Join(parts, ", "),
+	}
+	if len(clusters) > 0 {
+		summary.Clusters = clusters
+	}
+
+	return summary
+}
@@ -0,0 +1,83 @@
+package release
+
+import (
+	"reflect"
+	"testing"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestSummarizeForRelease(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     []shipper.ClusterTrafficTarget
+		status   []*shipper.ClusterTrafficStatus
+		expected shipper.TrafficSummary
+	}{
+		{
+			name:     "no clusters",
+			expected: shipper.TrafficSummary{},
+		},
+		{
+			name: "converged",
+			spec: []shipper.ClusterTrafficTarget{
+				{Name: "cluster-b", Weight: 100},
+				{Name: "cluster-a", Weight: 50},
+			},
+			status: []*shipper.ClusterTrafficStatus{
+				{Name: "cluster-a", AchievedTraffic: 50},
+				{Name: "cluster-b", AchievedTraffic: 100},
+			},
+			expected: shipper.TrafficSummary{
+				Distribution: "cluster-a: 50/50, cluster-b: 100/100",
+				Clusters: []shipper.ClusterTrafficSummary{
+					{Name: "cluster-a", DesiredWeight: 50, AchievedWeight: 50},
+					{Name: "cluster-b", DesiredWeight: 100, AchievedWeight: 100},
+				},
+			},
+		},
+		{
+			name: "shifting traffic",
+			spec: []shipper.ClusterTrafficTarget{
+				{Name: "cluster-a", Weight: 50},
+			},
+			status: []*shipper.ClusterTrafficStatus{
+				{Name: "cluster-a", AchievedTraffic: 20},
+			},
+			expected: shipper.TrafficSummary{
+				Distribution: "cluster-a: 20/50",
+				Clusters: []shipper.ClusterTrafficSummary{
+					{Name: "cluster-a", DesiredWeight: 50, AchievedWeight: 20},
+				},
+			},
+		},
+		{
+			name: "cluster without status",
+			spec: []shipper.ClusterTrafficTarget{
+				{Name: "cluster-a", Weight: 50},
+				{Name: "cluster-b", Weight: 50},
+			},
+			status: []*shipper.ClusterTrafficStatus{
+				{Name: "cluster-a", AchievedTraffic: 50},
+			},
+			expected: shipper.TrafficSummary{
+				Distribution: "cluster-a: 50/50, cluster-b: 0/50",
+				Clusters: []shipper.ClusterTrafficSummary{
+					{Name: "cluster-a", DesiredWeight: 50, AchievedWeight: 50},
+					{Name: "cluster-b", DesiredWeight: 50, AchievedWeight: 0},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tt := &shipper.TrafficTarget{
+			Spec:   shipper.TrafficTargetSpec{Clusters: test.spec},
+			Status: shipper.TrafficTargetStatus{Clusters: test.status},
+		}
+
+		if got := SummarizeForRelease(tt); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: expected summary %+v, got %+v", test.name, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package replicas
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DistributeCapacity spreads totalReplicas across the clusters in
+// clusterCaps, giving each of them a share proportional to its cap. Shares
+// are rounded with the largest remainder method, so they always add up to
+// totalReplicas, and clusters never get more than their cap. Ties in the
+// remainders go to clusters in name order.
+//
+// It returns an error if totalReplicas doesn't fit in all the caps combined.
+func DistributeCapacity(totalReplicas int, clusterCaps map[string]int) (map[string]int, error) {
+	if totalReplicas < 0 {
+		return nil, fmt.Errorf("cannot distribute a negative number of replicas: %d", totalReplicas)
+	}
+
+	names := make([]string, 0, len(clusterCaps))
+	combinedCap := 0
+	for name, limit := range clusterCaps {
+		if limit < 0 {
+			return nil, fmt.Errorf("cluster %q has a negative capacity cap: %d", name, limit)
+		}
+		names = append(names, name)
+		combinedCap += limit
+	}
+	sort.Strings(names)
+
+	if totalReplicas > combinedCap {
+		return nil, fmt.Errorf("%d replicas exceed the combined capacity cap of %d of clusters %v",
+			totalReplicas, combinedCap, names)
+	}
+
+	distribution := make(map[string]int, len(names))
+	if totalReplicas == 0 {
+		for _, name := range names {
+			distribution[name] = 0
+		}
+		return distribution, nil
+	}
+
+	// Every cluster first gets the whole part of its share, and we keep
+	// track of what's left over. Shares are never over a cluster's cap as
+	// totalReplicas <= combinedCap, so neither is their rounded up value.
+	remainders := make(map[string]int, len(names))
+	assigned := 0
+	for _, name := range names {
+		share := totalReplicas * clusterCaps[name]
+		distribution[name] = share / combinedCap
+		remainders[name] = share % combinedCap
+		assigned += distribution[name]
+	}
+
+	byRemainder := make([]string, len(names))
+	copy(byRemainder, names)
+	sort.SliceStable(byRemainder, func(i, j int) bool {
+		return remainders[byRemainder[i]] > remainders[byRemainder[j]]
+	})
+
+	for _, name := range byRemainder[:totalReplicas-assigned] {
+		distribution[name]++
+	}
+
+	return distribution, nil
+}
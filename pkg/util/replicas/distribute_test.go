@@ -0,0 +1,79 @@
+package replicas
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDistributeCapacity(t *testing.T) {
+	tests := []struct {
+		name          string
+		totalReplicas int
+		clusterCaps   map[string]int
+		expected      map[string]int
+	}{
+		{
+			name:          "under capacity with equal caps",
+			totalReplicas: 10,
+			clusterCaps:   map[string]int{"a": 10, "b": 10, "c": 10},
+			expected:      map[string]int{"a": 4, "b": 3, "c": 3},
+		},
+		{
+			name:          "under capacity with different caps",
+			totalReplicas: 10,
+			clusterCaps:   map[string]int{"a": 2, "b": 6, "c": 12},
+			expected:      map[string]int{"a": 1, "b": 3, "c": 6},
+		},
+		{
+			name:          "largest remainder wins",
+			totalReplicas: 7,
+			clusterCaps:   map[string]int{"a": 1, "b": 3, "c": 6},
+			expected:      map[string]int{"a": 1, "b": 2, "c": 4},
+		},
+		{
+			name:          "exactly at capacity",
+			totalReplicas: 20,
+			clusterCaps:   map[string]int{"a": 2, "b": 6, "c": 12},
+			expected:      map[string]int{"a": 2, "b": 6, "c": 12},
+		},
+		{
+			name:          "cluster without capacity",
+			totalReplicas: 4,
+			clusterCaps:   map[string]int{"a": 0, "b": 4},
+			expected:      map[string]int{"a": 0, "b": 4},
+		},
+		{
+			name:          "no replicas",
+			totalReplicas: 0,
+			clusterCaps:   map[string]int{"a": 0, "b": 4},
+			expected:      map[string]int{"a": 0, "b": 0},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := DistributeCapacity(tt.totalReplicas, tt.clusterCaps)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected %d replicas to be distributed as %v, got %v",
+				tt.name, tt.totalReplicas, tt.expected, got)
+		}
+
+		for name, replicas := range got {
+			if replicas > tt.clusterCaps[name] {
+				t.Errorf("%s: cluster %q got %d replicas, over its cap of %d",
+					tt.name, name, replicas, tt.clusterCaps[name])
+			}
+		}
+	}
+}
+
+func TestDistributeCapacityOverCapacity(t *testing.T) {
+	_, err := DistributeCapacity(21, map[string]int{"a": 2, "b": 6, "c": 12})
+	if err == nil {
+		t.Errorf("expected an error distributing more replicas than the clusters can take, got none")
+	}
+}
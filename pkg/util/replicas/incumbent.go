@@ -0,0 +1,31 @@
+package replicas
+
+// IncumbentCapacityHeadroom is the percentage of capacity an incumbent
+// keeps on top of what it needs for the traffic it still gets, so it can
+// take traffic back from a contender without waiting for new pods.
+const IncumbentCapacityHeadroom = 10
+
+// IncumbentCapacityPercentForStep returns the capacity percentage an
+// incumbent needs once its contender gets stepPercent of the traffic: the
+// share of the traffic it's left with plus IncumbentCapacityHeadroom,
+// capped at 100. An incumbent left with no traffic needs no capacity.
+func IncumbentCapacityPercentForStep(stepPercent uint32) int32 {
+	if stepPercent >= 100 {
+		return 0
+	}
+
+	percent := 100 - int32(stepPercent) + IncumbentCapacityHeadroom
+	if percent > 100 {
+		percent = 100
+	}
+
+	return percent
+}
+
+// IncumbentCapacityForStep returns the number of replicas out of
+// totalReplicas an incumbent needs once its contender gets stepPercent of
+// the traffic. See IncumbentCapacityPercentForStep.
+func IncumbentCapacityForStep(stepPercent uint32, totalReplicas int) int {
+	percent := IncumbentCapacityPercentForStep(stepPercent)
+	return int(CalculateDesiredReplicaCount(uint(totalReplicas), float64(percent)))
+}
@@ -0,0 +1,32 @@
+package replicas
+
+import (
+	"testing"
+)
+
+func TestIncumbentCapacityForStep(t *testing.T) {
+	tests := []struct {
+		name          string
+		stepPercent   uint32
+		totalReplicas int
+		expected      int
+	}{
+		{"contender without traffic", 0, 10, 10},
+		{"contender within headroom", 10, 10, 10},
+		{"contender past headroom", 20, 10, 9},
+		{"half of the traffic", 50, 10, 6},
+		{"most of the traffic", 90, 10, 2},
+		{"all of the traffic", 100, 10, 0},
+		{"rounding up", 50, 3, 2},
+		{"single replica", 95, 1, 1},
+		{"no replicas", 50, 0, 0},
+	}
+
+	for _, test := range tests {
+		got := IncumbentCapacityForStep(test.stepPercent, test.totalReplicas)
+		if got != test.expected {
+			t.Errorf("%s: expected %d replicas for %d%% out of %d, got %d",
+				test.name, test.expected, test.stepPercent, test.totalReplicas, got)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package replicas
+
+// CapacityStep returns the replica count to move to from current on the
+// way to desired, increasing by at most headroom replicas at a time.
+// Decreases are never capped, and neither is anything when headroom is 0
+// or less.
+func CapacityStep(current, desired, headroom int) int {
+	if headroom <= 0 || desired <= current {
+		return desired
+	}
+
+	if desired-current > headroom {
+		return current + headroom
+	}
+
+	return desired
+}
+
+// ReplicaCountPercentage returns the largest capacity percentage of
+// totalReplicaCount that CalculateDesiredReplicaCount doesn't take past
+// replicaCount.
+func ReplicaCountPercentage(totalReplicaCount, replicaCount uint) int32 {
+	if totalReplicaCount == 0 {
+		return 0
+	}
+
+	percent := replicaCount * 100 / totalReplicaCount
+	if percent > 100 {
+		percent = 100
+	}
+
+	return int32(percent)
+}
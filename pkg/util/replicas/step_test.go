@@ -0,0 +1,43 @@
+package replicas
+
+import (
+	"testing"
+)
+
+func TestCapacityStep(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  int
+		desired  int
+		headroom int
+		expected int
+	}{
+		{"unlimited", 0, 10, 0, 10},
+		{"negative headroom is unlimited", 0, 10, -1, 10},
+		{"limited by headroom", 0, 10, 3, 3},
+		{"limited by headroom half way", 6, 10, 3, 9},
+		{"within headroom", 8, 10, 3, 10},
+		{"exactly the headroom", 7, 10, 3, 10},
+		{"scaling down ignores headroom", 10, 2, 3, 2},
+		{"already there", 10, 10, 3, 10},
+	}
+
+	for _, test := range tests {
+		got := CapacityStep(test.current, test.desired, test.headroom)
+		if got != test.expected {
+			t.Errorf("%s: expected step from %d to %d with headroom %d to be %d, got %d",
+				test.name, test.current, test.desired, test.headroom, test.expected, got)
+		}
+	}
+}
+
+func TestReplicaCountPercentage(t *testing.T) {
+	for total := uint(1); total <= 100; total++ {
+		for count := uint(0); count <= total; count++ {
+			percent := ReplicaCountPercentage(total, count)
+			if got := CalculateDesiredReplicaCount(total, float64(percent)); got != count {
+				t.Fatalf("expected %d%% of %d replicas to be %d, got %d", percent, total, count, got)
+			}
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
 	"github.com/bookingcom/shipper/pkg/util/conditions"
 	"github.com/bookingcom/shipper/pkg/util/diff"
+	targetutil "github.com/bookingcom/shipper/pkg/util/target"
 )
 
 var TrafficConditionsShouldDiscardTimestamps = false
@@ -90,6 +91,16 @@ func GetClusterTrafficCondition(status shipper.ClusterTrafficStatus, condType sh
 	return nil
 }
 
+func SetTrafficTargetCondition(status *shipper.TrafficTargetStatus, condition shipper.TargetCondition) diff.Diff {
+	conditions, diff := targetutil.SetTargetCondition(status.Conditions, condition)
+	status.Conditions = conditions
+	return diff
+}
+
+func GetTrafficTargetCondition(status shipper.TrafficTargetStatus, condType shipper.TargetConditionType) *shipper.TargetCondition {
+	return targetutil.GetTargetCondition(status.Conditions, condType)
+}
+
 func filterOutCondition(conditions []shipper.ClusterTrafficCondition, condType shipper.ClusterConditionType) []shipper.ClusterTrafficCondition {
 	var newConditions []shipper.ClusterTrafficCondition
 	for _, c := range conditions {
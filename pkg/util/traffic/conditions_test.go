@@ -0,0 +1,82 @@
+package traffic
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	targetutil "github.com/bookingcom/shipper/pkg/util/target"
+)
+
+func TestSetTrafficTargetReadyCondition(t *testing.T) {
+	discardTimestamps := targetutil.ConditionsShouldDiscardTimestamps
+	targetutil.ConditionsShouldDiscardTimestamps = true
+	defer func() { targetutil.ConditionsShouldDiscardTimestamps = discardTimestamps }()
+
+	ready := targetutil.NewTargetCondition(
+		shipper.TrafficTargetConditionTypeReady,
+		corev1.ConditionTrue, "", "")
+	notReady := targetutil.NewTargetCondition(
+		shipper.TrafficTargetConditionTypeReady,
+		corev1.ConditionFalse, "ClustersNotReady", "minikube: not converged")
+
+	tests := []struct {
+		name       string
+		current    *shipper.TargetCondition
+		new        shipper.TargetCondition
+		expectDiff bool
+	}{
+		{
+			name:       "no condition yet becomes not ready",
+			current:    nil,
+			new:        notReady,
+			expectDiff: true,
+		},
+		{
+			name:       "not ready becomes ready",
+			current:    &notReady,
+			new:        ready,
+			expectDiff: true,
+		},
+		{
+			name:       "ready becomes not ready",
+			current:    &ready,
+			new:        notReady,
+			expectDiff: true,
+		},
+		{
+			name:       "ready stays ready",
+			current:    &ready,
+			new:        ready,
+			expectDiff: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &shipper.TrafficTargetStatus{}
+			if tt.current != nil {
+				status.Conditions = []shipper.TargetCondition{*tt.current}
+			}
+
+			diff := SetTrafficTargetCondition(status, tt.new)
+			if diff.IsEmpty() == tt.expectDiff {
+				t.Errorf("expected diff to be present: %t, got %q", tt.expectDiff, diff.String())
+			}
+
+			if len(status.Conditions) != 1 {
+				t.Fatalf("expected exactly 1 condition, got %d", len(status.Conditions))
+			}
+
+			cond := GetTrafficTargetCondition(*status, shipper.TrafficTargetConditionTypeReady)
+			if cond == nil {
+				t.Fatalf("expected a Ready condition to be present")
+			}
+			if cond.Status != tt.new.Status || cond.Reason != tt.new.Reason {
+				t.Errorf("expected condition %s %s, got %s %s",
+					tt.new.Status, tt.new.Reason, cond.Status, cond.Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package traffic
+
+import (
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// TrafficTargetsConflict returns whether a and b would fight over the
+// traffic of the same release if both were active: they are different
+// objects in the same namespace, labeled for the same release, with at
+// least one cluster in common. A traffic target with no clusters in its
+// spec gets its weight in every cluster of its application, so it
+// overlaps with any other.
+func TrafficTargetsConflict(a, b *shipper.TrafficTarget) bool {
+	if a.Namespace != b.Namespace || a.Name == b.Name {
+		return false
+	}
+
+	releaseA, okA := a.Labels[shipper.ReleaseLabel]
+	releaseB, okB := b.Labels[shipper.ReleaseLabel]
+	if !okA || !okB || releaseA != releaseB {
+		return false
+	}
+
+	if len(a.Spec.Clusters) == 0 || len(b.Spec.Clusters) == 0 {
+		return true
+	}
+
+	clusters := make(map[string]struct{}, len(a.Spec.Clusters))
+	for _, cluster := range a.Spec.Clusters {
+		clusters[cluster.Name] = struct{}{}
+	}
+
+	for _, cluster := range b.Spec.Clusters {
+		if _, ok := clusters[cluster.Name]; ok {
+			return true
+		}
+	}
+
+	return false
+}
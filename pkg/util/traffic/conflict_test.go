@@ -0,0 +1,82 @@
+package traffic
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func buildConflictTrafficTarget(name, release string, clusters ...string) *shipper.TrafficTarget {
+	tt := &shipper.TrafficTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test-namespace",
+			Name:      name,
+			Labels:    map[string]string{shipper.ReleaseLabel: release},
+		},
+	}
+
+	for _, cluster := range clusters {
+		tt.Spec.Clusters = append(tt.Spec.Clusters, shipper.ClusterTrafficTarget{
+			Name:   cluster,
+			Weight: 50,
+		})
+	}
+
+	return tt
+}
+
+func TestTrafficTargetsConflict(t *testing.T) {
+	otherNamespace := buildConflictTrafficTarget("foo-b", "foo", "cluster-a")
+	otherNamespace.Namespace = "other-namespace"
+
+	tests := []struct {
+		name     string
+		a, b     *shipper.TrafficTarget
+		expected bool
+	}{
+		{
+			name:     "same release in overlapping clusters",
+			a:        buildConflictTrafficTarget("foo-a", "foo", "cluster-a", "cluster-b"),
+			b:        buildConflictTrafficTarget("foo-b", "foo", "cluster-b", "cluster-c"),
+			expected: true,
+		},
+		{
+			name:     "same release in disjoint clusters",
+			a:        buildConflictTrafficTarget("foo-a", "foo", "cluster-a"),
+			b:        buildConflictTrafficTarget("foo-b", "foo", "cluster-b"),
+			expected: false,
+		},
+		{
+			name:     "same release in every cluster",
+			a:        buildConflictTrafficTarget("foo-a", "foo"),
+			b:        buildConflictTrafficTarget("foo-b", "foo", "cluster-b"),
+			expected: true,
+		},
+		{
+			name:     "different releases in the same cluster",
+			a:        buildConflictTrafficTarget("foo", "foo", "cluster-a"),
+			b:        buildConflictTrafficTarget("bar", "bar", "cluster-a"),
+			expected: false,
+		},
+		{
+			name:     "same release in different namespaces",
+			a:        buildConflictTrafficTarget("foo-a", "foo", "cluster-a"),
+			b:        otherNamespace,
+			expected: false,
+		},
+		{
+			name:     "same traffic target",
+			a:        buildConflictTrafficTarget("foo", "foo", "cluster-a"),
+			b:        buildConflictTrafficTarget("foo", "foo", "cluster-a"),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := TrafficTargetsConflict(tt.a, tt.b); got != tt.expected {
+			t.Errorf("%s: expected conflict to be %t, got %t", tt.name, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package traffic
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/bookingcom/shipper/pkg/util/replicas"
+)
+
+// ReleasePodTarget returns how many of the releasePods pods of a release
+// with releaseWeight out of totalWeight should receive traffic, when there
+// are totalPods pods in the application. Its share of the pods is rounded
+// up, and never more than the pods it has.
+func ReleasePodTarget(releasePods int, releaseWeight uint32, totalPods int, totalWeight uint32) int {
+	// What percentage of the entire fleet (across all releases) should
+	// this set of pods represent.
+	var targetPercent float64
+	if totalWeight == 0 {
+		targetPercent = 0
+	} else {
+		targetPercent = float64(releaseWeight) / float64(totalWeight) * 100
+	}
+
+	// Round up to the nearest pod, clamped to the number of pods this
+	// release has.
+	targetPods := int(replicas.CalculateDesiredReplicaCount(uint(totalPods), float64(targetPercent)))
+	targetPods = int(math.Min(float64(releasePods), float64(targetPods)))
+
+	return targetPods
+}
+
+// CapReleasePodTargets scales down podTargets, keyed by release name, so
+// they add up to no more than maxPods. Every release gets its proportional
+// share rounded down, and the pods left over after rounding go to the
+// releases with the largest remainders, ties broken by release name so
+// every release agrees on the outcome.
+func CapReleasePodTargets(podTargets map[string]int, maxPods int) map[string]int {
+	total := 0
+	for _, target := range podTargets {
+		total += target
+	}
+
+	if total <= maxPods {
+		return podTargets
+	}
+
+	releases := make([]string, 0, len(podTargets))
+	for release := range podTargets {
+		releases = append(releases, release)
+	}
+	sort.Strings(releases)
+
+	capped := make(map[string]int, len(podTargets))
+	remainders := make(map[string]int, len(podTargets))
+	assigned := 0
+	for _, release := range releases {
+		capped[release] = podTargets[release] * maxPods / total
+		remainders[release] = podTargets[release] * maxPods % total
+		assigned += capped[release]
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		return remainders[releases[i]] > remainders[releases[j]]
+	})
+
+	for i := 0; i < maxPods-assigned; i++ {
+		capped[releases[i]]++
+	}
+
+	return capped
+}
+
+// WillConverge checks whether the traffic weights of the releases sharing a
+// cluster can ever be achieved with at most maxPods pods receiving traffic,
+// as is the case when the cluster caps its traffic pods. Pods are picked the
+// same way the controller does: every release gets its share of the pods
+// rounded up, and their shares are scaled down to fit in maxPods. If that
+// leaves a release with a non-zero weight without any pods, it would never
+// get any traffic no matter how many pods there are, and the reason returned
+// says which release that is.
+func WillConverge(weights map[string]uint32, maxPods int) (bool, string) {
+	var totalWeight uint32
+	releases := make([]string, 0, len(weights))
+	for release, weight := range weights {
+		totalWeight += weight
+		releases = append(releases, release)
+	}
+
+	sort.Strings(releases)
+
+	podTargets := make(map[string]int, len(weights))
+	for _, release := range releases {
+		podTargets[release] = ReleasePodTarget(maxPods, weights[release], maxPods, totalWeight)
+	}
+
+	podTargets = CapReleasePodTargets(podTargets, maxPods)
+
+	for _, release := range releases {
+		weight := weights[release]
+		if weight > 0 && podTargets[release] == 0 {
+			return false, fmt.Sprintf(
+				"release %q has weight %d out of %d, but would get none of the at most %d pods receiving traffic",
+				release, weight, totalWeight, maxPods)
+		}
+	}
+
+	return true, ""
+}
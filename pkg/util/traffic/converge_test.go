@@ -0,0 +1,91 @@
+package traffic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCapReleasePodTargets(t *testing.T) {
+	tests := []struct {
+		name     string
+		targets  map[string]int
+		maxPods  int
+		expected map[string]int
+	}{
+		{
+			name:     "under the cap",
+			targets:  map[string]int{"incumbent": 4, "contender": 4},
+			maxPods:  10,
+			expected: map[string]int{"incumbent": 4, "contender": 4},
+		},
+		{
+			name:     "scaled down proportionally",
+			targets:  map[string]int{"incumbent": 9, "contender": 3},
+			maxPods:  4,
+			expected: map[string]int{"incumbent": 3, "contender": 1},
+		},
+		{
+			name:     "leftover pods go to the largest remainders",
+			targets:  map[string]int{"incumbent": 5, "contender": 3, "other": 2},
+			maxPods:  7,
+			expected: map[string]int{"incumbent": 4, "contender": 2, "other": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := CapReleasePodTargets(tt.targets, tt.maxPods)
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Errorf("expected pod targets %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWillConverge(t *testing.T) {
+	tests := []struct {
+		name      string
+		weights   map[string]uint32
+		maxPods   int
+		converges bool
+	}{
+		{
+			name:      "enough pods for every release",
+			weights:   map[string]uint32{"foo-a": 1, "foo-b": 99},
+			maxPods:   2,
+			converges: true,
+		},
+		{
+			name:      "release without weight",
+			weights:   map[string]uint32{"foo-a": 0, "foo-b": 100},
+			maxPods:   1,
+			converges: true,
+		},
+		{
+			name:      "not enough pods for every release",
+			weights:   map[string]uint32{"foo-a": 50, "foo-b": 50},
+			maxPods:   1,
+			converges: false,
+		},
+		{
+			name:      "no pods at all",
+			weights:   map[string]uint32{"foo-a": 100},
+			maxPods:   0,
+			converges: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			converges, reason := WillConverge(test.weights, test.maxPods)
+			if converges != test.converges {
+				t.Fatalf("expected weights %v with %d pods to converge: %t, got %t (%q)",
+					test.weights, test.maxPods, test.converges, converges, reason)
+			}
+
+			if !converges && reason == "" {
+				t.Errorf("expected a reason for weights not converging, got none")
+			}
+		})
+	}
+}
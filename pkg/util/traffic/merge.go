@@ -0,0 +1,54 @@
+package traffic
+
+import (
+	"fmt"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+// MergeTrafficTargets returns a copy of primary that also carries the
+// per-cluster weights of extra, so accidental duplicate traffic targets for
+// a release can be consolidated into one before the extras are deleted.
+// Clusters present in both must agree on their weight, otherwise there's no
+// telling which one was meant and an error is returned instead. Neither of
+// the traffic targets passed in is modified.
+func MergeTrafficTargets(primary, extra *shipper.TrafficTarget) (*shipper.TrafficTarget, error) {
+	if primary.Namespace != extra.Namespace {
+		return nil, fmt.Errorf(
+			`TrafficTargets "%s/%s" and "%s/%s" are in different namespaces`,
+			primary.Namespace, primary.Name, extra.Namespace, extra.Name)
+	}
+
+	primaryRelease := primary.Labels[shipper.ReleaseLabel]
+	extraRelease := extra.Labels[shipper.ReleaseLabel]
+	if primaryRelease != extraRelease {
+		return nil, fmt.Errorf(
+			`TrafficTargets "%s/%s" and "%s/%s" belong to different releases: %q and %q`,
+			primary.Namespace, primary.Name, extra.Namespace, extra.Name,
+			primaryRelease, extraRelease)
+	}
+
+	merged := primary.DeepCopy()
+
+	weights := make(map[string]uint32, len(merged.Spec.Clusters))
+	for _, cluster := range merged.Spec.Clusters {
+		weights[cluster.Name] = cluster.Weight
+	}
+
+	for _, cluster := range extra.Spec.Clusters {
+		weight, ok := weights[cluster.Name]
+		if !ok {
+			merged.Spec.Clusters = append(merged.Spec.Clusters, cluster)
+			weights[cluster.Name] = cluster.Weight
+			continue
+		}
+
+		if weight != cluster.Weight {
+			return nil, shippererrors.NewConflictingTrafficWeightsError(
+				primary, extra, cluster.Name, weight, cluster.Weight)
+		}
+	}
+
+	return merged, nil
+}
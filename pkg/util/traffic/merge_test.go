@@ -0,0 +1,79 @@
+package traffic
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
+)
+
+func buildTrafficTarget(name string, clusters ...shipper.ClusterTrafficTarget) *shipper.TrafficTarget {
+	return &shipper.TrafficTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "test-namespace",
+			Labels: map[string]string{
+				shipper.ReleaseLabel: "test-release",
+			},
+		},
+		Spec: shipper.TrafficTargetSpec{
+			Clusters: clusters,
+		},
+	}
+}
+
+func TestMergeTrafficTargetsWithDisjointClusters(t *testing.T) {
+	primary := buildTrafficTarget("test-release",
+		shipper.ClusterTrafficTarget{Name: "cluster-a", Weight: 10},
+		shipper.ClusterTrafficTarget{Name: "cluster-b", Weight: 20})
+	extra := buildTrafficTarget("test-release-duplicate",
+		shipper.ClusterTrafficTarget{Name: "cluster-b", Weight: 20},
+		shipper.ClusterTrafficTarget{Name: "cluster-c", Weight: 30})
+
+	merged, err := MergeTrafficTargets(primary, extra)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []shipper.ClusterTrafficTarget{
+		{Name: "cluster-a", Weight: 10},
+		{Name: "cluster-b", Weight: 20},
+		{Name: "cluster-c", Weight: 30},
+	}
+	if !reflect.DeepEqual(merged.Spec.Clusters, expected) {
+		t.Errorf("expected merged clusters %v, got %v", expected, merged.Spec.Clusters)
+	}
+
+	if merged.Name != primary.Name {
+		t.Errorf("expected merged traffic target to be named %q, got %q", primary.Name, merged.Name)
+	}
+
+	if len(primary.Spec.Clusters) != 2 {
+		t.Errorf("expected primary traffic target to be left untouched, got %v", primary.Spec.Clusters)
+	}
+}
+
+func TestMergeTrafficTargetsWithConflictingWeights(t *testing.T) {
+	primary := buildTrafficTarget("test-release",
+		shipper.ClusterTrafficTarget{Name: "cluster-a", Weight: 10})
+	extra := buildTrafficTarget("test-release-duplicate",
+		shipper.ClusterTrafficTarget{Name: "cluster-a", Weight: 50})
+
+	_, err := MergeTrafficTargets(primary, extra)
+	if !shippererrors.IsConflictingTrafficWeightsError(err) {
+		t.Fatalf("expected a conflicting traffic weights error, got %v", err)
+	}
+}
+
+func TestMergeTrafficTargetsOfDifferentReleases(t *testing.T) {
+	primary := buildTrafficTarget("test-release")
+	extra := buildTrafficTarget("other-release")
+	extra.Labels[shipper.ReleaseLabel] = "other-release"
+
+	if _, err := MergeTrafficTargets(primary, extra); err == nil {
+		t.Fatalf("expected an error merging traffic targets of different releases")
+	}
+}
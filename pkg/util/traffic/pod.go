@@ -0,0 +1,57 @@
+package traffic
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// DesiredPodTrafficStatus returns the traffic label a new pod of the
+// release behind tt should start with in clusterName, given the traffic
+// targets of every release of the same application. Pods are only enabled
+// right away when tt has all of the weight in clusterName, as the traffic
+// controller would then enable every one of them anyway. Anything else
+// depends on how many pods are already enabled, so they start disabled
+// and are left for the traffic controller to enable as needed.
+func DesiredPodTrafficStatus(tt *shipper.TrafficTarget, appTrafficTargets []*shipper.TrafficTarget, clusterName string) string {
+	if clusterWeight(tt, clusterName) == 0 {
+		return shipper.Disabled
+	}
+
+	for _, other := range appTrafficTargets {
+		if other.Name != tt.Name && clusterWeight(other, clusterName) > 0 {
+			return shipper.Disabled
+		}
+	}
+
+	return shipper.Enabled
+}
+
+func clusterWeight(tt *shipper.TrafficTarget, clusterName string) uint32 {
+	for _, spec := range tt.Spec.Clusters {
+		if spec.Name == clusterName {
+			return spec.Weight
+		}
+	}
+
+	return 0
+}
+
+// DefaultPodTrafficLabel sets the traffic label of pod to desired, unless it
+// already has one, and returns whether it did. It's meant to be called when
+// pods are created, so they don't sit out of traffic until the traffic
+// controller gets around to labeling them. Pods that already have a traffic
+// label are left for the traffic controller to deal with.
+func DefaultPodTrafficLabel(pod *corev1.Pod, desired string) bool {
+	if _, ok := pod.Labels[shipper.PodTrafficStatusLabel]; ok {
+		return false
+	}
+
+	if pod.Labels == nil {
+		pod.Labels = make(map[string]string)
+	}
+
+	pod.Labels[shipper.PodTrafficStatusLabel] = desired
+
+	return true
+}
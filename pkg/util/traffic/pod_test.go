@@ -0,0 +1,87 @@
+package traffic
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestDesiredPodTrafficStatus(t *testing.T) {
+	buildTrafficTarget := func(name string, weights map[string]uint32) *shipper.TrafficTarget {
+		tt := &shipper.TrafficTarget{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		for cluster, weight := range weights {
+			tt.Spec.Clusters = append(tt.Spec.Clusters, shipper.ClusterTrafficTarget{Name: cluster, Weight: weight})
+		}
+		return tt
+	}
+
+	tt := buildTrafficTarget("contender", map[string]uint32{"cluster-a": 10, "cluster-b": 0, "cluster-c": 100})
+	incumbent := buildTrafficTarget("incumbent", map[string]uint32{"cluster-a": 90, "cluster-b": 100, "cluster-c": 0})
+	appTrafficTargets := []*shipper.TrafficTarget{tt, incumbent}
+
+	tests := []struct {
+		cluster  string
+		expected string
+	}{
+		{"cluster-a", shipper.Disabled},
+		{"cluster-b", shipper.Disabled},
+		{"cluster-c", shipper.Enabled},
+		{"cluster-d", shipper.Disabled},
+	}
+
+	for _, test := range tests {
+		got := DesiredPodTrafficStatus(tt, appTrafficTargets, test.cluster)
+		if got != test.expected {
+			t.Errorf("%s: expected traffic status %q, got %q", test.cluster, test.expected, got)
+		}
+	}
+}
+
+func TestDefaultPodTrafficLabel(t *testing.T) {
+	tests := []struct {
+		name            string
+		labels          map[string]string
+		desired         string
+		expectedChanged bool
+		expectedLabel   string
+	}{
+		{
+			name:            "no labels at all",
+			desired:         shipper.Enabled,
+			expectedChanged: true,
+			expectedLabel:   shipper.Enabled,
+		},
+		{
+			name:            "no traffic label",
+			labels:          map[string]string{shipper.ReleaseLabel: "contender"},
+			desired:         shipper.Disabled,
+			expectedChanged: true,
+			expectedLabel:   shipper.Disabled,
+		},
+		{
+			name:            "traffic label already set",
+			labels:          map[string]string{shipper.PodTrafficStatusLabel: shipper.Disabled},
+			desired:         shipper.Enabled,
+			expectedChanged: false,
+			expectedLabel:   shipper.Disabled,
+		},
+	}
+
+	for _, test := range tests {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Labels: test.labels},
+		}
+
+		changed := DefaultPodTrafficLabel(pod, test.desired)
+		if changed != test.expectedChanged {
+			t.Errorf("%s: expected changed to be %t, got %t", test.name, test.expectedChanged, changed)
+		}
+
+		if got := pod.Labels[shipper.PodTrafficStatusLabel]; got != test.expectedLabel {
+			t.Errorf("%s: expected traffic label %q, got %q", test.name, test.expectedLabel, got)
+		}
+	}
+}
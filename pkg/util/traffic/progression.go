@@ -0,0 +1,86 @@
+package traffic
+
+import (
+	"fmt"
+)
+
+const (
+	// ContenderRelease is the name the contender's weights are recorded
+	// under in the history ValidateWeightProgression checks.
+	ContenderRelease = "shipper.booking.com/contender"
+
+	// RollbackFlag is recorded with a non-zero weight in any cluster of a
+	// snapshot that was taken right after a deliberate rollback. It's not
+	// a release, so it's never part of the weights being compared.
+	RollbackFlag = "shipper.booking.com/rollback"
+)
+
+// ValidateWeightProgression checks that the contender only ever gains
+// traffic over the course of a rollout, and returns an error for every
+// time it didn't. history holds snapshots of the rollout in the order they
+// were taken, as recorded from its traffic targets: every snapshot has the
+// weights of all releases in each cluster, with clusters in the same order
+// across snapshots. Going from one snapshot to the next is judged with
+// IsRollback, so only the contender's share of the traffic matters.
+//
+// Bare weights don't say which release is the contender, and guessing it
+// from how the weights move would hide the very mistakes this is meant to
+// catch, so its weights have to be recorded under ContenderRelease.
+//
+// A contender losing traffic is usually the sign of buggy automation, but
+// not when the rollout was deliberately rolled back. Snapshots flagged with
+// RollbackFlag are never reported. Snapshots with a different number of
+// clusters than the one before them can't be compared, so they're reported
+// too.
+func ValidateWeightProgression(history [][]map[string]uint32) []error {
+	var errs []error
+	for i := 1; i < len(history); i++ {
+		prev, curr := history[i-1], history[i]
+		if len(prev) != len(curr) {
+			errs = append(errs, fmt.Errorf(
+				"snapshot %d has weights for %d clusters, but snapshot %d had them for %d",
+				i, len(curr), i-1, len(prev)))
+			continue
+		}
+
+		if isFlaggedRollback(curr) {
+			continue
+		}
+
+		for cluster := range curr {
+			prevWeights, currWeights := releaseWeights(prev[cluster]), releaseWeights(curr[cluster])
+			if IsRollback(prevWeights, currWeights, ContenderRelease) {
+				errs = append(errs, fmt.Errorf(
+					"snapshot %d: contender lost traffic in cluster %d without a rollback, going from weights %v to %v",
+					i, cluster, prevWeights, currWeights))
+			}
+		}
+	}
+
+	return errs
+}
+
+func isFlaggedRollback(snapshot []map[string]uint32) bool {
+	for _, weights := range snapshot {
+		if weights[RollbackFlag] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseWeights returns weights without RollbackFlag, leaving only the
+// weights of actual releases.
+func releaseWeights(weights map[string]uint32) map[string]uint32 {
+	if _, ok := weights[RollbackFlag]; !ok {
+		return weights
+	}
+
+	releases := make(map[string]uint32, len(weights))
+	for release, weight := range weights {
+		if release != RollbackFlag {
+			releases[release] = weight
+		}
+	}
+	return releases
+}
@@ -0,0 +1,118 @@
+package traffic
+
+import (
+	"testing"
+)
+
+func TestValidateWeightProgression(t *testing.T) {
+	const (
+		contender = ContenderRelease
+		incumbent = "incumbent"
+	)
+
+	snapshot := func(weights ...uint32) []map[string]uint32 {
+		clusters := make([]map[string]uint32, 0, len(weights))
+		for _, weight := range weights {
+			clusters = append(clusters, map[string]uint32{
+				contender: weight,
+				incumbent: 100 - weight,
+			})
+		}
+		return clusters
+	}
+
+	rolledBack := func(clusters []map[string]uint32) []map[string]uint32 {
+		clusters[0][RollbackFlag] = 1
+		return clusters
+	}
+
+	tests := []struct {
+		name           string
+		history        [][]map[string]uint32
+		expectedErrors int
+	}{
+		{
+			name:           "no history",
+			expectedErrors: 0,
+		},
+		{
+			name: "clean ramp",
+			history: [][]map[string]uint32{
+				snapshot(0, 0),
+				snapshot(10, 10),
+				snapshot(50, 10),
+				snapshot(50, 50),
+				snapshot(100, 100),
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "holding weight",
+			history: [][]map[string]uint32{
+				snapshot(10),
+				snapshot(10),
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "scaled weights",
+			history: [][]map[string]uint32{
+				{{contender: 1, incumbent: 1}},
+				{{contender: 50, incumbent: 50}},
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "accidental dip",
+			history: [][]map[string]uint32{
+				snapshot(10, 10),
+				snapshot(50, 50),
+				snapshot(50, 20),
+				snapshot(100, 100),
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "dip in every cluster",
+			history: [][]map[string]uint32{
+				snapshot(50, 50),
+				snapshot(10, 10),
+			},
+			expectedErrors: 2,
+		},
+		{
+			name: "flagged rollback",
+			history: [][]map[string]uint32{
+				snapshot(10, 10),
+				snapshot(50, 50),
+				rolledBack(snapshot(0, 0)),
+			},
+			expectedErrors: 0,
+		},
+		{
+			name: "dip after a flagged rollback",
+			history: [][]map[string]uint32{
+				snapshot(50, 50),
+				rolledBack(snapshot(0, 0)),
+				snapshot(10, 10),
+				snapshot(5, 10),
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "cluster added",
+			history: [][]map[string]uint32{
+				snapshot(10),
+				snapshot(10, 0),
+			},
+			expectedErrors: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		errs := ValidateWeightProgression(tt.history)
+		if len(errs) != tt.expectedErrors {
+			t.Errorf("%s: expected %d errors, got %d: %v", tt.name, tt.expectedErrors, len(errs), errs)
+		}
+	}
+}
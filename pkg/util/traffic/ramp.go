@@ -0,0 +1,98 @@
+package traffic
+
+import (
+	"math"
+	"time"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+// PlanWeightSteps returns the weights a release goes through to get from
+// current to target according to ramp, target included. Weights only ever
+// ramp up: taking traffic away is done in a single move, and so is
+// everything when ramp is nil. Linear ramps grow by ramp.MaxDelta every
+// time, exponential ones double and fibonacci ones follow the Fibonacci
+// sequence, never growing by more than ramp.MaxDelta when it's set.
+func PlanWeightSteps(current, target uint32, ramp *shipper.TrafficRamp) []uint32 {
+	if current == target {
+		return nil
+	}
+
+	if ramp == nil || target < current {
+		return []uint32{target}
+	}
+
+	var steps []uint32
+	prev, weight := uint64(current), uint64(current)
+	for weight < uint64(target) {
+		next := nextRampWeight(prev, weight, ramp)
+		if next > uint64(target) {
+			next = uint64(target)
+		}
+
+		prev, weight = weight, next
+		steps = append(steps, uint32(weight))
+	}
+
+	return steps
+}
+
+// EstimatedSyncsToConverge returns how many reconciles a linear ramp that
+// never grows by more than maxDelta takes to get a release from current to
+// target weight, one weight of PlanWeightSteps for every one of them.
+// Without a maxDelta, or when taking traffic away, it's done in a single
+// reconcile. It's only an estimate: a reconcile can need a few retries
+// before traffic shifts to the weight it asked for.
+func EstimatedSyncsToConverge(current, target uint32, maxDelta uint32) int {
+	if current == target {
+		return 0
+	}
+
+	if maxDelta == 0 || target < current {
+		return 1
+	}
+
+	delta := uint64(target - current)
+
+	return int((delta + uint64(maxDelta) - 1) / uint64(maxDelta))
+}
+
+// EstimatedTimeToConverge returns how long the ramp of
+// EstimatedSyncsToConverge takes if every reconcile waits for the next
+// resync. Reconciles are usually triggered sooner than that by changes to
+// the objects involved, so it's closer to an upper bound.
+func EstimatedTimeToConverge(current, target uint32, maxDelta uint32, resync time.Duration) time.Duration {
+	return time.Duration(EstimatedSyncsToConverge(current, target, maxDelta)) * resync
+}
+
+// nextRampWeight returns the weight that comes after weight in ramp, prev
+// being the one that came right before it. Linear ramps without a
+// MaxDelta have no intermediate weights, so they go all the way in one
+// move. Other than that, weights always grow by at least 1, so ramps are
+// guaranteed to make progress.
+func nextRampWeight(prev, weight uint64, ramp *shipper.TrafficRamp) uint64 {
+	maxDelta := uint64(ramp.MaxDelta)
+
+	var delta uint64
+	switch ramp.Shape {
+	case shipper.TrafficRampShapeExponential:
+		delta = weight
+	case shipper.TrafficRampShapeFibonacci:
+		delta = prev
+	default:
+		if maxDelta == 0 {
+			return math.MaxUint32
+		}
+		delta = maxDelta
+	}
+
+	if maxDelta > 0 && delta > maxDelta {
+		delta = maxDelta
+	}
+
+	if delta == 0 {
+		delta = 1
+	}
+
+	return weight + delta
+}
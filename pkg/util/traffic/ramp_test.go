@@ -0,0 +1,138 @@
+package traffic
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+)
+
+func TestPlanWeightSteps(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  uint32
+		target   uint32
+		ramp     *shipper.TrafficRamp
+		expected []uint32
+	}{
+		{
+			name:     "no ramp",
+			current:  1,
+			target:   100,
+			expected: []uint32{100},
+		},
+		{
+			name:     "linear",
+			current:  1,
+			target:   100,
+			ramp:     &shipper.TrafficRamp{Shape: shipper.TrafficRampShapeLinear, MaxDelta: 20},
+			expected: []uint32{21, 41, 61, 81, 100},
+		},
+		{
+			name:     "linear without max delta",
+			current:  1,
+			target:   100,
+			ramp:     &shipper.TrafficRamp{Shape: shipper.TrafficRampShapeLinear},
+			expected: []uint32{100},
+		},
+		{
+			name:     "exponential",
+			current:  1,
+			target:   100,
+			ramp:     &shipper.TrafficRamp{Shape: shipper.TrafficRampShapeExponential},
+			expected: []uint32{2, 4, 8, 16, 32, 64, 100},
+		},
+		{
+			name:     "exponential with max delta",
+			current:  1,
+			target:   100,
+			ramp:     &shipper.TrafficRamp{Shape: shipper.TrafficRampShapeExponential, MaxDelta: 25},
+			expected: []uint32{2, 4, 8, 16, 32, 57, 82, 100},
+		},
+		{
+			name:     "exponential from no traffic",
+			current:  0,
+			target:   10,
+			ramp:     &shipper.TrafficRamp{Shape: shipper.TrafficRampShapeExponential},
+			expected: []uint32{1, 2, 4, 8, 10},
+		},
+		{
+			name:     "fibonacci",
+			current:  1,
+			target:   100,
+			ramp:     &shipper.TrafficRamp{Shape: shipper.TrafficRampShapeFibonacci},
+			expected: []uint32{2, 3, 5, 8, 13, 21, 34, 55, 89, 100},
+		},
+		{
+			name:     "fibonacci from no traffic",
+			current:  0,
+			target:   10,
+			ramp:     &shipper.TrafficRamp{Shape: shipper.TrafficRampShapeFibonacci},
+			expected: []uint32{1, 2, 3, 5, 8, 10},
+		},
+		{
+			name:     "ramping down",
+			current:  100,
+			target:   1,
+			ramp:     &shipper.TrafficRamp{Shape: shipper.TrafficRampShapeExponential},
+			expected: []uint32{1},
+		},
+		{
+			name:    "already there",
+			current: 50,
+			target:  50,
+			ramp:    &shipper.TrafficRamp{Shape: shipper.TrafficRampShapeLinear, MaxDelta: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		got := PlanWeightSteps(tt.current, tt.target, tt.ramp)
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected weight steps %v from %d to %d, got %v",
+				tt.name, tt.expected, tt.current, tt.target, got)
+		}
+	}
+}
+
+func TestEstimatedSyncsToConverge(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  uint32
+		target   uint32
+		maxDelta uint32
+		expected int
+	}{
+		{"already there", 50, 50, 10, 0},
+		{"no max delta", 1, 100, 0, 1},
+		{"ramping down", 100, 1, 10, 1},
+		{"exact multiple", 0, 100, 25, 4},
+		{"remainder", 1, 100, 20, 5},
+		{"single step", 10, 15, 20, 1},
+		{"one at a time", 0, 10, 1, 10},
+		{"whole range", 0, math.MaxUint32, math.MaxUint32, 1},
+	}
+
+	for _, tt := range tests {
+		got := EstimatedSyncsToConverge(tt.current, tt.target, tt.maxDelta)
+		if got != tt.expected {
+			t.Errorf("%s: expected %d syncs from %d to %d with max delta %d, got %d",
+				tt.name, tt.expected, tt.current, tt.target, tt.maxDelta, got)
+		}
+
+		// Every sync moves to the next planned weight.
+		ramp := &shipper.TrafficRamp{Shape: shipper.TrafficRampShapeLinear, MaxDelta: tt.maxDelta}
+		if planned := len(PlanWeightSteps(tt.current, tt.target, ramp)); planned != got {
+			t.Errorf("%s: expected as many syncs as planned weights, got %d syncs and %d weights",
+				tt.name, got, planned)
+		}
+	}
+}
+
+func TestEstimatedTimeToConverge(t *testing.T) {
+	got := EstimatedTimeToConverge(1, 100, 20, 30*time.Second)
+	if expected := 150 * time.Second; got != expected {
+		t.Errorf("expected %s to converge, got %s", expected, got)
+	}
+}
@@ -0,0 +1,63 @@
+package traffic
+
+import (
+	"math"
+)
+
+// EffectiveWeight returns the traffic weight a release effectively gets when
+// releasePods out of totalPods pods in the application receive traffic, and
+// the weights of all the releases involved add up to totalWeight. Since pod
+// counts are discrete, this rarely matches the requested weight exactly.
+//
+// This is the same math the traffic controller uses to report achieved
+// traffic, so it can be used to display weights consistently with it.
+func EffectiveWeight(releasePods, totalPods int, totalWeight uint32) uint32 {
+	if totalPods == 0 {
+		return 0
+	}
+
+	achievedPercentage := float64(releasePods) / float64(totalPods)
+
+	return uint32(math.Round(achievedPercentage * float64(totalWeight)))
+}
+
+// IsRollback returns whether going from the release weights in prev to the
+// ones in curr takes traffic away from contenderRelease. Weights are
+// relative, so what is compared is the contender's share of the total
+// weight rather than its weight alone: scaling every release's weight by
+// the same factor is not a rollback. A contender that is missing from prev
+// is just starting, and one missing from curr has lost all of its traffic.
+func IsRollback(prev, curr map[string]uint32, contenderRelease string) bool {
+	if prev[contenderRelease] == 0 {
+		return false
+	}
+
+	currTotal := totalWeight(curr)
+	if currTotal == 0 {
+		return true
+	}
+
+	// curr[c]/currTotal < prev[c]/prevTotal, without any rounding.
+	return uint64(curr[contenderRelease])*totalWeight(prev) <
+		uint64(prev[contenderRelease])*currTotal
+}
+
+func totalWeight(weights map[string]uint32) uint64 {
+	var total uint64
+	for _, weight := range weights {
+		total += uint64(weight)
+	}
+	return total
+}
+
+// ShiftProgress returns how far along a release is in getting the traffic
+// weight it requested, as a percentage of it. Achieving more than requested
+// still counts as 100%, as does requesting no weight at all, since there's
+// nothing left to shift.
+func ShiftProgress(achieved, requested uint32) int {
+	if requested == 0 || achieved >= requested {
+		return 100
+	}
+
+	return int(uint64(achieved) * 100 / uint64(requested))
+}
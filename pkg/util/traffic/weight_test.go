@@ -0,0 +1,123 @@
+package traffic
+
+import (
+	"testing"
+)
+
+func TestEffectiveWeight(t *testing.T) {
+	tests := []struct {
+		name        string
+		releasePods int
+		totalPods   int
+		totalWeight uint32
+		expected    uint32
+	}{
+		{"no pods", 0, 0, 100, 0},
+		{"no weight", 5, 10, 0, 0},
+		{"exact split", 5, 10, 100, 50},
+		{"rounds up", 2, 3, 10, 7},
+		{"rounds down", 1, 3, 10, 3},
+		{"discrete pods", 2, 18, 100, 11},
+		{"all pods", 18, 18, 100, 100},
+	}
+
+	for _, tt := range tests {
+		got := EffectiveWeight(tt.releasePods, tt.totalPods, tt.totalWeight)
+		if got != tt.expected {
+			t.Errorf("%s: expected effective weight %d for %d/%d pods and total weight %d, got %d",
+				tt.name, tt.expected, tt.releasePods, tt.totalPods, tt.totalWeight, got)
+		}
+	}
+}
+
+func TestIsRollback(t *testing.T) {
+	const contender = "contender"
+
+	tests := []struct {
+		name     string
+		prev     map[string]uint32
+		curr     map[string]uint32
+		expected bool
+	}{
+		{
+			"contender weight increasing",
+			map[string]uint32{contender: 10, "incumbent": 90},
+			map[string]uint32{contender: 50, "incumbent": 50},
+			false,
+		},
+		{
+			"contender weight decreasing",
+			map[string]uint32{contender: 50, "incumbent": 50},
+			map[string]uint32{contender: 10, "incumbent": 90},
+			true,
+		},
+		{
+			"contender weight unchanged",
+			map[string]uint32{contender: 50, "incumbent": 50},
+			map[string]uint32{contender: 50, "incumbent": 50},
+			false,
+		},
+		{
+			"all weights scaled down",
+			map[string]uint32{contender: 50, "incumbent": 50},
+			map[string]uint32{contender: 5, "incumbent": 5},
+			false,
+		},
+		{
+			"incumbent weight increasing",
+			map[string]uint32{contender: 50, "incumbent": 50},
+			map[string]uint32{contender: 50, "incumbent": 150},
+			true,
+		},
+		{
+			"contender just starting",
+			map[string]uint32{"incumbent": 100},
+			map[string]uint32{contender: 10, "incumbent": 90},
+			false,
+		},
+		{
+			"contender removed",
+			map[string]uint32{contender: 10, "incumbent": 90},
+			map[string]uint32{"incumbent": 100},
+			true,
+		},
+		{
+			"all traffic removed",
+			map[string]uint32{contender: 10, "incumbent": 90},
+			map[string]uint32{},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		if actual := IsRollback(tt.prev, tt.curr, contender); actual != tt.expected {
+			t.Errorf("%s: expected IsRollback(%v, %v) to be %t, got %t",
+				tt.name, tt.prev, tt.curr, tt.expected, actual)
+		}
+	}
+}
+
+func TestShiftProgress(t *testing.T) {
+	tests := []struct {
+		name      string
+		achieved  uint32
+		requested uint32
+		expected  int
+	}{
+		{"nothing requested", 0, 0, 100},
+		{"nothing requested but some achieved", 10, 0, 100},
+		{"not started", 0, 50, 0},
+		{"part way", 20, 50, 40},
+		{"rounds down", 1, 3, 33},
+		{"done", 50, 50, 100},
+		{"overshot", 60, 50, 100},
+	}
+
+	for _, tt := range tests {
+		got := ShiftProgress(tt.achieved, tt.requested)
+		if got != tt.expected {
+			t.Errorf("%s: expected progress %d%% for %d/%d weight, got %d%%",
+				tt.name, tt.expected, tt.achieved, tt.requested, got)
+		}
+	}
+}
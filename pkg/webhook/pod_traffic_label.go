@@ -0,0 +1,140 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	admission "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	trafficutil "github.com/bookingcom/shipper/pkg/util/traffic"
+)
+
+// jsonPatchOperation is a single operation of an RFC 6902 JSON patch, the
+// only kind of patch mutating admission webhooks can respond with.
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// podTrafficLabelHandler stamps the traffic label on pods as they're
+// created, enabling them only when their release currently gets all of the
+// traffic of its application in the cluster they're created in. Since
+// admission requests don't tell which cluster they come from, every
+// application cluster registers this webhook with its own name in the
+// "cluster" query parameter, as `shipperctl clusters join` does when given
+// --pod-traffic-webhook-url.
+func (c *Webhook) podTrafficLabelHandler(w http.ResponseWriter, r *http.Request) {
+	clusterName := r.URL.Query().Get("cluster")
+	adaptHandler(func(review *admission.AdmissionReview) *admission.AdmissionResponse {
+		return c.defaultPodTrafficLabel(review, clusterName)
+	})(w, r)
+}
+
+func (c *Webhook) defaultPodTrafficLabel(review *admission.AdmissionReview, clusterName string) *admission.AdmissionResponse {
+	request := review.Request
+	if request.Kind.Kind != "Pod" || request.Operation != admission.Create || clusterName == "" {
+		return &admission.AdmissionResponse{
+			Allowed: true,
+		}
+	}
+
+	var pod corev1.Pod
+	if err := json.Unmarshal(request.Object.Raw, &pod); err != nil {
+		return &admission.AdmissionResponse{
+			Result: &metav1.Status{
+				Message: err.Error(),
+			},
+		}
+	}
+
+	patch, err := c.buildPodTrafficLabelPatch(request.Namespace, &pod, clusterName)
+	if err != nil {
+		// Failing to default the label is no reason to keep pods from
+		// being created, the traffic controller eventually labels them
+		// anyway.
+		return &admission.AdmissionResponse{
+			Allowed: true,
+			Result: &metav1.Status{
+				Message: err.Error(),
+			},
+		}
+	}
+
+	if patch == nil {
+		return &admission.AdmissionResponse{
+			Allowed: true,
+		}
+	}
+
+	patchType := admission.PatchTypeJSONPatch
+	return &admission.AdmissionResponse{
+		Allowed:   true,
+		Patch:     patch,
+		PatchType: &patchType,
+	}
+}
+
+// buildPodTrafficLabelPatch returns the JSON patch that sets the traffic
+// label of pod, or nil if it doesn't need one, either because it's already
+// labeled or because it doesn't belong to any release shipper knows about.
+// Pods are left disabled unless their release gets all of the weight of
+// its application in clusterName, so they don't get more traffic than
+// the traffic controller means to give them.
+func (c *Webhook) buildPodTrafficLabelPatch(namespace string, pod *corev1.Pod, clusterName string) ([]byte, error) {
+	releaseName, ok := pod.Labels[shipper.ReleaseLabel]
+	if !ok {
+		return nil, nil
+	}
+
+	// Pods being created through a controller usually don't have their
+	// namespace set yet.
+	if pod.Namespace != "" {
+		namespace = pod.Namespace
+	}
+
+	selector := labels.Set{shipper.ReleaseLabel: releaseName}.AsSelector()
+	tts, err := c.trafficTargetsLister.TrafficTargets(namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tts) != 1 {
+		return nil, nil
+	}
+
+	tt := tts[0]
+	appName, ok := tt.Labels[shipper.AppLabel]
+	if !ok {
+		return nil, nil
+	}
+
+	appSelector := labels.Set{shipper.AppLabel: appName}.AsSelector()
+	appTTs, err := c.trafficTargetsLister.TrafficTargets(namespace).List(appSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	desired := trafficutil.DesiredPodTrafficStatus(tt, appTTs, clusterName)
+	if !trafficutil.DefaultPodTrafficLabel(pod, desired) {
+		return nil, nil
+	}
+
+	patch, err := json.Marshal([]jsonPatchOperation{
+		{
+			Op:    "add",
+			Path:  "/metadata/labels",
+			Value: pod.Labels,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not encode patch: %v", err)
+	}
+
+	return patch, nil
+}
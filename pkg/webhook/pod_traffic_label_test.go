@@ -0,0 +1,238 @@
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	admission "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	shipper "github.com/bookingcom/shipper/pkg/apis/shipper/v1alpha1"
+	shipperfake "github.com/bookingcom/shipper/pkg/client/clientset/versioned/fake"
+	shipperinformers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
+)
+
+const (
+	testNamespace = "test-namespace"
+	testApp       = "test-app"
+	testOtherApp  = "test-other-app"
+	testCluster   = "test-cluster"
+)
+
+func TestDefaultPodTrafficLabel(t *testing.T) {
+	webhook, stopCh := newPodTrafficLabelWebhook(
+		buildTrafficTarget(testApp, "test-release", 100),
+	)
+	defer close(stopCh)
+
+	pod := buildPod(testApp, "test-release")
+
+	tests := []struct {
+		name        string
+		kind        string
+		operation   admission.Operation
+		clusterName string
+		expectPatch bool
+	}{
+		{
+			name:        "pod being created",
+			kind:        "Pod",
+			operation:   admission.Create,
+			clusterName: testCluster,
+			expectPatch: true,
+		},
+		{
+			name:        "pod being updated",
+			kind:        "Pod",
+			operation:   admission.Update,
+			clusterName: testCluster,
+		},
+		{
+			name:        "something other than a pod",
+			kind:        "Service",
+			operation:   admission.Create,
+			clusterName: testCluster,
+		},
+		{
+			name:      "no cluster name",
+			kind:      "Pod",
+			operation: admission.Create,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			review := buildAdmissionReview(t, tt.kind, tt.operation, pod)
+			response := webhook.defaultPodTrafficLabel(review, tt.clusterName)
+
+			if !response.Allowed {
+				t.Fatalf("expected pod to be allowed, got response %+v", response)
+			}
+
+			if hasPatch := response.Patch != nil; hasPatch != tt.expectPatch {
+				t.Fatalf("expected a patch: %t, got %q", tt.expectPatch, response.Patch)
+			}
+
+			if tt.expectPatch && (response.PatchType == nil || *response.PatchType != admission.PatchTypeJSONPatch) {
+				t.Errorf("expected a JSON patch, got patch type %v", response.PatchType)
+			}
+		})
+	}
+}
+
+func TestBuildPodTrafficLabelPatch(t *testing.T) {
+	webhook, stopCh := newPodTrafficLabelWebhook(
+		buildTrafficTarget(testOtherApp, "full-release", 100),
+		buildTrafficTarget(testApp, "partial-release", 50),
+		buildTrafficTarget(testApp, "other-partial-release", 50),
+	)
+	defer close(stopCh)
+
+	labeled := buildPod(testOtherApp, "full-release")
+	labeled.Labels[shipper.PodTrafficStatusLabel] = shipper.Disabled
+
+	unreleased := buildPod(testApp, "")
+	delete(unreleased.Labels, shipper.ReleaseLabel)
+
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		expected []jsonPatchOperation
+	}{
+		{
+			name: "release with all of the traffic",
+			pod:  buildPod(testOtherApp, "full-release"),
+			expected: []jsonPatchOperation{
+				{
+					Op:   "add",
+					Path: "/metadata/labels",
+					Value: map[string]interface{}{
+						shipper.AppLabel:              testOtherApp,
+						shipper.ReleaseLabel:          "full-release",
+						shipper.PodTrafficStatusLabel: shipper.Enabled,
+					},
+				},
+			},
+		},
+		{
+			name: "release sharing traffic",
+			pod:  buildPod(testApp, "partial-release"),
+			expected: []jsonPatchOperation{
+				{
+					Op:   "add",
+					Path: "/metadata/labels",
+					Value: map[string]interface{}{
+						shipper.AppLabel:              testApp,
+						shipper.ReleaseLabel:          "partial-release",
+						shipper.PodTrafficStatusLabel: shipper.Disabled,
+					},
+				},
+			},
+		},
+		{
+			name: "pod already labeled",
+			pod:  labeled,
+		},
+		{
+			name: "pod without a release label",
+			pod:  unreleased,
+		},
+		{
+			name: "release without a traffic target",
+			pod:  buildPod(testApp, "unknown-release"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := webhook.buildPodTrafficLabelPatch(testNamespace, tt.pod, testCluster)
+			if err != nil {
+				t.Fatalf("unexpected error building patch: %s", err)
+			}
+
+			if tt.expected == nil {
+				if patch != nil {
+					t.Errorf("expected no patch, got %s", patch)
+				}
+				return
+			}
+
+			var got []jsonPatchOperation
+			if err := json.Unmarshal(patch, &got); err != nil {
+				t.Fatalf("expected patch to be a JSON patch, got %s: %s", patch, err)
+			}
+
+			expected, _ := json.Marshal(tt.expected)
+			actual, _ := json.Marshal(got)
+			if string(expected) != string(actual) {
+				t.Errorf("expected patch %s, got %s", expected, actual)
+			}
+		})
+	}
+}
+
+// newPodTrafficLabelWebhook returns a webhook whose traffic target lister
+// knows about objects, along with the channel to close to stop its
+// informers.
+func newPodTrafficLabelWebhook(objects ...runtime.Object) (*Webhook, chan struct{}) {
+	clientset := shipperfake.NewSimpleClientset(objects...)
+	informerFactory := shipperinformers.NewSharedInformerFactory(clientset, time.Duration(0))
+	webhook := &Webhook{
+		trafficTargetsLister: informerFactory.Shipper().V1alpha1().TrafficTargets().Lister(),
+	}
+
+	stopCh := make(chan struct{})
+	informerFactory.Start(stopCh)
+	informerFactory.WaitForCacheSync(stopCh)
+
+	return webhook, stopCh
+}
+
+func buildTrafficTarget(appName, releaseName string, weight uint32) *shipper.TrafficTarget {
+	return &shipper.TrafficTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      releaseName,
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				shipper.AppLabel:     appName,
+				shipper.ReleaseLabel: releaseName,
+			},
+		},
+		Spec: shipper.TrafficTargetSpec{
+			Clusters: []shipper.ClusterTrafficTarget{
+				{Name: testCluster, Weight: weight},
+			},
+		},
+	}
+}
+
+func buildPod(appName, releaseName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: releaseName + "-deadbeef",
+			Labels: map[string]string{
+				shipper.AppLabel:     appName,
+				shipper.ReleaseLabel: releaseName,
+			},
+		},
+	}
+}
+
+func buildAdmissionReview(t *testing.T, kind string, operation admission.Operation, pod *corev1.Pod) *admission.AdmissionReview {
+	raw, err := json.Marshal(pod)
+	if err != nil {
+		t.Fatalf("could not encode pod: %s", err)
+	}
+
+	return &admission.AdmissionReview{
+		Request: &admission.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: kind},
+			Namespace: testNamespace,
+			Operation: operation,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+}
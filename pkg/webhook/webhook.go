@@ -15,6 +15,7 @@ import (
 	admission "k8s.io/api/admission/v1beta1"
 	kubeclient "k8s.io/api/admission/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/client-go/tools/cache"
@@ -24,8 +25,10 @@ import (
 	clientset "github.com/bookingcom/shipper/pkg/client/clientset/versioned"
 	informers "github.com/bookingcom/shipper/pkg/client/informers/externalversions"
 	listers "github.com/bookingcom/shipper/pkg/client/listers/shipper/v1alpha1"
+	shippererrors "github.com/bookingcom/shipper/pkg/errors"
 	"github.com/bookingcom/shipper/pkg/metrics/prometheus"
 	"github.com/bookingcom/shipper/pkg/util/rolloutblock"
+	trafficutil "github.com/bookingcom/shipper/pkg/util/traffic"
 )
 
 const (
@@ -37,6 +40,9 @@ type Webhook struct {
 	rolloutBlocksLister listers.RolloutBlockLister
 	rolloutBlocksSynced cache.InformerSynced
 
+	trafficTargetsLister listers.TrafficTargetLister
+	trafficTargetsSynced cache.InformerSynced
+
 	bindAddr string
 	bindPort string
 
@@ -61,12 +67,16 @@ func NewWebhook(
 	heartbeatPeriod time.Duration,
 ) *Webhook {
 	rolloutBlocksInformer := shipperInformerFactory.Shipper().V1alpha1().RolloutBlocks()
+	trafficTargetsInformer := shipperInformerFactory.Shipper().V1alpha1().TrafficTargets()
 
 	return &Webhook{
 		shipperClientset:    shipperClientset,
 		rolloutBlocksLister: rolloutBlocksInformer.Lister(),
 		rolloutBlocksSynced: rolloutBlocksInformer.Informer().HasSynced,
 
+		trafficTargetsLister: trafficTargetsInformer.Lister(),
+		trafficTargetsSynced: trafficTargetsInformer.Informer().HasSynced,
+
 		bindAddr: bindAddr,
 		bindPort: bindPort,
 
@@ -86,7 +96,7 @@ func (c *Webhook) Run(stopCh <-chan struct{}) {
 		Handler: mux,
 	}
 
-	if !cache.WaitForCacheSync(stopCh, c.rolloutBlocksSynced) {
+	if !cache.WaitForCacheSync(stopCh, c.rolloutBlocksSynced, c.trafficTargetsSynced) {
 		klog.Fatalf("failed to wait for caches to sync")
 		return
 	}
@@ -154,6 +164,7 @@ func (c *Webhook) startHeartbeatRoutine(ctx context.Context, host string) {
 func (c *Webhook) initializeHandlers() *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/validate", adaptHandler(c.validateHandlerFunc))
+	mux.HandleFunc("/mutate/pods", c.podTrafficLabelHandler)
 	return mux
 }
 
@@ -245,6 +256,9 @@ func (c *Webhook) validateHandlerFunc(review *admission.AdmissionReview) *admiss
 	case "TrafficTarget":
 		var trafficTarget shipper.TrafficTarget
 		err = json.Unmarshal(request.Object.Raw, &trafficTarget)
+		if err == nil {
+			err = c.validateTrafficTarget(trafficTarget)
+		}
 	case "RolloutBlock":
 		var rolloutBlock shipper.RolloutBlock
 		err = json.Unmarshal(request.Object.Raw, &rolloutBlock)
@@ -296,6 +310,31 @@ func (c *Webhook) validateRelease(request *admission.AdmissionRequest, release s
 	return err
 }
 
+// validateTrafficTarget makes sure trafficTarget doesn't conflict with any
+// other traffic target of its release, as the traffic controller would
+// refuse to shift traffic for either of them.
+func (c *Webhook) validateTrafficTarget(trafficTarget shipper.TrafficTarget) error {
+	release, ok := trafficTarget.Labels[shipper.ReleaseLabel]
+	if !ok {
+		return nil
+	}
+
+	selector := labels.Set{shipper.ReleaseLabel: release}.AsSelector()
+	existingTTs, err := c.trafficTargetsLister.TrafficTargets(trafficTarget.Namespace).List(selector)
+	if err != nil {
+		return err
+	}
+
+	for _, existingTT := range existingTTs {
+		if trafficutil.TrafficTargetsConflict(existingTT, &trafficTarget) {
+			return shippererrors.NewMultipleTrafficTargetsForReleaseError(
+				trafficTarget.Namespace, release, []string{trafficTarget.Name, existingTT.Name})
+		}
+	}
+
+	return nil
+}
+
 func (c *Webhook) validateApplication(request *admission.AdmissionRequest, application shipper.Application) error {
 	var err error
 	overrides, existingBlocks, err := rolloutblock.GetAllBlocks(c.rolloutBlocksLister, &application)
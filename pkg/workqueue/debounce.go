@@ -0,0 +1,50 @@
+package workqueue
+
+import (
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// debouncingQueue is a rate limiting queue where every key waits for a
+// window before it's handed out. Since a key waiting to be handed out is
+// only ever queued once, every time it's added again during its window is
+// coalesced into a single item.
+type debouncingQueue struct {
+	workqueue.RateLimitingInterface
+
+	rateLimiter workqueue.RateLimiter
+	window      time.Duration
+}
+
+// NewNamedDebouncingRateLimitingQueue returns a rate limiting queue that
+// holds on to keys for window before handing them out, whether they're
+// added directly or rate limited, so bursts of changes to the same object
+// are only processed once. Rate limited keys wait for whichever is longer
+// of their backoff and window. A zero window behaves just like
+// workqueue.NewNamedRateLimitingQueue.
+func NewNamedDebouncingRateLimitingQueue(rateLimiter workqueue.RateLimiter, name string, window time.Duration) workqueue.RateLimitingInterface {
+	queue := workqueue.NewNamedRateLimitingQueue(rateLimiter, name)
+	if window <= 0 {
+		return queue
+	}
+
+	return &debouncingQueue{
+		RateLimitingInterface: queue,
+		rateLimiter:           rateLimiter,
+		window:                window,
+	}
+}
+
+func (q *debouncingQueue) Add(item interface{}) {
+	q.RateLimitingInterface.AddAfter(item, q.window)
+}
+
+func (q *debouncingQueue) AddRateLimited(item interface{}) {
+	delay := q.rateLimiter.When(item)
+	if delay < q.window {
+		delay = q.window
+	}
+
+	q.RateLimitingInterface.AddAfter(item, delay)
+}
@@ -39,3 +39,54 @@ func (r *JitteredRateLimiter) When(item interface{}) time.Duration {
 
 	return when + jitter/2
 }
+
+// PriorityRateLimiter hands every item to one of a set of rate limiters,
+// depending on its priority, so items with a higher priority can be retried
+// sooner than the rest.
+type PriorityRateLimiter struct {
+	limiters        map[string]workqueue.RateLimiter
+	defaultPriority string
+	priorityOf      func(item interface{}) string
+}
+
+var _ workqueue.RateLimiter = (*PriorityRateLimiter)(nil)
+
+// NewPriorityRateLimiter returns a PriorityRateLimiter that rate limits items
+// with the limiter for the priority priorityOf returns for them. Items with a
+// priority that has no limiter get the one for defaultPriority, which is
+// expected to be in limiters.
+func NewPriorityRateLimiter(
+	limiters map[string]workqueue.RateLimiter,
+	defaultPriority string,
+	priorityOf func(item interface{}) string,
+) *PriorityRateLimiter {
+	return &PriorityRateLimiter{
+		limiters:        limiters,
+		defaultPriority: defaultPriority,
+		priorityOf:      priorityOf,
+	}
+}
+
+func (r *PriorityRateLimiter) When(item interface{}) time.Duration {
+	return r.limiterFor(item).When(item)
+}
+
+// Forget is passed on to every limiter, as the priority of an item might
+// have changed since it was last rate limited.
+func (r *PriorityRateLimiter) Forget(item interface{}) {
+	for _, limiter := range r.limiters {
+		limiter.Forget(item)
+	}
+}
+
+func (r *PriorityRateLimiter) NumRequeues(item interface{}) int {
+	return r.limiterFor(item).NumRequeues(item)
+}
+
+func (r *PriorityRateLimiter) limiterFor(item interface{}) workqueue.RateLimiter {
+	if limiter, ok := r.limiters[r.priorityOf(item)]; ok {
+		return limiter
+	}
+
+	return r.limiters[r.defaultPriority]
+}